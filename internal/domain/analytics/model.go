@@ -1,26 +1,89 @@
 package analytics
 
-// Stub for analytics domain model
-
+// UserEngagement is a learner's aggregated activity in one course, computed
+// from analytics events by the aggregation job.
 type UserEngagement struct {
 	UserID           string
 	CourseID         string
-	ModulesCompleted int
-	TotalModules     int
-	LastActive       int64 // Unix timestamp
+	ModulesCompleted int   // distinct modules with a module_completed event
+	TotalModules     int   // modules the course has now
+	LastActive       int64 // Unix timestamp of the learner's newest event in the course
 }
 
+// CourseAnalytics is a course's aggregated enrollment and progress figures,
+// computed by the aggregation job. Learners who completed the course count
+// as fully progressed.
 type CourseAnalytics struct {
 	CourseID        string
-	Enrollments     int
-	Completions     int
-	AverageProgress float64
+	Enrollments     int     // enrollments that are active or completed
+	Completions     int     // completed enrollments
+	AverageProgress float64 // mean share of modules completed by enrolled learners, 0 to 1
+	UpdatedAt       int64   // Unix timestamp of the aggregation
+}
+
+// Event types. Course, module and quiz IDs go in the event's Metadata under
+// the Meta keys below.
+const (
+	EventLogin             = "login"
+	EventCourseViewed      = "course_viewed"
+	EventModuleCompleted   = "module_completed"
+	EventQuizSubmitted     = "quiz_submitted"
+	EventCertificateIssued = "certificate_issued"
+)
+
+// Metadata keys with a meaning to the aggregation job.
+const (
+	MetaCourseID = "course_id"
+	MetaModuleID = "module_id"
+	MetaQuizID   = "quiz_id"
+	MetaScore    = "score"
+	MetaPassed   = "passed" // true on a quiz_submitted event for a passing score
+
+	// MetaStatementID is set on events recorded from an xAPI statement, which
+	// are not emitted back as statements.
+	MetaStatementID = "statement_id"
+)
+
+// ValidEventType reports whether t is a known event type.
+func ValidEventType(t string) bool {
+	switch t {
+	case EventLogin, EventCourseViewed, EventModuleCompleted, EventQuizSubmitted, EventCertificateIssued:
+		return true
+	}
+	return false
+}
+
+// ClientEventType reports whether the frontend may report events of type t.
+// Logins and certificates are recorded by the server where they happen.
+func ClientEventType(t string) bool {
+	switch t {
+	case EventCourseViewed, EventModuleCompleted, EventQuizSubmitted:
+		return true
+	}
+	return false
 }
 
+// AnalyticsEvent is something a user did, kept in an append-only store.
 type AnalyticsEvent struct {
-	ID        string
+	ID        string // UUID; chosen by the client so that retried batches are stored once
 	UserID    string
-	EventType string
-	Timestamp int64
+	EventType string // see the Event constants
+	Timestamp int64  // Unix timestamp of when it happened
 	Metadata  map[string]interface{}
 }
+
+// CourseID returns the course the event belongs to, or "".
+func (e *AnalyticsEvent) CourseID() string {
+	id, _ := e.Metadata[MetaCourseID].(string)
+	return id
+}
+
+// EventFilter selects stored events, newest first.
+type EventFilter struct {
+	UserID    string
+	CourseID  string
+	EventType string
+	From      int64 // Unix timestamp, inclusive; 0 for no bound
+	To        int64 // Unix timestamp, exclusive; 0 for no bound
+	Limit     int
+}
@@ -0,0 +1,83 @@
+package analytics
+
+// ReportFilter narrows a report to one course and to learners of a
+// department or role who enrolled in a date range. Empty fields match
+// everything.
+type ReportFilter struct {
+	CourseID   string
+	Department string
+	Role       string
+	From       int64 // Unix timestamp, inclusive
+	To         int64 // Unix timestamp, exclusive
+}
+
+// LearnerProgress is one enrollment's way through a course, merged from the
+// enrollment, its progress record and the learner's analytics events.
+// Reports are computed from these.
+type LearnerProgress struct {
+	UserID           string
+	CourseID         string
+	Status           string   // enrollment status: active, completed or dropped
+	EnrolledAt       int64    // Unix timestamp
+	ActivatedAt      int64    // Unix timestamp the enrollment last became active, 0 if unknown
+	FirstActiveAt    int64    // Unix timestamp of the first event in the course, 0 if none
+	LastActiveAt     int64    // Unix timestamp of the newest event in the course, 0 if none
+	CompletedAt      int64    // Unix timestamp, 0 unless completed
+	CompletedModules []string // module IDs
+	PassedQuiz       bool
+}
+
+// Funnel stage names. Module stages are named module_1, module_2 and so on
+// in course order.
+const (
+	StageEnrolled   = "enrolled"
+	StageStarted    = "started"
+	StagePassedQuiz = "passed_quiz"
+	StageCompleted  = "completed"
+)
+
+// FunnelStage is how many learners of a course got at least as far as a
+// stage. A learner who reached a later stage counts for every earlier one.
+type FunnelStage struct {
+	Name       string
+	ModuleID   string // set for module stages
+	Title      string // module title, for module stages
+	Learners   int
+	Share      float64 // of enrolled learners, 0 to 1
+	Conversion float64 // of the learners at the previous stage, 0 to 1
+}
+
+// FunnelReport shows where a course's learners drop off.
+type FunnelReport struct {
+	CourseID    string
+	Stages      []FunnelStage
+	GeneratedAt int64 // Unix timestamp
+}
+
+// Cohort is the learners who enrolled in the same week. Retention[n] is the
+// share of them still active n weeks after enrolling: with activity at least
+// n weeks after their enrollment, or the course completed. Weeks that have
+// not passed yet for the whole cohort are left out.
+type Cohort struct {
+	WeekStart int64 // Unix timestamp of Monday 00:00 UTC
+	Learners  int
+	Retention []float64
+}
+
+// CohortReport is weekly cohort retention for a course, or all courses.
+type CohortReport struct {
+	CourseID    string
+	Weeks       int
+	Cohorts     []Cohort
+	GeneratedAt int64 // Unix timestamp
+}
+
+// CompletionTimeReport is how long learners take to complete a course, from
+// the enrollment becoming active to completion.
+type CompletionTimeReport struct {
+	CourseID      string
+	Completions   int
+	MedianSeconds int64
+	MedianDays    float64
+	GeneratedAt   int64 // Unix timestamp
+}
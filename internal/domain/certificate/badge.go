@@ -0,0 +1,87 @@
+package certificate
+
+// Open Badges 3.0 documents. Field names follow the 1EdTech Open Badges 3.0
+// and W3C Verifiable Credentials Data Model 2.0 specifications, so these types
+// carry JSON tags unlike the rest of the domain.
+
+// Contexts of an OpenBadgeCredential.
+var BadgeContexts = []string{
+	"https://www.w3.org/ns/credentials/v2",
+	"https://purl.imsglobal.org/spec/ob/v3p0/context-3.0.3.json",
+}
+
+// Profile describes the organization issuing credentials.
+type Profile struct {
+	Context []string `json:"@context,omitempty"`
+	ID      string   `json:"id"`
+	Type    []string `json:"type"`
+	Name    string   `json:"name"`
+	URL     string   `json:"url,omitempty"`
+}
+
+// Criteria explains what a learner did to earn an achievement.
+type Criteria struct {
+	Narrative string `json:"narrative"`
+}
+
+// Image references a badge image.
+type Image struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// Achievement is the definition of what a credential recognizes, generated from a course.
+type Achievement struct {
+	Context         []string `json:"@context,omitempty"`
+	ID              string   `json:"id"`
+	Type            []string `json:"type"`
+	AchievementType string   `json:"achievementType"`
+	Name            string   `json:"name"`
+	Description     string   `json:"description"`
+	Criteria        Criteria `json:"criteria"`
+	Image           *Image   `json:"image,omitempty"`
+	Creator         *Profile `json:"creator,omitempty"`
+}
+
+// IdentityObject identifies the recipient by a salted hash of their email.
+type IdentityObject struct {
+	Type         string `json:"type"`
+	IdentityHash string `json:"identityHash"`
+	IdentityType string `json:"identityType"`
+	Hashed       bool   `json:"hashed"`
+	Salt         string `json:"salt"`
+}
+
+// AchievementSubject is the recipient of a credential and what they achieved.
+type AchievementSubject struct {
+	Type        []string         `json:"type"`
+	Identifier  []IdentityObject `json:"identifier"`
+	Achievement Achievement      `json:"achievement"`
+}
+
+// CredentialStatus points verifiers to where the credential's status is published.
+type CredentialStatus struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// OpenBadgeCredential is a verifiable credential recognizing a completed course.
+type OpenBadgeCredential struct {
+	Context           []string           `json:"@context"`
+	ID                string             `json:"id"`
+	Type              []string           `json:"type"`
+	Issuer            Profile            `json:"issuer"`
+	ValidFrom         string             `json:"validFrom"`
+	ValidUntil        string             `json:"validUntil,omitempty"`
+	Name              string             `json:"name"`
+	CredentialSubject AchievementSubject `json:"credentialSubject"`
+	CredentialStatus  *CredentialStatus  `json:"credentialStatus,omitempty"`
+}
+
+// BadgeVerification is the result of verifying a presented badge credential.
+type BadgeVerification struct {
+	Valid        bool     `json:"valid"`
+	Status       string   `json:"status"` // "active", "expired", "revoked", "superseded" or "invalid"
+	CredentialID string   `json:"credentialId,omitempty"`
+	Errors       []string `json:"errors,omitempty"`
+}
@@ -1,10 +1,136 @@
 package certificate
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Status is the lifecycle state of a certificate. Expiry is not stored: an
+// active certificate is expired once its ExpiresAt has passed.
+type Status string
+
+const (
+	StatusActive     Status = "active"
+	StatusRevoked    Status = "revoked"    // withdrawn, e.g. after a misconduct finding
+	StatusSuperseded Status = "superseded" // replaced by a reissued certificate
+	StatusExpired    Status = "expired"
+)
+
 // Certificate represents a course completion certificate.
 type Certificate struct {
-	ID          string // UUID
-	UserID      string // UUID of the user who earned the certificate
-	CourseID    string // UUID of the course
-	IssuedAt    int64  // Unix timestamp of issuance
-	DownloadURL string // URL to download the certificate PDF or badge
+	ID           string // UUID
+	UserID       string // UUID of the user who earned the certificate
+	CourseID     string // UUID of the course
+	TemplateID   string // UUID of the template used to render the PDF
+	CredentialID string // Random, non-guessable identifier printed on the certificate
+	Score        int    // Final score in percent, 0 if not graded
+	IssuedAt     int64  // Unix timestamp of issuance
+	FileKey      string // Storage key of the generated PDF
+	DownloadURL  string // URL to download the certificate PDF or badge
+	Signature    string // Base64url Ed25519 signature over SigningPayload
+	ExpiresAt    int64  // Unix timestamp, 0 if the certificate does not expire
+
+	Status           Status
+	RevokedAt        int64  // Unix timestamp, 0 unless revoked
+	RevocationReason string // Why the certificate was revoked
+	ReplacesID       string // Certificate this one was reissued from
+	ReplacedByID     string // Certificate this one was superseded by
+}
+
+// CurrentStatus returns the certificate's status at now, accounting for expiry.
+func (c *Certificate) CurrentStatus(now time.Time) Status {
+	if c.Status == StatusActive && c.ExpiresAt != 0 && now.Unix() >= c.ExpiresAt {
+		return StatusExpired
+	}
+	return c.Status
+}
+
+// SigningPayload returns the canonical bytes covered by the certificate's
+// signature. Changing any of these fields invalidates the signature.
+// Certificates without an expiry keep the original v1 payload.
+func (c *Certificate) SigningPayload() []byte {
+	if c.ExpiresAt != 0 {
+		return []byte(fmt.Sprintf("training-portal-certificate:v2|%s|%s|%s|%d|%d|%d", c.CredentialID, c.UserID, c.CourseID, c.IssuedAt, c.Score, c.ExpiresAt))
+	}
+	return []byte(fmt.Sprintf("training-portal-certificate:v1|%s|%s|%s|%d|%d", c.CredentialID, c.UserID, c.CourseID, c.IssuedAt, c.Score))
+}
+
+// StatusChange records a single state transition of a certificate.
+type StatusChange struct {
+	ID            string // UUID
+	CertificateID string
+	FromStatus    Status // empty when the certificate is issued
+	ToStatus      Status
+	ChangedBy     string // user ID of the actor, empty for automatic issuance
+	Reason        string // optional
+	ChangedAt     int64  // Unix timestamp
+}
+
+// Verification is the public result of checking a credential ID.
+type Verification struct {
+	CredentialID string
+	Valid        bool   // the signature is intact and the certificate is active
+	Status       Status // current status, including expiry
+	Reason       string // why the certificate is not valid, empty when valid
+	HolderName   string
+	CourseTitle  string
+	IssuedAt     int64
+	ExpiresAt    int64  // 0 if the certificate does not expire
+	ReplacedBy   string // credential ID of the reissued certificate, if superseded
+}
+
+// Orientation is the page orientation of a certificate template.
+type Orientation string
+
+const (
+	Landscape Orientation = "landscape"
+	Portrait  Orientation = "portrait"
+)
+
+// Placeholders that may appear in a template's title and body.
+const (
+	PlaceholderLearnerName    = "{{learner_name}}"
+	PlaceholderCourseTitle    = "{{course_title}}"
+	PlaceholderCompletionDate = "{{completion_date}}"
+	PlaceholderScore          = "{{score}}"
+	PlaceholderCredentialID   = "{{credential_id}}"
+	PlaceholderExpiryDate     = "{{expiry_date}}" // empty if the certificate does not expire
+)
+
+// Template is an admin-managed certificate design. A template with a CourseID
+// applies to that course only; the default template applies to all others.
+type Template struct {
+	ID            string // UUID
+	Name          string
+	CourseID      string // Optional course the template is reserved for
+	IsDefault     bool
+	Orientation   Orientation
+	Title         string // Heading, e.g. "Certificate of Completion"
+	Body          string // Text with placeholders; one centered line per newline
+	SignatoryName string // Printed under the signature image
+	LogoKey       string // Storage key of the logo image, empty if none
+	SignatureKey  string // Storage key of the signature image, empty if none
+	CreatedAt     int64  // Unix timestamp
+}
+
+// Fill replaces the placeholders in s with values keyed by placeholder.
+func Fill(s string, values map[string]string) string {
+	pairs := make([]string, 0, len(values)*2)
+	for placeholder, value := range values {
+		pairs = append(pairs, placeholder, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// Document is a filled-in certificate ready to be rendered.
+type Document struct {
+	Orientation     Orientation
+	Title           string
+	Lines           []string
+	Logo            []byte // Encoded PNG or JPEG, optional
+	Signature       []byte // Encoded PNG or JPEG, optional
+	SignatoryName   string
+	Footer          string
+	VerificationURL string // Encoded as a QR code when set
 }
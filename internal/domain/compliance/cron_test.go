@@ -0,0 +1,55 @@
+package compliance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no timezone data")
+	}
+	// Friday 1 March 2024, 10:30 UTC.
+	from := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"*/15 * * * *", from, time.Date(2024, 3, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 8 * * 1", from, time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 1-5", from, time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", from, time.Date(2024, 3, 2, 10, 30, 0, 0, time.UTC)},
+		{"0 9 1,15 * *", from, time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", from, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", from, time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", from, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted.
+		{"0 0 13 * 5", from, time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)},
+		// 02:30 does not exist on 31 March 2024 in Berlin, so that day is skipped.
+		{"30 2 * * *", time.Date(2024, 3, 30, 12, 0, 0, 0, berlin), time.Date(2024, 4, 1, 2, 30, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := c.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("ParseCron(%q).Next() = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) expected error", expr)
+		}
+	}
+	c, _ := ParseCron("0 0 30 2 *")
+	if !c.Next(time.Now()).IsZero() {
+		t.Error("expected 30 February never to match")
+	}
+}
@@ -0,0 +1,145 @@
+package compliance
+
+import "encoding/json"
+
+// Filter narrows a compliance report. Empty fields match everything.
+type Filter struct {
+	Department string
+	CourseID   string
+	UserID     string // transcripts only
+	ManagerID  string // team progress only: the manager whose direct reports are included
+	Within     int    // expiring certifications: days ahead to include
+}
+
+// CourseCompletion is a course's completion of mandatory training, which is
+// training assigned with a due date. Active and completed enrollments with a
+// due date count; dropped ones do not.
+type CourseCompletion struct {
+	CourseID       string
+	CourseTitle    string
+	Assigned       int
+	Completed      int
+	Overdue        int
+	CompletionRate float64 // Completed / Assigned, 0 to 1
+}
+
+// DepartmentCompletion is a department's completion of mandatory training,
+// counted like CourseCompletion. Users without a department are grouped
+// under an empty name.
+type DepartmentCompletion struct {
+	Department     string
+	Assigned       int
+	Completed      int
+	Overdue        int
+	CompletionRate float64 // Completed / Assigned, 0 to 1
+}
+
+// ExpiringCertification is an active certificate that has expired or expires
+// within the report's window.
+type ExpiringCertification struct {
+	CertificateID string
+	CredentialID  string
+	UserID        string
+	UserName      string
+	UserEmail     string
+	Department    string
+	CourseID      string
+	CourseTitle   string
+	IssuedAt      int64 // Unix timestamp
+	ExpiresAt     int64 // Unix timestamp
+	Expired       bool
+}
+
+// TeamProgress is a direct report's progress in one of their active or
+// completed courses.
+type TeamProgress struct {
+	UserID           string
+	UserName         string
+	UserEmail        string
+	Department       string
+	CourseID         string
+	CourseTitle      string
+	Status           string // enrollment status
+	ModulesCompleted int
+	TotalModules     int
+	Progress         float64 // ModulesCompleted / TotalModules, 0 to 1; 1 once completed
+	DueAt            int64   // Unix timestamp, 0 if not mandatory
+	CompletedAt      int64   // Unix timestamp of the latest completion, 0 if none
+	Overdue          bool
+}
+
+// QuizResult is a learner's score on a quiz.
+type QuizResult struct {
+	QuizID      string
+	Score       float64
+	SubmittedAt int64 // Unix timestamp, 0 if unknown
+}
+
+// TranscriptEntry is one course in a user's transcript.
+type TranscriptEntry struct {
+	CourseID      string
+	CourseTitle   string
+	Status        string // enrollment status
+	EnrolledAt    int64  // Unix timestamp
+	DueAt         int64  // Unix timestamp, 0 if not mandatory
+	CompletedAt   int64  // Unix timestamp of the latest completion, 0 if none
+	Score         int    // score on the current certificate, 0 if none or ungraded
+	CredentialID  string // current certificate, if any
+	CertifiedAt   int64  // Unix timestamp the certificate was issued
+	CertExpiresAt int64  // Unix timestamp, 0 if it does not expire
+	Quizzes       []QuizResult
+}
+
+// Transcript is a user's full training record.
+type Transcript struct {
+	UserID     string
+	UserName   string
+	UserEmail  string
+	Department string
+	Entries    []TranscriptEntry
+}
+
+// Table is a report laid out for export as CSV or PDF.
+type Table struct {
+	Title    string
+	Subtitle string // e.g. the filters and when the report was generated
+	Columns  []string
+	Rows     [][]string
+	Footer   string // printed on every PDF page, e.g. a snapshot's hash
+}
+
+// ReportKind names a compliance report.
+type ReportKind string
+
+const (
+	ReportCourses     ReportKind = "courses"
+	ReportDepartments ReportKind = "departments"
+	ReportOverdue     ReportKind = "overdue"
+	ReportExpiring    ReportKind = "expiring"
+	ReportTranscript  ReportKind = "transcript"
+	ReportTeam        ReportKind = "team"
+)
+
+// Valid reports whether k is a known report.
+func (k ReportKind) Valid() bool {
+	switch k {
+	case ReportCourses, ReportDepartments, ReportOverdue, ReportExpiring, ReportTranscript, ReportTeam:
+		return true
+	}
+	return false
+}
+
+// Snapshot is a report saved as it was at CreatedAt. Snapshots cannot be
+// changed or deleted; Hash is the SHA-256 of Data, so a copy can be checked
+// against the stored one.
+type Snapshot struct {
+	ID        string // UUID
+	Kind      ReportKind
+	Filter    Filter
+	Note      string          // e.g. which audit it was taken for
+	Data      json.RawMessage // the report as returned by the JSON export
+	Table     Table
+	Hash      string // hex SHA-256 of Data
+	CreatedBy string // user ID
+	CreatedAt int64  // Unix timestamp
+}
@@ -0,0 +1,67 @@
+package compliance
+
+// Format is the file format a scheduled report is sent in.
+type Format string
+
+const (
+	FormatCSV Format = "csv"
+	FormatPDF Format = "pdf"
+)
+
+// Delivery is how a scheduled report reaches its recipients.
+type Delivery string
+
+const (
+	DeliveryAttachment Delivery = "attachment" // the file is attached to the email
+	DeliveryLink       Delivery = "link"       // the email links to a download that expires
+)
+
+// Schedule is a saved report definition that is generated and emailed to its
+// recipients whenever its cron expression fires.
+type Schedule struct {
+	ID         string // UUID
+	Name       string
+	Kind       ReportKind
+	Filter     Filter
+	Format     Format
+	Cron       string // five-field cron expression or a shorthand such as @weekly; see ParseCron
+	Timezone   string // IANA name the expression is evaluated in, e.g. "Europe/Berlin"
+	Delivery   Delivery
+	Recipients []string // user IDs
+	OwnerID    string
+	Enabled    bool
+	NextRunAt  int64 // Unix timestamp, 0 while disabled
+	LastRunAt  int64 // Unix timestamp, 0 if never run
+	CreatedAt  int64 // Unix timestamp
+	UpdatedAt  int64 // Unix timestamp
+}
+
+// RunStatus is the outcome of a scheduled report run.
+type RunStatus string
+
+const (
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+)
+
+// Run records one generation and delivery of a scheduled report.
+type Run struct {
+	ID           string // UUID
+	ScheduleID   string
+	ScheduleName string
+	Status       RunStatus
+	Error        string // why the run failed, empty on success
+	Recipients   int    // emails sent
+	FileName     string
+	FileKey      string // storage key of the file for link delivery, empty once purged
+	ExpiresAt    int64  // Unix timestamp the download link expires, 0 for attachments
+	StartedAt    int64  // Unix timestamp
+	FinishedAt   int64  // Unix timestamp
+}
+
+// RunFilter narrows the run history. Empty fields match everything.
+type RunFilter struct {
+	ScheduleID string
+	Status     RunStatus
+	Limit      int
+}
@@ -3,13 +3,26 @@
 
 package course
 
+// EnrollmentMode controls how learners join a course.
+type EnrollmentMode string
+
+const (
+	EnrollmentOpen     EnrollmentMode = "open"     // self-enrollment
+	EnrollmentApproval EnrollmentMode = "approval" // requests need manager approval
+	EnrollmentInvite   EnrollmentMode = "invite"   // only invited users may enroll
+)
+
 type Course struct {
-	ID          string // UUID
-	Title       string
-	Description string
-	Category    string
-	CreatedBy   string // user ID
-	Published   bool
+	ID             string // UUID
+	Title          string
+	Description    string
+	Category       string
+	CreatedBy      string // user ID
+	Published      bool
+	EnrollmentMode EnrollmentMode
+	SeatCapacity   int // 0 means unlimited
+
+	RecertificationMonths int // completion expires after this many months; 0 means never
 }
 
 type Module struct {
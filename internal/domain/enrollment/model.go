@@ -1,11 +1,115 @@
 package enrollment
 
+// Status represents the lifecycle state of an enrollment.
+type Status string
+
+const (
+	StatusPending    Status = "pending"    // awaiting approval
+	StatusInvited    Status = "invited"    // invited by staff, not yet accepted
+	StatusWaitlisted Status = "waitlisted" // admitted but no seat available yet
+	StatusActive     Status = "active"
+	StatusCompleted  Status = "completed"
+	StatusDropped    Status = "dropped"
+	StatusRejected   Status = "rejected"
+)
+
+// transitions lists the states each status may move to.
+var transitions = map[Status][]Status{
+	StatusPending:    {StatusActive, StatusWaitlisted, StatusRejected, StatusDropped},
+	StatusInvited:    {StatusActive, StatusWaitlisted, StatusDropped},
+	StatusWaitlisted: {StatusActive, StatusDropped},
+	StatusActive:     {StatusCompleted, StatusDropped},
+	StatusDropped:    {StatusActive, StatusPending, StatusWaitlisted, StatusInvited},
+	StatusRejected:   {StatusActive, StatusPending, StatusWaitlisted, StatusInvited},
+	StatusCompleted:  {StatusActive, StatusWaitlisted}, // re-enrolled for recertification
+}
+
+// CanTransition reports whether an enrollment may move from one status to another.
+func CanTransition(from, to Status) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// EscalationLevel records how far an overdue enrollment has been escalated.
+type EscalationLevel int
+
+const (
+	EscalationNone    EscalationLevel = iota
+	EscalationLearner                 // learner notified
+	EscalationManager                 // learner's manager notified
+	EscalationHR                      // HR notified
+)
+
+// String returns the name of the escalation level used in reports.
+func (l EscalationLevel) String() string {
+	switch l {
+	case EscalationLearner:
+		return "learner"
+	case EscalationManager:
+		return "manager"
+	case EscalationHR:
+		return "hr"
+	default:
+		return "none"
+	}
+}
+
 // Enrollment represents a user's enrollment in a course.
 type Enrollment struct {
-	ID        string // UUID
-	UserID    string // UUID of the enrolled user
-	CourseID  string // UUID of the course
-	Status    string // e.g., "active", "completed", "dropped"
-	CreatedAt int64  // Unix timestamp
-	UpdatedAt int64  // Unix timestamp
+	ID              string // UUID
+	UserID          string // UUID of the enrolled user
+	CourseID        string // UUID of the course
+	Status          Status // see Status constants
+	CreatedAt       int64  // Unix timestamp
+	UpdatedAt       int64  // Unix timestamp
+	AssignedBy      string // user ID of the assigner, empty for self-enrollment
+	DueAt           int64  // Unix timestamp, 0 when there is no deadline
+	GraceDays       int    // days after DueAt before the enrollment is overdue
+	OverdueAt       int64  // Unix timestamp it was marked overdue, 0 otherwise
+	EscalationLevel EscalationLevel
+	NotifiedHR      []string // HR users already told about the overdue enrollment
+}
+
+// OverdueAfter returns the Unix timestamp after which the enrollment is overdue,
+// or 0 when it has no deadline.
+func (e *Enrollment) OverdueAfter() int64 {
+	if e.DueAt == 0 {
+		return 0
+	}
+	return e.DueAt + int64(e.GraceDays)*24*60*60
+}
+
+// IsPastDue reports whether an active enrollment has passed its deadline and grace period.
+func (e *Enrollment) IsPastDue(now int64) bool {
+	return e.Status == StatusActive && e.DueAt != 0 && now > e.OverdueAfter()
+}
+
+// OverdueRecord is a row of the overdue training report.
+type OverdueRecord struct {
+	EnrollmentID    string
+	UserID          string
+	UserName        string
+	UserEmail       string
+	Department      string
+	CourseID        string
+	CourseTitle     string
+	AssignedBy      string
+	DueAt           int64 // Unix timestamp
+	OverdueAt       int64 // Unix timestamp
+	EscalationLevel EscalationLevel
+}
+
+// StatusChange records a single state transition of an enrollment.
+type StatusChange struct {
+	ID           string // UUID
+	EnrollmentID string
+	FromStatus   Status // empty for the initial enrollment
+	ToStatus     Status
+	ChangedBy    string // user ID of the actor
+	Reason       string // optional
+	ChangedAt    int64  // Unix timestamp
 }
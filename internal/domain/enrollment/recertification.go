@@ -0,0 +1,62 @@
+package enrollment
+
+import "time"
+
+// ComplianceStatus describes whether a learner's certification for a course is current.
+type ComplianceStatus string
+
+const (
+	ComplianceCompliant    ComplianceStatus = "compliant"
+	ComplianceExpiringSoon ComplianceStatus = "expiring_soon"
+	ComplianceExpired      ComplianceStatus = "expired"
+)
+
+// Cycle is one certification period of a recurring course: it starts when the
+// learner completes the course and ends when the certification expires or is
+// renewed by completing the next cycle.
+type Cycle struct {
+	ID               string // UUID
+	UserID           string // UUID of the certified user
+	CourseID         string // UUID of the course
+	EnrollmentID     string // UUID of the completed enrollment
+	Number           int    // 1 for the initial certification
+	CompletedAt      int64  // Unix timestamp of completion
+	ExpiresAt        int64  // Unix timestamp of expiry
+	RenewalStartedAt int64  // Unix timestamp of automatic re-enrollment, 0 until then
+	RemindersSent    int    // number of expiry reminders sent
+	RenewedAt        int64  // Unix timestamp the next cycle was completed, 0 while current
+}
+
+// ExpiryAfter returns the expiry of a certification completed at completedAt
+// for a course that must be retaken every months months.
+func ExpiryAfter(completedAt int64, months int) int64 {
+	return time.Unix(completedAt, 0).UTC().AddDate(0, months, 0).Unix()
+}
+
+// Current reports whether the cycle has not been superseded by a renewal.
+func (c *Cycle) Current() bool {
+	return c.RenewedAt == 0
+}
+
+// Compliance returns the cycle's compliance status at now. Certifications
+// expiring within warnWithin seconds are reported as expiring soon.
+func (c *Cycle) Compliance(now, warnWithin int64) ComplianceStatus {
+	switch {
+	case now >= c.ExpiresAt:
+		return ComplianceExpired
+	case now >= c.ExpiresAt-warnWithin:
+		return ComplianceExpiringSoon
+	default:
+		return ComplianceCompliant
+	}
+}
+
+// ComplianceRecord is a learner's current compliance for a recurring course.
+type ComplianceRecord struct {
+	UserID      string
+	CourseID    string
+	Cycle       int
+	CompletedAt int64
+	ExpiresAt   int64
+	Status      ComplianceStatus
+}
@@ -0,0 +1,56 @@
+package enrollment
+
+// ReminderAnchor is the point in an enrollment a reminder step is timed from.
+type ReminderAnchor string
+
+const (
+	// AnchorEnrolled steps are sent a number of days after the enrollment
+	// became active, only if the learner has made no progress since.
+	AnchorEnrolled ReminderAnchor = "enrolled"
+	// AnchorDue steps are sent a number of days before the due date; 0 is
+	// on the due date. Enrollments without a deadline are skipped.
+	AnchorDue ReminderAnchor = "due"
+)
+
+// ReminderStep is one reminder in the sequence sent for incomplete courses.
+type ReminderStep struct {
+	Name   string // unique, recorded for each reminder sent
+	Anchor ReminderAnchor
+	Days   int
+}
+
+// ReminderCandidate is an active enrollment with the data reminders are based on.
+type ReminderCandidate struct {
+	Enrollment     *Enrollment
+	ActiveSince    int64 // Unix timestamp the enrollment last became active
+	LastActivityAt int64 // Unix timestamp of the learner's latest progress, 0 if none
+}
+
+// AnchorAt returns the Unix timestamp the step is timed from, or 0 when the
+// step does not apply to the candidate.
+func (r ReminderStep) AnchorAt(c *ReminderCandidate) int64 {
+	switch r.Anchor {
+	case AnchorEnrolled:
+		if c.LastActivityAt >= c.ActiveSince {
+			return 0
+		}
+		return c.ActiveSince
+	case AnchorDue:
+		return c.Enrollment.DueAt
+	}
+	return 0
+}
+
+// SendAt returns the Unix timestamp the step is due for the candidate, or 0
+// when it does not apply.
+func (r ReminderStep) SendAt(c *ReminderCandidate) int64 {
+	anchor := r.AnchorAt(c)
+	if anchor == 0 {
+		return 0
+	}
+	const day = 24 * 60 * 60
+	if r.Anchor == AnchorDue {
+		return anchor - int64(r.Days)*day
+	}
+	return anchor + int64(r.Days)*day
+}
@@ -0,0 +1,45 @@
+package enrollment
+
+import "training-portal/internal/domain/user"
+
+// RuleField names the user attribute an auto-enrollment rule matches on.
+type RuleField string
+
+const (
+	RuleFieldRole       RuleField = "role"
+	RuleFieldDepartment RuleField = "department"
+)
+
+// Rule automatically enrolls every user whose attribute matches into a course,
+// e.g. all users with role "trainer" get course X.
+type Rule struct {
+	ID        string // UUID
+	Name      string
+	Field     RuleField
+	Value     string
+	CourseID  string
+	Active    bool
+	CreatedBy string // user ID
+	CreatedAt int64  // Unix timestamp
+}
+
+// Matches reports whether the rule applies to the user.
+func (r *Rule) Matches(u *user.User) bool {
+	switch r.Field {
+	case RuleFieldRole:
+		return string(u.Role) == r.Value
+	case RuleFieldDepartment:
+		return u.Department != "" && u.Department == r.Value
+	}
+	return false
+}
+
+// RuleEnrollment is an audit record of an enrollment created by a rule.
+type RuleEnrollment struct {
+	ID           string // UUID
+	RuleID       string
+	UserID       string
+	EnrollmentID string
+	Status       Status // status the enrollment was created in
+	EnrolledAt   int64  // Unix timestamp
+}
@@ -1,22 +1,51 @@
 package forum
 
-// Forum represents a discussion forum for a course or module.
+// Forum represents a discussion forum for a course or module. Every course
+// and module has exactly one; forums without a course are general forums
+// open to every user.
 type Forum struct {
 	ID        string // UUID
 	Title     string
-	CourseID  string // optional: link to course
+	CourseID  string // optional: link to course; also set for module forums
 	ModuleID  string // optional: link to module
-	CreatedBy string // user ID
+	CreatedBy string // user ID, empty for forums created with their course or module
 	CreatedAt int64  // Unix timestamp
 }
 
-// Post represents a post in a forum.
+// PostType distinguishes open discussions from questions, whose replies are
+// answers that can be voted on and accepted.
+type PostType string
+
+const (
+	TypeDiscussion PostType = "discussion"
+	TypeQuestion   PostType = "question"
+)
+
+// Valid reports whether t is a known post type.
+func (t PostType) Valid() bool {
+	return t == TypeDiscussion || t == TypeQuestion
+}
+
+// Post represents a thread in a forum: its opening post with a title.
 type Post struct {
-	ID        string // UUID
-	ForumID   string
-	UserID    string
-	Content   string
-	CreatedAt int64 // Unix timestamp
+	ID              string // UUID
+	ForumID         string
+	UserID          string
+	Type            PostType
+	State           State
+	Locked          bool // no new replies or edits except by moderators
+	Pinned          bool // listed before other threads
+	Title           string
+	Content         string // Markdown
+	HTML            string // Content rendered and sanitized for display
+	CreatedAt       int64  // Unix timestamp
+	EditedAt        int64  // Unix timestamp of the last edit, 0 if never edited
+	DeletedAt       int64  // Unix timestamp, 0 unless deleted
+	LastActivityAt  int64  // Unix timestamp of the post or its newest reply
+	ReplyCount      int    // visible replies
+	Score           int    // upvotes minus downvotes
+	AcceptedReplyID string
+	Vote            int // the viewer's vote: 1, -1 or 0
 }
 
 // Reply represents a reply to a post.
@@ -24,6 +53,86 @@ type Reply struct {
 	ID        string // UUID
 	PostID    string
 	UserID    string
-	Content   string
+	Content   string // Markdown
+	HTML      string // Content rendered and sanitized for display
+	State     State
 	CreatedAt int64 // Unix timestamp
+	EditedAt  int64 // Unix timestamp of the last edit, 0 if never edited
+	DeletedAt int64 // Unix timestamp, 0 unless deleted
+	Score     int   // upvotes minus downvotes
+	Accepted  bool  // the accepted answer to its question
+	Vote      int   // the viewer's vote: 1, -1 or 0
+}
+
+// Vote is a user's upvote (1) or downvote (-1) on a post or reply. Exactly
+// one of PostID and ReplyID is set.
+type Vote struct {
+	UserID    string
+	PostID    string
+	ReplyID   string
+	Value     int
+	CreatedAt int64 // Unix timestamp
+}
+
+// PostSort orders a forum's threads.
+type PostSort string
+
+const (
+	SortActivity PostSort = "activity" // most recently active first
+	SortVotes    PostSort = "votes"    // highest score first
+	SortNewest   PostSort = "newest"
+	// SortUnanswered lists only unanswered questions, oldest first.
+	SortUnanswered PostSort = "unanswered"
+)
+
+// Valid reports whether s is a known sort order.
+func (s PostSort) Valid() bool {
+	switch s {
+	case SortActivity, SortVotes, SortNewest, SortUnanswered:
+		return true
+	}
+	return false
+}
+
+// PostFilter selects a page of threads. A question is unanswered while it
+// has no accepted answer and no visible answer with a positive score.
+// Pinned threads come first except in the unanswered list.
+type PostFilter struct {
+	ForumID   string
+	CourseID  string // every forum of the course when ForumID is empty
+	Sort      PostSort
+	ViewerID  string // whose votes to report
+	AllStates bool   // include held and hidden threads, for moderators
+	Offset    int
+	Limit     int
+}
+
+// ReplyFilter selects a page of a post's replies, oldest first.
+type ReplyFilter struct {
+	PostID    string
+	ViewerID  string // whose votes to report
+	AllStates bool   // include held and hidden replies, for moderators
+	Offset    int
+	Limit     int
+}
+
+// RevisionAction says why a revision was recorded.
+type RevisionAction string
+
+const (
+	RevisionEdited  RevisionAction = "edited"
+	RevisionDeleted RevisionAction = "deleted"
+)
+
+// Revision keeps the content a post or reply had before it was edited or
+// deleted. Exactly one of PostID and ReplyID is set.
+type Revision struct {
+	ID       string // UUID
+	PostID   string
+	ReplyID  string
+	Title    string // previous post title, empty for replies
+	Content  string // previous content
+	Action   RevisionAction
+	EditedBy string // user ID
+	EditedAt int64  // Unix timestamp
 }
@@ -0,0 +1,97 @@
+package forum
+
+// State is the moderation state of a post or reply.
+type State string
+
+const (
+	StateVisible State = "visible"
+	StateHeld    State = "held"   // caught by the word filter, waiting for review
+	StateHidden  State = "hidden" // hidden by a moderator
+)
+
+// ReportStatus tracks a report through the moderator queue.
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportResolved  ReportStatus = "resolved"  // a moderator acted on the content
+	ReportDismissed ReportStatus = "dismissed" // a moderator left the content as it was
+)
+
+// Report is a user's complaint about a post or reply. Exactly one of PostID
+// and ReplyID is set.
+type Report struct {
+	ID         string // UUID
+	PostID     string
+	ReplyID    string
+	ReporterID string
+	Reason     string
+	Status     ReportStatus
+	CreatedAt  int64  // Unix timestamp
+	ResolvedBy string // moderator user ID
+	ResolvedAt int64  // Unix timestamp, 0 while open
+}
+
+// QueueItem is a post or reply that needs a moderator: it is held by the
+// word filter or has open reports.
+type QueueItem struct {
+	PostID    string
+	ReplyID   string // set for replies
+	ForumID   string
+	CourseID  string
+	AuthorID  string
+	Title     string // thread title
+	Content   string
+	State     State
+	Reports   int      // open reports
+	Reasons   []string // reasons given in the open reports
+	CreatedAt int64    // Unix timestamp of the content
+}
+
+// ModerationActionType names what a moderator did.
+type ModerationActionType string
+
+const (
+	ActionHold      ModerationActionType = "hold" // automatic, by the word filter
+	ActionApprove   ModerationActionType = "approve"
+	ActionHide      ModerationActionType = "hide"
+	ActionRestore   ModerationActionType = "restore"
+	ActionDismiss   ModerationActionType = "dismiss" // reports dismissed, content unchanged
+	ActionLock      ModerationActionType = "lock"
+	ActionUnlock    ModerationActionType = "unlock"
+	ActionPin       ModerationActionType = "pin"
+	ActionUnpin     ModerationActionType = "unpin"
+	ActionSuspend   ModerationActionType = "suspend"
+	ActionUnsuspend ModerationActionType = "unsuspend"
+)
+
+// ModerationAction is an entry in the moderation log. ModeratorID is empty
+// for actions taken automatically.
+type ModerationAction struct {
+	ID          string // UUID
+	Action      ModerationActionType
+	CourseID    string
+	PostID      string
+	ReplyID     string
+	UserID      string // the user acted on, e.g. the author or suspended user
+	ModeratorID string
+	Reason      string
+	CreatedAt   int64 // Unix timestamp
+}
+
+// Suspension keeps a user from posting in a course's forums until it ends.
+type Suspension struct {
+	ID        string // UUID
+	UserID    string
+	CourseID  string
+	Reason    string
+	CreatedBy string // moderator user ID
+	CreatedAt int64  // Unix timestamp
+	EndsAt    int64  // Unix timestamp
+	LiftedAt  int64  // Unix timestamp, 0 unless lifted early
+}
+
+// Active reports whether the suspension is in force at now.
+func (s *Suspension) Active(now int64) bool {
+	return s.LiftedAt == 0 && now < s.EndsAt
+}
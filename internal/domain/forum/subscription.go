@@ -0,0 +1,16 @@
+package forum
+
+import "training-portal/internal/domain/notification"
+
+// Subscription asks for notifications about new activity in a forum or a
+// thread. Exactly one of ForumID and PostID is set: forum subscribers hear
+// about new threads and replies, thread subscribers about new replies.
+type Subscription struct {
+	UserID  string
+	ForumID string
+	PostID  string
+	// Digest collects the emails into a daily or weekly digest; empty
+	// follows the user's notification preferences.
+	Digest    notification.DigestMode
+	CreatedAt int64 // Unix timestamp
+}
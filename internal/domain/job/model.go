@@ -0,0 +1,22 @@
+package job
+
+// Job is a recurring background job. Its row doubles as a lock: a server
+// instance runs the job only after claiming it, so with several replicas
+// each run happens once.
+type Job struct {
+	Name         string
+	Interval     int64  // seconds between runs
+	Paused       bool   // paused jobs are not run until resumed
+	NextRunAt    int64  // Unix timestamp
+	LastRunAt    int64  // Unix timestamp, 0 if never run
+	LastDuration int64  // milliseconds
+	LastError    string // empty if the last run succeeded
+	LockedBy     string // instance running the job, empty when idle
+	LockedUntil  int64  // Unix timestamp the claim expires at
+	UpdatedAt    int64  // Unix timestamp
+}
+
+// Running reports whether an instance holds an unexpired claim on the job at now.
+func (j *Job) Running(now int64) bool {
+	return j.LockedBy != "" && j.LockedUntil > now
+}
@@ -1,11 +1,69 @@
 package message
 
-// Message represents a direct message between users.
+import (
+	"sort"
+	"strings"
+)
+
+// Conversation is a message thread between two users or a small group.
+type Conversation struct {
+	ID           string // UUID
+	Title        string // group name, empty for direct conversations
+	Group        bool   // false for one-to-one conversations
+	CreatedBy    string // user ID
+	CreatedAt    int64  // Unix timestamp
+	UpdatedAt    int64  // Unix timestamp of the latest message
+	Participants []*Participant
+	LastMessage  *Message // set when listing conversations
+	Unread       int      // messages after the caller's read cursor, set when listing
+}
+
+// Participant is a member of a conversation with their read cursor.
+type Participant struct {
+	ConversationID string
+	UserID         string
+	JoinedAt       int64 // Unix timestamp
+	LastReadSeq    int64 // Seq of the newest message the user has read, 0 for none
+	LastReadAt     int64 // Unix timestamp, 0 if never read
+}
+
+// Message is a message posted to a conversation.
 type Message struct {
-	ID         string // UUID
-	SenderID   string // User ID of sender
-	ReceiverID string // User ID of receiver
-	Content    string
-	SentAt     int64 // Unix timestamp
-	Read       bool
+	ID             string // UUID
+	ConversationID string
+	SenderID       string // User ID of sender
+	Content        string
+	Seq            int64 // increasing position across all messages, used as cursor
+	SentAt         int64 // Unix timestamp
+	Attachments    []*Attachment
+}
+
+// Attachment is a file sent with a message. The file itself is kept in a
+// file store under StorageKey and downloaded through expiring links.
+type Attachment struct {
+	ID             string // UUID
+	MessageID      string
+	ConversationID string
+	FileName       string // as uploaded, for the download
+	ContentType    string
+	Size           int64  // bytes
+	StorageKey     string // file store key
+}
+
+// Participant returns the conversation's participant with the given user ID, or nil.
+func (c *Conversation) Participant(userID string) *Participant {
+	for _, p := range c.Participants {
+		if p.UserID == userID {
+			return p
+		}
+	}
+	return nil
+}
+
+// DirectKey identifies the one-to-one conversation between two users
+// regardless of who started it.
+func DirectKey(a, b string) string {
+	ids := []string{a, b}
+	sort.Strings(ids)
+	return strings.Join(ids, ":")
 }
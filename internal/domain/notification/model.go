@@ -13,10 +13,11 @@ const (
 type NotificationStatus string
 
 const (
-	StatusUnread NotificationStatus = "unread"
-	StatusRead   NotificationStatus = "read"
-	StatusSent   NotificationStatus = "sent"
-	StatusFailed NotificationStatus = "failed"
+	StatusUnread  NotificationStatus = "unread"
+	StatusRead    NotificationStatus = "read"
+	StatusPending NotificationStatus = "pending"
+	StatusSent    NotificationStatus = "sent"
+	StatusFailed  NotificationStatus = "failed"
 )
 
 // Notification represents a user notification in the system
@@ -24,9 +25,44 @@ type Notification struct {
 	ID        string             // UUID
 	UserID    string             // User to notify
 	Type      NotificationType   // Notification channel/type
+	Event     EventType          // What the notification is about
 	Title     string             // Short title
 	Message   string             // Notification message
 	Status    NotificationStatus // Read/sent status
 	CreatedAt int64              // Unix timestamp
 	ReadAt    *int64             // Unix timestamp (nullable)
+
+	Deliveries []*Delivery // Outbound deliveries over other channels, when loaded
+}
+
+// Delivery is an outbox entry: one notification to be sent over one external
+// channel. Failed attempts are retried until the attempt limit is reached.
+type Delivery struct {
+	ID             string             // UUID
+	NotificationID string             // UUID of the notification
+	UserID         string             // Recipient
+	Channel        NotificationType   // email or sms
+	Digest         DigestMode         // immediate, or collected into a daily or weekly digest
+	Status         NotificationStatus // pending, sent or failed
+	Attempts       int                // Send attempts so far
+	LastError      string             // Error of the last failed attempt
+	NextAttemptAt  int64              // Unix timestamp the next attempt is due
+	SentAt         int64              // Unix timestamp, 0 until sent
+	CreatedAt      int64              // Unix timestamp
+}
+
+// Email is a message sent straight to email addresses rather than as a
+// notification, such as a scheduled report.
+type Email struct {
+	To          []string // addresses
+	Subject     string
+	Body        string // plain text
+	Attachments []EmailAttachment
+}
+
+// EmailAttachment is a file attached to an Email.
+type EmailAttachment struct {
+	FileName    string
+	ContentType string
+	Data        []byte
 }
@@ -0,0 +1,131 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+)
+
+// DigestMode controls whether notifications are sent immediately or collected.
+type DigestMode string
+
+const (
+	DigestImmediate DigestMode = "immediate"
+	DigestDaily     DigestMode = "daily"
+	DigestWeekly    DigestMode = "weekly"
+)
+
+// DigestHour is the local hour digests are sent at; weekly digests go out on Mondays.
+const DigestHour = 8
+
+// Preference is a user's delivery choice for one event type. No channels
+// means the user does not want the notification at all.
+type Preference struct {
+	Event    EventType
+	Channels []NotificationType // in_app, email or sms
+	Digest   DigestMode
+}
+
+// Has reports whether the preference includes channel t.
+func (p Preference) Has(t NotificationType) bool {
+	for _, c := range p.Channels {
+		if c == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Settings are a user's notification settings.
+type Settings struct {
+	UserID      string
+	Locale      string // empty for the portal default
+	TimeZone    string // IANA name, empty for UTC
+	QuietStart  int    // minutes after local midnight
+	QuietEnd    int    // quiet hours are disabled when equal to QuietStart
+	Preferences []Preference
+}
+
+// Preference returns the user's preference for an event. Without a stored
+// preference, and always for mandatory events, every available channel is
+// used immediately.
+func (s *Settings) Preference(event EventType, available []NotificationType) Preference {
+	all := Preference{Event: event, Channels: available, Digest: DigestImmediate}
+	if event.Mandatory() {
+		return all
+	}
+	for _, p := range s.Preferences {
+		if p.Event == event {
+			if p.Digest == "" {
+				p.Digest = DigestImmediate
+			}
+			return p
+		}
+	}
+	return all
+}
+
+// Location returns the user's time zone, UTC when unset or unknown.
+func (s *Settings) Location() *time.Location {
+	if loc, err := time.LoadLocation(s.TimeZone); err == nil && s.TimeZone != "" {
+		return loc
+	}
+	return time.UTC
+}
+
+// QuietUntil returns t, or the end of the quiet hours t falls into.
+func (s *Settings) QuietUntil(t time.Time) time.Time {
+	if s.QuietStart == s.QuietEnd {
+		return t
+	}
+	local := t.In(s.Location())
+	minute := local.Hour()*60 + local.Minute()
+	var quiet bool
+	if s.QuietStart < s.QuietEnd {
+		quiet = minute >= s.QuietStart && minute < s.QuietEnd
+	} else { // spans midnight, e.g. 22:00-07:00
+		quiet = minute >= s.QuietStart || minute < s.QuietEnd
+	}
+	if !quiet {
+		return t
+	}
+	end := time.Date(local.Year(), local.Month(), local.Day(), s.QuietEnd/60, s.QuietEnd%60, 0, 0, local.Location())
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// NextDigest returns when a notification created at t goes out in a digest.
+func (s *Settings) NextDigest(t time.Time, mode DigestMode) time.Time {
+	local := t.In(s.Location())
+	next := time.Date(local.Year(), local.Month(), local.Day(), DigestHour, 0, 0, 0, local.Location())
+	switch mode {
+	case DigestDaily:
+		if !next.After(local) {
+			next = next.AddDate(0, 0, 1)
+		}
+	case DigestWeekly:
+		days := (int(time.Monday) - int(next.Weekday()) + 7) % 7
+		next = next.AddDate(0, 0, days)
+		if !next.After(local) {
+			next = next.AddDate(0, 0, 7)
+		}
+	default:
+		return t
+	}
+	return next
+}
+
+// ParseClock parses "HH:MM" into minutes after midnight.
+func ParseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid time of day %q, want HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+// FormatClock formats minutes after midnight as "HH:MM".
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
@@ -0,0 +1,152 @@
+package notification
+
+import "strings"
+
+// EventType identifies what a notification is about. Each event has a
+// template and per-user delivery preferences.
+type EventType string
+
+const (
+	EventGeneral                 EventType = "general" // ad-hoc messages from staff
+	EventCourseAssigned          EventType = "course_assigned"
+	EventCourseReminder          EventType = "course_reminder" // no progress since enrollment
+	EventDueSoon                 EventType = "due_soon"
+	EventOverdue                 EventType = "overdue"
+	EventOverdueManager          EventType = "overdue_manager" // sent to the learner's manager
+	EventOverdueHR               EventType = "overdue_hr"      // sent to HR on final escalation
+	EventRecertificationRequired EventType = "recertification_required"
+	EventCertificationExpiring   EventType = "certification_expiring"
+	EventQuizGraded              EventType = "quiz_graded"
+	EventCertificateIssued       EventType = "certificate_issued"
+	EventForumPost               EventType = "forum_post"  // new thread in a subscribed forum
+	EventForumReply              EventType = "forum_reply" // new reply in a subscribed forum or thread
+	EventForumMention            EventType = "forum_mention"
+	EventDigest                  EventType = "digest" // wraps digested notifications
+)
+
+// Events lists the event types users can set preferences for.
+var Events = []EventType{
+	EventGeneral,
+	EventCourseAssigned,
+	EventCourseReminder,
+	EventDueSoon,
+	EventOverdue,
+	EventOverdueManager,
+	EventOverdueHR,
+	EventRecertificationRequired,
+	EventCertificationExpiring,
+	EventQuizGraded,
+	EventCertificateIssued,
+	EventForumPost,
+	EventForumReply,
+	EventForumMention,
+}
+
+// Mandatory reports whether the event is a compliance message that users
+// cannot opt out of or digest.
+func (e EventType) Mandatory() bool {
+	switch e {
+	case EventOverdue, EventOverdueManager, EventOverdueHR, EventRecertificationRequired, EventCertificationExpiring:
+		return true
+	}
+	return false
+}
+
+// Valid reports whether e is a known event type.
+func (e EventType) Valid() bool {
+	for _, known := range append(Events, EventDigest) {
+		if e == known {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultLocale is the locale of the built-in templates.
+const DefaultLocale = "en"
+
+// Template is the wording of an event in one locale. Subject and Body may use
+// {{variable}} placeholders, e.g. {{course_title}}.
+type Template struct {
+	ID        string // UUID, empty for built-in templates
+	Event     EventType
+	Locale    string // BCP 47 tag, e.g. "de" or "pt-BR"
+	Subject   string
+	Body      string
+	UpdatedAt int64 // Unix timestamp
+}
+
+// Render fills the template's placeholders with vars.
+func (t *Template) Render(vars map[string]string) (subject, body string) {
+	pairs := make([]string, 0, len(vars)*2)
+	for name, value := range vars {
+		pairs = append(pairs, "{{"+name+"}}", value)
+	}
+	r := strings.NewReplacer(pairs...)
+	return r.Replace(t.Subject), r.Replace(t.Body)
+}
+
+// DefaultTemplates are the built-in English templates, used when no stored
+// template matches the recipient's locale.
+var DefaultTemplates = map[EventType]Template{
+	EventGeneral: {
+		Subject: "{{title}}",
+		Body:    "{{message}}",
+	},
+	EventCourseAssigned: {
+		Subject: "New training assigned: {{course_title}}",
+		Body:    "You have been enrolled in {{course_title}}.",
+	},
+	EventCourseReminder: {
+		Subject: "Reminder: {{course_title}}",
+		Body:    "You were enrolled in {{course_title}} {{days}} days ago and have not started yet.",
+	},
+	EventDueSoon: {
+		Subject: "Training due soon: {{course_title}}",
+		Body:    "Your training {{course_title}} is due on {{due_date}}.",
+	},
+	EventOverdue: {
+		Subject: "Overdue training",
+		Body:    "Your training {{course_title}} was due on {{due_date}} and is now overdue.",
+	},
+	EventOverdueManager: {
+		Subject: "Overdue training",
+		Body:    "{{learner_name}} has not completed the required training {{course_title}}, due {{due_date}}.",
+	},
+	EventOverdueHR: {
+		Subject: "Overdue training",
+		Body:    "Training {{course_title}} for {{learner_name}} is overdue since {{due_date}} and has been escalated to HR.",
+	},
+	EventRecertificationRequired: {
+		Subject: "Recertification required",
+		Body:    "Your certification for {{course_title}} expires on {{expiry_date}}. You have been re-enrolled to recertify.",
+	},
+	EventCertificationExpiring: {
+		Subject: "Certification expiring",
+		Body:    "Your certification for {{course_title}} expires in {{days}} days, on {{expiry_date}}. Please complete the course again before then.",
+	},
+	EventQuizGraded: {
+		Subject: "Quiz graded: {{quiz_title}}",
+		Body:    "Your quiz {{quiz_title}} in {{course_title}} has been graded. Score: {{score}}.",
+	},
+	EventCertificateIssued: {
+		Subject: "Certificate issued: {{course_title}}",
+		Body:    "Congratulations! Your certificate for {{course_title}} is ready. Credential ID: {{credential_id}}.",
+	},
+	EventForumPost: {
+		Subject: "New thread in {{forum_title}}: {{post_title}}",
+		Body:    "{{author_name}} started a thread in {{forum_title}}: {{excerpt}}",
+	},
+	EventForumReply: {
+		Subject: "New reply: {{post_title}}",
+		Body:    "{{author_name}} replied to {{post_title}} in {{forum_title}}: {{excerpt}}",
+	},
+	EventForumMention: {
+		Subject: "{{author_name}} mentioned you",
+		Body:    "{{author_name}} mentioned you in {{post_title}} in {{forum_title}}: {{excerpt}}",
+	},
+	EventDigest: {
+		Subject: "Your training portal digest ({{count}})",
+		Body:    "{{items}}",
+	},
+}
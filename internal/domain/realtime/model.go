@@ -0,0 +1,32 @@
+package realtime
+
+import "encoding/json"
+
+// EventType identifies what changed for the user an event is addressed to.
+type EventType string
+
+const (
+	TypeNotification EventType = "notification" // a new in-app notification
+	TypeMessage      EventType = "message"      // a direct message arrived
+	TypeMessageRead  EventType = "message_read" // a participant's read cursor moved
+	TypeGrade        EventType = "grade"        // a quiz was graded
+	TypeEnrollment   EventType = "enrollment"   // an enrollment changed status
+)
+
+// Grade is the data of a TypeGrade event.
+type Grade struct {
+	CourseID string // "" for quizzes outside a course
+	QuizID   string
+	Score    int
+	Total    int // the highest possible score
+}
+
+// Event is a change pushed to one user's realtime stream. Events are stored
+// so that clients can replay what they missed while disconnected.
+type Event struct {
+	ID        int64 // increasing cursor, assigned on insert
+	UserID    string
+	Type      EventType
+	Data      json.RawMessage // the changed resource as JSON
+	CreatedAt int64           // Unix timestamp
+}
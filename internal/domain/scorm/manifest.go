@@ -0,0 +1,263 @@
+package scorm
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ManifestFile is the name of the manifest at the root of every package.
+const ManifestFile = "imsmanifest.xml"
+
+var ErrInvalidManifest = errors.New("invalid imsmanifest.xml")
+
+// Manifest is what the portal takes from imsmanifest.xml: the default
+// organization's launchable items, in order.
+type Manifest struct {
+	Identifier string
+	Title      string
+	Version    Version
+	Items      []ManifestItem
+}
+
+// ManifestItem is a leaf of the organization tree that launches a resource.
+type ManifestItem struct {
+	Identifier          string
+	Title               string
+	LaunchPath          string // resolved against xml:base, with the item's parameters
+	Asset               bool
+	PassingScore        *float64 // scaled; nil when not set
+	CompletionThreshold float64
+	LaunchData          string
+}
+
+type xmlManifest struct {
+	Identifier    string `xml:"identifier,attr"`
+	Base          string `xml:"base,attr"`
+	SchemaVersion string `xml:"metadata>schemaversion"`
+	Organizations struct {
+		Default string            `xml:"default,attr"`
+		List    []xmlOrganization `xml:"organization"`
+	} `xml:"organizations"`
+	Resources struct {
+		Base string        `xml:"base,attr"`
+		List []xmlResource `xml:"resource"`
+	} `xml:"resources"`
+}
+
+type xmlOrganization struct {
+	Identifier string    `xml:"identifier,attr"`
+	Title      string    `xml:"title"`
+	Items      []xmlItem `xml:"item"`
+}
+
+type xmlItem struct {
+	Identifier    string    `xml:"identifier,attr"`
+	IdentifierRef string    `xml:"identifierref,attr"`
+	Parameters    string    `xml:"parameters,attr"`
+	Title         string    `xml:"title"`
+	Items         []xmlItem `xml:"item"`
+	// SCORM 1.2
+	MasteryScore string `xml:"masteryscore"`
+	DataFromLMS  string `xml:"datafromlms"`
+	// SCORM 2004
+	LaunchData          string `xml:"dataFromLMS"`
+	CompletionThreshold struct {
+		Value              string `xml:",chardata"`
+		MinProgressMeasure string `xml:"minProgressMeasure,attr"`
+	} `xml:"completionThreshold"`
+	PrimaryObjective struct {
+		SatisfiedByMeasure   string `xml:"satisfiedByMeasure,attr"`
+		MinNormalizedMeasure string `xml:"minNormalizedMeasure"`
+	} `xml:"sequencing>objectives>primaryObjective"`
+}
+
+type xmlResource struct {
+	Identifier string `xml:"identifier,attr"`
+	Href       string `xml:"href,attr"`
+	Base       string `xml:"base,attr"`
+	ScormType  string `xml:"scormtype,attr"` // 1.2
+	ScormType2 string `xml:"scormType,attr"` // 2004
+}
+
+// ParseManifest reads imsmanifest.xml. Items of the default organization
+// (or the first one) that reference a resource become launchable items;
+// launch paths outside the package or to other sites are rejected.
+func ParseManifest(data []byte) (*Manifest, error) {
+	var m xmlManifest
+	if err := xml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+	orgs := m.Organizations.List
+	if len(orgs) == 0 {
+		return nil, fmt.Errorf("%w: no organization", ErrInvalidManifest)
+	}
+	org := orgs[0]
+	for _, o := range orgs {
+		if o.Identifier == m.Organizations.Default {
+			org = o
+			break
+		}
+	}
+	resources := make(map[string]xmlResource, len(m.Resources.List))
+	for _, r := range m.Resources.List {
+		resources[r.Identifier] = r
+	}
+
+	out := &Manifest{
+		Identifier: m.Identifier,
+		Title:      strings.TrimSpace(org.Title),
+		Version:    manifestVersion(m.SchemaVersion, data),
+	}
+	var walk func(items []xmlItem) error
+	walk = func(items []xmlItem) error {
+		for _, it := range items {
+			if len(it.Items) > 0 {
+				if err := walk(it.Items); err != nil {
+					return err
+				}
+				continue
+			}
+			if it.IdentifierRef == "" {
+				continue
+			}
+			res, ok := resources[it.IdentifierRef]
+			if !ok {
+				return fmt.Errorf("%w: item %q references unknown resource %q", ErrInvalidManifest, it.Identifier, it.IdentifierRef)
+			}
+			item, err := manifestItem(m, res, it, out.Version)
+			if err != nil {
+				return err
+			}
+			out.Items = append(out.Items, item)
+		}
+		return nil
+	}
+	if err := walk(org.Items); err != nil {
+		return nil, err
+	}
+	if len(out.Items) == 0 {
+		return nil, fmt.Errorf("%w: no launchable items", ErrInvalidManifest)
+	}
+	if out.Title == "" {
+		out.Title = out.Items[0].Title
+	}
+	return out, nil
+}
+
+func manifestVersion(schemaVersion string, data []byte) Version {
+	v := strings.TrimSpace(schemaVersion)
+	switch {
+	case v == "1.2":
+		return Version12
+	case strings.Contains(v, "2004"), strings.Contains(v, "1.3"):
+		return Version2004
+	case strings.Contains(string(data), "adlcp_v1p3"):
+		return Version2004
+	}
+	return Version12
+}
+
+func manifestItem(m xmlManifest, res xmlResource, it xmlItem, version Version) (ManifestItem, error) {
+	item := ManifestItem{
+		Identifier: it.Identifier,
+		Title:      strings.TrimSpace(it.Title),
+		Asset:      !strings.EqualFold(res.ScormType+res.ScormType2, "sco"),
+	}
+	if item.Title == "" {
+		item.Title = it.Identifier
+	}
+	href := strings.TrimSpace(res.Href)
+	if href == "" {
+		return item, fmt.Errorf("%w: resource %q has no href", ErrInvalidManifest, res.Identifier)
+	}
+	launch, err := resolveHref(m.Base+m.Resources.Base+res.Base, href)
+	if err != nil {
+		return item, fmt.Errorf("%w: resource %q: %v", ErrInvalidManifest, res.Identifier, err)
+	}
+	item.LaunchPath = withParameters(launch, strings.TrimSpace(it.Parameters))
+
+	if version == Version12 {
+		item.LaunchData = it.DataFromLMS
+		if s := strings.TrimSpace(it.MasteryScore); s != "" {
+			score, err := strconv.ParseFloat(s, 64)
+			if err != nil || score < 0 || score > 100 {
+				return item, fmt.Errorf("%w: item %q has an invalid masteryscore", ErrInvalidManifest, it.Identifier)
+			}
+			score /= 100
+			item.PassingScore = &score
+		}
+		return item, nil
+	}
+	item.LaunchData = it.LaunchData
+	threshold := strings.TrimSpace(it.CompletionThreshold.MinProgressMeasure)
+	if threshold == "" {
+		threshold = strings.TrimSpace(it.CompletionThreshold.Value)
+	}
+	if threshold != "" {
+		v, err := strconv.ParseFloat(threshold, 64)
+		if err != nil || v < 0 || v > 1 {
+			return item, fmt.Errorf("%w: item %q has an invalid completionThreshold", ErrInvalidManifest, it.Identifier)
+		}
+		item.CompletionThreshold = v
+	}
+	if po := it.PrimaryObjective; po.SatisfiedByMeasure == "true" {
+		score := 1.0
+		if s := strings.TrimSpace(po.MinNormalizedMeasure); s != "" {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil || v < -1 || v > 1 {
+				return item, fmt.Errorf("%w: item %q has an invalid minNormalizedMeasure", ErrInvalidManifest, it.Identifier)
+			}
+			score = v
+		}
+		item.PassingScore = &score
+	}
+	return item, nil
+}
+
+// resolveHref joins a resource's href to its xml:base and checks the result
+// stays inside the package.
+func resolveHref(base, href string) (string, error) {
+	p, query, _ := strings.Cut(base+href, "?")
+	if strings.Contains(p, "://") || strings.HasPrefix(p, "/") || strings.HasPrefix(p, "\\") {
+		return "", errors.New("launch path must be relative to the package")
+	}
+	clean, err := CleanPath(p)
+	if err != nil {
+		return "", err
+	}
+	if query != "" {
+		clean += "?" + query
+	}
+	return clean, nil
+}
+
+// withParameters appends an item's parameters to its launch path the way
+// the IMS content packaging specification describes.
+func withParameters(href, params string) string {
+	switch {
+	case params == "":
+		return href
+	case strings.HasPrefix(params, "#"):
+		return href + params
+	}
+	params = strings.TrimLeft(params, "?&")
+	if strings.Contains(href, "?") {
+		return href + "&" + params
+	}
+	return href + "?" + params
+}
+
+// CleanPath normalizes a path inside a package, rejecting ones that would
+// escape it.
+func CleanPath(p string) (string, error) {
+	p = strings.ReplaceAll(p, "\\", "/")
+	clean := path.Clean("/" + p)[1:]
+	if clean == "" || strings.HasPrefix(p, "/") || strings.Contains("/"+p+"/", "/../") {
+		return "", fmt.Errorf("invalid path %q", p)
+	}
+	return clean, nil
+}
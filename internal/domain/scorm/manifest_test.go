@@ -0,0 +1,128 @@
+package scorm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const manifest12 = `<?xml version="1.0"?>
+<manifest identifier="com.example.safety" version="1.0"
+    xmlns="http://www.imsproject.org/xsd/imscp_rootv1p1p2"
+    xmlns:adlcp="http://www.adlnet.org/xsd/adlcp_rootv1p2">
+  <metadata><schema>ADL SCORM</schema><schemaversion>1.2</schemaversion></metadata>
+  <organizations default="org">
+    <organization identifier="other"><title>Other</title><item identifier="x" identifierref="r1"><title>X</title></item></organization>
+    <organization identifier="org">
+      <title>Workplace Safety</title>
+      <item identifier="m1">
+        <title>Module 1</title>
+        <item identifier="i1" identifierref="r1" parameters="?lang=en">
+          <title>Introduction</title>
+          <adlcp:masteryscore>80</adlcp:masteryscore>
+          <adlcp:datafromlms>level=1</adlcp:datafromlms>
+        </item>
+        <item identifier="i2" identifierref="r2"><title>Glossary</title></item>
+      </item>
+    </organization>
+  </organizations>
+  <resources xml:base="content/">
+    <resource identifier="r1" type="webcontent" adlcp:scormtype="sco" href="intro/index.html"><file href="intro/index.html"/></resource>
+    <resource identifier="r2" type="webcontent" adlcp:scormtype="asset" xml:base="docs/" href="glossary.html?v=2"/>
+  </resources>
+</manifest>`
+
+const manifest2004 = `<?xml version="1.0"?>
+<manifest identifier="com.example.quiz"
+    xmlns="http://www.imsglobal.org/xsd/imscp_v1p1"
+    xmlns:adlcp="http://www.adlnet.org/xsd/adlcp_v1p3"
+    xmlns:imsss="http://www.imsglobal.org/xsd/imsss">
+  <metadata><schema>ADL SCORM</schema><schemaversion>2004 4th Edition</schemaversion></metadata>
+  <organizations default="org">
+    <organization identifier="org">
+      <title>Final Quiz</title>
+      <item identifier="q" identifierref="r">
+        <title>Quiz</title>
+        <adlcp:completionThreshold minProgressMeasure="0.75"/>
+        <imsss:sequencing>
+          <imsss:objectives>
+            <imsss:primaryObjective objectiveID="pass" satisfiedByMeasure="true">
+              <imsss:minNormalizedMeasure>0.6</imsss:minNormalizedMeasure>
+            </imsss:primaryObjective>
+          </imsss:objectives>
+        </imsss:sequencing>
+      </item>
+    </organization>
+  </organizations>
+  <resources>
+    <resource identifier="r" type="webcontent" adlcp:scormType="sco" href="quiz.html"/>
+  </resources>
+</manifest>`
+
+func TestParseManifest_SCORM12(t *testing.T) {
+	m, err := ParseManifest([]byte(manifest12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != Version12 || m.Title != "Workplace Safety" || len(m.Items) != 2 {
+		t.Fatalf("ParseManifest() = %+v", m)
+	}
+	intro, glossary := m.Items[0], m.Items[1]
+	if intro.LaunchPath != "content/intro/index.html?lang=en" || intro.Asset || intro.PassingScore == nil || *intro.PassingScore != 0.8 || intro.LaunchData != "level=1" {
+		t.Errorf("intro = %+v", intro)
+	}
+	if glossary.LaunchPath != "content/docs/glossary.html?v=2" || !glossary.Asset || glossary.PassingScore != nil {
+		t.Errorf("glossary = %+v", glossary)
+	}
+}
+
+func TestParseManifest_ZeroMasteryScore(t *testing.T) {
+	m, err := ParseManifest([]byte(strings.Replace(manifest12, "<adlcp:masteryscore>80<", "<adlcp:masteryscore>0<", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := m.Items[0].PassingScore; p == nil || *p != 0 {
+		t.Errorf("passing score = %v, want 0", p)
+	}
+}
+
+func TestParseManifest_SCORM2004(t *testing.T) {
+	m, err := ParseManifest([]byte(manifest2004))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != Version2004 || len(m.Items) != 1 {
+		t.Fatalf("ParseManifest() = %+v", m)
+	}
+	if q := m.Items[0]; q.Asset || q.CompletionThreshold != 0.75 || q.PassingScore == nil || *q.PassingScore != 0.6 || q.LaunchPath != "quiz.html" {
+		t.Errorf("quiz = %+v", q)
+	}
+}
+
+func TestParseManifest_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"not xml":          `nope`,
+		"no organization":  `<manifest><organizations/></manifest>`,
+		"unknown resource": `<manifest><organizations><organization><item identifier="a" identifierref="missing"/></organization></organizations></manifest>`,
+		"escapes package":  `<manifest><organizations><organization><item identifier="a" identifierref="r"/></organization></organizations><resources><resource identifier="r" href="../../etc/passwd"/></resources></manifest>`,
+		"external":         `<manifest><organizations><organization><item identifier="a" identifierref="r"/></organization></organizations><resources><resource identifier="r" href="https://example.com/x.html"/></resources></manifest>`,
+	}
+	for name, xml := range tests {
+		if _, err := ParseManifest([]byte(xml)); !errors.Is(err, ErrInvalidManifest) {
+			t.Errorf("%s: error = %v, want ErrInvalidManifest", name, err)
+		}
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	for in, want := range map[string]string{"a/./b.html": "a/b.html", "a\\b.js": "a/b.js"} {
+		if got, err := CleanPath(in); err != nil || got != want {
+			t.Errorf("CleanPath(%q) = %q, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"../x", "a/../../x", "/etc/passwd", "", "."} {
+		if _, err := CleanPath(in); err == nil {
+			t.Errorf("CleanPath(%q) should fail", in)
+		}
+	}
+}
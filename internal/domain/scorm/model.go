@@ -0,0 +1,78 @@
+package scorm
+
+// Version is the SCORM edition a package was written for.
+type Version string
+
+const (
+	Version12   Version = "1.2"
+	Version2004 Version = "2004"
+)
+
+// Package is an uploaded SCORM zip. Its files are stored under
+// ContentPrefix, and each of its SCOs became a module of the course.
+type Package struct {
+	ID            string
+	CourseID      string
+	Title         string
+	Identifier    string // the manifest's identifier
+	Version       Version
+	ContentPrefix string   // storage key prefix of the extracted files
+	Files         []string // paths of the extracted files, relative to the package root
+	UploadedBy    string   // user ID
+	CreatedAt     int64    // Unix timestamp
+}
+
+// SCO is a launchable item of a package: a sharable content object, which
+// talks to the runtime API, or an asset, which does not.
+type SCO struct {
+	ID                  string
+	PackageID           string
+	CourseID            string
+	ModuleID            string // the module created for it
+	Identifier          string // the item's identifier in the manifest
+	Title               string
+	LaunchPath          string   // relative to the package root, with the item's parameters
+	Asset               bool     // assets count as completed once launched
+	PassingScore        *float64 // scaled; nil when the manifest sets none
+	CompletionThreshold float64  // 2004 progress measure that completes the SCO; 0 when not set
+	LaunchData          string   // adlcp:datafromlms, passed to the SCO as cmi.launch_data
+	OrderIndex          int
+}
+
+// Attempt is a learner's runtime data for a SCO, kept across sessions. Values
+// are the strings the runtime API exchanges; "" means not set.
+type Attempt struct {
+	UserID  string
+	SCOID   string
+	Version Version
+
+	LessonStatus     string // 1.2 cmi.core.lesson_status
+	CompletionStatus string // 2004 cmi.completion_status
+	SuccessStatus    string // 2004 cmi.success_status
+	ScoreRaw         string
+	ScoreMin         string
+	ScoreMax         string
+	ScoreScaled      string // 2004 only
+	ProgressMeasure  string // 2004 only
+	Location         string
+	SuspendData      string
+	Entry            string // ab-initio, resume or ""
+	Exit             string // how the last session ended
+	SessionTime      string // of the current session, in the version's format
+	TotalSeconds     float64
+	Sessions         int
+	// Extra holds elements the portal stores without interpreting them, such
+	// as interactions, objectives, comments and learner preferences.
+	Extra map[string]string
+
+	UpdatedAt int64 // Unix timestamp
+}
+
+// Completed reports whether the attempt completes its module: the SCO
+// reported it completed or passed.
+func (a *Attempt) Completed() bool {
+	if a.Version == Version12 {
+		return a.LessonStatus == "completed" || a.LessonStatus == "passed"
+	}
+	return a.CompletionStatus == "completed" || a.SuccessStatus == "passed"
+}
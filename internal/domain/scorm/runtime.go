@@ -0,0 +1,669 @@
+package scorm
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Learner is who the runtime reports as the student (1.2) or learner (2004).
+type Learner struct {
+	ID   string
+	Name string
+}
+
+// Error is a runtime API error, with the code and message of the SCO's
+// SCORM version.
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("%d %s", e.Code, e.Message) }
+
+type errKind int
+
+const (
+	errNone errKind = iota
+	errGeneral
+	errUndefined
+	errReadOnly
+	errWriteOnly
+	errType
+	errRange
+	errNotInitialized
+	errDependency
+	errGetIndex
+	errSetIndex
+)
+
+type errorCode struct {
+	code int
+	msg  string
+}
+
+var errorCodes = map[Version]map[errKind]errorCode{
+	Version12: {
+		errGeneral:   {101, "General exception"},
+		errUndefined: {401, "Not implemented error"},
+		errReadOnly:  {403, "Element is read only"},
+		errWriteOnly: {404, "Element is write only"},
+		errType:      {405, "Incorrect data type"},
+		errRange:     {405, "Incorrect data type"},
+		errGetIndex:  {201, "Invalid argument error"},
+		errSetIndex:  {201, "Invalid argument error"},
+	},
+	Version2004: {
+		errGeneral:        {351, "General Set Failure"},
+		errUndefined:      {401, "Undefined Data Model Element"},
+		errReadOnly:       {404, "Data Model Element Is Read Only"},
+		errWriteOnly:      {405, "Data Model Element Is Write Only"},
+		errType:           {406, "Data Model Element Type Mismatch"},
+		errRange:          {407, "Data Model Element Value Out Of Range"},
+		errNotInitialized: {403, "Data Model Element Value Not Initialized"},
+		errDependency:     {408, "Data Model Dependency Not Established"},
+		errGetIndex:       {301, "General Get Failure"},
+		errSetIndex:       {351, "General Set Failure"},
+	},
+}
+
+// Limits on the uninterpreted elements of an attempt, so a SCO cannot grow
+// a learner's row without bound.
+const (
+	maxExtraEntries = 5000
+	maxExtraBytes   = 1 << 20
+)
+
+// Runtime evaluates a SCO's runtime API calls against a learner's attempt.
+// The browser-side API adapter forwards the values the SCO sets; the portal
+// validates them here and keeps the attempt.
+type Runtime struct {
+	SCO     *SCO
+	Learner Learner
+	Attempt *Attempt
+}
+
+// GetValue returns an element's value, as LMSGetValue / GetValue would.
+func (r *Runtime) GetValue(name string) (string, *Error) {
+	el, ok := elements[r.Attempt.Version][normalize(name)]
+	if !ok {
+		return "", r.fail(errUndefined)
+	}
+	if el.access == writeOnly {
+		return "", r.fail(errWriteOnly)
+	}
+	if k := r.checkIndexes(name, false); k != errNone {
+		return "", r.fail(k)
+	}
+	v := el.get(r, name)
+	if v == "" && !el.blank {
+		if el.def != "" {
+			return el.def, nil
+		}
+		if r.Attempt.Version == Version2004 {
+			return "", r.fail(errNotInitialized)
+		}
+	}
+	return v, nil
+}
+
+// SetValue validates and stores an element's value, as LMSSetValue /
+// SetValue would.
+func (r *Runtime) SetValue(name, value string) *Error {
+	el, ok := elements[r.Attempt.Version][normalize(name)]
+	if !ok {
+		return r.fail(errUndefined)
+	}
+	if el.access == readOnly {
+		return r.fail(errReadOnly)
+	}
+	if k := r.checkIndexes(name, true); k != errNone {
+		return r.fail(k)
+	}
+	if el.check != nil {
+		if k := el.check(value); k != errNone {
+			return r.fail(k)
+		}
+	}
+	if el.extra {
+		if k := r.checkExtra(name, value); k != errNone {
+			return r.fail(k)
+		}
+	}
+	if r.Attempt.Extra == nil {
+		r.Attempt.Extra = map[string]string{}
+	}
+	el.set(r, name, value)
+	r.growCollections(name)
+	return nil
+}
+
+// Values returns every element the SCO could read that has a value, which
+// the API adapter caches to answer GetValue calls in the browser.
+func (r *Runtime) Values() map[string]string {
+	out := make(map[string]string)
+	for name, el := range elements[r.Attempt.Version] {
+		if el.access == writeOnly || strings.Contains(name, ".n.") {
+			continue
+		}
+		if v, err := r.GetValue(name); err == nil && (v != "" || el.blank) {
+			out[name] = v
+		}
+	}
+	for name, v := range r.Attempt.Extra {
+		if el, ok := elements[r.Attempt.Version][normalize(name)]; ok && el.access != writeOnly {
+			out[name] = v
+		}
+	}
+	return out
+}
+
+// Begin starts a session. A learner resumes a suspended attempt; in 2004 a
+// session after one that ended normally starts a new attempt.
+func (a *Attempt) Begin() {
+	switch {
+	case a.Sessions == 0:
+		a.Entry = "ab-initio"
+	case a.Exit == "suspend":
+		a.Entry = "resume"
+	case a.Version == Version2004 && a.Exit != "":
+		*a = Attempt{UserID: a.UserID, SCOID: a.SCOID, Version: a.Version, Sessions: a.Sessions, Entry: "ab-initio"}
+	default:
+		a.Entry = ""
+	}
+	if a.Extra == nil {
+		a.Extra = map[string]string{}
+	}
+	a.Exit = ""
+	a.SessionTime = ""
+	a.Sessions++
+}
+
+// Commit applies what the portal derives from the SCO's values, like
+// passing or failing against the manifest's mastery score.
+func (r *Runtime) Commit() {
+	a, sco := r.Attempt, r.SCO
+	if a.Version == Version12 {
+		if raw, err := strconv.ParseFloat(a.ScoreRaw, 64); err == nil && sco.PassingScore != nil {
+			if raw >= *sco.PassingScore*100 {
+				a.LessonStatus = "passed"
+			} else {
+				a.LessonStatus = "failed"
+			}
+		}
+		return
+	}
+	if pm, err := strconv.ParseFloat(a.ProgressMeasure, 64); err == nil && sco.CompletionThreshold > 0 {
+		if pm >= sco.CompletionThreshold {
+			a.CompletionStatus = "completed"
+		} else {
+			a.CompletionStatus = "incomplete"
+		}
+	}
+	if scaled, err := strconv.ParseFloat(a.ScoreScaled, 64); err == nil && sco.PassingScore != nil {
+		if scaled >= *sco.PassingScore {
+			a.SuccessStatus = "passed"
+		} else {
+			a.SuccessStatus = "failed"
+		}
+	}
+}
+
+// Finish ends the session, as LMSFinish / Terminate would: the session time
+// is added to the total, and a 1.2 SCO that never reported a status is
+// taken as completed.
+func (r *Runtime) Finish() {
+	a := r.Attempt
+	if secs, ok := parseTime(a.Version, a.SessionTime); ok {
+		a.TotalSeconds += secs
+	}
+	a.SessionTime = ""
+	if a.Version == Version12 && (a.LessonStatus == "" || a.LessonStatus == "not attempted") {
+		a.LessonStatus = "completed"
+	}
+	r.Commit()
+}
+
+// Score is the attempt's score as a percentage: the scaled score, or the raw
+// score within its range, or a raw score that is already a percentage.
+func (a *Attempt) Score() (int, bool) {
+	pct := func(v float64) (int, bool) {
+		return int(math.Round(math.Max(0, math.Min(100, v)))), true
+	}
+	if scaled, err := strconv.ParseFloat(a.ScoreScaled, 64); err == nil {
+		return pct(scaled * 100)
+	}
+	raw, err := strconv.ParseFloat(a.ScoreRaw, 64)
+	if err != nil {
+		return 0, false
+	}
+	lo, errMin := strconv.ParseFloat(a.ScoreMin, 64)
+	hi, errMax := strconv.ParseFloat(a.ScoreMax, 64)
+	if errMin == nil && errMax == nil && hi > lo {
+		return pct((raw - lo) / (hi - lo) * 100)
+	}
+	if raw < 0 || raw > 100 {
+		return 0, false
+	}
+	return pct(raw)
+}
+
+func (r *Runtime) fail(k errKind) *Error {
+	c, ok := errorCodes[r.Attempt.Version][k]
+	if !ok {
+		c = errorCodes[r.Attempt.Version][errGeneral]
+	}
+	return &Error{Code: c.code, Message: c.msg}
+}
+
+// checkIndexes checks the indexes of a collection element against the
+// collections' counts. A set may append one record at the end; in 2004 a new
+// objective or interaction must be given its id first.
+func (r *Runtime) checkIndexes(name string, set bool) errKind {
+	segs := strings.Split(name, ".")
+	for i, s := range segs {
+		n, ok := index(s)
+		if !ok {
+			continue
+		}
+		count := r.count(strings.Join(segs[:i], "."))
+		switch {
+		case n < count:
+		case !set:
+			return errGetIndex
+		case n > count:
+			return errSetIndex
+		case r.Attempt.Version == Version2004 && i == 2 && segs[1] != "comments_from_learner" && strings.Join(segs[i+1:], ".") != "id":
+			return errDependency
+		}
+	}
+	return errNone
+}
+
+// growCollections counts a record appended by a set.
+func (r *Runtime) growCollections(name string) {
+	segs := strings.Split(name, ".")
+	for i, s := range segs {
+		if n, ok := index(s); ok {
+			path := strings.Join(segs[:i], ".")
+			if n == r.count(path) {
+				r.Attempt.Extra[path+"._count"] = strconv.Itoa(n + 1)
+			}
+		}
+	}
+}
+
+func (r *Runtime) count(path string) int {
+	n, _ := strconv.Atoi(r.Attempt.Extra[path+"._count"])
+	return n
+}
+
+func (r *Runtime) checkExtra(name, value string) errKind {
+	extra := r.Attempt.Extra
+	if _, ok := extra[name]; !ok && len(extra) >= maxExtraEntries {
+		return errGeneral
+	}
+	size := len(value) - len(extra[name])
+	for k, v := range extra {
+		size += len(k) + len(v)
+	}
+	if size > maxExtraBytes {
+		return errGeneral
+	}
+	return errNone
+}
+
+func index(s string) (int, bool) {
+	if s == "" || strings.Trim(s, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// normalize replaces collection indexes with n, the form elements are
+// defined in.
+func normalize(name string) string {
+	segs := strings.Split(name, ".")
+	for i, s := range segs {
+		if _, ok := index(s); ok {
+			segs[i] = "n"
+		}
+	}
+	return strings.Join(segs, ".")
+}
+
+type access int
+
+const (
+	readWrite access = iota
+	readOnly
+	writeOnly
+)
+
+type element struct {
+	access access
+	get    func(r *Runtime, name string) string
+	set    func(r *Runtime, name, value string)
+	check  func(value string) errKind
+	def    string // returned when the value is not set
+	blank  bool   // "" is a value rather than an unset element
+	extra  bool   // stored in Attempt.Extra
+}
+
+func attr(acc access, field func(a *Attempt) *string, check func(string) errKind) element {
+	return element{
+		access: acc,
+		get:    func(r *Runtime, _ string) string { return *field(r.Attempt) },
+		set:    func(r *Runtime, _, v string) { *field(r.Attempt) = v },
+		check:  check,
+	}
+}
+
+func extra(acc access, check func(string) errKind) element {
+	return element{
+		access: acc,
+		get:    func(r *Runtime, name string) string { return r.Attempt.Extra[name] },
+		set:    func(r *Runtime, name, v string) { r.Attempt.Extra[name] = v },
+		check:  check,
+		extra:  true,
+	}
+}
+
+func computed(get func(r *Runtime) string) element {
+	return element{access: readOnly, get: func(r *Runtime, _ string) string { return get(r) }}
+}
+
+func fixed(v string) element {
+	return element{access: readOnly, get: func(*Runtime, string) string { return v }, blank: true}
+}
+
+func counter() element {
+	return element{access: readOnly, get: func(r *Runtime, name string) string { return r.Attempt.Extra[name] }, def: "0"}
+}
+
+func withDefault(el element, def string) element {
+	el.def = def
+	return el
+}
+
+func str(max int) func(string) errKind {
+	return func(v string) errKind {
+		if len(v) > max {
+			return errType
+		}
+		return errNone
+	}
+}
+
+func vocab(values ...string) func(string) errKind {
+	return func(v string) errKind {
+		for _, w := range values {
+			if v == w {
+				return errNone
+			}
+		}
+		return errType
+	}
+}
+
+func decimal(lo, hi float64, blank bool) func(string) errKind {
+	return func(v string) errKind {
+		if v == "" && blank {
+			return errNone
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return errType
+		}
+		if f < lo || f > hi {
+			return errRange
+		}
+		return errNone
+	}
+}
+
+func either(a, b func(string) errKind) func(string) errKind {
+	return func(v string) errKind {
+		if a(v) == errNone {
+			return errNone
+		}
+		return b(v)
+	}
+}
+
+var (
+	timespan12   = regexp.MustCompile(`^(\d{2,4}):(\d{2}):(\d{2}(?:\.\d{1,2})?)$`)
+	duration2004 = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d{1,2})?)S)?)?$`)
+)
+
+func timeFormat(version Version) func(string) errKind {
+	return func(v string) errKind {
+		if _, ok := parseTime(version, v); !ok {
+			return errType
+		}
+		return errNone
+	}
+}
+
+// parseTime reads a session time: HHHH:MM:SS.SS in 1.2, an ISO 8601
+// duration in 2004.
+func parseTime(version Version, v string) (float64, bool) {
+	num := func(s string) float64 {
+		f, _ := strconv.ParseFloat(s, 64)
+		return f
+	}
+	if version == Version12 {
+		m := timespan12.FindStringSubmatch(v)
+		if m == nil || num(m[2]) > 59 || num(m[3]) >= 60 {
+			return 0, false
+		}
+		return num(m[1])*3600 + num(m[2])*60 + num(m[3]), true
+	}
+	m := duration2004.FindStringSubmatch(v)
+	if m == nil || v == "P" || strings.HasSuffix(v, "T") {
+		return 0, false
+	}
+	days := num(m[1])*365 + num(m[2])*30 + num(m[3])
+	return days*86400 + num(m[4])*3600 + num(m[5])*60 + num(m[6]), true
+}
+
+// formatTime writes a total time in the version's format.
+func formatTime(version Version, secs float64) string {
+	cs := int64(math.Round(secs * 100))
+	h, m, s := cs/360000, cs/6000%60, float64(cs%6000)/100
+	if version == Version12 {
+		return fmt.Sprintf("%04d:%02d:%05.2f", h, m, s)
+	}
+	return fmt.Sprintf("PT%dH%dM%sS", h, m, strconv.FormatFloat(s, 'f', -1, 64))
+}
+
+func totalTime(r *Runtime) string {
+	return formatTime(r.Attempt.Version, r.Attempt.TotalSeconds)
+}
+
+var elements = map[Version]map[string]element{
+	Version12:   elements12(),
+	Version2004: elements2004(),
+}
+
+func elements12() map[string]element {
+	status := vocab("passed", "completed", "failed", "incomplete", "browsed")
+	score := decimal(0, 100, true)
+	return map[string]element{
+		"cmi._version":       fixed("3.4"),
+		"cmi.core._children": fixed("student_id,student_name,lesson_location,credit,lesson_status,entry,score,total_time,lesson_mode,exit,session_time"),
+		"cmi.core.student_id": computed(func(r *Runtime) string {
+			return r.Learner.ID
+		}),
+		"cmi.core.student_name": computed(func(r *Runtime) string {
+			return r.Learner.Name
+		}),
+		"cmi.core.lesson_location": attr(readWrite, func(a *Attempt) *string { return &a.Location }, str(255)),
+		"cmi.core.credit":          fixed("credit"),
+		"cmi.core.lesson_status": withDefault(attr(readWrite, func(a *Attempt) *string {
+			return &a.LessonStatus
+		}, status), "not attempted"),
+		"cmi.core.entry":             attr(readOnly, func(a *Attempt) *string { return &a.Entry }, nil),
+		"cmi.core.score._children":   fixed("raw,min,max"),
+		"cmi.core.score.raw":         attr(readWrite, func(a *Attempt) *string { return &a.ScoreRaw }, score),
+		"cmi.core.score.min":         attr(readWrite, func(a *Attempt) *string { return &a.ScoreMin }, score),
+		"cmi.core.score.max":         attr(readWrite, func(a *Attempt) *string { return &a.ScoreMax }, score),
+		"cmi.core.total_time":        computed(totalTime),
+		"cmi.core.lesson_mode":       fixed("normal"),
+		"cmi.core.exit":              attr(writeOnly, func(a *Attempt) *string { return &a.Exit }, vocab("time-out", "suspend", "logout", "")),
+		"cmi.core.session_time":      attr(writeOnly, func(a *Attempt) *string { return &a.SessionTime }, timeFormat(Version12)),
+		"cmi.suspend_data":           attr(readWrite, func(a *Attempt) *string { return &a.SuspendData }, str(4096)),
+		"cmi.launch_data":            computed(func(r *Runtime) string { return r.SCO.LaunchData }),
+		"cmi.comments":               extra(readWrite, str(4096)),
+		"cmi.comments_from_lms":      fixed(""),
+		"cmi.student_data._children": fixed("mastery_score,max_time_allowed,time_limit_action"),
+		"cmi.student_data.mastery_score": computed(func(r *Runtime) string {
+			if r.SCO.PassingScore == nil {
+				return ""
+			}
+			return strconv.FormatFloat(*r.SCO.PassingScore*100, 'f', -1, 64)
+		}),
+		"cmi.student_data.max_time_allowed":  fixed(""),
+		"cmi.student_data.time_limit_action": fixed(""),
+		"cmi.student_preference._children":   fixed("audio,language,speed,text"),
+		"cmi.student_preference.audio":       extra(readWrite, decimal(-1, 100, false)),
+		"cmi.student_preference.language":    extra(readWrite, str(255)),
+		"cmi.student_preference.speed":       extra(readWrite, decimal(-100, 100, false)),
+		"cmi.student_preference.text":        extra(readWrite, decimal(-1, 1, false)),
+
+		"cmi.objectives._children":         fixed("id,score,status"),
+		"cmi.objectives._count":            counter(),
+		"cmi.objectives.n.id":              extra(readWrite, str(255)),
+		"cmi.objectives.n.score._children": fixed("raw,min,max"),
+		"cmi.objectives.n.score.raw":       extra(readWrite, score),
+		"cmi.objectives.n.score.min":       extra(readWrite, score),
+		"cmi.objectives.n.score.max":       extra(readWrite, score),
+		"cmi.objectives.n.status":          extra(readWrite, vocab("passed", "completed", "failed", "incomplete", "browsed", "not attempted")),
+
+		"cmi.interactions._children":                     fixed("id,objectives,time,type,correct_responses,weighting,student_response,result,latency"),
+		"cmi.interactions._count":                        counter(),
+		"cmi.interactions.n.id":                          extra(writeOnly, str(255)),
+		"cmi.interactions.n.objectives._count":           counter(),
+		"cmi.interactions.n.objectives.n.id":             extra(writeOnly, str(255)),
+		"cmi.interactions.n.time":                        extra(writeOnly, str(255)),
+		"cmi.interactions.n.type":                        extra(writeOnly, vocab("true-false", "choice", "fill-in", "matching", "performance", "sequencing", "likert", "numeric")),
+		"cmi.interactions.n.correct_responses._count":    counter(),
+		"cmi.interactions.n.correct_responses.n.pattern": extra(writeOnly, str(255)),
+		"cmi.interactions.n.weighting":                   extra(writeOnly, decimal(math.Inf(-1), math.Inf(1), false)),
+		"cmi.interactions.n.student_response":            extra(writeOnly, str(255)),
+		"cmi.interactions.n.result":                      extra(writeOnly, either(vocab("correct", "wrong", "unanticipated", "neutral"), decimal(math.Inf(-1), math.Inf(1), false))),
+		"cmi.interactions.n.latency":                     extra(writeOnly, timeFormat(Version12)),
+	}
+}
+
+func elements2004() map[string]element {
+	number := decimal(math.Inf(-1), math.Inf(1), false)
+	text := str(4000)
+	completion := vocab("completed", "incomplete", "not attempted", "unknown")
+	success := vocab("passed", "failed", "unknown")
+	entry := attr(readOnly, func(a *Attempt) *string { return &a.Entry }, nil)
+	entry.blank = true
+	language := extra(readWrite, str(250))
+	language.blank = true
+	navigation := func(v string) errKind {
+		if strings.HasPrefix(v, "{target=") {
+			return errNone
+		}
+		return vocab("continue", "previous", "exit", "exitAll", "abandon", "abandonAll", "suspendAll", "_none_")(v)
+	}
+	return map[string]element{
+		"cmi._version": fixed("1.0"),
+		"cmi.learner_id": computed(func(r *Runtime) string {
+			return r.Learner.ID
+		}),
+		"cmi.learner_name": computed(func(r *Runtime) string {
+			return r.Learner.Name
+		}),
+		"cmi.location": attr(readWrite, func(a *Attempt) *string { return &a.Location }, str(1000)),
+		"cmi.credit":   fixed("credit"),
+		"cmi.mode":     fixed("normal"),
+		"cmi.entry":    entry,
+		"cmi.completion_status": withDefault(attr(readWrite, func(a *Attempt) *string {
+			return &a.CompletionStatus
+		}, completion), "unknown"),
+		"cmi.success_status": withDefault(attr(readWrite, func(a *Attempt) *string {
+			return &a.SuccessStatus
+		}, success), "unknown"),
+		"cmi.score._children": fixed("scaled,raw,min,max"),
+		"cmi.score.scaled":    attr(readWrite, func(a *Attempt) *string { return &a.ScoreScaled }, decimal(-1, 1, false)),
+		"cmi.score.raw":       attr(readWrite, func(a *Attempt) *string { return &a.ScoreRaw }, number),
+		"cmi.score.min":       attr(readWrite, func(a *Attempt) *string { return &a.ScoreMin }, number),
+		"cmi.score.max":       attr(readWrite, func(a *Attempt) *string { return &a.ScoreMax }, number),
+		"cmi.progress_measure": attr(readWrite, func(a *Attempt) *string {
+			return &a.ProgressMeasure
+		}, decimal(0, 1, false)),
+		"cmi.completion_threshold": computed(func(r *Runtime) string {
+			if r.SCO.CompletionThreshold == 0 {
+				return ""
+			}
+			return strconv.FormatFloat(r.SCO.CompletionThreshold, 'f', -1, 64)
+		}),
+		"cmi.scaled_passing_score": computed(func(r *Runtime) string {
+			if r.SCO.PassingScore == nil {
+				return ""
+			}
+			return strconv.FormatFloat(*r.SCO.PassingScore, 'f', -1, 64)
+		}),
+		"cmi.total_time":        computed(totalTime),
+		"cmi.session_time":      attr(writeOnly, func(a *Attempt) *string { return &a.SessionTime }, timeFormat(Version2004)),
+		"cmi.exit":              attr(writeOnly, func(a *Attempt) *string { return &a.Exit }, vocab("time-out", "suspend", "logout", "normal", "")),
+		"cmi.suspend_data":      attr(readWrite, func(a *Attempt) *string { return &a.SuspendData }, str(64000)),
+		"cmi.launch_data":       computed(func(r *Runtime) string { return r.SCO.LaunchData }),
+		"cmi.max_time_allowed":  computed(func(*Runtime) string { return "" }),
+		"cmi.time_limit_action": fixed("continue,no message"),
+
+		"cmi.learner_preference._children":        fixed("audio_level,language,delivery_speed,audio_captioning"),
+		"cmi.learner_preference.audio_level":      withDefault(extra(readWrite, decimal(0, math.Inf(1), false)), "1"),
+		"cmi.learner_preference.language":         language,
+		"cmi.learner_preference.delivery_speed":   withDefault(extra(readWrite, decimal(0, math.Inf(1), false)), "1"),
+		"cmi.learner_preference.audio_captioning": withDefault(extra(readWrite, vocab("-1", "0", "1")), "0"),
+
+		"cmi.comments_from_learner._children":   fixed("comment,location,timestamp"),
+		"cmi.comments_from_learner._count":      counter(),
+		"cmi.comments_from_learner.n.comment":   extra(readWrite, text),
+		"cmi.comments_from_learner.n.location":  extra(readWrite, str(250)),
+		"cmi.comments_from_learner.n.timestamp": extra(readWrite, str(64)),
+		"cmi.comments_from_lms._children":       fixed("comment,location,timestamp"),
+		"cmi.comments_from_lms._count":          counter(),
+
+		"cmi.objectives._children":           fixed("id,score,success_status,completion_status,progress_measure,description"),
+		"cmi.objectives._count":              counter(),
+		"cmi.objectives.n.id":                extra(readWrite, text),
+		"cmi.objectives.n.score._children":   fixed("scaled,raw,min,max"),
+		"cmi.objectives.n.score.scaled":      extra(readWrite, decimal(-1, 1, false)),
+		"cmi.objectives.n.score.raw":         extra(readWrite, number),
+		"cmi.objectives.n.score.min":         extra(readWrite, number),
+		"cmi.objectives.n.score.max":         extra(readWrite, number),
+		"cmi.objectives.n.success_status":    withDefault(extra(readWrite, success), "unknown"),
+		"cmi.objectives.n.completion_status": withDefault(extra(readWrite, completion), "unknown"),
+		"cmi.objectives.n.progress_measure":  extra(readWrite, decimal(0, 1, false)),
+		"cmi.objectives.n.description":       extra(readWrite, str(250)),
+
+		"cmi.interactions._children":                     fixed("id,type,objectives,timestamp,correct_responses,weighting,learner_response,result,latency,description"),
+		"cmi.interactions._count":                        counter(),
+		"cmi.interactions.n.id":                          extra(readWrite, text),
+		"cmi.interactions.n.type":                        extra(readWrite, vocab("true-false", "choice", "fill-in", "long-fill-in", "matching", "performance", "sequencing", "likert", "numeric", "other")),
+		"cmi.interactions.n.objectives._count":           counter(),
+		"cmi.interactions.n.objectives.n.id":             extra(readWrite, text),
+		"cmi.interactions.n.timestamp":                   extra(readWrite, str(64)),
+		"cmi.interactions.n.correct_responses._count":    counter(),
+		"cmi.interactions.n.correct_responses.n.pattern": extra(readWrite, text),
+		"cmi.interactions.n.weighting":                   extra(readWrite, number),
+		"cmi.interactions.n.learner_response":            extra(readWrite, text),
+		"cmi.interactions.n.result":                      extra(readWrite, either(vocab("correct", "incorrect", "unanticipated", "neutral"), number)),
+		"cmi.interactions.n.latency":                     extra(readWrite, timeFormat(Version2004)),
+		"cmi.interactions.n.description":                 extra(readWrite, str(250)),
+
+		"adl.nav.request":                withDefault(extra(readWrite, navigation), "_none_"),
+		"adl.nav.request_valid.continue": fixed("unknown"),
+		"adl.nav.request_valid.previous": fixed("unknown"),
+	}
+}
@@ -0,0 +1,188 @@
+package scorm
+
+import "testing"
+
+func score(v float64) *float64 { return &v }
+
+func newRuntime(version Version, sco *SCO) *Runtime {
+	a := &Attempt{UserID: "u1", SCOID: "s1", Version: version}
+	a.Begin()
+	return &Runtime{SCO: sco, Learner: Learner{ID: "u1", Name: "Ann Lee"}, Attempt: a}
+}
+
+func TestRuntime_SCORM12(t *testing.T) {
+	r := newRuntime(Version12, &SCO{PassingScore: score(0.8)})
+	if v, _ := r.GetValue("cmi.core.lesson_status"); v != "not attempted" {
+		t.Errorf("initial lesson_status = %q", v)
+	}
+	if v, _ := r.GetValue("cmi.core.entry"); v != "ab-initio" {
+		t.Errorf("entry = %q", v)
+	}
+	if v, _ := r.GetValue("cmi.student_data.mastery_score"); v != "80" {
+		t.Errorf("mastery_score = %q", v)
+	}
+
+	errs := map[string]int{}
+	for _, kv := range [][2]string{
+		{"cmi.core.lesson_status", "incomplete"},
+		{"cmi.core.lesson_status", "not attempted"},
+		{"cmi.core.score.raw", "85"},
+		{"cmi.core.score.raw", "120"},
+		{"cmi.core.student_id", "x"},
+		{"cmi.core.session_time", "00:10:30.5"},
+		{"cmi.core.session_time", "10 minutes"},
+		{"cmi.suspend_data", "page=3"},
+		{"cmi.core.exit", "suspend"},
+		{"cmi.interactions.0.id", "q1"},
+		{"cmi.interactions.2.id", "q3"},
+		{"cmi.unknown", "1"},
+	} {
+		if err := r.SetValue(kv[0], kv[1]); err != nil {
+			errs[kv[0]+"="+kv[1]] = err.Code
+		}
+	}
+	want := map[string]int{
+		"cmi.core.lesson_status=not attempted": 405,
+		"cmi.core.score.raw=120":               405,
+		"cmi.core.student_id=x":                403,
+		"cmi.core.session_time=10 minutes":     405,
+		"cmi.interactions.2.id=q3":             201,
+		"cmi.unknown=1":                        401,
+	}
+	if len(errs) != len(want) {
+		t.Errorf("errors = %v, want %v", errs, want)
+	}
+	for k, code := range want {
+		if errs[k] != code {
+			t.Errorf("%s: code = %d, want %d", k, errs[k], code)
+		}
+	}
+	if _, err := r.GetValue("cmi.interactions.0.id"); err == nil || err.Code != 404 {
+		t.Errorf("reading a 1.2 interaction = %v, want write only", err)
+	}
+	if v, _ := r.GetValue("cmi.interactions._count"); v != "1" {
+		t.Errorf("interactions._count = %q", v)
+	}
+
+	r.Finish()
+	if r.Attempt.LessonStatus != "passed" || r.Attempt.TotalSeconds != 630.5 {
+		t.Errorf("after finish: status %q, total %v", r.Attempt.LessonStatus, r.Attempt.TotalSeconds)
+	}
+	if v, _ := r.GetValue("cmi.core.total_time"); v != "0000:10:30.50" {
+		t.Errorf("total_time = %q", v)
+	}
+	if score, ok := r.Attempt.Score(); !ok || score != 85 || !r.Attempt.Completed() {
+		t.Errorf("Score() = %d, %v; Completed() = %v", score, ok, r.Attempt.Completed())
+	}
+
+	r.Attempt.Begin()
+	if r.Attempt.Entry != "resume" || r.Attempt.SuspendData != "page=3" {
+		t.Errorf("resumed attempt = %+v", r.Attempt)
+	}
+}
+
+func TestRuntime_SCORM12DefaultsToCompleted(t *testing.T) {
+	r := newRuntime(Version12, &SCO{})
+	r.Finish()
+	if r.Attempt.LessonStatus != "completed" {
+		t.Errorf("lesson_status = %q, want completed", r.Attempt.LessonStatus)
+	}
+}
+
+func TestRuntime_PassingScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		version Version
+		passing *float64
+		score   string
+		want    string
+	}{
+		{"1.2 without a mastery score", Version12, nil, "0", "incomplete"},
+		{"1.2 mastery score of 0", Version12, score(0), "0", "passed"},
+		{"1.2 below the mastery score", Version12, score(0.5), "40", "failed"},
+		{"2004 without a passing score", Version2004, nil, "0", "unknown"},
+		{"2004 passing score of 0", Version2004, score(0), "0", "passed"},
+		{"2004 negative passing score", Version2004, score(-0.5), "-0.2", "passed"},
+		{"2004 below a negative passing score", Version2004, score(-0.5), "-0.8", "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRuntime(tt.version, &SCO{PassingScore: tt.passing})
+			status, element := "cmi.core.lesson_status", "cmi.core.score.raw"
+			if tt.version == Version2004 {
+				status, element = "cmi.success_status", "cmi.score.scaled"
+			} else if err := r.SetValue(status, "incomplete"); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.SetValue(element, tt.score); err != nil {
+				t.Fatal(err)
+			}
+			r.Commit()
+			if v, _ := r.GetValue(status); v != tt.want {
+				t.Errorf("%s = %q, want %q", status, v, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntime_SCORM2004(t *testing.T) {
+	r := newRuntime(Version2004, &SCO{PassingScore: score(0.7), CompletionThreshold: 0.5})
+	if _, err := r.GetValue("cmi.location"); err == nil || err.Code != 403 {
+		t.Errorf("unset location: %v, want not initialized", err)
+	}
+	if v, _ := r.GetValue("cmi.completion_status"); v != "unknown" {
+		t.Errorf("completion_status = %q", v)
+	}
+	if err := r.SetValue("cmi.objectives.0.score.scaled", "0.5"); err == nil || err.Code != 408 {
+		t.Errorf("objective without id: %v, want dependency error", err)
+	}
+	for _, kv := range [][2]string{
+		{"cmi.objectives.0.id", "obj-1"},
+		{"cmi.objectives.0.score.scaled", "0.5"},
+		{"cmi.progress_measure", "0.6"},
+		{"cmi.score.scaled", "0.65"},
+		{"cmi.session_time", "PT1H2M3.5S"},
+		{"cmi.exit", "normal"},
+	} {
+		if err := r.SetValue(kv[0], kv[1]); err != nil {
+			t.Errorf("SetValue(%s, %s) = %v", kv[0], kv[1], err)
+		}
+	}
+	if err := r.SetValue("cmi.score.scaled", "2"); err == nil || err.Code != 407 {
+		t.Errorf("scaled out of range: %v", err)
+	}
+	r.Finish()
+	if a := r.Attempt; a.CompletionStatus != "completed" || a.SuccessStatus != "failed" || a.TotalSeconds != 3723.5 {
+		t.Errorf("after finish: %+v", a)
+	}
+	if v, _ := r.GetValue("cmi.total_time"); v != "PT1H2M3.5S" {
+		t.Errorf("total_time = %q", v)
+	}
+	if values := r.Values(); values["cmi.objectives.0.id"] != "obj-1" || values["cmi.objectives._count"] != "1" {
+		t.Errorf("Values() = %v", values)
+	}
+
+	r.Attempt.Begin()
+	if a := r.Attempt; a.Entry != "ab-initio" || a.CompletionStatus != "" || len(a.Extra) != 0 || a.Sessions != 2 {
+		t.Errorf("new attempt after a normal exit = %+v", a)
+	}
+}
+
+func TestAttempt_Score(t *testing.T) {
+	tests := []struct {
+		a    Attempt
+		want int
+		ok   bool
+	}{
+		{Attempt{ScoreScaled: "0.756"}, 76, true},
+		{Attempt{ScoreRaw: "15", ScoreMin: "0", ScoreMax: "20"}, 75, true},
+		{Attempt{ScoreRaw: "64"}, 64, true},
+		{Attempt{ScoreRaw: "640"}, 0, false},
+		{Attempt{}, 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := tt.a.Score(); got != tt.want || ok != tt.ok {
+			t.Errorf("Score(%+v) = %d, %v; want %d, %v", tt.a, got, ok, tt.want, tt.ok)
+		}
+	}
+}
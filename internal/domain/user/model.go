@@ -9,12 +9,15 @@ const (
     RoleEmployee Role = "employee"
     RoleAdmin    Role = "admin"
     RoleTrainer  Role = "trainer"
+    RoleManager  Role = "manager"
 )
 
 type User struct {
-    ID       string // UUID
-    Name     string
-    Email    string
-    Password string // hashed password
-    Role     Role
+    ID         string // UUID
+    Name       string
+    Email      string
+    Password   string // hashed password
+    Role       Role
+    Department string // organisational group, e.g. "Sales"
+    ManagerID  string // user ID of the line manager, optional
 }
@@ -0,0 +1,394 @@
+package xapi
+
+// Experience API (xAPI) 1.0.3 statements. Field names follow the xAPI
+// specification, so these types carry JSON tags unlike the rest of the domain.
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Version is the xAPI version the portal implements.
+const Version = "1.0.3"
+
+// Verbs the portal emits or interprets.
+const (
+	VerbRegistered = "http://adlnet.gov/expapi/verbs/registered"
+	VerbCompleted  = "http://adlnet.gov/expapi/verbs/completed"
+	VerbPassed     = "http://adlnet.gov/expapi/verbs/passed"
+	VerbFailed     = "http://adlnet.gov/expapi/verbs/failed"
+	VerbEarned     = "http://id.tincanapi.com/verb/earned"
+	VerbVoided     = "http://adlnet.gov/expapi/verbs/voided"
+)
+
+// Activity types of the portal's activities.
+const (
+	ActivityCourse     = "http://adlnet.gov/expapi/activities/course"
+	ActivityModule     = "http://adlnet.gov/expapi/activities/module"
+	ActivityAssessment = "http://adlnet.gov/expapi/activities/assessment"
+)
+
+// Object types.
+const (
+	ObjectActivity     = "Activity"
+	ObjectAgent        = "Agent"
+	ObjectGroup        = "Group"
+	ObjectStatementRef = "StatementRef"
+	ObjectSubStatement = "SubStatement"
+)
+
+// LanguageMap maps RFC 5646 language tags to text.
+type LanguageMap map[string]string
+
+// Statement is an xAPI statement: an actor did something (the verb) to an
+// object.
+type Statement struct {
+	ID          string       `json:"id,omitempty"` // UUID
+	Actor       *Agent       `json:"actor"`
+	Verb        *Verb        `json:"verb"`
+	Object      *Object      `json:"object"`
+	Result      *Result      `json:"result,omitempty"`
+	Context     *Context     `json:"context,omitempty"`
+	Timestamp   string       `json:"timestamp,omitempty"` // ISO 8601; set to Stored when missing
+	Stored      string       `json:"stored,omitempty"`    // ISO 8601; set by the LRS
+	Authority   *Agent       `json:"authority,omitempty"` // set by the LRS
+	Version     string       `json:"version,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Agent is an Agent or, with ObjectType "Group", a Group. An Agent is
+// identified by exactly one of Mbox, MboxSHA1Sum, OpenID and Account; a Group
+// by at most one, and an anonymous Group by its members.
+type Agent struct {
+	ObjectType  string   `json:"objectType,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Mbox        string   `json:"mbox,omitempty"` // mailto:address
+	MboxSHA1Sum string   `json:"mbox_sha1sum,omitempty"`
+	OpenID      string   `json:"openid,omitempty"`
+	Account     *Account `json:"account,omitempty"`
+	Member      []*Agent `json:"member,omitempty"`
+}
+
+// Account is an account on a system, such as the portal itself.
+type Account struct {
+	HomePage string `json:"homePage"`
+	Name     string `json:"name"`
+}
+
+// Verb is what the actor did.
+type Verb struct {
+	ID      string      `json:"id"`
+	Display LanguageMap `json:"display,omitempty"`
+}
+
+// Object is what the statement is about: an Activity (the default), an Agent
+// or Group, a StatementRef or a SubStatement. Only the fields of its type are
+// set.
+type Object struct {
+	ObjectType string `json:"objectType,omitempty"`
+
+	// Activity or StatementRef
+	ID         string              `json:"id,omitempty"`
+	Definition *ActivityDefinition `json:"definition,omitempty"`
+
+	// Agent or Group
+	Name        string   `json:"name,omitempty"`
+	Mbox        string   `json:"mbox,omitempty"`
+	MboxSHA1Sum string   `json:"mbox_sha1sum,omitempty"`
+	OpenID      string   `json:"openid,omitempty"`
+	Account     *Account `json:"account,omitempty"`
+	Member      []*Agent `json:"member,omitempty"`
+
+	// SubStatement
+	Actor       *Agent       `json:"actor,omitempty"`
+	Verb        *Verb        `json:"verb,omitempty"`
+	Object      *Object      `json:"object,omitempty"`
+	Result      *Result      `json:"result,omitempty"`
+	Context     *Context     `json:"context,omitempty"`
+	Timestamp   string       `json:"timestamp,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Type returns the object's type, defaulting to Activity.
+func (o *Object) Type() string {
+	if o.ObjectType == "" {
+		return ObjectActivity
+	}
+	return o.ObjectType
+}
+
+// Agent returns an Agent or Group object as an Agent, or nil for other types.
+func (o *Object) Agent() *Agent {
+	if t := o.Type(); t != ObjectAgent && t != ObjectGroup {
+		return nil
+	}
+	return &Agent{ObjectType: o.ObjectType, Name: o.Name, Mbox: o.Mbox, MboxSHA1Sum: o.MboxSHA1Sum,
+		OpenID: o.OpenID, Account: o.Account, Member: o.Member}
+}
+
+// ActivityDefinition describes an activity. The interaction fields are used
+// by assessment content such as cmi.interaction activities.
+type ActivityDefinition struct {
+	Name        LanguageMap            `json:"name,omitempty"`
+	Description LanguageMap            `json:"description,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	MoreInfo    string                 `json:"moreInfo,omitempty"`
+	Extensions  map[string]interface{} `json:"extensions,omitempty"`
+
+	InteractionType         string                 `json:"interactionType,omitempty"`
+	CorrectResponsesPattern []string               `json:"correctResponsesPattern,omitempty"`
+	Choices                 []InteractionComponent `json:"choices,omitempty"`
+	Scale                   []InteractionComponent `json:"scale,omitempty"`
+	Source                  []InteractionComponent `json:"source,omitempty"`
+	Target                  []InteractionComponent `json:"target,omitempty"`
+	Steps                   []InteractionComponent `json:"steps,omitempty"`
+}
+
+// InteractionComponent is a choice, scale point, source, target or step of
+// an interaction.
+type InteractionComponent struct {
+	ID          string      `json:"id"`
+	Description LanguageMap `json:"description,omitempty"`
+}
+
+// Result is the outcome of the statement.
+type Result struct {
+	Score      *Score                 `json:"score,omitempty"`
+	Success    *bool                  `json:"success,omitempty"`
+	Completion *bool                  `json:"completion,omitempty"`
+	Response   string                 `json:"response,omitempty"`
+	Duration   string                 `json:"duration,omitempty"` // ISO 8601 duration, e.g. PT1H30M
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Score is a result's score. Scaled runs from -1 to 1.
+type Score struct {
+	Scaled *float64 `json:"scaled,omitempty"`
+	Raw    *float64 `json:"raw,omitempty"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+}
+
+// Context places the statement in a wider picture, e.g. the course a module
+// belongs to.
+type Context struct {
+	Registration      string                 `json:"registration,omitempty"` // UUID of the attempt or enrollment
+	Instructor        *Agent                 `json:"instructor,omitempty"`
+	Team              *Agent                 `json:"team,omitempty"`
+	ContextActivities *ContextActivities     `json:"contextActivities,omitempty"`
+	Revision          string                 `json:"revision,omitempty"`
+	Platform          string                 `json:"platform,omitempty"`
+	Language          string                 `json:"language,omitempty"`
+	Statement         *StatementRef          `json:"statement,omitempty"`
+	Extensions        map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// ContextActivities relate the statement to other activities.
+type ContextActivities struct {
+	Parent   ActivityList `json:"parent,omitempty"`
+	Grouping ActivityList `json:"grouping,omitempty"`
+	Category ActivityList `json:"category,omitempty"`
+	Other    ActivityList `json:"other,omitempty"`
+}
+
+// All returns every context activity.
+func (c *ContextActivities) All() []*Object {
+	var all []*Object
+	for _, list := range []ActivityList{c.Parent, c.Grouping, c.Category, c.Other} {
+		all = append(all, list...)
+	}
+	return all
+}
+
+// ActivityList is a list of activities. Older clients send a single activity
+// instead of a list, which is accepted too.
+type ActivityList []*Object
+
+func (l *ActivityList) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var one Object
+		if err := decodeStrict(data, &one); err != nil {
+			return err
+		}
+		*l = ActivityList{&one}
+		return nil
+	}
+	var list []*Object
+	if err := decodeStrict(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// StatementRef refers to another statement by ID.
+type StatementRef struct {
+	ObjectType string `json:"objectType"` // StatementRef
+	ID         string `json:"id"`
+}
+
+// Attachment describes a file attached to a statement. The portal only
+// accepts attachments hosted elsewhere, referenced by FileURL.
+type Attachment struct {
+	UsageType   string      `json:"usageType"`
+	Display     LanguageMap `json:"display"`
+	Description LanguageMap `json:"description,omitempty"`
+	ContentType string      `json:"contentType"`
+	Length      int64       `json:"length"`
+	SHA2        string      `json:"sha2"`
+	FileURL     string      `json:"fileUrl,omitempty"`
+}
+
+// StatementResult is a page of a statement query. More is the URL of the
+// next page, or empty on the last one.
+type StatementResult struct {
+	Statements []*Statement `json:"statements"`
+	More       string       `json:"more"`
+}
+
+// About describes the LRS.
+type About struct {
+	Version []string `json:"version"`
+}
+
+// ParseStatement decodes one statement, rejecting properties the
+// specification does not define.
+func ParseStatement(data []byte) (*Statement, error) {
+	var s Statement
+	if err := decodeStrict(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ParseStatements decodes a single statement or an array of statements.
+func ParseStatements(data []byte) ([]*Statement, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var list []*Statement
+		if err := decodeStrict(data, &list); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	s, err := ParseStatement(data)
+	if err != nil {
+		return nil, err
+	}
+	return []*Statement{s}, nil
+}
+
+// ParseAgent decodes an Agent or Group, e.g. from a query parameter.
+func ParseAgent(data []byte) (*Agent, error) {
+	var a Agent
+	if err := decodeStrict(data, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errTrailingData
+	}
+	return nil
+}
+
+// FormatTime formats t the way the LRS writes stored and timestamp values.
+func FormatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// Voids returns the ID of the statement s voids, or "" if it is not a
+// voiding statement.
+func (s *Statement) Voids() string {
+	if s.Verb == nil || s.Verb.ID != VerbVoided || s.Object == nil || s.Object.Type() != ObjectStatementRef {
+		return ""
+	}
+	return s.Object.ID
+}
+
+// Equivalent reports whether two statements with the same ID say the same
+// thing. Properties the LRS sets are ignored, as is the timestamp when one of
+// them was stored without it.
+func Equivalent(a, b *Statement) bool {
+	ca, cb := *a, *b
+	ca.Stored, cb.Stored = "", ""
+	ca.Authority, cb.Authority = nil, nil
+	ca.Version, cb.Version = "", ""
+	if ca.Timestamp == "" || cb.Timestamp == "" {
+		ca.Timestamp, cb.Timestamp = "", ""
+	}
+	ja, err1 := json.Marshal(&ca)
+	jb, err2 := json.Marshal(&cb)
+	return err1 == nil && err2 == nil && bytes.Equal(ja, jb)
+}
+
+// IDsOnly returns a copy of s for the "ids" query format: agents and groups
+// keep only their identifiers and activities only their IDs.
+func (s *Statement) IDsOnly() *Statement {
+	c := *s
+	c.Actor = c.Actor.idsOnly()
+	c.Authority = c.Authority.idsOnly()
+	c.Object = c.Object.idsOnly()
+	if c.Context != nil {
+		ctx := *c.Context
+		ctx.Instructor = ctx.Instructor.idsOnly()
+		ctx.Team = ctx.Team.idsOnly()
+		if ctx.ContextActivities != nil {
+			ca := ContextActivities{}
+			for _, pair := range []struct{ dst, src *ActivityList }{
+				{&ca.Parent, &ctx.ContextActivities.Parent}, {&ca.Grouping, &ctx.ContextActivities.Grouping},
+				{&ca.Category, &ctx.ContextActivities.Category}, {&ca.Other, &ctx.ContextActivities.Other},
+			} {
+				for _, o := range *pair.src {
+					*pair.dst = append(*pair.dst, o.idsOnly())
+				}
+			}
+			ctx.ContextActivities = &ca
+		}
+		c.Context = &ctx
+	}
+	return &c
+}
+
+func (a *Agent) idsOnly() *Agent {
+	if a == nil {
+		return nil
+	}
+	c := *a
+	c.Name = ""
+	if c.ObjectType == ObjectGroup && c.IFI() != "" {
+		c.Member = nil
+	}
+	members := c.Member
+	c.Member = nil
+	for _, m := range members {
+		c.Member = append(c.Member, m.idsOnly())
+	}
+	return &c
+}
+
+func (o *Object) idsOnly() *Object {
+	if o == nil {
+		return nil
+	}
+	c := *o
+	c.Definition = nil
+	c.Name = ""
+	if a := o.Agent(); a != nil {
+		c.Member = a.idsOnly().Member
+	}
+	if c.Type() == ObjectSubStatement {
+		sub := (&Statement{Actor: c.Actor, Verb: c.Verb, Object: c.Object, Context: c.Context}).IDsOnly()
+		c.Actor, c.Object, c.Context = sub.Actor, sub.Object, sub.Context
+	}
+	return &c
+}
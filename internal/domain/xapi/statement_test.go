@@ -0,0 +1,85 @@
+package xapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStatement_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string // substring; empty for a valid statement
+	}{
+		{"minimal", `{"actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"http://adlnet.gov/expapi/verbs/completed"},"object":{"id":"http://example.com/a"}}`, ""},
+		{"single context activity", `{"actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"http://adlnet.gov/expapi/verbs/completed"},"object":{"id":"http://example.com/a"},"context":{"contextActivities":{"parent":{"id":"http://example.com/course"}}}}`, ""},
+		{"unknown property", `{"actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"http://adlnet.gov/expapi/verbs/completed"},"object":{"id":"http://example.com/a"},"extra":1}`, "unknown field"},
+		{"two identifiers", `{"actor":{"mbox":"mailto:ann@example.com","openid":"http://openid.example.com/ann"},"verb":{"id":"http://adlnet.gov/expapi/verbs/completed"},"object":{"id":"http://example.com/a"}}`, "exactly one"},
+		{"mbox without mailto", `{"actor":{"mbox":"ann@example.com"},"verb":{"id":"http://adlnet.gov/expapi/verbs/completed"},"object":{"id":"http://example.com/a"}}`, "mailto"},
+		{"verb not an IRI", `{"actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"completed"},"object":{"id":"http://example.com/a"}}`, "IRI"},
+		{"bad id", `{"id":"nope","actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"http://adlnet.gov/expapi/verbs/completed"},"object":{"id":"http://example.com/a"}}`, "UUID"},
+		{"scaled out of range", `{"actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"http://adlnet.gov/expapi/verbs/scored"},"object":{"id":"http://example.com/a"},"result":{"score":{"scaled":1.5}}}`, "scaled"},
+		{"bad duration", `{"actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"http://adlnet.gov/expapi/verbs/completed"},"object":{"id":"http://example.com/a"},"result":{"duration":"90 minutes"}}`, "duration"},
+		{"voiding an activity", `{"actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"http://adlnet.gov/expapi/verbs/voided"},"object":{"id":"http://example.com/a"}}`, "StatementRef"},
+		{"nested substatement", `{"actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"http://example.com/planned"},"object":{"objectType":"SubStatement","actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"http://example.com/did"},"object":{"objectType":"SubStatement"}}}`, "SubStatement"},
+		{"anonymous group without members", `{"actor":{"objectType":"Group"},"verb":{"id":"http://adlnet.gov/expapi/verbs/completed"},"object":{"id":"http://example.com/a"}}`, "members"},
+		{"platform on agent object", `{"actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"http://example.com/met"},"object":{"objectType":"Agent","mbox":"mailto:bob@example.com"},"context":{"platform":"web"}}`, "platform"},
+	}
+	for _, tt := range tests {
+		st, err := ParseStatement([]byte(tt.json))
+		if err == nil {
+			err = st.Validate()
+		}
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: error = %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: error = %v, want one mentioning %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestStatement_Index(t *testing.T) {
+	st, err := ParseStatement([]byte(`{
+		"actor": {"objectType": "Group", "member": [{"mbox": "mailto:ann@example.com"}, {"account": {"homePage": "http://portal", "name": "bob"}}]},
+		"verb": {"id": "http://adlnet.gov/expapi/verbs/completed"},
+		"object": {"id": "http://example.com/module"},
+		"context": {
+			"registration": "0f4c2b1e-8f0a-4c9b-9a4e-0d2d6b7c1a11",
+			"instructor": {"mbox": "mailto:teacher@example.com"},
+			"contextActivities": {"parent": [{"id": "http://example.com/course"}]}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := st.Index()
+	if ix.Activity != "http://example.com/module" || ix.Registration != "0f4c2b1e-8f0a-4c9b-9a4e-0d2d6b7c1a11" {
+		t.Errorf("Index() = %+v", ix)
+	}
+	if want := []string{"mbox:mailto:ann@example.com", "account:http://portal|bob"}; strings.Join(ix.Agents, " ") != strings.Join(want, " ") {
+		t.Errorf("Agents = %v, want %v", ix.Agents, want)
+	}
+	if len(ix.RelatedAgents) != 3 || ix.RelatedAgents[2] != "mbox:mailto:teacher@example.com" {
+		t.Errorf("RelatedAgents = %v", ix.RelatedAgents)
+	}
+	if len(ix.RelatedActivities) != 2 || ix.RelatedActivities[1] != "http://example.com/course" {
+		t.Errorf("RelatedActivities = %v", ix.RelatedActivities)
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	a, _ := ParseStatement([]byte(`{"id":"0f4c2b1e-8f0a-4c9b-9a4e-0d2d6b7c1a11","actor":{"mbox":"mailto:ann@example.com"},"verb":{"id":"http://example.com/did"},"object":{"id":"http://example.com/a"}}`))
+	b := *a
+	b.Stored = "2024-03-01T10:00:00.000Z"
+	b.Timestamp = "2024-03-01T10:00:00.000Z"
+	b.Authority = &Agent{Mbox: "mailto:lrs@example.com"}
+	if !Equivalent(a, &b) {
+		t.Error("statements differing only in LRS properties should be equivalent")
+	}
+	c := b
+	c.Verb = &Verb{ID: "http://example.com/other"}
+	if Equivalent(&b, &c) {
+		t.Error("statements with different verbs should not be equivalent")
+	}
+}
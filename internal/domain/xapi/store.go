@@ -0,0 +1,167 @@
+package xapi
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"time"
+)
+
+// IFI returns the agent's inverse functional identifier as one string, e.g.
+// "mbox:mailto:ann@example.com", or "" for an anonymous Group. Agents with
+// the same IFI are the same person.
+func (a *Agent) IFI() string {
+	switch {
+	case a == nil:
+		return ""
+	case a.Mbox != "":
+		return "mbox:" + a.Mbox
+	case a.MboxSHA1Sum != "":
+		return "mbox_sha1sum:" + a.MboxSHA1Sum
+	case a.OpenID != "":
+		return "openid:" + a.OpenID
+	case a.Account != nil:
+		return "account:" + a.Account.HomePage + "|" + a.Account.Name
+	}
+	return ""
+}
+
+// IFIs returns the IFIs of the agent and, for a Group, its members.
+func (a *Agent) IFIs() []string {
+	if a == nil {
+		return nil
+	}
+	var ifis []string
+	if ifi := a.IFI(); ifi != "" {
+		ifis = append(ifis, ifi)
+	}
+	for _, m := range a.Member {
+		ifis = append(ifis, m.IFIs()...)
+	}
+	return ifis
+}
+
+// MboxSHA1 returns the mbox_sha1sum of an email address.
+func MboxSHA1(email string) string {
+	sum := sha1.Sum([]byte("mailto:" + email))
+	return hex.EncodeToString(sum[:])
+}
+
+// Index holds the values statement queries filter on.
+type Index struct {
+	Verb              string
+	Agents            []string // IFIs of the actor and of an Agent or Group object, with group members
+	RelatedAgents     []string // IFIs of every agent in the statement
+	Activity          string   // ID of an Activity object
+	RelatedActivities []string // IDs of the object and context activities, including a SubStatement's
+	Registration      string
+	Voids             string // ID of the statement a voiding statement voids
+}
+
+// Index computes the statement's index.
+func (s *Statement) Index() Index {
+	ix := Index{Verb: s.Verb.ID, Voids: s.Voids()}
+	ix.Agents = append(ix.Agents, s.Actor.IFIs()...)
+	if s.Object.Type() == ObjectActivity {
+		ix.Activity = s.Object.ID
+	}
+	if a := s.Object.Agent(); a != nil {
+		ix.Agents = append(ix.Agents, a.IFIs()...)
+	}
+	if s.Context != nil {
+		ix.Registration = s.Context.Registration
+	}
+	ix.RelatedAgents, ix.RelatedActivities = related(s.Actor, s.Object, s.Context)
+	ix.RelatedAgents = append(ix.RelatedAgents, s.Authority.IFIs()...)
+	ix.Agents = unique(ix.Agents)
+	ix.RelatedAgents = unique(ix.RelatedAgents)
+	ix.RelatedActivities = unique(ix.RelatedActivities)
+	return ix
+}
+
+func related(actor *Agent, object *Object, ctx *Context) (agents, activities []string) {
+	agents = actor.IFIs()
+	switch object.Type() {
+	case ObjectActivity:
+		activities = append(activities, object.ID)
+	case ObjectAgent, ObjectGroup:
+		agents = append(agents, object.Agent().IFIs()...)
+	case ObjectSubStatement:
+		if object.Actor != nil && object.Object != nil {
+			a, act := related(object.Actor, object.Object, object.Context)
+			agents, activities = append(agents, a...), append(activities, act...)
+		}
+	}
+	if ctx != nil {
+		agents = append(agents, ctx.Instructor.IFIs()...)
+		agents = append(agents, ctx.Team.IFIs()...)
+		if ctx.ContextActivities != nil {
+			for _, o := range ctx.ContextActivities.All() {
+				activities = append(activities, o.ID)
+			}
+		}
+	}
+	return agents, activities
+}
+
+func unique(values []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Record is a stored statement.
+type Record struct {
+	Statement *Statement
+	Seq       int64  // storage order, used as the query cursor
+	UserID    string // the portal user the actor is, "" when unknown
+	Voided    bool
+}
+
+// StatementFilter selects stored statements that have not been voided.
+type StatementFilter struct {
+	Agent             string // IFI
+	Verb              string
+	Activity          string
+	Registration      string
+	RelatedAgents     bool // match Agent anywhere in the statement
+	RelatedActivities bool // match Activity anywhere in the statement
+	Since, Until      time.Time
+	UserID            string // only statements whose actor is this portal user
+	After             int64  // cursor: only statements stored after (or, descending, before) this Seq
+	Ascending         bool
+	Limit             int
+}
+
+// DocumentKind tells State documents from Activity Profile documents.
+type DocumentKind string
+
+const (
+	DocumentState           DocumentKind = "state"
+	DocumentActivityProfile DocumentKind = "activity_profile"
+)
+
+// DocumentKey identifies a document. Agent and Registration are only used
+// by State documents; an empty DocID addresses all documents under the key.
+type DocumentKey struct {
+	Kind         DocumentKind
+	ActivityID   string
+	Agent        string // IFI
+	Registration string
+	DocID        string
+}
+
+// Document is a State or Activity Profile document that content stores in
+// the LRS.
+type Document struct {
+	DocumentKey
+	Content     []byte
+	ContentType string
+	ETag        string // hex SHA-1 of Content
+	UpdatedAt   time.Time
+}
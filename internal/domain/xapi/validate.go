@@ -0,0 +1,257 @@
+package xapi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var errTrailingData = errors.New("unexpected data after JSON value")
+
+var (
+	sha1Pattern     = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+	durationPattern = regexp.MustCompile(`^P(\d+(\.\d+)?W|(\d+(\.\d+)?Y)?(\d+(\.\d+)?M)?(\d+(\.\d+)?D)?(T(\d+(\.\d+)?H)?(\d+(\.\d+)?M)?(\d+(\.\d+)?S)?)?)$`)
+)
+
+// Validate checks the statement against the requirements of the
+// specification that matter to the portal. Properties the LRS sets (stored
+// and authority) are not checked.
+func (s *Statement) Validate() error {
+	if s.ID != "" && uuid.Validate(s.ID) != nil {
+		return errors.New("id must be a UUID")
+	}
+	if s.Version != "" && !strings.HasPrefix(s.Version, "1.0") {
+		return fmt.Errorf("version %q is not supported", s.Version)
+	}
+	if err := validateStatementBody(s.Actor, s.Verb, s.Object, s.Result, s.Context, s.Timestamp, s.Attachments); err != nil {
+		return err
+	}
+	if s.Object.Type() == ObjectSubStatement {
+		o := s.Object
+		if o.Object != nil && o.Object.Type() == ObjectSubStatement {
+			return errors.New("object: a SubStatement cannot contain a SubStatement")
+		}
+		if o.ID != "" || o.Definition != nil || o.Name != "" || o.Mbox != "" || o.MboxSHA1Sum != "" || o.OpenID != "" || o.Account != nil || o.Member != nil {
+			return errors.New("object: a SubStatement only has statement properties")
+		}
+		if err := validateStatementBody(o.Actor, o.Verb, o.Object, o.Result, o.Context, o.Timestamp, o.Attachments); err != nil {
+			return fmt.Errorf("object: %w", err)
+		}
+	}
+	if s.Verb.ID == VerbVoided && s.Object.Type() != ObjectStatementRef {
+		return errors.New("a voiding statement's object must be a StatementRef")
+	}
+	return nil
+}
+
+func validateStatementBody(actor *Agent, verb *Verb, object *Object, result *Result, ctx *Context, timestamp string, attachments []Attachment) error {
+	if actor == nil {
+		return errors.New("actor is required")
+	}
+	if err := actor.Validate(); err != nil {
+		return fmt.Errorf("actor: %w", err)
+	}
+	if verb == nil {
+		return errors.New("verb is required")
+	}
+	if !isIRI(verb.ID) {
+		return errors.New("verb: id must be an IRI")
+	}
+	if err := validateLanguageMap(verb.Display); err != nil {
+		return fmt.Errorf("verb: display: %w", err)
+	}
+	if object == nil {
+		return errors.New("object is required")
+	}
+	if err := object.validate(); err != nil {
+		return fmt.Errorf("object: %w", err)
+	}
+	if result != nil {
+		if err := result.validate(); err != nil {
+			return fmt.Errorf("result: %w", err)
+		}
+	}
+	if ctx != nil {
+		if err := ctx.validate(object); err != nil {
+			return fmt.Errorf("context: %w", err)
+		}
+	}
+	if timestamp != "" {
+		if _, err := time.Parse(time.RFC3339Nano, timestamp); err != nil {
+			return errors.New("timestamp must be an ISO 8601 date and time")
+		}
+	}
+	for i, a := range attachments {
+		if a.UsageType == "" || !isIRI(a.UsageType) || a.ContentType == "" || a.SHA2 == "" || len(a.Display) == 0 {
+			return fmt.Errorf("attachment %d: usageType, display, contentType, length and sha2 are required", i)
+		}
+		if a.FileURL == "" {
+			return fmt.Errorf("attachment %d: only attachments with a fileUrl are supported", i)
+		}
+	}
+	return nil
+}
+
+// Validate checks that an Agent has exactly one identifier and that a Group
+// is identified or lists its members.
+func (a *Agent) Validate() error {
+	switch a.ObjectType {
+	case "", ObjectAgent:
+		if a.Member != nil {
+			return errors.New("an Agent has no members")
+		}
+		if a.identifiers() != 1 {
+			return errors.New("an Agent needs exactly one of mbox, mbox_sha1sum, openid and account")
+		}
+	case ObjectGroup:
+		switch n := a.identifiers(); {
+		case n > 1:
+			return errors.New("a Group has at most one of mbox, mbox_sha1sum, openid and account")
+		case n == 0 && len(a.Member) == 0:
+			return errors.New("an anonymous Group needs members")
+		}
+		for i, m := range a.Member {
+			if m == nil || m.ObjectType == ObjectGroup {
+				return fmt.Errorf("member %d must be an Agent", i)
+			}
+			if err := m.Validate(); err != nil {
+				return fmt.Errorf("member %d: %w", i, err)
+			}
+		}
+	default:
+		return fmt.Errorf("objectType %q is not an Agent or Group", a.ObjectType)
+	}
+	if a.Mbox != "" && (!strings.HasPrefix(a.Mbox, "mailto:") || !strings.Contains(a.Mbox, "@")) {
+		return errors.New("mbox must be a mailto: IRI")
+	}
+	if a.MboxSHA1Sum != "" && !sha1Pattern.MatchString(a.MboxSHA1Sum) {
+		return errors.New("mbox_sha1sum must be a hex SHA-1")
+	}
+	if a.OpenID != "" && !isIRI(a.OpenID) {
+		return errors.New("openid must be a URI")
+	}
+	if a.Account != nil && (!isIRI(a.Account.HomePage) || a.Account.Name == "") {
+		return errors.New("account needs a homePage IRL and a name")
+	}
+	return nil
+}
+
+func (a *Agent) identifiers() int {
+	n := 0
+	for _, set := range []bool{a.Mbox != "", a.MboxSHA1Sum != "", a.OpenID != "", a.Account != nil} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+func (o *Object) validate() error {
+	switch o.Type() {
+	case ObjectActivity:
+		if !isIRI(o.ID) {
+			return errors.New("an Activity's id must be an IRI")
+		}
+		if d := o.Definition; d != nil {
+			if err := validateLanguageMap(d.Name); err != nil {
+				return fmt.Errorf("definition: name: %w", err)
+			}
+			if err := validateLanguageMap(d.Description); err != nil {
+				return fmt.Errorf("definition: description: %w", err)
+			}
+			if d.Type != "" && !isIRI(d.Type) {
+				return errors.New("definition: type must be an IRI")
+			}
+			if d.MoreInfo != "" && !isIRI(d.MoreInfo) {
+				return errors.New("definition: moreInfo must be an IRL")
+			}
+		}
+	case ObjectAgent, ObjectGroup:
+		if o.ID != "" || o.Definition != nil {
+			return errors.New("an Agent or Group has no id or definition")
+		}
+		return o.Agent().Validate()
+	case ObjectStatementRef:
+		if uuid.Validate(o.ID) != nil {
+			return errors.New("a StatementRef's id must be a UUID")
+		}
+	case ObjectSubStatement:
+		// Checked by Statement.Validate, which knows it is nested.
+	default:
+		return fmt.Errorf("unknown objectType %q", o.ObjectType)
+	}
+	return nil
+}
+
+func (r *Result) validate() error {
+	if sc := r.Score; sc != nil {
+		if sc.Scaled != nil && (*sc.Scaled < -1 || *sc.Scaled > 1) {
+			return errors.New("score: scaled must be between -1 and 1")
+		}
+		if sc.Min != nil && sc.Max != nil && *sc.Min > *sc.Max {
+			return errors.New("score: min is above max")
+		}
+		if sc.Raw != nil && ((sc.Min != nil && *sc.Raw < *sc.Min) || (sc.Max != nil && *sc.Raw > *sc.Max)) {
+			return errors.New("score: raw is outside min and max")
+		}
+	}
+	if r.Duration != "" && (!durationPattern.MatchString(r.Duration) || strings.HasSuffix(r.Duration, "T") || r.Duration == "P") {
+		return errors.New("duration must be an ISO 8601 duration")
+	}
+	return nil
+}
+
+func (c *Context) validate(object *Object) error {
+	if c.Registration != "" && uuid.Validate(c.Registration) != nil {
+		return errors.New("registration must be a UUID")
+	}
+	if c.Instructor != nil {
+		if err := c.Instructor.Validate(); err != nil {
+			return fmt.Errorf("instructor: %w", err)
+		}
+	}
+	if c.Team != nil {
+		if c.Team.ObjectType != ObjectGroup {
+			return errors.New("team must be a Group")
+		}
+		if err := c.Team.Validate(); err != nil {
+			return fmt.Errorf("team: %w", err)
+		}
+	}
+	if (c.Revision != "" || c.Platform != "") && object.Type() != ObjectActivity {
+		return errors.New("revision and platform are only allowed when the object is an Activity")
+	}
+	if c.Statement != nil && (c.Statement.ObjectType != ObjectStatementRef || uuid.Validate(c.Statement.ID) != nil) {
+		return errors.New("statement must be a StatementRef")
+	}
+	if ca := c.ContextActivities; ca != nil {
+		for _, o := range ca.All() {
+			if o == nil || o.Type() != ObjectActivity {
+				return errors.New("contextActivities must be Activities")
+			}
+			if err := o.validate(); err != nil {
+				return fmt.Errorf("contextActivities: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateLanguageMap(m LanguageMap) error {
+	for tag := range m {
+		if tag == "" || strings.ContainsAny(tag, " _") {
+			return fmt.Errorf("invalid language tag %q", tag)
+		}
+	}
+	return nil
+}
+
+func isIRI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && (u.Host != "" || u.Opaque != "" || u.Path != "")
+}
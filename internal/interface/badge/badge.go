@@ -0,0 +1,150 @@
+// Package badge draws Open Badges images and bakes credentials into them.
+package badge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+)
+
+// Keyword of the PNG iTXt chunk holding an Open Badges 3.0 credential.
+const pngKeyword = "openbadgecredential"
+
+// Namespace of the SVG element holding an Open Badges 3.0 credential.
+const svgNamespace = "https://purl.imsglobal.org/ob/v3p0"
+
+const size = 256
+
+var (
+	rim    = color.RGBA{0x1f, 0x3a, 0x5f, 0xff}
+	face   = color.RGBA{0xe8, 0xb9, 0x3c, 0xff}
+	marker = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// Renderer draws a round emblem for each achievement. PNG images carry no
+// text; SVG images show the achievement name below the check mark.
+type Renderer struct{}
+
+// PNG draws the emblem and, when credential is set, bakes it into an iTXt chunk.
+func (Renderer) PNG(title, credential string) ([]byte, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	c := float64(size) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			d := math.Hypot(float64(x)+0.5-c, float64(y)+0.5-c)
+			switch {
+			case d > c-2:
+				// transparent outside the emblem
+			case d > c-18:
+				img.Set(x, y, rim)
+			case onCheck(float64(x)+0.5, float64(y)+0.5):
+				img.Set(x, y, marker)
+			default:
+				img.Set(x, y, face)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	if credential == "" {
+		return buf.Bytes(), nil
+	}
+	return BakePNG(buf.Bytes(), credential)
+}
+
+// onCheck reports whether a point lies on the check mark.
+func onCheck(x, y float64) bool {
+	const width = 14
+	return segmentDistance(x, y, 80, 132, 112, 164) < width || segmentDistance(x, y, 112, 164, 176, 96) < width
+}
+
+func segmentDistance(px, py, ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	t := ((px-ax)*dx + (py-ay)*dy) / (dx*dx + dy*dy)
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(px-(ax+t*dx), py-(ay+t*dy))
+}
+
+// SVG draws the emblem and, when credential is set, embeds it in an
+// openbadges:credential element.
+func (Renderer) SVG(title, credential string) ([]byte, error) {
+	if strings.Contains(credential, "]]>") {
+		return nil, errors.New("credential cannot be embedded in SVG")
+	}
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:openbadges="` + svgNamespace + `" width="256" height="300" viewBox="0 0 256 300">` + "\n")
+	if credential != "" {
+		b.WriteString("<openbadges:credential><![CDATA[" + credential + "]]></openbadges:credential>\n")
+	}
+	b.WriteString(`<circle cx="128" cy="128" r="119" fill="#e8b93c" stroke="#1f3a5f" stroke-width="16"/>` + "\n")
+	b.WriteString(`<polyline points="80,132 112,164 176,96" fill="none" stroke="#ffffff" stroke-width="28" stroke-linecap="round" stroke-linejoin="round"/>` + "\n")
+	b.WriteString(`<text x="128" y="288" text-anchor="middle" font-family="Helvetica, Arial, sans-serif" font-size="16" fill="#1f3a5f">` + html.EscapeString(title) + "</text>\n")
+	b.WriteString("</svg>\n")
+	return []byte(b.String()), nil
+}
+
+// BakePNG inserts credential into a PNG image as an uncompressed iTXt chunk
+// directly after the IHDR chunk.
+func BakePNG(img []byte, credential string) ([]byte, error) {
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4 // signature, then IHDR length, type, data and CRC
+	if len(img) < ihdrEnd || !bytes.HasPrefix(img, []byte("\x89PNG\r\n\x1a\n")) || string(img[12:16]) != "IHDR" {
+		return nil, errors.New("not a PNG image")
+	}
+
+	// keyword, null separator, compression flag and method, empty language
+	// tag and translated keyword, then the text itself.
+	data := append([]byte(pngKeyword), 0, 0, 0, 0, 0)
+	data = append(data, credential...)
+
+	var chunk bytes.Buffer
+	binary.Write(&chunk, binary.BigEndian, uint32(len(data)))
+	chunk.WriteString("iTXt")
+	chunk.Write(data)
+	binary.Write(&chunk, binary.BigEndian, crc32.ChecksumIEEE(chunk.Bytes()[4:]))
+
+	out := make([]byte, 0, len(img)+chunk.Len())
+	out = append(out, img[:ihdrEnd]...)
+	out = append(out, chunk.Bytes()...)
+	return append(out, img[ihdrEnd:]...), nil
+}
+
+// ExtractPNG returns the credential baked into a PNG image, or "" if there is none.
+func ExtractPNG(img []byte) (string, error) {
+	if !bytes.HasPrefix(img, []byte("\x89PNG\r\n\x1a\n")) {
+		return "", errors.New("not a PNG image")
+	}
+	for pos := 8; pos+12 <= len(img); {
+		n := int(binary.BigEndian.Uint32(img[pos:]))
+		if n < 0 || pos+12+n > len(img) {
+			return "", errors.New("truncated PNG chunk")
+		}
+		typ, data := string(img[pos+4:pos+8]), img[pos+8:pos+8+n]
+		if typ == "iTXt" && bytes.HasPrefix(data, []byte(pngKeyword+"\x00")) {
+			rest := data[len(pngKeyword)+1:]
+			if len(rest) < 2 || rest[0] != 0 {
+				return "", errors.New("compressed badge credentials are not supported")
+			}
+			// skip the language tag and translated keyword
+			parts := bytes.SplitN(rest[2:], []byte{0}, 3)
+			if len(parts) != 3 {
+				return "", errors.New("malformed iTXt chunk")
+			}
+			return string(parts[2]), nil
+		}
+		if typ == "IEND" {
+			break
+		}
+		pos += 12 + n
+	}
+	return "", nil
+}
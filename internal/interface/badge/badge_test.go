@@ -0,0 +1,47 @@
+package badge
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestRenderer_PNGBakesCredential(t *testing.T) {
+	img, err := Renderer{}.PNG("Safety 101", "header.payload.signature")
+	if err != nil {
+		t.Fatalf("PNG() error = %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(img)); err != nil {
+		t.Fatalf("baked PNG does not decode: %v", err)
+	}
+	got, err := ExtractPNG(img)
+	if err != nil || got != "header.payload.signature" {
+		t.Errorf("ExtractPNG() = %q, %v, want baked credential", got, err)
+	}
+
+	plain, _ := Renderer{}.PNG("Safety 101", "")
+	if got, _ := ExtractPNG(plain); got != "" {
+		t.Errorf("ExtractPNG() of plain image = %q, want none", got)
+	}
+}
+
+func TestRenderer_SVGBakesCredential(t *testing.T) {
+	img, err := Renderer{}.SVG("Fire & Safety", "header.payload.signature")
+	if err != nil {
+		t.Fatalf("SVG() error = %v", err)
+	}
+	svg := string(img)
+	for _, want := range []string{
+		`xmlns:openbadges="https://purl.imsglobal.org/ob/v3p0"`,
+		"<openbadges:credential><![CDATA[header.payload.signature]]></openbadges:credential>",
+		"Fire &amp; Safety",
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG() missing %q", want)
+		}
+	}
+	if _, err := (Renderer{}).SVG("x", "a]]>b"); err == nil {
+		t.Error("SVG() with CDATA terminator expected error")
+	}
+}
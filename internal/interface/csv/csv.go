@@ -0,0 +1,79 @@
+// Package csv writes the portal's CSV exports. Spreadsheets run a cell that
+// starts with =, +, -, @, a tab or a carriage return as a formula, so such
+// cells are prefixed with a single quote to be shown as text.
+package csv
+
+import (
+	stdcsv "encoding/csv"
+	"io"
+	"strings"
+
+	"training-portal/internal/domain/compliance"
+)
+
+// Writer writes CSV records with every cell escaped.
+type Writer struct {
+	w *stdcsv.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: stdcsv.NewWriter(w)}
+}
+
+// Write writes one record. Records are buffered until Flush.
+func (w *Writer) Write(record []string) error {
+	escaped := make([]string, len(record))
+	for i, cell := range record {
+		escaped[i] = Escape(cell)
+	}
+	return w.w.Write(escaped)
+}
+
+// WriteAll writes the records and flushes them.
+func (w *Writer) WriteAll(records [][]string) error {
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Flush writes buffered records to the underlying writer.
+func (w *Writer) Flush() {
+	w.w.Flush()
+}
+
+// Error reports any error from a previous Write or Flush.
+func (w *Writer) Error() error {
+	return w.w.Error()
+}
+
+// Escape returns cell so that a spreadsheet shows it as text rather than
+// evaluating it.
+func Escape(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// ReportRenderer renders compliance report tables as CSV: the column
+// headings, the rows and the footer, if any, as the last row.
+type ReportRenderer struct{}
+
+// Render produces the CSV bytes for t.
+func (ReportRenderer) Render(t *compliance.Table) ([]byte, error) {
+	var buf strings.Builder
+	w := NewWriter(&buf)
+	w.Write(t.Columns)
+	for _, row := range t.Rows {
+		w.Write(row)
+	}
+	if t.Footer != "" {
+		w.Write([]string{t.Footer})
+	}
+	w.Flush()
+	return []byte(buf.String()), w.Error()
+}
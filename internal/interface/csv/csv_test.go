@@ -0,0 +1,50 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+
+	"training-portal/internal/domain/compliance"
+)
+
+func TestEscape(t *testing.T) {
+	tests := map[string]string{
+		"":                  "",
+		"Safety 101":        "Safety 101",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1":                "'+1",
+		"-2":                "'-2",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"\tcmd":             "'\tcmd",
+		"\rcmd":             "'\rcmd",
+		"a=b":               "a=b",
+		"2024-01-31":        "2024-01-31",
+	}
+	for in, want := range tests {
+		if got := Escape(in); got != want {
+			t.Errorf("Escape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf strings.Builder
+	w := NewWriter(&buf)
+	if err := w.WriteAll([][]string{{"name", "note"}, {"=cmd|' /C calc'!A0", "x,y"}}); err != nil {
+		t.Fatalf("WriteAll() error = %v", err)
+	}
+	if want := "name,note\n'=cmd|' /C calc'!A0,\"x,y\"\n"; buf.String() != want {
+		t.Errorf("WriteAll() wrote %q, want %q", buf.String(), want)
+	}
+}
+
+func TestReportRenderer(t *testing.T) {
+	data, err := ReportRenderer{}.Render(&compliance.Table{
+		Columns: []string{"a", "b"},
+		Rows:    [][]string{{"1", "x,y"}, {"@risk", "-"}},
+		Footer:  "hash",
+	})
+	if want := "a,b\n1,\"x,y\"\n'@risk,'-\nhash\n"; err != nil || string(data) != want {
+		t.Errorf("Render() = %q, %v; want %q", data, err, want)
+	}
+}
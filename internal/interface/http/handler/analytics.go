@@ -1,74 +1,228 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
+	"training-portal/internal/domain/analytics"
+	"training-portal/internal/interface/csv"
+	analyticsusecase "training-portal/internal/usecase/analytics"
+
 	"github.com/gofiber/fiber/v2"
 )
 
-// UserEngagement represents a stub for user engagement metrics
-type UserEngagement struct {
-	UserID       string `json:"userId"`
-	LastLogin    string `json:"lastLogin"`
-	CoursesTaken int    `json:"coursesTaken"`
+// AnalyticsHandler provides HTTP handlers for analytics endpoints.
+type AnalyticsHandler struct {
+	Service *analyticsusecase.Service
+	Reports *analyticsusecase.ReportService
 }
 
-// CourseAnalytics represents a stub for course-level analytics
-type CourseAnalytics struct {
-	CourseID       string `json:"courseId"`
-	EnrolledUsers  int    `json:"enrolledUsers"`
-	CompletedUsers int    `json:"completedUsers"`
-	AverageScore   int    `json:"averageScore"`
+var _ = AnalyticsHandler{} // Exported for router.go
+
+// IngestEvents handles POST /api/analytics/events
+// Stores a batch of the authenticated user's events reported by the
+// frontend. Events with an ID that was already stored are skipped.
+func (h *AnalyticsHandler) IngestEvents(c *fiber.Ctx) error {
+	var req struct {
+		Events []*analytics.AnalyticsEvent `json:"events"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	stored, err := h.Service.Ingest(currentUserID(c), req.Events, time.Now())
+	if err != nil {
+		return analyticsError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"received": len(req.Events), "stored": stored})
 }
 
-// AnalyticsEvent represents a stub for analytics events
-type AnalyticsEvent struct {
-	EventID   string    `json:"eventId"`
-	UserID    string    `json:"userId"`
-	EventType string    `json:"eventType"`
-	Timestamp time.Time `json:"timestamp"`
+// GetUserEngagement handles GET /api/analytics/user-engagement
+// Staff see everyone's engagement, filtered by ?userId= and ?courseId=;
+// other users only see their own.
+func (h *AnalyticsHandler) GetUserEngagement(c *fiber.Ctx) error {
+	userID := c.Query("userId")
+	if !isStaff(c) {
+		if userID != "" && userID != currentUserID(c) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+		}
+		userID = currentUserID(c)
+	}
+	engagement, err := h.Service.Engagement(userID, c.Query("courseId"))
+	if err != nil {
+		return analyticsError(c, err)
+	}
+	return c.JSON(engagement)
 }
 
-// AnalyticsHandler provides HTTP handlers for analytics endpoints.
-type AnalyticsHandler struct{}
+// GetCourseAnalytics handles GET /api/analytics/course/:courseID (staff only)
+func (h *AnalyticsHandler) GetCourseAnalytics(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	ca, err := h.Service.CourseAnalytics(c.Params("courseID"))
+	if err != nil {
+		return analyticsError(c, err)
+	}
+	return c.JSON(ca)
+}
 
-// In-memory stub data (replace with real DB/analytics service in the future)
-var (
-	userEngagements = []UserEngagement{
-		{UserID: "1", LastLogin: time.Now().AddDate(0, 0, -1).Format(time.RFC3339), CoursesTaken: 3},
-		{UserID: "2", LastLogin: time.Now().AddDate(0, 0, -2).Format(time.RFC3339), CoursesTaken: 5},
+// GetEvents handles GET /api/analytics/events (staff only)
+// Filters: ?userId=, ?courseId=, ?type=, ?from= and ?to= (Unix timestamps)
+// and ?limit=.
+func (h *AnalyticsHandler) GetEvents(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	events, err := h.Service.Events(analytics.EventFilter{
+		UserID:    c.Query("userId"),
+		CourseID:  c.Query("courseId"),
+		EventType: c.Query("type"),
+		From:      int64(c.QueryInt("from")),
+		To:        int64(c.QueryInt("to")),
+		Limit:     c.QueryInt("limit", 100),
+	})
+	if err != nil {
+		return analyticsError(c, err)
 	}
+	return c.JSON(events)
+}
 
-	courseAnalytics = []CourseAnalytics{
-		{CourseID: "101", EnrolledUsers: 50, CompletedUsers: 30, AverageScore: 85},
-		{CourseID: "102", EnrolledUsers: 40, CompletedUsers: 20, AverageScore: 78},
+// GetFunnel handles GET /api/analytics/course/:courseID/funnel (staff and managers)
+// Returns how many learners reached each stage of the course, as JSON or as
+// a CSV download when format=csv. See reportFilter for the filters.
+func (h *AnalyticsHandler) GetFunnel(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	filter, err := reportFilter(c, c.Params("courseID"))
+	if err != nil {
+		return analyticsError(c, err)
+	}
+	report, err := h.Reports.Funnel(filter, time.Now())
+	if err != nil {
+		return analyticsError(c, err)
+	}
+	if c.Query("format") != "csv" {
+		return c.JSON(report)
 	}
 
-	analyticsEvents = []AnalyticsEvent{
-		{EventID: "1", UserID: "1", EventType: "login", Timestamp: time.Now().Add(-48 * time.Hour)},
-		{EventID: "2", UserID: "2", EventType: "course_completed", Timestamp: time.Now().Add(-24 * time.Hour)},
+	rows := [][]string{{"stage", "module_id", "module_title", "learners", "share", "conversion"}}
+	for _, s := range report.Stages {
+		rows = append(rows, []string{s.Name, s.ModuleID, s.Title, strconv.Itoa(s.Learners), formatRatio(s.Share), formatRatio(s.Conversion)})
 	}
-)
+	return sendCSV(c, "funnel-"+report.CourseID+".csv", rows)
+}
 
-// GetUserEngagement handles GET /api/analytics/user-engagement
-func (h *AnalyticsHandler) GetUserEngagement(c *fiber.Ctx) error {
-	// Return all user engagement data (stub)
-	return c.JSON(userEngagements)
+// GetCohorts handles GET /api/analytics/cohorts?courseId=&weeks= (staff and managers)
+// Returns weekly enrollment cohorts with the share of each still active after
+// 0 to weeks weeks, as JSON or CSV.
+func (h *AnalyticsHandler) GetCohorts(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	filter, err := reportFilter(c, c.Query("courseId"))
+	if err != nil {
+		return analyticsError(c, err)
+	}
+	report, err := h.Reports.Cohorts(filter, c.QueryInt("weeks", 8), time.Now())
+	if err != nil {
+		return analyticsError(c, err)
+	}
+	if c.Query("format") != "csv" {
+		return c.JSON(report)
+	}
+
+	header := []string{"week_start", "learners"}
+	for n := 0; n <= report.Weeks; n++ {
+		header = append(header, "week_"+strconv.Itoa(n))
+	}
+	rows := [][]string{header}
+	for _, cohort := range report.Cohorts {
+		row := []string{formatDate(cohort.WeekStart), strconv.Itoa(cohort.Learners)}
+		for n := 0; n <= report.Weeks; n++ {
+			value := "" // not reached yet
+			if n < len(cohort.Retention) {
+				value = formatRatio(cohort.Retention[n])
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+	return sendCSV(c, "cohorts.csv", rows)
 }
 
-// GetCourseAnalytics handles GET /api/analytics/course/:courseID
-func (h *AnalyticsHandler) GetCourseAnalytics(c *fiber.Ctx) error {
-	courseID := c.Params("courseID")
-	for _, ca := range courseAnalytics {
-		if ca.CourseID == courseID {
-			return c.JSON(ca)
+// GetCompletionTime handles GET /api/analytics/completion-time?courseId= (staff and managers)
+// Returns the median time to complete, as JSON or CSV.
+func (h *AnalyticsHandler) GetCompletionTime(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	filter, err := reportFilter(c, c.Query("courseId"))
+	if err != nil {
+		return analyticsError(c, err)
+	}
+	report, err := h.Reports.CompletionTime(filter, time.Now())
+	if err != nil {
+		return analyticsError(c, err)
+	}
+	if c.Query("format") != "csv" {
+		return c.JSON(report)
+	}
+	return sendCSV(c, "completion-time.csv", [][]string{
+		{"course_id", "completions", "median_seconds", "median_days"},
+		{report.CourseID, strconv.Itoa(report.Completions), strconv.FormatInt(report.MedianSeconds, 10), strconv.FormatFloat(report.MedianDays, 'f', 2, 64)},
+	})
+}
+
+// reportFilter reads the report filters: ?department=, ?role= and the
+// enrollment date range ?from= and ?to=, as YYYY-MM-DD dates in UTC with
+// both days included.
+func reportFilter(c *fiber.Ctx, courseID string) (analytics.ReportFilter, error) {
+	filter := analytics.ReportFilter{CourseID: courseID, Department: c.Query("department"), Role: c.Query("role")}
+	for _, bound := range []struct {
+		name string
+		days int
+		dst  *int64
+	}{{"from", 0, &filter.From}, {"to", 1, &filter.To}} {
+		value := c.Query(bound.name)
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, fmt.Errorf("%w: %s must be a date like 2024-01-31", analyticsusecase.ErrInvalidFilter, bound.name)
 		}
+		*bound.dst = day.AddDate(0, 0, bound.days).Unix()
 	}
-	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course analytics not found"})
+	return filter, nil
 }
 
-// GetEvents handles GET /api/analytics/events
-func (h *AnalyticsHandler) GetEvents(c *fiber.Ctx) error {
-	// Return all analytics events (stub)
-	return c.JSON(analyticsEvents)
+func formatRatio(r float64) string {
+	return strconv.FormatFloat(r, 'f', 4, 64)
+}
+
+// sendCSV writes rows as a CSV download.
+func sendCSV(c *fiber.Ctx, filename string, rows [][]string) error {
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	w := csv.NewWriter(c.Response().BodyWriter())
+	w.WriteAll(rows)
+	return w.Error()
+}
+
+func analyticsError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, analyticsusecase.ErrNotAggregated),
+		errors.Is(err, analyticsusecase.ErrCourseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, analyticsusecase.ErrBatchTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, analyticsusecase.ErrInvalidEvent),
+		errors.Is(err, analyticsusecase.ErrInvalidFilter):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
 }
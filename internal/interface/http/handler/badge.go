@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	certificateusecase "training-portal/internal/usecase/certificate"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BadgeHandler provides HTTP handlers for Open Badges 3.0 credentials. All
+// routes except the export are public so that badge platforms and verifiers
+// can resolve the URLs inside issued credentials.
+type BadgeHandler struct {
+	Service *certificateusecase.BadgeService
+}
+
+var _ = BadgeHandler{} // Exported for router.go
+
+// GetIssuer handles GET /ob/issuer (public)
+func (h *BadgeHandler) GetIssuer(c *fiber.Ctx) error {
+	return c.JSON(h.Service.Issuer())
+}
+
+// GetIssuerKey handles GET /ob/issuer/key (public)
+// Returns the public key credentials are signed with as a JWK.
+func (h *BadgeHandler) GetIssuerKey(c *fiber.Ctx) error {
+	return c.JSON(h.Service.PublicKey())
+}
+
+// GetAchievement handles GET /ob/achievements/:course_id (public)
+func (h *BadgeHandler) GetAchievement(c *fiber.Ctx) error {
+	a, err := h.Service.Achievement(c.Params("course_id"))
+	if err != nil {
+		return badgeError(c, err)
+	}
+	return c.JSON(a)
+}
+
+// GetAchievementImage handles GET /ob/achievements/:course_id/image (public)
+func (h *BadgeHandler) GetAchievementImage(c *fiber.Ctx) error {
+	img, err := h.Service.AchievementImage(c.Params("course_id"))
+	if err != nil {
+		return badgeError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, "image/svg+xml")
+	return c.Send(img)
+}
+
+// GetCredential handles GET /ob/credentials/:credential_id (public)
+// The recipient is only identified by a salted hash of their email.
+func (h *BadgeHandler) GetCredential(c *fiber.Ctx) error {
+	cred, err := h.Service.HostedCredential(c.Params("credential_id"))
+	if err != nil {
+		return badgeError(c, err)
+	}
+	return c.JSON(cred)
+}
+
+// GetCredentialStatus handles GET /ob/credentials/:credential_id/status (public)
+func (h *BadgeHandler) GetCredentialStatus(c *fiber.Ctx) error {
+	return c.JSON(h.Service.Status(c.Params("credential_id")))
+}
+
+// VerifyBadge handles POST /ob/verify (public)
+// Accepts a VC-JWT either as {"credential": "..."} or as the raw request body,
+// and checks its signature and the current status of the certificate.
+func (h *BadgeHandler) VerifyBadge(c *fiber.Ctx) error {
+	token := string(c.Body())
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+		var req struct {
+			Credential string `json:"credential"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+		}
+		token = req.Credential
+	}
+	if strings.TrimSpace(token) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Credential required"})
+	}
+	return c.JSON(h.Service.Verify(token))
+}
+
+// ExportBadge handles GET /certificate/:id/badge?format=jwt|png|svg|json
+// Returns the certificate as an Open Badges credential: the signed VC-JWT
+// (default), a PNG or SVG image with the credential baked in, or the
+// unsigned credential document.
+func (h *BadgeHandler) ExportBadge(c *fiber.Ctx) error {
+	cert, err := h.Service.Certificates.GetCertificate(c.Params("id"))
+	if err != nil {
+		return badgeError(c, err)
+	}
+	if cert.UserID != currentUserID(c) && !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	format := c.Query("format", string(certificateusecase.BadgeJWT))
+	if format == "json" {
+		cred, err := h.Service.Credential(cert.ID)
+		if err != nil {
+			return badgeError(c, err)
+		}
+		return c.JSON(cred)
+	}
+	data, contentType, err := h.Service.Export(cert.ID, certificateusecase.BadgeFormat(format))
+	if err != nil {
+		return badgeError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	if format != string(certificateusecase.BadgeJWT) {
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+cert.CredentialID+`.`+format+`"`)
+	}
+	return c.Send(data)
+}
+
+// badgeError maps badge service errors to HTTP responses.
+func badgeError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, certificateusecase.ErrAchievementNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Achievement not found"})
+	}
+	return certificateError(c, err)
+}
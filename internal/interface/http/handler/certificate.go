@@ -1,37 +1,37 @@
 package handler
 
 import (
-	"strconv"
+	"errors"
+	"io"
 	"time"
 
+	"training-portal/internal/domain/certificate"
+	certificateusecase "training-portal/internal/usecase/certificate"
+
 	"github.com/gofiber/fiber/v2"
 )
 
-// Certificate represents a user's certificate for a completed course.
-// In a real implementation, replace with a DB model and store files as needed.
-type Certificate struct {
-	ID       string    `json:"id"`
-	UserID   string    `json:"userId"`
-	CourseID string    `json:"courseId"`
-	IssuedAt time.Time `json:"issuedAt"`
-	FileURL  string    `json:"fileUrl"` // Could point to a PDF or image
-}
+// maxTemplateImageSize limits uploaded logo and signature images.
+const maxTemplateImageSize = 2 << 20
 
 // CertificateHandler provides HTTP handlers for certificate-related endpoints.
-type CertificateHandler struct{}
+type CertificateHandler struct {
+	Service   *certificateusecase.CertificateService
+	Templates *certificateusecase.TemplateService
+}
 
-// In-memory storage for demonstration (replace with DB in the future)
-var certificates = []Certificate{}
+var _ = CertificateHandler{} // Exported for router.go
 
 // GetCertificate handles GET /certificate/:id
 func (h *CertificateHandler) GetCertificate(c *fiber.Ctx) error {
-	id := c.Params("id")
-	for _, cert := range certificates {
-		if cert.ID == id {
-			return c.JSON(cert)
-		}
+	cert, err := h.Service.GetCertificate(c.Params("id"))
+	if err != nil {
+		return certificateError(c, err)
 	}
-	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Certificate not found"})
+	if cert.UserID != currentUserID(c) && !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	return c.JSON(cert)
 }
 
 // ListCertificates handles GET /user/:user_id/certificates
@@ -40,41 +40,251 @@ func (h *CertificateHandler) ListCertificates(c *fiber.Ctx) error {
 	if userID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "User ID required"})
 	}
-
-	var userCertificates []Certificate
-	for _, cert := range certificates {
-		if cert.UserID == userID {
-			userCertificates = append(userCertificates, cert)
-		}
+	if userID != currentUserID(c) && !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
 	}
-
-	return c.JSON(userCertificates)
+	certs, err := h.Service.ListCertificates(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(certs)
 }
 
 // DownloadCertificate handles GET /certificate/:id/download
+// Serves the generated PDF.
 func (h *CertificateHandler) DownloadCertificate(c *fiber.Ctx) error {
-	id := c.Params("id")
-	for _, cert := range certificates {
-		if cert.ID == id {
-			// Stub: In a real system, return file bytes or redirect to file storage
-			return c.JSON(fiber.Map{
-				"message": "Download endpoint (stub)",
-				"fileUrl": cert.FileURL,
-			})
-		}
-	}
-	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Certificate not found"})
-}
-
-// Optional helper: create a new certificate (could be called after course completion)
-func (h *CertificateHandler) IssueCertificate(userID, courseID string) Certificate {
-	cert := Certificate{
-		ID:       strconv.Itoa(len(certificates) + 1),
-		UserID:   userID,
-		CourseID: courseID,
-		IssuedAt: time.Now(),
-		FileURL:  "/path/to/certificate.pdf", // Replace with real file generation
-	}
-	certificates = append(certificates, cert)
-	return cert
+	cert, err := h.Service.GetCertificate(c.Params("id"))
+	if err != nil {
+		return certificateError(c, err)
+	}
+	if cert.UserID != currentUserID(c) && !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	pdf, err := h.Service.Download(cert.ID)
+	if err != nil {
+		return certificateError(c, err)
+	}
+	c.Set("X-Certificate-Status", string(cert.CurrentStatus(time.Now())))
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="certificate-`+cert.CredentialID+`.pdf"`)
+	return c.Send(pdf)
+}
+
+// IssueCertificate handles POST /certificates (staff only)
+// Issues a certificate outside the automatic completion flow, e.g. with a score.
+func (h *CertificateHandler) IssueCertificate(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req struct {
+		UserID   string `json:"userId"`
+		CourseID string `json:"courseId"`
+		Score    int    `json:"score"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+	}
+	cert, err := h.Service.Issue(req.UserID, req.CourseID, req.Score, currentUserID(c))
+	if err != nil {
+		return certificateError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(cert)
+}
+
+type certificateChangeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RevokeCertificate handles POST /certificate/:id/revoke (staff only)
+// A reason is required and kept in the certificate's history.
+func (h *CertificateHandler) RevokeCertificate(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req certificateChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+	}
+	cert, err := h.Service.Revoke(c.Params("id"), currentUserID(c), req.Reason)
+	if err != nil {
+		return certificateError(c, err)
+	}
+	return c.JSON(cert)
+}
+
+// ReissueCertificate handles POST /certificate/:id/reissue (staff only)
+// Renders a replacement with the learner's current name and the current
+// template, e.g. after a legal name change. Returns the new certificate.
+func (h *CertificateHandler) ReissueCertificate(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req certificateChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+	}
+	cert, err := h.Service.Reissue(c.Params("id"), currentUserID(c), req.Reason)
+	if err != nil {
+		return certificateError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(cert)
+}
+
+// GetCertificateHistory handles GET /certificate/:id/history
+func (h *CertificateHandler) GetCertificateHistory(c *fiber.Ctx) error {
+	cert, err := h.Service.GetCertificate(c.Params("id"))
+	if err != nil {
+		return certificateError(c, err)
+	}
+	if cert.UserID != currentUserID(c) && !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	history, err := h.Service.History(cert.ID)
+	if err != nil {
+		return certificateError(c, err)
+	}
+	return c.JSON(history)
+}
+
+type certificateTemplateRequest struct {
+	Name          string                  `json:"name"`
+	CourseID      string                  `json:"courseId"`
+	IsDefault     bool                    `json:"isDefault"`
+	Orientation   certificate.Orientation `json:"orientation"`
+	Title         string                  `json:"title"`
+	Body          string                  `json:"body"`
+	SignatoryName string                  `json:"signatoryName"`
+}
+
+func (req *certificateTemplateRequest) template() *certificate.Template {
+	return &certificate.Template{
+		Name:          req.Name,
+		CourseID:      req.CourseID,
+		IsDefault:     req.IsDefault,
+		Orientation:   req.Orientation,
+		Title:         req.Title,
+		Body:          req.Body,
+		SignatoryName: req.SignatoryName,
+	}
+}
+
+// CreateTemplate handles POST /certificate-templates (admin only)
+func (h *CertificateHandler) CreateTemplate(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req certificateTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+	}
+	t := req.template()
+	if err := h.Templates.CreateTemplate(t); err != nil {
+		return certificateError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(t)
+}
+
+// ListTemplates handles GET /certificate-templates (admin only)
+func (h *CertificateHandler) ListTemplates(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	templates, err := h.Templates.ListTemplates()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(templates)
+}
+
+// UpdateTemplate handles PUT /certificate-template/:id (admin only)
+func (h *CertificateHandler) UpdateTemplate(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req certificateTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+	}
+	t := req.template()
+	t.ID = c.Params("id")
+	if err := h.Templates.UpdateTemplate(t); err != nil {
+		return certificateError(c, err)
+	}
+	return c.JSON(t)
+}
+
+// DeleteTemplate handles DELETE /certificate-template/:id (admin only)
+func (h *CertificateHandler) DeleteTemplate(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	if err := h.Templates.DeleteTemplate(c.Params("id")); err != nil {
+		return certificateError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Template deleted"})
+}
+
+// UploadTemplateImage handles PUT /certificate-template/:id/:kind (admin only)
+// Accepts a multipart "file" field holding a PNG or JPEG logo or signature.
+func (h *CertificateHandler) UploadTemplateImage(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "File required"})
+	}
+	if header.Size > maxTemplateImageSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Image too large"})
+	}
+	f, err := header.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid file"})
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxTemplateImageSize))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid file"})
+	}
+	t, err := h.Templates.SetImage(c.Params("id"), certificateusecase.ImageKind(c.Params("kind")), data)
+	if err != nil {
+		return certificateError(c, err)
+	}
+	return c.JSON(t)
+}
+
+// ReissueTemplateCertificates handles POST /certificate-template/:id/reissue (admin only)
+// Reissues every active certificate rendered from the template after it was updated.
+func (h *CertificateHandler) ReissueTemplateCertificates(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req certificateChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+	}
+	if req.Reason == "" {
+		req.Reason = "template updated"
+	}
+	reissued, err := h.Service.ReissueForTemplate(c.Params("id"), currentUserID(c), req.Reason)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error(), "reissued": reissued})
+	}
+	return c.JSON(fiber.Map{"reissued": reissued})
+}
+
+// certificateError maps certificate service errors to HTTP responses.
+func certificateError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, certificateusecase.ErrCertificateNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Certificate not found"})
+	case errors.Is(err, certificateusecase.ErrTemplateNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Template not found"})
+	case errors.Is(err, certificateusecase.ErrNoTemplate):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, certificateusecase.ErrCertificateRevoked), errors.Is(err, certificateusecase.ErrCertificateSuperseded):
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 }
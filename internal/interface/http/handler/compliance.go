@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"training-portal/internal/domain/compliance"
+	complianceusecase "training-portal/internal/usecase/compliance"
+	enrollmentusecase "training-portal/internal/usecase/enrollment"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ComplianceHandler provides HTTP handlers for recertification compliance
+// and the compliance reports and snapshots given to auditors.
+type ComplianceHandler struct {
+	Service *enrollmentusecase.RecertificationService
+	Reports *complianceusecase.ReportService
+}
+
+var _ = ComplianceHandler{} // Exported for router.go
+
+// GetCompliance handles GET /compliance?userId=&courseId=
+// Returns compliant, expiring_soon or expired per user and recurring course.
+// Learners only see their own status.
+func (h *ComplianceHandler) GetCompliance(c *fiber.Ctx) error {
+	userID := c.Query("userId")
+	if !isApprover(c) {
+		userID = currentUserID(c)
+	}
+	records, err := h.Service.Compliance(userID, c.Query("courseId"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(records)
+}
+
+// GetCertificationHistory handles GET /compliance/history?userId=&courseId=
+// Returns every certification cycle of a user in a course, oldest first.
+func (h *ComplianceHandler) GetCertificationHistory(c *fiber.Ctx) error {
+	userID := c.Query("userId")
+	if userID == "" {
+		userID = currentUserID(c)
+	}
+	if userID != currentUserID(c) && !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	cycles, err := h.Service.History(userID, c.Query("courseId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(cycles)
+}
+
+// GetReport handles GET /compliance/reports/:kind?department=&courseId=&userId=&managerId=&within=&format=csv|pdf
+// kind is courses, departments, overdue, expiring, transcript or team. Learners
+// may only see their own transcript. Managers see their own team, the
+// transcripts of their direct reports and the other reports for their own
+// department; everything else is for staff.
+func (h *ComplianceHandler) GetReport(c *fiber.Ctx) error {
+	kind := compliance.ReportKind(c.Params("kind"))
+	filter, err := complianceFilter(c.Query("department"), c.Query("courseId"), c.Query("userId"), c.Query("within"))
+	if err != nil {
+		return complianceError(c, err)
+	}
+	filter.ManagerID = c.Query("managerId")
+	if kind == compliance.ReportTranscript && filter.UserID == "" {
+		filter.UserID = currentUserID(c)
+	}
+	if kind == compliance.ReportTeam && filter.ManagerID == "" {
+		filter.ManagerID = currentUserID(c)
+	}
+	if err := h.Reports.Scope(kind, &filter, currentUserID(c)); err != nil {
+		return complianceError(c, err)
+	}
+	report, err := h.Reports.Generate(kind, filter, time.Now())
+	if err != nil {
+		return complianceError(c, err)
+	}
+	switch c.Query("format") {
+	case "csv":
+		return h.sendTableCSV(c, "compliance-"+string(kind)+".csv", report.Table())
+	case "pdf":
+		return h.sendTablePDF(c, "compliance-"+string(kind)+".pdf", report.Table())
+	}
+	return c.JSON(report)
+}
+
+// CreateSnapshot handles POST /compliance/snapshots (staff only)
+// Saves a report as it is now. Snapshots cannot be changed or deleted.
+func (h *ComplianceHandler) CreateSnapshot(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req struct {
+		Kind       string `json:"kind"`
+		Department string `json:"department"`
+		CourseID   string `json:"courseId"`
+		UserID     string `json:"userId"`
+		ManagerID  string `json:"managerId"`
+		Within     int    `json:"within"`
+		Note       string `json:"note"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+	}
+	filter := compliance.Filter{Department: req.Department, CourseID: req.CourseID, UserID: req.UserID, ManagerID: req.ManagerID, Within: req.Within}
+	if compliance.ReportKind(req.Kind) == compliance.ReportTeam && filter.ManagerID == "" {
+		filter.ManagerID = currentUserID(c)
+	}
+	snap, err := h.Reports.SaveSnapshot(compliance.ReportKind(req.Kind), filter, req.Note, currentUserID(c), time.Now())
+	if err != nil {
+		return complianceError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(snap)
+}
+
+// ListSnapshots handles GET /compliance/snapshots?kind= (staff and managers)
+// Returns snapshots newest first, without their contents. Managers only see
+// snapshots of reports they may see.
+func (h *ComplianceHandler) ListSnapshots(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	snaps, err := h.Reports.Snapshots(compliance.ReportKind(c.Query("kind")))
+	if err != nil {
+		return complianceError(c, err)
+	}
+	if isStaff(c) {
+		return c.JSON(snaps)
+	}
+	visible := []*compliance.Snapshot{}
+	for _, snap := range snaps {
+		ok, err := h.Reports.Visible(snap.Kind, snap.Filter, currentUserID(c))
+		if err != nil {
+			return complianceError(c, err)
+		}
+		if ok {
+			visible = append(visible, snap)
+		}
+	}
+	return c.JSON(visible)
+}
+
+// GetSnapshot handles GET /compliance/snapshot/:id?format=csv|pdf (staff and managers)
+// The snapshot is checked against its hash before it is returned. Managers
+// only get snapshots of reports they may see.
+func (h *ComplianceHandler) GetSnapshot(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	snap, err := h.Reports.Snapshot(c.Params("id"))
+	if err != nil {
+		return complianceError(c, err)
+	}
+	if !isStaff(c) {
+		ok, err := h.Reports.Visible(snap.Kind, snap.Filter, currentUserID(c))
+		if err != nil {
+			return complianceError(c, err)
+		}
+		if !ok {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+		}
+	}
+	c.Set("X-Snapshot-SHA256", snap.Hash)
+	switch c.Query("format") {
+	case "csv":
+		return h.sendTableCSV(c, "compliance-snapshot-"+snap.ID+".csv", &snap.Table)
+	case "pdf":
+		return h.sendTablePDF(c, "compliance-snapshot-"+snap.ID+".pdf", &snap.Table)
+	}
+	return c.JSON(snap)
+}
+
+func complianceFilter(department, courseID, userID, within string) (compliance.Filter, error) {
+	filter := compliance.Filter{Department: department, CourseID: courseID, UserID: userID}
+	if within != "" {
+		days, err := strconv.Atoi(within)
+		if err != nil || days < 0 {
+			return filter, fmt.Errorf("%w: within must be a number of days", complianceusecase.ErrInvalidFilter)
+		}
+		filter.Within = days
+	}
+	return filter, nil
+}
+
+func (h *ComplianceHandler) sendTableCSV(c *fiber.Ctx, filename string, t *compliance.Table) error {
+	data, err := h.Reports.CSV(t)
+	if err != nil {
+		return complianceError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return c.Send(data)
+}
+
+func (h *ComplianceHandler) sendTablePDF(c *fiber.Ctx, filename string, t *compliance.Table) error {
+	pdf, err := h.Reports.PDF(t)
+	if err != nil {
+		return complianceError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return c.Send(pdf)
+}
+
+func complianceError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, complianceusecase.ErrUnknownReport),
+		errors.Is(err, complianceusecase.ErrUserNotFound),
+		errors.Is(err, complianceusecase.ErrSnapshotNotFound),
+		errors.Is(err, complianceusecase.ErrScheduleNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, complianceusecase.ErrInvalidFilter),
+		errors.Is(err, complianceusecase.ErrInvalidSchedule):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, complianceusecase.ErrForbidden),
+		errors.Is(err, complianceusecase.ErrReportForbidden),
+		errors.Is(err, complianceusecase.ErrInvalidLink):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, complianceusecase.ErrLinkExpired):
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
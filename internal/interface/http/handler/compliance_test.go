@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"training-portal/internal/domain/compliance"
+	"training-portal/internal/domain/user"
+	complianceusecase "training-portal/internal/usecase/compliance"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockComplianceRepository serves empty reports and fixed transcripts and
+// snapshots, and records the filter of the last report
+type MockComplianceRepository struct {
+	filter    compliance.Filter
+	snapshots map[string]*compliance.Snapshot
+}
+
+func (m *MockComplianceRepository) CourseCompletion(filter compliance.Filter) ([]*compliance.CourseCompletion, error) {
+	m.filter = filter
+	return nil, nil
+}
+
+func (m *MockComplianceRepository) DepartmentCompletion(filter compliance.Filter) ([]*compliance.DepartmentCompletion, error) {
+	m.filter = filter
+	return nil, nil
+}
+
+func (m *MockComplianceRepository) ListExpiring(filter compliance.Filter, until int64) ([]*compliance.ExpiringCertification, error) {
+	m.filter = filter
+	return nil, nil
+}
+
+func (m *MockComplianceRepository) Transcript(userID string) (*compliance.Transcript, error) {
+	m.filter = compliance.Filter{UserID: userID}
+	return &compliance.Transcript{UserID: userID}, nil
+}
+
+func (m *MockComplianceRepository) TeamProgress(filter compliance.Filter) ([]*compliance.TeamProgress, error) {
+	m.filter = filter
+	return nil, nil
+}
+
+func (m *MockComplianceRepository) CreateSnapshot(s *compliance.Snapshot) error { return nil }
+
+func (m *MockComplianceRepository) FindSnapshot(id string) (*compliance.Snapshot, error) {
+	return m.snapshots[id], nil
+}
+
+func (m *MockComplianceRepository) ListSnapshots(kind compliance.ReportKind) ([]*compliance.Snapshot, error) {
+	return nil, nil
+}
+
+// MockUserFinder serves fixed users by ID
+type MockUserFinder map[string]*user.User
+
+func (m MockUserFinder) FindByID(id string) (*user.User, error) { return m[id], nil }
+
+// newComplianceTestApp routes the report endpoints, authenticating every
+// request as the user with the given ID and role.
+func newComplianceTestApp(repo *MockComplianceRepository, userID string, role user.Role) *fiber.App {
+	h := &ComplianceHandler{Reports: &complianceusecase.ReportService{
+		Repo: repo,
+		Users: MockUserFinder{
+			"admin":   {ID: "admin", Role: user.RoleAdmin},
+			"manager": {ID: "manager", Role: user.RoleManager, Department: "Ops"},
+			"report":  {ID: "report", Role: user.RoleEmployee, Department: "Ops", ManagerID: "manager"},
+			"other":   {ID: "other", Role: user.RoleEmployee, Department: "Sales"},
+		},
+	}}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID)
+		c.Locals("role", string(role))
+		return c.Next()
+	})
+	app.Get("/compliance/reports/:kind", h.GetReport)
+	app.Get("/compliance/snapshot/:id", h.GetSnapshot)
+	return app
+}
+
+func TestComplianceHandler_GetReportScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		role           user.Role
+		url            string
+		expectedStatus int
+		expectedFilter compliance.Filter
+	}{
+		{"Staff see any transcript", "admin", user.RoleAdmin, "/compliance/reports/transcript?userId=other", fiber.StatusOK, compliance.Filter{UserID: "other"}},
+		{"Staff see any department", "admin", user.RoleAdmin, "/compliance/reports/courses?department=Sales", fiber.StatusOK, compliance.Filter{Department: "Sales"}},
+		{"Manager sees a direct report's transcript", "manager", user.RoleManager, "/compliance/reports/transcript?userId=report", fiber.StatusOK, compliance.Filter{UserID: "report"}},
+		{"Manager cannot see other transcripts", "manager", user.RoleManager, "/compliance/reports/transcript?userId=other", fiber.StatusForbidden, compliance.Filter{}},
+		{"Manager reports are kept to their department", "manager", user.RoleManager, "/compliance/reports/courses?department=Sales", fiber.StatusOK, compliance.Filter{Department: "Ops"}},
+		{"Manager only sees their own team", "manager", user.RoleManager, "/compliance/reports/team?managerId=someone", fiber.StatusOK, compliance.Filter{ManagerID: "manager"}},
+		{"Learner sees their own transcript", "report", user.RoleEmployee, "/compliance/reports/transcript", fiber.StatusOK, compliance.Filter{UserID: "report"}},
+		{"Learner cannot see department reports", "report", user.RoleEmployee, "/compliance/reports/courses", fiber.StatusForbidden, compliance.Filter{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockComplianceRepository{}
+			app := newComplianceTestApp(repo, tt.userID, tt.role)
+
+			resp, err := app.Test(httptest.NewRequest("GET", tt.url, nil))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tt.expectedFilter, repo.filter)
+		})
+	}
+}
+
+func TestComplianceHandler_GetSnapshotScope(t *testing.T) {
+	snapshot := func(kind compliance.ReportKind, filter compliance.Filter) *compliance.Snapshot {
+		return &compliance.Snapshot{Kind: kind, Filter: filter}
+	}
+	repo := &MockComplianceRepository{snapshots: map[string]*compliance.Snapshot{
+		"ops":       snapshot(compliance.ReportCourses, compliance.Filter{Department: "Ops"}),
+		"org":       snapshot(compliance.ReportCourses, compliance.Filter{}),
+		"team":      snapshot(compliance.ReportTeam, compliance.Filter{ManagerID: "manager"}),
+		"other-cv":  snapshot(compliance.ReportTranscript, compliance.Filter{UserID: "other"}),
+		"report-cv": snapshot(compliance.ReportTranscript, compliance.Filter{UserID: "report"}),
+	}}
+	for _, s := range repo.snapshots {
+		s.Hash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // SHA-256 of no data
+	}
+
+	tests := []struct {
+		id             string
+		expectedStatus int
+	}{
+		{"ops", fiber.StatusOK},
+		{"org", fiber.StatusForbidden},
+		{"team", fiber.StatusOK},
+		{"other-cv", fiber.StatusForbidden},
+		{"report-cv", fiber.StatusOK},
+	}
+	app := newComplianceTestApp(repo, "manager", user.RoleManager)
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", "/compliance/snapshot/"+tt.id, nil))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+		})
+	}
+}
@@ -1,83 +1,290 @@
 package handler
 
 import (
-	"strconv"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/interface/csv"
+	enrollmentusecase "training-portal/internal/usecase/enrollment"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// Enrollment represents a user's enrollment in a course.
-type Enrollment struct {
-	ID       string `json:"id"`
-	UserID   string `json:"userId"`
-	CourseID string `json:"courseId"`
+// EnrollmentHandler provides HTTP handlers for enrollment-related endpoints.
+type EnrollmentHandler struct {
+	Service   *enrollmentusecase.EnrollmentService
+	Deadlines *enrollmentusecase.DeadlineService
 }
 
-// EnrollmentHandler provides HTTP handlers for enrollment-related endpoints.
-type EnrollmentHandler struct{}
+var _ = EnrollmentHandler{} // Exported for router.go
 
-// In-memory storage for enrollments (replace with DB in the future)
-var enrollments = []Enrollment{}
+type enrollmentRequest struct {
+	UserID    string `json:"userId"`
+	CourseID  string `json:"courseId"`
+	Reason    string `json:"reason"`
+	DueAt     int64  `json:"dueAt"`
+	GraceDays int    `json:"graceDays"`
+}
+
+// parseEnrollmentRequest reads the request body and defaults the user to the caller.
+// Only staff may act on another user's enrollment. When the request is rejected the
+// returned enrollmentRequest is nil and the error response has already been written.
+func parseEnrollmentRequest(c *fiber.Ctx) (*enrollmentRequest, error) {
+	var req enrollmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+	}
+	if req.UserID == "" {
+		req.UserID = currentUserID(c)
+	}
+	if req.UserID != currentUserID(c) && !isStaff(c) {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	return &req, nil
+}
 
 // EnrollUser handles POST /enroll
+// Learners enrolling themselves follow the course's enrollment mode and may end up
+// pending approval or waitlisted; staff enrolling someone else bypass the mode
+// and may set a due date and grace period for mandatory training.
 func (h *EnrollmentHandler) EnrollUser(c *fiber.Ctx) error {
+	req, resp := parseEnrollmentRequest(c)
+	if req == nil {
+		return resp
+	}
+	enroll := h.Service.Enroll
+	if req.UserID != currentUserID(c) {
+		enroll = h.Service.Assign
+	}
+	e, err := enroll(req.UserID, req.CourseID, currentUserID(c))
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+	if req.DueAt != 0 && isStaff(c) {
+		if e, err = h.Deadlines.SetDeadline(e.ID, req.DueAt, req.GraceDays, currentUserID(c)); err != nil {
+			return enrollmentError(c, err)
+		}
+	}
+	return c.JSON(e)
+}
+
+// SetDeadline handles PUT /enrollment/:id/deadline (staff only)
+// A dueAt of zero removes the deadline. Changing the deadline resets escalation.
+func (h *EnrollmentHandler) SetDeadline(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
 	var req struct {
-		UserID   string `json:"userId"`
-		CourseID string `json:"courseId"`
+		DueAt     int64 `json:"dueAt"`
+		GraceDays int   `json:"graceDays"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
 	}
+	e, err := h.Deadlines.SetDeadline(c.Params("id"), req.DueAt, req.GraceDays, currentUserID(c))
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+	return c.JSON(e)
+}
 
-	// Check if user is already enrolled
-	for _, e := range enrollments {
-		if e.UserID == req.UserID && e.CourseID == req.CourseID {
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "User already enrolled in this course"})
-		}
+// OverdueReport handles GET /reports/overdue?department=&format=csv (staff and managers)
+// Returns overdue training as JSON, or as a CSV download when format=csv.
+func (h *EnrollmentHandler) OverdueReport(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	records, err := h.Deadlines.OverdueReport(c.Query("department"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if c.Query("format") != "csv" {
+		return c.JSON(records)
 	}
 
-	enrollment := Enrollment{
-		ID:       strconv.Itoa(len(enrollments) + 1),
-		UserID:   req.UserID,
-		CourseID: req.CourseID,
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="overdue-training.csv"`)
+	w := csv.NewWriter(c.Response().BodyWriter())
+	w.Write([]string{"department", "user_id", "name", "email", "course_id", "course", "assigned_by", "due_at", "overdue_at", "escalation_level"})
+	for _, r := range records {
+		w.Write([]string{
+			r.Department,
+			r.UserID,
+			r.UserName,
+			r.UserEmail,
+			r.CourseID,
+			r.CourseTitle,
+			r.AssignedBy,
+			formatDate(r.DueAt),
+			formatDate(r.OverdueAt),
+			r.EscalationLevel.String(),
+		})
 	}
-	enrollments = append(enrollments, enrollment)
+	w.Flush()
+	return w.Error()
+}
 
-	return c.Status(fiber.StatusCreated).JSON(enrollment)
+// InviteUser handles POST /enrollment/invite (staff only)
+func (h *EnrollmentHandler) InviteUser(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	req, resp := parseEnrollmentRequest(c)
+	if req == nil {
+		return resp
+	}
+	e, err := h.Service.Invite(req.UserID, req.CourseID, currentUserID(c))
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+	return c.JSON(e)
 }
 
-// UnenrollUser handles POST /unenroll
-func (h *EnrollmentHandler) UnenrollUser(c *fiber.Ctx) error {
+// ListPendingApprovals handles GET /enrollments/pending?courseId=
+// Returns the approval queue, oldest request first.
+func (h *EnrollmentHandler) ListPendingApprovals(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	pending, err := h.Service.PendingApprovals(c.Query("courseId"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(pending)
+}
+
+// ApproveEnrollment handles POST /enrollment/:id/approve
+func (h *EnrollmentHandler) ApproveEnrollment(c *fiber.Ctx) error {
+	return h.decide(c, h.Service.Approve)
+}
+
+// RejectEnrollment handles POST /enrollment/:id/reject
+func (h *EnrollmentHandler) RejectEnrollment(c *fiber.Ctx) error {
+	return h.decide(c, h.Service.Reject)
+}
+
+func (h *EnrollmentHandler) decide(c *fiber.Ctx, decision func(id, actorID, reason string) (*enrollment.Enrollment, error)) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
 	var req struct {
-		UserID   string `json:"userId"`
-		CourseID string `json:"courseId"`
+		Reason string `json:"reason"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
 	}
+	e, err := decision(c.Params("id"), currentUserID(c), req.Reason)
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+	return c.JSON(e)
+}
 
-	for i, e := range enrollments {
-		if e.UserID == req.UserID && e.CourseID == req.CourseID {
-			// Remove enrollment
-			enrollments = append(enrollments[:i], enrollments[i+1:]...)
-			return c.JSON(fiber.Map{"message": "User unenrolled successfully"})
-		}
+// GetWaitlist handles GET /course/:id/waitlist (staff only)
+// Returns waitlisted enrollments in promotion order.
+func (h *EnrollmentHandler) GetWaitlist(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
 	}
+	waitlist, err := h.Service.Waitlist(c.Params("id"))
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+	return c.JSON(waitlist)
+}
 
-	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Enrollment not found"})
+// UnenrollUser handles POST /unenroll
+func (h *EnrollmentHandler) UnenrollUser(c *fiber.Ctx) error {
+	req, resp := parseEnrollmentRequest(c)
+	if req == nil {
+		return resp
+	}
+	e, err := h.Service.Drop(req.UserID, req.CourseID, currentUserID(c), req.Reason)
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "User unenrolled successfully", "enrollment": e})
+}
+
+// CompleteEnrollment handles POST /enrollment/complete (staff only)
+func (h *EnrollmentHandler) CompleteEnrollment(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	req, resp := parseEnrollmentRequest(c)
+	if req == nil {
+		return resp
+	}
+	e, err := h.Service.Complete(req.UserID, req.CourseID, currentUserID(c))
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+	return c.JSON(e)
 }
 
 // ListEnrollments handles GET /enrollments
+// Non-staff users only see their own enrollments.
 func (h *EnrollmentHandler) ListEnrollments(c *fiber.Ctx) error {
 	userID := c.Query("userId")
 	courseID := c.Query("courseId")
+	if !isStaff(c) {
+		userID = currentUserID(c)
+	}
 
-	var filtered []Enrollment
-	for _, e := range enrollments {
-		if (userID == "" || e.UserID == userID) && (courseID == "" || e.CourseID == courseID) {
-			filtered = append(filtered, e)
-		}
+	enrollments, err := h.Service.ListEnrollments(userID, courseID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(enrollments)
+}
+
+// GetEnrollmentHistory handles GET /enrollment/:id/history
+func (h *EnrollmentHandler) GetEnrollmentHistory(c *fiber.Ctx) error {
+	id := c.Params("id")
+	e, err := h.Service.GetEnrollment(id)
+	if err != nil {
+		return enrollmentError(c, err)
+	}
+	if e.UserID != currentUserID(c) && !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	history, err := h.Service.History(id)
+	if err != nil {
+		return enrollmentError(c, err)
 	}
+	return c.JSON(history)
+}
+
+// enrollmentError maps enrollment service errors to HTTP responses.
+func enrollmentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, enrollmentusecase.ErrEnrollmentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Enrollment not found"})
+	case errors.Is(err, enrollmentusecase.ErrCourseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course not found"})
+	case errors.Is(err, enrollmentusecase.ErrInvitationRequired):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, enrollmentusecase.ErrInvalidTransition):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+}
 
-	return c.JSON(filtered)
+// formatDate renders a Unix timestamp as an RFC 3339 date, or "" when unset.
+func formatDate(unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
+
+// canAccessCourse reports whether the caller may view a course's content.
+// Staff always can; learners need an active or completed enrollment.
+func canAccessCourse(c *fiber.Ctx, enrollments *enrollmentusecase.EnrollmentService, courseID string) (bool, error) {
+	if isStaff(c) {
+		return true, nil
+	}
+	return enrollments.IsEnrolled(currentUserID(c), courseID)
 }
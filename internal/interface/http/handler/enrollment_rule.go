@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+
+	"training-portal/internal/domain/enrollment"
+	enrollmentusecase "training-portal/internal/usecase/enrollment"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// EnrollmentRuleHandler provides admin HTTP handlers for auto-enrollment rules.
+type EnrollmentRuleHandler struct {
+	Service *enrollmentusecase.RuleService
+}
+
+var _ = EnrollmentRuleHandler{} // Exported for router.go
+
+type enrollmentRuleRequest struct {
+	Name     string               `json:"name"`
+	Field    enrollment.RuleField `json:"field"`
+	Value    string               `json:"value"`
+	CourseID string               `json:"courseId"`
+	Active   *bool                `json:"active"`
+}
+
+func (req *enrollmentRuleRequest) rule() *enrollment.Rule {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	return &enrollment.Rule{
+		Name:     req.Name,
+		Field:    req.Field,
+		Value:    req.Value,
+		CourseID: req.CourseID,
+		Active:   active,
+	}
+}
+
+// CreateRule handles POST /enrollment-rules
+func (h *EnrollmentRuleHandler) CreateRule(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req enrollmentRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	rule := req.rule()
+	rule.CreatedBy = currentUserID(c)
+	if err := h.Service.CreateRule(rule); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// ListRules handles GET /enrollment-rules
+func (h *EnrollmentRuleHandler) ListRules(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	rules, err := h.Service.ListRules()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(rules)
+}
+
+// UpdateRule handles PUT /enrollment-rule/:id
+func (h *EnrollmentRuleHandler) UpdateRule(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req enrollmentRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	rule := req.rule()
+	rule.ID = c.Params("id")
+	if err := h.Service.UpdateRule(rule); err != nil {
+		return ruleError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Rule updated"})
+}
+
+// DeleteRule handles DELETE /enrollment-rule/:id
+func (h *EnrollmentRuleHandler) DeleteRule(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	if err := h.Service.DeleteRule(c.Params("id")); err != nil {
+		return ruleError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Rule deleted"})
+}
+
+// PreviewRule handles POST /enrollment-rules/preview
+// Reports how many users the rule in the body would affect, without saving it.
+func (h *EnrollmentRuleHandler) PreviewRule(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req enrollmentRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	preview, err := h.Service.Preview(req.rule())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"matchingUsers":  preview.MatchingUsers,
+		"newEnrollments": preview.NewEnrollments,
+	})
+}
+
+// GetRuleAudit handles GET /enrollment-rule/:id/audit
+func (h *EnrollmentRuleHandler) GetRuleAudit(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	audit, err := h.Service.Audit(c.Params("id"))
+	if err != nil {
+		return ruleError(c, err)
+	}
+	return c.JSON(audit)
+}
+
+// EvaluateRules handles POST /enrollment-rules/evaluate
+// Runs all active rules against every user immediately.
+func (h *EnrollmentRuleHandler) EvaluateRules(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	created, err := h.Service.EvaluateAll()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"enrollmentsCreated": created})
+}
+
+func ruleError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, enrollmentusecase.ErrRuleNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+}
@@ -1,45 +1,27 @@
 package handler
 
 import (
-	"strconv"
+	"errors"
 	"time"
 
+	"training-portal/internal/domain/forum"
+	"training-portal/internal/domain/notification"
+	forumusecase "training-portal/internal/usecase/forum"
+
 	"github.com/gofiber/fiber/v2"
 )
 
-// Forum represents a forum structure.
-type Forum struct {
-	ID    string `json:"id"`
-	Title string `json:"title"`
-}
-
-// Post represents a post in a forum.
-type Post struct {
-	ID       string    `json:"id"`
-	ForumID  string    `json:"forumId"`
-	AuthorID string    `json:"authorId"`
-	Content  string    `json:"content"`
-	Created  time.Time `json:"created"`
+// ForumHandler provides HTTP handlers for course and module forums. Authors
+// are always the authenticated user. Posts and replies are written in
+// Markdown and returned with an HTML field that is safe to embed.
+type ForumHandler struct {
+	Service *forumusecase.ForumService
 }
 
-// Reply represents a reply to a post.
-type Reply struct {
-	ID       string    `json:"id"`
-	PostID   string    `json:"postId"`
-	AuthorID string    `json:"authorId"`
-	Content  string    `json:"content"`
-	Created  time.Time `json:"created"`
-}
-
-// ForumHandler provides HTTP handlers for forum-related endpoints.
-type ForumHandler struct{}
-
-// In-memory storage (replace with DB in the future)
-var forums = []Forum{}
-var posts = []Post{}
-var replies = []Reply{}
+var _ = ForumHandler{} // Exported for router.go
 
-// CreateForum handles POST /forum
+// CreateForum handles POST /forums (staff only)
+// Creates a general forum; course and module forums are created automatically.
 func (h *ForumHandler) CreateForum(c *fiber.Ctx) error {
 	var req struct {
 		Title string `json:"title"`
@@ -47,95 +29,450 @@ func (h *ForumHandler) CreateForum(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
+	f, err := h.Service.CreateForum(req.Title, forumActor(c))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(f)
+}
 
-	forum := Forum{
-		ID:    strconv.Itoa(len(forums) + 1),
-		Title: req.Title,
+// ListForums handles GET /forums?course_id=
+// Without course_id it lists the general forums; with it, the course forum
+// followed by its module forums.
+func (h *ForumHandler) ListForums(c *fiber.Ctx) error {
+	forums, err := h.Service.ListForums(c.Query("course_id"), forumActor(c))
+	if err != nil {
+		return forumError(c, err)
 	}
-	forums = append(forums, forum)
-	return c.Status(fiber.StatusCreated).JSON(forum)
+	return c.JSON(forums)
 }
 
 // GetForum handles GET /forum/:id
 func (h *ForumHandler) GetForum(c *fiber.Ctx) error {
-	id := c.Params("id")
-	for _, f := range forums {
-		if f.ID == id {
-			return c.JSON(f)
-		}
+	f, err := h.Service.GetForum(c.Params("id"), forumActor(c))
+	if err != nil {
+		return forumError(c, err)
 	}
-	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Forum not found"})
+	return c.JSON(f)
 }
 
-// ListForums handles GET /forums
-func (h *ForumHandler) ListForums(c *fiber.Ctx) error {
-	return c.JSON(forums)
+// GetCourseForum handles GET /course/:id/forum
+func (h *ForumHandler) GetCourseForum(c *fiber.Ctx) error {
+	f, err := h.Service.CourseForum(c.Params("id"), forumActor(c))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(f)
+}
+
+// GetModuleForum handles GET /module/:id/forum
+func (h *ForumHandler) GetModuleForum(c *fiber.Ctx) error {
+	f, err := h.Service.ModuleForum(c.Params("id"), forumActor(c))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(f)
 }
 
-// CreatePost handles POST /forum/:forum_id/post
+// ListPosts handles GET /forum/:id/posts?sort=&page=&limit=
+// Sorts threads by activity (default), votes or newest; sort=unanswered lists
+// only unanswered questions.
+func (h *ForumHandler) ListPosts(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	sort := forum.PostSort(c.Query("sort"))
+	posts, total, err := h.Service.ListPosts(c.Params("id"), forumActor(c), sort, page, c.QueryInt("limit", 20))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(fiber.Map{"posts": posts, "total": total, "page": page})
+}
+
+// CreatePost handles POST /forum/:id/posts
+// Type is "discussion" (default) or "question".
 func (h *ForumHandler) CreatePost(c *fiber.Ctx) error {
-	forumID := c.Params("forum_id")
 	var req struct {
-		AuthorID string `json:"authorId"`
-		Content  string `json:"content"`
+		Type    string `json:"type"`
+		Title   string `json:"title"`
+		Content string `json:"content"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
+	p, err := h.Service.CreatePost(c.Params("id"), forumActor(c), forum.PostType(req.Type), req.Title, req.Content)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(p)
+}
 
-	post := Post{
-		ID:       strconv.Itoa(len(posts) + 1),
-		ForumID:  forumID,
-		AuthorID: req.AuthorID,
-		Content:  req.Content,
-		Created:  time.Now(),
+// GetPost handles GET /post/:id
+func (h *ForumHandler) GetPost(c *fiber.Ctx) error {
+	p, err := h.Service.GetPost(c.Params("id"), forumActor(c))
+	if err != nil {
+		return forumError(c, err)
 	}
-	posts = append(posts, post)
-	return c.Status(fiber.StatusCreated).JSON(post)
+	return c.JSON(p)
 }
 
-// ListPosts handles GET /forum/:forum_id/posts
-func (h *ForumHandler) ListPosts(c *fiber.Ctx) error {
-	forumID := c.Params("forum_id")
-	var forumPosts []Post
-	for _, p := range posts {
-		if p.ForumID == forumID {
-			forumPosts = append(forumPosts, p)
-		}
+// UpdatePost handles PUT /post/:id (author or staff)
+func (h *ForumHandler) UpdatePost(c *fiber.Ctx) error {
+	var req struct {
+		Title   string `json:"title"`
+		Content string `json:"content"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	p, err := h.Service.EditPost(c.Params("id"), forumActor(c), req.Title, req.Content)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(p)
+}
+
+// DeletePost handles DELETE /post/:id (author or staff)
+func (h *ForumHandler) DeletePost(c *fiber.Ctx) error {
+	if err := h.Service.DeletePost(c.Params("id"), forumActor(c)); err != nil {
+		return forumError(c, err)
 	}
-	return c.JSON(forumPosts)
+	return c.JSON(fiber.Map{"message": "Post deleted"})
 }
 
-// CreateReply handles POST /post/:post_id/reply
+// GetPostHistory handles GET /post/:id/history (author or staff)
+func (h *ForumHandler) GetPostHistory(c *fiber.Ctx) error {
+	revisions, err := h.Service.PostHistory(c.Params("id"), forumActor(c))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(revisions)
+}
+
+// ListReplies handles GET /post/:id/replies?page=&limit=
+// Returns replies oldest first.
+func (h *ForumHandler) ListReplies(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	replies, total, err := h.Service.ListReplies(c.Params("id"), forumActor(c), page, c.QueryInt("limit", 20))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(fiber.Map{"replies": replies, "total": total, "page": page})
+}
+
+// CreateReply handles POST /post/:id/replies
 func (h *ForumHandler) CreateReply(c *fiber.Ctx) error {
-	postID := c.Params("post_id")
 	var req struct {
-		AuthorID string `json:"authorId"`
-		Content  string `json:"content"`
+		Content string `json:"content"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	r, err := h.Service.Reply(c.Params("id"), forumActor(c), req.Content)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(r)
+}
+
+// UpdateReply handles PUT /reply/:id (author or staff)
+func (h *ForumHandler) UpdateReply(c *fiber.Ctx) error {
+	var req struct {
+		Content string `json:"content"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
+	r, err := h.Service.EditReply(c.Params("id"), forumActor(c), req.Content)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(r)
+}
 
-	reply := Reply{
-		ID:       strconv.Itoa(len(replies) + 1),
-		PostID:   postID,
-		AuthorID: req.AuthorID,
-		Content:  req.Content,
-		Created:  time.Now(),
+// DeleteReply handles DELETE /reply/:id (author or staff)
+func (h *ForumHandler) DeleteReply(c *fiber.Ctx) error {
+	if err := h.Service.DeleteReply(c.Params("id"), forumActor(c)); err != nil {
+		return forumError(c, err)
 	}
-	replies = append(replies, reply)
-	return c.Status(fiber.StatusCreated).JSON(reply)
+	return c.JSON(fiber.Map{"message": "Reply deleted"})
 }
 
-// ListReplies handles GET /post/:post_id/replies
-func (h *ForumHandler) ListReplies(c *fiber.Ctx) error {
-	postID := c.Params("post_id")
-	var postReplies []Reply
-	for _, r := range replies {
-		if r.PostID == postID {
-			postReplies = append(postReplies, r)
-		}
-	}
-	return c.JSON(postReplies)
+// GetReplyHistory handles GET /reply/:id/history (author or staff)
+func (h *ForumHandler) GetReplyHistory(c *fiber.Ctx) error {
+	revisions, err := h.Service.ReplyHistory(c.Params("id"), forumActor(c))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(revisions)
+}
+
+// VotePost handles POST /post/:id/vote
+// Value is 1 to upvote a question, -1 to downvote it and 0 to withdraw the vote.
+func (h *ForumHandler) VotePost(c *fiber.Ctx) error {
+	var req struct {
+		Value int `json:"value"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	score, err := h.Service.VotePost(c.Params("id"), forumActor(c), req.Value)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(fiber.Map{"score": score, "vote": req.Value})
+}
+
+// VoteReply handles POST /reply/:id/vote
+func (h *ForumHandler) VoteReply(c *fiber.Ctx) error {
+	var req struct {
+		Value int `json:"value"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	score, err := h.Service.VoteReply(c.Params("id"), forumActor(c), req.Value)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(fiber.Map{"score": score, "vote": req.Value})
+}
+
+// AcceptAnswer handles PUT /post/:id/accepted (asker or staff)
+// Marks replyId as the question's accepted answer; an empty replyId clears it.
+func (h *ForumHandler) AcceptAnswer(c *fiber.Ctx) error {
+	var req struct {
+		ReplyID string `json:"replyId"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	p, err := h.Service.AcceptAnswer(c.Params("id"), req.ReplyID, forumActor(c))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(p)
+}
+
+// ListUnanswered handles GET /course/:id/unanswered?page=&limit= (staff only)
+// Returns the course's unanswered questions across its forums, oldest first.
+func (h *ForumHandler) ListUnanswered(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	posts, total, err := h.Service.UnansweredQuestions(c.Params("id"), forumActor(c), page, c.QueryInt("limit", 20))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(fiber.Map{"posts": posts, "total": total, "page": page})
+}
+
+// ReportPost handles POST /post/:id/report
+func (h *ForumHandler) ReportPost(c *fiber.Ctx) error {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	report, err := h.Service.ReportPost(c.Params("id"), forumActor(c), req.Reason)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
+
+// ReportReply handles POST /reply/:id/report
+func (h *ForumHandler) ReportReply(c *fiber.Ctx) error {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	report, err := h.Service.ReportReply(c.Params("id"), forumActor(c), req.Reason)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
+
+// ModerationQueue handles GET /moderation/queue?course_id= (staff only)
+// Lists held and reported posts and replies, oldest first.
+func (h *ForumHandler) ModerationQueue(c *fiber.Ctx) error {
+	items, err := h.Service.ModerationQueue(c.Query("course_id"), forumActor(c))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(items)
+}
+
+// ModerationLog handles GET /moderation/log?course_id=&limit= (staff only)
+func (h *ForumHandler) ModerationLog(c *fiber.Ctx) error {
+	actions, err := h.Service.ModerationLog(c.Query("course_id"), forumActor(c), c.QueryInt("limit", 50))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(actions)
+}
+
+// ModeratePost handles POST /post/:id/moderate (staff only)
+// Action is approve, hide, restore, dismiss, lock, unlock, pin or unpin; a
+// reason is required and recorded in the moderation log.
+func (h *ForumHandler) ModeratePost(c *fiber.Ctx) error {
+	var req struct {
+		Action string `json:"action"`
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	p, err := h.Service.ModeratePost(c.Params("id"), forumActor(c), forum.ModerationActionType(req.Action), req.Reason)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(p)
+}
+
+// ModerateReply handles POST /reply/:id/moderate (staff only)
+// Action is approve, hide, restore or dismiss.
+func (h *ForumHandler) ModerateReply(c *fiber.Ctx) error {
+	var req struct {
+		Action string `json:"action"`
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	r, err := h.Service.ModerateReply(c.Params("id"), forumActor(c), forum.ModerationActionType(req.Action), req.Reason)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(r)
+}
+
+// ListSuspensions handles GET /course/:id/suspensions (staff only)
+func (h *ForumHandler) ListSuspensions(c *fiber.Ctx) error {
+	suspensions, err := h.Service.ListSuspensions(c.Params("id"), forumActor(c))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(suspensions)
+}
+
+// Suspend handles POST /course/:id/suspensions (staff only)
+// Keeps userId from posting in the course's forums for the given days.
+func (h *ForumHandler) Suspend(c *fiber.Ctx) error {
+	var req struct {
+		UserID string `json:"userId"`
+		Days   int    `json:"days"`
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	duration := time.Duration(req.Days) * 24 * time.Hour
+	s, err := h.Service.Suspend(c.Params("id"), req.UserID, forumActor(c), duration, req.Reason)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(s)
+}
+
+// LiftSuspension handles DELETE /suspension/:id (staff only)
+func (h *ForumHandler) LiftSuspension(c *fiber.Ctx) error {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	s, err := h.Service.LiftSuspension(c.Params("id"), forumActor(c), req.Reason)
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(s)
+}
+
+// ListSubscriptions handles GET /forums/subscriptions
+// Lists the authenticated user's forum and thread subscriptions.
+func (h *ForumHandler) ListSubscriptions(c *fiber.Ctx) error {
+	subs, err := h.Service.ListSubscriptions(forumActor(c))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(subs)
+}
+
+// SubscribeForum handles PUT /forum/:id/subscription
+// Digest is immediate, daily or weekly; empty follows the user's notification
+// preferences.
+func (h *ForumHandler) SubscribeForum(c *fiber.Ctx) error {
+	var req struct {
+		Digest string `json:"digest"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	sub, err := h.Service.SubscribeForum(c.Params("id"), forumActor(c), notification.DigestMode(req.Digest))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(sub)
+}
+
+// UnsubscribeForum handles DELETE /forum/:id/subscription
+func (h *ForumHandler) UnsubscribeForum(c *fiber.Ctx) error {
+	if err := h.Service.UnsubscribeForum(c.Params("id"), forumActor(c)); err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Unsubscribed"})
+}
+
+// SubscribeThread handles PUT /post/:id/subscription
+func (h *ForumHandler) SubscribeThread(c *fiber.Ctx) error {
+	var req struct {
+		Digest string `json:"digest"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	sub, err := h.Service.SubscribeThread(c.Params("id"), forumActor(c), notification.DigestMode(req.Digest))
+	if err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(sub)
+}
+
+// UnsubscribeThread handles DELETE /post/:id/subscription
+func (h *ForumHandler) UnsubscribeThread(c *fiber.Ctx) error {
+	if err := h.Service.UnsubscribeThread(c.Params("id"), forumActor(c)); err != nil {
+		return forumError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Unsubscribed"})
+}
+
+// forumActor returns the authenticated user as a forum actor.
+func forumActor(c *fiber.Ctx) forumusecase.Actor {
+	return forumusecase.Actor{UserID: currentUserID(c), Staff: isStaff(c)}
+}
+
+// forumError maps forum service errors to HTTP responses.
+func forumError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, forumusecase.ErrForumNotFound),
+		errors.Is(err, forumusecase.ErrPostNotFound),
+		errors.Is(err, forumusecase.ErrReplyNotFound),
+		errors.Is(err, forumusecase.ErrCourseNotFound),
+		errors.Is(err, forumusecase.ErrModuleNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, forumusecase.ErrEnrollmentRequired):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Enrollment required"})
+	case errors.Is(err, forumusecase.ErrForbidden),
+		errors.Is(err, forumusecase.ErrLocked),
+		errors.Is(err, forumusecase.ErrSuspended):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, forumusecase.ErrAlreadyReported):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, forumusecase.ErrNotQuestion):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 }
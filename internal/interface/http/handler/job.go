@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/job"
+	schedulerusecase "training-portal/internal/usecase/scheduler"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// JobHandler lets admins inspect and control background jobs.
+type JobHandler struct {
+	Scheduler *schedulerusecase.Scheduler
+}
+
+var _ = JobHandler{} // Exported for router.go
+
+type jobResponse struct {
+	*job.Job
+	Running bool `json:"running"`
+}
+
+// ListJobs handles GET /jobs (admin only)
+// Returns every background job with its schedule, last run and lock.
+func (h *JobHandler) ListJobs(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	jobs, err := h.Scheduler.ListJobs()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	now := time.Now().Unix()
+	out := make([]jobResponse, 0, len(jobs))
+	for _, j := range jobs {
+		out = append(out, jobResponse{Job: j, Running: j.Running(now)})
+	}
+	return c.JSON(out)
+}
+
+// PauseJob handles POST /job/:name/pause (admin only)
+func (h *JobHandler) PauseJob(c *fiber.Ctx) error {
+	return h.control(c, h.Scheduler.Pause)
+}
+
+// ResumeJob handles POST /job/:name/resume (admin only)
+func (h *JobHandler) ResumeJob(c *fiber.Ctx) error {
+	return h.control(c, h.Scheduler.Resume)
+}
+
+// RunJob handles POST /job/:name/run (admin only)
+// Makes the job due now; paused jobs stay paused.
+func (h *JobHandler) RunJob(c *fiber.Ctx) error {
+	return h.control(c, h.Scheduler.Trigger)
+}
+
+func (h *JobHandler) control(c *fiber.Ctx, action func(name string) (*job.Job, error)) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	j, err := action(c.Params("name"))
+	if errors.Is(err, schedulerusecase.ErrJobNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Job not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(jobResponse{Job: j, Running: j.Running(time.Now().Unix())})
+}
@@ -1,102 +1,197 @@
 package handler
 
 import (
+	"errors"
+	"io"
+	"net/url"
 	"strconv"
-	"time"
+	"strings"
+
+	messageusecase "training-portal/internal/usecase/message"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// Message represents a chat message structure.
-// Replace with proper domain model and DB integration in the future.
-type Message struct {
-	ID         string    `json:"id"`
-	SenderID   string    `json:"senderId"`
-	ReceiverID string    `json:"receiverId"`
-	Content    string    `json:"content"`
-	Read       bool      `json:"read"`
-	CreatedAt  time.Time `json:"createdAt"`
+// MessageHandler provides HTTP handlers for direct messaging. The sender and
+// reader are always the authenticated user.
+type MessageHandler struct {
+	Service *messageusecase.MessageService
 }
 
-// MessageHandler provides HTTP handlers for messaging features.
-type MessageHandler struct{}
+var _ = MessageHandler{} // Exported for router.go
 
-// In-memory message storage (stub for demonstration purposes)
-var messages = []Message{}
+// ListConversations handles GET /conversations
+// Returns the caller's conversations with their last message and unread count.
+func (h *MessageHandler) ListConversations(c *fiber.Ctx) error {
+	conversations, err := h.Service.ListConversations(currentUserID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(conversations)
+}
 
-// SendMessage handles sending a direct message between users.
-// POST /api/message
-func (h *MessageHandler) SendMessage(c *fiber.Ctx) error {
+// CreateConversation handles POST /conversations
+// With one participant and no title this opens (or returns) the direct
+// conversation with that user; otherwise it creates a group.
+func (h *MessageHandler) CreateConversation(c *fiber.Ctx) error {
 	var req struct {
-		SenderID   string `json:"senderId"`
-		ReceiverID string `json:"receiverId"`
-		Content    string `json:"content"`
+		ParticipantIDs []string `json:"participantIds"`
+		Title          string   `json:"title"`
 	}
-
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request payload",
-		})
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
 	}
-
-	newMessage := Message{
-		ID:         strconv.Itoa(len(messages) + 1),
-		SenderID:   req.SenderID,
-		ReceiverID: req.ReceiverID,
-		Content:    req.Content,
-		Read:       false,
-		CreatedAt:  time.Now(),
+	if len(req.ParticipantIDs) == 1 && req.Title == "" {
+		conv, err := h.Service.StartDirect(currentUserID(c), req.ParticipantIDs[0])
+		if err != nil {
+			return messageError(c, err)
+		}
+		return c.JSON(conv)
 	}
+	conv, err := h.Service.CreateGroup(currentUserID(c), req.Title, req.ParticipantIDs)
+	if err != nil {
+		return messageError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(conv)
+}
 
-	messages = append(messages, newMessage)
-
-	return c.Status(fiber.StatusCreated).JSON(newMessage)
+// GetConversation handles GET /conversation/:id
+// Participants include their read cursors, which clients show as read receipts.
+func (h *MessageHandler) GetConversation(c *fiber.Ctx) error {
+	conv, err := h.Service.GetConversation(c.Params("id"), currentUserID(c))
+	if err != nil {
+		return messageError(c, err)
+	}
+	return c.JSON(conv)
 }
 
-// GetMessages retrieves messages for a user or conversation.
-// GET /api/messages?userId=<id> or GET /api/messages/:userId
-func (h *MessageHandler) GetMessages(c *fiber.Ctx) error {
-	userID := c.Query("userId")
-	if userID == "" {
-		userID = c.Params("userId")
-	}
-	if userID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "User ID is required",
-		})
+// ListMessages handles GET /conversation/:id/messages?before=&limit=
+// Returns messages newest first; pass the returned cursor as before for older ones.
+func (h *MessageHandler) ListMessages(c *fiber.Ctx) error {
+	before, err := strconv.ParseInt(c.Query("before", "0"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid cursor"})
 	}
+	messages, next, err := h.Service.History(c.Params("id"), currentUserID(c), before, c.QueryInt("limit", 50))
+	if err != nil {
+		return messageError(c, err)
+	}
+	return c.JSON(fiber.Map{"messages": messages, "next": next})
+}
 
-	var userMessages []Message
-	for _, msg := range messages {
-		if msg.SenderID == userID || msg.ReceiverID == userID {
-			userMessages = append(userMessages, msg)
+// SendMessage handles POST /conversation/:id/messages
+// Accepts JSON, or a multipart form with a "content" field and any number of
+// "files" fields to send attachments.
+func (h *MessageHandler) SendMessage(c *fiber.Ctx) error {
+	var req struct {
+		Content string `json:"content"`
+	}
+	var uploads []*messageusecase.Upload
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid form"})
+		}
+		if values := form.Value["content"]; len(values) > 0 {
+			req.Content = values[0]
 		}
+		for _, header := range form.File["files"] {
+			f, err := header.Open()
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid file"})
+			}
+			data, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid file"})
+			}
+			uploads = append(uploads, &messageusecase.Upload{FileName: header.Filename, Data: data})
+		}
+	} else if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+	}
+	m, err := h.Service.Send(c.Params("id"), currentUserID(c), req.Content, uploads...)
+	if err != nil {
+		return messageError(c, err)
 	}
+	return c.Status(fiber.StatusCreated).JSON(m)
+}
 
-	return c.JSON(userMessages)
+// AttachmentLink handles GET /attachment/:id/link
+// Returns a short-lived download URL for an attachment in one of the caller's
+// conversations. The URL works without the auth header, e.g. in an <a> tag.
+func (h *MessageHandler) AttachmentLink(c *fiber.Ctx) error {
+	userID := currentUserID(c)
+	sig, expiresAt, err := h.Service.AttachmentLink(c.Params("id"), userID)
+	if err != nil {
+		return messageError(c, err)
+	}
+	query := url.Values{"user": {userID}, "expires": {strconv.FormatInt(expiresAt, 10)}, "sig": {sig}}
+	return c.JSON(fiber.Map{
+		"url":       "/attachments/" + url.PathEscape(c.Params("id")) + "?" + query.Encode(),
+		"expiresAt": expiresAt,
+	})
 }
 
-// MarkMessageRead marks a message as read.
-// POST /api/message/:id/read
-func (h *MessageHandler) MarkMessageRead(c *fiber.Ctx) error {
-	id := c.Params("id")
-	if id == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Message ID is required",
-		})
+// DownloadAttachment handles GET /attachments/:id?user=&expires=&sig=
+// Serves the file behind a link from AttachmentLink. It is always sent as a
+// download so uploaded files are never rendered by the browser.
+func (h *MessageHandler) DownloadAttachment(c *fiber.Ctx) error {
+	expiresAt, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Invalid download link"})
+	}
+	a, data, err := h.Service.OpenAttachment(c.Params("id"), c.Query("user"), expiresAt, c.Query("sig"))
+	if err != nil {
+		return messageError(c, err)
 	}
+	c.Set(fiber.HeaderContentType, a.ContentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename*=UTF-8''`+url.PathEscape(a.FileName))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.Send(data)
+}
 
-	for i, msg := range messages {
-		if msg.ID == id {
-			messages[i].Read = true
-			return c.JSON(fiber.Map{
-				"message": "Message marked as read",
-				"data":    messages[i],
-			})
+// MarkConversationRead handles POST /conversation/:id/read
+// Moves the caller's read cursor to seq, or to the latest message without one.
+func (h *MessageHandler) MarkConversationRead(c *fiber.Ctx) error {
+	var req struct {
+		Seq int64 `json:"seq"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
 		}
 	}
+	p, err := h.Service.MarkRead(c.Params("id"), currentUserID(c), req.Seq)
+	if err != nil {
+		return messageError(c, err)
+	}
+	return c.JSON(p)
+}
 
-	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-		"error": "Message not found",
-	})
+// LeaveConversation handles POST /conversation/:id/leave
+func (h *MessageHandler) LeaveConversation(c *fiber.Ctx) error {
+	if err := h.Service.Leave(c.Params("id"), currentUserID(c)); err != nil {
+		return messageError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Left conversation"})
+}
+
+// messageError maps message service errors to HTTP responses.
+func messageError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, messageusecase.ErrConversationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Conversation not found"})
+	case errors.Is(err, messageusecase.ErrAttachmentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+	case errors.Is(err, messageusecase.ErrInvalidLink):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, messageusecase.ErrLinkExpired):
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, messageusecase.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 }
@@ -3,12 +3,14 @@ package handler
 import (
 	"training-portal/internal/domain/course"
 	moduleusecase "training-portal/internal/usecase/course"
+	enrollmentusecase "training-portal/internal/usecase/enrollment"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type ModuleHandler struct {
-	Service *moduleusecase.ModuleService
+	Service     *moduleusecase.ModuleService
+	Enrollments *enrollmentusecase.EnrollmentService
 }
 
 var _ = ModuleHandler{} // Exported for router.go
@@ -32,12 +34,26 @@ func (h *ModuleHandler) GetModule(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 	}
+	allowed, err := canAccessCourse(c, h.Enrollments, module.CourseID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Enrollment required"})
+	}
 	return c.JSON(module)
 }
 
 // ListModulesByCourse handles GET /course/:course_id/modules
 func (h *ModuleHandler) ListModulesByCourse(c *fiber.Ctx) error {
 	courseID := c.Params("course_id")
+	allowed, err := canAccessCourse(c, h.Enrollments, courseID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Enrollment required"})
+	}
 	modules, err := h.Service.ListModulesByCourse(courseID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
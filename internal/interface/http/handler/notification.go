@@ -1,99 +1,96 @@
 package handler
 
 import (
-	"sync"
-	"time"
+	"errors"
+
+	notificationusecase "training-portal/internal/usecase/notification"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// Notification represents a user/system notification.
-type Notification struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Message   string    `json:"message"`
-	Read      bool      `json:"read"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
 // NotificationHandler provides HTTP handlers for notifications.
-// Currently uses in-memory storage for demonstration.
 type NotificationHandler struct {
-	mu            sync.Mutex
-	notifications map[string]*Notification // key = notification ID
+	Service     *notificationusecase.NotificationService
+	Templates   *notificationusecase.TemplateService
+	Preferences *notificationusecase.PreferenceService
 }
 
-// NewNotificationHandler creates a new NotificationHandler.
-// In the future, inject a database repository/service for persistent storage.
-func NewNotificationHandler() *NotificationHandler {
-	return &NotificationHandler{
-		notifications: make(map[string]*Notification),
-	}
-}
+var _ = NotificationHandler{} // Exported for router.go
 
-// ListNotifications handles GET /notifications
-// Returns all notifications for the authenticated user.
+// ListNotifications handles GET /notifications?unread=true
+// Returns the authenticated user's notifications, newest first.
 func (h *NotificationHandler) ListNotifications(c *fiber.Ctx) error {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	userID := c.Locals("user_id") // Assuming JWT middleware sets this
-	if userID == nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	notifications, err := h.Service.ListNotifications(currentUserID(c), c.QueryBool("unread"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
+	return c.JSON(notifications)
+}
 
-	var userNotifications []Notification
-	for _, n := range h.notifications {
-		if n.UserID == userID.(string) {
-			userNotifications = append(userNotifications, *n) // Replace with DB query in the future
-		}
+// GetNotification handles GET /notification/:id
+// Includes the delivery status per channel.
+func (h *NotificationHandler) GetNotification(c *fiber.Ctx) error {
+	n, err := h.Service.GetNotification(c.Params("id"), h.owner(c))
+	if err != nil {
+		return notificationError(c, err)
 	}
-
-	return c.JSON(userNotifications)
+	return c.JSON(n)
 }
 
 // MarkAsRead handles POST /notifications/:id/read
-// Marks a notification as read.
 func (h *NotificationHandler) MarkAsRead(c *fiber.Ctx) error {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	id := c.Params("id")
-	if id == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Missing notification ID"})
-	}
-
-	notification, exists := h.notifications[id]
-	if !exists {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Notification not found"})
+	n, err := h.Service.MarkRead(c.Params("id"), currentUserID(c))
+	if err != nil {
+		return notificationError(c, err)
 	}
-
-	notification.Read = true
-	// Replace with DB UPDATE query in the future
-
 	return c.JSON(fiber.Map{
 		"message":      "Notification marked as read",
-		"notification": notification,
+		"notification": n,
 	})
 }
 
-// CreateNotification handles POST /notifications
-// Creates a new notification (for admin/system use).
+// CreateNotification handles POST /notifications (staff only)
+// Sends an ad-hoc message following the recipient's preferences for general notifications.
 func (h *NotificationHandler) CreateNotification(c *fiber.Ctx) error {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	var input Notification
-	if err := c.BodyParser(&input); err != nil {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req struct {
+		UserID  string `json:"userId"`
+		Title   string `json:"title"`
+		Message string `json:"message"`
+	}
+	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
+	n, err := h.Service.Send(req.UserID, req.Title, req.Message)
+	if err != nil {
+		return notificationError(c, err)
+	}
+	if n == nil {
+		return c.JSON(fiber.Map{"message": "User has turned off these notifications"})
+	}
+	return c.Status(fiber.StatusCreated).JSON(n)
+}
 
-	// Generate a simple unique ID using timestamp + user ID; replace with UUID or DB auto-ID
-	input.ID = time.Now().Format("20060102150405") + "_" + input.UserID
-	input.Read = false
-	input.CreatedAt = time.Now()
-
-	h.notifications[input.ID] = &input // Replace with DB INSERT in the future
+// owner restricts lookups to the caller's own notifications, except for staff.
+func (h *NotificationHandler) owner(c *fiber.Ctx) string {
+	if isStaff(c) {
+		return ""
+	}
+	return currentUserID(c)
+}
 
-	return c.Status(fiber.StatusCreated).JSON(input)
+// notificationError maps notification service errors to HTTP responses.
+func notificationError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, notificationusecase.ErrNotificationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Notification not found"})
+	case errors.Is(err, notificationusecase.ErrTemplateNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Template not found"})
+	case errors.Is(err, notificationusecase.ErrMandatoryEvent):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 }
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"training-portal/internal/domain/notification"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type notificationPreferenceBody struct {
+	Event     notification.EventType          `json:"event"`
+	Channels  []notification.NotificationType `json:"channels"`
+	Digest    notification.DigestMode         `json:"digest"`
+	Mandatory bool                            `json:"mandatory,omitempty"`
+}
+
+type notificationSettingsBody struct {
+	Locale      string                       `json:"locale"`
+	TimeZone    string                       `json:"timeZone"`
+	QuietStart  string                       `json:"quietStart"` // "HH:MM", equal to quietEnd to disable
+	QuietEnd    string                       `json:"quietEnd"`
+	Preferences []notificationPreferenceBody `json:"preferences"`
+}
+
+// GetNotificationSettings handles GET /notification-settings
+// Returns the caller's settings with the effective preference for every event.
+func (h *NotificationHandler) GetNotificationSettings(c *fiber.Ctx) error {
+	settings, err := h.Preferences.Settings(currentUserID(c))
+	if err != nil {
+		return notificationError(c, err)
+	}
+	body := notificationSettingsBody{
+		Locale:     settings.Locale,
+		TimeZone:   settings.TimeZone,
+		QuietStart: notification.FormatClock(settings.QuietStart),
+		QuietEnd:   notification.FormatClock(settings.QuietEnd),
+	}
+	available := h.Service.AvailableChannels()
+	for _, event := range notification.Events {
+		p := settings.Preference(event, available)
+		body.Preferences = append(body.Preferences, notificationPreferenceBody{
+			Event:     event,
+			Channels:  p.Channels,
+			Digest:    p.Digest,
+			Mandatory: event.Mandatory(),
+		})
+	}
+	return c.JSON(body)
+}
+
+// UpdateNotificationSettings handles PUT /notification-settings
+// Replaces the caller's locale, time zone, quiet hours and per-event preferences.
+// An empty channel list turns an event off; compliance events cannot be turned off.
+func (h *NotificationHandler) UpdateNotificationSettings(c *fiber.Ctx) error {
+	var body notificationSettingsBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	settings := &notification.Settings{
+		UserID:   currentUserID(c),
+		Locale:   body.Locale,
+		TimeZone: body.TimeZone,
+	}
+	if body.QuietStart != "" || body.QuietEnd != "" {
+		var err error
+		if settings.QuietStart, err = notification.ParseClock(body.QuietStart); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if settings.QuietEnd, err = notification.ParseClock(body.QuietEnd); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	for _, p := range body.Preferences {
+		settings.Preferences = append(settings.Preferences, notification.Preference{Event: p.Event, Channels: p.Channels, Digest: p.Digest})
+	}
+	if err := h.Preferences.SaveSettings(settings); err != nil {
+		return notificationError(c, err)
+	}
+	return h.GetNotificationSettings(c)
+}
+
+// ListNotificationTemplates handles GET /notification-templates (admin only)
+// Returns the stored templates and the built-in defaults.
+func (h *NotificationHandler) ListNotificationTemplates(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	templates, err := h.Templates.ListTemplates()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"templates": templates, "defaults": notification.DefaultTemplates})
+}
+
+// SaveNotificationTemplate handles PUT /notification-template/:event/:locale (admin only)
+func (h *NotificationHandler) SaveNotificationTemplate(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	var req struct {
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	t := &notification.Template{
+		Event:   notification.EventType(c.Params("event")),
+		Locale:  c.Params("locale"),
+		Subject: req.Subject,
+		Body:    req.Body,
+	}
+	if err := h.Templates.SaveTemplate(t); err != nil {
+		return notificationError(c, err)
+	}
+	return c.JSON(t)
+}
+
+// DeleteNotificationTemplate handles DELETE /notification-template/:event/:locale (admin only)
+func (h *NotificationHandler) DeleteNotificationTemplate(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	if err := h.Templates.DeleteTemplate(notification.EventType(c.Params("event")), c.Params("locale")); err != nil {
+		return notificationError(c, err)
+	}
+	return c.JSON(fiber.Map{"message": "Template deleted"})
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"training-portal/internal/domain/user"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// currentUserID returns the authenticated user's ID set by JWTMiddleware.
+func currentUserID(c *fiber.Ctx) string {
+	id, _ := c.Locals("user_id").(string)
+	return id
+}
+
+// currentRole returns the authenticated user's role set by JWTMiddleware.
+func currentRole(c *fiber.Ctx) user.Role {
+	role, _ := c.Locals("role").(string)
+	return user.Role(role)
+}
+
+// isStaff reports whether the authenticated user is an admin or trainer.
+func isStaff(c *fiber.Ctx) bool {
+	role := currentRole(c)
+	return role == user.RoleAdmin || role == user.RoleTrainer
+}
+
+// isAdmin reports whether the authenticated user is an admin.
+func isAdmin(c *fiber.Ctx) bool {
+	return currentRole(c) == user.RoleAdmin
+}
+
+// isApprover reports whether the authenticated user may approve enrollment requests.
+func isApprover(c *fiber.Ctx) bool {
+	return isStaff(c) || currentRole(c) == user.RoleManager
+}
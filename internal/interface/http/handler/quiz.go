@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"log"
 	"sync"
 
+	"training-portal/internal/domain/realtime"
+	realtimeusecase "training-portal/internal/usecase/realtime"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -34,6 +38,8 @@ type Answer struct {
 type QuizHandler struct {
 	mu      sync.Mutex
 	quizzes map[string]*Quiz // in-memory storage
+
+	Events *realtimeusecase.Service // optional; pushes released grades to the learner
 }
 
 // NewQuizHandler creates a new QuizHandler.
@@ -44,9 +50,12 @@ func NewQuizHandler() *QuizHandler {
 	}
 }
 
-// CreateQuiz handles POST /api/quiz
+// CreateQuiz handles POST /api/quiz (staff only)
 // Stores the quiz in memory; replace with DB insert in the future.
 func (h *QuizHandler) CreateQuiz(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only staff can create quizzes"})
+	}
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -75,7 +84,7 @@ func (h *QuizHandler) GetQuiz(c *fiber.Ctx) error {
 	if !exists {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Quiz not found"})
 	}
-	return c.JSON(quiz)
+	return c.JSON(quizView(c, quiz))
 }
 
 // ListQuizzes handles GET /api/quizzes
@@ -86,11 +95,23 @@ func (h *QuizHandler) ListQuizzes(c *fiber.Ctx) error {
 
 	list := make([]*Quiz, 0, len(h.quizzes))
 	for _, q := range h.quizzes { // Replace with DB query
-		list = append(list, q)
+		list = append(list, quizView(c, q))
 	}
 	return c.JSON(list)
 }
 
+// quizView hides the answers and other learners' submissions from learners.
+func quizView(c *fiber.Ctx, q *Quiz) *Quiz {
+	if isStaff(c) {
+		return q
+	}
+	view := &Quiz{ID: q.ID, Title: q.Title, Questions: make([]Question, len(q.Questions))}
+	for i, question := range q.Questions {
+		view.Questions[i] = Question{ID: question.ID, Text: question.Text}
+	}
+	return view
+}
+
 // SubmitQuiz handles POST /api/quiz/:id/submit
 // Stores user submission in memory; replace with DB insert/update in the future.
 func (h *QuizHandler) SubmitQuiz(c *fiber.Ctx) error {
@@ -104,19 +125,19 @@ func (h *QuizHandler) SubmitQuiz(c *fiber.Ctx) error {
 	}
 
 	var submission struct {
-		UserID  string   `json:"user_id"`
 		Answers []Answer `json:"answers"`
 	}
 	if err := c.BodyParser(&submission); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid submission"})
 	}
 
-	quiz.Submissions[submission.UserID] = submission.Answers // Replace with DB insert/update
+	quiz.Submissions[currentUserID(c)] = submission.Answers // Replace with DB insert/update
 	return c.JSON(fiber.Map{"message": "Quiz submitted successfully"})
 }
 
 // GradeQuiz handles GET /api/quiz/:id/grade?user_id=xxx
 // Computes score from in-memory submissions; replace with DB query in the future.
+// A grade looked up by staff is released to the learner's realtime stream.
 func (h *QuizHandler) GradeQuiz(c *fiber.Ctx) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -126,6 +147,9 @@ func (h *QuizHandler) GradeQuiz(c *fiber.Ctx) error {
 	if userID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Missing user_id query parameter"})
 	}
+	if userID != currentUserID(c) && !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only staff can grade other learners"})
+	}
 
 	quiz, exists := h.quizzes[id] // Replace with DB query
 	if !exists {
@@ -147,6 +171,13 @@ func (h *QuizHandler) GradeQuiz(c *fiber.Ctx) error {
 		}
 	}
 
+	if h.Events != nil && isStaff(c) {
+		grade := realtime.Grade{QuizID: id, Score: score, Total: len(quiz.Questions)}
+		if err := h.Events.Publish(userID, realtime.TypeGrade, grade); err != nil {
+			log.Printf("quiz: releasing the grade of %s to user %s failed: %v", id, userID, err)
+		}
+	}
+
 	return c.JSON(fiber.Map{
 		"user_id": userID,
 		"quiz_id": id,
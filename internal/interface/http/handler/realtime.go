@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	realtimeusecase "training-portal/internal/usecase/realtime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RealtimeHandler streams per-user events over Server-Sent Events.
+type RealtimeHandler struct {
+	Service *realtimeusecase.Service
+	// Heartbeat is how often an idle stream sends a comment to keep proxies
+	// from closing it; 0 means 25 seconds.
+	Heartbeat time.Duration
+}
+
+var _ = RealtimeHandler{} // Exported for router.go
+
+// realtimePageSize is how many events are read per query while catching up.
+const realtimePageSize = 100
+
+// ListEvents handles GET /events?after=&limit=
+// Returns the caller's events after the cursor, for clients that poll or
+// catch up before opening the stream.
+func (h *RealtimeHandler) ListEvents(c *fiber.Ctx) error {
+	after, err := strconv.ParseInt(c.Query("after", "0"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid cursor"})
+	}
+	events, err := h.Service.Replay(currentUserID(c), after, c.QueryInt("limit", realtimePageSize))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	cursor := after
+	if len(events) > 0 {
+		cursor = events[len(events)-1].ID
+	}
+	return c.JSON(fiber.Map{"events": events, "cursor": cursor})
+}
+
+// StreamEvents handles GET /events/stream
+// Streams the caller's events as Server-Sent Events. Reconnecting clients
+// resume after the Last-Event-ID header or ?after= cursor; without one
+// only new events are sent. Each event's id is its cursor.
+func (h *RealtimeHandler) StreamEvents(c *fiber.Ctx) error {
+	userID := currentUserID(c)
+	if userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	cursorParam := c.Get("Last-Event-ID", c.Query("after"))
+
+	// Subscribe before reading the cursor so no wake-up is missed in between.
+	sub := h.Service.Subscribe(userID)
+	var cursor int64
+	var err error
+	if cursorParam != "" {
+		cursor, err = strconv.ParseInt(cursorParam, 10, 64)
+		if err != nil {
+			h.Service.Unsubscribe(sub)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid cursor"})
+		}
+	} else if cursor, err = h.Service.Cursor(userID); err != nil {
+		h.Service.Unsubscribe(sub)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	heartbeat := h.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = 25 * time.Second
+	}
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // disable nginx response buffering
+
+	// The writer runs after the handler returns, so it must not touch c.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer h.Service.Unsubscribe(sub)
+		fmt.Fprintf(w, "retry: 3000\nid: %d\nevent: ready\ndata: {}\n\n", cursor)
+		if w.Flush() != nil {
+			return
+		}
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			for {
+				events, err := h.Service.Replay(userID, cursor, realtimePageSize)
+				if err != nil {
+					log.Printf("realtime: replay for user %s failed: %v", userID, err)
+					return
+				}
+				for _, e := range events {
+					fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+					cursor = e.ID
+				}
+				if w.Flush() != nil {
+					return // client disconnected
+				}
+				if len(events) < realtimePageSize {
+					break
+				}
+			}
+			select {
+			case <-sub.Wake:
+			case <-ticker.C:
+				w.WriteString(": ping\n\n")
+				if w.Flush() != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
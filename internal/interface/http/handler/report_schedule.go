@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/compliance"
+	complianceusecase "training-portal/internal/usecase/compliance"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReportScheduleHandler provides HTTP handlers for compliance reports that
+// are emailed on a schedule.
+type ReportScheduleHandler struct {
+	Service *complianceusecase.ScheduleService
+}
+
+var _ = ReportScheduleHandler{} // Exported for router.go
+
+type scheduleRequest struct {
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"`
+	Format     string   `json:"format"`   // csv or pdf (default)
+	Cron       string   `json:"cron"`     // e.g. "0 8 * * 1" or "@weekly"
+	Timezone   string   `json:"timezone"` // default UTC
+	Delivery   string   `json:"delivery"` // attachment (default) or link
+	Recipients []string `json:"recipients"`
+	Enabled    *bool    `json:"enabled"` // default true
+	Department string   `json:"department"`
+	CourseID   string   `json:"courseId"`
+	UserID     string   `json:"userId"`
+	ManagerID  string   `json:"managerId"`
+	Within     int      `json:"within"`
+}
+
+// parseSchedule reads a schedule definition from the body. On failure the
+// error response has already been written.
+func parseSchedule(c *fiber.Ctx) (*compliance.Schedule, error) {
+	var req scheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+	return &compliance.Schedule{
+		Name:       req.Name,
+		Kind:       compliance.ReportKind(req.Kind),
+		Format:     compliance.Format(req.Format),
+		Cron:       req.Cron,
+		Timezone:   req.Timezone,
+		Delivery:   compliance.Delivery(req.Delivery),
+		Recipients: req.Recipients,
+		Enabled:    enabled,
+		Filter: compliance.Filter{
+			Department: req.Department,
+			CourseID:   req.CourseID,
+			UserID:     req.UserID,
+			ManagerID:  req.ManagerID,
+			Within:     req.Within,
+		},
+	}, nil
+}
+
+func scheduleActor(c *fiber.Ctx) complianceusecase.Actor {
+	return complianceusecase.Actor{UserID: currentUserID(c), Staff: isStaff(c)}
+}
+
+// CreateSchedule handles POST /report-schedules (staff and managers)
+// Managers can only send reports to themselves.
+func (h *ReportScheduleHandler) CreateSchedule(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	in, err := parseSchedule(c)
+	if in == nil {
+		return err
+	}
+	sched, err := h.Service.Create(in, scheduleActor(c), time.Now())
+	if err != nil {
+		return complianceError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(sched)
+}
+
+// ListSchedules handles GET /report-schedules (staff and managers)
+// Managers see their own schedules, staff see all of them.
+func (h *ReportScheduleHandler) ListSchedules(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	scheds, err := h.Service.List(scheduleActor(c))
+	if err != nil {
+		return complianceError(c, err)
+	}
+	return c.JSON(scheds)
+}
+
+// GetSchedule handles GET /report-schedule/:id (staff and managers)
+func (h *ReportScheduleHandler) GetSchedule(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	sched, err := h.Service.Get(c.Params("id"), scheduleActor(c))
+	if err != nil {
+		return complianceError(c, err)
+	}
+	return c.JSON(sched)
+}
+
+// UpdateSchedule handles PUT /report-schedule/:id (staff and managers)
+// Replaces the whole definition; send enabled=false to pause it.
+func (h *ReportScheduleHandler) UpdateSchedule(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	in, err := parseSchedule(c)
+	if in == nil {
+		return err
+	}
+	sched, err := h.Service.Update(c.Params("id"), in, scheduleActor(c), time.Now())
+	if err != nil {
+		return complianceError(c, err)
+	}
+	return c.JSON(sched)
+}
+
+// DeleteSchedule handles DELETE /report-schedule/:id (staff and managers)
+func (h *ReportScheduleHandler) DeleteSchedule(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	if err := h.Service.Delete(c.Params("id"), scheduleActor(c)); err != nil {
+		return complianceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RunSchedule handles POST /report-schedule/:id/run (staff and managers)
+// Sends the report now, e.g. to try a new schedule. The run is returned
+// whether or not it succeeded.
+func (h *ReportScheduleHandler) RunSchedule(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	run, err := h.Service.RunNow(c.Params("id"), scheduleActor(c), time.Now())
+	if err != nil {
+		return complianceError(c, err)
+	}
+	return c.JSON(run)
+}
+
+// GetScheduleRuns handles GET /report-schedule/:id/runs?status=&limit= (staff and managers)
+func (h *ReportScheduleHandler) GetScheduleRuns(c *fiber.Ctx) error {
+	if !isApprover(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	filter := compliance.RunFilter{ScheduleID: c.Params("id"), Status: compliance.RunStatus(c.Query("status")), Limit: c.QueryInt("limit")}
+	runs, err := h.Service.Runs(filter, scheduleActor(c))
+	if err != nil {
+		return complianceError(c, err)
+	}
+	return c.JSON(runs)
+}
+
+// ListRuns handles GET /report-runs?status=failed&scheduleId=&limit= (admin only)
+// Returns the run history of every schedule, newest first, including runs of
+// deleted schedules.
+func (h *ReportScheduleHandler) ListRuns(c *fiber.Ctx) error {
+	if !isAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+	filter := compliance.RunFilter{ScheduleID: c.Query("scheduleId"), Status: compliance.RunStatus(c.Query("status")), Limit: c.QueryInt("limit")}
+	runs, err := h.Service.Runs(filter, complianceusecase.Actor{UserID: currentUserID(c), Staff: true})
+	if err != nil {
+		return complianceError(c, err)
+	}
+	return c.JSON(runs)
+}
+
+// Download handles GET /reports/download/:id?expires=&sig=
+// Serves a report emailed as a link. The signed link is the authorization.
+func (h *ReportScheduleHandler) Download(c *fiber.Ctx) error {
+	expiresAt, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Invalid download link"})
+	}
+	run, data, err := h.Service.OpenDownload(c.Params("id"), expiresAt, c.Query("sig"), time.Now())
+	if err != nil {
+		return complianceError(c, err)
+	}
+	contentType := "text/csv"
+	if strings.HasSuffix(run.FileName, ".pdf") {
+		contentType = "application/pdf"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename*=UTF-8''`+url.PathEscape(run.FileName))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.Send(data)
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"training-portal/internal/domain/user"
+	complianceusecase "training-portal/internal/usecase/compliance"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportScheduleHandler_LearnersAreForbidden(t *testing.T) {
+	h := &ReportScheduleHandler{Service: &complianceusecase.ScheduleService{}}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", "learner")
+		c.Locals("role", string(user.RoleEmployee))
+		return c.Next()
+	})
+	app.Get("/report-schedule/:id", h.GetSchedule)
+	app.Put("/report-schedule/:id", h.UpdateSchedule)
+	app.Delete("/report-schedule/:id", h.DeleteSchedule)
+	app.Post("/report-schedule/:id/run", h.RunSchedule)
+	app.Get("/report-schedule/:id/runs", h.GetScheduleRuns)
+
+	for _, route := range []struct{ method, url string }{
+		{"GET", "/report-schedule/s1"},
+		{"PUT", "/report-schedule/s1"},
+		{"DELETE", "/report-schedule/s1"},
+		{"POST", "/report-schedule/s1/run"},
+		{"GET", "/report-schedule/s1/runs"},
+	} {
+		resp, err := app.Test(httptest.NewRequest(route.method, route.url, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode, route.method+" "+route.url)
+	}
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	enrollmentusecase "training-portal/internal/usecase/enrollment"
+	scormusecase "training-portal/internal/usecase/scorm"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SCORMHandler imports SCORM packages, serves their content and receives the
+// runtime data SCOs report through the browser's API adapter.
+type SCORMHandler struct {
+	Service     *scormusecase.Service
+	Enrollments *enrollmentusecase.EnrollmentService
+}
+
+var _ = SCORMHandler{} // Exported for router.go
+
+// UploadPackage handles POST /course/:id/scorm (staff only)
+// Takes a multipart form with the zip in a "package" field; each launchable
+// item of the manifest becomes a module of the course.
+func (h *SCORMHandler) UploadPackage(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only staff can upload SCORM packages"})
+	}
+	header, err := c.FormFile("package")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A package file is required"})
+	}
+	f, err := header.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid file"})
+	}
+	defer f.Close()
+	p, scos, err := h.Service.Import(c.Params("id"), currentUserID(c), f, header.Size, time.Now())
+	if err != nil {
+		return scormError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"package": p, "scos": scos})
+}
+
+// ListPackages handles GET /course/:id/scorm (staff only)
+func (h *SCORMHandler) ListPackages(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only staff can view SCORM packages"})
+	}
+	packages, err := h.Service.Packages(c.Params("id"))
+	if err != nil {
+		return scormError(c, err)
+	}
+	return c.JSON(packages)
+}
+
+// GetPackage handles GET /scorm/package/:id (staff only)
+func (h *SCORMHandler) GetPackage(c *fiber.Ctx) error {
+	if !isStaff(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only staff can view SCORM packages"})
+	}
+	p, scos, err := h.Service.Package(c.Params("id"))
+	if err != nil {
+		return scormError(c, err)
+	}
+	return c.JSON(fiber.Map{"package": p, "scos": scos})
+}
+
+// Launch handles POST /scorm/sco/:id/launch
+// Starts a session and returns the signed URL of the SCO's launch page with
+// the runtime values the API adapter answers GetValue calls from.
+func (h *SCORMHandler) Launch(c *fiber.Ctx) error {
+	if ok, err := h.canRun(c); !ok {
+		return err
+	}
+	session, err := h.Service.Launch(c.Params("id"), currentUserID(c), time.Now())
+	if err != nil {
+		return scormError(c, err)
+	}
+	return c.JSON(session)
+}
+
+// Commit handles POST /scorm/sco/:id/commit
+// Takes the values the SCO set, in order, as [{"element", "value"}], and
+// "finish" when the SCO called LMSFinish / Terminate. Refused values come
+// back under "Errors" with their SCORM error codes.
+func (h *SCORMHandler) Commit(c *fiber.Ctx) error {
+	var req struct {
+		Values []struct {
+			Element string `json:"element"`
+			Value   string `json:"value"`
+		} `json:"values"`
+		Finish bool `json:"finish"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request payload"})
+	}
+	if ok, err := h.canRun(c); !ok {
+		return err
+	}
+	values := make([]scormusecase.Value, len(req.Values))
+	for i, v := range req.Values {
+		values[i] = scormusecase.Value{Element: v.Element, Value: v.Value}
+	}
+	session, err := h.Service.Commit(c.Params("id"), currentUserID(c), values, req.Finish, time.Now())
+	if err != nil {
+		return scormError(c, err)
+	}
+	return c.JSON(session)
+}
+
+// GetAttempt handles GET /scorm/sco/:id/attempt?user=
+// Returns the caller's runtime data for the SCO; staff may ask for a learner's.
+func (h *SCORMHandler) GetAttempt(c *fiber.Ctx) error {
+	userID := currentUserID(c)
+	if other := c.Query("user"); other != "" && other != userID {
+		if !isStaff(c) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only staff can view other learners' attempts"})
+		}
+		userID = other
+	} else if ok, err := h.canRun(c); !ok {
+		return err
+	}
+	a, err := h.Service.Attempt(c.Params("id"), userID)
+	if err != nil {
+		return scormError(c, err)
+	}
+	if a == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No attempt yet"})
+	}
+	return c.JSON(a)
+}
+
+// Content handles GET /scorm/content/:package/:expires/:sig/*
+// Serves a file of an imported package behind a link from Launch. The
+// signature is part of the path so the package's relative links work.
+func (h *SCORMHandler) Content(c *fiber.Ctx) error {
+	expiresAt, err := strconv.ParseInt(c.Params("expires"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Invalid content link"})
+	}
+	sig, err := url.PathUnescape(c.Params("sig"))
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Invalid content link"})
+	}
+	name, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Content not found"})
+	}
+	data, err := h.Service.OpenContent(c.Params("package"), expiresAt, sig, name)
+	if err != nil {
+		return scormError(c, err)
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+	return c.Send(data)
+}
+
+// canRun reports whether the caller may run the SCO, which needs access to
+// its course. When not, the response has been written.
+func (h *SCORMHandler) canRun(c *fiber.Ctx) (bool, error) {
+	sco, err := h.Service.SCO(c.Params("id"))
+	if err != nil {
+		return false, scormError(c, err)
+	}
+	allowed, err := canAccessCourse(c, h.Enrollments, sco.CourseID)
+	if err != nil {
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !allowed {
+		return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Enrollment required"})
+	}
+	return true, nil
+}
+
+func scormError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, scormusecase.ErrInvalidPackage):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, scormusecase.ErrPackageTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, scormusecase.ErrNotLaunched):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, scormusecase.ErrInvalidLink),
+		errors.Is(err, scormusecase.ErrLinkExpired):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, scormusecase.ErrCourseNotFound),
+		errors.Is(err, scormusecase.ErrPackageNotFound),
+		errors.Is(err, scormusecase.ErrSCONotFound),
+		errors.Is(err, scormusecase.ErrContentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"os"
 	"time"
 
+	"training-portal/internal/domain/analytics"
 	"training-portal/internal/domain/user"
+	analyticsusecase "training-portal/internal/usecase/analytics"
 	userusecase "training-portal/internal/usecase/user"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,7 +14,8 @@ import (
 )
 
 type UserHandler struct {
-	Service *userusecase.UserService
+	Service   *userusecase.UserService
+	Analytics *analyticsusecase.Service // optional; records logins
 }
 
 var _ = UserHandler{} // Exported for router.go
@@ -58,6 +61,9 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to sign token"})
 	}
+	if h.Analytics != nil {
+		h.Analytics.Record(u.ID, analytics.EventLogin, nil)
+	}
 	return c.JSON(fiber.Map{"token": tokenStr})
 }
 
@@ -87,19 +93,29 @@ func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
 // UpdateUser handles PUT /user/:id
 func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	id := c.Params("id")
+	// Department and manager are only changed when the request sets them.
 	var req struct {
-		Name  string    `json:"name"`
-		Email string    `json:"email"`
-		Role  user.Role `json:"role"`
+		Name       string    `json:"name"`
+		Email      string    `json:"email"`
+		Role       user.Role `json:"role"`
+		Department *string   `json:"department"`
+		ManagerID  *string   `json:"managerId"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
-	u := &user.User{
-		ID:    id,
-		Name:  req.Name,
-		Email: req.Email,
-		Role:  req.Role,
+	u, err := h.Service.GetUser(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	u.Name = req.Name
+	u.Email = req.Email
+	u.Role = req.Role
+	if req.Department != nil {
+		u.Department = *req.Department
+	}
+	if req.ManagerID != nil {
+		u.ManagerID = *req.ManagerID
 	}
 	if err := h.Service.UpdateUser(u); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"time"
+
+	"training-portal/internal/domain/certificate"
+	certificateusecase "training-portal/internal/usecase/certificate"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var verificationPage = template.Must(template.New("verify").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Certificate verification</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; background: #f5f5f0; margin: 0; padding: 48px 16px; color: #222; }
+main { max-width: 520px; margin: 0 auto; background: #fff; border-radius: 8px; padding: 32px; box-shadow: 0 1px 4px rgba(0,0,0,.1); }
+.status { font-size: 1.4em; font-weight: bold; margin-bottom: 24px; }
+.valid { color: #1b7f3b; } .invalid { color: #b3261e; }
+dt { font-size: .8em; text-transform: uppercase; color: #777; margin-top: 16px; }
+dd { margin: 4px 0 0; font-size: 1.1em; }
+</style>
+</head>
+<body>
+<main>
+{{if .Found}}
+<div class="status {{if .Valid}}valid{{else}}invalid{{end}}">{{if .Valid}}&#10003; Valid certificate{{else}}&#10007; Not valid: {{.Reason}}{{end}}</div>
+<dl>
+<dt>Holder</dt><dd>{{.HolderName}}</dd>
+<dt>Course</dt><dd>{{.CourseTitle}}</dd>
+<dt>Issued</dt><dd>{{.IssuedOn}}</dd>
+{{if .ExpiresOn}}<dt>{{if eq .Status "expired"}}Expired{{else}}Expires{{end}}</dt><dd>{{.ExpiresOn}}</dd>{{end}}
+{{if .ReplacedBy}}<dt>Replaced by</dt><dd><a href="{{.ReplacedBy}}">{{.ReplacedBy}}</a></dd>{{end}}
+<dt>Credential ID</dt><dd>{{.CredentialID}}</dd>
+</dl>
+{{else}}
+<div class="status invalid">&#10007; No certificate with credential ID {{.CredentialID}}</div>
+{{end}}
+</main>
+</body>
+</html>
+`))
+
+// VerifyCertificate handles GET /certificates/verify/:credential_id (public)
+// Returns the validity, status, holder, course and issue date of a certificate.
+func (h *CertificateHandler) VerifyCertificate(c *fiber.Ctx) error {
+	v, err := h.Service.Verify(c.Params("credential_id"))
+	if errors.Is(err, certificateusecase.ErrCertificateNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"valid": false, "error": "Certificate not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"credentialId": v.CredentialID,
+		"valid":        v.Valid,
+		"status":       v.Status,
+		"reason":       v.Reason,
+		"holderName":   v.HolderName,
+		"courseTitle":  v.CourseTitle,
+		"issuedAt":     v.IssuedAt,
+		"expiresAt":    v.ExpiresAt,
+		"replacedBy":   v.ReplacedBy,
+	})
+}
+
+// VerificationPage handles GET /verify/:credential_id (public)
+// Renders the page the QR code on each certificate links to.
+func (h *CertificateHandler) VerificationPage(c *fiber.Ctx) error {
+	credentialID := c.Params("credential_id")
+	v, err := h.Service.Verify(credentialID)
+	status := fiber.StatusOK
+	switch {
+	case errors.Is(err, certificateusecase.ErrCertificateNotFound):
+		status = fiber.StatusNotFound
+		v = nil
+	case err != nil:
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	data := struct {
+		certificate.Verification
+		Found     bool
+		IssuedOn  string
+		ExpiresOn string
+	}{Found: v != nil}
+	if v != nil {
+		data.Verification = *v
+		data.IssuedOn = time.Unix(v.IssuedAt, 0).UTC().Format("January 2, 2006")
+		if v.ExpiresAt != 0 {
+			data.ExpiresOn = time.Unix(v.ExpiresAt, 0).UTC().Format("January 2, 2006")
+		}
+	} else {
+		data.CredentialID = credentialID
+	}
+
+	var buf bytes.Buffer
+	if err := verificationPage.Execute(&buf, data); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to render page")
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(status).Send(buf.Bytes())
+}
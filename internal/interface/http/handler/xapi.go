@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/xapi"
+	xapiusecase "training-portal/internal/usecase/xapi"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// XAPIHandler provides the REST endpoints of the built-in xAPI Learning
+// Record Store: statements, and the State and Activity Profile resources.
+type XAPIHandler struct {
+	Service *xapiusecase.Service
+}
+
+var _ = XAPIHandler{} // Exported for router.go
+
+// xapiActor returns the caller: a client authenticated by XAPIMiddleware, or
+// a portal user.
+func xapiActor(c *fiber.Ctx) xapiusecase.Actor {
+	if client, _ := c.Locals("xapi_client").(string); client != "" {
+		return xapiusecase.Actor{Client: client, Staff: true}
+	}
+	return xapiusecase.Actor{UserID: currentUserID(c), Staff: isStaff(c)}
+}
+
+// About handles GET /xapi/about (public)
+func (h *XAPIHandler) About(c *fiber.Ctx) error {
+	c.Set("X-Experience-API-Version", xapi.Version)
+	return c.JSON(xapi.About{Version: []string{xapi.Version}})
+}
+
+// PutStatement handles PUT /xapi/statements?statementId=
+// Stores one statement under the given ID. Learners may only store
+// statements about themselves.
+func (h *XAPIHandler) PutStatement(c *fiber.Ctx) error {
+	id := strings.ToLower(c.Query("statementId"))
+	if id == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "statementId is required"})
+	}
+	st, err := xapi.ParseStatement(c.Body())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid statement: " + err.Error()})
+	}
+	if st.ID != "" && !strings.EqualFold(st.ID, id) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "statementId does not match the statement's id"})
+	}
+	st.ID = id
+	if _, err := h.Service.Store(xapiActor(c), []*xapi.Statement{st}, time.Now()); err != nil {
+		return xapiError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// PostStatements handles POST /xapi/statements
+// Stores a statement or an array of statements and returns their IDs.
+func (h *XAPIHandler) PostStatements(c *fiber.Ctx) error {
+	statements, err := xapi.ParseStatements(c.Body())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid statement: " + err.Error()})
+	}
+	ids, err := h.Service.Store(xapiActor(c), statements, time.Now())
+	if err != nil {
+		return xapiError(c, err)
+	}
+	return c.JSON(ids)
+}
+
+// GetStatements handles GET /xapi/statements
+// With statementId or voidedStatementId returns that statement; otherwise
+// returns a page of statements filtered by agent, verb, activity,
+// registration, related_agents, related_activities, since, until, limit and
+// ascending, newest first. format=ids strips names and definitions.
+// Learners only see statements about themselves.
+func (h *XAPIHandler) GetStatements(c *fiber.Ctx) error {
+	c.Set("X-Experience-API-Consistent-Through", xapi.FormatTime(time.Now()))
+	format := c.Query("format", "exact")
+	if format != "exact" && format != "ids" && format != "canonical" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be exact, ids or canonical"})
+	}
+	render := func(st *xapi.Statement) *xapi.Statement {
+		if format == "ids" {
+			return st.IDsOnly()
+		}
+		return st
+	}
+
+	id, voided := c.Query("statementId"), false
+	if v := c.Query("voidedStatementId"); v != "" {
+		if id != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Send statementId or voidedStatementId, not both"})
+		}
+		id, voided = v, true
+	}
+	if id != "" {
+		for _, param := range []string{"agent", "verb", "activity", "registration", "related_activities", "related_agents", "since", "until", "limit", "ascending", "cursor"} {
+			if c.Query(param) != "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": param + " cannot be used with a statement id"})
+			}
+		}
+		st, err := h.Service.Statement(xapiActor(c), id, voided)
+		if err != nil {
+			return xapiError(c, err)
+		}
+		return c.JSON(render(st))
+	}
+
+	filter, err := statementFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	statements, next, err := h.Service.Query(xapiActor(c), filter)
+	if err != nil {
+		return xapiError(c, err)
+	}
+	result := xapi.StatementResult{Statements: make([]*xapi.Statement, len(statements))}
+	for i, st := range statements {
+		result.Statements[i] = render(st)
+	}
+	if next > 0 {
+		params, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+		params.Set("cursor", strconv.FormatInt(next, 10))
+		result.More = "/xapi/statements?" + params.Encode()
+	}
+	return c.JSON(result)
+}
+
+func statementFilter(c *fiber.Ctx) (xapi.StatementFilter, error) {
+	filter := xapi.StatementFilter{
+		Verb:              c.Query("verb"),
+		Activity:          c.Query("activity"),
+		Registration:      c.Query("registration"),
+		RelatedAgents:     c.Query("related_agents") == "true",
+		RelatedActivities: c.Query("related_activities") == "true",
+		Ascending:         c.Query("ascending") == "true",
+		Limit:             c.QueryInt("limit"),
+	}
+	if raw := c.Query("agent"); raw != "" {
+		ifi, err := agentIFI(raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.Agent = ifi
+	}
+	for _, t := range []struct {
+		param string
+		dst   *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		if raw := c.Query(t.param); raw != "" {
+			parsed, err := time.Parse(time.RFC3339Nano, raw)
+			if err != nil {
+				return filter, errors.New(t.param + " must be an ISO 8601 timestamp")
+			}
+			*t.dst = parsed
+		}
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n <= 0 {
+			return filter, errors.New("invalid cursor")
+		}
+		filter.After = n
+	}
+	return filter, nil
+}
+
+// agentIFI parses an agent query parameter into its IFI.
+func agentIFI(raw string) (string, error) {
+	a, err := xapi.ParseAgent([]byte(raw))
+	if err == nil {
+		err = a.Validate()
+	}
+	if err != nil {
+		return "", errors.New("invalid agent: " + err.Error())
+	}
+	if a.IFI() == "" {
+		return "", errors.New("agent must be identified")
+	}
+	return a.IFI(), nil
+}
+
+// documentKey reads the parameters identifying a State or Activity Profile
+// document.
+func documentKey(c *fiber.Ctx, kind xapi.DocumentKind) (xapi.DocumentKey, error) {
+	key := xapi.DocumentKey{Kind: kind, ActivityID: c.Query("activityId")}
+	if kind == xapi.DocumentActivityProfile {
+		key.DocID = c.Query("profileId")
+		return key, nil
+	}
+	key.DocID = c.Query("stateId")
+	key.Registration = strings.ToLower(c.Query("registration"))
+	if raw := c.Query("agent"); raw != "" {
+		ifi, err := agentIFI(raw)
+		if err != nil {
+			return key, err
+		}
+		key.Agent = ifi
+	}
+	return key, nil
+}
+
+func precondition(c *fiber.Ctx) xapiusecase.Precondition {
+	unquote := func(tag string) string {
+		return strings.Trim(strings.TrimPrefix(strings.TrimSpace(tag), "W/"), `"`)
+	}
+	return xapiusecase.Precondition{IfMatch: unquote(c.Get(fiber.HeaderIfMatch)), IfNoneMatch: unquote(c.Get(fiber.HeaderIfNoneMatch))}
+}
+
+// GetState handles GET /xapi/activities/state?activityId=&agent=&registration=&stateId=&since=
+// Returns the document, or the IDs of the agent's documents without a stateId.
+func (h *XAPIHandler) GetState(c *fiber.Ctx) error {
+	return h.getDocument(c, xapi.DocumentState, "stateId")
+}
+
+// PutState handles PUT /xapi/activities/state (replaces the document)
+func (h *XAPIHandler) PutState(c *fiber.Ctx) error {
+	return h.saveDocument(c, xapi.DocumentState, false)
+}
+
+// PostState handles POST /xapi/activities/state (merges a JSON document)
+func (h *XAPIHandler) PostState(c *fiber.Ctx) error {
+	return h.saveDocument(c, xapi.DocumentState, true)
+}
+
+// DeleteState handles DELETE /xapi/activities/state
+// Deletes the document, or all of the agent's documents without a stateId.
+func (h *XAPIHandler) DeleteState(c *fiber.Ctx) error {
+	return h.deleteDocuments(c, xapi.DocumentState)
+}
+
+// GetActivityProfile handles GET /xapi/activities/profile?activityId=&profileId=&since=
+// Returns the document, or the IDs of the activity's documents without a profileId.
+func (h *XAPIHandler) GetActivityProfile(c *fiber.Ctx) error {
+	return h.getDocument(c, xapi.DocumentActivityProfile, "profileId")
+}
+
+// PutActivityProfile handles PUT /xapi/activities/profile (staff and clients only)
+// Replacing an existing document needs If-Match or If-None-Match.
+func (h *XAPIHandler) PutActivityProfile(c *fiber.Ctx) error {
+	return h.saveDocument(c, xapi.DocumentActivityProfile, false)
+}
+
+// PostActivityProfile handles POST /xapi/activities/profile (staff and clients only)
+func (h *XAPIHandler) PostActivityProfile(c *fiber.Ctx) error {
+	return h.saveDocument(c, xapi.DocumentActivityProfile, true)
+}
+
+// DeleteActivityProfile handles DELETE /xapi/activities/profile (staff and clients only)
+func (h *XAPIHandler) DeleteActivityProfile(c *fiber.Ctx) error {
+	return h.deleteDocuments(c, xapi.DocumentActivityProfile)
+}
+
+func (h *XAPIHandler) getDocument(c *fiber.Ctx, kind xapi.DocumentKind, idParam string) error {
+	key, err := documentKey(c, kind)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if key.DocID == "" {
+		var since time.Time
+		if raw := c.Query("since"); raw != "" {
+			if since, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "since must be an ISO 8601 timestamp"})
+			}
+		}
+		ids, err := h.Service.DocumentIDs(xapiActor(c), key, since)
+		if err != nil {
+			return xapiError(c, err)
+		}
+		return c.JSON(ids)
+	}
+	if c.Query("since") != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "since cannot be used with " + idParam})
+	}
+	d, err := h.Service.Document(xapiActor(c), key)
+	if err != nil {
+		return xapiError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, d.ContentType)
+	c.Set(fiber.HeaderETag, `"`+d.ETag+`"`)
+	c.Set(fiber.HeaderLastModified, d.UpdatedAt.UTC().Format(http.TimeFormat))
+	return c.Send(d.Content)
+}
+
+func (h *XAPIHandler) saveDocument(c *fiber.Ctx, kind xapi.DocumentKind, merge bool) error {
+	key, err := documentKey(c, kind)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	d := &xapi.Document{DocumentKey: key, Content: append([]byte(nil), c.Body()...), ContentType: c.Get(fiber.HeaderContentType)}
+	if err := h.Service.SaveDocument(xapiActor(c), d, precondition(c), merge, time.Now()); err != nil {
+		return xapiError(c, err)
+	}
+	c.Set(fiber.HeaderETag, `"`+d.ETag+`"`)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *XAPIHandler) deleteDocuments(c *fiber.Ctx, kind xapi.DocumentKind) error {
+	key, err := documentKey(c, kind)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := h.Service.DeleteDocuments(xapiActor(c), key, precondition(c)); err != nil {
+		return xapiError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func xapiError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, xapiusecase.ErrInvalidStatement),
+		errors.Is(err, xapiusecase.ErrInvalidRequest):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, xapiusecase.ErrForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, xapiusecase.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, xapiusecase.ErrConflict),
+		errors.Is(err, xapiusecase.ErrConcurrency):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, xapiusecase.ErrPrecondition):
+		return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, xapiusecase.ErrTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -14,6 +14,11 @@ import (
 func JWTMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
+		if authHeader == "" && c.Get(fiber.HeaderAccept) == "text/event-stream" && c.Query("access_token") != "" {
+			// Browsers' EventSource cannot set headers, so event streams may
+			// pass the token as a query parameter.
+			authHeader = "Bearer " + c.Query("access_token")
+		}
 		if authHeader == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing token"})
 		}
@@ -28,6 +33,14 @@ func JWTMiddleware() fiber.Handler {
 		}
 
 		c.Locals("user", token.Claims)
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if userID, ok := claims["user_id"].(string); ok {
+				c.Locals("user_id", userID)
+			}
+			if role, ok := claims["role"].(string); ok {
+				c.Locals("role", role)
+			}
+		}
 		return c.Next()
 	}
 }
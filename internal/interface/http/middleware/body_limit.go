@@ -0,0 +1,44 @@
+// Request body size middleware
+
+package middleware
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultBodyLimit is the largest request body accepted on routes that do not
+// set their own limit.
+const DefaultBodyLimit = 4 << 20
+
+// BodyLimit rejects requests whose body is larger than limit bytes. The server
+// streams request bodies, so this is what bounds them: a body with a declared
+// length is rejected before it is read, and a chunked body is read up to the
+// limit and handed on buffered.
+func BodyLimit(limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := c.Request()
+		switch n := req.Header.ContentLength(); {
+		case n > limit:
+			return bodyTooLarge(c)
+		case n == -1 && req.IsBodyStream():
+			body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Failed to read request body"})
+			}
+			if len(body) > limit {
+				return bodyTooLarge(c)
+			}
+			req.SetBody(body)
+		}
+		return c.Next()
+	}
+}
+
+// bodyTooLarge rejects the request and closes the connection, since the rest
+// of its body is left unread.
+func bodyTooLarge(c *fiber.Ctx) error {
+	c.Context().SetConnectionClose()
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Request body too large"})
+}
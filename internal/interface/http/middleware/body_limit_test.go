@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	// Create test app with an upload route that allows more than the default
+	app := fiber.New(fiber.Config{StreamRequestBody: true, DisablePreParseMultipartForm: true})
+	echo := func(c *fiber.Ctx) error {
+		return c.SendString(strconv.Itoa(len(c.Body())))
+	}
+	app.Post("/upload", BodyLimit(32), echo)
+	app.Use(BodyLimit(16))
+	app.Post("/form", echo)
+
+	tests := []struct {
+		name           string
+		url            string
+		body           string
+		chunked        bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Body within the default limit", "/form", strings.Repeat("a", 16), false, fiber.StatusOK, "16"},
+		{"Body over the default limit", "/form", strings.Repeat("a", 17), false, fiber.StatusRequestEntityTooLarge, `{"error":"Request body too large"}`},
+		{"Upload over the default limit", "/upload", strings.Repeat("a", 32), false, fiber.StatusOK, "32"},
+		{"Upload over its own limit", "/upload", strings.Repeat("a", 33), false, fiber.StatusRequestEntityTooLarge, `{"error":"Request body too large"}`},
+		{"Chunked body within the limit", "/form", strings.Repeat("a", 16), true, fiber.StatusOK, "16"},
+		{"Chunked body over the limit", "/form", strings.Repeat("a", 17), true, fiber.StatusRequestEntityTooLarge, `{"error":"Request body too large"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.expectedBody, string(body))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// xAPI (Learning Record Store) authentication and version middleware
+
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+
+	"training-portal/internal/domain/xapi"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// XAPIMiddleware authenticates requests to the Learning Record Store and
+// checks the X-Experience-API-Version header the specification requires.
+// Besides portal tokens it accepts HTTP Basic credentials of the configured
+// clients (key to secret), which external tools use; the client's key is
+// stored in the xapi_client local.
+func XAPIMiddleware(clients map[string]string) fiber.Handler {
+	jwtAuth := JWTMiddleware()
+	return func(c *fiber.Ctx) error {
+		c.Set("X-Experience-API-Version", xapi.Version)
+		if v := c.Get("X-Experience-API-Version"); !strings.HasPrefix(v, "1.0") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "X-Experience-API-Version 1.0.x is required"})
+		}
+		auth := c.Get(fiber.HeaderAuthorization)
+		if !strings.HasPrefix(auth, "Basic ") {
+			return jwtAuth(c)
+		}
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Basic "))
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
+		}
+		key, secret, _ := strings.Cut(string(raw), ":")
+		want, ok := clients[key]
+		if !ok || want == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(want)) != 1 {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
+		}
+		c.Locals("xapi_client", key)
+		return c.Next()
+	}
+}
@@ -3,14 +3,36 @@
 package router
 
 import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"log"
 	"os"
+	"strings"
+	"time"
 	"training-portal/configs"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/interface/badge"
+	"training-portal/internal/interface/csv"
 	"training-portal/internal/interface/http/handler"
 	"training-portal/internal/interface/http/middleware"
+	"training-portal/internal/interface/mail"
+	"training-portal/internal/interface/pdf"
 	"training-portal/internal/interface/repository/postgres"
+	"training-portal/internal/interface/storage"
+	analyticsusecase "training-portal/internal/usecase/analytics"
+	certificateusecase "training-portal/internal/usecase/certificate"
+	complianceusecase "training-portal/internal/usecase/compliance"
 	courseusecase "training-portal/internal/usecase/course"
+	enrollmentusecase "training-portal/internal/usecase/enrollment"
+	forumusecase "training-portal/internal/usecase/forum"
+	messageusecase "training-portal/internal/usecase/message"
+	notificationusecase "training-portal/internal/usecase/notification"
+	realtimeusecase "training-portal/internal/usecase/realtime"
+	schedulerusecase "training-portal/internal/usecase/scheduler"
+	scormusecase "training-portal/internal/usecase/scorm"
 	userusecase "training-portal/internal/usecase/user"
+	xapiusecase "training-portal/internal/usecase/xapi"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -44,22 +66,273 @@ func SetupAndRun() {
 	userRepo := postgres.NewUserRepository(db)
 	courseRepo := postgres.NewCourseRepository(db)
 	moduleRepo := postgres.NewModuleRepository(db)
+	enrollmentRepo := postgres.NewEnrollmentRepository(db)
+	enrollmentRuleRepo := postgres.NewEnrollmentRuleRepository(db)
+	realtimeRepo := postgres.NewRealtimeEventRepository(db)
+	jobRepo := postgres.NewJobRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	forumRepo := postgres.NewForumRepository(db)
+	forumModerationRepo := postgres.NewForumModerationRepository(db)
+	forumSubscriptionRepo := postgres.NewForumSubscriptionRepository(db)
+	reminderRepo := postgres.NewReminderRepository(db)
+	notificationRepo := postgres.NewNotificationRepository(db)
+	notificationTemplateRepo := postgres.NewNotificationTemplateRepository(db)
+	notificationPreferenceRepo := postgres.NewNotificationPreferenceRepository(db)
+	cycleRepo := postgres.NewCertificationCycleRepository(db)
+	certificateRepo := postgres.NewCertificateRepository(db)
+	certificateTemplateRepo := postgres.NewCertificateTemplateRepository(db)
+	analyticsRepo := postgres.NewAnalyticsRepository(db)
+	complianceRepo := postgres.NewComplianceRepository(db)
+	reportScheduleRepo := postgres.NewReportScheduleRepository(db)
+	xapiRepo := postgres.NewXAPIRepository(db)
+	progressRepo := postgres.NewProgressRepository(db)
+	scormRepo := postgres.NewSCORMRepository(db)
+
+	// Init file storage
+	fileStore := loadFileStore()
+	attachmentPolicy := loadAttachmentPolicy()
 
 	// Init services
-	userService := &userusecase.UserService{Repo: userRepo}
+	scheduler := &schedulerusecase.Scheduler{Repo: jobRepo, Instance: viperGetString("scheduler.instance")}
+	realtimeService := &realtimeusecase.Service{Repo: realtimeRepo, Retention: viper.GetDuration("realtime.retention")}
+	analyticsService := &analyticsusecase.Service{
+		Repo: analyticsRepo,
+		Limits: analyticsusecase.Limits{
+			MaxBatch: viper.GetInt("analytics.max_batch"),
+			MaxAge:   viper.GetDuration("analytics.max_age"),
+		},
+	}
+	analyticsReportService := &analyticsusecase.ReportService{Repo: analyticsRepo, Courses: courseRepo, Modules: moduleRepo}
 	courseService := &courseusecase.CourseService{Repo: courseRepo}
 	moduleService := &courseusecase.ModuleService{Repo: moduleRepo}
+	messageService := &messageusecase.MessageService{
+		Repo:         messageRepo,
+		Users:        userRepo,
+		Events:       realtimeService,
+		MaxGroupSize: viper.GetInt("messages.max_group_size"),
+		MaxLength:    viper.GetInt("messages.max_length"),
+		Files:        fileStore,
+		Attachments:  attachmentPolicy,
+		LinkSecret:   loadLinkSecret("messages.attachments.link_secret"),
+		LinkTTL:      viper.GetDuration("messages.attachments.link_ttl"),
+	}
+	notificationTemplateService := &notificationusecase.TemplateService{Repo: notificationTemplateRepo}
+	notificationPreferenceService := &notificationusecase.PreferenceService{Repo: notificationPreferenceRepo}
+	notificationService := &notificationusecase.NotificationService{
+		Repo:          notificationRepo,
+		Users:         userRepo,
+		Templates:     notificationTemplateService,
+		Preferences:   notificationPreferenceService,
+		DefaultLocale: viperGetString("notifications.default_locale"),
+		Events:        realtimeService,
+		Retry: notificationusecase.RetryPolicy{
+			MaxAttempts: viper.GetInt("notifications.retry.max_attempts"),
+			BaseDelay:   viper.GetDuration("notifications.retry.base_delay"),
+			MaxDelay:    viper.GetDuration("notifications.retry.max_delay"),
+		},
+	}
+	// Scheduled reports are emailed through the same relay, and fail while
+	// none is configured.
+	var mailer complianceusecase.Mailer
+	if addr := viperGetString("notifications.smtp.addr"); addr != "" {
+		smtpChannel := &mail.SMTPChannel{
+			Addr:     addr,
+			From:     viperGetString("notifications.smtp.from"),
+			Username: viperGetString("notifications.smtp.username"),
+			Password: viperGetString("notifications.smtp.password"),
+		}
+		notificationService.Channels = append(notificationService.Channels, smtpChannel)
+		mailer = smtpChannel
+	}
+	enrollmentService := &enrollmentusecase.EnrollmentService{Repo: enrollmentRepo, Courses: courseRepo, Notifier: notificationService, Events: realtimeService}
+	forumService := &forumusecase.ForumService{
+		Repo:          forumRepo,
+		Moderation:    forumModerationRepo,
+		Subscriptions: forumSubscriptionRepo,
+		Courses:       courseRepo,
+		Modules:       moduleRepo,
+		Enrollments:   enrollmentService,
+		Users:         userRepo,
+		Notifier:      notificationService,
+		MaxLength:     viper.GetInt("forums.max_length"),
+		BlockedWords:  viper.GetStringSlice("forums.moderation.blocked_words"),
+	}
+	enrollmentRuleService := &enrollmentusecase.RuleService{Repo: enrollmentRuleRepo, Users: userRepo, Enrollments: enrollmentService}
+	userService := &userusecase.UserService{Repo: userRepo, AutoEnroll: enrollmentRuleService}
+	deadlineService := &enrollmentusecase.DeadlineService{
+		Repo:     enrollmentRepo,
+		Users:    userRepo,
+		Courses:  courseRepo,
+		Notifier: notificationService,
+		Policy: enrollmentusecase.EscalationPolicy{
+			ManagerAfter: viper.GetDuration("compliance.escalation.manager_after"),
+			HRAfter:      viper.GetDuration("compliance.escalation.hr_after"),
+			HRUserIDs:    viper.GetStringSlice("compliance.escalation.hr_user_ids"),
+		},
+	}
+	recertificationService := &enrollmentusecase.RecertificationService{
+		Repo:        cycleRepo,
+		Enrollments: enrollmentService,
+		Deadlines:   deadlineService,
+		Notifier:    notificationService,
+		Policy: enrollmentusecase.RecertificationPolicy{
+			RenewBefore:    viper.GetDuration("compliance.recertification.renew_before"),
+			ExpiringWithin: viper.GetDuration("compliance.recertification.expiring_within"),
+			ReminderDays:   viper.GetIntSlice("compliance.recertification.reminder_days"),
+		},
+	}
+	reminderService := &enrollmentusecase.ReminderService{
+		Repo:     reminderRepo,
+		Courses:  courseRepo,
+		Notifier: notificationService,
+		Steps:    loadReminderSteps(),
+		Window:   viper.GetDuration("reminders.window"),
+	}
+	certificateService := &certificateusecase.CertificateService{
+		Repo:          certificateRepo,
+		Templates:     certificateTemplateRepo,
+		Files:         fileStore,
+		Renderer:      pdf.CertificateRenderer{},
+		Users:         userRepo,
+		Courses:       courseRepo,
+		Key:           loadSigningKey(viperGetString("certificates.signing_key")),
+		VerifyBaseURL: viperGetString("certificates.public_base_url"),
+		Notifier:      notificationService,
+		Analytics:     analyticsService,
+		Scores:        progressRepo,
+	}
+	certificateTemplateService := &certificateusecase.TemplateService{Repo: certificateTemplateRepo, Files: fileStore}
+	badgeService := &certificateusecase.BadgeService{
+		Certificates: certificateService,
+		Renderer:     badge.Renderer{},
+		IssuerName:   viperGetString("certificates.issuer_name"),
+	}
+	complianceReportService := &complianceusecase.ReportService{
+		Repo:        complianceRepo,
+		Overdue:     deadlineService,
+		Renderer:    pdf.ReportRenderer{},
+		CSVRenderer: csv.ReportRenderer{},
+		Users:       userRepo,
+	}
+	reportScheduleService := &complianceusecase.ScheduleService{
+		Repo:       reportScheduleRepo,
+		Reports:    complianceReportService,
+		Users:      userRepo,
+		Mailer:     mailer,
+		Files:      fileStore,
+		LinkSecret: loadLinkSecret("reports.link_secret"),
+		LinkTTL:    viper.GetDuration("reports.link_ttl"),
+		BaseURL:    viperGetString("certificates.public_base_url"),
+	}
+	xapiService := &xapiusecase.Service{
+		Repo:        xapiRepo,
+		Progress:    progressRepo,
+		Users:       userRepo,
+		Modules:     moduleRepo,
+		Enrollments: enrollmentRepo,
+		Analytics:   analyticsService,
+		Events:      realtimeService,
+		BaseURL:     viperGetString("certificates.public_base_url"),
+		Name:        viperGetString("certificates.issuer_name"),
+		MaxBatch:    viper.GetInt("xapi.max_batch"),
+		MaxDocument: viper.GetInt("xapi.max_document"),
+	}
+	scormService := &scormusecase.Service{
+		Repo:       scormRepo,
+		Courses:    courseRepo,
+		Files:      fileStore,
+		Modules:    moduleRepo,
+		Progress:   progressRepo,
+		Users:      userRepo,
+		Analytics:  analyticsService,
+		Events:     realtimeService,
+		LinkSecret: loadLinkSecret("scorm.link_secret"),
+		LinkTTL:    viper.GetDuration("scorm.link_ttl"),
+		MaxSize:    viper.GetInt64("scorm.max_extracted_size"),
+		MaxFiles:   viper.GetInt("scorm.max_files"),
+	}
+	courseService.Waitlists = enrollmentService
+	enrollmentService.Completions = []enrollmentusecase.CompletionRecorder{recertificationService, certificateService}
+	// The portal's own learning events are also recorded as xAPI statements.
+	enrollmentService.Observers = []enrollmentusecase.StatusObserver{xapiService}
+	analyticsService.Listeners = []analyticsusecase.Listener{xapiService}
+	analyticsService.Enrollments = enrollmentService
 
 	// Init handlers
-	userHandler := &handler.UserHandler{Service: userService}
+	userHandler := &handler.UserHandler{Service: userService, Analytics: analyticsService}
 	courseHandler := &handler.CourseHandler{Service: courseService}
-	moduleHandler := &handler.ModuleHandler{Service: moduleService}
+	moduleHandler := &handler.ModuleHandler{Service: moduleService, Enrollments: enrollmentService}
+	enrollmentHandler := &handler.EnrollmentHandler{Service: enrollmentService, Deadlines: deadlineService}
+	enrollmentRuleHandler := &handler.EnrollmentRuleHandler{Service: enrollmentRuleService}
+	complianceHandler := &handler.ComplianceHandler{Service: recertificationService, Reports: complianceReportService}
+	reportScheduleHandler := &handler.ReportScheduleHandler{Service: reportScheduleService}
+	certificateHandler := &handler.CertificateHandler{Service: certificateService, Templates: certificateTemplateService}
+	badgeHandler := &handler.BadgeHandler{Service: badgeService}
+	jobHandler := &handler.JobHandler{Scheduler: scheduler}
+	forumHandler := &handler.ForumHandler{Service: forumService}
+	messageHandler := &handler.MessageHandler{Service: messageService}
+	realtimeHandler := &handler.RealtimeHandler{Service: realtimeService, Heartbeat: viper.GetDuration("realtime.heartbeat")}
+	analyticsHandler := &handler.AnalyticsHandler{Service: analyticsService, Reports: analyticsReportService}
+	xapiHandler := &handler.XAPIHandler{Service: xapiService}
+	scormHandler := &handler.SCORMHandler{Service: scormService, Enrollments: enrollmentService}
+	quizHandler := handler.NewQuizHandler()
+	quizHandler.Events = realtimeService
+	notificationHandler := &handler.NotificationHandler{
+		Service:     notificationService,
+		Templates:   notificationTemplateService,
+		Preferences: notificationPreferenceService,
+	}
+
+	// Background jobs run through the scheduler, which uses the job rows as
+	// locks so that each run happens on only one instance.
+	compliance := configDuration("compliance.overdue_check_interval", time.Hour)
+	jobs := []struct {
+		name     string
+		interval time.Duration
+		run      schedulerusecase.Func
+	}{
+		{"auto_enrollment", configDuration("auto_enrollment.interval", time.Hour), enrollmentRuleService.RunJob},
+		{"overdue_escalation", compliance, deadlineService.RunJob},
+		{"recertification", compliance, recertificationService.RunJob}, // shares the compliance check interval
+		{"course_reminders", configDuration("reminders.interval", time.Hour), reminderService.RunJob},
+		{"notification_dispatch", configDuration("notifications.dispatch_interval", 30*time.Second), notificationService.RunJob},
+		{"realtime_prune", configDuration("realtime.prune_interval", time.Hour), realtimeService.RunJob},
+		{"analytics_aggregation", configDuration("analytics.aggregation_interval", 15*time.Minute), analyticsService.RunJob},
+		{"scheduled_reports", configDuration("reports.check_interval", time.Minute), reportScheduleService.RunJob},
+	}
+	for _, j := range jobs {
+		if err := scheduler.Register(j.name, j.interval, 0, j.run); err != nil {
+			log.Fatalf("Failed to register job %s: %v", j.name, err)
+		}
+	}
+	go scheduler.Run(context.Background(), configDuration("scheduler.tick", 10*time.Second))
+	// Stream subscribers are woken through LISTEN so events published on any
+	// instance reach clients connected to this one.
+	dsn, err := postgres.DSN()
+	if err != nil {
+		log.Fatalf("Failed to configure realtime listener: %v", err)
+	}
+	go postgres.NewRealtimeListener(dsn).Run(context.Background(), realtimeService.Wake, realtimeService.WakeAll)
 
-	app := fiber.New()
+	// Request bodies are streamed so uploads are not held in memory; the body
+	// limit middleware below is what bounds them.
+	app := fiber.New(fiber.Config{StreamRequestBody: true, DisablePreParseMultipartForm: true})
 
 	// Enable CORS for all origins (adjust as needed for production)
 	app.Use(cors.New())
 
+	// Protected API routes
+	api := app.Group("/api", middleware.JWTMiddleware())
+
+	// Uploads leave room for a message's attachments or a SCORM package plus
+	// the rest of the form. They are routed before the default body limit so
+	// only their own applies.
+	attachmentLimit := int(attachmentPolicy.MaxSize)*attachmentPolicy.MaxPerMessage + 1<<20
+	packageLimit := viper.GetInt("scorm.max_package_size") + 1<<20
+	api.Post("/conversation/:id/messages", middleware.BodyLimit(max(attachmentLimit, middleware.DefaultBodyLimit)), messageHandler.SendMessage)
+	api.Post("/course/:id/scorm", middleware.BodyLimit(max(packageLimit, middleware.DefaultBodyLimit)), scormHandler.UploadPackage)
+	app.Use(middleware.BodyLimit(middleware.DefaultBodyLimit))
+
 	// Public routes
 	app.Post("/register", userHandler.Register)
 	app.Post("/login", userHandler.Login)
@@ -67,10 +340,35 @@ func SetupAndRun() {
 	app.Get("/users", userHandler.ListUsers)
 	app.Get("/course/:id", courseHandler.GetCourse)
 	app.Get("/courses", courseHandler.ListCourses)
-	app.Get("/course/:course_id/modules", moduleHandler.ListModulesByCourse)
+	app.Get("/verify/:credential_id", certificateHandler.VerificationPage)
+	app.Get("/certificates/verify/:credential_id", certificateHandler.VerifyCertificate)
+	app.Get("/attachments/:id", messageHandler.DownloadAttachment)           // authorized by the signed link
+	app.Get("/reports/download/:id", reportScheduleHandler.Download)         // authorized by the signed link
+	app.Get("/scorm/content/:package/:expires/:sig/*", scormHandler.Content) // authorized by the signed link
 
-	// Protected API routes
-	api := app.Group("/api", middleware.JWTMiddleware())
+	// Open Badges 3.0 issuer, achievements and hosted credentials
+	app.Get("/ob/issuer", badgeHandler.GetIssuer)
+	app.Get("/ob/issuer/key", badgeHandler.GetIssuerKey)
+	app.Get("/ob/achievements/:course_id", badgeHandler.GetAchievement)
+	app.Get("/ob/achievements/:course_id/image", badgeHandler.GetAchievementImage)
+	app.Get("/ob/credentials/:credential_id", badgeHandler.GetCredential)
+	app.Get("/ob/credentials/:credential_id/status", badgeHandler.GetCredentialStatus)
+	app.Post("/ob/verify", badgeHandler.VerifyBadge)
+
+	// xAPI Learning Record Store, for portal users and configured client tools
+	app.Get("/xapi/about", xapiHandler.About)
+	lrs := app.Group("/xapi", middleware.XAPIMiddleware(viper.GetStringMapString("xapi.clients")))
+	lrs.Put("/statements", xapiHandler.PutStatement)
+	lrs.Post("/statements", xapiHandler.PostStatements)
+	lrs.Get("/statements", xapiHandler.GetStatements)
+	lrs.Get("/activities/state", xapiHandler.GetState)
+	lrs.Put("/activities/state", xapiHandler.PutState)
+	lrs.Post("/activities/state", xapiHandler.PostState)
+	lrs.Delete("/activities/state", xapiHandler.DeleteState)
+	lrs.Get("/activities/profile", xapiHandler.GetActivityProfile)
+	lrs.Put("/activities/profile", xapiHandler.PutActivityProfile)
+	lrs.Post("/activities/profile", xapiHandler.PostActivityProfile)
+	lrs.Delete("/activities/profile", xapiHandler.DeleteActivityProfile)
 
 	// User management
 	api.Put("/user/:id", userHandler.UpdateUser)
@@ -82,12 +380,162 @@ func SetupAndRun() {
 	api.Put("/course/:id", courseHandler.UpdateCourse)
 	api.Delete("/course/:id", courseHandler.DeleteCourse)
 
-	// Module management
+	// Module management (content requires enrollment)
+	api.Get("/course/:course_id/modules", moduleHandler.ListModulesByCourse)
 	api.Post("/module", moduleHandler.CreateModule)
 	api.Get("/module/:id", moduleHandler.GetModule)
 	api.Put("/module/:id", moduleHandler.UpdateModule)
 	api.Delete("/module/:id", moduleHandler.DeleteModule)
 
+	// Quizzes (grading releases the grade to the learner)
+	api.Post("/quiz", quizHandler.CreateQuiz)
+	api.Get("/quizzes", quizHandler.ListQuizzes)
+	api.Get("/quiz/:id", quizHandler.GetQuiz)
+	api.Post("/quiz/:id/submit", quizHandler.SubmitQuiz)
+	api.Get("/quiz/:id/grade", quizHandler.GradeQuiz)
+
+	// SCORM packages and runtime (running a SCO requires enrollment); packages
+	// are uploaded through the route with its own body limit above
+	api.Get("/course/:id/scorm", scormHandler.ListPackages)
+	api.Get("/scorm/package/:id", scormHandler.GetPackage)
+	api.Post("/scorm/sco/:id/launch", scormHandler.Launch)
+	api.Post("/scorm/sco/:id/commit", scormHandler.Commit)
+	api.Get("/scorm/sco/:id/attempt", scormHandler.GetAttempt)
+
+	// Enrollment
+	api.Post("/enroll", enrollmentHandler.EnrollUser)
+	api.Post("/unenroll", enrollmentHandler.UnenrollUser)
+	api.Post("/enrollment/complete", enrollmentHandler.CompleteEnrollment)
+	api.Post("/enrollment/invite", enrollmentHandler.InviteUser)
+	api.Get("/enrollments", enrollmentHandler.ListEnrollments)
+	api.Get("/enrollments/pending", enrollmentHandler.ListPendingApprovals)
+	api.Get("/enrollment/:id/history", enrollmentHandler.GetEnrollmentHistory)
+	api.Post("/enrollment/:id/approve", enrollmentHandler.ApproveEnrollment)
+	api.Post("/enrollment/:id/reject", enrollmentHandler.RejectEnrollment)
+	api.Get("/course/:id/waitlist", enrollmentHandler.GetWaitlist)
+	api.Put("/enrollment/:id/deadline", enrollmentHandler.SetDeadline)
+
+	// Compliance reports
+	api.Get("/reports/overdue", enrollmentHandler.OverdueReport)
+	api.Get("/compliance", complianceHandler.GetCompliance)
+	api.Get("/compliance/history", complianceHandler.GetCertificationHistory)
+	api.Get("/compliance/reports/:kind", complianceHandler.GetReport)
+	api.Post("/compliance/snapshots", complianceHandler.CreateSnapshot)
+	api.Get("/compliance/snapshots", complianceHandler.ListSnapshots)
+	api.Get("/compliance/snapshot/:id", complianceHandler.GetSnapshot)
+
+	// Scheduled report delivery
+	api.Post("/report-schedules", reportScheduleHandler.CreateSchedule)
+	api.Get("/report-schedules", reportScheduleHandler.ListSchedules)
+	api.Get("/report-schedule/:id", reportScheduleHandler.GetSchedule)
+	api.Put("/report-schedule/:id", reportScheduleHandler.UpdateSchedule)
+	api.Delete("/report-schedule/:id", reportScheduleHandler.DeleteSchedule)
+	api.Post("/report-schedule/:id/run", reportScheduleHandler.RunSchedule)
+	api.Get("/report-schedule/:id/runs", reportScheduleHandler.GetScheduleRuns)
+	api.Get("/report-runs", reportScheduleHandler.ListRuns) // admin only
+
+	// Certificates
+	api.Post("/certificates", certificateHandler.IssueCertificate)
+	api.Get("/certificate/:id", certificateHandler.GetCertificate)
+	api.Get("/certificate/:id/download", certificateHandler.DownloadCertificate)
+	api.Get("/certificate/:id/badge", badgeHandler.ExportBadge)
+	api.Get("/certificate/:id/history", certificateHandler.GetCertificateHistory)
+	api.Post("/certificate/:id/revoke", certificateHandler.RevokeCertificate)
+	api.Post("/certificate/:id/reissue", certificateHandler.ReissueCertificate)
+	api.Get("/user/:user_id/certificates", certificateHandler.ListCertificates)
+
+	// Certificate templates (admin only)
+	api.Post("/certificate-templates", certificateHandler.CreateTemplate)
+	api.Get("/certificate-templates", certificateHandler.ListTemplates)
+	api.Put("/certificate-template/:id", certificateHandler.UpdateTemplate)
+	api.Delete("/certificate-template/:id", certificateHandler.DeleteTemplate)
+	api.Post("/certificate-template/:id/reissue", certificateHandler.ReissueTemplateCertificates)
+	api.Put("/certificate-template/:id/:kind", certificateHandler.UploadTemplateImage)
+
+	// Auto-enrollment rules (admin only)
+	api.Post("/enrollment-rules", enrollmentRuleHandler.CreateRule)
+	api.Get("/enrollment-rules", enrollmentRuleHandler.ListRules)
+	api.Post("/enrollment-rules/preview", enrollmentRuleHandler.PreviewRule)
+	api.Post("/enrollment-rules/evaluate", enrollmentRuleHandler.EvaluateRules)
+	api.Put("/enrollment-rule/:id", enrollmentRuleHandler.UpdateRule)
+	api.Delete("/enrollment-rule/:id", enrollmentRuleHandler.DeleteRule)
+	api.Get("/enrollment-rule/:id/audit", enrollmentRuleHandler.GetRuleAudit)
+
+	// Notifications
+	api.Get("/notifications", notificationHandler.ListNotifications)
+	api.Post("/notifications", notificationHandler.CreateNotification)
+	api.Get("/notification/:id", notificationHandler.GetNotification)
+	api.Post("/notifications/:id/read", notificationHandler.MarkAsRead)
+	api.Get("/notification-settings", notificationHandler.GetNotificationSettings)
+	api.Put("/notification-settings", notificationHandler.UpdateNotificationSettings)
+	api.Get("/notification-templates", notificationHandler.ListNotificationTemplates)
+	api.Put("/notification-template/:event/:locale", notificationHandler.SaveNotificationTemplate)
+	api.Delete("/notification-template/:event/:locale", notificationHandler.DeleteNotificationTemplate)
+
+	// Direct messages; sending one is routed above with the attachment body limit
+	api.Get("/conversations", messageHandler.ListConversations)
+	api.Post("/conversations", messageHandler.CreateConversation)
+	api.Get("/conversation/:id", messageHandler.GetConversation)
+	api.Get("/conversation/:id/messages", messageHandler.ListMessages)
+	api.Post("/conversation/:id/read", messageHandler.MarkConversationRead)
+	api.Post("/conversation/:id/leave", messageHandler.LeaveConversation)
+	api.Get("/attachment/:id/link", messageHandler.AttachmentLink)
+
+	// Forums (course and module forums need enrollment)
+	api.Get("/forums", forumHandler.ListForums)
+	api.Post("/forums", forumHandler.CreateForum)
+	api.Get("/forum/:id", forumHandler.GetForum)
+	api.Get("/course/:id/forum", forumHandler.GetCourseForum)
+	api.Get("/module/:id/forum", forumHandler.GetModuleForum)
+	api.Get("/forum/:id/posts", forumHandler.ListPosts)
+	api.Post("/forum/:id/posts", forumHandler.CreatePost)
+	api.Get("/post/:id", forumHandler.GetPost)
+	api.Put("/post/:id", forumHandler.UpdatePost)
+	api.Delete("/post/:id", forumHandler.DeletePost)
+	api.Get("/post/:id/history", forumHandler.GetPostHistory)
+	api.Get("/post/:id/replies", forumHandler.ListReplies)
+	api.Post("/post/:id/replies", forumHandler.CreateReply)
+	api.Put("/reply/:id", forumHandler.UpdateReply)
+	api.Delete("/reply/:id", forumHandler.DeleteReply)
+	api.Get("/reply/:id/history", forumHandler.GetReplyHistory)
+	api.Post("/post/:id/vote", forumHandler.VotePost)
+	api.Post("/reply/:id/vote", forumHandler.VoteReply)
+	api.Put("/post/:id/accepted", forumHandler.AcceptAnswer)
+	api.Get("/course/:id/unanswered", forumHandler.ListUnanswered)
+	api.Post("/post/:id/report", forumHandler.ReportPost)
+	api.Post("/reply/:id/report", forumHandler.ReportReply)
+	api.Get("/moderation/queue", forumHandler.ModerationQueue)
+	api.Get("/moderation/log", forumHandler.ModerationLog)
+	api.Post("/post/:id/moderate", forumHandler.ModeratePost)
+	api.Post("/reply/:id/moderate", forumHandler.ModerateReply)
+	api.Get("/course/:id/suspensions", forumHandler.ListSuspensions)
+	api.Post("/course/:id/suspensions", forumHandler.Suspend)
+	api.Delete("/suspension/:id", forumHandler.LiftSuspension)
+	api.Get("/forums/subscriptions", forumHandler.ListSubscriptions)
+	api.Put("/forum/:id/subscription", forumHandler.SubscribeForum)
+	api.Delete("/forum/:id/subscription", forumHandler.UnsubscribeForum)
+	api.Put("/post/:id/subscription", forumHandler.SubscribeThread)
+	api.Delete("/post/:id/subscription", forumHandler.UnsubscribeThread)
+
+	// Realtime events (Server-Sent Events, with replay from a cursor)
+	api.Get("/events", realtimeHandler.ListEvents)
+	api.Get("/events/stream", realtimeHandler.StreamEvents)
+
+	// Analytics (reports are for staff and managers)
+	api.Post("/analytics/events", analyticsHandler.IngestEvents)
+	api.Get("/analytics/events", analyticsHandler.GetEvents)
+	api.Get("/analytics/user-engagement", analyticsHandler.GetUserEngagement)
+	api.Get("/analytics/course/:courseID", analyticsHandler.GetCourseAnalytics)
+	api.Get("/analytics/course/:courseID/funnel", analyticsHandler.GetFunnel)
+	api.Get("/analytics/cohorts", analyticsHandler.GetCohorts)
+	api.Get("/analytics/completion-time", analyticsHandler.GetCompletionTime)
+
+	// Background jobs (admin only)
+	api.Get("/jobs", jobHandler.ListJobs)
+	api.Post("/job/:name/pause", jobHandler.PauseJob)
+	api.Post("/job/:name/resume", jobHandler.ResumeJob)
+	api.Post("/job/:name/run", jobHandler.RunJob)
+
 	api.Get("/dashboard", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"message": "Welcome to the protected dashboard!"})
 	})
@@ -110,6 +558,114 @@ func setEnvIfEmpty(key, value string) {
 	}
 }
 
+// loadFileStore returns the file store selected by storage.driver.
+func loadFileStore() storage.Store {
+	switch driver := viperGetString("storage.driver"); driver {
+	case "", "local":
+		root := viper.GetString("storage.local_root")
+		if root == "" {
+			root = "./data"
+		}
+		return storage.NewLocalStore(root)
+	case "s3":
+		s := storage.NewS3Store(
+			viperGetString("storage.s3.endpoint"),
+			viperGetString("storage.s3.region"),
+			viperGetString("storage.s3.bucket"),
+			viperGetString("storage.s3.access_key"),
+			viperGetString("storage.s3.secret_key"),
+		)
+		s.Prefix = viperGetString("storage.s3.prefix")
+		if s.Endpoint == "" || s.Bucket == "" {
+			log.Fatal("storage.s3.endpoint and storage.s3.bucket are required for the s3 driver")
+		}
+		return s
+	default:
+		log.Fatalf("Unknown storage.driver %q", driver)
+		return nil
+	}
+}
+
+// loadAttachmentPolicy reads the message attachment limits. Allowed types are
+// a subset of the built-in extension list.
+func loadAttachmentPolicy() messageusecase.AttachmentPolicy {
+	p := messageusecase.AttachmentPolicy{
+		MaxSize:       viper.GetInt64("messages.attachments.max_size"),
+		MaxPerMessage: viper.GetInt("messages.attachments.max_files"),
+	}
+	if p.MaxSize <= 0 {
+		p.MaxSize = 10 << 20
+	}
+	if p.MaxPerMessage <= 0 {
+		p.MaxPerMessage = 5
+	}
+	if allowed := viper.GetStringSlice("messages.attachments.allowed_types"); len(allowed) > 0 {
+		p.Types = map[string]string{}
+		for _, ext := range allowed {
+			ext = strings.ToLower(ext)
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			contentType, ok := messageusecase.DefaultAttachmentTypes[ext]
+			if !ok {
+				log.Fatalf("messages.attachments.allowed_types: unsupported type %q", ext)
+			}
+			p.Types[ext] = contentType
+		}
+	}
+	return p
+}
+
+// loadLinkSecret returns the download link secret configured under setting.
+// The secret is required: a temporary one would break every link handed out
+// before the next restart.
+func loadLinkSecret(setting string) []byte {
+	secret := viperGetString(setting)
+	if secret == "" {
+		log.Fatalf("%s must be set", setting)
+	}
+	return []byte(secret)
+}
+
+// loadSigningKey decodes a base64 Ed25519 seed. The key is required: a
+// temporary one would make every certificate issued before the next restart
+// fail verification.
+func loadSigningKey(seed string) ed25519.PrivateKey {
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if seed == "" || err != nil || len(raw) != ed25519.SeedSize {
+		log.Fatalf("certificates.signing_key must be a base64-encoded %d-byte seed", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(raw)
+}
+
+// loadReminderSteps reads the reminder sequence from reminders.steps.
+func loadReminderSteps() []enrollment.ReminderStep {
+	var config []struct {
+		Name   string `mapstructure:"name"`
+		Anchor string `mapstructure:"anchor"`
+		Days   int    `mapstructure:"days"`
+	}
+	if err := viper.UnmarshalKey("reminders.steps", &config); err != nil {
+		log.Fatalf("Invalid reminders.steps: %v", err)
+	}
+	steps := make([]enrollment.ReminderStep, 0, len(config))
+	for _, c := range config {
+		steps = append(steps, enrollment.ReminderStep{Name: c.Name, Anchor: enrollment.ReminderAnchor(c.Anchor), Days: c.Days})
+	}
+	if err := enrollmentusecase.ValidateReminderSteps(steps); err != nil {
+		log.Fatalf("Invalid reminders.steps: %v", err)
+	}
+	return steps
+}
+
+// configDuration returns a duration from config, or def when it is unset or not positive.
+func configDuration(key string, def time.Duration) time.Duration {
+	if d := viper.GetDuration(key); d > 0 {
+		return d
+	}
+	return def
+}
+
 // viperGetString is a helper to get a string from viper config.
 func viperGetString(key string) string {
 	return configsGetString(key)
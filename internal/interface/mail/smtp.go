@@ -0,0 +1,174 @@
+// Package mail delivers notifications and reports by email.
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/user"
+)
+
+// SMTPChannel sends notifications as plain-text email through an SMTP relay.
+// STARTTLS is used whenever the server offers it; credentials are optional.
+type SMTPChannel struct {
+	Addr     string // host:port of the relay
+	From     string // sender address, e.g. "Training Portal <noreply@example.com>"
+	Username string
+	Password string
+}
+
+// Type reports that the channel delivers email.
+func (s *SMTPChannel) Type() notification.NotificationType {
+	return notification.TypeEmail
+}
+
+// Send emails n to the user's address.
+func (s *SMTPChannel) Send(to *user.User, n *notification.Notification) error {
+	if to.Email == "" {
+		return errors.New("recipient has no email address")
+	}
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	rcpt := &mail.Address{Name: to.Name, Address: to.Email}
+	msg, err := s.message(from, rcpt, n)
+	if err != nil {
+		return err
+	}
+	return s.send(from, []string{rcpt.Address}, msg)
+}
+
+// SendEmail sends e with its attachments. With several recipients the To
+// header is left undisclosed so they do not see each other's addresses.
+func (s *SMTPChannel) SendEmail(e *notification.Email) error {
+	if len(e.To) == 0 {
+		return errors.New("email has no recipients")
+	}
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	for _, addr := range e.To {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("invalid recipient address %q: %w", addr, err)
+		}
+	}
+	to := "undisclosed-recipients:;"
+	if len(e.To) == 1 {
+		to = e.To[0]
+	}
+
+	var buf bytes.Buffer
+	writeHeaders(&buf, from, to, e.Subject, time.Now())
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeQuotedPrintable(part, e.Body); err != nil {
+		return err
+	}
+	for _, a := range e.Attachments {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.FileName})},
+		})
+		if err != nil {
+			return err
+		}
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			io.WriteString(part, encoded[:76]+"\r\n")
+			encoded = encoded[76:]
+		}
+		io.WriteString(part, encoded+"\r\n")
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	return s.send(from, e.To, buf.Bytes())
+}
+
+// send hands msg to the relay, authenticating when credentials are set.
+func (s *SMTPChannel) send(from *mail.Address, to []string, msg []byte) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	return smtp.SendMail(s.Addr, auth, from.Address, to, msg)
+}
+
+// message builds an RFC 5322 message with a quoted-printable UTF-8 body.
+func (s *SMTPChannel) message(from, to *mail.Address, n *notification.Notification) ([]byte, error) {
+	subject := n.Title
+	if subject == "" {
+		subject = "Notification"
+	}
+	var buf bytes.Buffer
+	writeHeaders(&buf, from, to.String(), subject, time.Unix(n.CreatedAt, 0))
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+	if err := writeQuotedPrintable(&buf, n.Message); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeHeaders writes the headers every message has, up to but not
+// including its Content-Type.
+func writeHeaders(buf *bytes.Buffer, from *mail.Address, to, subject string, date time.Time) {
+	var id [12]byte
+	rand.Read(id[:])
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	header := func(key, value string) {
+		// Values are single-line by construction; strip anything that could
+		// start a new header.
+		value = strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+		fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+	}
+	header("From", from.String())
+	header("To", to)
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", date.UTC().Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id[:])+"@"+domain+">")
+	header("MIME-Version", "1.0")
+}
+
+// writeQuotedPrintable writes text as a quoted-printable body with CRLF line
+// endings.
+func writeQuotedPrintable(dst io.Writer, text string) error {
+	w := quotedprintable.NewWriter(dst)
+	body := strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := w.Write([]byte(body + "\r\n")); err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,186 @@
+package mail
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/user"
+)
+
+// smtpStandIn is a minimal SMTP server that accepts one message per session.
+type smtpStandIn struct {
+	ln       net.Listener
+	messages chan received
+}
+
+type received struct {
+	from, to string
+	data     string
+}
+
+func startSMTPStandIn(t *testing.T) *smtpStandIn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStandIn{ln: ln, messages: make(chan received, 1)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	var msg received
+
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			msg.from = cmd
+			reply("250 OK")
+		case "RCPT":
+			msg.to = cmd
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			msg.data = data.String()
+			s.messages <- msg
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPChannel_Send(t *testing.T) {
+	server := startSMTPStandIn(t)
+	channel := &SMTPChannel{Addr: server.ln.Addr().String(), From: "Training Portal <noreply@example.com>"}
+	n := &notification.Notification{
+		Title:     "Overdue training: Sécurité",
+		Message:   "Your course is overdue.\nPlease complete it this week.",
+		CreatedAt: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC).Unix(),
+	}
+	if err := channel.Send(&user.User{Name: "Ada", Email: "ada@example.com"}, n); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	select {
+	case got := <-server.messages:
+		if got.from != "MAIL FROM:<noreply@example.com>" || got.to != "RCPT TO:<ada@example.com>" {
+			t.Errorf("envelope = %q, %q", got.from, got.to)
+		}
+		m, err := mail.ReadMessage(strings.NewReader(got.data))
+		if err != nil {
+			t.Fatalf("ReadMessage() error = %v", err)
+		}
+		subject, _ := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+		if subject != n.Title {
+			t.Errorf("Subject = %q, want %q", subject, n.Title)
+		}
+		body, _ := io.ReadAll(quotedprintable.NewReader(m.Body))
+		if !strings.Contains(string(body), "Please complete it this week.") {
+			t.Errorf("body = %q", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stand-in received no message")
+	}
+}
+
+func TestSMTPChannel_SendRequiresAddress(t *testing.T) {
+	channel := &SMTPChannel{Addr: "127.0.0.1:1", From: "noreply@example.com"}
+	if err := channel.Send(&user.User{Name: "No Mail"}, &notification.Notification{Message: "x"}); err == nil {
+		t.Error("Send() to a user without email expected error")
+	}
+}
+
+func TestSMTPChannel_SendEmail(t *testing.T) {
+	server := startSMTPStandIn(t)
+	channel := &SMTPChannel{Addr: server.ln.Addr().String(), From: "Training Portal <noreply@example.com>"}
+	pdf := []byte("%PDF-1.4 " + strings.Repeat("x", 200))
+	e := &notification.Email{
+		To:          []string{"ada@example.com", "grace@example.com"},
+		Subject:     "Weekly team progress",
+		Body:        "Team training progress\nGenerated today",
+		Attachments: []notification.EmailAttachment{{FileName: "team-progress.pdf", ContentType: "application/pdf", Data: pdf}},
+	}
+	if err := channel.SendEmail(e); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+
+	select {
+	case got := <-server.messages:
+		m, err := mail.ReadMessage(strings.NewReader(got.data))
+		if err != nil {
+			t.Fatalf("ReadMessage() error = %v", err)
+		}
+		if to := m.Header.Get("To"); to != "undisclosed-recipients:;" {
+			t.Errorf("To = %q, want recipients hidden from each other", to)
+		}
+		mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/mixed" {
+			t.Fatalf("Content-Type = %q, %v", m.Header.Get("Content-Type"), err)
+		}
+		r := multipart.NewReader(m.Body, params["boundary"])
+		text, err := r.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(quotedprintable.NewReader(text))
+		if !strings.Contains(string(body), "Generated today") {
+			t.Errorf("body = %q", body)
+		}
+		attachment, err := r.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, _ := io.ReadAll(attachment)
+		data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+		if attachment.FileName() != "team-progress.pdf" || err != nil || string(data) != string(pdf) {
+			t.Errorf("attachment %q = %q, %v", attachment.FileName(), data, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stand-in received no message")
+	}
+}
@@ -0,0 +1,178 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"training-portal/internal/domain/certificate"
+)
+
+// A4 page size in points.
+const (
+	a4Short = 595.0
+	a4Long  = 842.0
+)
+
+// CertificateRenderer lays out certificate documents on a single A4 page:
+// logo, title, body lines, signature, a footer with the credential ID and a
+// QR code linking to the verification page.
+type CertificateRenderer struct{}
+
+// Render produces the PDF bytes for d.
+func (CertificateRenderer) Render(d *certificate.Document) ([]byte, error) {
+	width, height := a4Long, a4Short
+	if d.Orientation == certificate.Portrait {
+		width, height = a4Short, a4Long
+	}
+
+	w := newWriter()
+	fonts := map[font]int{regular: w.reserve(), bold: w.reserve()}
+	for f, id := range fonts {
+		w.object(id, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.baseFont))
+	}
+
+	var logo, signature *embeddedImage
+	var err error
+	if len(d.Logo) > 0 {
+		if logo, err = w.image(d.Logo); err != nil {
+			return nil, fmt.Errorf("logo: %w", err)
+		}
+	}
+	if len(d.Signature) > 0 {
+		if signature, err = w.image(d.Signature); err != nil {
+			return nil, fmt.Errorf("signature: %w", err)
+		}
+	}
+
+	c := &canvas{width: width}
+	c.printf("0.55 0.45 0.2 RG 3 w 24 24 %.2f %.2f re S", width-48, height-48)
+	c.printf("0.5 w 32 32 %.2f %.2f re S", width-64, height-64)
+
+	y := height - 70
+	if logo != nil {
+		lw, lh := fit(logo, 160, 70)
+		y -= lh
+		c.image("Im1", (width-lw)/2, y, lw, lh)
+		y -= 20
+	} else {
+		y -= 40
+	}
+
+	y -= 36
+	c.centered(bold, 34, d.Title, y)
+	y -= 50
+	for _, line := range d.Lines {
+		for _, wrapped := range wrap(regular, 16, line, width-160) {
+			c.centered(regular, 16, wrapped, y)
+			y -= 26
+		}
+	}
+
+	// Signature block: image above a rule, signatory name below it.
+	ruleY := 110.0
+	if signature != nil {
+		sw, sh := fit(signature, 180, 60)
+		c.image("Im2", (width-sw)/2, ruleY+4, sw, sh)
+	}
+	if signature != nil || d.SignatoryName != "" {
+		c.printf("0 0 0 RG 0.75 w %.2f %.2f m %.2f %.2f l S", width/2-110, ruleY, width/2+110, ruleY)
+		c.centered(regular, 12, d.SignatoryName, ruleY-16)
+	}
+	c.centered(regular, 9, d.Footer, 44)
+	if d.VerificationURL != "" {
+		q, err := encodeQR([]byte(d.VerificationURL))
+		if err != nil {
+			return nil, fmt.Errorf("verification url: %w", err)
+		}
+		c.qr(q, width-126, 48, 72)
+		c.printf("BT /%s 7 Tf %.2f 40 Td %s Tj ET", regular.name, width-126+(72-regular.width("Scan to verify", 7))/2, encodeText("Scan to verify"))
+	}
+
+	contents := w.reserve()
+	w.stream(contents, "", []byte(c.String()))
+
+	resources := fmt.Sprintf("/Font << /F1 %d 0 R /F2 %d 0 R >>", fonts[regular], fonts[bold])
+	var xobjects []string
+	if logo != nil {
+		xobjects = append(xobjects, fmt.Sprintf("/Im1 %d 0 R", logo.id))
+	}
+	if signature != nil {
+		xobjects = append(xobjects, fmt.Sprintf("/Im2 %d 0 R", signature.id))
+	}
+	if len(xobjects) > 0 {
+		resources += " /XObject << " + strings.Join(xobjects, " ") + " >>"
+	}
+
+	page, pages, catalog := w.reserve(), w.reserve(), w.reserve()
+	w.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Resources << %s >> /Contents %d 0 R >>", pages, width, height, resources, contents))
+	w.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%d 0 R] /Count 1 >>", page))
+	w.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	return w.finish(catalog), nil
+}
+
+// canvas accumulates page content operators.
+type canvas struct {
+	strings.Builder
+	width float64
+}
+
+func (c *canvas) printf(format string, args ...interface{}) {
+	fmt.Fprintf(c, format+"\n", args...)
+}
+
+// centered draws one line of text centered horizontally at baseline y.
+func (c *canvas) centered(f font, size float64, text string, y float64) {
+	if text == "" {
+		return
+	}
+	x := (c.width - f.width(text, size)) / 2
+	c.printf("BT /%s %.1f Tf 0 0 0 rg %.2f %.2f Td %s Tj ET", f.name, size, x, y, encodeText(text))
+}
+
+// image draws a named image XObject scaled to w x h with its lower-left corner at x, y.
+func (c *canvas) image(name string, x, y, w, h float64) {
+	c.printf("q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q", w, h, x, y, name)
+}
+
+// qr draws a QR code as filled squares with its lower-left corner at x, y.
+func (c *canvas) qr(q *qrCode, x, y, size float64) {
+	module := size / float64(q.size)
+	c.printf("0 0 0 rg")
+	for row := 0; row < q.size; row++ {
+		for col := 0; col < q.size; col++ {
+			if q.modules[row][col] {
+				c.printf("%.3f %.3f %.3f %.3f re", x+float64(col)*module, y+size-float64(row+1)*module, module, module)
+			}
+		}
+	}
+	c.printf("f")
+}
+
+// fit scales an image to fit within maxW x maxH, preserving its aspect ratio.
+func fit(img *embeddedImage, maxW, maxH float64) (float64, float64) {
+	w, h := float64(img.width), float64(img.height)
+	scale := maxW / w
+	if s := maxH / h; s < scale {
+		scale = s
+	}
+	return w * scale, h * scale
+}
+
+// wrap breaks text into lines no wider than maxWidth at the given size.
+func wrap(f font, size float64, text string, maxWidth float64) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+	var lines []string
+	line := words[0]
+	for _, word := range words[1:] {
+		if f.width(line+" "+word, size) > maxWidth {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line += " " + word
+	}
+	return append(lines, line)
+}
@@ -0,0 +1,67 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"training-portal/internal/domain/certificate"
+)
+
+func testPNG(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCertificateRenderer_Render(t *testing.T) {
+	doc := &certificate.Document{
+		Orientation:     certificate.Landscape,
+		Title:           "Certificate of Completion",
+		Lines:           []string{"This certifies that", "Zoë (QA) Smith", "completed Safety 101"},
+		Logo:            testPNG(t),
+		Signature:       testPNG(t),
+		SignatoryName:   "Head of Training",
+		Footer:          "Credential ID: TP-AAAA-BBBB-CCCC-DDDD",
+		VerificationURL: "https://portal.example.com/verify/TP-AAAA-BBBB-CCCC-DDDD",
+	}
+	out, err := CertificateRenderer{}.Render(doc)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	s := string(out)
+	if !strings.HasPrefix(s, "%PDF-1.4") || !strings.HasSuffix(s, "%%EOF\n") {
+		t.Error("Render() output is not a complete PDF file")
+	}
+	for _, want := range []string{"/MediaBox [0 0 842 595]", "/Im1", "/Im2", "(Zo\xeb \\(QA\\) Smith)", "(Credential ID: TP-AAAA-BBBB-CCCC-DDDD)", "(Scan to verify)"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Render() output missing %q", want)
+		}
+	}
+}
+
+func TestCertificateRenderer_InvalidImage(t *testing.T) {
+	_, err := CertificateRenderer{}.Render(&certificate.Document{Title: "x", Logo: []byte("not an image")})
+	if err == nil {
+		t.Error("Render() with invalid logo expected error")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := wrap(regular, 16, strings.Repeat("word ", 60), 400)
+	if len(lines) < 2 {
+		t.Fatalf("wrap() = %d lines, want several", len(lines))
+	}
+	for _, line := range lines {
+		if regular.width(line, 16) > 400 {
+			t.Errorf("wrap() line %q exceeds max width", line)
+		}
+	}
+}
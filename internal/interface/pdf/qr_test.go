@@ -0,0 +1,60 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncodeQR_Version(t *testing.T) {
+	tests := []struct {
+		length int
+		size   int
+	}{
+		{14, 21},  // version 1
+		{70, 37},  // version 5
+		{213, 57}, // version 10, the largest supported
+	}
+	for _, tt := range tests {
+		q, err := encodeQR([]byte(strings.Repeat("a", tt.length)))
+		if err != nil {
+			t.Fatalf("encodeQR(%d bytes) error = %v", tt.length, err)
+		}
+		if q.size != tt.size {
+			t.Errorf("encodeQR(%d bytes) size = %d, want %d", tt.length, q.size, tt.size)
+		}
+	}
+	if _, err := encodeQR(make([]byte, 214)); err == nil {
+		t.Error("encodeQR() of 214 bytes expected error")
+	}
+}
+
+func TestEncodeQR_FinderPatterns(t *testing.T) {
+	q, err := encodeQR([]byte("https://portal.example.com/verify/TP-ABCD-EFGH-IJKL-MNOP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each finder pattern has a dark outer ring, a light ring and a dark 3x3 core.
+	for _, corner := range [][2]int{{0, 0}, {q.size - 7, 0}, {0, q.size - 7}} {
+		for _, p := range [][3]int{{0, 0, 1}, {1, 1, 0}, {3, 3, 1}, {6, 6, 1}, {5, 1, 0}} {
+			x, y, dark := corner[0]+p[0], corner[1]+p[1], p[2] == 1
+			if q.modules[y][x] != dark {
+				t.Errorf("module (%d, %d) dark = %v, want %v", x, y, q.modules[y][x], dark)
+			}
+		}
+	}
+	if !q.modules[q.size-8][8] {
+		t.Error("dark module is not set")
+	}
+}
+
+func TestRSRemainder(t *testing.T) {
+	// "01234567" in numeric mode at version 1-M, from ISO/IEC 18004 Annex I.
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	want := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+	got := rsRemainder(data, rsDivisor(10))
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("rsRemainder() = % X, want % X", got, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"training-portal/internal/domain/compliance"
+)
+
+// Report table layout, in points.
+const (
+	reportMargin   = 36.0
+	reportFontSize = 7.0
+	reportLeading  = 9.0   // line height within a cell
+	reportPadding  = 3.0   // space around cell text
+	reportMaxCol   = 170.0 // widest a column grows before its text wraps
+	reportMinCol   = 36.0
+)
+
+// ReportRenderer lays out report tables on landscape A4 pages: the title and
+// subtitle on the first page, the column headings repeated on every page and
+// a footer with the page number. Cell text wraps rather than being cut off,
+// so nothing in the table is lost.
+type ReportRenderer struct{}
+
+// Render produces the PDF bytes for t.
+func (ReportRenderer) Render(t *compliance.Table) ([]byte, error) {
+	width, height := a4Long, a4Short
+	widths := columnWidths(t, width-2*reportMargin)
+
+	var pages []*canvas
+	var c *canvas
+	var y float64
+	newPage := func() {
+		c = &canvas{width: width}
+		pages = append(pages, c)
+		y = height - reportMargin
+		if len(pages) == 1 {
+			for _, line := range wrap(bold, 14, t.Title, width-2*reportMargin) {
+				y -= 16
+				c.text(bold, 14, reportMargin, y, line)
+			}
+			if t.Subtitle != "" {
+				y -= 14
+				c.text(regular, 9, reportMargin, y, t.Subtitle)
+			}
+			y -= 12
+		}
+		y = c.row(bold, widths, t.Columns, y, true)
+	}
+
+	newPage()
+	if len(t.Rows) == 0 {
+		c.text(regular, 9, reportMargin, y-14, "No records.")
+	}
+	for _, row := range t.Rows {
+		if y-rowHeight(regular, widths, row) < reportMargin+16 {
+			newPage()
+		}
+		y = c.row(regular, widths, row, y, false)
+	}
+
+	w := newWriter()
+	fonts := map[font]int{regular: w.reserve(), bold: w.reserve()}
+	for f, id := range fonts {
+		w.object(id, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.baseFont))
+	}
+	resources := fmt.Sprintf("/Font << /F1 %d 0 R /F2 %d 0 R >>", fonts[regular], fonts[bold])
+
+	pagesID := w.reserve()
+	kids := make([]string, len(pages))
+	for i, page := range pages {
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(pages))
+		if t.Footer != "" {
+			footer = t.Footer + " - " + footer
+		}
+		page.text(regular, 7, reportMargin, reportMargin-12, footer)
+
+		contents, id := w.reserve(), w.reserve()
+		w.stream(contents, "", []byte(page.String()))
+		w.object(id, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Resources << %s >> /Contents %d 0 R >>", pagesID, width, height, resources, contents))
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	w.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	catalog := w.reserve()
+	w.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	return w.finish(catalog), nil
+}
+
+// text draws one line of text with its baseline starting at x, y.
+func (c *canvas) text(f font, size, x, y float64, s string) {
+	if s == "" {
+		return
+	}
+	c.printf("BT /%s %.1f Tf 0 0 0 rg %.2f %.2f Td %s Tj ET", f.name, size, x, y, encodeText(s))
+}
+
+// row draws a table row whose top is at y and returns the y of its bottom.
+// Headings get a shaded background.
+func (c *canvas) row(f font, widths []float64, cells []string, y float64, heading bool) float64 {
+	h := rowHeight(f, widths, cells)
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	if heading {
+		c.printf("0.9 0.9 0.9 rg %.2f %.2f %.2f %.2f re f", reportMargin, y-h, total, h)
+	}
+	x := reportMargin
+	for i, w := range widths {
+		if i < len(cells) {
+			for j, line := range wrapCell(f, cells[i], w-2*reportPadding) {
+				c.text(f, reportFontSize, x+reportPadding, y-reportPadding-float64(j+1)*reportLeading+2, line)
+			}
+		}
+		x += w
+	}
+	c.printf("0.7 0.7 0.7 RG 0.5 w %.2f %.2f m %.2f %.2f l S", reportMargin, y-h, reportMargin+total, y-h)
+	return y - h
+}
+
+// rowHeight returns the height of a row with its cells wrapped to widths.
+func rowHeight(f font, widths []float64, cells []string) float64 {
+	lines := 1
+	for i, w := range widths {
+		if i < len(cells) {
+			if n := len(wrapCell(f, cells[i], w-2*reportPadding)); n > lines {
+				lines = n
+			}
+		}
+	}
+	return float64(lines)*reportLeading + 2*reportPadding
+}
+
+// columnWidths sizes columns to their widest text, up to reportMaxCol, and
+// scales them down to fit the page if needed.
+func columnWidths(t *compliance.Table, available float64) []float64 {
+	widths := make([]float64, len(t.Columns))
+	total := 0.0
+	for i, heading := range t.Columns {
+		w := bold.width(heading, reportFontSize)
+		for _, row := range t.Rows {
+			if i < len(row) {
+				if cw := regular.width(row[i], reportFontSize); cw > w {
+					w = cw
+				}
+			}
+		}
+		w += 2 * reportPadding
+		if w > reportMaxCol {
+			w = reportMaxCol
+		}
+		if w < reportMinCol {
+			w = reportMinCol
+		}
+		widths[i] = w
+		total += w
+	}
+	if total > available {
+		for i := range widths {
+			widths[i] *= available / total
+		}
+	}
+	return widths
+}
+
+// wrapCell wraps text to maxWidth, breaking words that are too long on
+// their own, such as IDs.
+func wrapCell(f font, text string, maxWidth float64) []string {
+	var lines []string
+	for _, line := range wrap(f, reportFontSize, text, maxWidth) {
+		runes := []rune(line)
+		for f.width(string(runes), reportFontSize) > maxWidth && len(runes) > 1 {
+			cut := len(runes) - 1
+			for cut > 1 && f.width(string(runes[:cut]), reportFontSize) > maxWidth {
+				cut--
+			}
+			lines = append(lines, string(runes[:cut]))
+			runes = runes[cut:]
+		}
+		lines = append(lines, string(runes))
+	}
+	return lines
+}
@@ -0,0 +1,61 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"training-portal/internal/domain/compliance"
+)
+
+func TestReportRenderer_Render(t *testing.T) {
+	table := &compliance.Table{
+		Title:    "Overdue mandatory training",
+		Subtitle: "Generated 2024-03-04T09:00:00Z, department Ops",
+		Columns:  []string{"Name", "Course"},
+		Footer:   "Snapshot 1 taken 2024-03-04T09:00:00Z - SHA-256 abc",
+	}
+	for i := 0; i < 120; i++ {
+		table.Rows = append(table.Rows, []string{fmt.Sprintf("Learner %d", i), "Safety"})
+	}
+	out, err := ReportRenderer{}.Render(table)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	s := string(out)
+	if !strings.HasPrefix(s, "%PDF-1.4") || !strings.HasSuffix(s, "%%EOF\n") {
+		t.Error("Render() output is not a complete PDF file")
+	}
+	pages := strings.Count(s, "/Type /Page ")
+	if pages < 2 {
+		t.Fatalf("Render() of 120 rows produced %d pages, want several", pages)
+	}
+	if n := strings.Count(s, "(Name)"); n != pages {
+		t.Errorf("column headings appear %d times, want once on each of %d pages", n, pages)
+	}
+	for _, want := range []string{"/MediaBox [0 0 842 595]", "(Overdue mandatory training)", "(Learner 119)",
+		fmt.Sprintf("(Snapshot 1 taken 2024-03-04T09:00:00Z - SHA-256 abc - Page %d of %d)", pages, pages)} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Render() output missing %q", want)
+		}
+	}
+}
+
+func TestWrapCell(t *testing.T) {
+	id := "3f2b8c1e-7a4d-4e6b-9c0f-1d2e3f4a5b6c"
+	lines := wrapCell(regular, id, 40)
+	if len(lines) < 2 {
+		t.Fatalf("wrapCell() of a long ID = %q, want it broken over several lines", lines)
+	}
+	if joined := strings.Join(lines, ""); joined != id {
+		t.Errorf("wrapCell() lost text: %q", joined)
+	}
+	for _, line := range lines {
+		if w := regular.width(line, reportFontSize); w > 40 {
+			t.Errorf("line %q is %.1f wide, want at most 40", line, w)
+		}
+	}
+	if lines := wrapCell(regular, "Zoë", 40); len(lines) != 1 || lines[0] != "Zoë" {
+		t.Errorf("wrapCell() of a short name = %q", lines)
+	}
+}
@@ -0,0 +1,115 @@
+// Package pdf renders certificates and report tables as PDF documents using
+// only the standard library. It supports the base-14 Helvetica fonts and
+// embedded PNG or JPEG images, which is all these layouts need.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // register decoders for embedded images
+	_ "image/png"
+	"strings"
+)
+
+// writer assembles PDF objects and the cross-reference table.
+type writer struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+	next    int
+}
+
+func newWriter() *writer {
+	w := &writer{offsets: make(map[int]int), next: 1}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	return w
+}
+
+// reserve allocates an object number to be written later.
+func (w *writer) reserve() int {
+	id := w.next
+	w.next++
+	return id
+}
+
+// object writes a dictionary or other object body under id.
+func (w *writer) object(id int, body string) {
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream writes a stream object; dict holds extra entries besides /Length.
+func (w *writer) stream(id int, dict string, data []byte) {
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// finish writes the cross-reference table and trailer and returns the file.
+func (w *writer) finish(root int) []byte {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", w.next)
+	for id := 1; id < w.next; id++ {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", w.offsets[id])
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", w.next, root, xref)
+	return w.buf.Bytes()
+}
+
+// embeddedImage is an image written as an XObject.
+type embeddedImage struct {
+	id            int
+	width, height int
+}
+
+// image decodes a PNG or JPEG, flattens it onto white and writes it as a
+// Flate-compressed RGB image XObject.
+func (w *writer) image(data []byte) (*embeddedImage, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	b := src.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(canvas, canvas.Bounds(), src, b.Min, draw.Over)
+
+	var raw bytes.Buffer
+	zw := zlib.NewWriter(&raw)
+	for i := 0; i < len(canvas.Pix); i += 4 {
+		zw.Write(canvas.Pix[i : i+3])
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	img := &embeddedImage{id: w.reserve(), width: b.Dx(), height: b.Dy()}
+	w.stream(img.id, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode", img.width, img.height), raw.Bytes())
+	return img, nil
+}
+
+// encodeText converts s to WinAnsi (Latin-1 for the characters we accept) and
+// escapes it as a PDF literal string. Characters outside Latin-1 become '?'.
+func encodeText(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 256:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
@@ -0,0 +1,248 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"training-portal/internal/domain/analytics"
+)
+
+// AnalyticsRepository implements the analytics event store and its
+// aggregates using PostgreSQL.
+type AnalyticsRepository struct {
+	DB *sql.DB
+}
+
+func NewAnalyticsRepository(db *sql.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{DB: db}
+}
+
+// aggregationDelay keeps the aggregation job away from the newest events: a
+// seq is taken when an insert starts, so an event can commit after others
+// with a later seq. Waiting for inserts to finish keeps the cursor from
+// passing it.
+const aggregationDelay = time.Minute
+
+// Append inserts the events in one transaction, skipping IDs already stored.
+func (r *AnalyticsRepository) Append(events []*analytics.AnalyticsEvent) (int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO analytics_events (id, user_id, event_type, course_id, metadata, occurred_at)
+		 VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (id) DO NOTHING`,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	stored := 0
+	for _, e := range events {
+		metadata, err := json.Marshal(e.Metadata)
+		if err != nil {
+			return 0, err
+		}
+		if e.Metadata == nil {
+			metadata = []byte("{}")
+		}
+		res, err := stmt.Exec(e.ID, e.UserID, e.EventType, nullString(e.CourseID()), metadata, time.Unix(e.Timestamp, 0))
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		stored += int(n)
+	}
+	return stored, tx.Commit()
+}
+
+func (r *AnalyticsRepository) ListEvents(filter analytics.EventFilter) ([]*analytics.AnalyticsEvent, error) {
+	where := `TRUE`
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where += ` AND ` + cond + ` $` + strconv.Itoa(len(args))
+	}
+	if filter.UserID != "" {
+		add(`user_id::text =`, filter.UserID)
+	}
+	if filter.CourseID != "" {
+		add(`course_id::text =`, filter.CourseID)
+	}
+	if filter.EventType != "" {
+		add(`event_type =`, filter.EventType)
+	}
+	if filter.From != 0 {
+		add(`occurred_at >=`, time.Unix(filter.From, 0))
+	}
+	if filter.To != 0 {
+		add(`occurred_at <`, time.Unix(filter.To, 0))
+	}
+	args = append(args, filter.Limit)
+
+	rows, err := r.DB.Query(
+		`SELECT id, user_id, event_type, metadata, occurred_at FROM analytics_events
+		 WHERE `+where+` ORDER BY occurred_at DESC, seq DESC LIMIT $`+strconv.Itoa(len(args)),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*analytics.AnalyticsEvent
+	for rows.Next() {
+		var e analytics.AnalyticsEvent
+		var metadata []byte
+		var occurredAt time.Time
+		if err := rows.Scan(&e.ID, &e.UserID, &e.EventType, &metadata, &occurredAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(metadata, &e.Metadata); err != nil {
+			return nil, err
+		}
+		e.Timestamp = occurredAt.Unix()
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// Aggregate recomputes, in one transaction, the engagement of each user and
+// course with events stored since the last run, the module totals of all
+// engagement rows, and the analytics of every course.
+func (r *AnalyticsRepository) Aggregate(now int64) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var last, upTo int64
+	if err := tx.QueryRow(`SELECT last_seq FROM analytics_aggregation FOR UPDATE`).Scan(&last); err != nil {
+		return err
+	}
+	if err := tx.QueryRow(
+		`SELECT COALESCE(MAX(seq), $1) FROM analytics_events WHERE seq > $1 AND received_at < $2`,
+		last, time.Unix(now, 0).Add(-aggregationDelay),
+	).Scan(&upTo); err != nil {
+		return err
+	}
+	at := time.Unix(now, 0)
+
+	if upTo > last {
+		if _, err := tx.Exec(
+			`INSERT INTO user_engagement (user_id, course_id, modules_completed, total_modules, last_active, updated_at)
+			 SELECT t.user_id, t.course_id,
+			        (SELECT COUNT(DISTINCT m.id) FROM analytics_events e
+			         JOIN modules m ON m.id::text = e.metadata->>'module_id' AND m.course_id = t.course_id
+			         WHERE e.user_id = t.user_id AND e.course_id = t.course_id AND e.event_type = $3),
+			        (SELECT COUNT(*) FROM modules m WHERE m.course_id = t.course_id),
+			        (SELECT MAX(e.occurred_at) FROM analytics_events e WHERE e.user_id = t.user_id AND e.course_id = t.course_id),
+			        $4
+			 FROM (SELECT DISTINCT user_id, course_id FROM analytics_events
+			       WHERE seq > $1 AND seq <= $2 AND course_id IS NOT NULL) t
+			 JOIN courses c ON c.id = t.course_id
+			 ON CONFLICT (user_id, course_id) DO UPDATE SET
+			     modules_completed = EXCLUDED.modules_completed,
+			     total_modules = EXCLUDED.total_modules,
+			     last_active = EXCLUDED.last_active,
+			     updated_at = EXCLUDED.updated_at`,
+			last, upTo, analytics.EventModuleCompleted, at,
+		); err != nil {
+			return err
+		}
+	}
+
+	// Modules are added and removed without new events.
+	if _, err := tx.Exec(
+		`UPDATE user_engagement ue SET total_modules = t.n, updated_at = $1
+		 FROM (SELECT c.id AS course_id, COUNT(m.id) AS n FROM courses c
+		       LEFT JOIN modules m ON m.course_id = c.id GROUP BY c.id) t
+		 WHERE ue.course_id = t.course_id AND ue.total_modules <> t.n`,
+		at,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO course_analytics (course_id, enrollments, completions, average_progress, updated_at)
+		 SELECT c.id,
+		        COUNT(e.id) FILTER (WHERE e.status IN ('active', 'completed')),
+		        COUNT(e.id) FILTER (WHERE e.status = 'completed'),
+		        COALESCE(AVG(CASE
+		            WHEN e.status = 'completed' THEN 1.0
+		            WHEN ue.total_modules > 0 THEN LEAST(ue.modules_completed::float / ue.total_modules, 1.0)
+		            ELSE 0.0
+		        END) FILTER (WHERE e.status IN ('active', 'completed')), 0),
+		        $1
+		 FROM courses c
+		 LEFT JOIN enrollments e ON e.course_id = c.id
+		 LEFT JOIN user_engagement ue ON ue.user_id = e.user_id AND ue.course_id = c.id
+		 GROUP BY c.id
+		 ON CONFLICT (course_id) DO UPDATE SET
+		     enrollments = EXCLUDED.enrollments,
+		     completions = EXCLUDED.completions,
+		     average_progress = EXCLUDED.average_progress,
+		     updated_at = EXCLUDED.updated_at`,
+		at,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE analytics_aggregation SET last_seq = $1`, upTo); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *AnalyticsRepository) ListEngagement(userID, courseID string) ([]*analytics.UserEngagement, error) {
+	rows, err := r.DB.Query(
+		`SELECT user_id, course_id, modules_completed, total_modules, last_active FROM user_engagement
+		 WHERE ($1 = '' OR user_id::text = $1) AND ($2 = '' OR course_id::text = $2)
+		 ORDER BY last_active DESC NULLS LAST, user_id, course_id`,
+		userID, courseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*analytics.UserEngagement
+	for rows.Next() {
+		var ue analytics.UserEngagement
+		var lastActive sql.NullTime
+		if err := rows.Scan(&ue.UserID, &ue.CourseID, &ue.ModulesCompleted, &ue.TotalModules, &lastActive); err != nil {
+			return nil, err
+		}
+		ue.LastActive = unixOrZero(lastActive)
+		out = append(out, &ue)
+	}
+	return out, rows.Err()
+}
+
+func (r *AnalyticsRepository) FindCourseAnalytics(courseID string) (*analytics.CourseAnalytics, error) {
+	var ca analytics.CourseAnalytics
+	var updatedAt time.Time
+	err := r.DB.QueryRow(
+		`SELECT course_id, enrollments, completions, average_progress, updated_at FROM course_analytics
+		 WHERE course_id::text = $1`,
+		courseID,
+	).Scan(&ca.CourseID, &ca.Enrollments, &ca.Completions, &ca.AverageProgress, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ca.UpdatedAt = updatedAt.Unix()
+	return &ca, nil
+}
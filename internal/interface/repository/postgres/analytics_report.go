@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"training-portal/internal/domain/analytics"
+
+	"github.com/lib/pq"
+)
+
+// ListLearnerProgress merges each matching enrollment with its progress
+// record and the learner's events in the course. Dropped enrollments are
+// included if they were ever active; pending, invited, waitlisted and
+// rejected ones are not.
+func (r *AnalyticsRepository) ListLearnerProgress(filter analytics.ReportFilter) ([]*analytics.LearnerProgress, error) {
+	rows, err := r.DB.Query(
+		`WITH enrolled AS (
+		     SELECT e.id, e.user_id, e.course_id, e.status, e.enrolled_at,
+		            (SELECT MAX(h.changed_at) FROM enrollment_history h WHERE h.enrollment_id = e.id AND h.to_status = 'active') AS activated_at,
+		            (SELECT MAX(h.changed_at) FROM enrollment_history h WHERE h.enrollment_id = e.id AND h.to_status = 'completed') AS completed_at
+		     FROM enrollments e
+		     JOIN users u ON u.id = e.user_id
+		     WHERE (e.status IN ('active', 'completed')
+		            OR (e.status = 'dropped' AND EXISTS (
+		                SELECT 1 FROM enrollment_history h WHERE h.enrollment_id = e.id AND h.to_status = 'active')))
+		       AND ($1 = '' OR e.course_id::text = $1)
+		       AND ($2 = '' OR u.department = $2)
+		       AND ($3 = '' OR u.role = $3)
+		       AND ($4::timestamp IS NULL OR e.enrolled_at >= $4)
+		       AND ($5::timestamp IS NULL OR e.enrolled_at < $5)
+		 )
+		 SELECT en.user_id, en.course_id, en.status, en.enrolled_at, en.activated_at,
+		        CASE WHEN en.status = 'completed' THEN COALESCE(en.completed_at, en.enrolled_at) END,
+		        ev.first_at, ev.last_at,
+		        ARRAY(
+		            SELECT DISTINCT m.id::text FROM modules m
+		            WHERE m.course_id = en.course_id AND (
+		                m.id IN (SELECT unnest(p.completed_modules) FROM progress p
+		                         WHERE p.user_id = en.user_id AND p.course_id = en.course_id)
+		                OR m.id::text IN (SELECT a.metadata->>'module_id' FROM analytics_events a
+		                                  WHERE a.user_id = en.user_id AND a.course_id = en.course_id
+		                                    AND a.event_type = 'module_completed'))
+		        ),
+		        EXISTS (SELECT 1 FROM analytics_events a
+		                WHERE a.user_id = en.user_id AND a.course_id = en.course_id
+		                  AND a.event_type = 'quiz_submitted' AND a.metadata->'passed' = 'true'::jsonb)
+		        OR EXISTS (SELECT 1 FROM progress p
+		                   WHERE p.user_id = en.user_id AND p.course_id = en.course_id
+		                     AND p.completed_quizzes IS NOT NULL AND p.completed_quizzes <> '{}'::jsonb)
+		 FROM enrolled en
+		 LEFT JOIN LATERAL (
+		     SELECT MIN(a.occurred_at) AS first_at, MAX(a.occurred_at) AS last_at FROM analytics_events a
+		     WHERE a.user_id = en.user_id AND a.course_id = en.course_id AND a.occurred_at >= en.enrolled_at
+		 ) ev ON TRUE
+		 ORDER BY en.enrolled_at`,
+		filter.CourseID, filter.Department, filter.Role, nullTime(filter.From), nullTime(filter.To),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*analytics.LearnerProgress
+	for rows.Next() {
+		var l analytics.LearnerProgress
+		var enrolledAt, activatedAt, completedAt, firstAt, lastAt sql.NullTime
+		if err := rows.Scan(&l.UserID, &l.CourseID, &l.Status, &enrolledAt, &activatedAt, &completedAt,
+			&firstAt, &lastAt, pq.Array(&l.CompletedModules), &l.PassedQuiz); err != nil {
+			return nil, err
+		}
+		l.EnrolledAt = unixOrZero(enrolledAt)
+		l.ActivatedAt = unixOrZero(activatedAt)
+		l.CompletedAt = unixOrZero(completedAt)
+		l.FirstActiveAt = unixOrZero(firstAt)
+		l.LastActiveAt = unixOrZero(lastAt)
+		out = append(out, &l)
+	}
+	return out, rows.Err()
+}
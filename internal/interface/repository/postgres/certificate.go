@@ -0,0 +1,197 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/certificate"
+)
+
+// CertificateRepository implements issued certificate data access using PostgreSQL.
+type CertificateRepository struct {
+	DB *sql.DB
+}
+
+func NewCertificateRepository(db *sql.DB) *CertificateRepository {
+	return &CertificateRepository{DB: db}
+}
+
+const certificateColumns = `id, user_id, course_id, COALESCE(template_id::text, ''), credential_id, score, issued_at, file_key, COALESCE(certificate_url, ''), COALESCE(signature, ''),
+	expires_at, status, revoked_at, COALESCE(revocation_reason, ''), COALESCE(replaces_id::text, ''), COALESCE(replaced_by_id::text, '')`
+
+func scanCertificate(row interface{ Scan(...interface{}) error }) (*certificate.Certificate, error) {
+	var c certificate.Certificate
+	var issuedAt time.Time
+	var expiresAt, revokedAt sql.NullTime
+	if err := row.Scan(
+		&c.ID, &c.UserID, &c.CourseID, &c.TemplateID, &c.CredentialID, &c.Score, &issuedAt, &c.FileKey, &c.DownloadURL, &c.Signature,
+		&expiresAt, &c.Status, &revokedAt, &c.RevocationReason, &c.ReplacesID, &c.ReplacedByID,
+	); err != nil {
+		return nil, err
+	}
+	c.IssuedAt = issuedAt.Unix()
+	c.ExpiresAt = unixOrZero(expiresAt)
+	c.RevokedAt = unixOrZero(revokedAt)
+	return &c, nil
+}
+
+// Create inserts a new certificate together with its initial history entry.
+func (r *CertificateRepository) Create(c *certificate.Certificate, change *certificate.StatusChange) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertCertificate(tx, c); err != nil {
+		return err
+	}
+	if err := insertCertificateChange(tx, change); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateStatus persists a status change and records it in the history.
+func (r *CertificateRepository) UpdateStatus(c *certificate.Certificate, change *certificate.StatusChange) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateCertificateStatus(tx, c, change); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Reissue inserts the replacement and supersedes the original in one transaction.
+func (r *CertificateRepository) Reissue(old, replacement *certificate.Certificate, superseded, issued *certificate.StatusChange) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertCertificate(tx, replacement); err != nil {
+		return err
+	}
+	if err := insertCertificateChange(tx, issued); err != nil {
+		return err
+	}
+	if err := updateCertificateStatus(tx, old, superseded); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *CertificateRepository) FindByID(id string) (*certificate.Certificate, error) {
+	return r.findOne(`SELECT `+certificateColumns+` FROM certificates WHERE id = $1`, id)
+}
+
+func (r *CertificateRepository) FindByCredentialID(credentialID string) (*certificate.Certificate, error) {
+	return r.findOne(`SELECT `+certificateColumns+` FROM certificates WHERE credential_id = $1`, credentialID)
+}
+
+func (r *CertificateRepository) findOne(query, arg string) (*certificate.Certificate, error) {
+	c, err := scanCertificate(r.DB.QueryRow(query, arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *CertificateRepository) ListByUser(userID string) ([]*certificate.Certificate, error) {
+	return r.list(`SELECT `+certificateColumns+` FROM certificates WHERE user_id = $1 ORDER BY issued_at DESC`, userID)
+}
+
+// ListActiveByTemplate returns the active certificates rendered from a template,
+// including expired ones.
+func (r *CertificateRepository) ListActiveByTemplate(templateID string) ([]*certificate.Certificate, error) {
+	return r.list(`SELECT `+certificateColumns+` FROM certificates WHERE template_id = $1 AND status = 'active' ORDER BY issued_at ASC`, templateID)
+}
+
+func (r *CertificateRepository) list(query, arg string) ([]*certificate.Certificate, error) {
+	rows, err := r.DB.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var certificates []*certificate.Certificate
+	for rows.Next() {
+		c, err := scanCertificate(rows)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, c)
+	}
+	return certificates, rows.Err()
+}
+
+func (r *CertificateRepository) ListHistory(certificateID string) ([]*certificate.StatusChange, error) {
+	rows, err := r.DB.Query(
+		`SELECT id, certificate_id, COALESCE(from_status, ''), to_status, COALESCE(changed_by::text, ''), COALESCE(reason, ''), changed_at
+		 FROM certificate_history WHERE certificate_id = $1 ORDER BY changed_at ASC`,
+		certificateID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*certificate.StatusChange
+	for rows.Next() {
+		var sc certificate.StatusChange
+		var changedAt time.Time
+		if err := rows.Scan(&sc.ID, &sc.CertificateID, &sc.FromStatus, &sc.ToStatus, &sc.ChangedBy, &sc.Reason, &changedAt); err != nil {
+			return nil, err
+		}
+		sc.ChangedAt = changedAt.Unix()
+		history = append(history, &sc)
+	}
+	return history, rows.Err()
+}
+
+func insertCertificate(tx *sql.Tx, c *certificate.Certificate) error {
+	_, err := tx.Exec(
+		`INSERT INTO certificates (id, user_id, course_id, template_id, credential_id, score, issued_at, file_key, certificate_url, signature, expires_at, status, replaces_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		c.ID, c.UserID, c.CourseID, nullString(c.TemplateID), c.CredentialID, c.Score, time.Unix(c.IssuedAt, 0), c.FileKey, c.DownloadURL, c.Signature,
+		nullTime(c.ExpiresAt), c.Status, nullString(c.ReplacesID),
+	)
+	return err
+}
+
+// updateCertificateStatus moves the certificate from change.FromStatus to its
+// new status and records the change.
+func updateCertificateStatus(tx *sql.Tx, c *certificate.Certificate, change *certificate.StatusChange) error {
+	res, err := tx.Exec(
+		`UPDATE certificates SET status = $1, revoked_at = $2, revocation_reason = $3, replaced_by_id = $4 WHERE id = $5 AND status = $6`,
+		c.Status, nullTime(c.RevokedAt), nullString(c.RevocationReason), nullString(c.ReplacedByID), c.ID, change.FromStatus,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return insertCertificateChange(tx, change)
+}
+
+func insertCertificateChange(tx *sql.Tx, sc *certificate.StatusChange) error {
+	_, err := tx.Exec(
+		`INSERT INTO certificate_history (id, certificate_id, from_status, to_status, changed_by, reason, changed_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		sc.ID, sc.CertificateID, nullString(string(sc.FromStatus)), sc.ToStatus, nullString(sc.ChangedBy), nullString(sc.Reason), time.Unix(sc.ChangedAt, 0),
+	)
+	return err
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/certificate"
+)
+
+// CertificateTemplateRepository implements certificate template data access using PostgreSQL.
+type CertificateTemplateRepository struct {
+	DB *sql.DB
+}
+
+func NewCertificateTemplateRepository(db *sql.DB) *CertificateTemplateRepository {
+	return &CertificateTemplateRepository{DB: db}
+}
+
+const certificateTemplateColumns = `id, name, COALESCE(course_id::text, ''), is_default, orientation, title, body, signatory_name, COALESCE(logo_key, ''), COALESCE(signature_key, ''), created_at`
+
+func scanCertificateTemplate(row interface{ Scan(...interface{}) error }) (*certificate.Template, error) {
+	var t certificate.Template
+	var createdAt time.Time
+	if err := row.Scan(&t.ID, &t.Name, &t.CourseID, &t.IsDefault, &t.Orientation, &t.Title, &t.Body, &t.SignatoryName, &t.LogoKey, &t.SignatureKey, &createdAt); err != nil {
+		return nil, err
+	}
+	t.CreatedAt = createdAt.Unix()
+	return &t, nil
+}
+
+func (r *CertificateTemplateRepository) Create(t *certificate.Template) error {
+	return r.save(t,
+		`INSERT INTO certificate_templates (id, name, course_id, is_default, orientation, title, body, signatory_name, logo_key, signature_key, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		t.ID, t.Name, nullString(t.CourseID), t.IsDefault, t.Orientation, t.Title, t.Body, t.SignatoryName,
+		nullString(t.LogoKey), nullString(t.SignatureKey), time.Unix(t.CreatedAt, 0),
+	)
+}
+
+func (r *CertificateTemplateRepository) Update(t *certificate.Template) error {
+	return r.save(t,
+		`UPDATE certificate_templates SET name = $1, course_id = $2, is_default = $3, orientation = $4, title = $5, body = $6,
+		 signatory_name = $7, logo_key = $8, signature_key = $9 WHERE id = $10`,
+		t.Name, nullString(t.CourseID), t.IsDefault, t.Orientation, t.Title, t.Body, t.SignatoryName,
+		nullString(t.LogoKey), nullString(t.SignatureKey), t.ID,
+	)
+}
+
+// save runs query and, for a default template, clears the flag on every
+// other template in the same transaction.
+func (r *CertificateTemplateRepository) save(t *certificate.Template, query string, args ...interface{}) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if t.IsDefault {
+		if _, err := tx.Exec(`UPDATE certificate_templates SET is_default = FALSE WHERE is_default AND id <> $1`, t.ID); err != nil {
+			return err
+		}
+	}
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+func (r *CertificateTemplateRepository) Delete(id string) error {
+	res, err := r.DB.Exec(`DELETE FROM certificate_templates WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *CertificateTemplateRepository) FindByID(id string) (*certificate.Template, error) {
+	return r.findOne(`SELECT `+certificateTemplateColumns+` FROM certificate_templates WHERE id = $1`, id)
+}
+
+func (r *CertificateTemplateRepository) FindForCourse(courseID string) (*certificate.Template, error) {
+	return r.findOne(
+		`SELECT `+certificateTemplateColumns+` FROM certificate_templates
+		 WHERE course_id::text = $1 OR (course_id IS NULL AND is_default)
+		 ORDER BY course_id IS NULL, created_at DESC LIMIT 1`,
+		courseID,
+	)
+}
+
+func (r *CertificateTemplateRepository) findOne(query string, arg string) (*certificate.Template, error) {
+	t, err := scanCertificateTemplate(r.DB.QueryRow(query, arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *CertificateTemplateRepository) List() ([]*certificate.Template, error) {
+	rows, err := r.DB.Query(`SELECT ` + certificateTemplateColumns + ` FROM certificate_templates ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*certificate.Template
+	for rows.Next() {
+		t, err := scanCertificateTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
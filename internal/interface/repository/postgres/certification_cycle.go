@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/enrollment"
+)
+
+// CertificationCycleRepository implements recertification cycle data access using PostgreSQL.
+type CertificationCycleRepository struct {
+	DB *sql.DB
+}
+
+func NewCertificationCycleRepository(db *sql.DB) *CertificationCycleRepository {
+	return &CertificationCycleRepository{DB: db}
+}
+
+const cycleColumns = `id, user_id, course_id, enrollment_id, cycle_number, completed_at, expires_at, renewal_started_at, reminders_sent, renewed_at`
+
+func scanCycle(row interface{ Scan(...interface{}) error }) (*enrollment.Cycle, error) {
+	var c enrollment.Cycle
+	var completedAt, expiresAt time.Time
+	var renewalStartedAt, renewedAt sql.NullTime
+	if err := row.Scan(&c.ID, &c.UserID, &c.CourseID, &c.EnrollmentID, &c.Number, &completedAt, &expiresAt, &renewalStartedAt, &c.RemindersSent, &renewedAt); err != nil {
+		return nil, err
+	}
+	c.CompletedAt = completedAt.Unix()
+	c.ExpiresAt = expiresAt.Unix()
+	c.RenewalStartedAt = unixOrZero(renewalStartedAt)
+	c.RenewedAt = unixOrZero(renewedAt)
+	return &c, nil
+}
+
+func scanCycles(rows *sql.Rows) ([]*enrollment.Cycle, error) {
+	defer rows.Close()
+	var cycles []*enrollment.Cycle
+	for rows.Next() {
+		c, err := scanCycle(rows)
+		if err != nil {
+			return nil, err
+		}
+		cycles = append(cycles, c)
+	}
+	return cycles, rows.Err()
+}
+
+// Create closes the user's current cycle for the course, if any, and inserts
+// the new one in a single transaction.
+func (r *CertificationCycleRepository) Create(c *enrollment.Cycle) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE certification_cycles SET renewed_at = $1 WHERE user_id = $2 AND course_id = $3 AND renewed_at IS NULL`,
+		time.Unix(c.CompletedAt, 0), c.UserID, c.CourseID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO certification_cycles (`+cycleColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		c.ID, c.UserID, c.CourseID, c.EnrollmentID, c.Number, time.Unix(c.CompletedAt, 0), time.Unix(c.ExpiresAt, 0),
+		nullTime(c.RenewalStartedAt), c.RemindersSent, nullTime(c.RenewedAt),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update saves the renewal and reminder progress of a cycle.
+func (r *CertificationCycleRepository) Update(c *enrollment.Cycle) error {
+	res, err := r.DB.Exec(
+		`UPDATE certification_cycles SET renewal_started_at = $1, reminders_sent = $2 WHERE id = $3`,
+		nullTime(c.RenewalStartedAt), c.RemindersSent, c.ID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *CertificationCycleRepository) FindCurrent(userID, courseID string) (*enrollment.Cycle, error) {
+	c, err := scanCycle(r.DB.QueryRow(
+		`SELECT `+cycleColumns+` FROM certification_cycles WHERE user_id = $1 AND course_id = $2 AND renewed_at IS NULL`,
+		userID, courseID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *CertificationCycleRepository) ListCurrent(userID, courseID string) ([]*enrollment.Cycle, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+cycleColumns+` FROM certification_cycles
+		 WHERE renewed_at IS NULL AND ($1 = '' OR user_id::text = $1) AND ($2 = '' OR course_id::text = $2)
+		 ORDER BY expires_at`,
+		userID, courseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanCycles(rows)
+}
+
+func (r *CertificationCycleRepository) ListHistory(userID, courseID string) ([]*enrollment.Cycle, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+cycleColumns+` FROM certification_cycles WHERE user_id = $1 AND course_id = $2 ORDER BY cycle_number`,
+		userID, courseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanCycles(rows)
+}
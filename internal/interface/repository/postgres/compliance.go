@@ -0,0 +1,313 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/compliance"
+)
+
+// ComplianceRepository implements compliance reports and snapshots using
+// PostgreSQL.
+type ComplianceRepository struct {
+	DB *sql.DB
+}
+
+func NewComplianceRepository(db *sql.DB) *ComplianceRepository {
+	return &ComplianceRepository{DB: db}
+}
+
+// mandatoryEnrollments selects the enrollments that count towards mandatory
+// training completion, filtered by department ($1) and course ($2).
+const mandatoryEnrollments = `FROM enrollments e
+	 JOIN users u ON u.id = e.user_id
+	 JOIN courses c ON c.id = e.course_id
+	 WHERE e.due_at IS NOT NULL AND e.status IN ('active', 'completed')
+	   AND ($1 = '' OR u.department = $1) AND ($2 = '' OR c.id::text = $2)`
+
+const completionCounts = `COUNT(*),
+	 COUNT(*) FILTER (WHERE e.status = 'completed'),
+	 COUNT(*) FILTER (WHERE e.status = 'active' AND e.overdue_at IS NOT NULL)`
+
+func (r *ComplianceRepository) CourseCompletion(filter compliance.Filter) ([]*compliance.CourseCompletion, error) {
+	rows, err := r.DB.Query(
+		`SELECT c.id, COALESCE(c.title, ''), `+completionCounts+` `+mandatoryEnrollments+`
+		 GROUP BY c.id, c.title ORDER BY c.title, c.id`,
+		filter.Department, filter.CourseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*compliance.CourseCompletion
+	for rows.Next() {
+		var c compliance.CourseCompletion
+		if err := rows.Scan(&c.CourseID, &c.CourseTitle, &c.Assigned, &c.Completed, &c.Overdue); err != nil {
+			return nil, err
+		}
+		out = append(out, &c)
+	}
+	return out, rows.Err()
+}
+
+func (r *ComplianceRepository) DepartmentCompletion(filter compliance.Filter) ([]*compliance.DepartmentCompletion, error) {
+	rows, err := r.DB.Query(
+		`SELECT COALESCE(u.department, ''), `+completionCounts+` `+mandatoryEnrollments+`
+		 GROUP BY COALESCE(u.department, '') ORDER BY 1`,
+		filter.Department, filter.CourseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*compliance.DepartmentCompletion
+	for rows.Next() {
+		var d compliance.DepartmentCompletion
+		if err := rows.Scan(&d.Department, &d.Assigned, &d.Completed, &d.Overdue); err != nil {
+			return nil, err
+		}
+		out = append(out, &d)
+	}
+	return out, rows.Err()
+}
+
+func (r *ComplianceRepository) ListExpiring(filter compliance.Filter, until int64) ([]*compliance.ExpiringCertification, error) {
+	rows, err := r.DB.Query(
+		`SELECT ce.id, ce.credential_id, u.id, COALESCE(u.name, ''), u.email, COALESCE(u.department, ''),
+		        c.id, COALESCE(c.title, ''), ce.issued_at, ce.expires_at
+		 FROM certificates ce
+		 JOIN users u ON u.id = ce.user_id
+		 JOIN courses c ON c.id = ce.course_id
+		 WHERE ce.status = 'active' AND ce.expires_at IS NOT NULL AND ce.expires_at < $3
+		   AND ($1 = '' OR u.department = $1) AND ($2 = '' OR c.id::text = $2)
+		 ORDER BY ce.expires_at, u.email`,
+		filter.Department, filter.CourseID, time.Unix(until, 0),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*compliance.ExpiringCertification
+	for rows.Next() {
+		var e compliance.ExpiringCertification
+		var issuedAt sql.NullTime
+		var expiresAt time.Time
+		if err := rows.Scan(&e.CertificateID, &e.CredentialID, &e.UserID, &e.UserName, &e.UserEmail, &e.Department,
+			&e.CourseID, &e.CourseTitle, &issuedAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		e.IssuedAt = unixOrZero(issuedAt)
+		e.ExpiresAt = expiresAt.Unix()
+		out = append(out, &e)
+	}
+	return out, rows.Err()
+}
+
+// Transcript returns every enrollment of the user with its latest
+// completion, current certificate and quiz scores from the progress record
+// and quiz_submitted events.
+func (r *ComplianceRepository) Transcript(userID string) (*compliance.Transcript, error) {
+	t := &compliance.Transcript{Entries: []compliance.TranscriptEntry{}}
+	err := r.DB.QueryRow(
+		`SELECT id, COALESCE(name, ''), email, COALESCE(department, '') FROM users WHERE id::text = $1`,
+		userID,
+	).Scan(&t.UserID, &t.UserName, &t.UserEmail, &t.Department)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(
+		`SELECT c.id, COALESCE(c.title, ''), e.status, e.enrolled_at, e.due_at,
+		        (SELECT MAX(h.changed_at) FROM enrollment_history h WHERE h.enrollment_id = e.id AND h.to_status = 'completed'),
+		        COALESCE(ce.score, 0), COALESCE(ce.credential_id, ''), ce.issued_at, ce.expires_at
+		 FROM enrollments e
+		 JOIN courses c ON c.id = e.course_id
+		 LEFT JOIN LATERAL (
+		     SELECT score, credential_id, issued_at, expires_at FROM certificates
+		     WHERE user_id = e.user_id AND course_id = e.course_id AND status = 'active'
+		     ORDER BY issued_at DESC LIMIT 1
+		 ) ce ON TRUE
+		 WHERE e.user_id = $1
+		 ORDER BY e.enrolled_at, c.title`,
+		t.UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	index := map[string]int{} // course ID to entry
+	for rows.Next() {
+		var e compliance.TranscriptEntry
+		var enrolledAt, dueAt, completedAt, certifiedAt, expiresAt sql.NullTime
+		if err := rows.Scan(&e.CourseID, &e.CourseTitle, &e.Status, &enrolledAt, &dueAt, &completedAt,
+			&e.Score, &e.CredentialID, &certifiedAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		e.EnrolledAt = unixOrZero(enrolledAt)
+		e.DueAt = unixOrZero(dueAt)
+		e.CompletedAt = unixOrZero(completedAt)
+		e.CertifiedAt = unixOrZero(certifiedAt)
+		e.CertExpiresAt = unixOrZero(expiresAt)
+		index[e.CourseID] = len(t.Entries)
+		t.Entries = append(t.Entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	quizzes, err := r.DB.Query(
+		`SELECT course_id::text, quiz_id, score, submitted_at FROM (
+		     SELECT p.course_id, q.key AS quiz_id, (q.value #>> '{}')::float AS score, NULL::timestamp AS submitted_at
+		     FROM progress p
+		     CROSS JOIN LATERAL jsonb_each(CASE WHEN jsonb_typeof(p.completed_quizzes) = 'object'
+		                                        THEN p.completed_quizzes ELSE '{}'::jsonb END) q
+		     WHERE p.user_id = $1 AND jsonb_typeof(q.value) = 'number'
+		     UNION ALL
+		     SELECT a.course_id, a.metadata->>'quiz_id', (a.metadata->>'score')::float, a.occurred_at
+		     FROM analytics_events a
+		     WHERE a.user_id = $1 AND a.event_type = 'quiz_submitted' AND jsonb_typeof(a.metadata->'score') = 'number'
+		 ) results
+		 WHERE course_id IS NOT NULL
+		 ORDER BY submitted_at NULLS FIRST, quiz_id`,
+		t.UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer quizzes.Close()
+	for quizzes.Next() {
+		var courseID string
+		var q compliance.QuizResult
+		var submittedAt sql.NullTime
+		if err := quizzes.Scan(&courseID, &q.QuizID, &q.Score, &submittedAt); err != nil {
+			return nil, err
+		}
+		q.SubmittedAt = unixOrZero(submittedAt)
+		if i, ok := index[courseID]; ok {
+			t.Entries[i].Quizzes = append(t.Entries[i].Quizzes, q)
+		}
+	}
+	return t, quizzes.Err()
+}
+
+func (r *ComplianceRepository) TeamProgress(filter compliance.Filter) ([]*compliance.TeamProgress, error) {
+	rows, err := r.DB.Query(
+		`SELECT u.id, COALESCE(u.name, ''), u.email, COALESCE(u.department, ''), c.id, COALESCE(c.title, ''), e.status,
+		        (SELECT COUNT(*) FROM modules m WHERE m.course_id = c.id AND m.id = ANY(COALESCE(p.completed_modules, '{}'))),
+		        (SELECT COUNT(*) FROM modules m WHERE m.course_id = c.id),
+		        e.due_at,
+		        (SELECT MAX(h.changed_at) FROM enrollment_history h WHERE h.enrollment_id = e.id AND h.to_status = 'completed'),
+		        e.status = 'active' AND e.overdue_at IS NOT NULL
+		 FROM enrollments e
+		 JOIN users u ON u.id = e.user_id
+		 JOIN courses c ON c.id = e.course_id
+		 LEFT JOIN LATERAL (
+		     SELECT completed_modules FROM progress
+		     WHERE user_id = e.user_id AND course_id = e.course_id
+		     ORDER BY updated_at DESC LIMIT 1
+		 ) p ON TRUE
+		 WHERE u.manager_id::text = $1 AND e.status IN ('active', 'completed')
+		   AND ($2 = '' OR u.department = $2) AND ($3 = '' OR c.id::text = $3)
+		 ORDER BY u.name, u.email, c.title`,
+		filter.ManagerID, filter.Department, filter.CourseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*compliance.TeamProgress
+	for rows.Next() {
+		var p compliance.TeamProgress
+		var dueAt, completedAt sql.NullTime
+		if err := rows.Scan(&p.UserID, &p.UserName, &p.UserEmail, &p.Department, &p.CourseID, &p.CourseTitle, &p.Status,
+			&p.ModulesCompleted, &p.TotalModules, &dueAt, &completedAt, &p.Overdue); err != nil {
+			return nil, err
+		}
+		p.DueAt = unixOrZero(dueAt)
+		p.CompletedAt = unixOrZero(completedAt)
+		out = append(out, &p)
+	}
+	return out, rows.Err()
+}
+
+func (r *ComplianceRepository) CreateSnapshot(s *compliance.Snapshot) error {
+	filter, err := json.Marshal(s.Filter)
+	if err != nil {
+		return err
+	}
+	table, err := json.Marshal(s.Table)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Exec(
+		`INSERT INTO compliance_snapshots (id, kind, filter, note, data, report_table, hash, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		s.ID, s.Kind, filter, s.Note, string(s.Data), string(table), s.Hash, nullString(s.CreatedBy), time.Unix(s.CreatedAt, 0),
+	)
+	return err
+}
+
+func (r *ComplianceRepository) FindSnapshot(id string) (*compliance.Snapshot, error) {
+	var s compliance.Snapshot
+	var filter []byte
+	var data, table string
+	var createdAt time.Time
+	err := r.DB.QueryRow(
+		`SELECT id, kind, filter, note, data, report_table, hash, COALESCE(created_by::text, ''), created_at
+		 FROM compliance_snapshots WHERE id::text = $1`,
+		id,
+	).Scan(&s.ID, &s.Kind, &filter, &s.Note, &data, &table, &s.Hash, &s.CreatedBy, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(filter, &s.Filter); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(table), &s.Table); err != nil {
+		return nil, err
+	}
+	s.Data = json.RawMessage(data)
+	s.CreatedAt = createdAt.Unix()
+	return &s, nil
+}
+
+func (r *ComplianceRepository) ListSnapshots(kind compliance.ReportKind) ([]*compliance.Snapshot, error) {
+	rows, err := r.DB.Query(
+		`SELECT id, kind, filter, note, hash, COALESCE(created_by::text, ''), created_at
+		 FROM compliance_snapshots WHERE ($1 = '' OR kind = $1) ORDER BY created_at DESC`,
+		kind,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*compliance.Snapshot
+	for rows.Next() {
+		var s compliance.Snapshot
+		var filter []byte
+		var createdAt time.Time
+		if err := rows.Scan(&s.ID, &s.Kind, &filter, &s.Note, &s.Hash, &s.CreatedBy, &createdAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(filter, &s.Filter); err != nil {
+			return nil, err
+		}
+		s.CreatedAt = createdAt.Unix()
+		out = append(out, &s)
+	}
+	return out, rows.Err()
+}
@@ -17,9 +17,9 @@ func NewCourseRepository(db *sql.DB) *CourseRepository {
 func (r *CourseRepository) FindByID(id string) (*course.Course, error) {
 	var c course.Course
 	err := r.DB.QueryRow(
-		`SELECT id, title, description, category, created_by, is_published FROM courses WHERE id = $1`,
+		`SELECT id, title, description, category, created_by, is_published, enrollment_mode, seat_capacity, recertification_months FROM courses WHERE id = $1`,
 		id,
-	).Scan(&c.ID, &c.Title, &c.Description, &c.Category, &c.CreatedBy, &c.Published)
+	).Scan(&c.ID, &c.Title, &c.Description, &c.Category, &c.CreatedBy, &c.Published, &c.EnrollmentMode, &c.SeatCapacity, &c.RecertificationMonths)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -31,16 +31,16 @@ func (r *CourseRepository) FindByID(id string) (*course.Course, error) {
 
 func (r *CourseRepository) Create(c *course.Course) error {
 	_, err := r.DB.Exec(
-		`INSERT INTO courses (id, title, description, category, created_by, is_published) VALUES ($1, $2, $3, $4, $5, $6)`,
-		c.ID, c.Title, c.Description, c.Category, c.CreatedBy, c.Published,
+		`INSERT INTO courses (id, title, description, category, created_by, is_published, enrollment_mode, seat_capacity, recertification_months) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		c.ID, c.Title, c.Description, c.Category, c.CreatedBy, c.Published, c.EnrollmentMode, c.SeatCapacity, c.RecertificationMonths,
 	)
 	return err
 }
 
 func (r *CourseRepository) Update(c *course.Course) error {
 	res, err := r.DB.Exec(
-		`UPDATE courses SET title = $1, description = $2, category = $3, created_by = $4, is_published = $5 WHERE id = $6`,
-		c.Title, c.Description, c.Category, c.CreatedBy, c.Published, c.ID,
+		`UPDATE courses SET title = $1, description = $2, category = $3, created_by = $4, is_published = $5, enrollment_mode = $6, seat_capacity = $7, recertification_months = $8 WHERE id = $9`,
+		c.Title, c.Description, c.Category, c.CreatedBy, c.Published, c.EnrollmentMode, c.SeatCapacity, c.RecertificationMonths, c.ID,
 	)
 	if err != nil {
 		return err
@@ -71,7 +71,7 @@ func (r *CourseRepository) Delete(id string) error {
 }
 
 func (r *CourseRepository) List() ([]*course.Course, error) {
-	rows, err := r.DB.Query(`SELECT id, title, description, category, created_by, is_published FROM courses`)
+	rows, err := r.DB.Query(`SELECT id, title, description, category, created_by, is_published, enrollment_mode, seat_capacity, recertification_months FROM courses`)
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +80,7 @@ func (r *CourseRepository) List() ([]*course.Course, error) {
 	var courses []*course.Course
 	for rows.Next() {
 		var c course.Course
-		if err := rows.Scan(&c.ID, &c.Title, &c.Description, &c.Category, &c.CreatedBy, &c.Published); err != nil {
+		if err := rows.Scan(&c.ID, &c.Title, &c.Description, &c.Category, &c.CreatedBy, &c.Published, &c.EnrollmentMode, &c.SeatCapacity, &c.RecertificationMonths); err != nil {
 			return nil, err
 		}
 		courses = append(courses, &c)
@@ -16,21 +16,11 @@ func ConnectDB() (*sql.DB, error) {
 		return db, nil
 	}
 
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-
-	if host == "" || port == "" || user == "" || password == "" || dbname == "" {
-		return nil, fmt.Errorf("database environment variables are not set")
+	psqlInfo, err := DSN()
+	if err != nil {
+		return nil, err
 	}
 
-	psqlInfo := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname,
-	)
-
 	conn, err := sql.Open("postgres", psqlInfo)
 	if err != nil {
 		return nil, err
@@ -45,6 +35,24 @@ func ConnectDB() (*sql.DB, error) {
 	return db, nil
 }
 
+// DSN builds the connection string from environment variables.
+func DSN() (string, error) {
+	host := os.Getenv("DB_HOST")
+	port := os.Getenv("DB_PORT")
+	user := os.Getenv("DB_USER")
+	password := os.Getenv("DB_PASSWORD")
+	dbname := os.Getenv("DB_NAME")
+
+	if host == "" || port == "" || user == "" || password == "" || dbname == "" {
+		return "", fmt.Errorf("database environment variables are not set")
+	}
+
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		host, port, user, password, dbname,
+	), nil
+}
+
 // GetDB returns the global DB connection (must call ConnectDB first).
 func GetDB() *sql.DB {
 	return db
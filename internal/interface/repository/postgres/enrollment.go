@@ -0,0 +1,343 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/enrollment"
+
+	"github.com/lib/pq"
+)
+
+// EnrollmentRepository implements enrollment data access using PostgreSQL.
+type EnrollmentRepository struct {
+	DB *sql.DB
+}
+
+func NewEnrollmentRepository(db *sql.DB) *EnrollmentRepository {
+	return &EnrollmentRepository{DB: db}
+}
+
+const enrollmentColumns = `id, user_id, course_id, status, enrolled_at, COALESCE(updated_at, enrolled_at),
+	COALESCE(assigned_by::text, ''), due_at, grace_days, overdue_at, escalation_level, notified_hr`
+
+func scanEnrollment(row interface{ Scan(...interface{}) error }) (*enrollment.Enrollment, error) {
+	var e enrollment.Enrollment
+	var createdAt, updatedAt time.Time
+	var dueAt, overdueAt sql.NullTime
+	if err := row.Scan(
+		&e.ID, &e.UserID, &e.CourseID, &e.Status, &createdAt, &updatedAt,
+		&e.AssignedBy, &dueAt, &e.GraceDays, &overdueAt, &e.EscalationLevel, pq.Array(&e.NotifiedHR),
+	); err != nil {
+		return nil, err
+	}
+	e.CreatedAt = createdAt.Unix()
+	e.UpdatedAt = updatedAt.Unix()
+	e.DueAt = unixOrZero(dueAt)
+	e.OverdueAt = unixOrZero(overdueAt)
+	return &e, nil
+}
+
+func (r *EnrollmentRepository) FindByID(id string) (*enrollment.Enrollment, error) {
+	e, err := scanEnrollment(r.DB.QueryRow(
+		`SELECT `+enrollmentColumns+` FROM enrollments WHERE id = $1`,
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *EnrollmentRepository) FindByUserAndCourse(userID, courseID string) (*enrollment.Enrollment, error) {
+	e, err := scanEnrollment(r.DB.QueryRow(
+		`SELECT `+enrollmentColumns+` FROM enrollments WHERE user_id = $1 AND course_id = $2`,
+		userID, courseID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+// Create inserts a new enrollment together with its initial history entry.
+func (r *EnrollmentRepository) Create(e *enrollment.Enrollment, change *enrollment.StatusChange) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := writeEnrollment(tx, e, change); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateStatus persists a status transition and records it in the history.
+func (r *EnrollmentRepository) UpdateStatus(e *enrollment.Enrollment, change *enrollment.StatusChange) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := writeEnrollment(tx, e, change); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClaimSeat applies a status change only if the course has fewer than capacity
+// active enrollments. A course-scoped advisory lock serialises concurrent claims.
+// It reports whether a seat was claimed; nothing is written when the course is full.
+func (r *EnrollmentRepository) ClaimSeat(e *enrollment.Enrollment, change *enrollment.StatusChange, capacity int) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, e.CourseID); err != nil {
+		return false, err
+	}
+	var taken int
+	if err := tx.QueryRow(
+		`SELECT COUNT(*) FROM enrollments WHERE course_id = $1 AND status = $2`,
+		e.CourseID, enrollment.StatusActive,
+	).Scan(&taken); err != nil {
+		return false, err
+	}
+	if taken >= capacity {
+		return false, nil
+	}
+
+	if err := writeEnrollment(tx, e, change); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// ListByStatus returns enrollments in the given status in the order they entered it.
+// An empty courseID lists across all courses.
+func (r *EnrollmentRepository) ListByStatus(courseID string, status enrollment.Status) ([]*enrollment.Enrollment, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+enrollmentColumns+` FROM enrollments
+		 WHERE ($1 = '' OR course_id::text = $1) AND status = $2
+		 ORDER BY status_seq ASC`,
+		courseID, status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var enrollments []*enrollment.Enrollment
+	for rows.Next() {
+		e, err := scanEnrollment(rows)
+		if err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, e)
+	}
+	return enrollments, rows.Err()
+}
+
+// UpdateDeadline sets the assigner, due date and grace period of an enrollment.
+// Changing the deadline clears any overdue state and escalation.
+func (r *EnrollmentRepository) UpdateDeadline(e *enrollment.Enrollment) error {
+	res, err := r.DB.Exec(
+		`UPDATE enrollments SET assigned_by = $1, due_at = $2, grace_days = $3, overdue_at = NULL, escalation_level = 0, notified_hr = '{}', updated_at = $4 WHERE id = $5`,
+		nullString(e.AssignedBy), nullTime(e.DueAt), e.GraceDays, time.Unix(e.UpdatedAt, 0), e.ID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateEscalation persists the overdue timestamp, escalation level and the
+// HR users notified so far.
+func (r *EnrollmentRepository) UpdateEscalation(e *enrollment.Enrollment) error {
+	_, err := r.DB.Exec(
+		`UPDATE enrollments SET overdue_at = $1, escalation_level = $2, notified_hr = $3 WHERE id = $4`,
+		nullTime(e.OverdueAt), e.EscalationLevel, pq.Array(e.NotifiedHR), e.ID,
+	)
+	return err
+}
+
+// ListWithDeadline returns active enrollments that have a due date.
+func (r *EnrollmentRepository) ListWithDeadline() ([]*enrollment.Enrollment, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+enrollmentColumns+` FROM enrollments WHERE status = $1 AND due_at IS NOT NULL ORDER BY due_at ASC`,
+		enrollment.StatusActive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var enrollments []*enrollment.Enrollment
+	for rows.Next() {
+		e, err := scanEnrollment(rows)
+		if err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, e)
+	}
+	return enrollments, rows.Err()
+}
+
+// ListOverdue returns active enrollments marked overdue, joined with user and
+// course details. An empty department lists all departments.
+func (r *EnrollmentRepository) ListOverdue(department string) ([]*enrollment.OverdueRecord, error) {
+	rows, err := r.DB.Query(
+		`SELECT e.id, u.id, COALESCE(u.name, ''), u.email, COALESCE(u.department, ''), c.id, COALESCE(c.title, ''),
+		        COALESCE(e.assigned_by::text, ''), e.due_at, e.overdue_at, e.escalation_level
+		 FROM enrollments e
+		 JOIN users u ON u.id = e.user_id
+		 JOIN courses c ON c.id = e.course_id
+		 WHERE e.status = $1 AND e.overdue_at IS NOT NULL AND ($2 = '' OR u.department = $2)
+		 ORDER BY u.department, e.due_at`,
+		enrollment.StatusActive, department,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*enrollment.OverdueRecord
+	for rows.Next() {
+		var rec enrollment.OverdueRecord
+		var dueAt, overdueAt time.Time
+		if err := rows.Scan(
+			&rec.EnrollmentID, &rec.UserID, &rec.UserName, &rec.UserEmail, &rec.Department, &rec.CourseID, &rec.CourseTitle,
+			&rec.AssignedBy, &dueAt, &overdueAt, &rec.EscalationLevel,
+		); err != nil {
+			return nil, err
+		}
+		rec.DueAt = dueAt.Unix()
+		rec.OverdueAt = overdueAt.Unix()
+		records = append(records, &rec)
+	}
+	return records, rows.Err()
+}
+
+// List returns enrollments, optionally filtered by user and/or course.
+func (r *EnrollmentRepository) List(userID, courseID string) ([]*enrollment.Enrollment, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+enrollmentColumns+` FROM enrollments
+		 WHERE ($1 = '' OR user_id::text = $1) AND ($2 = '' OR course_id::text = $2)
+		 ORDER BY enrolled_at ASC`,
+		userID, courseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var enrollments []*enrollment.Enrollment
+	for rows.Next() {
+		e, err := scanEnrollment(rows)
+		if err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, e)
+	}
+	return enrollments, rows.Err()
+}
+
+func (r *EnrollmentRepository) ListHistory(enrollmentID string) ([]*enrollment.StatusChange, error) {
+	rows, err := r.DB.Query(
+		`SELECT id, enrollment_id, COALESCE(from_status, ''), to_status, COALESCE(changed_by::text, ''), COALESCE(reason, ''), changed_at
+		 FROM enrollment_history WHERE enrollment_id = $1 ORDER BY changed_at ASC`,
+		enrollmentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*enrollment.StatusChange
+	for rows.Next() {
+		var sc enrollment.StatusChange
+		var changedAt time.Time
+		if err := rows.Scan(&sc.ID, &sc.EnrollmentID, &sc.FromStatus, &sc.ToStatus, &sc.ChangedBy, &sc.Reason, &changedAt); err != nil {
+			return nil, err
+		}
+		sc.ChangedAt = changedAt.Unix()
+		history = append(history, &sc)
+	}
+	return history, rows.Err()
+}
+
+// writeEnrollment inserts the enrollment when change.FromStatus is empty, otherwise
+// moves it from change.FromStatus to its new status, and records the change.
+func writeEnrollment(tx *sql.Tx, e *enrollment.Enrollment, change *enrollment.StatusChange) error {
+	if change.FromStatus == "" {
+		_, err := tx.Exec(
+			`INSERT INTO enrollments (id, user_id, course_id, status, enrolled_at, updated_at, assigned_by, due_at, grace_days) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			e.ID, e.UserID, e.CourseID, e.Status, time.Unix(e.CreatedAt, 0), time.Unix(e.UpdatedAt, 0),
+			nullString(e.AssignedBy), nullTime(e.DueAt), e.GraceDays,
+		)
+		if err != nil {
+			return err
+		}
+	} else {
+		res, err := tx.Exec(
+			`UPDATE enrollments SET status = $1, updated_at = $2, status_seq = nextval('enrollment_status_seq') WHERE id = $3 AND status = $4`,
+			e.Status, time.Unix(e.UpdatedAt, 0), e.ID, change.FromStatus,
+		)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return sql.ErrNoRows
+		}
+	}
+	return insertStatusChange(tx, change)
+}
+
+func insertStatusChange(tx *sql.Tx, sc *enrollment.StatusChange) error {
+	_, err := tx.Exec(
+		`INSERT INTO enrollment_history (id, enrollment_id, from_status, to_status, changed_by, reason, changed_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		sc.ID, sc.EnrollmentID, nullString(string(sc.FromStatus)), sc.ToStatus, nullString(sc.ChangedBy), nullString(sc.Reason), time.Unix(sc.ChangedAt, 0),
+	)
+	return err
+}
+
+// nullString maps an empty string to SQL NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullTime maps a zero Unix timestamp to SQL NULL.
+func nullTime(unix int64) sql.NullTime {
+	return sql.NullTime{Time: time.Unix(unix, 0), Valid: unix != 0}
+}
+
+// unixOrZero converts a nullable timestamp to Unix seconds, 0 for NULL.
+func unixOrZero(t sql.NullTime) int64 {
+	if !t.Valid {
+		return 0
+	}
+	return t.Time.Unix()
+}
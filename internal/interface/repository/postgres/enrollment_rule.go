@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/enrollment"
+)
+
+// EnrollmentRuleRepository implements auto-enrollment rule data access using PostgreSQL.
+type EnrollmentRuleRepository struct {
+	DB *sql.DB
+}
+
+func NewEnrollmentRuleRepository(db *sql.DB) *EnrollmentRuleRepository {
+	return &EnrollmentRuleRepository{DB: db}
+}
+
+const enrollmentRuleColumns = `id, name, field, value, course_id, active, COALESCE(created_by::text, ''), created_at`
+
+func scanEnrollmentRule(row interface{ Scan(...interface{}) error }) (*enrollment.Rule, error) {
+	var r enrollment.Rule
+	var createdAt time.Time
+	if err := row.Scan(&r.ID, &r.Name, &r.Field, &r.Value, &r.CourseID, &r.Active, &r.CreatedBy, &createdAt); err != nil {
+		return nil, err
+	}
+	r.CreatedAt = createdAt.Unix()
+	return &r, nil
+}
+
+func (r *EnrollmentRuleRepository) FindByID(id string) (*enrollment.Rule, error) {
+	rule, err := scanEnrollmentRule(r.DB.QueryRow(
+		`SELECT `+enrollmentRuleColumns+` FROM enrollment_rules WHERE id = $1`,
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *EnrollmentRuleRepository) Create(rule *enrollment.Rule) error {
+	_, err := r.DB.Exec(
+		`INSERT INTO enrollment_rules (id, name, field, value, course_id, active, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		rule.ID, rule.Name, rule.Field, rule.Value, rule.CourseID, rule.Active, nullString(rule.CreatedBy), time.Unix(rule.CreatedAt, 0),
+	)
+	return err
+}
+
+func (r *EnrollmentRuleRepository) Update(rule *enrollment.Rule) error {
+	res, err := r.DB.Exec(
+		`UPDATE enrollment_rules SET name = $1, field = $2, value = $3, course_id = $4, active = $5 WHERE id = $6`,
+		rule.Name, rule.Field, rule.Value, rule.CourseID, rule.Active, rule.ID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *EnrollmentRuleRepository) Delete(id string) error {
+	res, err := r.DB.Exec(`DELETE FROM enrollment_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *EnrollmentRuleRepository) List() ([]*enrollment.Rule, error) {
+	rows, err := r.DB.Query(`SELECT ` + enrollmentRuleColumns + ` FROM enrollment_rules ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*enrollment.Rule
+	for rows.Next() {
+		rule, err := scanEnrollmentRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *EnrollmentRuleRepository) RecordEnrollment(a *enrollment.RuleEnrollment) error {
+	_, err := r.DB.Exec(
+		`INSERT INTO enrollment_rule_audit (id, rule_id, user_id, enrollment_id, status, enrolled_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		a.ID, a.RuleID, a.UserID, a.EnrollmentID, a.Status, time.Unix(a.EnrolledAt, 0),
+	)
+	return err
+}
+
+func (r *EnrollmentRuleRepository) ListAudit(ruleID string) ([]*enrollment.RuleEnrollment, error) {
+	rows, err := r.DB.Query(
+		`SELECT id, rule_id, user_id, COALESCE(enrollment_id::text, ''), status, enrolled_at
+		 FROM enrollment_rule_audit WHERE rule_id = $1 ORDER BY enrolled_at ASC`,
+		ruleID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var audit []*enrollment.RuleEnrollment
+	for rows.Next() {
+		var a enrollment.RuleEnrollment
+		var enrolledAt time.Time
+		if err := rows.Scan(&a.ID, &a.RuleID, &a.UserID, &a.EnrollmentID, &a.Status, &enrolledAt); err != nil {
+			return nil, err
+		}
+		a.EnrolledAt = enrolledAt.Unix()
+		audit = append(audit, &a)
+	}
+	return audit, rows.Err()
+}
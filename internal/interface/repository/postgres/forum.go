@@ -0,0 +1,420 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/forum"
+)
+
+// ForumRepository implements forum, post and reply data access using PostgreSQL.
+type ForumRepository struct {
+	DB *sql.DB
+}
+
+func NewForumRepository(db *sql.DB) *ForumRepository {
+	return &ForumRepository{DB: db}
+}
+
+const forumColumns = `f.id, f.title, COALESCE(f.course_id::text, ''), COALESCE(f.module_id::text, ''), COALESCE(f.created_by::text, ''), COALESCE(f.created_at, CURRENT_TIMESTAMP)`
+
+func scanForum(row interface{ Scan(...interface{}) error }) (*forum.Forum, error) {
+	var f forum.Forum
+	var createdAt time.Time
+	if err := row.Scan(&f.ID, &f.Title, &f.CourseID, &f.ModuleID, &f.CreatedBy, &createdAt); err != nil {
+		return nil, err
+	}
+	f.CreatedAt = createdAt.Unix()
+	return &f, nil
+}
+
+const postColumns = `p.id, p.forum_id, COALESCE(p.user_id::text, ''), p.type, p.state, p.locked, p.pinned,
+	p.title, p.content, COALESCE(p.created_at, p.last_activity_at), p.edited_at, p.deleted_at, p.last_activity_at,
+	(SELECT COUNT(*) FROM replies r WHERE r.post_id = p.id AND r.deleted_at IS NULL AND r.state = 'visible'),
+	p.score, COALESCE(p.accepted_reply_id::text, '')`
+
+func scanPost(row interface{ Scan(...interface{}) error }) (*forum.Post, error) {
+	var p forum.Post
+	var createdAt, lastActivityAt time.Time
+	var editedAt, deletedAt sql.NullTime
+	if err := row.Scan(
+		&p.ID, &p.ForumID, &p.UserID, &p.Type, &p.State, &p.Locked, &p.Pinned,
+		&p.Title, &p.Content, &createdAt, &editedAt, &deletedAt, &lastActivityAt, &p.ReplyCount,
+		&p.Score, &p.AcceptedReplyID,
+	); err != nil {
+		return nil, err
+	}
+	p.CreatedAt = createdAt.Unix()
+	p.EditedAt = unixOrZero(editedAt)
+	p.DeletedAt = unixOrZero(deletedAt)
+	p.LastActivityAt = lastActivityAt.Unix()
+	return &p, nil
+}
+
+const replyColumns = `r.id, r.post_id, COALESCE(r.user_id::text, ''), r.content, r.state, COALESCE(r.created_at, CURRENT_TIMESTAMP), r.edited_at, r.deleted_at,
+	r.score, EXISTS (SELECT 1 FROM posts p WHERE p.id = r.post_id AND p.accepted_reply_id = r.id)`
+
+func scanReply(row interface{ Scan(...interface{}) error }) (*forum.Reply, error) {
+	var r forum.Reply
+	var createdAt time.Time
+	var editedAt, deletedAt sql.NullTime
+	if err := row.Scan(&r.ID, &r.PostID, &r.UserID, &r.Content, &r.State, &createdAt, &editedAt, &deletedAt, &r.Score, &r.Accepted); err != nil {
+		return nil, err
+	}
+	r.CreatedAt = createdAt.Unix()
+	r.EditedAt = unixOrZero(editedAt)
+	r.DeletedAt = unixOrZero(deletedAt)
+	return &r, nil
+}
+
+func (r *ForumRepository) CreateForum(f *forum.Forum) error {
+	_, err := r.DB.Exec(
+		`INSERT INTO forums (id, title, course_id, module_id, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		f.ID, f.Title, nullString(f.CourseID), nullString(f.ModuleID), nullString(f.CreatedBy), time.Unix(f.CreatedAt, 0),
+	)
+	return err
+}
+
+// EnsureForum inserts f unless its course or module already has a forum and
+// returns the one that is stored. The unique scope indexes settle races.
+func (r *ForumRepository) EnsureForum(f *forum.Forum) (*forum.Forum, error) {
+	if _, err := r.DB.Exec(
+		`INSERT INTO forums (id, title, course_id, module_id, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT DO NOTHING`,
+		f.ID, f.Title, nullString(f.CourseID), nullString(f.ModuleID), nullString(f.CreatedBy), time.Unix(f.CreatedAt, 0),
+	); err != nil {
+		return nil, err
+	}
+	if f.ModuleID != "" {
+		return scanForum(r.DB.QueryRow(`SELECT `+forumColumns+` FROM forums f WHERE f.module_id = $1`, f.ModuleID))
+	}
+	return scanForum(r.DB.QueryRow(`SELECT `+forumColumns+` FROM forums f WHERE f.course_id = $1 AND f.module_id IS NULL`, f.CourseID))
+}
+
+func (r *ForumRepository) FindForum(id string) (*forum.Forum, error) {
+	f, err := scanForum(r.DB.QueryRow(`SELECT `+forumColumns+` FROM forums f WHERE f.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// ListForums returns the course forum first, followed by the module forums in
+// module order.
+func (r *ForumRepository) ListForums(courseID string) ([]*forum.Forum, error) {
+	var rows *sql.Rows
+	var err error
+	if courseID == "" {
+		rows, err = r.DB.Query(`SELECT ` + forumColumns + ` FROM forums f WHERE f.course_id IS NULL ORDER BY f.created_at, f.title`)
+	} else {
+		rows, err = r.DB.Query(
+			`SELECT `+forumColumns+` FROM forums f
+			 LEFT JOIN modules m ON m.id = f.module_id
+			 WHERE f.course_id = $1
+			 ORDER BY f.module_id IS NOT NULL, m.order_index, f.title`,
+			courseID,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var forums []*forum.Forum
+	for rows.Next() {
+		f, err := scanForum(rows)
+		if err != nil {
+			return nil, err
+		}
+		forums = append(forums, f)
+	}
+	return forums, rows.Err()
+}
+
+func (r *ForumRepository) CreatePost(p *forum.Post) error {
+	_, err := r.DB.Exec(
+		`INSERT INTO posts (id, forum_id, user_id, type, state, title, content, created_at, last_activity_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		p.ID, p.ForumID, nullString(p.UserID), p.Type, p.State, p.Title, p.Content,
+		time.Unix(p.CreatedAt, 0), time.Unix(p.LastActivityAt, 0),
+	)
+	return err
+}
+
+func (r *ForumRepository) FindPost(id string) (*forum.Post, error) {
+	p, err := scanPost(r.DB.QueryRow(`SELECT `+postColumns+` FROM posts p WHERE p.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return p, err
+}
+
+// postOrders maps each sort to its ORDER BY clause. ListPosts puts pinned
+// threads first except for unanswered questions.
+var postOrders = map[forum.PostSort]string{
+	forum.SortActivity:   `p.last_activity_at DESC, p.id`,
+	forum.SortVotes:      `p.score DESC, p.last_activity_at DESC, p.id`,
+	forum.SortNewest:     `p.created_at DESC, p.id`,
+	forum.SortUnanswered: `p.created_at, p.id`,
+}
+
+func (r *ForumRepository) ListPosts(filter forum.PostFilter) ([]*forum.Post, int, error) {
+	order, ok := postOrders[filter.Sort]
+	if !ok {
+		order = postOrders[forum.SortActivity]
+	}
+	where := `p.deleted_at IS NULL AND p.forum_id = $1`
+	scope := filter.ForumID
+	if scope == "" {
+		where = `p.deleted_at IS NULL AND p.forum_id IN (SELECT id FROM forums WHERE course_id = $1)`
+		scope = filter.CourseID
+	}
+	if !filter.AllStates {
+		where += ` AND p.state = 'visible'`
+	}
+	if filter.Sort == forum.SortUnanswered {
+		where += ` AND p.type = 'question' AND p.accepted_reply_id IS NULL
+		   AND NOT EXISTS (SELECT 1 FROM replies a WHERE a.post_id = p.id AND a.deleted_at IS NULL AND a.state = 'visible' AND a.score > 0)`
+	} else {
+		order = `p.pinned DESC, ` + order
+	}
+
+	var total int
+	if err := r.DB.QueryRow(`SELECT COUNT(*) FROM posts p WHERE `+where, scope).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.DB.Query(
+		`SELECT `+postColumns+`, COALESCE(v.value, 0) FROM posts p
+		 LEFT JOIN post_votes v ON v.post_id = p.id AND v.user_id::text = $2
+		 WHERE `+where+` ORDER BY `+order+` LIMIT $3 OFFSET $4`,
+		scope, filter.ViewerID, filter.Limit, filter.Offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var posts []*forum.Post
+	for rows.Next() {
+		var vote int
+		p, err := scanPost(withExtraColumns{rows, []interface{}{&vote}})
+		if err != nil {
+			return nil, 0, err
+		}
+		p.Vote = vote
+		posts = append(posts, p)
+	}
+	return posts, total, rows.Err()
+}
+
+func (r *ForumRepository) UpdatePost(p *forum.Post, rev *forum.Revision) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertRevision(tx, rev); err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		`UPDATE posts SET title = $2, content = $3, state = $4, edited_at = $5, deleted_at = $6 WHERE id = $1`,
+		p.ID, p.Title, p.Content, p.State, nullTime(p.EditedAt), nullTime(p.DeletedAt),
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+// CreateReply inserts the reply and, when it is visible, moves its post's
+// LastActivityAt.
+func (r *ForumRepository) CreateReply(reply *forum.Reply) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO replies (id, post_id, user_id, content, state, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		reply.ID, reply.PostID, nullString(reply.UserID), reply.Content, reply.State, time.Unix(reply.CreatedAt, 0),
+	); err != nil {
+		return err
+	}
+	if reply.State != forum.StateVisible {
+		return tx.Commit()
+	}
+	if _, err := tx.Exec(
+		`UPDATE posts SET last_activity_at = GREATEST(last_activity_at, $2) WHERE id = $1`,
+		reply.PostID, time.Unix(reply.CreatedAt, 0),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ForumRepository) FindReply(id string) (*forum.Reply, error) {
+	reply, err := scanReply(r.DB.QueryRow(`SELECT `+replyColumns+` FROM replies r WHERE r.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return reply, err
+}
+
+func (r *ForumRepository) ListReplies(filter forum.ReplyFilter) ([]*forum.Reply, int, error) {
+	where := `r.post_id = $1 AND r.deleted_at IS NULL`
+	if !filter.AllStates {
+		where += ` AND r.state = 'visible'`
+	}
+	var total int
+	if err := r.DB.QueryRow(`SELECT COUNT(*) FROM replies r WHERE `+where, filter.PostID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := r.DB.Query(
+		`SELECT `+replyColumns+`, COALESCE(v.value, 0) FROM replies r
+		 LEFT JOIN reply_votes v ON v.reply_id = r.id AND v.user_id::text = $2
+		 WHERE `+where+`
+		 ORDER BY r.created_at, r.id LIMIT $3 OFFSET $4`,
+		filter.PostID, filter.ViewerID, filter.Limit, filter.Offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var replies []*forum.Reply
+	for rows.Next() {
+		var vote int
+		reply, err := scanReply(withExtraColumns{rows, []interface{}{&vote}})
+		if err != nil {
+			return nil, 0, err
+		}
+		reply.Vote = vote
+		replies = append(replies, reply)
+	}
+	return replies, total, rows.Err()
+}
+
+func (r *ForumRepository) UpdateReply(reply *forum.Reply, rev *forum.Revision) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertRevision(tx, rev); err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		`UPDATE replies SET content = $2, state = $3, edited_at = $4, deleted_at = $5 WHERE id = $1`,
+		reply.ID, reply.Content, reply.State, nullTime(reply.EditedAt), nullTime(reply.DeletedAt),
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+func (r *ForumRepository) ListRevisions(postID, replyID string) ([]*forum.Revision, error) {
+	rows, err := r.DB.Query(
+		`SELECT id, COALESCE(post_id::text, ''), COALESCE(reply_id::text, ''), title, content, action,
+		        COALESCE(edited_by::text, ''), edited_at
+		 FROM forum_revisions
+		 WHERE ($1 <> '' AND post_id::text = $1) OR ($2 <> '' AND reply_id::text = $2)
+		 ORDER BY edited_at, id`,
+		postID, replyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []*forum.Revision
+	for rows.Next() {
+		var rev forum.Revision
+		var editedAt time.Time
+		if err := rows.Scan(&rev.ID, &rev.PostID, &rev.ReplyID, &rev.Title, &rev.Content, &rev.Action, &rev.EditedBy, &editedAt); err != nil {
+			return nil, err
+		}
+		rev.EditedAt = editedAt.Unix()
+		revisions = append(revisions, &rev)
+	}
+	return revisions, rows.Err()
+}
+
+// SaveVote upserts or removes the vote and recounts the score in the same
+// transaction.
+func (r *ForumRepository) SaveVote(v *forum.Vote) (int, error) {
+	table, column, target, scored := "post_votes", "post_id", v.PostID, "posts"
+	if v.ReplyID != "" {
+		table, column, target, scored = "reply_votes", "reply_id", v.ReplyID, "replies"
+	}
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if v.Value == 0 {
+		_, err = tx.Exec(`DELETE FROM `+table+` WHERE `+column+` = $1 AND user_id = $2`, target, v.UserID)
+	} else {
+		_, err = tx.Exec(
+			`INSERT INTO `+table+` (`+column+`, user_id, value, created_at) VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (`+column+`, user_id) DO UPDATE SET value = EXCLUDED.value, created_at = EXCLUDED.created_at`,
+			target, v.UserID, v.Value, time.Unix(v.CreatedAt, 0),
+		)
+	}
+	if err != nil {
+		return 0, err
+	}
+	var score int
+	if err := tx.QueryRow(
+		`UPDATE `+scored+` SET score = (SELECT COALESCE(SUM(value), 0) FROM `+table+` WHERE `+column+` = $1)
+		 WHERE id = $1 RETURNING score`,
+		target,
+	).Scan(&score); err != nil {
+		return 0, err
+	}
+	return score, tx.Commit()
+}
+
+func (r *ForumRepository) FindVote(userID, postID, replyID string) (int, error) {
+	var value int
+	err := r.DB.QueryRow(
+		`SELECT value FROM post_votes WHERE $2 <> '' AND post_id::text = $2 AND user_id::text = $1
+		 UNION ALL
+		 SELECT value FROM reply_votes WHERE $3 <> '' AND reply_id::text = $3 AND user_id::text = $1`,
+		userID, postID, replyID,
+	).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return value, err
+}
+
+func (r *ForumRepository) SetAccepted(postID, replyID string) error {
+	_, err := r.DB.Exec(`UPDATE posts SET accepted_reply_id = $2 WHERE id = $1`, postID, nullString(replyID))
+	return err
+}
+
+func insertRevision(tx *sql.Tx, rev *forum.Revision) error {
+	_, err := tx.Exec(
+		`INSERT INTO forum_revisions (id, post_id, reply_id, title, content, action, edited_by, edited_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		rev.ID, nullString(rev.PostID), nullString(rev.ReplyID), rev.Title, rev.Content, rev.Action,
+		nullString(rev.EditedBy), time.Unix(rev.EditedAt, 0),
+	)
+	return err
+}
@@ -0,0 +1,275 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/forum"
+
+	"github.com/lib/pq"
+)
+
+// ForumModerationRepository implements forum reports, the moderation log and
+// suspensions using PostgreSQL.
+type ForumModerationRepository struct {
+	DB *sql.DB
+}
+
+func NewForumModerationRepository(db *sql.DB) *ForumModerationRepository {
+	return &ForumModerationRepository{DB: db}
+}
+
+// CreateReport relies on the unique open-report indexes to drop duplicates.
+func (r *ForumModerationRepository) CreateReport(rep *forum.Report) (bool, error) {
+	res, err := r.DB.Exec(
+		`INSERT INTO forum_reports (id, post_id, reply_id, reporter_id, reason, status, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 ON CONFLICT DO NOTHING`,
+		rep.ID, nullString(rep.PostID), nullString(rep.ReplyID), rep.ReporterID, rep.Reason, rep.Status,
+		time.Unix(rep.CreatedAt, 0),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+func (r *ForumModerationRepository) ListQueue(courseID string) ([]*forum.QueueItem, error) {
+	rows, err := r.DB.Query(
+		`SELECT p.id, '', p.forum_id, COALESCE(f.course_id::text, ''), COALESCE(p.user_id::text, ''),
+		        p.title, p.content, p.state, COUNT(rep.id),
+		        COALESCE(ARRAY_AGG(rep.reason ORDER BY rep.created_at) FILTER (WHERE rep.id IS NOT NULL), '{}'),
+		        COALESCE(p.created_at, p.last_activity_at) AS created
+		 FROM posts p
+		 JOIN forums f ON f.id = p.forum_id
+		 LEFT JOIN forum_reports rep ON rep.post_id = p.id AND rep.status = 'open'
+		 WHERE p.deleted_at IS NULL AND ($1 = '' OR f.course_id::text = $1)
+		 GROUP BY p.id, f.id
+		 HAVING p.state = 'held' OR COUNT(rep.id) > 0
+		 UNION ALL
+		 SELECT p.id, r.id::text, p.forum_id, COALESCE(f.course_id::text, ''), COALESCE(r.user_id::text, ''),
+		        p.title, r.content, r.state, COUNT(rep.id),
+		        COALESCE(ARRAY_AGG(rep.reason ORDER BY rep.created_at) FILTER (WHERE rep.id IS NOT NULL), '{}'),
+		        COALESCE(r.created_at, CURRENT_TIMESTAMP)
+		 FROM replies r
+		 JOIN posts p ON p.id = r.post_id
+		 JOIN forums f ON f.id = p.forum_id
+		 LEFT JOIN forum_reports rep ON rep.reply_id = r.id AND rep.status = 'open'
+		 WHERE r.deleted_at IS NULL AND p.deleted_at IS NULL AND ($1 = '' OR f.course_id::text = $1)
+		 GROUP BY r.id, p.id, f.id
+		 HAVING r.state = 'held' OR COUNT(rep.id) > 0
+		 ORDER BY created`,
+		courseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*forum.QueueItem
+	for rows.Next() {
+		var item forum.QueueItem
+		var createdAt time.Time
+		if err := rows.Scan(
+			&item.PostID, &item.ReplyID, &item.ForumID, &item.CourseID, &item.AuthorID,
+			&item.Title, &item.Content, &item.State, &item.Reports, pq.Array(&item.Reasons), &createdAt,
+		); err != nil {
+			return nil, err
+		}
+		item.CreatedAt = createdAt.Unix()
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+func (r *ForumModerationRepository) ApplyModeration(a *forum.ModerationAction, p *forum.Post, reply *forum.Reply, status forum.ReportStatus) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if p != nil {
+		if _, err := tx.Exec(
+			`UPDATE posts SET state = $2, locked = $3, pinned = $4 WHERE id = $1`,
+			p.ID, p.State, p.Locked, p.Pinned,
+		); err != nil {
+			return err
+		}
+	}
+	if reply != nil {
+		if _, err := tx.Exec(`UPDATE replies SET state = $2 WHERE id = $1`, reply.ID, reply.State); err != nil {
+			return err
+		}
+	}
+	if status != "" {
+		where := `post_id = $1`
+		target := a.PostID
+		if a.ReplyID != "" {
+			where, target = `reply_id = $1`, a.ReplyID
+		}
+		if _, err := tx.Exec(
+			`UPDATE forum_reports SET status = $2, resolved_by = $3, resolved_at = $4
+			 WHERE `+where+` AND status = 'open'`,
+			target, status, nullString(a.ModeratorID), time.Unix(a.CreatedAt, 0),
+		); err != nil {
+			return err
+		}
+	}
+	if err := insertModerationAction(tx, a); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ForumModerationRepository) LogAction(a *forum.ModerationAction) error {
+	return insertModerationAction(r.DB, a)
+}
+
+func (r *ForumModerationRepository) ListActions(courseID string, limit int) ([]*forum.ModerationAction, error) {
+	rows, err := r.DB.Query(
+		`SELECT id, action, COALESCE(course_id::text, ''), COALESCE(post_id::text, ''), COALESCE(reply_id::text, ''),
+		        COALESCE(user_id::text, ''), COALESCE(moderator_id::text, ''), reason, created_at
+		 FROM forum_moderation_log
+		 WHERE $1 = '' OR course_id::text = $1
+		 ORDER BY created_at DESC, id
+		 LIMIT $2`,
+		courseID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []*forum.ModerationAction
+	for rows.Next() {
+		var a forum.ModerationAction
+		var createdAt time.Time
+		if err := rows.Scan(&a.ID, &a.Action, &a.CourseID, &a.PostID, &a.ReplyID, &a.UserID, &a.ModeratorID, &a.Reason, &createdAt); err != nil {
+			return nil, err
+		}
+		a.CreatedAt = createdAt.Unix()
+		actions = append(actions, &a)
+	}
+	return actions, rows.Err()
+}
+
+const suspensionColumns = `id, user_id, course_id, reason, COALESCE(created_by::text, ''), created_at, ends_at, lifted_at`
+
+func scanSuspension(row interface{ Scan(...interface{}) error }) (*forum.Suspension, error) {
+	var s forum.Suspension
+	var createdAt, endsAt time.Time
+	var liftedAt sql.NullTime
+	if err := row.Scan(&s.ID, &s.UserID, &s.CourseID, &s.Reason, &s.CreatedBy, &createdAt, &endsAt, &liftedAt); err != nil {
+		return nil, err
+	}
+	s.CreatedAt = createdAt.Unix()
+	s.EndsAt = endsAt.Unix()
+	s.LiftedAt = unixOrZero(liftedAt)
+	return &s, nil
+}
+
+func (r *ForumModerationRepository) CreateSuspension(s *forum.Suspension, a *forum.ModerationAction) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO forum_suspensions (id, user_id, course_id, reason, created_by, created_at, ends_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		s.ID, s.UserID, s.CourseID, s.Reason, nullString(s.CreatedBy), time.Unix(s.CreatedAt, 0), time.Unix(s.EndsAt, 0),
+	); err != nil {
+		return err
+	}
+	if err := insertModerationAction(tx, a); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ForumModerationRepository) FindSuspension(id string) (*forum.Suspension, error) {
+	s, err := scanSuspension(r.DB.QueryRow(`SELECT `+suspensionColumns+` FROM forum_suspensions WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+func (r *ForumModerationRepository) ActiveSuspension(userID, courseID string, now int64) (*forum.Suspension, error) {
+	s, err := scanSuspension(r.DB.QueryRow(
+		`SELECT `+suspensionColumns+` FROM forum_suspensions
+		 WHERE user_id::text = $1 AND course_id::text = $2 AND lifted_at IS NULL AND ends_at > $3
+		 ORDER BY ends_at DESC
+		 LIMIT 1`,
+		userID, courseID, time.Unix(now, 0),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+func (r *ForumModerationRepository) ListSuspensions(courseID string) ([]*forum.Suspension, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+suspensionColumns+` FROM forum_suspensions WHERE course_id = $1 ORDER BY created_at DESC, id`,
+		courseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suspensions []*forum.Suspension
+	for rows.Next() {
+		s, err := scanSuspension(rows)
+		if err != nil {
+			return nil, err
+		}
+		suspensions = append(suspensions, s)
+	}
+	return suspensions, rows.Err()
+}
+
+func (r *ForumModerationRepository) LiftSuspension(s *forum.Suspension, a *forum.ModerationAction) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE forum_suspensions SET lifted_at = $2 WHERE id = $1 AND lifted_at IS NULL`,
+		s.ID, time.Unix(s.LiftedAt, 0),
+	)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return sql.ErrNoRows
+	}
+	if err := insertModerationAction(tx, a); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertModerationAction(db execer, a *forum.ModerationAction) error {
+	_, err := db.Exec(
+		`INSERT INTO forum_moderation_log (id, action, course_id, post_id, reply_id, user_id, moderator_id, reason, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		a.ID, a.Action, nullString(a.CourseID), nullString(a.PostID), nullString(a.ReplyID),
+		nullString(a.UserID), nullString(a.ModeratorID), a.Reason, time.Unix(a.CreatedAt, 0),
+	)
+	return err
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"training-portal/internal/domain/forum"
+)
+
+// ForumSubscriptionRepository implements forum and thread subscriptions
+// using PostgreSQL.
+type ForumSubscriptionRepository struct {
+	DB *sql.DB
+}
+
+func NewForumSubscriptionRepository(db *sql.DB) *ForumSubscriptionRepository {
+	return &ForumSubscriptionRepository{DB: db}
+}
+
+const subscriptionColumns = `user_id, COALESCE(forum_id::text, ''), COALESCE(post_id::text, ''), digest, created_at`
+
+func scanSubscription(row interface{ Scan(...interface{}) error }) (*forum.Subscription, error) {
+	var s forum.Subscription
+	var createdAt time.Time
+	if err := row.Scan(&s.UserID, &s.ForumID, &s.PostID, &s.Digest, &createdAt); err != nil {
+		return nil, err
+	}
+	s.CreatedAt = createdAt.Unix()
+	return &s, nil
+}
+
+func (r *ForumSubscriptionRepository) Subscribe(s *forum.Subscription) error {
+	conflict := `(forum_id, user_id) WHERE forum_id IS NOT NULL`
+	if s.PostID != "" {
+		conflict = `(post_id, user_id) WHERE post_id IS NOT NULL`
+	}
+	_, err := r.DB.Exec(
+		`INSERT INTO forum_subscriptions (user_id, forum_id, post_id, digest, created_at) VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT `+conflict+` DO UPDATE SET digest = EXCLUDED.digest`,
+		s.UserID, nullString(s.ForumID), nullString(s.PostID), s.Digest, time.Unix(s.CreatedAt, 0),
+	)
+	return err
+}
+
+func (r *ForumSubscriptionRepository) Unsubscribe(userID, forumID, postID string) error {
+	_, err := r.DB.Exec(
+		`DELETE FROM forum_subscriptions
+		 WHERE user_id = $1 AND (($2 <> '' AND forum_id::text = $2) OR ($3 <> '' AND post_id::text = $3))`,
+		userID, forumID, postID,
+	)
+	return err
+}
+
+func (r *ForumSubscriptionRepository) ListSubscriptions(userID string) ([]*forum.Subscription, error) {
+	return r.list(
+		`SELECT `+subscriptionColumns+` FROM forum_subscriptions WHERE user_id = $1 ORDER BY created_at DESC`,
+		userID,
+	)
+}
+
+func (r *ForumSubscriptionRepository) Subscribers(forumID, postID string) ([]*forum.Subscription, error) {
+	return r.list(
+		`SELECT `+subscriptionColumns+` FROM forum_subscriptions
+		 WHERE ($2 <> '' AND post_id::text = $2) OR forum_id = $1
+		 ORDER BY post_id IS NULL, created_at`,
+		forumID, postID,
+	)
+}
+
+func (r *ForumSubscriptionRepository) list(query string, args ...interface{}) ([]*forum.Subscription, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*forum.Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/job"
+)
+
+// JobRepository implements scheduled job state and locking using PostgreSQL.
+type JobRepository struct {
+	DB *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) *JobRepository {
+	return &JobRepository{DB: db}
+}
+
+const jobColumns = `name, interval_seconds, paused, next_run_at, last_run_at, last_duration_ms,
+	COALESCE(last_error, ''), COALESCE(locked_by, ''), locked_until, updated_at`
+
+func scanJob(row interface{ Scan(...interface{}) error }) (*job.Job, error) {
+	var j job.Job
+	var nextRunAt, updatedAt time.Time
+	var lastRunAt, lockedUntil sql.NullTime
+	if err := row.Scan(&j.Name, &j.Interval, &j.Paused, &nextRunAt, &lastRunAt, &j.LastDuration,
+		&j.LastError, &j.LockedBy, &lockedUntil, &updatedAt); err != nil {
+		return nil, err
+	}
+	j.NextRunAt = nextRunAt.Unix()
+	j.LastRunAt = unixOrZero(lastRunAt)
+	j.LockedUntil = unixOrZero(lockedUntil)
+	j.UpdatedAt = updatedAt.Unix()
+	return &j, nil
+}
+
+func (r *JobRepository) Ensure(name string, interval time.Duration, now int64) error {
+	_, err := r.DB.Exec(
+		`INSERT INTO scheduled_jobs (name, interval_seconds, next_run_at, updated_at) VALUES ($1, $2, $3, $3)
+		 ON CONFLICT (name) DO UPDATE SET interval_seconds = EXCLUDED.interval_seconds`,
+		name, int64(interval/time.Second), time.Unix(now, 0),
+	)
+	return err
+}
+
+// Claim takes the job's lock with a conditional update, so only one instance
+// can claim a due run.
+func (r *JobRepository) Claim(name, owner string, now, leaseUntil int64) (bool, error) {
+	res, err := r.DB.Exec(
+		`UPDATE scheduled_jobs SET locked_by = $2, locked_until = $4
+		 WHERE name = $1 AND NOT paused AND next_run_at <= $3 AND (locked_until IS NULL OR locked_until <= $3)`,
+		name, owner, time.Unix(now, 0), time.Unix(leaseUntil, 0),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *JobRepository) Finish(name, owner string, ranAt, durationMillis int64, lastError string, nextRunAt int64) error {
+	_, err := r.DB.Exec(
+		`UPDATE scheduled_jobs SET last_run_at = $3, last_duration_ms = $4, last_error = $5,
+		        next_run_at = $6, locked_by = NULL, locked_until = NULL, updated_at = $3
+		 WHERE name = $1 AND locked_by = $2`,
+		name, owner, time.Unix(ranAt, 0), durationMillis, nullString(lastError), time.Unix(nextRunAt, 0),
+	)
+	return err
+}
+
+func (r *JobRepository) FindByName(name string) (*job.Job, error) {
+	j, err := scanJob(r.DB.QueryRow(`SELECT `+jobColumns+` FROM scheduled_jobs WHERE name = $1`, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return j, err
+}
+
+func (r *JobRepository) List() ([]*job.Job, error) {
+	rows, err := r.DB.Query(`SELECT ` + jobColumns + ` FROM scheduled_jobs ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*job.Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *JobRepository) SetPaused(name string, paused bool) error {
+	_, err := r.DB.Exec(`UPDATE scheduled_jobs SET paused = $2, updated_at = $3 WHERE name = $1`, name, paused, time.Now())
+	return err
+}
+
+func (r *JobRepository) SetNextRun(name string, at int64) error {
+	_, err := r.DB.Exec(`UPDATE scheduled_jobs SET next_run_at = $2, updated_at = $3 WHERE name = $1`, name, time.Unix(at, 0), time.Now())
+	return err
+}
@@ -0,0 +1,281 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/message"
+
+	"github.com/lib/pq"
+)
+
+// MessageRepository implements conversation and message data access using PostgreSQL.
+type MessageRepository struct {
+	DB *sql.DB
+}
+
+func NewMessageRepository(db *sql.DB) *MessageRepository {
+	return &MessageRepository{DB: db}
+}
+
+const conversationColumns = `c.id, COALESCE(c.title, ''), c.is_group, COALESCE(c.created_by::text, ''), c.created_at, c.updated_at`
+
+func scanConversation(row interface{ Scan(...interface{}) error }) (*message.Conversation, error) {
+	var c message.Conversation
+	var createdAt, updatedAt time.Time
+	if err := row.Scan(&c.ID, &c.Title, &c.Group, &c.CreatedBy, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	c.CreatedAt = createdAt.Unix()
+	c.UpdatedAt = updatedAt.Unix()
+	return &c, nil
+}
+
+const messageColumns = `id, conversation_id, sender_id, content, seq, created_at`
+
+func scanMessage(row interface{ Scan(...interface{}) error }) (*message.Message, error) {
+	var m message.Message
+	var sentAt time.Time
+	if err := row.Scan(&m.ID, &m.ConversationID, &m.SenderID, &m.Content, &m.Seq, &sentAt); err != nil {
+		return nil, err
+	}
+	m.SentAt = sentAt.Unix()
+	return &m, nil
+}
+
+const attachmentColumns = `id, message_id, conversation_id, file_name, content_type, size, storage_key`
+
+func scanAttachment(row interface{ Scan(...interface{}) error }) (*message.Attachment, error) {
+	var a message.Attachment
+	if err := row.Scan(&a.ID, &a.MessageID, &a.ConversationID, &a.FileName, &a.ContentType, &a.Size, &a.StorageKey); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// CreateConversation inserts the conversation and its participants in one
+// transaction. The unique direct key makes a concurrent duplicate fail.
+func (r *MessageRepository) CreateConversation(c *message.Conversation, directKey string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO conversations (id, title, is_group, direct_key, created_by, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		c.ID, nullString(c.Title), c.Group, nullString(directKey), nullString(c.CreatedBy), time.Unix(c.CreatedAt, 0), time.Unix(c.UpdatedAt, 0),
+	); err != nil {
+		return err
+	}
+	for _, p := range c.Participants {
+		if _, err := tx.Exec(
+			`INSERT INTO conversation_participants (conversation_id, user_id, joined_at) VALUES ($1, $2, $3)`,
+			c.ID, p.UserID, time.Unix(p.JoinedAt, 0),
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *MessageRepository) FindConversation(id string) (*message.Conversation, error) {
+	c, err := scanConversation(r.DB.QueryRow(`SELECT `+conversationColumns+` FROM conversations c WHERE c.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, r.loadParticipants(c)
+}
+
+func (r *MessageRepository) FindDirect(directKey string) (*message.Conversation, error) {
+	c, err := scanConversation(r.DB.QueryRow(`SELECT `+conversationColumns+` FROM conversations c WHERE c.direct_key = $1`, directKey))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, r.loadParticipants(c)
+}
+
+func (r *MessageRepository) ListConversations(userID string) ([]*message.Conversation, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+conversationColumns+`,
+		        (SELECT COUNT(*) FROM messages m WHERE m.conversation_id = c.id AND m.seq > p.last_read_seq)
+		 FROM conversations c
+		 JOIN conversation_participants p ON p.conversation_id = c.id AND p.user_id = $1
+		 ORDER BY c.updated_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conversations []*message.Conversation
+	for rows.Next() {
+		var unread int
+		c, err := scanConversation(withExtraColumns{rows, []interface{}{&unread}})
+		if err != nil {
+			return nil, err
+		}
+		c.Unread = unread
+		conversations = append(conversations, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, c := range conversations {
+		if err := r.loadParticipants(c); err != nil {
+			return nil, err
+		}
+		latest, err := r.ListMessages(c.ID, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(latest) > 0 {
+			c.LastMessage = latest[0]
+		}
+	}
+	return conversations, nil
+}
+
+// CreateMessage inserts the message with its attachments and moves the
+// conversation's activity time.
+func (r *MessageRepository) CreateMessage(m *message.Message) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(
+		`INSERT INTO messages (id, conversation_id, sender_id, content, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING seq`,
+		m.ID, m.ConversationID, m.SenderID, m.Content, time.Unix(m.SentAt, 0),
+	).Scan(&m.Seq); err != nil {
+		return err
+	}
+	for _, a := range m.Attachments {
+		if _, err := tx.Exec(
+			`INSERT INTO message_attachments (id, message_id, conversation_id, file_name, content_type, size, storage_key, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			a.ID, m.ID, m.ConversationID, a.FileName, a.ContentType, a.Size, a.StorageKey, time.Unix(m.SentAt, 0),
+		); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE conversations SET updated_at = $2 WHERE id = $1`, m.ConversationID, time.Unix(m.SentAt, 0)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *MessageRepository) ListMessages(conversationID string, before int64, limit int) ([]*message.Message, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+messageColumns+` FROM messages
+		 WHERE conversation_id = $1 AND ($2 = 0 OR seq < $2) ORDER BY seq DESC LIMIT $3`,
+		conversationID, before, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*message.Message
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, r.loadAttachments(messages)
+}
+
+func (r *MessageRepository) FindAttachment(id string) (*message.Attachment, error) {
+	a, err := scanAttachment(r.DB.QueryRow(`SELECT `+attachmentColumns+` FROM message_attachments WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return a, err
+}
+
+// loadAttachments fills in the attachments of a page of messages.
+func (r *MessageRepository) loadAttachments(messages []*message.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	byID := map[string]*message.Message{}
+	ids := make([]string, 0, len(messages))
+	for _, m := range messages {
+		byID[m.ID] = m
+		ids = append(ids, m.ID)
+	}
+	rows, err := r.DB.Query(
+		`SELECT `+attachmentColumns+` FROM message_attachments
+		 WHERE message_id = ANY($1::uuid[]) ORDER BY created_at, file_name`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return err
+		}
+		if m := byID[a.MessageID]; m != nil {
+			m.Attachments = append(m.Attachments, a)
+		}
+	}
+	return rows.Err()
+}
+
+func (r *MessageRepository) UpdateReadCursor(conversationID, userID string, seq, readAt int64) error {
+	_, err := r.DB.Exec(
+		`UPDATE conversation_participants SET last_read_seq = $3, last_read_at = $4
+		 WHERE conversation_id = $1 AND user_id = $2 AND last_read_seq < $3`,
+		conversationID, userID, seq, time.Unix(readAt, 0),
+	)
+	return err
+}
+
+func (r *MessageRepository) RemoveParticipant(conversationID, userID string) error {
+	_, err := r.DB.Exec(`DELETE FROM conversation_participants WHERE conversation_id = $1 AND user_id = $2`, conversationID, userID)
+	return err
+}
+
+func (r *MessageRepository) loadParticipants(c *message.Conversation) error {
+	rows, err := r.DB.Query(
+		`SELECT user_id, joined_at, last_read_seq, last_read_at FROM conversation_participants
+		 WHERE conversation_id = $1 ORDER BY joined_at, user_id`,
+		c.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	c.Participants = nil
+	for rows.Next() {
+		p := message.Participant{ConversationID: c.ID}
+		var joinedAt time.Time
+		var lastReadAt sql.NullTime
+		if err := rows.Scan(&p.UserID, &joinedAt, &p.LastReadSeq, &lastReadAt); err != nil {
+			return err
+		}
+		p.JoinedAt = joinedAt.Unix()
+		p.LastReadAt = unixOrZero(lastReadAt)
+		c.Participants = append(c.Participants, &p)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/notification"
+)
+
+// NotificationRepository implements notification data access using PostgreSQL.
+type NotificationRepository struct {
+	DB *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{DB: db}
+}
+
+const notificationColumns = `id, user_id, type, event, COALESCE(title, ''), message, status, created_at, read_at`
+
+func scanNotification(row interface{ Scan(...interface{}) error }) (*notification.Notification, error) {
+	var n notification.Notification
+	var createdAt time.Time
+	var readAt sql.NullTime
+	if err := row.Scan(&n.ID, &n.UserID, &n.Type, &n.Event, &n.Title, &n.Message, &n.Status, &createdAt, &readAt); err != nil {
+		return nil, err
+	}
+	n.CreatedAt = createdAt.Unix()
+	if readAt.Valid {
+		t := readAt.Time.Unix()
+		n.ReadAt = &t
+	}
+	return &n, nil
+}
+
+const deliveryColumns = `id, notification_id, user_id, channel, digest, status, attempts, COALESCE(last_error, ''), next_attempt_at, sent_at, created_at`
+
+func scanDelivery(row interface{ Scan(...interface{}) error }) (*notification.Delivery, error) {
+	var d notification.Delivery
+	var nextAttemptAt, createdAt time.Time
+	var sentAt sql.NullTime
+	if err := row.Scan(&d.ID, &d.NotificationID, &d.UserID, &d.Channel, &d.Digest, &d.Status, &d.Attempts, &d.LastError, &nextAttemptAt, &sentAt, &createdAt); err != nil {
+		return nil, err
+	}
+	d.NextAttemptAt = nextAttemptAt.Unix()
+	d.SentAt = unixOrZero(sentAt)
+	d.CreatedAt = createdAt.Unix()
+	return &d, nil
+}
+
+// Create inserts the notification and its deliveries in one transaction, so a
+// delivery is queued if and only if the notification exists.
+func (r *NotificationRepository) Create(n *notification.Notification, deliveries []*notification.Delivery) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`INSERT INTO notifications (id, user_id, type, event, title, message, status, read, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		n.ID, n.UserID, n.Type, n.Event, n.Title, n.Message, n.Status, n.Status == notification.StatusRead, time.Unix(n.CreatedAt, 0),
+	)
+	if err != nil {
+		return err
+	}
+	for _, d := range deliveries {
+		_, err := tx.Exec(
+			`INSERT INTO notification_deliveries (id, notification_id, user_id, channel, digest, status, attempts, next_attempt_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			d.ID, d.NotificationID, d.UserID, d.Channel, d.Digest, d.Status, d.Attempts, time.Unix(d.NextAttemptAt, 0), time.Unix(d.CreatedAt, 0),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *NotificationRepository) FindByID(id string) (*notification.Notification, error) {
+	n, err := scanNotification(r.DB.QueryRow(`SELECT `+notificationColumns+` FROM notifications WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return n, nil
+}
+
+func (r *NotificationRepository) ListByUser(userID string, unreadOnly bool) ([]*notification.Notification, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+notificationColumns+` FROM notifications WHERE user_id = $1 AND type = 'in_app' AND (NOT $2 OR status = 'unread') ORDER BY created_at DESC`,
+		userID, unreadOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*notification.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *NotificationRepository) MarkRead(id string, readAt int64) error {
+	_, err := r.DB.Exec(`UPDATE notifications SET status = 'read', read = TRUE, read_at = $1 WHERE id = $2`, time.Unix(readAt, 0), id)
+	return err
+}
+
+func (r *NotificationRepository) ListDeliveries(notificationID string) ([]*notification.Delivery, error) {
+	return r.listDeliveries(`SELECT `+deliveryColumns+` FROM notification_deliveries WHERE notification_id = $1 ORDER BY created_at ASC`, notificationID)
+}
+
+// ClaimDue leases due pending deliveries. SKIP LOCKED lets several dispatchers
+// share the outbox without claiming the same delivery.
+func (r *NotificationRepository) ClaimDue(now, leaseUntil int64, limit int) ([]*notification.Delivery, error) {
+	return r.listDeliveries(
+		`UPDATE notification_deliveries SET next_attempt_at = $2
+		 WHERE id IN (
+		     SELECT id FROM notification_deliveries
+		     WHERE status = 'pending' AND next_attempt_at <= $1
+		     ORDER BY next_attempt_at
+		     LIMIT $3
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+deliveryColumns,
+		time.Unix(now, 0), time.Unix(leaseUntil, 0), limit,
+	)
+}
+
+func (r *NotificationRepository) UpdateDelivery(d *notification.Delivery) error {
+	_, err := r.DB.Exec(
+		`UPDATE notification_deliveries SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4, sent_at = $5 WHERE id = $6`,
+		d.Status, d.Attempts, nullString(d.LastError), time.Unix(d.NextAttemptAt, 0), nullTime(d.SentAt), d.ID,
+	)
+	return err
+}
+
+func (r *NotificationRepository) listDeliveries(query string, args ...interface{}) ([]*notification.Delivery, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*notification.Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
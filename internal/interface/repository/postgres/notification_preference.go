@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/notification"
+)
+
+// NotificationTemplateRepository implements notification template data access using PostgreSQL.
+type NotificationTemplateRepository struct {
+	DB *sql.DB
+}
+
+func NewNotificationTemplateRepository(db *sql.DB) *NotificationTemplateRepository {
+	return &NotificationTemplateRepository{DB: db}
+}
+
+const notificationTemplateColumns = `id, event, locale, subject, body, updated_at`
+
+func scanNotificationTemplate(row interface{ Scan(...interface{}) error }) (*notification.Template, error) {
+	var t notification.Template
+	var updatedAt time.Time
+	if err := row.Scan(&t.ID, &t.Event, &t.Locale, &t.Subject, &t.Body, &updatedAt); err != nil {
+		return nil, err
+	}
+	t.UpdatedAt = updatedAt.Unix()
+	return &t, nil
+}
+
+func (r *NotificationTemplateRepository) Save(t *notification.Template) error {
+	_, err := r.DB.Exec(
+		`INSERT INTO notification_templates (id, event, locale, subject, body, updated_at) VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (event, locale) DO UPDATE SET subject = EXCLUDED.subject, body = EXCLUDED.body, updated_at = EXCLUDED.updated_at`,
+		t.ID, t.Event, t.Locale, t.Subject, t.Body, time.Unix(t.UpdatedAt, 0),
+	)
+	return err
+}
+
+func (r *NotificationTemplateRepository) Delete(event notification.EventType, locale string) error {
+	_, err := r.DB.Exec(`DELETE FROM notification_templates WHERE event = $1 AND locale = $2`, event, locale)
+	return err
+}
+
+func (r *NotificationTemplateRepository) Find(event notification.EventType, locale string) (*notification.Template, error) {
+	t, err := scanNotificationTemplate(r.DB.QueryRow(`SELECT `+notificationTemplateColumns+` FROM notification_templates WHERE event = $1 AND locale = $2`, event, locale))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *NotificationTemplateRepository) List() ([]*notification.Template, error) {
+	rows, err := r.DB.Query(`SELECT ` + notificationTemplateColumns + ` FROM notification_templates ORDER BY event, locale`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*notification.Template
+	for rows.Next() {
+		t, err := scanNotificationTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+// NotificationPreferenceRepository implements notification settings data access using PostgreSQL.
+type NotificationPreferenceRepository struct {
+	DB *sql.DB
+}
+
+func NewNotificationPreferenceRepository(db *sql.DB) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{DB: db}
+}
+
+func (r *NotificationPreferenceRepository) FindSettings(userID string) (*notification.Settings, error) {
+	s := notification.Settings{UserID: userID}
+	err := r.DB.QueryRow(
+		`SELECT COALESCE(locale, ''), COALESCE(time_zone, ''), quiet_start, quiet_end FROM notification_settings WHERE user_id = $1`,
+		userID,
+	).Scan(&s.Locale, &s.TimeZone, &s.QuietStart, &s.QuietEnd)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(`SELECT event, channels, digest FROM notification_preferences WHERE user_id = $1 ORDER BY event`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p notification.Preference
+		var channels string
+		if err := rows.Scan(&p.Event, &channels, &p.Digest); err != nil {
+			return nil, err
+		}
+		for _, c := range strings.Split(channels, ",") {
+			if c != "" {
+				p.Channels = append(p.Channels, notification.NotificationType(c))
+			}
+		}
+		s.Preferences = append(s.Preferences, p)
+	}
+	return &s, rows.Err()
+}
+
+// SaveSettings replaces the user's settings and preferences in one transaction.
+func (r *NotificationPreferenceRepository) SaveSettings(s *notification.Settings) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`INSERT INTO notification_settings (user_id, locale, time_zone, quiet_start, quiet_end) VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (user_id) DO UPDATE SET locale = EXCLUDED.locale, time_zone = EXCLUDED.time_zone, quiet_start = EXCLUDED.quiet_start, quiet_end = EXCLUDED.quiet_end`,
+		s.UserID, nullString(s.Locale), nullString(s.TimeZone), s.QuietStart, s.QuietEnd,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM notification_preferences WHERE user_id = $1`, s.UserID); err != nil {
+		return err
+	}
+	for _, p := range s.Preferences {
+		channels := make([]string, len(p.Channels))
+		for i, c := range p.Channels {
+			channels[i] = string(c)
+		}
+		_, err := tx.Exec(
+			`INSERT INTO notification_preferences (user_id, event, channels, digest) VALUES ($1, $2, $3, $4)`,
+			s.UserID, p.Event, strings.Join(channels, ","), p.Digest,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
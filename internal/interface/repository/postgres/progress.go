@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProgressRepository records learners' course progress in the progress
+// table, which the compliance and analytics reports read.
+type ProgressRepository struct {
+	DB *sql.DB
+}
+
+func NewProgressRepository(db *sql.DB) *ProgressRepository {
+	return &ProgressRepository{DB: db}
+}
+
+// CompleteModule adds the module to the learner's completed modules in the
+// course and reports whether it was new.
+func (r *ProgressRepository) CompleteModule(userID, courseID, moduleID string, at int64) (bool, error) {
+	var added bool
+	err := r.withProgress(userID, courseID, func(tx *sql.Tx, id string) error {
+		res, err := tx.Exec(
+			`UPDATE progress SET completed_modules = array_append(COALESCE(completed_modules, '{}'), $2::uuid), updated_at = $3
+			 WHERE id = $1 AND NOT ($2::uuid = ANY(COALESCE(completed_modules, '{}')))`,
+			id, moduleID, time.Unix(at, 0),
+		)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		added = n > 0
+		return err
+	})
+	return added, err
+}
+
+// RecordQuizScore keeps the learner's best score for the quiz.
+func (r *ProgressRepository) RecordQuizScore(userID, courseID, quizID string, score int, at int64) error {
+	return r.withProgress(userID, courseID, func(tx *sql.Tx, id string) error {
+		_, err := tx.Exec(
+			`UPDATE progress
+			 SET completed_quizzes = CASE WHEN jsonb_typeof(completed_quizzes) = 'object' THEN completed_quizzes ELSE '{}'::jsonb END
+			                         || jsonb_build_object($2::text, $3::int),
+			     updated_at = $4
+			 WHERE id = $1 AND COALESCE((completed_quizzes ->> $2::text)::numeric < $3, TRUE)`,
+			id, quizID, score, time.Unix(at, 0),
+		)
+		return err
+	})
+}
+
+// CourseScore returns the average of the learner's best quiz scores in the
+// course, or 0 if no quiz was scored.
+func (r *ProgressRepository) CourseScore(userID, courseID string) (int, error) {
+	var score int
+	err := r.DB.QueryRow(
+		`SELECT COALESCE(ROUND(AVG(q.value::numeric)), 0)::int
+		 FROM progress p, jsonb_each_text(CASE WHEN jsonb_typeof(p.completed_quizzes) = 'object' THEN p.completed_quizzes ELSE '{}'::jsonb END) q
+		 WHERE p.user_id = $1 AND p.course_id = $2`,
+		userID, courseID,
+	).Scan(&score)
+	return score, err
+}
+
+// withProgress runs fn in a transaction with the ID of the learner's progress
+// row for the course, creating it if needed. An advisory lock keeps
+// concurrent updates from creating two rows.
+func (r *ProgressRepository) withProgress(userID, courseID string, fn func(tx *sql.Tx, id string) error) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('progress:' || $1 || ':' || $2))`, userID, courseID); err != nil {
+		return err
+	}
+	var id string
+	err = tx.QueryRow(
+		`SELECT id FROM progress WHERE user_id = $1 AND course_id = $2 ORDER BY updated_at DESC LIMIT 1`,
+		userID, courseID,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRow(
+			`INSERT INTO progress (id, user_id, course_id, completed_modules, completed_quizzes)
+			 VALUES ($1, $2, $3, '{}', '{}') RETURNING id`,
+			uuid.New().String(), userID, courseID,
+		).Scan(&id)
+	}
+	if err != nil {
+		return err
+	}
+	if err := fn(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// QuizCourse returns the course of a quiz's module, or "" if there is no
+// such quiz.
+func (r *ProgressRepository) QuizCourse(quizID string) (string, error) {
+	var courseID string
+	err := r.DB.QueryRow(
+		`SELECT m.course_id FROM quizzes q JOIN modules m ON m.id = q.module_id WHERE q.id::text = $1`, quizID,
+	).Scan(&courseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return courseID, err
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"training-portal/internal/domain/realtime"
+
+	"github.com/lib/pq"
+)
+
+// realtimeChannel is the NOTIFY channel the realtime_events trigger publishes
+// user IDs on.
+const realtimeChannel = "realtime_events"
+
+// RealtimeEventRepository implements realtime event storage using PostgreSQL.
+type RealtimeEventRepository struct {
+	DB *sql.DB
+}
+
+func NewRealtimeEventRepository(db *sql.DB) *RealtimeEventRepository {
+	return &RealtimeEventRepository{DB: db}
+}
+
+// Append inserts the event; the table's trigger notifies listeners on commit.
+// A user-scoped advisory lock serialises appends for the user, so their events
+// commit in ID order and a replay cursor never skips one that commits late.
+func (r *RealtimeEventRepository) Append(e *realtime.Event) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, e.UserID); err != nil {
+		return err
+	}
+	if err := tx.QueryRow(
+		`INSERT INTO realtime_events (user_id, type, data, created_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+		e.UserID, e.Type, []byte(e.Data), time.Unix(e.CreatedAt, 0),
+	).Scan(&e.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *RealtimeEventRepository) ListAfter(userID string, after int64, limit int) ([]*realtime.Event, error) {
+	rows, err := r.DB.Query(
+		`SELECT id, user_id, type, data, created_at FROM realtime_events
+		 WHERE user_id = $1 AND id > $2 ORDER BY id LIMIT $3`,
+		userID, after, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*realtime.Event
+	for rows.Next() {
+		var e realtime.Event
+		var data []byte
+		var createdAt time.Time
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Type, &data, &createdAt); err != nil {
+			return nil, err
+		}
+		e.Data, e.CreatedAt = data, createdAt.Unix()
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+func (r *RealtimeEventRepository) LastID(userID string) (int64, error) {
+	var id int64
+	err := r.DB.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM realtime_events WHERE user_id = $1`, userID).Scan(&id)
+	return id, err
+}
+
+func (r *RealtimeEventRepository) DeleteBefore(before int64) (int64, error) {
+	res, err := r.DB.Exec(`DELETE FROM realtime_events WHERE created_at < $1`, time.Unix(before, 0))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RealtimeListener receives realtime event notifications from every server
+// instance through Postgres LISTEN.
+type RealtimeListener struct {
+	DSN string
+}
+
+func NewRealtimeListener(dsn string) *RealtimeListener {
+	return &RealtimeListener{DSN: dsn}
+}
+
+// Run calls wake with the user ID of each stored event, and resync after
+// reconnecting since notifications sent in the meantime are lost. It returns
+// when ctx is cancelled.
+func (l *RealtimeListener) Run(ctx context.Context, wake func(userID string), resync func()) {
+	listener := pq.NewListener(l.DSN, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("realtime: listener: %v", err)
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(realtimeChannel); err != nil {
+		log.Printf("realtime: listen failed: %v", err)
+		return
+	}
+
+	ping := time.NewTicker(time.Minute)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			if n == nil { // reconnected
+				resync()
+				continue
+			}
+			wake(n.Extra)
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"training-portal/internal/domain/enrollment"
+)
+
+// ReminderRepository implements reminder bookkeeping using PostgreSQL.
+type ReminderRepository struct {
+	DB *sql.DB
+}
+
+func NewReminderRepository(db *sql.DB) *ReminderRepository {
+	return &ReminderRepository{DB: db}
+}
+
+// ListCandidates returns active enrollments. An enrollment became active at
+// its latest transition to active, or when it was created if it has no history.
+func (r *ReminderRepository) ListCandidates() ([]*enrollment.ReminderCandidate, error) {
+	rows, err := r.DB.Query(
+		`SELECT `+enrollmentColumns+`,
+		        COALESCE((SELECT MAX(h.changed_at) FROM enrollment_history h
+		                  WHERE h.enrollment_id = enrollments.id AND h.to_status = $1), enrolled_at),
+		        (SELECT MAX(p.updated_at) FROM progress p
+		         WHERE p.user_id = enrollments.user_id AND p.course_id = enrollments.course_id)
+		 FROM enrollments WHERE status = $1`,
+		enrollment.StatusActive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []*enrollment.ReminderCandidate
+	for rows.Next() {
+		var activeSince time.Time
+		var lastActivity sql.NullTime
+		e, err := scanEnrollment(withExtraColumns{rows, []interface{}{&activeSince, &lastActivity}})
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, &enrollment.ReminderCandidate{
+			Enrollment:     e,
+			ActiveSince:    activeSince.Unix(),
+			LastActivityAt: unixOrZero(lastActivity),
+		})
+	}
+	return candidates, rows.Err()
+}
+
+// MarkSent relies on the primary key to record each step once per anchor.
+func (r *ReminderRepository) MarkSent(enrollmentID, step string, anchorAt, sentAt int64) (bool, error) {
+	res, err := r.DB.Exec(
+		`INSERT INTO enrollment_reminders (enrollment_id, step, anchor_at, sent_at) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT DO NOTHING`,
+		enrollmentID, step, time.Unix(anchorAt, 0), time.Unix(sentAt, 0),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// withExtraColumns lets a scanXxx function read a row that has additional
+// columns after the ones it knows about.
+type withExtraColumns struct {
+	row   interface{ Scan(...interface{}) error }
+	extra []interface{}
+}
+
+func (w withExtraColumns) Scan(dest ...interface{}) error {
+	return w.row.Scan(append(dest, w.extra...)...)
+}
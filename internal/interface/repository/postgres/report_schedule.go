@@ -0,0 +1,208 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/compliance"
+
+	"github.com/lib/pq"
+)
+
+// ReportScheduleRepository implements report schedules and their run history
+// using PostgreSQL.
+type ReportScheduleRepository struct {
+	DB *sql.DB
+}
+
+func NewReportScheduleRepository(db *sql.DB) *ReportScheduleRepository {
+	return &ReportScheduleRepository{DB: db}
+}
+
+const scheduleColumns = `id, name, kind, filter, format, cron, timezone, delivery, recipients::text[],
+	COALESCE(owner_id::text, ''), enabled, next_run_at, last_run_at, created_at, updated_at`
+
+func (r *ReportScheduleRepository) CreateSchedule(s *compliance.Schedule) error {
+	filter, err := json.Marshal(s.Filter)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Exec(
+		`INSERT INTO report_schedules (id, name, kind, filter, format, cron, timezone, delivery, recipients, owner_id,
+		                               enabled, next_run_at, last_run_at, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		s.ID, s.Name, s.Kind, filter, s.Format, s.Cron, s.Timezone, s.Delivery, pq.Array(s.Recipients), nullString(s.OwnerID),
+		s.Enabled, nullTime(s.NextRunAt), nullTime(s.LastRunAt), time.Unix(s.CreatedAt, 0), time.Unix(s.UpdatedAt, 0),
+	)
+	return err
+}
+
+func (r *ReportScheduleRepository) UpdateSchedule(s *compliance.Schedule) error {
+	filter, err := json.Marshal(s.Filter)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Exec(
+		`UPDATE report_schedules SET name = $2, kind = $3, filter = $4, format = $5, cron = $6, timezone = $7, delivery = $8,
+		        recipients = $9, enabled = $10, next_run_at = $11, last_run_at = $12, updated_at = $13
+		 WHERE id = $1`,
+		s.ID, s.Name, s.Kind, filter, s.Format, s.Cron, s.Timezone, s.Delivery,
+		pq.Array(s.Recipients), s.Enabled, nullTime(s.NextRunAt), nullTime(s.LastRunAt), time.Unix(s.UpdatedAt, 0),
+	)
+	return err
+}
+
+func (r *ReportScheduleRepository) DeleteSchedule(id string) error {
+	_, err := r.DB.Exec(`DELETE FROM report_schedules WHERE id = $1`, id)
+	return err
+}
+
+func (r *ReportScheduleRepository) FindSchedule(id string) (*compliance.Schedule, error) {
+	s, err := scanSchedule(r.DB.QueryRow(`SELECT `+scheduleColumns+` FROM report_schedules WHERE id::text = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+func (r *ReportScheduleRepository) ListSchedules(ownerID string) ([]*compliance.Schedule, error) {
+	return r.listSchedules(
+		`SELECT `+scheduleColumns+` FROM report_schedules
+		 WHERE ($1 = '' OR owner_id::text = $1) ORDER BY name, created_at`,
+		ownerID,
+	)
+}
+
+func (r *ReportScheduleRepository) ListDueSchedules(now int64) ([]*compliance.Schedule, error) {
+	return r.listSchedules(
+		`SELECT `+scheduleColumns+` FROM report_schedules
+		 WHERE enabled AND next_run_at <= $1 ORDER BY next_run_at`,
+		time.Unix(now, 0),
+	)
+}
+
+func (r *ReportScheduleRepository) listSchedules(query string, args ...interface{}) ([]*compliance.Schedule, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*compliance.Schedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func scanSchedule(row interface{ Scan(...interface{}) error }) (*compliance.Schedule, error) {
+	var s compliance.Schedule
+	var filter []byte
+	var nextRunAt, lastRunAt sql.NullTime
+	var createdAt, updatedAt time.Time
+	if err := row.Scan(&s.ID, &s.Name, &s.Kind, &filter, &s.Format, &s.Cron, &s.Timezone, &s.Delivery, pq.Array(&s.Recipients),
+		&s.OwnerID, &s.Enabled, &nextRunAt, &lastRunAt, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(filter, &s.Filter); err != nil {
+		return nil, err
+	}
+	s.NextRunAt = unixOrZero(nextRunAt)
+	s.LastRunAt = unixOrZero(lastRunAt)
+	s.CreatedAt = createdAt.Unix()
+	s.UpdatedAt = updatedAt.Unix()
+	return &s, nil
+}
+
+const runColumns = `id, schedule_id, schedule_name, status, error, recipients, file_name, file_key, expires_at, started_at, finished_at`
+
+func (r *ReportScheduleRepository) CreateRun(run *compliance.Run) error {
+	_, err := r.DB.Exec(
+		`INSERT INTO report_runs (`+runColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		run.ID, run.ScheduleID, run.ScheduleName, run.Status, run.Error, run.Recipients, run.FileName, run.FileKey,
+		nullTime(run.ExpiresAt), time.Unix(run.StartedAt, 0), time.Unix(run.FinishedAt, 0),
+	)
+	return err
+}
+
+func (r *ReportScheduleRepository) FindRun(id string) (*compliance.Run, error) {
+	run, err := scanRun(r.DB.QueryRow(`SELECT `+runColumns+` FROM report_runs WHERE id::text = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return run, err
+}
+
+func (r *ReportScheduleRepository) ListRuns(filter compliance.RunFilter) ([]*compliance.Run, error) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, strings.Replace(cond, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+	if filter.ScheduleID != "" {
+		add("schedule_id::text = ?", filter.ScheduleID)
+	}
+	if filter.Status != "" {
+		add("status = ?", filter.Status)
+	}
+	query := `SELECT ` + runColumns + ` FROM report_runs`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += " ORDER BY started_at DESC, id LIMIT $" + strconv.Itoa(len(args))
+	return r.listRuns(query, args...)
+}
+
+func (r *ReportScheduleRepository) ListExpiredFiles(now int64) ([]*compliance.Run, error) {
+	return r.listRuns(
+		`SELECT `+runColumns+` FROM report_runs WHERE file_key <> '' AND expires_at < $1`,
+		time.Unix(now, 0),
+	)
+}
+
+func (r *ReportScheduleRepository) ClearFile(runID string) error {
+	_, err := r.DB.Exec(`UPDATE report_runs SET file_key = '' WHERE id = $1`, runID)
+	return err
+}
+
+func (r *ReportScheduleRepository) listRuns(query string, args ...interface{}) ([]*compliance.Run, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*compliance.Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, run)
+	}
+	return out, rows.Err()
+}
+
+func scanRun(row interface{ Scan(...interface{}) error }) (*compliance.Run, error) {
+	var run compliance.Run
+	var expiresAt sql.NullTime
+	var startedAt, finishedAt time.Time
+	if err := row.Scan(&run.ID, &run.ScheduleID, &run.ScheduleName, &run.Status, &run.Error, &run.Recipients,
+		&run.FileName, &run.FileKey, &expiresAt, &startedAt, &finishedAt); err != nil {
+		return nil, err
+	}
+	run.ExpiresAt = unixOrZero(expiresAt)
+	run.StartedAt = startedAt.Unix()
+	run.FinishedAt = finishedAt.Unix()
+	return &run, nil
+}
@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"training-portal/internal/domain/scorm"
+
+	"github.com/lib/pq"
+)
+
+// SCORMRepository implements SCORM package and attempt persistence using
+// PostgreSQL.
+type SCORMRepository struct {
+	DB *sql.DB
+}
+
+func NewSCORMRepository(db *sql.DB) *SCORMRepository {
+	return &SCORMRepository{DB: db}
+}
+
+const packageColumns = `id, course_id, title, identifier, version, content_prefix, files, COALESCE(uploaded_by::text, ''), created_at`
+
+const scoColumns = `id, package_id, course_id, module_id, identifier, title, launch_path, asset, passing_score,
+	completion_threshold, launch_data, order_index`
+
+func (r *SCORMRepository) CreatePackage(p *scorm.Package, scos []*scorm.SCO) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO scorm_packages (id, course_id, title, identifier, version, content_prefix, files, uploaded_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		p.ID, p.CourseID, p.Title, p.Identifier, string(p.Version), p.ContentPrefix, pq.Array(p.Files),
+		nullString(p.UploadedBy), time.Unix(p.CreatedAt, 0),
+	); err != nil {
+		return err
+	}
+	for _, sco := range scos {
+		if _, err := tx.Exec(
+			`INSERT INTO scorm_scos (`+scoColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			sco.ID, sco.PackageID, sco.CourseID, sco.ModuleID, sco.Identifier, sco.Title, sco.LaunchPath, sco.Asset,
+			sco.PassingScore, sco.CompletionThreshold, sco.LaunchData, sco.OrderIndex,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SCORMRepository) FindPackage(id string) (*scorm.Package, error) {
+	p, err := scanPackage(r.DB.QueryRow(`SELECT `+packageColumns+` FROM scorm_packages WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return p, err
+}
+
+func (r *SCORMRepository) ListPackages(courseID string) ([]*scorm.Package, error) {
+	rows, err := r.DB.Query(`SELECT `+packageColumns+` FROM scorm_packages WHERE course_id = $1 ORDER BY created_at`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*scorm.Package
+	for rows.Next() {
+		p, err := scanPackage(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (r *SCORMRepository) FindSCO(id string) (*scorm.SCO, error) {
+	sco, err := scanSCO(r.DB.QueryRow(`SELECT `+scoColumns+` FROM scorm_scos WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return sco, err
+}
+
+func (r *SCORMRepository) ListSCOs(packageID string) ([]*scorm.SCO, error) {
+	rows, err := r.DB.Query(`SELECT `+scoColumns+` FROM scorm_scos WHERE package_id = $1 ORDER BY order_index`, packageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*scorm.SCO
+	for rows.Next() {
+		sco, err := scanSCO(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sco)
+	}
+	return out, rows.Err()
+}
+
+func (r *SCORMRepository) FindAttempt(userID, scoID string) (*scorm.Attempt, error) {
+	a := scorm.Attempt{UserID: userID, SCOID: scoID}
+	var version string
+	var extra []byte
+	var updatedAt time.Time
+	err := r.DB.QueryRow(
+		`SELECT version, lesson_status, completion_status, success_status, score_raw, score_min, score_max, score_scaled,
+		        progress_measure, location, suspend_data, entry, exit_reason, session_time, total_seconds, sessions, extra, updated_at
+		 FROM scorm_attempts WHERE user_id = $1 AND sco_id = $2`,
+		userID, scoID,
+	).Scan(&version, &a.LessonStatus, &a.CompletionStatus, &a.SuccessStatus, &a.ScoreRaw, &a.ScoreMin, &a.ScoreMax,
+		&a.ScoreScaled, &a.ProgressMeasure, &a.Location, &a.SuspendData, &a.Entry, &a.Exit, &a.SessionTime,
+		&a.TotalSeconds, &a.Sessions, &extra, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.Version = scorm.Version(version)
+	a.UpdatedAt = updatedAt.Unix()
+	if err := json.Unmarshal(extra, &a.Extra); err != nil {
+		return nil, err
+	}
+	if a.Extra == nil {
+		a.Extra = map[string]string{}
+	}
+	return &a, nil
+}
+
+func (r *SCORMRepository) SaveAttempt(a *scorm.Attempt) error {
+	extra, err := json.Marshal(a.Extra)
+	if err != nil {
+		return err
+	}
+	if a.Extra == nil {
+		extra = []byte("{}")
+	}
+	_, err = r.DB.Exec(
+		`INSERT INTO scorm_attempts (user_id, sco_id, version, lesson_status, completion_status, success_status, score_raw,
+		                             score_min, score_max, score_scaled, progress_measure, location, suspend_data, entry,
+		                             exit_reason, session_time, total_seconds, sessions, extra, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		 ON CONFLICT (user_id, sco_id) DO UPDATE SET
+		     version = EXCLUDED.version, lesson_status = EXCLUDED.lesson_status,
+		     completion_status = EXCLUDED.completion_status, success_status = EXCLUDED.success_status,
+		     score_raw = EXCLUDED.score_raw, score_min = EXCLUDED.score_min, score_max = EXCLUDED.score_max,
+		     score_scaled = EXCLUDED.score_scaled, progress_measure = EXCLUDED.progress_measure,
+		     location = EXCLUDED.location, suspend_data = EXCLUDED.suspend_data, entry = EXCLUDED.entry,
+		     exit_reason = EXCLUDED.exit_reason, session_time = EXCLUDED.session_time,
+		     total_seconds = EXCLUDED.total_seconds, sessions = EXCLUDED.sessions, extra = EXCLUDED.extra,
+		     updated_at = EXCLUDED.updated_at`,
+		a.UserID, a.SCOID, string(a.Version), a.LessonStatus, a.CompletionStatus, a.SuccessStatus, a.ScoreRaw,
+		a.ScoreMin, a.ScoreMax, a.ScoreScaled, a.ProgressMeasure, a.Location, a.SuspendData, a.Entry,
+		a.Exit, a.SessionTime, a.TotalSeconds, a.Sessions, extra, time.Unix(a.UpdatedAt, 0),
+	)
+	return err
+}
+
+func scanPackage(row interface{ Scan(...interface{}) error }) (*scorm.Package, error) {
+	var p scorm.Package
+	var version string
+	var createdAt time.Time
+	if err := row.Scan(&p.ID, &p.CourseID, &p.Title, &p.Identifier, &version, &p.ContentPrefix, pq.Array(&p.Files),
+		&p.UploadedBy, &createdAt); err != nil {
+		return nil, err
+	}
+	p.Version = scorm.Version(version)
+	p.CreatedAt = createdAt.Unix()
+	return &p, nil
+}
+
+func scanSCO(row interface{ Scan(...interface{}) error }) (*scorm.SCO, error) {
+	var sco scorm.SCO
+	if err := row.Scan(&sco.ID, &sco.PackageID, &sco.CourseID, &sco.ModuleID, &sco.Identifier, &sco.Title,
+		&sco.LaunchPath, &sco.Asset, &sco.PassingScore, &sco.CompletionThreshold, &sco.LaunchData, &sco.OrderIndex); err != nil {
+		return nil, err
+	}
+	return &sco, nil
+}
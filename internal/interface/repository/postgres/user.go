@@ -18,9 +18,9 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 func (r *UserRepository) FindByID(id string) (*user.User, error) {
 	var u user.User
 	err := r.DB.QueryRow(
-		`SELECT id, name, email, password, role FROM users WHERE id = $1`,
+		`SELECT id, name, email, password, role, COALESCE(department, ''), COALESCE(manager_id::text, '') FROM users WHERE id = $1`,
 		id,
-	).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.Role)
+	).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.Role, &u.Department, &u.ManagerID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -33,9 +33,9 @@ func (r *UserRepository) FindByID(id string) (*user.User, error) {
 func (r *UserRepository) FindByEmail(email string) (*user.User, error) {
 	var u user.User
 	err := r.DB.QueryRow(
-		`SELECT id, name, email, password, role FROM users WHERE email = $1`,
+		`SELECT id, name, email, password, role, COALESCE(department, ''), COALESCE(manager_id::text, '') FROM users WHERE email = $1`,
 		email,
-	).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.Role)
+	).Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.Role, &u.Department, &u.ManagerID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -45,18 +45,46 @@ func (r *UserRepository) FindByEmail(email string) (*user.User, error) {
 	return &u, nil
 }
 
+// FindByUsername matches the part of the email address before the @, and
+// returns nil unless exactly one user matches.
+func (r *UserRepository) FindByUsername(username string) (*user.User, error) {
+	rows, err := r.DB.Query(
+		`SELECT id, name, email, password, role, COALESCE(department, ''), COALESCE(manager_id::text, '') FROM users
+		 WHERE LOWER(split_part(email, '@', 1)) = LOWER($1)
+		 LIMIT 2`,
+		username,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []*user.User
+	for rows.Next() {
+		var u user.User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.Role, &u.Department, &u.ManagerID); err != nil {
+			return nil, err
+		}
+		found = append(found, &u)
+	}
+	if err := rows.Err(); err != nil || len(found) != 1 {
+		return nil, err
+	}
+	return found[0], nil
+}
+
 func (r *UserRepository) Create(u *user.User) error {
 	_, err := r.DB.Exec(
-		`INSERT INTO users (id, name, email, password, role) VALUES ($1, $2, $3, $4, $5)`,
-		u.ID, u.Name, u.Email, u.Password, u.Role,
+		`INSERT INTO users (id, name, email, password, role, department, manager_id) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		u.ID, u.Name, u.Email, u.Password, u.Role, u.Department, nullString(u.ManagerID),
 	)
 	return err
 }
 
 func (r *UserRepository) Update(u *user.User) error {
 	res, err := r.DB.Exec(
-		`UPDATE users SET name = $1, email = $2, password = $3, role = $4 WHERE id = $5`,
-		u.Name, u.Email, u.Password, u.Role, u.ID,
+		`UPDATE users SET name = $1, email = $2, password = $3, role = $4, department = $5, manager_id = $6 WHERE id = $7`,
+		u.Name, u.Email, u.Password, u.Role, u.Department, nullString(u.ManagerID), u.ID,
 	)
 	if err != nil {
 		return err
@@ -87,7 +115,7 @@ func (r *UserRepository) Delete(id string) error {
 }
 
 func (r *UserRepository) List() ([]*user.User, error) {
-	rows, err := r.DB.Query(`SELECT id, name, email, password, role FROM users`)
+	rows, err := r.DB.Query(`SELECT id, name, email, password, role, COALESCE(department, ''), COALESCE(manager_id::text, '') FROM users`)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +124,7 @@ func (r *UserRepository) List() ([]*user.User, error) {
 	var users []*user.User
 	for rows.Next() {
 		var u user.User
-		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.Role); err != nil {
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.Role, &u.Department, &u.ManagerID); err != nil {
 			return nil, err
 		}
 		users = append(users, &u)
@@ -0,0 +1,235 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/xapi"
+
+	"github.com/lib/pq"
+)
+
+// XAPIRepository implements the Learning Record Store's statements and
+// documents using PostgreSQL.
+type XAPIRepository struct {
+	DB *sql.DB
+}
+
+func NewXAPIRepository(db *sql.DB) *XAPIRepository {
+	return &XAPIRepository{DB: db}
+}
+
+const statementColumns = `statement, seq, COALESCE(user_id::text, ''), voided`
+
+func (r *XAPIRepository) FindStatements(ids []string) ([]*xapi.Record, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return r.listStatements(`SELECT `+statementColumns+` FROM xapi_statements WHERE id::text = ANY($1)`, pq.Array(ids))
+}
+
+// SaveStatements inserts the statements in one transaction, skipping IDs
+// already stored. A statement is voided when a voiding statement targets it,
+// whether the voiding statement is stored before or after it; voiding
+// statements and statements the portal vouches for are never voided.
+func (r *XAPIRepository) SaveStatements(records []*xapi.Record) ([]*xapi.Record, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO xapi_statements (id, statement, verb_id, agents, related_agents, activity_id, related_activities,
+		                              registration, voids_id, user_id, stored)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (id) DO NOTHING`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var saved []*xapi.Record
+	var touched []string
+	for _, rec := range records {
+		st := rec.Statement
+		raw, err := json.Marshal(st)
+		if err != nil {
+			return nil, err
+		}
+		stored, err := time.Parse(time.RFC3339Nano, st.Stored)
+		if err != nil {
+			return nil, err
+		}
+		ix := st.Index()
+		res, err := stmt.Exec(st.ID, raw, ix.Verb, pq.Array(ix.Agents), pq.Array(ix.RelatedAgents), ix.Activity,
+			pq.Array(ix.RelatedActivities), nullString(ix.Registration), nullString(ix.Voids), nullString(rec.UserID), stored)
+		if err != nil {
+			return nil, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			continue
+		}
+		saved = append(saved, rec)
+		touched = append(touched, st.ID)
+		if ix.Voids != "" {
+			touched = append(touched, ix.Voids)
+		}
+	}
+	if len(touched) > 0 {
+		if _, err := tx.Exec(
+			`UPDATE xapi_statements s SET voided = TRUE
+			 WHERE s.id::text = ANY($1) AND s.voids_id IS NULL AND NOT s.voided
+			   AND s.statement #>> '{authority,account,name}' IS DISTINCT FROM 'portal'
+			   AND EXISTS (SELECT 1 FROM xapi_statements v WHERE v.voids_id = s.id)`,
+			pq.Array(touched),
+		); err != nil {
+			return nil, err
+		}
+	}
+	return saved, tx.Commit()
+}
+
+func (r *XAPIRepository) QueryStatements(filter xapi.StatementFilter) ([]*xapi.Record, error) {
+	where := []string{"NOT voided"}
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, strings.Replace(cond, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+	if filter.Agent != "" {
+		if filter.RelatedAgents {
+			add("related_agents @> ARRAY[?]::text[]", filter.Agent)
+		} else {
+			add("agents @> ARRAY[?]::text[]", filter.Agent)
+		}
+	}
+	if filter.Verb != "" {
+		add("verb_id = ?", filter.Verb)
+	}
+	if filter.Activity != "" {
+		if filter.RelatedActivities {
+			add("related_activities @> ARRAY[?]::text[]", filter.Activity)
+		} else {
+			add("activity_id = ?", filter.Activity)
+		}
+	}
+	if filter.Registration != "" {
+		add("registration::text = ?", filter.Registration)
+	}
+	if !filter.Since.IsZero() {
+		add("stored > ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("stored <= ?", filter.Until)
+	}
+	if filter.UserID != "" {
+		add("user_id::text = ?", filter.UserID)
+	}
+	order := "DESC"
+	if filter.Ascending {
+		order = "ASC"
+	}
+	if filter.After > 0 {
+		if filter.Ascending {
+			add("seq > ?", filter.After)
+		} else {
+			add("seq < ?", filter.After)
+		}
+	}
+	args = append(args, filter.Limit)
+	return r.listStatements(
+		`SELECT `+statementColumns+` FROM xapi_statements WHERE `+strings.Join(where, " AND ")+
+			` ORDER BY seq `+order+` LIMIT $`+strconv.Itoa(len(args)),
+		args...,
+	)
+}
+
+func (r *XAPIRepository) listStatements(query string, args ...interface{}) ([]*xapi.Record, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*xapi.Record
+	for rows.Next() {
+		var rec xapi.Record
+		var raw []byte
+		if err := rows.Scan(&raw, &rec.Seq, &rec.UserID, &rec.Voided); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &rec.Statement); err != nil {
+			return nil, err
+		}
+		out = append(out, &rec)
+	}
+	return out, rows.Err()
+}
+
+func (r *XAPIRepository) FindDocument(key xapi.DocumentKey) (*xapi.Document, error) {
+	d := xapi.Document{DocumentKey: key}
+	err := r.DB.QueryRow(
+		`SELECT content, content_type, etag, updated_at FROM xapi_documents
+		 WHERE kind = $1 AND activity_id = $2 AND agent = $3 AND registration = $4 AND doc_id = $5`,
+		key.Kind, key.ActivityID, key.Agent, key.Registration, key.DocID,
+	).Scan(&d.Content, &d.ContentType, &d.ETag, &d.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *XAPIRepository) ListDocumentIDs(key xapi.DocumentKey, since time.Time) ([]string, error) {
+	rows, err := r.DB.Query(
+		`SELECT doc_id FROM xapi_documents
+		 WHERE kind = $1 AND activity_id = $2 AND agent = $3 AND registration = $4 AND ($5 OR updated_at > $6)
+		 ORDER BY doc_id`,
+		key.Kind, key.ActivityID, key.Agent, key.Registration, since.IsZero(), since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *XAPIRepository) SaveDocument(d *xapi.Document) error {
+	_, err := r.DB.Exec(
+		`INSERT INTO xapi_documents (kind, activity_id, agent, registration, doc_id, content, content_type, etag, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (kind, activity_id, agent, registration, doc_id)
+		 DO UPDATE SET content = $6, content_type = $7, etag = $8, updated_at = $9`,
+		d.Kind, d.ActivityID, d.Agent, d.Registration, d.DocID, d.Content, d.ContentType, d.ETag, d.UpdatedAt,
+	)
+	return err
+}
+
+func (r *XAPIRepository) DeleteDocuments(key xapi.DocumentKey) error {
+	_, err := r.DB.Exec(
+		`DELETE FROM xapi_documents
+		 WHERE kind = $1 AND activity_id = $2 AND agent = $3 AND registration = $4 AND ($5 = '' OR doc_id = $5)`,
+		key.Kind, key.ActivityID, key.Agent, key.Registration, key.DocID,
+	)
+	return err
+}
@@ -0,0 +1,20 @@
+package storage
+
+import "testing"
+
+func TestLocalStore_Keys(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+
+	if err := store.Put("reports/v1..2.pdf", []byte("report")); err != nil {
+		t.Fatalf("Put() with dots in the file name error = %v", err)
+	}
+	if data, err := store.Get("reports/v1..2.pdf"); err != nil || string(data) != "report" {
+		t.Errorf("Get() = %q, %v, want the stored file", data, err)
+	}
+
+	for _, key := range []string{"", "/", "../secret", "reports/../../secret", ".."} {
+		if err := store.Put(key, []byte("x")); err == nil {
+			t.Errorf("Put(%q) error = nil, want an invalid key", key)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"training-portal/internal/domain/analytics"
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/user"
+)
+
+var (
+	ErrCourseNotFound = errors.New("course not found")
+	ErrInvalidFilter  = errors.New("invalid report filter")
+)
+
+// ReportRepository loads the progress records reports are computed from.
+type ReportRepository interface {
+	// ListLearnerProgress returns the progress of every enrollment matching
+	// the filter that was ever active.
+	ListLearnerProgress(filter analytics.ReportFilter) ([]*analytics.LearnerProgress, error)
+}
+
+// CourseFinder looks up courses by ID.
+type CourseFinder interface {
+	FindByID(id string) (*course.Course, error)
+}
+
+// ModuleLister lists a course's modules in order.
+type ModuleLister interface {
+	ListByCourse(courseID string) ([]*course.Module, error)
+}
+
+// ReportService builds funnel, cohort retention and completion time reports
+// from enrollments, progress records and analytics events.
+type ReportService struct {
+	Repo    ReportRepository
+	Courses CourseFinder
+	Modules ModuleLister
+}
+
+const week = 7 * 24 * time.Hour
+
+// Funnel counts how far the learners of a course got: enrolled, started,
+// each module in order, passed a quiz and completed. Every learner is placed
+// at the furthest stage they reached and counted for all stages before it,
+// so the counts never grow from one stage to the next.
+func (s *ReportService) Funnel(filter analytics.ReportFilter, now time.Time) (*analytics.FunnelReport, error) {
+	if err := s.check(filter, true); err != nil {
+		return nil, err
+	}
+	modules, err := s.Modules.ListByCourse(filter.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	learners, err := s.Repo.ListLearnerProgress(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stages := []analytics.FunnelStage{{Name: analytics.StageEnrolled}, {Name: analytics.StageStarted}}
+	position := map[string]int{} // module ID to its stage index
+	for i, m := range modules {
+		position[m.ID] = len(stages)
+		stages = append(stages, analytics.FunnelStage{Name: "module_" + strconv.Itoa(i+1), ModuleID: m.ID, Title: m.Title})
+	}
+	passed := len(stages)
+	stages = append(stages, analytics.FunnelStage{Name: analytics.StagePassedQuiz}, analytics.FunnelStage{Name: analytics.StageCompleted})
+
+	reached := make([]int, len(stages)) // learners whose furthest stage is i
+	for _, l := range learners {
+		furthest := 0
+		if l.FirstActiveAt != 0 || len(l.CompletedModules) > 0 {
+			furthest = 1
+		}
+		for _, id := range l.CompletedModules {
+			if p, ok := position[id]; ok && p > furthest {
+				furthest = p
+			}
+		}
+		if l.PassedQuiz {
+			furthest = passed
+		}
+		if l.CompletedAt != 0 || l.Status == string(enrollment.StatusCompleted) {
+			furthest = passed + 1
+		}
+		reached[furthest]++
+	}
+
+	total := 0
+	for i := len(stages) - 1; i >= 0; i-- {
+		total += reached[i]
+		stages[i].Learners = total
+	}
+	for i := range stages {
+		stages[i].Share = ratio(stages[i].Learners, stages[0].Learners)
+		previous := stages[0].Learners
+		if i > 0 {
+			previous = stages[i-1].Learners
+		}
+		stages[i].Conversion = ratio(stages[i].Learners, previous)
+	}
+	return &analytics.FunnelReport{CourseID: filter.CourseID, Stages: stages, GeneratedAt: now.Unix()}, nil
+}
+
+// Cohorts groups learners by the week they enrolled and reports, for up to
+// weeks weeks after, the share of each cohort still active.
+func (s *ReportService) Cohorts(filter analytics.ReportFilter, weeks int, now time.Time) (*analytics.CohortReport, error) {
+	if err := s.check(filter, false); err != nil {
+		return nil, err
+	}
+	if weeks <= 0 {
+		weeks = 8
+	}
+	if weeks > 52 {
+		weeks = 52
+	}
+	learners, err := s.Repo.ListLearnerProgress(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	byWeek := map[int64][]*analytics.LearnerProgress{}
+	for _, l := range learners {
+		start := weekStart(l.EnrolledAt)
+		byWeek[start] = append(byWeek[start], l)
+	}
+	report := &analytics.CohortReport{CourseID: filter.CourseID, Weeks: weeks, Cohorts: []analytics.Cohort{}, GeneratedAt: now.Unix()}
+	for start, members := range byWeek {
+		cohort := analytics.Cohort{WeekStart: start, Learners: len(members), Retention: []float64{}}
+		// The cohort's last learners enrolled up to a week after its start.
+		for n := 0; n <= weeks && time.Unix(start, 0).Add(time.Duration(n+1)*week).Unix() <= now.Unix(); n++ {
+			active := 0
+			for _, l := range members {
+				after := time.Unix(l.EnrolledAt, 0).Add(time.Duration(n) * week).Unix()
+				if l.CompletedAt != 0 || l.Status == string(enrollment.StatusCompleted) || l.LastActiveAt >= after {
+					active++
+				}
+			}
+			cohort.Retention = append(cohort.Retention, ratio(active, len(members)))
+		}
+		report.Cohorts = append(report.Cohorts, cohort)
+	}
+	sort.Slice(report.Cohorts, func(i, j int) bool { return report.Cohorts[i].WeekStart < report.Cohorts[j].WeekStart })
+	return report, nil
+}
+
+// CompletionTime reports the median time learners took to complete, from
+// their enrollment becoming active.
+func (s *ReportService) CompletionTime(filter analytics.ReportFilter, now time.Time) (*analytics.CompletionTimeReport, error) {
+	if err := s.check(filter, false); err != nil {
+		return nil, err
+	}
+	learners, err := s.Repo.ListLearnerProgress(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var durations []int64
+	for _, l := range learners {
+		if l.CompletedAt == 0 {
+			continue
+		}
+		start := l.ActivatedAt
+		if start == 0 || start > l.CompletedAt {
+			start = l.EnrolledAt
+		}
+		if d := l.CompletedAt - start; d >= 0 {
+			durations = append(durations, d)
+		}
+	}
+	report := &analytics.CompletionTimeReport{CourseID: filter.CourseID, Completions: len(durations), GeneratedAt: now.Unix()}
+	if len(durations) == 0 {
+		return report, nil
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	mid := len(durations) / 2
+	report.MedianSeconds = durations[mid]
+	if len(durations)%2 == 0 {
+		report.MedianSeconds = (durations[mid-1] + durations[mid]) / 2
+	}
+	report.MedianDays = float64(report.MedianSeconds) / float64(24*60*60)
+	return report, nil
+}
+
+// check validates a filter; courseRequired is set for per-course reports.
+func (s *ReportService) check(filter analytics.ReportFilter, courseRequired bool) error {
+	if filter.From != 0 && filter.To != 0 && filter.To <= filter.From {
+		return fmt.Errorf("%w: the end of the date range must be after its start", ErrInvalidFilter)
+	}
+	switch user.Role(filter.Role) {
+	case "", user.RoleEmployee, user.RoleManager, user.RoleTrainer, user.RoleAdmin:
+	default:
+		return fmt.Errorf("%w: unknown role %q", ErrInvalidFilter, filter.Role)
+	}
+	if filter.CourseID == "" {
+		if courseRequired {
+			return fmt.Errorf("%w: course_id is required", ErrInvalidFilter)
+		}
+		return nil
+	}
+	c, err := s.Courses.FindByID(filter.CourseID)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return ErrCourseNotFound
+	}
+	return nil
+}
+
+// weekStart returns Monday 00:00 UTC of the week containing the timestamp.
+func weekStart(unix int64) int64 {
+	t := time.Unix(unix, 0).UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+	return day.AddDate(0, 0, -offset).Unix()
+}
+
+func ratio(n, of int) float64 {
+	if of == 0 {
+		return 0
+	}
+	return float64(n) / float64(of)
+}
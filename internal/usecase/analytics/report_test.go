@@ -0,0 +1,176 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/analytics"
+	"training-portal/internal/domain/course"
+)
+
+type mockReports struct {
+	learners []*analytics.LearnerProgress
+	filter   analytics.ReportFilter
+}
+
+func (m *mockReports) ListLearnerProgress(filter analytics.ReportFilter) ([]*analytics.LearnerProgress, error) {
+	m.filter = filter
+	return m.learners, nil
+}
+
+type mockCourses map[string]*course.Course
+
+func (m mockCourses) FindByID(id string) (*course.Course, error) {
+	return m[id], nil
+}
+
+type mockModules []*course.Module
+
+func (m mockModules) ListByCourse(courseID string) ([]*course.Module, error) {
+	return m, nil
+}
+
+func newReportService(learners ...*analytics.LearnerProgress) (*ReportService, *mockReports) {
+	repo := &mockReports{learners: learners}
+	return &ReportService{
+		Repo:    repo,
+		Courses: mockCourses{courseID: {ID: courseID, Title: "Safety"}},
+		Modules: mockModules{{ID: "m1", Title: "Intro"}, {ID: "m2", Title: "Hazards"}},
+	}, repo
+}
+
+func TestReportService_Funnel(t *testing.T) {
+	enrolledAt := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC).Unix()
+	service, repo := newReportService(
+		&analytics.LearnerProgress{UserID: "never-started", Status: "active", EnrolledAt: enrolledAt},
+		&analytics.LearnerProgress{UserID: "browsed", Status: "active", EnrolledAt: enrolledAt, FirstActiveAt: enrolledAt + 60},
+		&analytics.LearnerProgress{UserID: "skipped-ahead", Status: "dropped", EnrolledAt: enrolledAt, CompletedModules: []string{"m2"}},
+		&analytics.LearnerProgress{UserID: "passed", Status: "active", EnrolledAt: enrolledAt, CompletedModules: []string{"m1"}, PassedQuiz: true},
+		// Completed by staff without any recorded activity.
+		&analytics.LearnerProgress{UserID: "done", Status: "completed", EnrolledAt: enrolledAt, CompletedAt: enrolledAt + 3600},
+	)
+
+	filter := analytics.ReportFilter{CourseID: courseID, Department: "Ops"}
+	report, err := service.Funnel(filter, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.filter != filter {
+		t.Errorf("expected the filter to reach the repository, got %+v", repo.filter)
+	}
+
+	want := []struct {
+		name     string
+		learners int
+	}{
+		{analytics.StageEnrolled, 5},
+		{analytics.StageStarted, 4},
+		{"module_1", 3},
+		{"module_2", 3},
+		{analytics.StagePassedQuiz, 2},
+		{analytics.StageCompleted, 1},
+	}
+	if len(report.Stages) != len(want) {
+		t.Fatalf("expected %d stages, got %+v", len(want), report.Stages)
+	}
+	for i, w := range want {
+		if s := report.Stages[i]; s.Name != w.name || s.Learners != w.learners {
+			t.Errorf("stage %d: expected %s with %d learners, got %s with %d", i, w.name, w.learners, s.Name, s.Learners)
+		}
+	}
+	if s := report.Stages[2]; s.ModuleID != "m1" || s.Title != "Intro" || s.Share != 0.6 || s.Conversion != 0.75 {
+		t.Errorf("unexpected module stage %+v", s)
+	}
+}
+
+func TestReportService_FunnelFilters(t *testing.T) {
+	service, _ := newReportService()
+	tests := []struct {
+		name   string
+		filter analytics.ReportFilter
+		want   error
+	}{
+		{"no course", analytics.ReportFilter{}, ErrInvalidFilter},
+		{"unknown course", analytics.ReportFilter{CourseID: "missing"}, ErrCourseNotFound},
+		{"unknown role", analytics.ReportFilter{CourseID: courseID, Role: "intern"}, ErrInvalidFilter},
+		{"empty range", analytics.ReportFilter{CourseID: courseID, From: 200, To: 100}, ErrInvalidFilter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Funnel(tt.filter, time.Now()); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestReportService_Cohorts(t *testing.T) {
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	at := func(d time.Duration) int64 { return monday.Add(d).Unix() }
+	service, _ := newReportService(
+		// First cohort: one learner stays active for three weeks, one stops
+		// after a few days and one completes in the first week.
+		&analytics.LearnerProgress{UserID: "a", Status: "active", EnrolledAt: at(day), LastActiveAt: at(22 * day)},
+		&analytics.LearnerProgress{UserID: "b", Status: "active", EnrolledAt: at(2 * day), LastActiveAt: at(4 * day)},
+		&analytics.LearnerProgress{UserID: "c", Status: "completed", EnrolledAt: at(6 * day), CompletedAt: at(7 * day)},
+		// Second cohort, one week later.
+		&analytics.LearnerProgress{UserID: "d", Status: "active", EnrolledAt: at(8 * day)},
+	)
+
+	now := monday.Add(4*week + day)
+	report, err := service.Cohorts(analytics.ReportFilter{}, 3, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Cohorts) != 2 {
+		t.Fatalf("expected 2 cohorts, got %+v", report.Cohorts)
+	}
+
+	first := report.Cohorts[0]
+	if first.WeekStart != monday.Unix() || first.Learners != 3 {
+		t.Errorf("unexpected first cohort %+v", first)
+	}
+	want := []float64{1, 2.0 / 3, 2.0 / 3, 2.0 / 3}
+	if len(first.Retention) != len(want) {
+		t.Fatalf("expected retention for weeks 0 to 3, got %v", first.Retention)
+	}
+	for n, w := range want {
+		if first.Retention[n] != w {
+			t.Errorf("week %d: expected %v, got %v", n, w, first.Retention[n])
+		}
+	}
+
+	// Weeks the whole second cohort has not lived through yet are left out.
+	second := report.Cohorts[1]
+	if second.WeekStart != monday.Add(week).Unix() || len(second.Retention) != 3 || second.Retention[0] != 0 {
+		t.Errorf("unexpected second cohort %+v", second)
+	}
+}
+
+func TestReportService_CompletionTime(t *testing.T) {
+	hour := int64(3600)
+	service, _ := newReportService(
+		&analytics.LearnerProgress{UserID: "a", EnrolledAt: 0, ActivatedAt: 10 * hour, CompletedAt: 12 * hour},
+		&analytics.LearnerProgress{UserID: "b", EnrolledAt: 0, CompletedAt: 48 * hour},
+		&analytics.LearnerProgress{UserID: "c", EnrolledAt: 0, CompletedAt: 6 * hour},
+		&analytics.LearnerProgress{UserID: "d", EnrolledAt: 0, CompletedAt: 24 * hour},
+		&analytics.LearnerProgress{UserID: "in-progress", EnrolledAt: 0},
+	)
+
+	report, err := service.CompletionTime(analytics.ReportFilter{CourseID: courseID}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Durations are 2h (from activation), 6h, 24h and 48h.
+	if report.Completions != 4 || report.MedianSeconds != 15*hour || report.MedianDays != 0.625 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	empty, _ := newReportService()
+	report, err = empty.CompletionTime(analytics.ReportFilter{}, time.Now())
+	if err != nil || report.Completions != 0 || report.MedianSeconds != 0 {
+		t.Errorf("expected an empty report, got %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,249 @@
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"training-portal/internal/domain/analytics"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidEvent  = errors.New("invalid analytics event")
+	ErrBatchTooLarge = errors.New("too many events in batch")
+	ErrNotAggregated = errors.New("no analytics for this course yet")
+)
+
+// Repository is the persistence contract for analytics. Events are append
+// only: they are never updated, and an event whose ID is already stored is
+// skipped.
+type Repository interface {
+	// Append stores the events and returns how many were new.
+	Append(events []*analytics.AnalyticsEvent) (int, error)
+	ListEvents(filter analytics.EventFilter) ([]*analytics.AnalyticsEvent, error)
+	// Aggregate recomputes the engagement of every user and course with
+	// events stored since the previous run, then every course's analytics.
+	Aggregate(now int64) error
+	ListEngagement(userID, courseID string) ([]*analytics.UserEngagement, error)
+	// FindCourseAnalytics returns nil if the course has not been aggregated.
+	FindCourseAnalytics(courseID string) (*analytics.CourseAnalytics, error)
+}
+
+// Limits bound what clients may send in one batch. Zero values use the
+// defaults.
+type Limits struct {
+	MaxBatch    int           // events per batch; defaults to 100
+	MaxAge      time.Duration // how old an event may be; defaults to a week, for clients that were offline
+	MaxSkew     time.Duration // how far in the future an event may be; defaults to five minutes
+	MaxMetadata int           // bytes of JSON metadata per event; defaults to 4 KiB
+}
+
+// Listener is told about events after they are stored, e.g. to forward them
+// to another system. Retried events are passed again.
+type Listener interface {
+	EventsRecorded(events []*analytics.AnalyticsEvent)
+}
+
+// EnrollmentChecker reports whether a user is enrolled in a course.
+type EnrollmentChecker interface {
+	IsEnrolled(userID, courseID string) (bool, error)
+}
+
+// Service records analytics events and serves the aggregates the
+// aggregation job computes from them.
+type Service struct {
+	Repo        Repository
+	Limits      Limits
+	Listeners   []Listener
+	Enrollments EnrollmentChecker // optional; drops reported module completions in courses the user is not enrolled in
+}
+
+// Record stores an event observed by the server, such as a login. Failures
+// are logged rather than returned so that analytics never break the action
+// being recorded.
+func (s *Service) Record(userID, eventType string, metadata map[string]interface{}) {
+	e := &analytics.AnalyticsEvent{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		EventType: eventType,
+		Timestamp: time.Now().Unix(),
+		Metadata:  metadata,
+	}
+	err := s.validate(e)
+	if err == nil {
+		_, err = s.append([]*analytics.AnalyticsEvent{e})
+	}
+	if err != nil {
+		log.Printf("analytics: recording %s for user %s failed: %v", eventType, userID, err)
+	}
+}
+
+// Ingest stores a batch of events reported by the frontend for the
+// authenticated user, whose ID replaces any the client sent. Clients should
+// set each event's ID so that a retried batch is not counted twice; events
+// without one get a new ID. A batch with any invalid event is rejected as a
+// whole; module completions in courses the user is not enrolled in are
+// dropped. Ingest returns how many events were new.
+func (s *Service) Ingest(userID string, events []*analytics.AnalyticsEvent, now time.Time) (int, error) {
+	if userID == "" {
+		return 0, errors.New("user_id is required")
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	if max := positive(s.Limits.MaxBatch, 100); len(events) > max {
+		return 0, fmt.Errorf("%w: at most %d", ErrBatchTooLarge, max)
+	}
+	oldest := now.Add(-positiveDuration(s.Limits.MaxAge, 7*24*time.Hour)).Unix()
+	newest := now.Add(positiveDuration(s.Limits.MaxSkew, 5*time.Minute)).Unix()
+	for i, e := range events {
+		if e == nil {
+			return 0, fmt.Errorf("%w: event %d is empty", ErrInvalidEvent, i)
+		}
+		e.UserID = userID
+		if e.ID == "" {
+			e.ID = uuid.New().String()
+		} else if uuid.Validate(e.ID) != nil {
+			return 0, fmt.Errorf("%w: event %d: id must be a UUID", ErrInvalidEvent, i)
+		}
+		if !analytics.ClientEventType(e.EventType) {
+			return 0, fmt.Errorf("%w: event %d: type %q cannot be reported", ErrInvalidEvent, i, e.EventType)
+		}
+		if e.Timestamp < oldest || e.Timestamp > newest {
+			return 0, fmt.Errorf("%w: event %d: timestamp out of range", ErrInvalidEvent, i)
+		}
+		if err := s.validate(e); err != nil {
+			return 0, fmt.Errorf("event %d: %w", i, err)
+		}
+	}
+	events, err := s.dropUnenrolledCompletions(userID, events)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+	return s.append(events)
+}
+
+// dropUnenrolledCompletions removes module completions in courses the user
+// is not enrolled in, which would otherwise count towards engagement.
+func (s *Service) dropUnenrolledCompletions(userID string, events []*analytics.AnalyticsEvent) ([]*analytics.AnalyticsEvent, error) {
+	if s.Enrollments == nil {
+		return events, nil
+	}
+	enrolled := make(map[string]bool)
+	kept := make([]*analytics.AnalyticsEvent, 0, len(events))
+	for _, e := range events {
+		if e.EventType == analytics.EventModuleCompleted {
+			courseID := e.CourseID()
+			ok, checked := enrolled[courseID]
+			if !checked {
+				var err error
+				if ok, err = s.Enrollments.IsEnrolled(userID, courseID); err != nil {
+					return nil, err
+				}
+				enrolled[courseID] = ok
+			}
+			if !ok {
+				continue
+			}
+		}
+		kept = append(kept, e)
+	}
+	return kept, nil
+}
+
+// append stores events and passes them to the listeners.
+func (s *Service) append(events []*analytics.AnalyticsEvent) (int, error) {
+	n, err := s.Repo.Append(events)
+	if err != nil {
+		return 0, err
+	}
+	for _, l := range s.Listeners {
+		l.EventsRecorded(events)
+	}
+	return n, nil
+}
+
+// validate checks an event's type and the metadata its type requires.
+func (s *Service) validate(e *analytics.AnalyticsEvent) error {
+	if e.UserID == "" {
+		return fmt.Errorf("%w: user_id is required", ErrInvalidEvent)
+	}
+	if !analytics.ValidEventType(e.EventType) {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidEvent, e.EventType)
+	}
+	var required []string
+	switch e.EventType {
+	case analytics.EventCourseViewed, analytics.EventCertificateIssued:
+		required = []string{analytics.MetaCourseID}
+	case analytics.EventModuleCompleted:
+		required = []string{analytics.MetaCourseID, analytics.MetaModuleID}
+	case analytics.EventQuizSubmitted:
+		required = []string{analytics.MetaCourseID, analytics.MetaQuizID}
+	}
+	for _, key := range required {
+		if id, _ := e.Metadata[key].(string); uuid.Validate(id) != nil {
+			return fmt.Errorf("%w: %s requires metadata %s", ErrInvalidEvent, e.EventType, key)
+		}
+	}
+	raw, err := json.Marshal(e.Metadata)
+	if err != nil {
+		return fmt.Errorf("%w: metadata: %v", ErrInvalidEvent, err)
+	}
+	if max := positive(s.Limits.MaxMetadata, 4<<10); len(raw) > max {
+		return fmt.Errorf("%w: metadata larger than %d bytes", ErrInvalidEvent, max)
+	}
+	return nil
+}
+
+// Events returns up to limit stored events matching the filter.
+func (s *Service) Events(filter analytics.EventFilter) ([]*analytics.AnalyticsEvent, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 500
+	}
+	return s.Repo.ListEvents(filter)
+}
+
+// Engagement returns aggregated engagement, optionally for one user or
+// course.
+func (s *Service) Engagement(userID, courseID string) ([]*analytics.UserEngagement, error) {
+	return s.Repo.ListEngagement(userID, courseID)
+}
+
+// CourseAnalytics returns a course's aggregated analytics.
+func (s *Service) CourseAnalytics(courseID string) (*analytics.CourseAnalytics, error) {
+	if courseID == "" {
+		return nil, errors.New("course_id is required")
+	}
+	ca, err := s.Repo.FindCourseAnalytics(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if ca == nil {
+		return nil, ErrNotAggregated
+	}
+	return ca, nil
+}
+
+// RunJob aggregates the events stored since the last run; it is run by the
+// scheduler.
+func (s *Service) RunJob(now time.Time) error {
+	return s.Repo.Aggregate(now.Unix())
+}
+
+func positive(n, fallback int) int {
+	if n <= 0 {
+		return fallback
+	}
+	return n
+}
+
+func positiveDuration(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return d
+}
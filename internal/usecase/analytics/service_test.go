@@ -0,0 +1,229 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/analytics"
+)
+
+const (
+	courseID = "0b6c2d4e-1f3a-4b5c-8d9e-0a1b2c3d4e5f"
+	moduleID = "7e8f9a0b-1c2d-4e3f-9a4b-5c6d7e8f9a0b"
+)
+
+// mockRepository keeps events in memory and, like the store, skips IDs it
+// already has.
+type mockRepository struct {
+	events     []*analytics.AnalyticsEvent
+	aggregated []int64
+	course     *analytics.CourseAnalytics
+}
+
+func (m *mockRepository) Append(events []*analytics.AnalyticsEvent) (int, error) {
+	stored := 0
+	for _, e := range events {
+		if !m.has(e.ID) {
+			m.events = append(m.events, e)
+			stored++
+		}
+	}
+	return stored, nil
+}
+
+func (m *mockRepository) has(id string) bool {
+	for _, e := range m.events {
+		if e.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockRepository) ListEvents(filter analytics.EventFilter) ([]*analytics.AnalyticsEvent, error) {
+	var out []*analytics.AnalyticsEvent
+	for _, e := range m.events {
+		if (filter.UserID == "" || e.UserID == filter.UserID) && len(out) < filter.Limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockRepository) Aggregate(now int64) error {
+	m.aggregated = append(m.aggregated, now)
+	return nil
+}
+
+func (m *mockRepository) ListEngagement(userID, courseID string) ([]*analytics.UserEngagement, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) FindCourseAnalytics(courseID string) (*analytics.CourseAnalytics, error) {
+	return m.course, nil
+}
+
+func viewed(id string, at time.Time) *analytics.AnalyticsEvent {
+	return &analytics.AnalyticsEvent{
+		ID:        id,
+		EventType: analytics.EventCourseViewed,
+		Timestamp: at.Unix(),
+		Metadata:  map[string]interface{}{analytics.MetaCourseID: courseID},
+	}
+}
+
+func TestService_Ingest(t *testing.T) {
+	repo := &mockRepository{}
+	service := &Service{Repo: repo}
+	now := time.Now()
+
+	batch := []*analytics.AnalyticsEvent{
+		viewed("3f1e2d3c-4b5a-4968-8776-a5b4c3d2e1f0", now.Add(-time.Hour)),
+		{
+			EventType: analytics.EventModuleCompleted,
+			UserID:    "someone-else",
+			Timestamp: now.Unix(),
+			Metadata:  map[string]interface{}{analytics.MetaCourseID: courseID, analytics.MetaModuleID: moduleID},
+		},
+	}
+	stored, err := service.Ingest("user-1", batch, now)
+	if err != nil || stored != 2 {
+		t.Fatalf("expected 2 stored events, got %d, %v", stored, err)
+	}
+	for _, e := range repo.events {
+		if e.UserID != "user-1" {
+			t.Errorf("expected events to belong to the authenticated user, got %q", e.UserID)
+		}
+		if e.ID == "" {
+			t.Error("expected events without an ID to get one")
+		}
+	}
+
+	// A retried batch is not stored twice.
+	stored, err = service.Ingest("user-1", batch[:1], now)
+	if err != nil || stored != 0 {
+		t.Fatalf("expected the retried event to be skipped, got %d, %v", stored, err)
+	}
+}
+
+// mockEnrollments reports the courses each user is enrolled in
+type mockEnrollments map[string]string
+
+func (m mockEnrollments) IsEnrolled(userID, courseID string) (bool, error) {
+	return m[userID] == courseID, nil
+}
+
+func TestService_IngestDropsUnenrolledCompletions(t *testing.T) {
+	repo := &mockRepository{}
+	service := &Service{Repo: repo, Enrollments: mockEnrollments{"user-1": courseID}}
+	now := time.Now()
+	completed := func() *analytics.AnalyticsEvent {
+		return &analytics.AnalyticsEvent{
+			EventType: analytics.EventModuleCompleted,
+			Timestamp: now.Unix(),
+			Metadata:  map[string]interface{}{analytics.MetaCourseID: courseID, analytics.MetaModuleID: moduleID},
+		}
+	}
+
+	if stored, err := service.Ingest("user-2", []*analytics.AnalyticsEvent{completed(), viewed("", now)}, now); err != nil || stored != 1 {
+		t.Fatalf("Ingest() for an unenrolled user = %d, %v; want only the course view stored", stored, err)
+	}
+	if stored, err := service.Ingest("user-1", []*analytics.AnalyticsEvent{completed()}, now); err != nil || stored != 1 {
+		t.Fatalf("Ingest() for an enrolled user = %d, %v; want the completion stored", stored, err)
+	}
+	for _, e := range repo.events {
+		if e.EventType == analytics.EventModuleCompleted && e.UserID != "user-1" {
+			t.Errorf("stored a module completion for %s, who is not enrolled", e.UserID)
+		}
+	}
+}
+
+func TestService_IngestRejectsInvalidBatches(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		event *analytics.AnalyticsEvent
+	}{
+		{"server-side type", &analytics.AnalyticsEvent{EventType: analytics.EventLogin, Timestamp: now.Unix()}},
+		{"unknown type", &analytics.AnalyticsEvent{EventType: "page_scrolled", Timestamp: now.Unix()}},
+		{"missing course", &analytics.AnalyticsEvent{EventType: analytics.EventCourseViewed, Timestamp: now.Unix()}},
+		{"missing module", &analytics.AnalyticsEvent{
+			EventType: analytics.EventModuleCompleted,
+			Timestamp: now.Unix(),
+			Metadata:  map[string]interface{}{analytics.MetaCourseID: courseID},
+		}},
+		{"bad id", viewed("not-a-uuid", now)},
+		{"too old", viewed("", now.Add(-8*24*time.Hour))},
+		{"in the future", viewed("", now.Add(time.Hour))},
+		{"large metadata", &analytics.AnalyticsEvent{
+			EventType: analytics.EventCourseViewed,
+			Timestamp: now.Unix(),
+			Metadata:  map[string]interface{}{analytics.MetaCourseID: courseID, "note": string(make([]byte, 5000))},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepository{}
+			service := &Service{Repo: repo}
+			batch := []*analytics.AnalyticsEvent{viewed("", now), tt.event}
+			if _, err := service.Ingest("user-1", batch, now); !errors.Is(err, ErrInvalidEvent) {
+				t.Fatalf("expected ErrInvalidEvent, got %v", err)
+			}
+			if len(repo.events) != 0 {
+				t.Error("expected nothing of a rejected batch to be stored")
+			}
+		})
+	}
+}
+
+func TestService_IngestBatchLimit(t *testing.T) {
+	service := &Service{Repo: &mockRepository{}, Limits: Limits{MaxBatch: 2}}
+	now := time.Now()
+	batch := []*analytics.AnalyticsEvent{viewed("", now), viewed("", now), viewed("", now)}
+	if _, err := service.Ingest("user-1", batch, now); !errors.Is(err, ErrBatchTooLarge) {
+		t.Fatalf("expected ErrBatchTooLarge, got %v", err)
+	}
+}
+
+func TestService_Record(t *testing.T) {
+	repo := &mockRepository{}
+	service := &Service{Repo: repo}
+
+	service.Record("user-1", analytics.EventLogin, nil)
+	service.Record("user-1", analytics.EventCertificateIssued, map[string]interface{}{analytics.MetaCourseID: courseID})
+	service.Record("user-1", analytics.EventCertificateIssued, nil) // no course: logged, not stored
+
+	if len(repo.events) != 2 {
+		t.Fatalf("expected 2 recorded events, got %d", len(repo.events))
+	}
+	if repo.events[1].CourseID() != courseID {
+		t.Errorf("expected the certificate event to carry its course, got %q", repo.events[1].CourseID())
+	}
+}
+
+func TestService_CourseAnalytics(t *testing.T) {
+	repo := &mockRepository{}
+	service := &Service{Repo: repo}
+	if _, err := service.CourseAnalytics(courseID); !errors.Is(err, ErrNotAggregated) {
+		t.Fatalf("expected ErrNotAggregated before the first aggregation, got %v", err)
+	}
+
+	repo.course = &analytics.CourseAnalytics{CourseID: courseID, Enrollments: 4, Completions: 1, AverageProgress: 0.5}
+	ca, err := service.CourseAnalytics(courseID)
+	if err != nil || ca.Enrollments != 4 {
+		t.Fatalf("expected the aggregated analytics, got %+v, %v", ca, err)
+	}
+}
+
+func TestService_RunJob(t *testing.T) {
+	repo := &mockRepository{}
+	service := &Service{Repo: repo}
+	now := time.Unix(1700000000, 0)
+	if err := service.RunJob(now); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.aggregated) != 1 || repo.aggregated[0] != now.Unix() {
+		t.Errorf("expected one aggregation at %d, got %v", now.Unix(), repo.aggregated)
+	}
+}
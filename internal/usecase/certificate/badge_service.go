@@ -0,0 +1,289 @@
+// File: internal/usecase/certificate/badge_service.go
+package certificate
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/certificate"
+	"training-portal/internal/domain/course"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrAchievementNotFound is returned when no course backs an achievement ID.
+var ErrAchievementNotFound = errors.New("achievement not found")
+
+// BadgeFormat is an export format for a badge credential.
+type BadgeFormat string
+
+const (
+	BadgeJWT BadgeFormat = "jwt" // compact VC-JWT
+	BadgePNG BadgeFormat = "png" // PNG image baked with the VC-JWT
+	BadgeSVG BadgeFormat = "svg" // SVG image baked with the VC-JWT
+)
+
+// BadgeRenderer draws badge images with a credential baked in. An empty
+// credential produces the plain achievement image.
+type BadgeRenderer interface {
+	PNG(title, credential string) ([]byte, error)
+	SVG(title, credential string) ([]byte, error)
+}
+
+// BadgeService issues certificates as Open Badges 3.0 credentials, secured as
+// VC-JWTs signed with the certificate signing key. Issuer profiles and
+// achievement definitions are generated from the portal and its courses and
+// served below /ob on the public base URL.
+type BadgeService struct {
+	Certificates *CertificateService
+	Renderer     BadgeRenderer
+	IssuerName   string
+}
+
+// Issuer returns the issuer profile document.
+func (s *BadgeService) Issuer() *certificate.Profile {
+	p := s.issuer()
+	p.Context = certificate.BadgeContexts
+	return p
+}
+
+// issuer returns the issuer profile as embedded in other documents.
+func (s *BadgeService) issuer() *certificate.Profile {
+	return &certificate.Profile{
+		ID:   s.url("/ob/issuer"),
+		Type: []string{"Profile"},
+		Name: s.IssuerName,
+		URL:  s.url(""),
+	}
+}
+
+// KeyID returns the URL of the issuer's public key, used as the JWT "kid".
+func (s *BadgeService) KeyID() string {
+	return s.url("/ob/issuer/key")
+}
+
+// PublicKey returns the issuer's public key as a JSON Web Key.
+func (s *BadgeService) PublicKey() map[string]string {
+	pub := s.Certificates.Key.Public().(ed25519.PublicKey)
+	return map[string]string{
+		"kid": s.KeyID(),
+		"kty": "OKP",
+		"crv": "Ed25519",
+		"alg": "EdDSA",
+		"use": "sig",
+		"x":   base64.RawURLEncoding.EncodeToString(pub),
+	}
+}
+
+// Achievement returns the achievement definition for a course.
+func (s *BadgeService) Achievement(courseID string) (*certificate.Achievement, error) {
+	c, err := s.Certificates.Courses.FindByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrAchievementNotFound
+	}
+	return s.achievement(c), nil
+}
+
+// AchievementImage returns the SVG image of a course's achievement.
+func (s *BadgeService) AchievementImage(courseID string) ([]byte, error) {
+	a, err := s.Achievement(courseID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Renderer.SVG(a.Name, "")
+}
+
+// Credential builds the Open Badges credential for a certificate.
+func (s *BadgeService) Credential(certificateID string) (*certificate.OpenBadgeCredential, error) {
+	cert, err := s.Certificates.GetCertificate(certificateID)
+	if err != nil {
+		return nil, err
+	}
+	return s.credential(cert)
+}
+
+// HostedCredential returns the credential for a credential ID, as linked from its "id".
+func (s *BadgeService) HostedCredential(credentialID string) (*certificate.OpenBadgeCredential, error) {
+	cert, err := s.Certificates.Repo.FindByCredentialID(credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, ErrCertificateNotFound
+	}
+	return s.credential(cert)
+}
+
+// Export returns the certificate's credential in the requested format along
+// with its content type.
+func (s *BadgeService) Export(certificateID string, format BadgeFormat) ([]byte, string, error) {
+	cred, err := s.Credential(certificateID)
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := s.sign(cred)
+	if err != nil {
+		return nil, "", err
+	}
+	switch format {
+	case BadgeJWT, "":
+		return []byte(token), "application/vc+jwt", nil
+	case BadgePNG:
+		img, err := s.Renderer.PNG(cred.Name, token)
+		return img, "image/png", err
+	case BadgeSVG:
+		img, err := s.Renderer.SVG(cred.Name, token)
+		return img, "image/svg+xml", err
+	default:
+		return nil, "", errors.New("invalid badge format")
+	}
+}
+
+// Verify checks a presented VC-JWT: its signature, that it was issued here,
+// and the current status of the underlying certificate. Expiry is taken from
+// the certificate rather than the token so that it is reported as a status.
+func (s *BadgeService) Verify(token string) *certificate.BadgeVerification {
+	result := &certificate.BadgeVerification{Status: "invalid"}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(strings.TrimSpace(token), claims, func(*jwt.Token) (interface{}, error) {
+		return s.Certificates.Key.Public(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}), jwt.WithoutClaimsValidation())
+	if err != nil {
+		result.Errors = append(result.Errors, "signature invalid: "+err.Error())
+		return result
+	}
+	if iss, _ := claims.GetIssuer(); iss != s.issuer().ID {
+		result.Errors = append(result.Errors, "credential was not issued here")
+		return result
+	}
+	jti, _ := claims["jti"].(string)
+	return s.Status(path.Base(jti))
+}
+
+// Status reports the current status of the certificate behind a credential
+// ID: active, expired, revoked, superseded, or invalid.
+func (s *BadgeService) Status(credentialID string) *certificate.BadgeVerification {
+	result := &certificate.BadgeVerification{Status: "invalid", CredentialID: credentialID}
+	v, err := s.Certificates.Verify(credentialID)
+	switch {
+	case errors.Is(err, ErrCertificateNotFound):
+		result.Errors = append(result.Errors, "credential is not known to the issuer")
+	case err != nil:
+		result.Errors = append(result.Errors, err.Error())
+	case v.Valid:
+		result.Valid, result.Status = true, string(v.Status)
+	default:
+		if v.Status != certificate.StatusActive {
+			result.Status = string(v.Status)
+		}
+		result.Errors = append(result.Errors, v.Reason)
+	}
+	return result
+}
+
+func (s *BadgeService) credential(cert *certificate.Certificate) (*certificate.OpenBadgeCredential, error) {
+	u, err := s.Certificates.Users.FindByID(cert.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, errors.New("user not found")
+	}
+	c, err := s.Certificates.Courses.FindByID(cert.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, errors.New("course not found")
+	}
+
+	// The credential ID doubles as the salt, so the same credential is
+	// produced every time it is exported.
+	hash := sha256.Sum256([]byte(strings.ToLower(u.Email) + cert.CredentialID))
+	achievement := s.achievement(c)
+	achievement.Context = nil
+	validUntil := ""
+	if cert.ExpiresAt != 0 {
+		validUntil = time.Unix(cert.ExpiresAt, 0).UTC().Format(time.RFC3339)
+	}
+	return &certificate.OpenBadgeCredential{
+		Context:    certificate.BadgeContexts,
+		ID:         s.url("/ob/credentials/" + cert.CredentialID),
+		Type:       []string{"VerifiableCredential", "OpenBadgeCredential"},
+		Issuer:     *s.issuer(),
+		ValidFrom:  time.Unix(cert.IssuedAt, 0).UTC().Format(time.RFC3339),
+		ValidUntil: validUntil,
+		Name:       c.Title,
+		CredentialSubject: certificate.AchievementSubject{
+			Type: []string{"AchievementSubject"},
+			Identifier: []certificate.IdentityObject{{
+				Type:         "IdentityObject",
+				IdentityHash: "sha256$" + hex.EncodeToString(hash[:]),
+				IdentityType: "emailAddress",
+				Hashed:       true,
+				Salt:         cert.CredentialID,
+			}},
+			Achievement: *achievement,
+		},
+		CredentialStatus: &certificate.CredentialStatus{
+			ID:   s.url("/ob/credentials/" + cert.CredentialID + "/status"),
+			Type: "1EdTechCredentialStatus",
+		},
+	}, nil
+}
+
+func (s *BadgeService) achievement(c *course.Course) *certificate.Achievement {
+	description := c.Description
+	if description == "" {
+		description = c.Title
+	}
+	return &certificate.Achievement{
+		Context:         certificate.BadgeContexts,
+		ID:              s.url("/ob/achievements/" + c.ID),
+		Type:            []string{"Achievement"},
+		AchievementType: "Course",
+		Name:            c.Title,
+		Description:     description,
+		Criteria:        certificate.Criteria{Narrative: "Completed all required modules of the course " + c.Title + "."},
+		Image:           &certificate.Image{ID: s.url("/ob/achievements/" + c.ID + "/image"), Type: "Image"},
+		Creator:         s.issuer(),
+	}
+}
+
+// sign secures the credential as a VC-JWT.
+func (s *BadgeService) sign(cred *certificate.OpenBadgeCredential) (string, error) {
+	validFrom, err := time.Parse(time.RFC3339, cred.ValidFrom)
+	if err != nil {
+		return "", err
+	}
+	claims := jwt.MapClaims{
+		"iss": cred.Issuer.ID,
+		"jti": cred.ID,
+		"nbf": validFrom.Unix(),
+		"iat": validFrom.Unix(),
+		"vc":  cred,
+	}
+	if cred.ValidUntil != "" {
+		validUntil, err := time.Parse(time.RFC3339, cred.ValidUntil)
+		if err != nil {
+			return "", err
+		}
+		claims["exp"] = validUntil.Unix()
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = s.KeyID()
+	return token.SignedString(s.Certificates.Key)
+}
+
+func (s *BadgeService) url(p string) string {
+	return strings.TrimRight(s.Certificates.VerifyBaseURL, "/") + p
+}
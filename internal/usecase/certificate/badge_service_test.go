@@ -0,0 +1,115 @@
+package certificate
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"training-portal/internal/domain/certificate"
+)
+
+// mockBadgeRenderer returns the baked credential as the image
+type mockBadgeRenderer struct{}
+
+func (mockBadgeRenderer) PNG(title, credential string) ([]byte, error) {
+	return []byte(credential), nil
+}
+func (mockBadgeRenderer) SVG(title, credential string) ([]byte, error) {
+	return []byte(credential), nil
+}
+
+func newTestBadgeService(t *testing.T) (*BadgeService, *certificate.Certificate) {
+	service, templates, _, _ := newTestService()
+	service.Users.(mockUsers)["user-1"].Email = "Ada@example.com"
+	templates.Create(&certificate.Template{ID: "t1", IsDefault: true, Title: "Certificate", Body: "{{learner_name}}"})
+	cert, err := service.Issue("user-1", "course-1", 90, "")
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	return &BadgeService{Certificates: service, Renderer: mockBadgeRenderer{}, IssuerName: "Example Academy"}, cert
+}
+
+func TestBadgeService_Credential(t *testing.T) {
+	service, cert := newTestBadgeService(t)
+	cred, err := service.Credential(cert.ID)
+	if err != nil {
+		t.Fatalf("Credential() error = %v", err)
+	}
+	if want := "https://portal.example.com/ob/credentials/" + cert.CredentialID; cred.ID != want {
+		t.Errorf("ID = %q, want %q", cred.ID, want)
+	}
+	if cred.Issuer.ID != "https://portal.example.com/ob/issuer" || cred.Issuer.Name != "Example Academy" {
+		t.Errorf("Issuer = %+v", cred.Issuer)
+	}
+	a := cred.CredentialSubject.Achievement
+	if a.ID != "https://portal.example.com/ob/achievements/course-1" || a.Name != "Safety 101" || a.AchievementType != "Course" {
+		t.Errorf("Achievement = %+v", a)
+	}
+	identity := cred.CredentialSubject.Identifier[0]
+	if !identity.Hashed || !strings.HasPrefix(identity.IdentityHash, "sha256$") || strings.Contains(identity.IdentityHash, "example.com") {
+		t.Errorf("Identifier = %+v, want hashed email", identity)
+	}
+}
+
+func TestBadgeService_ExportAndVerify(t *testing.T) {
+	service, cert := newTestBadgeService(t)
+	token, contentType, err := service.Export(cert.ID, BadgeJWT)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if contentType != "application/vc+jwt" {
+		t.Errorf("content type = %q", contentType)
+	}
+	if png, _, _ := service.Export(cert.ID, BadgePNG); string(png) != string(token) {
+		t.Error("Export(png) did not bake the same credential")
+	}
+
+	v := service.Verify(string(token))
+	if !v.Valid || v.Status != "active" || v.CredentialID != cert.CredentialID {
+		t.Fatalf("Verify() = %+v, want active credential %s", v, cert.CredentialID)
+	}
+
+	// A payload edited after signing fails the signature check.
+	parts := strings.Split(string(token), ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]interface{}
+	json.Unmarshal(payload, &claims)
+	claims["jti"] = "https://portal.example.com/ob/credentials/TP-OTHER"
+	payload, _ = json.Marshal(claims)
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+	if v := service.Verify(strings.Join(parts, ".")); v.Valid {
+		t.Error("Verify() of a tampered credential reported valid")
+	}
+
+	// Changes to the certificate record invalidate previously issued badges.
+	cert.Score = 100
+	if v := service.Verify(string(token)); v.Valid || v.Status != "invalid" {
+		t.Errorf("Verify() after tampering with the certificate = %+v, want invalid", v)
+	}
+}
+
+func TestBadgeService_VerifyRejectsOtherIssuers(t *testing.T) {
+	service, cert := newTestBadgeService(t)
+	token, _, _ := service.Export(cert.ID, BadgeJWT)
+
+	other, _ := newTestBadgeService(t)
+	seed := make([]byte, ed25519.SeedSize)
+	seed[0] = 1
+	other.Certificates.Key = ed25519.NewKeyFromSeed(seed)
+	if v := other.Verify(string(token)); v.Valid {
+		t.Error("Verify() accepted a credential signed with another key")
+	}
+}
+
+func TestBadgeService_VerifyReportsRevocation(t *testing.T) {
+	service, cert := newTestBadgeService(t)
+	token, _, _ := service.Export(cert.ID, BadgeJWT)
+	if _, err := service.Certificates.Revoke(cert.ID, "admin", "misconduct"); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if v := service.Verify(string(token)); v.Valid || v.Status != "revoked" {
+		t.Errorf("Verify() = %+v, want revoked", v)
+	}
+}
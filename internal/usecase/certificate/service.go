@@ -0,0 +1,493 @@
+// File: internal/usecase/certificate/service.go
+package certificate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/analytics"
+	"training-portal/internal/domain/certificate"
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCertificateNotFound   = errors.New("certificate not found")
+	ErrNoTemplate            = errors.New("no certificate template configured")
+	ErrCertificateRevoked    = errors.New("certificate has been revoked")
+	ErrCertificateSuperseded = errors.New("certificate has been reissued")
+)
+
+// Repository is the persistence contract for issued certificates. Every
+// status change is written together with its history entry.
+type Repository interface {
+	Create(c *certificate.Certificate, change *certificate.StatusChange) error
+	UpdateStatus(c *certificate.Certificate, change *certificate.StatusChange) error
+	// Reissue stores replacement and marks old as superseded by it.
+	Reissue(old, replacement *certificate.Certificate, superseded, issued *certificate.StatusChange) error
+	FindByID(id string) (*certificate.Certificate, error)
+	FindByCredentialID(credentialID string) (*certificate.Certificate, error)
+	ListByUser(userID string) ([]*certificate.Certificate, error)
+	ListActiveByTemplate(templateID string) ([]*certificate.Certificate, error)
+	ListHistory(certificateID string) ([]*certificate.StatusChange, error)
+}
+
+// FileStore stores generated certificates and template images.
+type FileStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+// Renderer turns a filled-in certificate into a PDF.
+type Renderer interface {
+	Render(d *certificate.Document) ([]byte, error)
+}
+
+// UserFinder looks up users by ID.
+type UserFinder interface {
+	FindByID(id string) (*user.User, error)
+}
+
+// CourseFinder looks up courses by ID.
+type CourseFinder interface {
+	FindByID(id string) (*course.Course, error)
+}
+
+// Notifier sends a user the templated notification for an event.
+type Notifier interface {
+	Notify(userID string, event notification.EventType, vars map[string]string) error
+}
+
+// ScoreFinder looks up a learner's final score in a course.
+type ScoreFinder interface {
+	// CourseScore returns the score in percent, 0 if the course was not graded.
+	CourseScore(userID, courseID string) (int, error)
+}
+
+// Recorder records analytics events.
+type Recorder interface {
+	Record(userID, eventType string, metadata map[string]interface{})
+}
+
+// CertificateService issues certificates as PDFs rendered from templates.
+// Every certificate is signed with Key so that anyone can check it through
+// the public verification page at VerifyBaseURL.
+//
+// Certificates of recurring courses expire with their certification period.
+// A certificate can be revoked, or reissued with the learner's current name
+// and the course's current template, which supersedes the original.
+type CertificateService struct {
+	Repo          Repository
+	Templates     TemplateRepository
+	Files         FileStore
+	Renderer      Renderer
+	Users         UserFinder
+	Courses       CourseFinder
+	Key           ed25519.PrivateKey
+	VerifyBaseURL string      // public base URL of the portal, e.g. https://training.example.com
+	Notifier      Notifier    // optional; tells learners about issued certificates
+	Analytics     Recorder    // optional; records issued certificates
+	Scores        ScoreFinder // optional; supplies the score of certificates issued on completion
+}
+
+// Issue renders and stores a certificate for a user who completed a course.
+// A score of 0 means the course was not graded. issuedBy is empty when the
+// certificate is issued automatically on completion.
+func (s *CertificateService) Issue(userID, courseID string, score int, issuedBy string) (*certificate.Certificate, error) {
+	if userID == "" || courseID == "" {
+		return nil, errors.New("user_id and course_id are required")
+	}
+	if score < 0 || score > 100 {
+		return nil, errors.New("score must be between 0 and 100")
+	}
+	if len(s.Key) != ed25519.PrivateKeySize {
+		return nil, errors.New("certificate signing key is not configured")
+	}
+	u, c, err := s.lookup(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.Templates.FindForCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrNoTemplate
+	}
+
+	cert := &certificate.Certificate{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		CourseID:     courseID,
+		TemplateID:   t.ID,
+		CredentialID: newCredentialID(),
+		Score:        score,
+		IssuedAt:     time.Now().Unix(),
+		Status:       certificate.StatusActive,
+	}
+	if c.RecertificationMonths > 0 {
+		cert.ExpiresAt = enrollment.ExpiryAfter(cert.IssuedAt, c.RecertificationMonths)
+	}
+	if err := s.render(cert, t, u, c); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Create(cert, newStatusChange(cert.ID, "", certificate.StatusActive, issuedBy, "")); err != nil {
+		s.Files.Delete(cert.FileKey)
+		return nil, err
+	}
+	if s.Notifier != nil {
+		vars := map[string]string{"course_title": c.Title, "credential_id": cert.CredentialID, "download_url": cert.DownloadURL}
+		if err := s.Notifier.Notify(userID, notification.EventCertificateIssued, vars); err != nil {
+			log.Printf("certificates: notifying user %s of %s failed: %v", userID, cert.CredentialID, err)
+		}
+	}
+	if s.Analytics != nil {
+		s.Analytics.Record(userID, analytics.EventCertificateIssued, map[string]interface{}{
+			analytics.MetaCourseID: courseID, "certificate_id": cert.ID,
+		})
+	}
+	return cert, nil
+}
+
+// Revoke withdraws a certificate, e.g. after a misconduct finding. The reason
+// is kept in the history; verification only reports that it was revoked.
+func (s *CertificateService) Revoke(id, actorID, reason string) (*certificate.Certificate, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("reason is required")
+	}
+	cert, err := s.changeable(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	cert.Status = certificate.StatusRevoked
+	cert.RevokedAt = now
+	cert.RevocationReason = reason
+	change := newStatusChange(cert.ID, certificate.StatusActive, certificate.StatusRevoked, actorID, reason)
+	if err := s.Repo.UpdateStatus(cert, change); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// Reissue replaces a certificate with a new one rendered from the learner's
+// current name and the course's current template, e.g. after a legal name
+// change or a template update. The replacement gets a new credential ID but
+// keeps the completion date, score and expiry; the original is superseded.
+func (s *CertificateService) Reissue(id, actorID, reason string) (*certificate.Certificate, error) {
+	old, err := s.changeable(id)
+	if err != nil {
+		return nil, err
+	}
+	u, c, err := s.lookup(old.UserID, old.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.Templates.FindForCourse(old.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrNoTemplate
+	}
+
+	replacement := &certificate.Certificate{
+		ID:           uuid.New().String(),
+		UserID:       old.UserID,
+		CourseID:     old.CourseID,
+		TemplateID:   t.ID,
+		CredentialID: newCredentialID(),
+		Score:        old.Score,
+		IssuedAt:     old.IssuedAt,
+		ExpiresAt:    old.ExpiresAt,
+		Status:       certificate.StatusActive,
+		ReplacesID:   old.ID,
+	}
+	if err := s.render(replacement, t, u, c); err != nil {
+		return nil, err
+	}
+	old.Status = certificate.StatusSuperseded
+	old.ReplacedByID = replacement.ID
+	superseded := newStatusChange(old.ID, certificate.StatusActive, certificate.StatusSuperseded, actorID, reason)
+	issued := newStatusChange(replacement.ID, "", certificate.StatusActive, actorID, "reissued from "+old.CredentialID)
+	if err := s.Repo.Reissue(old, replacement, superseded, issued); err != nil {
+		s.Files.Delete(replacement.FileKey)
+		return nil, err
+	}
+	return replacement, nil
+}
+
+// ReissueForTemplate reissues every active certificate rendered from a
+// template, typically after the template was corrected. It returns the
+// replacements issued before the first failure.
+func (s *CertificateService) ReissueForTemplate(templateID, actorID, reason string) ([]*certificate.Certificate, error) {
+	if templateID == "" {
+		return nil, errors.New("template_id is required")
+	}
+	certs, err := s.Repo.ListActiveByTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	var reissued []*certificate.Certificate
+	for _, c := range certs {
+		r, err := s.Reissue(c.ID, actorID, reason)
+		if err != nil {
+			return reissued, fmt.Errorf("reissue %s: %w", c.CredentialID, err)
+		}
+		reissued = append(reissued, r)
+	}
+	return reissued, nil
+}
+
+// History returns the status changes of a certificate, oldest first.
+func (s *CertificateService) History(id string) ([]*certificate.StatusChange, error) {
+	if _, err := s.GetCertificate(id); err != nil {
+		return nil, err
+	}
+	return s.Repo.ListHistory(id)
+}
+
+// RecordCompletion issues a certificate with the learner's final score when
+// an enrollment is completed. Nothing is issued while no template is configured.
+func (s *CertificateService) RecordCompletion(e *enrollment.Enrollment) error {
+	score := 0
+	if s.Scores != nil {
+		var err error
+		if score, err = s.Scores.CourseScore(e.UserID, e.CourseID); err != nil {
+			return err
+		}
+	}
+	_, err := s.Issue(e.UserID, e.CourseID, score, "")
+	if errors.Is(err, ErrNoTemplate) {
+		return nil
+	}
+	return err
+}
+
+// GetCertificate retrieves a certificate by ID.
+func (s *CertificateService) GetCertificate(id string) (*certificate.Certificate, error) {
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+	c, err := s.Repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrCertificateNotFound
+	}
+	return c, nil
+}
+
+// ListCertificates returns a user's certificates.
+func (s *CertificateService) ListCertificates(userID string) ([]*certificate.Certificate, error) {
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+	return s.Repo.ListByUser(userID)
+}
+
+// Verify checks a credential ID for the public verification page.
+func (s *CertificateService) Verify(credentialID string) (*certificate.Verification, error) {
+	if credentialID == "" {
+		return nil, errors.New("credential_id is required")
+	}
+	c, err := s.Repo.FindByCredentialID(credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrCertificateNotFound
+	}
+	v := &certificate.Verification{
+		CredentialID: c.CredentialID,
+		Status:       c.CurrentStatus(time.Now()),
+		IssuedAt:     c.IssuedAt,
+		ExpiresAt:    c.ExpiresAt,
+	}
+	switch {
+	case !s.signatureValid(c):
+		v.Reason = "signature does not match"
+	case v.Status == certificate.StatusRevoked:
+		v.Reason = "certificate has been revoked"
+	case v.Status == certificate.StatusExpired:
+		v.Reason = "certificate expired on " + time.Unix(c.ExpiresAt, 0).UTC().Format("January 2, 2006")
+	case v.Status == certificate.StatusSuperseded:
+		v.Reason = "certificate has been reissued"
+		if r, err := s.Repo.FindByID(c.ReplacedByID); err != nil {
+			return nil, err
+		} else if r != nil {
+			v.ReplacedBy = r.CredentialID
+		}
+	default:
+		v.Valid = true
+	}
+	if u, err := s.Users.FindByID(c.UserID); err != nil {
+		return nil, err
+	} else if u != nil {
+		v.HolderName = u.Name
+	}
+	if crs, err := s.Courses.FindByID(c.CourseID); err != nil {
+		return nil, err
+	} else if crs != nil {
+		v.CourseTitle = crs.Title
+	}
+	return v, nil
+}
+
+// VerificationURL returns the public page that verifies credentialID.
+func (s *CertificateService) VerificationURL(credentialID string) string {
+	return strings.TrimRight(s.VerifyBaseURL, "/") + "/verify/" + credentialID
+}
+
+// Download returns the stored PDF of a certificate. Revoked and superseded
+// certificates cannot be downloaded; expired ones still can, as a record of
+// the completion.
+func (s *CertificateService) Download(id string) ([]byte, error) {
+	c, err := s.GetCertificate(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := statusError(c); err != nil {
+		return nil, err
+	}
+	return s.Files.Get(c.FileKey)
+}
+
+// lookup loads the user and course a certificate is issued for.
+func (s *CertificateService) lookup(userID, courseID string) (*user.User, *course.Course, error) {
+	u, err := s.Users.FindByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u == nil {
+		return nil, nil, errors.New("user not found")
+	}
+	c, err := s.Courses.FindByID(courseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c == nil {
+		return nil, nil, errors.New("course not found")
+	}
+	return u, c, nil
+}
+
+// render signs cert, renders it from t and stores the PDF.
+func (s *CertificateService) render(cert *certificate.Certificate, t *certificate.Template, u *user.User, c *course.Course) error {
+	cert.Signature = base64.RawURLEncoding.EncodeToString(ed25519.Sign(s.Key, cert.SigningPayload()))
+	doc, err := s.document(t, cert, u, c)
+	if err != nil {
+		return err
+	}
+	pdf, err := s.Renderer.Render(doc)
+	if err != nil {
+		return err
+	}
+	cert.FileKey = "certificates/" + cert.ID + ".pdf"
+	cert.DownloadURL = "/api/certificate/" + cert.ID + "/download"
+	return s.Files.Put(cert.FileKey, pdf)
+}
+
+// changeable loads a certificate that may still be revoked or reissued.
+func (s *CertificateService) changeable(id string) (*certificate.Certificate, error) {
+	c, err := s.GetCertificate(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := statusError(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// statusError reports revoked and superseded certificates as errors.
+func statusError(c *certificate.Certificate) error {
+	switch c.Status {
+	case certificate.StatusRevoked:
+		return ErrCertificateRevoked
+	case certificate.StatusSuperseded:
+		return ErrCertificateSuperseded
+	}
+	return nil
+}
+
+func newStatusChange(certificateID string, from, to certificate.Status, actorID, reason string) *certificate.StatusChange {
+	return &certificate.StatusChange{
+		ID:            uuid.New().String(),
+		CertificateID: certificateID,
+		FromStatus:    from,
+		ToStatus:      to,
+		ChangedBy:     actorID,
+		Reason:        reason,
+		ChangedAt:     time.Now().Unix(),
+	}
+}
+
+// document fills the template's placeholders and loads its images.
+func (s *CertificateService) document(t *certificate.Template, cert *certificate.Certificate, u *user.User, c *course.Course) (*certificate.Document, error) {
+	score := ""
+	if cert.Score > 0 {
+		score = fmt.Sprintf("%d%%", cert.Score)
+	}
+	expiry := ""
+	if cert.ExpiresAt != 0 {
+		expiry = time.Unix(cert.ExpiresAt, 0).UTC().Format("January 2, 2006")
+	}
+	values := map[string]string{
+		certificate.PlaceholderLearnerName:    u.Name,
+		certificate.PlaceholderCourseTitle:    c.Title,
+		certificate.PlaceholderCompletionDate: time.Unix(cert.IssuedAt, 0).UTC().Format("January 2, 2006"),
+		certificate.PlaceholderScore:          score,
+		certificate.PlaceholderCredentialID:   cert.CredentialID,
+		certificate.PlaceholderExpiryDate:     expiry,
+	}
+	doc := &certificate.Document{
+		Orientation:     t.Orientation,
+		Title:           certificate.Fill(t.Title, values),
+		Lines:           strings.Split(certificate.Fill(t.Body, values), "\n"),
+		SignatoryName:   t.SignatoryName,
+		Footer:          "Credential ID: " + cert.CredentialID,
+		VerificationURL: s.VerificationURL(cert.CredentialID),
+	}
+	var err error
+	if t.LogoKey != "" {
+		if doc.Logo, err = s.Files.Get(t.LogoKey); err != nil {
+			return nil, fmt.Errorf("load logo: %w", err)
+		}
+	}
+	if t.SignatureKey != "" {
+		if doc.Signature, err = s.Files.Get(t.SignatureKey); err != nil {
+			return nil, fmt.Errorf("load signature: %w", err)
+		}
+	}
+	return doc, nil
+}
+
+func (s *CertificateService) signatureValid(c *certificate.Certificate) bool {
+	sig, err := base64.RawURLEncoding.DecodeString(c.Signature)
+	if err != nil || len(s.Key) != ed25519.PrivateKeySize {
+		return false
+	}
+	return ed25519.Verify(s.Key.Public().(ed25519.PublicKey), c.SigningPayload(), sig)
+}
+
+// newCredentialID returns a random 80-bit identifier such as TP-ABCD-EFGH-IJKL-MNOP.
+func newCredentialID() string {
+	b := make([]byte, 10)
+	rand.Read(b)
+	s := base32.StdEncoding.EncodeToString(b)
+	return fmt.Sprintf("TP-%s-%s-%s-%s", s[0:4], s[4:8], s[8:12], s[12:16])
+}
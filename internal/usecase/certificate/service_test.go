@@ -0,0 +1,382 @@
+package certificate
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/certificate"
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/user"
+)
+
+// MockRepository is an in-memory implementation of Repository
+type MockRepository struct {
+	certificates []*certificate.Certificate
+	history      []*certificate.StatusChange
+}
+
+func (m *MockRepository) Create(c *certificate.Certificate, change *certificate.StatusChange) error {
+	m.certificates = append(m.certificates, c)
+	m.history = append(m.history, change)
+	return nil
+}
+
+func (m *MockRepository) UpdateStatus(c *certificate.Certificate, change *certificate.StatusChange) error {
+	m.history = append(m.history, change)
+	return nil
+}
+
+func (m *MockRepository) Reissue(old, replacement *certificate.Certificate, superseded, issued *certificate.StatusChange) error {
+	m.certificates = append(m.certificates, replacement)
+	m.history = append(m.history, issued, superseded)
+	return nil
+}
+
+func (m *MockRepository) FindByID(id string) (*certificate.Certificate, error) {
+	for _, c := range m.certificates {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockRepository) FindByCredentialID(credentialID string) (*certificate.Certificate, error) {
+	for _, c := range m.certificates {
+		if c.CredentialID == credentialID {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockRepository) ListByUser(userID string) ([]*certificate.Certificate, error) {
+	var out []*certificate.Certificate
+	for _, c := range m.certificates {
+		if c.UserID == userID {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockRepository) ListActiveByTemplate(templateID string) ([]*certificate.Certificate, error) {
+	var out []*certificate.Certificate
+	for _, c := range m.certificates {
+		if c.TemplateID == templateID && c.Status == certificate.StatusActive {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockRepository) ListHistory(certificateID string) ([]*certificate.StatusChange, error) {
+	var out []*certificate.StatusChange
+	for _, sc := range m.history {
+		if sc.CertificateID == certificateID {
+			out = append(out, sc)
+		}
+	}
+	return out, nil
+}
+
+// MockTemplateRepository is an in-memory implementation of TemplateRepository
+type MockTemplateRepository struct {
+	templates []*certificate.Template
+}
+
+func (m *MockTemplateRepository) Create(t *certificate.Template) error {
+	m.templates = append(m.templates, t)
+	return nil
+}
+
+func (m *MockTemplateRepository) Update(t *certificate.Template) error { return nil }
+
+func (m *MockTemplateRepository) Delete(id string) error { return nil }
+
+func (m *MockTemplateRepository) FindByID(id string) (*certificate.Template, error) {
+	for _, t := range m.templates {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockTemplateRepository) List() ([]*certificate.Template, error) { return m.templates, nil }
+
+func (m *MockTemplateRepository) FindForCourse(courseID string) (*certificate.Template, error) {
+	var fallback *certificate.Template
+	for _, t := range m.templates {
+		if t.CourseID == courseID {
+			return t, nil
+		}
+		if t.CourseID == "" && t.IsDefault {
+			fallback = t
+		}
+	}
+	return fallback, nil
+}
+
+// MockFileStore keeps files in memory
+type MockFileStore map[string][]byte
+
+func (m MockFileStore) Put(key string, data []byte) error { m[key] = data; return nil }
+
+func (m MockFileStore) Get(key string) ([]byte, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, errors.New("file not found")
+	}
+	return data, nil
+}
+
+func (m MockFileStore) Delete(key string) error { delete(m, key); return nil }
+
+// MockRenderer records the last document and renders it as plain text
+type MockRenderer struct {
+	last *certificate.Document
+}
+
+func (m *MockRenderer) Render(d *certificate.Document) ([]byte, error) {
+	m.last = d
+	return []byte(d.Title + "\n" + strings.Join(d.Lines, "\n")), nil
+}
+
+type mockUsers map[string]*user.User
+
+func (m mockUsers) FindByID(id string) (*user.User, error) { return m[id], nil }
+
+type mockCourses map[string]*course.Course
+
+func (m mockCourses) FindByID(id string) (*course.Course, error) { return m[id], nil }
+
+func newTestService() (*CertificateService, *MockTemplateRepository, *MockRenderer, MockFileStore) {
+	templates := &MockTemplateRepository{}
+	renderer := &MockRenderer{}
+	files := MockFileStore{}
+	return &CertificateService{
+		Repo:          &MockRepository{},
+		Templates:     templates,
+		Files:         files,
+		Renderer:      renderer,
+		Users:         mockUsers{"user-1": {ID: "user-1", Name: "Ada Lovelace"}},
+		Courses:       mockCourses{"course-1": {ID: "course-1", Title: "Safety 101"}},
+		Key:           ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)),
+		VerifyBaseURL: "https://portal.example.com/",
+	}, templates, renderer, files
+}
+
+func TestCertificateService_Issue(t *testing.T) {
+	service, templates, renderer, files := newTestService()
+	templateService := &TemplateService{Repo: templates, Files: files}
+	tmpl := &certificate.Template{
+		Name:      "Default",
+		IsDefault: true,
+		Title:     "Certificate of Completion",
+		Body:      "{{learner_name}}\ncompleted {{course_title}} with {{score}}\n{{credential_id}}",
+	}
+	if err := templateService.CreateTemplate(tmpl); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+
+	cert, err := service.Issue("user-1", "course-1", 92, "admin")
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if cert.TemplateID != tmpl.ID || cert.CredentialID == "" {
+		t.Errorf("Issue() = %+v, want template and credential ID set", cert)
+	}
+	want := []string{"Ada Lovelace", "completed Safety 101 with 92%", cert.CredentialID}
+	for i, line := range want {
+		if renderer.last.Lines[i] != line {
+			t.Errorf("line %d = %q, want %q", i, renderer.last.Lines[i], line)
+		}
+	}
+	if renderer.last.Orientation != certificate.Landscape {
+		t.Errorf("Orientation = %q, want landscape default", renderer.last.Orientation)
+	}
+
+	if want := "https://portal.example.com/verify/" + cert.CredentialID; renderer.last.VerificationURL != want {
+		t.Errorf("VerificationURL = %q, want %q", renderer.last.VerificationURL, want)
+	}
+
+	pdf, err := service.Download(cert.ID)
+	if err != nil || len(pdf) == 0 {
+		t.Errorf("Download() = %d bytes, %v", len(pdf), err)
+	}
+}
+
+func TestCertificateService_Verify(t *testing.T) {
+	service, templates, _, _ := newTestService()
+	templates.Create(&certificate.Template{ID: "t1", IsDefault: true, Title: "Certificate", Body: "{{learner_name}}"})
+	cert, err := service.Issue("user-1", "course-1", 0, "")
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	v, err := service.Verify(cert.CredentialID)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !v.Valid || v.HolderName != "Ada Lovelace" || v.CourseTitle != "Safety 101" {
+		t.Errorf("Verify() = %+v, want valid certificate for Ada Lovelace", v)
+	}
+
+	cert.Score = 100 // tampering with a signed field
+	if v, _ := service.Verify(cert.CredentialID); v.Valid {
+		t.Error("Verify() of a tampered certificate reported valid")
+	}
+	if _, err := service.Verify("TP-NOPE"); !errors.Is(err, ErrCertificateNotFound) {
+		t.Errorf("Verify() unknown credential error = %v, want ErrCertificateNotFound", err)
+	}
+}
+
+func TestCertificateService_RecordCompletionWithoutTemplate(t *testing.T) {
+	service, _, _, _ := newTestService()
+	e := &enrollment.Enrollment{UserID: "user-1", CourseID: "course-1"}
+	if err := service.RecordCompletion(e); err != nil {
+		t.Errorf("RecordCompletion() without template error = %v, want nil", err)
+	}
+	if certs, _ := service.ListCertificates("user-1"); len(certs) != 0 {
+		t.Errorf("RecordCompletion() issued %d certificates without a template", len(certs))
+	}
+}
+
+// mockScores serves fixed course scores per user
+type mockScores map[string]int
+
+func (m mockScores) CourseScore(userID, courseID string) (int, error) {
+	return m[userID], nil
+}
+
+func TestCertificateService_RecordCompletionUsesScore(t *testing.T) {
+	service, templates, _, files := newTestService()
+	service.Scores = mockScores{"user-1": 87}
+	templateService := &TemplateService{Repo: templates, Files: files}
+	templateService.CreateTemplate(&certificate.Template{Name: "Default", IsDefault: true, Title: "x", Body: "x"})
+
+	if err := service.RecordCompletion(&enrollment.Enrollment{UserID: "user-1", CourseID: "course-1"}); err != nil {
+		t.Fatalf("RecordCompletion() error = %v", err)
+	}
+	certs, _ := service.ListCertificates("user-1")
+	if len(certs) != 1 || certs[0].Score != 87 {
+		t.Errorf("RecordCompletion() issued %+v, want one certificate with score 87", certs)
+	}
+}
+
+func TestTemplateService_SetImageRejectsNonImage(t *testing.T) {
+	templates := &MockTemplateRepository{}
+	service := &TemplateService{Repo: templates, Files: MockFileStore{}}
+	tmpl := &certificate.Template{Name: "x", Title: "x", Body: "x"}
+	service.CreateTemplate(tmpl)
+	if _, err := service.SetImage(tmpl.ID, ImageLogo, []byte("GIF89a")); err == nil {
+		t.Error("SetImage() with non-image data expected error")
+	}
+}
+
+func TestCertificateService_Revoke(t *testing.T) {
+	service, templates, _, _ := newTestService()
+	templates.Create(&certificate.Template{ID: "t1", IsDefault: true, Title: "Certificate", Body: "{{learner_name}}"})
+	cert, _ := service.Issue("user-1", "course-1", 0, "")
+
+	if _, err := service.Revoke(cert.ID, "admin", ""); err == nil {
+		t.Error("Revoke() without reason expected error")
+	}
+	if _, err := service.Revoke(cert.ID, "admin", "exam misconduct"); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	v, _ := service.Verify(cert.CredentialID)
+	if v.Valid || v.Status != certificate.StatusRevoked || strings.Contains(v.Reason, "misconduct") {
+		t.Errorf("Verify() = %+v, want revoked without the internal reason", v)
+	}
+	if _, err := service.Download(cert.ID); !errors.Is(err, ErrCertificateRevoked) {
+		t.Errorf("Download() error = %v, want ErrCertificateRevoked", err)
+	}
+	if _, err := service.Revoke(cert.ID, "admin", "again"); !errors.Is(err, ErrCertificateRevoked) {
+		t.Errorf("Revoke() twice error = %v, want ErrCertificateRevoked", err)
+	}
+
+	history, _ := service.History(cert.ID)
+	if len(history) != 2 || history[1].ToStatus != certificate.StatusRevoked || history[1].Reason != "exam misconduct" || history[1].ChangedBy != "admin" {
+		t.Errorf("History() = %+v, want issue and revocation", history)
+	}
+}
+
+func TestCertificateService_Reissue(t *testing.T) {
+	service, templates, renderer, _ := newTestService()
+	templates.Create(&certificate.Template{ID: "t1", IsDefault: true, Title: "Certificate", Body: "{{learner_name}}"})
+	cert, _ := service.Issue("user-1", "course-1", 80, "")
+
+	service.Users.(mockUsers)["user-1"].Name = "Ada King"
+	replacement, err := service.Reissue(cert.ID, "admin", "legal name change")
+	if err != nil {
+		t.Fatalf("Reissue() error = %v", err)
+	}
+	if renderer.last.Lines[0] != "Ada King" {
+		t.Errorf("reissued certificate shows %q, want the new name", renderer.last.Lines[0])
+	}
+	if replacement.CredentialID == cert.CredentialID || replacement.ReplacesID != cert.ID || replacement.IssuedAt != cert.IssuedAt || replacement.Score != 80 {
+		t.Errorf("Reissue() = %+v, want new credential keeping the completion", replacement)
+	}
+
+	v, _ := service.Verify(cert.CredentialID)
+	if v.Valid || v.Status != certificate.StatusSuperseded || v.ReplacedBy != replacement.CredentialID {
+		t.Errorf("Verify() original = %+v, want superseded by %s", v, replacement.CredentialID)
+	}
+	if v, _ := service.Verify(replacement.CredentialID); !v.Valid {
+		t.Errorf("Verify() replacement = %+v, want valid", v)
+	}
+	if _, err := service.Reissue(cert.ID, "admin", ""); !errors.Is(err, ErrCertificateSuperseded) {
+		t.Errorf("Reissue() of superseded certificate error = %v, want ErrCertificateSuperseded", err)
+	}
+}
+
+func TestCertificateService_ReissueForTemplate(t *testing.T) {
+	service, templates, _, _ := newTestService()
+	templates.Create(&certificate.Template{ID: "t1", IsDefault: true, Title: "Certificate", Body: "{{learner_name}}"})
+	first, _ := service.Issue("user-1", "course-1", 0, "")
+	second, _ := service.Issue("user-1", "course-1", 0, "")
+	service.Revoke(second.ID, "admin", "duplicate")
+
+	reissued, err := service.ReissueForTemplate("t1", "admin", "template updated")
+	if err != nil {
+		t.Fatalf("ReissueForTemplate() error = %v", err)
+	}
+	if len(reissued) != 1 || reissued[0].ReplacesID != first.ID {
+		t.Errorf("ReissueForTemplate() = %+v, want only the active certificate reissued", reissued)
+	}
+}
+
+func TestCertificateService_Expiry(t *testing.T) {
+	service, templates, renderer, _ := newTestService()
+	service.Courses.(mockCourses)["course-1"].RecertificationMonths = 12
+	templates.Create(&certificate.Template{ID: "t1", IsDefault: true, Title: "Certificate", Body: "valid until {{expiry_date}}"})
+	cert, err := service.Issue("user-1", "course-1", 0, "")
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if want := time.Unix(cert.IssuedAt, 0).UTC().AddDate(1, 0, 0).Unix(); cert.ExpiresAt != want {
+		t.Errorf("ExpiresAt = %d, want %d", cert.ExpiresAt, want)
+	}
+	if renderer.last.Lines[0] == "valid until " {
+		t.Error("expiry date placeholder was not filled")
+	}
+	if v, _ := service.Verify(cert.CredentialID); !v.Valid {
+		t.Errorf("Verify() = %+v, want valid before expiry", v)
+	}
+
+	cert.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	cert.Signature = base64.RawURLEncoding.EncodeToString(ed25519.Sign(service.Key, cert.SigningPayload()))
+	if v, _ := service.Verify(cert.CredentialID); v.Valid || v.Status != certificate.StatusExpired {
+		t.Errorf("Verify() = %+v, want expired", v)
+	}
+	if _, err := service.Download(cert.ID); err != nil {
+		t.Errorf("Download() of expired certificate error = %v", err)
+	}
+}
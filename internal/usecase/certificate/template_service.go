@@ -0,0 +1,150 @@
+// File: internal/usecase/certificate/template_service.go
+package certificate
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/jpeg" // register decoders for uploaded images
+	_ "image/png"
+	"time"
+
+	"training-portal/internal/domain/certificate"
+
+	"github.com/google/uuid"
+)
+
+var ErrTemplateNotFound = errors.New("certificate template not found")
+
+// TemplateRepository is the persistence contract for certificate templates.
+type TemplateRepository interface {
+	// Create and Update clear the default flag on other templates when the
+	// saved template is the default.
+	Create(t *certificate.Template) error
+	Update(t *certificate.Template) error
+	Delete(id string) error
+	FindByID(id string) (*certificate.Template, error)
+	List() ([]*certificate.Template, error)
+	// FindForCourse returns the course's own template, falling back to the default.
+	FindForCourse(courseID string) (*certificate.Template, error)
+}
+
+// ImageKind names an image slot on a template.
+type ImageKind string
+
+const (
+	ImageLogo      ImageKind = "logo"
+	ImageSignature ImageKind = "signature"
+)
+
+// TemplateService manages certificate templates and their images.
+type TemplateService struct {
+	Repo  TemplateRepository
+	Files FileStore
+}
+
+// ValidateTemplate checks required fields, defaulting the orientation to landscape.
+func ValidateTemplate(t *certificate.Template) error {
+	if t == nil {
+		return errors.New("template is required")
+	}
+	if t.Name == "" || t.Title == "" || t.Body == "" {
+		return errors.New("name, title and body are required")
+	}
+	switch t.Orientation {
+	case "":
+		t.Orientation = certificate.Landscape
+	case certificate.Landscape, certificate.Portrait:
+	default:
+		return errors.New("invalid orientation")
+	}
+	return nil
+}
+
+// CreateTemplate stores a new template.
+func (s *TemplateService) CreateTemplate(t *certificate.Template) error {
+	if err := ValidateTemplate(t); err != nil {
+		return err
+	}
+	t.ID = uuid.New().String()
+	t.CreatedAt = time.Now().Unix()
+	t.LogoKey, t.SignatureKey = "", ""
+	return s.Repo.Create(t)
+}
+
+// UpdateTemplate modifies a template's text and layout. Images are kept.
+func (s *TemplateService) UpdateTemplate(t *certificate.Template) error {
+	if err := ValidateTemplate(t); err != nil {
+		return err
+	}
+	existing, err := s.GetTemplate(t.ID)
+	if err != nil {
+		return err
+	}
+	t.LogoKey, t.SignatureKey, t.CreatedAt = existing.LogoKey, existing.SignatureKey, existing.CreatedAt
+	return s.Repo.Update(t)
+}
+
+// DeleteTemplate removes a template and its images. Issued certificates are kept.
+func (s *TemplateService) DeleteTemplate(id string) error {
+	t, err := s.GetTemplate(id)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.Delete(id); err != nil {
+		return err
+	}
+	for _, key := range []string{t.LogoKey, t.SignatureKey} {
+		if key != "" {
+			s.Files.Delete(key)
+		}
+	}
+	return nil
+}
+
+// GetTemplate retrieves a template by ID.
+func (s *TemplateService) GetTemplate(id string) (*certificate.Template, error) {
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+	t, err := s.Repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrTemplateNotFound
+	}
+	return t, nil
+}
+
+// ListTemplates returns all templates.
+func (s *TemplateService) ListTemplates() ([]*certificate.Template, error) {
+	return s.Repo.List()
+}
+
+// SetImage stores a PNG or JPEG logo or signature for a template.
+func (s *TemplateService) SetImage(id string, kind ImageKind, data []byte) (*certificate.Template, error) {
+	t, err := s.GetTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, format, err := image.DecodeConfig(bytes.NewReader(data)); err != nil || (format != "png" && format != "jpeg") {
+		return nil, errors.New("image must be a PNG or JPEG")
+	}
+	key := "templates/" + t.ID + "/" + string(kind)
+	switch kind {
+	case ImageLogo:
+		t.LogoKey = key
+	case ImageSignature:
+		t.SignatureKey = key
+	default:
+		return nil, errors.New("invalid image kind")
+	}
+	if err := s.Files.Put(key, data); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Update(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
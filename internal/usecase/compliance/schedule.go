@@ -0,0 +1,499 @@
+package compliance
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/compliance"
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("report schedule not found")
+	ErrInvalidSchedule  = errors.New("invalid report schedule")
+	ErrForbidden        = errors.New("not allowed to manage this report schedule")
+	ErrInvalidLink      = errors.New("invalid download link")
+	ErrLinkExpired      = errors.New("download link has expired")
+)
+
+// ScheduleRepository is the persistence contract for report schedules and
+// their run history.
+type ScheduleRepository interface {
+	CreateSchedule(s *compliance.Schedule) error
+	UpdateSchedule(s *compliance.Schedule) error
+	DeleteSchedule(id string) error
+	FindSchedule(id string) (*compliance.Schedule, error)
+	// ListSchedules returns the owner's schedules, or every schedule when
+	// ownerID is empty.
+	ListSchedules(ownerID string) ([]*compliance.Schedule, error)
+	// ListDueSchedules returns enabled schedules whose next run is at or
+	// before now.
+	ListDueSchedules(now int64) ([]*compliance.Schedule, error)
+	CreateRun(r *compliance.Run) error
+	FindRun(id string) (*compliance.Run, error)
+	// ListRuns returns runs newest first.
+	ListRuns(filter compliance.RunFilter) ([]*compliance.Run, error)
+	// ListExpiredFiles returns runs whose download expired before now but
+	// whose file is still stored.
+	ListExpiredFiles(now int64) ([]*compliance.Run, error)
+	ClearFile(runID string) error
+}
+
+// UserFinder looks up report recipients.
+type UserFinder interface {
+	FindByID(id string) (*user.User, error)
+}
+
+// Mailer sends email with attachments.
+type Mailer interface {
+	SendEmail(e *notification.Email) error
+}
+
+// FileStore keeps reports delivered as download links.
+type FileStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+// Actor is the user managing a schedule.
+type Actor struct {
+	UserID string
+	Staff  bool // admins and trainers manage every schedule and may send to anyone
+}
+
+// ScheduleService runs saved report definitions on their schedules and
+// emails the results. Managers may schedule the reports they may see for
+// themselves only; staff may send any report to any user.
+type ScheduleService struct {
+	Repo    ScheduleRepository
+	Reports *ReportService
+	Users   UserFinder
+	Mailer  Mailer // nil when email is not configured, which makes runs fail
+	Files   FileStore
+	// LinkSecret signs download links, which are valid for LinkTTL (seven
+	// days by default). BaseURL is the public address the links point at.
+	LinkSecret []byte
+	LinkTTL    time.Duration
+	BaseURL    string
+}
+
+// Create validates and saves a new schedule owned by the actor.
+func (s *ScheduleService) Create(in *compliance.Schedule, actor Actor, now time.Time) (*compliance.Schedule, error) {
+	sched := &compliance.Schedule{
+		ID:        uuid.New().String(),
+		OwnerID:   actor.UserID,
+		CreatedAt: now.Unix(),
+	}
+	if err := s.apply(sched, in, actor, now); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateSchedule(sched); err != nil {
+		return nil, err
+	}
+	return sched, nil
+}
+
+// Update replaces a schedule's definition. The next run is recalculated from
+// now.
+func (s *ScheduleService) Update(id string, in *compliance.Schedule, actor Actor, now time.Time) (*compliance.Schedule, error) {
+	sched, err := s.Get(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(sched, in, actor, now); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.UpdateSchedule(sched); err != nil {
+		return nil, err
+	}
+	return sched, nil
+}
+
+// Delete removes a schedule. Its run history is kept.
+func (s *ScheduleService) Delete(id string, actor Actor) error {
+	if _, err := s.Get(id, actor); err != nil {
+		return err
+	}
+	return s.Repo.DeleteSchedule(id)
+}
+
+// Get returns a schedule the actor owns, or any schedule for staff.
+func (s *ScheduleService) Get(id string, actor Actor) (*compliance.Schedule, error) {
+	sched, err := s.Repo.FindSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if sched == nil {
+		return nil, ErrScheduleNotFound
+	}
+	if !actor.Staff && sched.OwnerID != actor.UserID {
+		return nil, ErrScheduleNotFound
+	}
+	return sched, nil
+}
+
+// List returns the actor's schedules, or every schedule for staff.
+func (s *ScheduleService) List(actor Actor) ([]*compliance.Schedule, error) {
+	if actor.Staff {
+		return s.Repo.ListSchedules("")
+	}
+	return s.Repo.ListSchedules(actor.UserID)
+}
+
+// Runs returns run history, newest first. Only the actor's own schedules are
+// included for non-staff, so they must ask for one of them.
+func (s *ScheduleService) Runs(filter compliance.RunFilter, actor Actor) ([]*compliance.Run, error) {
+	if !actor.Staff {
+		if filter.ScheduleID == "" {
+			return nil, ErrForbidden
+		}
+		if _, err := s.Get(filter.ScheduleID, actor); err != nil {
+			return nil, err
+		}
+	}
+	if filter.Status != "" && filter.Status != compliance.RunSucceeded && filter.Status != compliance.RunFailed {
+		return nil, fmt.Errorf("%w: unknown run status %q", ErrInvalidSchedule, filter.Status)
+	}
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return s.Repo.ListRuns(filter)
+}
+
+// RunNow generates and delivers a schedule immediately, without moving its
+// next scheduled run.
+func (s *ScheduleService) RunNow(id string, actor Actor, now time.Time) (*compliance.Run, error) {
+	sched, err := s.Get(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(sched, now)
+}
+
+// RunJob delivers every due schedule and removes download files that have
+// expired. A failed run is recorded and the schedule moves on to its next
+// time rather than retrying.
+func (s *ScheduleService) RunJob(now time.Time) error {
+	due, err := s.Repo.ListDueSchedules(now.Unix())
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, sched := range due {
+		// Move the schedule on first, so an error below cannot send the
+		// report again on the next tick.
+		sched.LastRunAt = now.Unix()
+		sched.NextRunAt = nextRun(sched, now)
+		sched.UpdatedAt = now.Unix()
+		if err := s.Repo.UpdateSchedule(sched); err != nil {
+			return err
+		}
+		run, err := s.run(sched, now)
+		if err != nil {
+			return err
+		}
+		if run.Status == compliance.RunFailed {
+			failed++
+		}
+	}
+
+	expired, err := s.Repo.ListExpiredFiles(now.Unix())
+	if err != nil {
+		return err
+	}
+	for _, r := range expired {
+		if err := s.Files.Delete(r.FileKey); err != nil {
+			log.Printf("compliance: deleting expired report %s: %v", r.FileKey, err)
+			continue
+		}
+		if err := s.Repo.ClearFile(r.ID); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d scheduled reports failed", failed, len(due))
+	}
+	return nil
+}
+
+// run generates a schedule's report, delivers it and records the outcome.
+// Only a failure to record the run is returned as an error.
+func (s *ScheduleService) run(sched *compliance.Schedule, now time.Time) (*compliance.Run, error) {
+	run := &compliance.Run{
+		ID:           uuid.New().String(),
+		ScheduleID:   sched.ID,
+		ScheduleName: sched.Name,
+		Status:       compliance.RunSucceeded,
+		StartedAt:    now.Unix(),
+	}
+	if err := s.deliver(sched, run, now); err != nil {
+		run.Status = compliance.RunFailed
+		run.Error = err.Error()
+		log.Printf("compliance: scheduled report %s (%s) failed: %v", sched.Name, sched.ID, err)
+	}
+	run.FinishedAt = time.Now().Unix()
+	if run.FinishedAt < run.StartedAt {
+		run.FinishedAt = run.StartedAt
+	}
+	if err := s.Repo.CreateRun(run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *ScheduleService) deliver(sched *compliance.Schedule, run *compliance.Run, now time.Time) error {
+	if s.Mailer == nil {
+		return errors.New("email delivery is not configured")
+	}
+	if err := s.authorize(sched); err != nil {
+		return err
+	}
+	var recipients []string
+	for _, id := range sched.Recipients {
+		u, err := s.Users.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if u != nil && u.Email != "" {
+			recipients = append(recipients, u.Email)
+		}
+	}
+	if len(recipients) == 0 {
+		return errors.New("none of the recipients has an email address")
+	}
+
+	report, err := s.Reports.Generate(sched.Kind, sched.Filter, now)
+	if err != nil {
+		return err
+	}
+	table := report.Table()
+	var data []byte
+	contentType := "text/csv"
+	if sched.Format == compliance.FormatPDF {
+		data, err = s.Reports.PDF(table)
+		contentType = "application/pdf"
+	} else {
+		data, err = s.Reports.CSV(table)
+	}
+	if err != nil {
+		return err
+	}
+	run.FileName = fileName(sched, now)
+
+	email := &notification.Email{
+		To:      recipients,
+		Subject: sched.Name + " - " + now.UTC().Format("2 Jan 2006"),
+		Body:    table.Title + "\n" + table.Subtitle + "\n",
+	}
+	if sched.Delivery == compliance.DeliveryLink {
+		run.FileKey = "reports/" + run.ID + "/" + run.FileName
+		if err := s.Files.Put(run.FileKey, data); err != nil {
+			run.FileKey = ""
+			return err
+		}
+		run.ExpiresAt = now.Add(s.linkTTL()).Unix()
+		email.Body += "\nDownload the report until " + now.Add(s.linkTTL()).UTC().Format("2 Jan 2006 15:04 MST") + ":\n" + s.link(run) + "\n"
+	} else {
+		email.Attachments = []notification.EmailAttachment{{FileName: run.FileName, ContentType: contentType, Data: data}}
+	}
+	email.Body += "\nThis report is sent on the schedule \"" + sched.Cron + "\" (" + sched.Timezone + "). Manage it in the training portal.\n"
+
+	if err := s.Mailer.SendEmail(email); err != nil {
+		return err
+	}
+	run.Recipients = len(recipients)
+	return nil
+}
+
+// authorize checks that the schedule's owner, with their current role, may
+// still see its report and send it to its recipients. A manager who left the
+// role or the department keeps their schedules, but they stop running.
+func (s *ScheduleService) authorize(sched *compliance.Schedule) error {
+	ok, err := s.Reports.Visible(sched.Kind, sched.Filter, sched.OwnerID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("owner %s: %w", sched.OwnerID, ErrReportForbidden)
+	}
+	owner, err := s.Users.FindByID(sched.OwnerID)
+	if err != nil {
+		return err
+	}
+	staff := owner != nil && (owner.Role == user.RoleAdmin || owner.Role == user.RoleTrainer)
+	if !staff && (len(sched.Recipients) != 1 || sched.Recipients[0] != sched.OwnerID) {
+		return fmt.Errorf("%w: only staff may send reports to other users", ErrForbidden)
+	}
+	return nil
+}
+
+// OpenDownload checks a download link and returns the run and its file.
+func (s *ScheduleService) OpenDownload(runID string, expiresAt int64, signature string, now time.Time) (*compliance.Run, []byte, error) {
+	if !hmac.Equal([]byte(signature), []byte(s.linkSignature(runID, expiresAt))) {
+		return nil, nil, ErrInvalidLink
+	}
+	if now.Unix() > expiresAt {
+		return nil, nil, ErrLinkExpired
+	}
+	run, err := s.Repo.FindRun(runID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if run == nil || run.FileKey == "" {
+		return nil, nil, ErrLinkExpired
+	}
+	data, err := s.Files.Get(run.FileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return run, data, nil
+}
+
+// apply validates in and copies its definition onto sched.
+func (s *ScheduleService) apply(sched, in *compliance.Schedule, actor Actor, now time.Time) error {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" || len(in.Name) > 255 {
+		return fmt.Errorf("%w: name is required and at most 255 characters", ErrInvalidSchedule)
+	}
+	if !in.Kind.Valid() {
+		return fmt.Errorf("%w: %q", ErrUnknownReport, in.Kind)
+	}
+	if in.Format == "" {
+		in.Format = compliance.FormatPDF
+	}
+	if in.Format != compliance.FormatCSV && in.Format != compliance.FormatPDF {
+		return fmt.Errorf("%w: format must be csv or pdf", ErrInvalidSchedule)
+	}
+	if in.Delivery == "" {
+		in.Delivery = compliance.DeliveryAttachment
+	}
+	if in.Delivery != compliance.DeliveryAttachment && in.Delivery != compliance.DeliveryLink {
+		return fmt.Errorf("%w: delivery must be attachment or link", ErrInvalidSchedule)
+	}
+	if in.Timezone == "" {
+		in.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(in.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, in.Timezone)
+	}
+	cron, err := compliance.ParseCron(in.Cron)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	if cron.Next(now).IsZero() {
+		return fmt.Errorf("%w: cron expression never fires", ErrInvalidSchedule)
+	}
+
+	// Managers schedule reports for themselves, and only the reports they
+	// may see.
+	if len(in.Recipients) == 0 {
+		in.Recipients = []string{sched.OwnerID}
+	}
+	if !actor.Staff && (len(in.Recipients) != 1 || in.Recipients[0] != actor.UserID) {
+		return fmt.Errorf("%w: only staff may send reports to other users", ErrForbidden)
+	}
+	if in.Kind == compliance.ReportTeam && in.Filter.ManagerID == "" {
+		in.Filter.ManagerID = sched.OwnerID
+	}
+	if in.Kind == compliance.ReportTranscript && in.Filter.UserID == "" {
+		return fmt.Errorf("%w: a transcript needs a user", ErrInvalidFilter)
+	}
+	if err := s.Reports.Scope(in.Kind, &in.Filter, actor.UserID); err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	var recipients []string
+	for _, id := range in.Recipients {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		u, err := s.Users.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if u == nil {
+			return fmt.Errorf("%w: recipient %s does not exist", ErrInvalidSchedule, id)
+		}
+		recipients = append(recipients, id)
+	}
+
+	sched.Name = in.Name
+	sched.Kind = in.Kind
+	sched.Filter = in.Filter
+	sched.Format = in.Format
+	sched.Cron = strings.TrimSpace(in.Cron)
+	sched.Timezone = in.Timezone
+	sched.Delivery = in.Delivery
+	sched.Recipients = recipients
+	sched.Enabled = in.Enabled
+	sched.NextRunAt = nextRun(sched, now)
+	sched.UpdatedAt = now.Unix()
+	return nil
+}
+
+// nextRun returns when a schedule is next due after now, or 0 if it is
+// disabled.
+func nextRun(sched *compliance.Schedule, now time.Time) int64 {
+	if !sched.Enabled {
+		return 0
+	}
+	cron, err := compliance.ParseCron(sched.Cron)
+	if err != nil {
+		return 0
+	}
+	loc, err := time.LoadLocation(sched.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	next := cron.Next(now.In(loc))
+	if next.IsZero() {
+		return 0
+	}
+	return next.Unix()
+}
+
+func fileName(sched *compliance.Schedule, now time.Time) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '-'
+	}, sched.Name)
+	return name + "-" + now.UTC().Format("2006-01-02") + "." + string(sched.Format)
+}
+
+func (s *ScheduleService) linkTTL() time.Duration {
+	if s.LinkTTL <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return s.LinkTTL
+}
+
+func (s *ScheduleService) link(run *compliance.Run) string {
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(run.ExpiresAt, 10))
+	q.Set("sig", s.linkSignature(run.ID, run.ExpiresAt))
+	return strings.TrimRight(s.BaseURL, "/") + "/reports/download/" + run.ID + "?" + q.Encode()
+}
+
+func (s *ScheduleService) linkSignature(runID string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, s.LinkSecret)
+	mac.Write([]byte(runID + "\n" + strconv.FormatInt(expiresAt, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,362 @@
+package compliance
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/compliance"
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/user"
+)
+
+type mockSchedules struct {
+	schedules map[string]*compliance.Schedule
+	runs      []*compliance.Run
+	cleared   []string
+}
+
+func (m *mockSchedules) CreateSchedule(s *compliance.Schedule) error {
+	m.schedules[s.ID] = s
+	return nil
+}
+
+func (m *mockSchedules) UpdateSchedule(s *compliance.Schedule) error {
+	m.schedules[s.ID] = s
+	return nil
+}
+
+func (m *mockSchedules) DeleteSchedule(id string) error {
+	delete(m.schedules, id)
+	return nil
+}
+
+func (m *mockSchedules) FindSchedule(id string) (*compliance.Schedule, error) {
+	return m.schedules[id], nil
+}
+
+func (m *mockSchedules) ListSchedules(ownerID string) ([]*compliance.Schedule, error) {
+	var out []*compliance.Schedule
+	for _, s := range m.schedules {
+		if ownerID == "" || s.OwnerID == ownerID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockSchedules) ListDueSchedules(now int64) ([]*compliance.Schedule, error) {
+	var out []*compliance.Schedule
+	for _, s := range m.schedules {
+		if s.Enabled && s.NextRunAt <= now {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockSchedules) CreateRun(r *compliance.Run) error {
+	m.runs = append(m.runs, r)
+	return nil
+}
+
+func (m *mockSchedules) FindRun(id string) (*compliance.Run, error) {
+	for _, r := range m.runs {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockSchedules) ListRuns(filter compliance.RunFilter) ([]*compliance.Run, error) {
+	return m.runs, nil
+}
+
+func (m *mockSchedules) ListExpiredFiles(now int64) ([]*compliance.Run, error) {
+	var out []*compliance.Run
+	for _, r := range m.runs {
+		if r.FileKey != "" && r.ExpiresAt < now {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockSchedules) ClearFile(runID string) error {
+	m.cleared = append(m.cleared, runID)
+	for _, r := range m.runs {
+		if r.ID == runID {
+			r.FileKey = ""
+		}
+	}
+	return nil
+}
+
+type mockUsers map[string]*user.User
+
+func (m mockUsers) FindByID(id string) (*user.User, error) {
+	return m[id], nil
+}
+
+type mockMailer struct {
+	sent []*notification.Email
+	err  error
+}
+
+func (m *mockMailer) SendEmail(e *notification.Email) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, e)
+	return nil
+}
+
+type mockFiles map[string][]byte
+
+func (m mockFiles) Put(key string, data []byte) error {
+	m[key] = data
+	return nil
+}
+
+func (m mockFiles) Get(key string) ([]byte, error) {
+	return m[key], nil
+}
+
+func (m mockFiles) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+type stubRenderer struct{}
+
+func (stubRenderer) Render(t *compliance.Table) ([]byte, error) {
+	return []byte("%PDF " + t.Title), nil
+}
+
+var (
+	manager = Actor{UserID: "manager"}
+	staff   = Actor{UserID: "admin", Staff: true}
+)
+
+func newScheduleService() (*ScheduleService, *mockSchedules, *mockMailer, mockFiles) {
+	users := mockUsers{
+		"manager": {ID: "manager", Email: "manager@example.com", Role: user.RoleManager, Department: "Ops"},
+		"admin":   {ID: "admin", Email: "admin@example.com", Role: user.RoleAdmin},
+		"auditor": {ID: "auditor", Email: "auditor@example.com", Role: user.RoleEmployee},
+	}
+	reports, _ := newService()
+	reports.Renderer = stubRenderer{}
+	reports.CSVRenderer = stubRenderer{}
+	reports.Users = users
+	repo := &mockSchedules{schedules: map[string]*compliance.Schedule{}}
+	mailer := &mockMailer{}
+	files := mockFiles{}
+	return &ScheduleService{
+		Repo:       repo,
+		Reports:    reports,
+		Users:      users,
+		Mailer:     mailer,
+		Files:      files,
+		LinkSecret: []byte("secret"),
+		BaseURL:    "https://portal.example.com/",
+	}, repo, mailer, files
+}
+
+func TestScheduleService_Create(t *testing.T) {
+	service, _, _, _ := newScheduleService()
+
+	// Sunday 3 March 2024 at 09:00 UTC; the next Monday 08:00 in Berlin is
+	// 07:00 UTC.
+	now := time.Date(2024, 3, 3, 9, 0, 0, 0, time.UTC)
+	sched, err := service.Create(&compliance.Schedule{
+		Name: "Weekly team progress", Kind: compliance.ReportTeam, Cron: "0 8 * * 1", Timezone: "Europe/Berlin", Enabled: true,
+		Filter: compliance.Filter{ManagerID: "someone-else"},
+	}, manager, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC).Unix(); sched.NextRunAt != want {
+		t.Errorf("NextRunAt = %v, want %v", time.Unix(sched.NextRunAt, 0).UTC(), time.Unix(want, 0).UTC())
+	}
+	if sched.Filter.ManagerID != "manager" || len(sched.Recipients) != 1 || sched.Recipients[0] != "manager" {
+		t.Errorf("expected a manager's team report for themselves, got %+v", sched)
+	}
+	if sched.Format != compliance.FormatPDF || sched.Delivery != compliance.DeliveryAttachment {
+		t.Errorf("unexpected defaults %q, %q", sched.Format, sched.Delivery)
+	}
+
+	tests := []struct {
+		name  string
+		in    compliance.Schedule
+		actor Actor
+		want  error
+	}{
+		{"bad cron", compliance.Schedule{Name: "x", Kind: compliance.ReportCourses, Cron: "0 8 * *"}, staff, ErrInvalidSchedule},
+		{"never fires", compliance.Schedule{Name: "x", Kind: compliance.ReportCourses, Cron: "0 0 30 2 *"}, staff, ErrInvalidSchedule},
+		{"bad timezone", compliance.Schedule{Name: "x", Kind: compliance.ReportCourses, Cron: "@daily", Timezone: "Mars/Olympus"}, staff, ErrInvalidSchedule},
+		{"unknown kind", compliance.Schedule{Name: "x", Kind: "salaries", Cron: "@daily"}, staff, ErrUnknownReport},
+		{"unknown recipient", compliance.Schedule{Name: "x", Kind: compliance.ReportCourses, Cron: "@daily", Recipients: []string{"nobody"}}, staff, ErrInvalidSchedule},
+		{"manager sending to others", compliance.Schedule{Name: "x", Kind: compliance.ReportCourses, Cron: "@daily", Recipients: []string{"auditor"}}, manager, ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Create(&tt.in, tt.actor, now); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestScheduleService_Access(t *testing.T) {
+	service, _, _, _ := newScheduleService()
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	sched, err := service.Create(&compliance.Schedule{Name: "Mine", Kind: compliance.ReportCourses, Cron: "@weekly", Enabled: true}, manager, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Get(sched.ID, Actor{UserID: "other-manager"}); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("expected another manager not to see the schedule, got %v", err)
+	}
+	if _, err := service.Get(sched.ID, staff); err != nil {
+		t.Errorf("expected staff to see every schedule, got %v", err)
+	}
+	if _, err := service.Runs(compliance.RunFilter{}, manager); !errors.Is(err, ErrForbidden) {
+		t.Errorf("expected managers to need a schedule for its runs, got %v", err)
+	}
+}
+
+func TestScheduleService_ManagerScope(t *testing.T) {
+	service, repo, mailer, _ := newScheduleService()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	if _, err := service.Create(&compliance.Schedule{
+		Name: "x", Kind: compliance.ReportTranscript, Cron: "@daily", Filter: compliance.Filter{UserID: "auditor"},
+	}, manager, now); !errors.Is(err, ErrReportForbidden) {
+		t.Errorf("expected a manager not to schedule another user's transcript, got %v", err)
+	}
+	sched, err := service.Create(&compliance.Schedule{
+		Name: "Courses", Kind: compliance.ReportCourses, Cron: "@hourly", Enabled: true, Filter: compliance.Filter{Department: "Sales"},
+	}, manager, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sched.Filter.Department != "Ops" {
+		t.Errorf("expected the report to be kept to the manager's department, got %q", sched.Filter.Department)
+	}
+
+	// A manager who left the role keeps the schedule, but it stops sending.
+	service.Users.(mockUsers)["manager"].Role = user.RoleEmployee
+	if err := service.RunJob(now); err == nil {
+		t.Error("expected the job to report the failed run")
+	}
+	if len(mailer.sent) != 0 || repo.runs[0].Status != compliance.RunFailed {
+		t.Errorf("expected the run to fail without email, got %d emails and %+v", len(mailer.sent), repo.runs[0])
+	}
+}
+
+func TestScheduleService_RunJobAttachment(t *testing.T) {
+	service, repo, mailer, _ := newScheduleService()
+	created := time.Date(2024, 3, 3, 9, 0, 0, 0, time.UTC)
+	sched, err := service.Create(&compliance.Schedule{
+		Name: "Courses", Kind: compliance.ReportCourses, Format: compliance.FormatCSV, Cron: "0 8 * * 1", Enabled: true,
+		Recipients: []string{"admin", "auditor"},
+	}, staff, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paused, _ := service.Create(&compliance.Schedule{Name: "Paused", Kind: compliance.ReportCourses, Cron: "* * * * *"}, staff, created)
+
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	if err := service.RunJob(now); err != nil {
+		t.Fatal(err)
+	}
+	if len(mailer.sent) != 1 || len(repo.runs) != 1 {
+		t.Fatalf("expected one email and one run, got %d and %d", len(mailer.sent), len(repo.runs))
+	}
+	email := mailer.sent[0]
+	if strings.Join(email.To, ",") != "admin@example.com,auditor@example.com" || len(email.Attachments) != 1 {
+		t.Errorf("unexpected email %+v", email)
+	}
+	if a := email.Attachments[0]; a.FileName != "courses-2024-03-04.csv" || a.ContentType != "text/csv" {
+		t.Errorf("unexpected attachment %q, %q", a.FileName, a.ContentType)
+	}
+	run := repo.runs[0]
+	if run.Status != compliance.RunSucceeded || run.Recipients != 2 || run.ScheduleID != sched.ID {
+		t.Errorf("unexpected run %+v", run)
+	}
+	if got := repo.schedules[sched.ID]; got.LastRunAt != now.Unix() || got.NextRunAt != now.AddDate(0, 0, 7).Unix() {
+		t.Errorf("expected the schedule to move on a week, got %+v", got)
+	}
+	if repo.schedules[paused.ID].NextRunAt != 0 {
+		t.Error("expected a disabled schedule to have no next run")
+	}
+
+	// Nothing is due until next week.
+	if err := service.RunJob(now.Add(time.Hour)); err != nil || len(mailer.sent) != 1 {
+		t.Errorf("expected no further email, got %d, %v", len(mailer.sent), err)
+	}
+}
+
+func TestScheduleService_RunJobFailure(t *testing.T) {
+	service, repo, mailer, _ := newScheduleService()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	sched, _ := service.Create(&compliance.Schedule{Name: "Courses", Kind: compliance.ReportCourses, Cron: "@hourly", Enabled: true}, staff, now.Add(-time.Hour))
+	mailer.err = errors.New("relay refused")
+
+	if err := service.RunJob(now); err == nil {
+		t.Error("expected the job to report the failed run")
+	}
+	if run := repo.runs[0]; run.Status != compliance.RunFailed || run.Error != "relay refused" {
+		t.Errorf("unexpected run %+v", run)
+	}
+	// A failed run is not retried before the next scheduled time.
+	if got := repo.schedules[sched.ID].NextRunAt; got != now.Add(time.Hour).Unix() {
+		t.Errorf("NextRunAt = %v", time.Unix(got, 0).UTC())
+	}
+}
+
+func TestScheduleService_DownloadLink(t *testing.T) {
+	service, repo, mailer, files := newScheduleService()
+	now := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	sched, _ := service.Create(&compliance.Schedule{Name: "Courses", Kind: compliance.ReportCourses, Cron: "@daily", Delivery: compliance.DeliveryLink}, staff, now)
+
+	run, err := service.RunNow(sched.ID, staff, now)
+	if err != nil || run.Status != compliance.RunSucceeded {
+		t.Fatalf("RunNow() = %+v, %v", run, err)
+	}
+	if len(mailer.sent[0].Attachments) != 0 || files[run.FileKey] == nil {
+		t.Fatal("expected the report to be stored instead of attached")
+	}
+	body := mailer.sent[0].Body
+	start := strings.Index(body, "https://portal.example.com/reports/download/"+run.ID+"?")
+	if start < 0 {
+		t.Fatalf("no download link in %q", body)
+	}
+	link, _ := url.Parse(strings.Fields(body[start:])[0])
+	expires, _ := strconv.ParseInt(link.Query().Get("expires"), 10, 64)
+	if expires != now.AddDate(0, 0, 7).Unix() {
+		t.Errorf("expected the link to last a week, got %v", time.Unix(expires, 0).UTC())
+	}
+
+	if _, data, err := service.OpenDownload(run.ID, expires, link.Query().Get("sig"), now); err != nil || !strings.HasPrefix(string(data), "%PDF") {
+		t.Errorf("OpenDownload() = %q, %v", data, err)
+	}
+	if _, _, err := service.OpenDownload(run.ID, expires+3600, link.Query().Get("sig"), now); !errors.Is(err, ErrInvalidLink) {
+		t.Errorf("expected a changed expiry to be rejected, got %v", err)
+	}
+	if _, _, err := service.OpenDownload(run.ID, expires, link.Query().Get("sig"), now.AddDate(0, 0, 8)); !errors.Is(err, ErrLinkExpired) {
+		t.Errorf("expected an expired link, got %v", err)
+	}
+
+	// The job removes the file once the link has expired.
+	if err := service.RunJob(now.AddDate(0, 0, 8)); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 || len(repo.cleared) != 1 {
+		t.Errorf("expected the expired file to be removed, got %d files", len(files))
+	}
+}
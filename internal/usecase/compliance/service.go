@@ -0,0 +1,360 @@
+package compliance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/compliance"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownReport    = errors.New("unknown compliance report")
+	ErrInvalidFilter    = errors.New("invalid report filter")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+	ErrReportForbidden  = errors.New("not allowed to see this report")
+	// ErrSnapshotCorrupt means a snapshot's data no longer matches its hash.
+	ErrSnapshotCorrupt = errors.New("snapshot does not match its hash")
+)
+
+// Repository is the persistence contract for compliance reports and their
+// snapshots. Snapshots are insert only.
+type Repository interface {
+	CourseCompletion(filter compliance.Filter) ([]*compliance.CourseCompletion, error)
+	DepartmentCompletion(filter compliance.Filter) ([]*compliance.DepartmentCompletion, error)
+	// ListExpiring returns active certificates expiring before until, soonest first.
+	ListExpiring(filter compliance.Filter, until int64) ([]*compliance.ExpiringCertification, error)
+	// Transcript returns nil if the user does not exist.
+	Transcript(userID string) (*compliance.Transcript, error)
+	// TeamProgress returns the progress of the manager's direct reports in
+	// their active and completed courses.
+	TeamProgress(filter compliance.Filter) ([]*compliance.TeamProgress, error)
+	CreateSnapshot(s *compliance.Snapshot) error
+	FindSnapshot(id string) (*compliance.Snapshot, error)
+	// ListSnapshots returns snapshots newest first, without Data and Table.
+	ListSnapshots(kind compliance.ReportKind) ([]*compliance.Snapshot, error)
+}
+
+// OverdueLister lists overdue mandatory training.
+type OverdueLister interface {
+	OverdueReport(department string) ([]*enrollment.OverdueRecord, error)
+}
+
+// TableRenderer renders a report table as a file.
+type TableRenderer interface {
+	Render(t *compliance.Table) ([]byte, error)
+}
+
+// Report is a generated compliance report. Data holds its rows as returned
+// by the JSON export.
+type Report struct {
+	Kind        compliance.ReportKind
+	Filter      compliance.Filter
+	GeneratedAt int64 // Unix timestamp
+	Data        interface{}
+}
+
+// ReportService builds the compliance reports auditors ask for and keeps
+// snapshots of them.
+type ReportService struct {
+	Repo        Repository
+	Overdue     OverdueLister
+	Renderer    TableRenderer // renders PDF exports
+	CSVRenderer TableRenderer // renders CSV exports
+	Users       UserFinder    // looks up viewers' current roles for Scope
+}
+
+// Scope narrows filter to what the user may see of a report, going by the
+// user's current role. Staff see every report. Managers see their own team,
+// transcripts of themselves and their direct reports, and the other reports
+// for their own department only. Everyone else only sees their own
+// transcript. ErrReportForbidden is returned for a report the user may not
+// see at all.
+func (s *ReportService) Scope(kind compliance.ReportKind, filter *compliance.Filter, userID string) error {
+	if !kind.Valid() {
+		return fmt.Errorf("%w: %q", ErrUnknownReport, kind)
+	}
+	viewer, err := s.Users.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if viewer == nil {
+		return ErrReportForbidden
+	}
+	if viewer.Role == user.RoleAdmin || viewer.Role == user.RoleTrainer {
+		return nil
+	}
+	if kind == compliance.ReportTranscript && filter.UserID == viewer.ID {
+		return nil
+	}
+	if viewer.Role != user.RoleManager {
+		return ErrReportForbidden
+	}
+	switch kind {
+	case compliance.ReportTranscript:
+		learner, err := s.Users.FindByID(filter.UserID)
+		if err != nil {
+			return err
+		}
+		if learner == nil || learner.ManagerID != viewer.ID {
+			return ErrReportForbidden
+		}
+	case compliance.ReportTeam:
+		filter.ManagerID = viewer.ID
+	default:
+		if viewer.Department == "" {
+			return ErrReportForbidden
+		}
+		filter.Department = viewer.Department
+	}
+	return nil
+}
+
+// Visible reports whether the user may see a report exactly as filtered,
+// e.g. a saved snapshot, without narrowing it.
+func (s *ReportService) Visible(kind compliance.ReportKind, filter compliance.Filter, userID string) (bool, error) {
+	scoped := filter
+	err := s.Scope(kind, &scoped, userID)
+	if errors.Is(err, ErrReportForbidden) || errors.Is(err, ErrUnknownReport) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return scoped == filter, nil
+}
+
+// Generate builds a report as of now.
+func (s *ReportService) Generate(kind compliance.ReportKind, filter compliance.Filter, now time.Time) (*Report, error) {
+	report := &Report{Kind: kind, Filter: filter, GeneratedAt: now.Unix()}
+	var err error
+	switch kind {
+	case compliance.ReportCourses:
+		courses, e := s.Repo.CourseCompletion(filter)
+		for _, c := range courses {
+			c.CompletionRate = rate(c.Completed, c.Assigned)
+		}
+		report.Data, err = append([]*compliance.CourseCompletion{}, courses...), e
+	case compliance.ReportDepartments:
+		departments, e := s.Repo.DepartmentCompletion(filter)
+		for _, d := range departments {
+			d.CompletionRate = rate(d.Completed, d.Assigned)
+		}
+		report.Data, err = append([]*compliance.DepartmentCompletion{}, departments...), e
+	case compliance.ReportOverdue:
+		overdue, e := s.Overdue.OverdueReport(filter.Department)
+		report.Data, err = append([]*enrollment.OverdueRecord{}, overdue...), e
+	case compliance.ReportExpiring:
+		if filter.Within <= 0 {
+			filter.Within = 30
+		}
+		report.Filter = filter
+		var certs []*compliance.ExpiringCertification
+		certs, err = s.Repo.ListExpiring(filter, now.AddDate(0, 0, filter.Within).Unix())
+		for _, c := range certs {
+			c.Expired = c.ExpiresAt <= now.Unix()
+		}
+		report.Data = append([]*compliance.ExpiringCertification{}, certs...)
+	case compliance.ReportTranscript:
+		if filter.UserID == "" {
+			return nil, fmt.Errorf("%w: a transcript needs a user", ErrInvalidFilter)
+		}
+		var t *compliance.Transcript
+		if t, err = s.Repo.Transcript(filter.UserID); err == nil && t == nil {
+			err = ErrUserNotFound
+		}
+		report.Data = t
+	case compliance.ReportTeam:
+		if filter.ManagerID == "" {
+			return nil, fmt.Errorf("%w: a team report needs a manager", ErrInvalidFilter)
+		}
+		team, e := s.Repo.TeamProgress(filter)
+		for _, p := range team {
+			p.Progress = rate(p.ModulesCompleted, p.TotalModules)
+			if p.Status == string(enrollment.StatusCompleted) || p.Progress > 1 {
+				p.Progress = 1
+			}
+		}
+		report.Data, err = append([]*compliance.TeamProgress{}, team...), e
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownReport, kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Table lays a report out for CSV and PDF export.
+func (r *Report) Table() *compliance.Table {
+	t := &compliance.Table{Subtitle: subtitle(r.Filter, r.GeneratedAt)}
+	switch data := r.Data.(type) {
+	case []*compliance.CourseCompletion:
+		t.Title = "Mandatory training completion by course"
+		t.Columns = []string{"Course ID", "Course", "Assigned", "Completed", "Overdue", "Completion %"}
+		for _, c := range data {
+			t.Rows = append(t.Rows, []string{c.CourseID, c.CourseTitle, itoa(c.Assigned), itoa(c.Completed), itoa(c.Overdue), percent(c.CompletionRate)})
+		}
+	case []*compliance.DepartmentCompletion:
+		t.Title = "Mandatory training completion by department"
+		t.Columns = []string{"Department", "Assigned", "Completed", "Overdue", "Completion %"}
+		for _, d := range data {
+			t.Rows = append(t.Rows, []string{d.Department, itoa(d.Assigned), itoa(d.Completed), itoa(d.Overdue), percent(d.CompletionRate)})
+		}
+	case []*enrollment.OverdueRecord:
+		t.Title = "Overdue mandatory training"
+		t.Columns = []string{"Department", "User ID", "Name", "Email", "Course ID", "Course", "Due", "Overdue since", "Escalation"}
+		for _, o := range data {
+			t.Rows = append(t.Rows, []string{o.Department, o.UserID, o.UserName, o.UserEmail, o.CourseID, o.CourseTitle,
+				formatTime(o.DueAt), formatTime(o.OverdueAt), o.EscalationLevel.String()})
+		}
+	case []*compliance.ExpiringCertification:
+		t.Title = "Expiring certifications"
+		t.Columns = []string{"Department", "User ID", "Name", "Email", "Course", "Credential ID", "Issued", "Expires", "Expired"}
+		for _, c := range data {
+			t.Rows = append(t.Rows, []string{c.Department, c.UserID, c.UserName, c.UserEmail, c.CourseTitle, c.CredentialID,
+				formatTime(c.IssuedAt), formatTime(c.ExpiresAt), strconv.FormatBool(c.Expired)})
+		}
+	case *compliance.Transcript:
+		t.Title = "Training transcript: " + data.UserName + " <" + data.UserEmail + ">"
+		if data.Department != "" {
+			t.Title += ", " + data.Department
+		}
+		t.Columns = []string{"Course ID", "Course", "Status", "Enrolled", "Due", "Completed", "Score", "Credential ID", "Certified", "Certificate expires", "Quiz scores"}
+		for _, e := range data.Entries {
+			var quizzes []string
+			for _, q := range e.Quizzes {
+				quizzes = append(quizzes, q.QuizID+": "+strconv.FormatFloat(q.Score, 'f', -1, 64))
+			}
+			score := ""
+			if e.Score > 0 {
+				score = itoa(e.Score)
+			}
+			t.Rows = append(t.Rows, []string{e.CourseID, e.CourseTitle, e.Status, formatTime(e.EnrolledAt), formatTime(e.DueAt),
+				formatTime(e.CompletedAt), score, e.CredentialID, formatTime(e.CertifiedAt), formatTime(e.CertExpiresAt), strings.Join(quizzes, "; ")})
+		}
+	case []*compliance.TeamProgress:
+		t.Title = "Team training progress"
+		t.Columns = []string{"Name", "Email", "Department", "Course", "Status", "Modules", "Progress %", "Due", "Completed", "Overdue"}
+		for _, p := range data {
+			t.Rows = append(t.Rows, []string{p.UserName, p.UserEmail, p.Department, p.CourseTitle, p.Status,
+				itoa(p.ModulesCompleted) + "/" + itoa(p.TotalModules), percent(p.Progress), formatTime(p.DueAt), formatTime(p.CompletedAt), strconv.FormatBool(p.Overdue)})
+		}
+	}
+	return t
+}
+
+// PDF renders a report table as a PDF document.
+func (s *ReportService) PDF(t *compliance.Table) ([]byte, error) {
+	return s.Renderer.Render(t)
+}
+
+// CSV renders a report table as CSV, with the footer, if any, as the last
+// row.
+func (s *ReportService) CSV(t *compliance.Table) ([]byte, error) {
+	return s.CSVRenderer.Render(t)
+}
+
+// SaveSnapshot generates a report and stores it unchangeably with the hash
+// of its JSON, to show later what the state was at now.
+func (s *ReportService) SaveSnapshot(kind compliance.ReportKind, filter compliance.Filter, note, actorID string, now time.Time) (*compliance.Snapshot, error) {
+	report, err := s.Generate(kind, filter, now)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	snap := &compliance.Snapshot{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		Filter:    report.Filter,
+		Note:      strings.TrimSpace(note),
+		Data:      data,
+		Table:     *report.Table(),
+		Hash:      hex.EncodeToString(sum[:]),
+		CreatedBy: actorID,
+		CreatedAt: now.Unix(),
+	}
+	snap.Table.Footer = "Snapshot " + snap.ID + " taken " + formatTime(snap.CreatedAt) + " - SHA-256 " + snap.Hash
+	if err := s.Repo.CreateSnapshot(snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Snapshot returns a saved snapshot after checking it against its hash.
+func (s *ReportService) Snapshot(id string) (*compliance.Snapshot, error) {
+	snap, err := s.Repo.FindSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return nil, ErrSnapshotNotFound
+	}
+	sum := sha256.Sum256(snap.Data)
+	if hex.EncodeToString(sum[:]) != snap.Hash {
+		return nil, ErrSnapshotCorrupt
+	}
+	return snap, nil
+}
+
+// Snapshots lists saved snapshots, optionally of one kind.
+func (s *ReportService) Snapshots(kind compliance.ReportKind) ([]*compliance.Snapshot, error) {
+	if kind != "" && !kind.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownReport, kind)
+	}
+	return s.Repo.ListSnapshots(kind)
+}
+
+// subtitle describes a report's filters and when it was generated.
+func subtitle(f compliance.Filter, generatedAt int64) string {
+	parts := []string{"Generated " + formatTime(generatedAt)}
+	if f.Department != "" {
+		parts = append(parts, "department "+f.Department)
+	}
+	if f.CourseID != "" {
+		parts = append(parts, "course "+f.CourseID)
+	}
+	if f.ManagerID != "" {
+		parts = append(parts, "reports of manager "+f.ManagerID)
+	}
+	if f.Within > 0 {
+		parts = append(parts, "expiring within "+itoa(f.Within)+" days")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatTime(unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
+
+func rate(n, of int) float64 {
+	if of == 0 {
+		return 0
+	}
+	return float64(n) / float64(of)
+}
+
+func percent(rate float64) string {
+	return strconv.FormatFloat(rate*100, 'f', 1, 64)
+}
+
+func itoa(n int) string {
+	return strconv.Itoa(n)
+}
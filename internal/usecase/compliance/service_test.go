@@ -0,0 +1,189 @@
+package compliance
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/compliance"
+	"training-portal/internal/domain/enrollment"
+)
+
+type mockRepo struct {
+	courses     []*compliance.CourseCompletion
+	expiring    []*compliance.ExpiringCertification
+	transcripts map[string]*compliance.Transcript
+	team        []*compliance.TeamProgress
+	snapshots   map[string]*compliance.Snapshot
+	until       int64
+}
+
+func (m *mockRepo) CourseCompletion(filter compliance.Filter) ([]*compliance.CourseCompletion, error) {
+	return m.courses, nil
+}
+
+func (m *mockRepo) DepartmentCompletion(filter compliance.Filter) ([]*compliance.DepartmentCompletion, error) {
+	return nil, nil
+}
+
+func (m *mockRepo) ListExpiring(filter compliance.Filter, until int64) ([]*compliance.ExpiringCertification, error) {
+	m.until = until
+	return m.expiring, nil
+}
+
+func (m *mockRepo) Transcript(userID string) (*compliance.Transcript, error) {
+	return m.transcripts[userID], nil
+}
+
+func (m *mockRepo) TeamProgress(filter compliance.Filter) ([]*compliance.TeamProgress, error) {
+	return m.team, nil
+}
+
+func (m *mockRepo) CreateSnapshot(s *compliance.Snapshot) error {
+	m.snapshots[s.ID] = s
+	return nil
+}
+
+func (m *mockRepo) FindSnapshot(id string) (*compliance.Snapshot, error) {
+	return m.snapshots[id], nil
+}
+
+func (m *mockRepo) ListSnapshots(kind compliance.ReportKind) ([]*compliance.Snapshot, error) {
+	return nil, nil
+}
+
+type mockOverdue []*enrollment.OverdueRecord
+
+func (m mockOverdue) OverdueReport(department string) ([]*enrollment.OverdueRecord, error) {
+	return m, nil
+}
+
+var now = time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+func newService() (*ReportService, *mockRepo) {
+	repo := &mockRepo{snapshots: map[string]*compliance.Snapshot{}, transcripts: map[string]*compliance.Transcript{}}
+	return &ReportService{Repo: repo, Overdue: mockOverdue{}}, repo
+}
+
+func TestReportService_GenerateCourses(t *testing.T) {
+	service, repo := newService()
+	repo.courses = []*compliance.CourseCompletion{
+		{CourseID: "c1", CourseTitle: "Safety", Assigned: 8, Completed: 6, Overdue: 1},
+		{CourseID: "c2", CourseTitle: "Privacy"},
+	}
+	report, err := service.Generate(compliance.ReportCourses, compliance.Filter{Department: "Ops"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	courses := report.Data.([]*compliance.CourseCompletion)
+	if courses[0].CompletionRate != 0.75 || courses[1].CompletionRate != 0 {
+		t.Errorf("unexpected completion rates %v and %v", courses[0].CompletionRate, courses[1].CompletionRate)
+	}
+
+	table := report.Table()
+	if got := table.Rows[0]; strings.Join(got, ",") != "c1,Safety,8,6,1,75.0" {
+		t.Errorf("unexpected row %q", got)
+	}
+	if table.Subtitle != "Generated 2024-03-04T09:00:00Z, department Ops" {
+		t.Errorf("unexpected subtitle %q", table.Subtitle)
+	}
+}
+
+func TestReportService_GenerateExpiring(t *testing.T) {
+	service, repo := newService()
+	repo.expiring = []*compliance.ExpiringCertification{
+		{CredentialID: "old", ExpiresAt: now.Add(-time.Hour).Unix()},
+		{CredentialID: "soon", ExpiresAt: now.AddDate(0, 0, 10).Unix()},
+	}
+	report, err := service.Generate(compliance.ReportExpiring, compliance.Filter{}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Filter.Within != 30 || repo.until != now.AddDate(0, 0, 30).Unix() {
+		t.Errorf("expected a 30 day default window, got %d days until %d", report.Filter.Within, repo.until)
+	}
+	certs := report.Data.([]*compliance.ExpiringCertification)
+	if !certs[0].Expired || certs[1].Expired {
+		t.Errorf("expected only the first certificate to be expired, got %+v %+v", certs[0], certs[1])
+	}
+}
+
+func TestReportService_GenerateTeam(t *testing.T) {
+	service, repo := newService()
+	repo.team = []*compliance.TeamProgress{
+		{UserName: "Ana", CourseTitle: "Safety", Status: "active", ModulesCompleted: 1, TotalModules: 4, Overdue: true},
+		// Completed before modules were added to the course.
+		{UserName: "Ben", CourseTitle: "Safety", Status: "completed", ModulesCompleted: 3, TotalModules: 4},
+	}
+	report, err := service.Generate(compliance.ReportTeam, compliance.Filter{ManagerID: "m1"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := report.Table().Rows
+	if got := strings.Join(rows[0], ","); got != "Ana,,,Safety,active,1/4,25.0,,,true" {
+		t.Errorf("unexpected row %q", got)
+	}
+	if rows[1][6] != "100.0" {
+		t.Errorf("expected a completed course to count as fully progressed, got %q", rows[1][6])
+	}
+}
+
+func TestReportService_GenerateErrors(t *testing.T) {
+	service, _ := newService()
+	tests := []struct {
+		name   string
+		kind   compliance.ReportKind
+		filter compliance.Filter
+		want   error
+	}{
+		{"unknown kind", "salaries", compliance.Filter{}, ErrUnknownReport},
+		{"transcript without user", compliance.ReportTranscript, compliance.Filter{}, ErrInvalidFilter},
+		{"transcript of unknown user", compliance.ReportTranscript, compliance.Filter{UserID: "missing"}, ErrUserNotFound},
+		{"team without manager", compliance.ReportTeam, compliance.Filter{}, ErrInvalidFilter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Generate(tt.kind, tt.filter, now); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestReportService_Snapshot(t *testing.T) {
+	service, repo := newService()
+	repo.transcripts["u1"] = &compliance.Transcript{
+		UserID: "u1", UserName: "Ana", UserEmail: "ana@example.com",
+		Entries: []compliance.TranscriptEntry{{CourseID: "c1", CourseTitle: "Safety", Status: "completed", Score: 92,
+			Quizzes: []compliance.QuizResult{{QuizID: "q1", Score: 80}, {QuizID: "q2", Score: 92.5}}}},
+	}
+	snap, err := service.SaveSnapshot(compliance.ReportTranscript, compliance.Filter{UserID: "u1"}, " Q1 audit ", "admin", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Note != "Q1 audit" || len(snap.Hash) != 64 || !strings.Contains(snap.Table.Footer, snap.Hash) {
+		t.Errorf("unexpected snapshot %+v", snap)
+	}
+	if got := snap.Table.Rows[0][10]; got != "q1: 80; q2: 92.5" {
+		t.Errorf("unexpected quiz scores %q", got)
+	}
+	var report struct{ Kind compliance.ReportKind }
+	if err := json.Unmarshal(snap.Data, &report); err != nil || report.Kind != compliance.ReportTranscript {
+		t.Errorf("expected the report JSON as data, got %s", snap.Data)
+	}
+
+	if got, err := service.Snapshot(snap.ID); err != nil || got != snap {
+		t.Errorf("Snapshot() = %v, %v", got, err)
+	}
+	if _, err := service.Snapshot("missing"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("expected ErrSnapshotNotFound, got %v", err)
+	}
+
+	// Data changed behind the service's back no longer matches the hash.
+	snap.Data = json.RawMessage(strings.Replace(string(snap.Data), "92", "99", 1))
+	if _, err := service.Snapshot(snap.ID); !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("expected ErrSnapshotCorrupt, got %v", err)
+	}
+}
@@ -3,8 +3,10 @@ package course
 
 import (
 	"errors"
+	"log"
 
 	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
 	"training-portal/internal/interface/repository"
 
 	"github.com/google/uuid"
@@ -14,6 +16,13 @@ import (
 type CourseService struct {
 	// Use interface type for easier testing and future DB swaps
 	Repo repository.CourseRepository
+	// Waitlists is optional; it fills seats freed by a larger capacity.
+	Waitlists WaitlistPromoter
+}
+
+// WaitlistPromoter activates waitlisted enrollments while seats are free.
+type WaitlistPromoter interface {
+	PromoteWaitlist(courseID string) ([]*enrollment.Enrollment, error)
 }
 
 // ValidateTitle checks if the course title is non-empty and within a reasonable length.
@@ -21,6 +30,25 @@ func ValidateTitle(title string) bool {
 	return len(title) > 0 && len(title) <= 255
 }
 
+// ValidateEnrollmentSettings checks the enrollment mode, seat capacity and
+// recertification interval, defaulting an empty mode to open self-enrollment.
+func ValidateEnrollmentSettings(c *course.Course) error {
+	switch c.EnrollmentMode {
+	case "":
+		c.EnrollmentMode = course.EnrollmentOpen
+	case course.EnrollmentOpen, course.EnrollmentApproval, course.EnrollmentInvite:
+	default:
+		return errors.New("invalid enrollment mode")
+	}
+	if c.SeatCapacity < 0 {
+		return errors.New("seat capacity cannot be negative")
+	}
+	if c.RecertificationMonths < 0 {
+		return errors.New("recertification interval cannot be negative")
+	}
+	return nil
+}
+
 // CreateCourse creates a new course after validating input.
 func (s *CourseService) CreateCourse(c *course.Course) error {
 	if c == nil {
@@ -32,6 +60,9 @@ func (s *CourseService) CreateCourse(c *course.Course) error {
 	if c.CreatedBy == "" {
 		return errors.New("created_by is required")
 	}
+	if err := ValidateEnrollmentSettings(c); err != nil {
+		return err
+	}
 
 	// Assign a new UUID for the course
 	c.ID = uuid.New().String()
@@ -66,9 +97,30 @@ func (s *CourseService) UpdateCourse(c *course.Course) error {
 	if c.Title != "" && !ValidateTitle(c.Title) {
 		return errors.New("invalid course title")
 	}
+	if err := ValidateEnrollmentSettings(c); err != nil {
+		return err
+	}
+
+	previous, err := s.Repo.FindByID(c.ID)
+	if err != nil {
+		return err
+	}
 
 	// TODO: Add permission checks if needed
-	return s.Repo.Update(c)
+	if err := s.Repo.Update(c); err != nil {
+		return err
+	}
+	if previous != nil && s.Waitlists != nil && seatsAdded(previous.SeatCapacity, c.SeatCapacity) {
+		if _, err := s.Waitlists.PromoteWaitlist(c.ID); err != nil {
+			log.Printf("course: promoting the waitlist of %s failed: %v", c.ID, err)
+		}
+	}
+	return nil
+}
+
+// seatsAdded reports whether a capacity change frees seats; 0 is unlimited.
+func seatsAdded(before, after int) bool {
+	return before > 0 && (after == 0 || after > before)
 }
 
 // DeleteCourse deletes a course by its ID.
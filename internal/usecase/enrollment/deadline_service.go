@@ -0,0 +1,193 @@
+// File: internal/usecase/enrollment/deadline_service.go
+package enrollment
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/user"
+)
+
+// DeadlineRepository is the persistence contract used by DeadlineService.
+type DeadlineRepository interface {
+	FindByID(id string) (*enrollment.Enrollment, error)
+	UpdateDeadline(e *enrollment.Enrollment) error
+	UpdateEscalation(e *enrollment.Enrollment) error
+	ListWithDeadline() ([]*enrollment.Enrollment, error)
+	ListOverdue(department string) ([]*enrollment.OverdueRecord, error)
+}
+
+// UserFinder looks up users by ID.
+type UserFinder interface {
+	FindByID(id string) (*user.User, error)
+}
+
+// Notifier sends a user the templated notification for an event.
+type Notifier interface {
+	Notify(userID string, event notification.EventType, vars map[string]string) error
+}
+
+// EscalationPolicy configures when overdue enrollments are escalated. Intervals
+// are measured from the end of the grace period; the learner is notified as
+// soon as the enrollment is marked overdue.
+type EscalationPolicy struct {
+	ManagerAfter time.Duration
+	HRAfter      time.Duration
+	HRUserIDs    []string
+}
+
+// DeadlineService manages enrollment due dates and escalates overdue training.
+type DeadlineService struct {
+	Repo     DeadlineRepository
+	Users    UserFinder
+	Courses  CourseRepository
+	Notifier Notifier
+	Policy   EscalationPolicy
+}
+
+// SetDeadline assigns a due date and grace period to an enrollment on behalf of
+// actorID. A zero dueAt removes the deadline.
+func (s *DeadlineService) SetDeadline(enrollmentID string, dueAt int64, graceDays int, actorID string) (*enrollment.Enrollment, error) {
+	if graceDays < 0 {
+		return nil, errors.New("grace period cannot be negative")
+	}
+	if enrollmentID == "" {
+		return nil, errors.New("id is required")
+	}
+	e, err := s.Repo.FindByID(enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrEnrollmentNotFound
+	}
+	e.AssignedBy = actorID
+	e.DueAt = dueAt
+	e.GraceDays = graceDays
+	e.OverdueAt = 0
+	e.EscalationLevel = enrollment.EscalationNone
+	e.NotifiedHR = nil
+	e.UpdatedAt = time.Now().Unix()
+	if err := s.Repo.UpdateDeadline(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// ProcessOverdue marks past-due enrollments overdue and escalates them one step
+// at a time: learner, then manager, then HR. It returns the number of
+// enrollments whose escalation advanced. A failure for one enrollment does not
+// stop the others; the failures are returned together.
+func (s *DeadlineService) ProcessOverdue(now time.Time) (int, error) {
+	enrollments, err := s.Repo.ListWithDeadline()
+	if err != nil {
+		return 0, err
+	}
+	advanced := 0
+	var errs []error
+	for _, e := range enrollments {
+		if !e.IsPastDue(now.Unix()) {
+			continue
+		}
+		changed, err := s.escalate(e, now)
+		if err != nil {
+			log.Printf("deadlines: escalation failed for enrollment %s: %v", e.ID, err)
+			errs = append(errs, fmt.Errorf("enrollment %s: %w", e.ID, err))
+		}
+		if changed {
+			if err := s.Repo.UpdateEscalation(e); err != nil {
+				errs = append(errs, fmt.Errorf("enrollment %s: %w", e.ID, err))
+				continue
+			}
+			advanced++
+		}
+	}
+	return advanced, errors.Join(errs...)
+}
+
+// OverdueReport lists overdue enrollments, optionally for a single department.
+func (s *DeadlineService) OverdueReport(department string) ([]*enrollment.OverdueRecord, error) {
+	return s.Repo.ListOverdue(department)
+}
+
+// RunJob processes overdue enrollments; it is run by the scheduler.
+func (s *DeadlineService) RunJob(now time.Time) error {
+	_, err := s.ProcessOverdue(now)
+	return err
+}
+
+// escalate advances the enrollment's escalation as far as the policy allows at
+// now. It stops at the first failed notification so the step is retried later;
+// HR users who were already notified are skipped on the retry.
+func (s *DeadlineService) escalate(e *enrollment.Enrollment, now time.Time) (bool, error) {
+	deadline := time.Unix(e.OverdueAfter(), 0)
+	changed := false
+
+	c, err := s.Courses.FindByID(e.CourseID)
+	if err != nil {
+		return false, err
+	}
+	vars := map[string]string{
+		"course_title": e.CourseID,
+		"due_date":     time.Unix(e.DueAt, 0).Format("2006-01-02"),
+		"learner_name": e.UserID,
+	}
+	if c != nil {
+		vars["course_title"] = c.Title
+	}
+
+	if e.EscalationLevel == enrollment.EscalationNone {
+		if err := s.Notifier.Notify(e.UserID, notification.EventOverdue, vars); err != nil {
+			return changed, err
+		}
+		e.OverdueAt = now.Unix()
+		e.EscalationLevel = enrollment.EscalationLearner
+		changed = true
+	}
+
+	var learner *user.User
+	if e.EscalationLevel < enrollment.EscalationHR && !now.Before(deadline.Add(s.Policy.ManagerAfter)) {
+		if learner, err = s.Users.FindByID(e.UserID); err != nil {
+			return changed, err
+		}
+		if learner != nil {
+			vars["learner_name"] = learner.Name
+		}
+	}
+
+	if e.EscalationLevel == enrollment.EscalationLearner && !now.Before(deadline.Add(s.Policy.ManagerAfter)) {
+		if learner != nil && learner.ManagerID != "" {
+			if err := s.Notifier.Notify(learner.ManagerID, notification.EventOverdueManager, vars); err != nil {
+				return changed, err
+			}
+		}
+		e.EscalationLevel = enrollment.EscalationManager
+		changed = true
+	}
+
+	if e.EscalationLevel == enrollment.EscalationManager && !now.Before(deadline.Add(s.Policy.HRAfter)) {
+		var errs []error
+		for _, hrID := range s.Policy.HRUserIDs {
+			if slices.Contains(e.NotifiedHR, hrID) {
+				continue
+			}
+			if err := s.Notifier.Notify(hrID, notification.EventOverdueHR, vars); err != nil {
+				errs = append(errs, fmt.Errorf("notify HR user %s: %w", hrID, err))
+				continue
+			}
+			e.NotifiedHR = append(e.NotifiedHR, hrID)
+			changed = true
+		}
+		if len(errs) > 0 {
+			return changed, errors.Join(errs...)
+		}
+		e.EscalationLevel = enrollment.EscalationHR
+		changed = true
+	}
+	return changed, nil
+}
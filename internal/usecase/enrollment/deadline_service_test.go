@@ -0,0 +1,184 @@
+package enrollment
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/user"
+)
+
+// MockDeadlineRepository is an in-memory implementation of DeadlineRepository
+type MockDeadlineRepository struct {
+	enrollments map[string]*enrollment.Enrollment
+}
+
+func (m *MockDeadlineRepository) FindByID(id string) (*enrollment.Enrollment, error) {
+	return m.enrollments[id], nil
+}
+
+func (m *MockDeadlineRepository) UpdateDeadline(e *enrollment.Enrollment) error {
+	m.enrollments[e.ID] = e
+	return nil
+}
+
+func (m *MockDeadlineRepository) UpdateEscalation(e *enrollment.Enrollment) error {
+	m.enrollments[e.ID] = e
+	return nil
+}
+
+func (m *MockDeadlineRepository) ListWithDeadline() ([]*enrollment.Enrollment, error) {
+	var out []*enrollment.Enrollment
+	for _, e := range m.enrollments {
+		if e.Status == enrollment.StatusActive && e.DueAt != 0 {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockDeadlineRepository) ListOverdue(department string) ([]*enrollment.OverdueRecord, error) {
+	return nil, nil
+}
+
+// MockUserFinder serves fixed users by ID
+type MockUserFinder map[string]*user.User
+
+func (m MockUserFinder) FindByID(id string) (*user.User, error) { return m[id], nil }
+
+// MockNotifier records the recipients and events of each notification
+type MockNotifier struct {
+	recipients []string
+	events     []notification.EventType
+}
+
+func (m *MockNotifier) Notify(userID string, event notification.EventType, vars map[string]string) error {
+	m.recipients = append(m.recipients, userID)
+	m.events = append(m.events, event)
+	return nil
+}
+
+func TestDeadlineService_Escalation(t *testing.T) {
+	due := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	repo := &MockDeadlineRepository{enrollments: map[string]*enrollment.Enrollment{
+		"e1": {ID: "e1", UserID: "learner", CourseID: "course-1", Status: enrollment.StatusActive},
+	}}
+	notifier := &MockNotifier{}
+	service := &DeadlineService{
+		Repo:     repo,
+		Users:    MockUserFinder{"learner": {ID: "learner", Name: "Lee", ManagerID: "manager"}},
+		Courses:  MockCourseRepository{"course-1": {ID: "course-1", Title: "Safety"}},
+		Notifier: notifier,
+		Policy:   EscalationPolicy{ManagerAfter: 3 * 24 * time.Hour, HRAfter: 7 * 24 * time.Hour, HRUserIDs: []string{"hr"}},
+	}
+
+	if _, err := service.SetDeadline("e1", due.Unix(), 2, "admin"); err != nil {
+		t.Fatalf("SetDeadline() error = %v", err)
+	}
+
+	steps := []struct {
+		at         time.Time
+		wantLevel  enrollment.EscalationLevel
+		wantNotify []string
+	}{
+		{due.Add(24 * time.Hour), enrollment.EscalationNone, nil},                        // within grace period
+		{due.Add(3 * 24 * time.Hour), enrollment.EscalationLearner, []string{"learner"}}, // overdue
+		{due.Add(4 * 24 * time.Hour), enrollment.EscalationLearner, []string{"learner"}}, // nothing new
+		{due.Add(5 * 24 * time.Hour), enrollment.EscalationManager, []string{"learner", "manager"}},
+		{due.Add(9 * 24 * time.Hour), enrollment.EscalationHR, []string{"learner", "manager", "hr"}},
+		{due.Add(30 * 24 * time.Hour), enrollment.EscalationHR, []string{"learner", "manager", "hr"}},
+	}
+	for _, step := range steps {
+		if _, err := service.ProcessOverdue(step.at); err != nil {
+			t.Fatalf("ProcessOverdue(%v) error = %v", step.at, err)
+		}
+		e := repo.enrollments["e1"]
+		if e.EscalationLevel != step.wantLevel {
+			t.Errorf("at %v level = %v, want %v", step.at, e.EscalationLevel, step.wantLevel)
+		}
+		if len(notifier.recipients) != len(step.wantNotify) {
+			t.Fatalf("at %v notified %v, want %v", step.at, notifier.recipients, step.wantNotify)
+		}
+		for i := range step.wantNotify {
+			if notifier.recipients[i] != step.wantNotify[i] {
+				t.Errorf("at %v notified %v, want %v", step.at, notifier.recipients, step.wantNotify)
+			}
+		}
+	}
+	wantEvents := []notification.EventType{notification.EventOverdue, notification.EventOverdueManager, notification.EventOverdueHR}
+	for i, event := range wantEvents {
+		if notifier.events[i] != event {
+			t.Errorf("event %d = %q, want %q", i, notifier.events[i], event)
+		}
+	}
+	if repo.enrollments["e1"].OverdueAt == 0 {
+		t.Error("OverdueAt was not set")
+	}
+}
+
+func TestDeadlineService_SetDeadlineResetsEscalation(t *testing.T) {
+	repo := &MockDeadlineRepository{enrollments: map[string]*enrollment.Enrollment{
+		"e1": {ID: "e1", Status: enrollment.StatusActive, DueAt: 1, OverdueAt: 2, EscalationLevel: enrollment.EscalationHR},
+	}}
+	service := &DeadlineService{Repo: repo, Courses: MockCourseRepository{"course-1": &course.Course{ID: "course-1"}}}
+
+	e, err := service.SetDeadline("e1", time.Now().Add(24*time.Hour).Unix(), 0, "admin")
+	if err != nil {
+		t.Fatalf("SetDeadline() error = %v", err)
+	}
+	if e.OverdueAt != 0 || e.EscalationLevel != enrollment.EscalationNone || e.AssignedBy != "admin" {
+		t.Errorf("SetDeadline() = %+v, want overdue state cleared", e)
+	}
+	if _, err := service.SetDeadline("e1", 0, -1, "admin"); err == nil {
+		t.Error("SetDeadline() with negative grace period expected error")
+	}
+}
+
+// flakyNotifier fails every notification to one user
+type flakyNotifier struct {
+	MockNotifier
+	failFor string
+}
+
+func (n *flakyNotifier) Notify(userID string, event notification.EventType, vars map[string]string) error {
+	if userID == n.failFor {
+		return errors.New("mail relay down")
+	}
+	return n.MockNotifier.Notify(userID, event, vars)
+}
+
+func TestDeadlineService_HREscalationRetriesOnlyMissedRecipients(t *testing.T) {
+	due := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	repo := &MockDeadlineRepository{enrollments: map[string]*enrollment.Enrollment{
+		"e1": {ID: "e1", UserID: "learner", CourseID: "course-1", Status: enrollment.StatusActive, DueAt: due.Unix(), EscalationLevel: enrollment.EscalationManager},
+	}}
+	notifier := &flakyNotifier{failFor: "hr-2"}
+	service := &DeadlineService{
+		Repo:     repo,
+		Users:    MockUserFinder{"learner": {ID: "learner", Name: "Lee"}},
+		Courses:  MockCourseRepository{"course-1": {ID: "course-1", Title: "Safety"}},
+		Notifier: notifier,
+		Policy:   EscalationPolicy{HRUserIDs: []string{"hr-1", "hr-2"}},
+	}
+
+	if err := service.RunJob(due.Add(24 * time.Hour)); err == nil {
+		t.Error("RunJob() error = nil, want the failed HR notification")
+	}
+	if e := repo.enrollments["e1"]; e.EscalationLevel != enrollment.EscalationManager || len(e.NotifiedHR) != 1 {
+		t.Errorf("after a partial failure level = %v, notified %v; want manager level and hr-1 recorded", e.EscalationLevel, e.NotifiedHR)
+	}
+
+	notifier.failFor = ""
+	if err := service.RunJob(due.Add(48 * time.Hour)); err != nil {
+		t.Fatalf("RunJob() retry error = %v", err)
+	}
+	if want := []string{"hr-1", "hr-2"}; len(notifier.recipients) != 2 || notifier.recipients[0] != want[0] || notifier.recipients[1] != want[1] {
+		t.Errorf("notified %v, want each HR user once: %v", notifier.recipients, want)
+	}
+	if e := repo.enrollments["e1"]; e.EscalationLevel != enrollment.EscalationHR {
+		t.Errorf("level = %v, want HR after the retry", e.EscalationLevel)
+	}
+}
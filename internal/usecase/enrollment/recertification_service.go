@@ -0,0 +1,223 @@
+// File: internal/usecase/enrollment/recertification_service.go
+package enrollment
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/notification"
+
+	"github.com/google/uuid"
+)
+
+// CycleRepository is the persistence contract used by RecertificationService.
+type CycleRepository interface {
+	// Create stores a new cycle and marks the user's current cycle for the
+	// course, if any, as renewed.
+	Create(c *enrollment.Cycle) error
+	Update(c *enrollment.Cycle) error
+	FindCurrent(userID, courseID string) (*enrollment.Cycle, error)
+	// ListCurrent returns current cycles; empty filters span all users or courses.
+	ListCurrent(userID, courseID string) ([]*enrollment.Cycle, error)
+	// ListHistory returns all cycles of a user in a course, oldest first.
+	ListHistory(userID, courseID string) ([]*enrollment.Cycle, error)
+}
+
+// RecertificationPolicy configures renewal of recurring certifications.
+type RecertificationPolicy struct {
+	RenewBefore    time.Duration // re-enroll the learner this long before expiry
+	ExpiringWithin time.Duration // report certifications expiring within this window as expiring soon
+	ReminderDays   []int         // days before expiry on which to remind the learner
+}
+
+// RecertificationService tracks certification cycles for courses that must be
+// retaken periodically. Each completion starts a cycle that expires after the
+// course's recertification interval; ahead of expiry the learner is
+// re-enrolled with the expiry as due date, so overdue escalation applies if
+// they do not finish in time.
+type RecertificationService struct {
+	Repo        CycleRepository
+	Enrollments *EnrollmentService
+	Deadlines   *DeadlineService
+	Notifier    Notifier
+	Policy      RecertificationPolicy
+}
+
+// RecordCompletion starts a new certification cycle for a completed
+// enrollment. Courses without a recertification interval are ignored.
+func (s *RecertificationService) RecordCompletion(e *enrollment.Enrollment) error {
+	c, err := s.Enrollments.course(e.CourseID)
+	if err != nil {
+		return err
+	}
+	if c.RecertificationMonths <= 0 {
+		return nil
+	}
+	previous, err := s.Repo.FindCurrent(e.UserID, e.CourseID)
+	if err != nil {
+		return err
+	}
+	number := 1
+	if previous != nil {
+		number = previous.Number + 1
+	}
+	return s.Repo.Create(&enrollment.Cycle{
+		ID:           uuid.New().String(),
+		UserID:       e.UserID,
+		CourseID:     e.CourseID,
+		EnrollmentID: e.ID,
+		Number:       number,
+		CompletedAt:  e.UpdatedAt,
+		ExpiresAt:    enrollment.ExpiryAfter(e.UpdatedAt, c.RecertificationMonths),
+	})
+}
+
+// ProcessRenewals re-enrolls learners whose certification is due for renewal
+// and sends expiry reminders. It returns the number of learners re-enrolled.
+func (s *RecertificationService) ProcessRenewals(now time.Time) (int, error) {
+	cycles, err := s.Repo.ListCurrent("", "")
+	if err != nil {
+		return 0, err
+	}
+	renewed := 0
+	for _, c := range cycles {
+		changed := false
+		if c.RenewalStartedAt == 0 && !now.Before(time.Unix(c.ExpiresAt, 0).Add(-s.Policy.RenewBefore)) {
+			if err := s.renew(c, now); err != nil {
+				log.Printf("recertification: renewal failed for cycle %s: %v", c.ID, err)
+				continue
+			}
+			changed = true
+			renewed++
+		}
+		if due := s.remindersDue(c, now); due > c.RemindersSent {
+			if err := s.remind(c, now); err != nil {
+				log.Printf("recertification: reminder failed for cycle %s: %v", c.ID, err)
+			} else {
+				c.RemindersSent = due
+				changed = true
+			}
+		}
+		if changed {
+			if err := s.Repo.Update(c); err != nil {
+				return renewed, err
+			}
+		}
+	}
+	return renewed, nil
+}
+
+// Compliance returns the current compliance status for recurring courses,
+// filtered by user and/or course.
+func (s *RecertificationService) Compliance(userID, courseID string) ([]*enrollment.ComplianceRecord, error) {
+	cycles, err := s.Repo.ListCurrent(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	warn := int64(s.Policy.ExpiringWithin / time.Second)
+	records := make([]*enrollment.ComplianceRecord, 0, len(cycles))
+	for _, c := range cycles {
+		records = append(records, &enrollment.ComplianceRecord{
+			UserID:      c.UserID,
+			CourseID:    c.CourseID,
+			Cycle:       c.Number,
+			CompletedAt: c.CompletedAt,
+			ExpiresAt:   c.ExpiresAt,
+			Status:      c.Compliance(now, warn),
+		})
+	}
+	return records, nil
+}
+
+// History returns every certification cycle of a user in a course, oldest first.
+func (s *RecertificationService) History(userID, courseID string) ([]*enrollment.Cycle, error) {
+	if userID == "" || courseID == "" {
+		return nil, errors.New("user_id and course_id are required")
+	}
+	return s.Repo.ListHistory(userID, courseID)
+}
+
+// RunJob processes renewals; it is run by the scheduler.
+func (s *RecertificationService) RunJob(now time.Time) error {
+	n, err := s.ProcessRenewals(now)
+	if n > 0 {
+		log.Printf("recertification: re-enrolled %d learners", n)
+	}
+	return err
+}
+
+// renew re-enrolls the learner in the cycle's course with the expiry as due
+// date, or waitlists them when the course is full. If the enrollment has
+// already moved on, for example because staff re-enrolled the learner by hand,
+// only the cycle is marked.
+//
+// The due date is saved before the status so that a failure in between
+// leaves the enrollment completed and the next run retries both.
+func (s *RecertificationService) renew(c *enrollment.Cycle, now time.Time) error {
+	e, err := s.Enrollments.Repo.FindByID(c.EnrollmentID)
+	if err != nil {
+		return err
+	}
+	if e != nil && e.Status == enrollment.StatusCompleted {
+		crs, err := s.Enrollments.course(c.CourseID)
+		if err != nil {
+			return err
+		}
+		if s.Deadlines != nil {
+			if e, err = s.Deadlines.SetDeadline(e.ID, c.ExpiresAt, 0, ""); err != nil {
+				return err
+			}
+		}
+		if err := s.Enrollments.admit(e, crs, "", "recertification"); err != nil {
+			return err
+		}
+		vars := map[string]string{
+			"course_title": s.courseTitle(c.CourseID),
+			"expiry_date":  time.Unix(c.ExpiresAt, 0).Format("2006-01-02"),
+		}
+		if err := s.Notifier.Notify(c.UserID, notification.EventRecertificationRequired, vars); err != nil {
+			log.Printf("recertification: notifying user %s failed: %v", c.UserID, err)
+		}
+	}
+	c.RenewalStartedAt = now.Unix()
+	return nil
+}
+
+// remindersDue returns how many of the configured reminders should have been
+// sent by now. No reminders are due once the certification has expired.
+func (s *RecertificationService) remindersDue(c *enrollment.Cycle, now time.Time) int {
+	expires := time.Unix(c.ExpiresAt, 0)
+	if !now.Before(expires) {
+		return c.RemindersSent
+	}
+	due := 0
+	for _, days := range s.Policy.ReminderDays {
+		if !now.Before(expires.AddDate(0, 0, -days)) {
+			due++
+		}
+	}
+	return due
+}
+
+func (s *RecertificationService) remind(c *enrollment.Cycle, now time.Time) error {
+	days := int(math.Ceil(time.Unix(c.ExpiresAt, 0).Sub(now).Hours() / 24))
+	return s.Notifier.Notify(c.UserID, notification.EventCertificationExpiring, map[string]string{
+		"course_title": s.courseTitle(c.CourseID),
+		"days":         strconv.Itoa(days),
+		"expiry_date":  time.Unix(c.ExpiresAt, 0).Format("2006-01-02"),
+	})
+}
+
+// courseTitle returns the course's title for messages, its ID if it is gone.
+func (s *RecertificationService) courseTitle(courseID string) string {
+	c, err := s.Enrollments.Courses.FindByID(courseID)
+	if err != nil || c == nil {
+		return courseID
+	}
+	return c.Title
+}
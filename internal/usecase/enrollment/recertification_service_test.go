@@ -0,0 +1,164 @@
+package enrollment
+
+import (
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
+)
+
+// MockCycleRepository is an in-memory implementation of CycleRepository
+type MockCycleRepository struct {
+	cycles []*enrollment.Cycle
+}
+
+func (m *MockCycleRepository) Create(c *enrollment.Cycle) error {
+	for _, existing := range m.cycles {
+		if existing.UserID == c.UserID && existing.CourseID == c.CourseID && existing.Current() {
+			existing.RenewedAt = c.CompletedAt
+		}
+	}
+	m.cycles = append(m.cycles, c)
+	return nil
+}
+
+func (m *MockCycleRepository) Update(c *enrollment.Cycle) error { return nil }
+
+func (m *MockCycleRepository) FindCurrent(userID, courseID string) (*enrollment.Cycle, error) {
+	for _, c := range m.cycles {
+		if c.UserID == userID && c.CourseID == courseID && c.Current() {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockCycleRepository) ListCurrent(userID, courseID string) ([]*enrollment.Cycle, error) {
+	var out []*enrollment.Cycle
+	for _, c := range m.cycles {
+		if c.Current() && (userID == "" || c.UserID == userID) && (courseID == "" || c.CourseID == courseID) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockCycleRepository) ListHistory(userID, courseID string) ([]*enrollment.Cycle, error) {
+	var out []*enrollment.Cycle
+	for _, c := range m.cycles {
+		if c.UserID == userID && c.CourseID == courseID {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func TestRecertificationService_Cycle(t *testing.T) {
+	enrollments, repo := newTestService(&course.Course{ID: "safety", RecertificationMonths: 12}, &course.Course{ID: "intro"})
+	cycles := &MockCycleRepository{}
+	notifier := &MockNotifier{}
+	service := &RecertificationService{
+		Repo:        cycles,
+		Enrollments: enrollments,
+		Notifier:    notifier,
+		Policy: RecertificationPolicy{
+			RenewBefore:    30 * 24 * time.Hour,
+			ExpiringWithin: 30 * 24 * time.Hour,
+			ReminderDays:   []int{30, 7},
+		},
+	}
+	enrollments.Completions = []CompletionRecorder{service}
+
+	for _, courseID := range []string{"safety", "intro"} {
+		enrollments.Enroll("user-1", courseID, "user-1")
+		if _, err := enrollments.Complete("user-1", courseID, "trainer-1"); err != nil {
+			t.Fatalf("Complete(%s) error = %v", courseID, err)
+		}
+	}
+	if len(cycles.cycles) != 1 {
+		t.Fatalf("cycles = %d, want 1 for the recurring course only", len(cycles.cycles))
+	}
+	first := cycles.cycles[0]
+	if want := time.Unix(first.CompletedAt, 0).UTC().AddDate(1, 0, 0).Unix(); first.ExpiresAt != want {
+		t.Errorf("ExpiresAt = %d, want %d", first.ExpiresAt, want)
+	}
+
+	expires := time.Unix(first.ExpiresAt, 0)
+	if n, _ := service.ProcessRenewals(expires.AddDate(0, 0, -40)); n != 0 || len(notifier.recipients) != 0 {
+		t.Errorf("ProcessRenewals() before the renewal window renewed %d, notified %d", n, len(notifier.recipients))
+	}
+
+	if n, _ := service.ProcessRenewals(expires.AddDate(0, 0, -29)); n != 1 {
+		t.Errorf("ProcessRenewals() renewed = %d, want 1", n)
+	}
+	e, _ := repo.FindByUserAndCourse("user-1", "safety")
+	if e.Status != enrollment.StatusActive {
+		t.Errorf("Status after renewal = %s, want active", e.Status)
+	}
+	if first.RemindersSent != 1 || len(notifier.recipients) != 2 {
+		t.Errorf("RemindersSent = %d, notifications = %d, want 1 and 2", first.RemindersSent, len(notifier.recipients))
+	}
+	records, _ := service.Compliance("user-1", "")
+	if len(records) != 1 || records[0].Status != enrollment.ComplianceCompliant {
+		// Compliance is evaluated against the wall clock, where the cycle is fresh.
+		t.Errorf("Compliance() = %+v, want one compliant record", records)
+	}
+
+	service.ProcessRenewals(expires.AddDate(0, 0, -6))
+	if n, _ := service.ProcessRenewals(expires.AddDate(0, 0, -5)); n != 0 || first.RemindersSent != 2 || len(notifier.recipients) != 3 {
+		t.Errorf("second reminder: renewed %d, RemindersSent = %d, notifications = %d", n, first.RemindersSent, len(notifier.recipients))
+	}
+
+	if _, err := enrollments.Complete("user-1", "safety", "trainer-1"); err != nil {
+		t.Fatalf("Complete() recertification error = %v", err)
+	}
+	history, _ := service.History("user-1", "safety")
+	if len(history) != 2 || history[0].Current() || !history[1].Current() || history[1].Number != 2 {
+		t.Errorf("History() = %+v, want the first cycle renewed by a second", history)
+	}
+}
+
+func TestCycle_Compliance(t *testing.T) {
+	c := &enrollment.Cycle{ExpiresAt: 1000}
+	tests := []struct {
+		now  int64
+		want enrollment.ComplianceStatus
+	}{
+		{800, enrollment.ComplianceCompliant},
+		{950, enrollment.ComplianceExpiringSoon},
+		{1000, enrollment.ComplianceExpired},
+	}
+	for _, tt := range tests {
+		if got := c.Compliance(tt.now, 100); got != tt.want {
+			t.Errorf("Compliance(%d) = %s, want %s", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestRecertificationService_RenewalRespectsSeats(t *testing.T) {
+	enrollments, repo := newTestService(&course.Course{ID: "safety", RecertificationMonths: 12, SeatCapacity: 1})
+	cycles := &MockCycleRepository{}
+	service := &RecertificationService{
+		Repo:        cycles,
+		Enrollments: enrollments,
+		Notifier:    &MockNotifier{},
+		Policy:      RecertificationPolicy{RenewBefore: 30 * 24 * time.Hour},
+	}
+	enrollments.Completions = []CompletionRecorder{service}
+
+	enrollments.Enroll("user-1", "safety", "user-1")
+	if _, err := enrollments.Complete("user-1", "safety", "trainer-1"); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	enrollments.Enroll("user-2", "safety", "user-2")
+
+	expires := time.Unix(cycles.cycles[0].ExpiresAt, 0)
+	if n, _ := service.ProcessRenewals(expires.AddDate(0, 0, -29)); n != 1 {
+		t.Errorf("ProcessRenewals() renewed = %d, want 1", n)
+	}
+	e, _ := repo.FindByUserAndCourse("user-1", "safety")
+	if e.Status != enrollment.StatusWaitlisted {
+		t.Errorf("Status after renewal in a full course = %s, want waitlisted", e.Status)
+	}
+}
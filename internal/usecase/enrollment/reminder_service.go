@@ -0,0 +1,130 @@
+package enrollment
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/notification"
+)
+
+// ReminderRepository is the persistence contract used by ReminderService.
+type ReminderRepository interface {
+	// ListCandidates returns active enrollments with when they became active
+	// and the learner's latest progress in the course.
+	ListCandidates() ([]*enrollment.ReminderCandidate, error)
+	// MarkSent records that a step was sent for an enrollment and anchor
+	// time, and reports false if it already had been.
+	MarkSent(enrollmentID, step string, anchorAt, sentAt int64) (bool, error)
+}
+
+// ReminderService sends learners a configurable sequence of reminders for
+// courses they have not completed. Each step is sent at most once per
+// enrollment and anchor, so moving a deadline or re-enrolling starts the
+// affected steps over. Overdue enrollments are left to DeadlineService.
+type ReminderService struct {
+	Repo     ReminderRepository
+	Courses  CourseRepository
+	Notifier Notifier
+	Steps    []enrollment.ReminderStep
+	// Window is how late a step may still be sent, e.g. after downtime or
+	// when a deadline is set close to now; 0 means one day.
+	Window time.Duration
+}
+
+// ValidateReminderSteps checks that step names are unique and days are not negative.
+func ValidateReminderSteps(steps []enrollment.ReminderStep) error {
+	seen := map[string]bool{}
+	for _, st := range steps {
+		if st.Name == "" || seen[st.Name] {
+			return fmt.Errorf("reminder step names must be unique and non-empty: %q", st.Name)
+		}
+		seen[st.Name] = true
+		if st.Anchor != enrollment.AnchorEnrolled && st.Anchor != enrollment.AnchorDue {
+			return fmt.Errorf("reminder step %s: unknown anchor %q", st.Name, st.Anchor)
+		}
+		if st.Days < 0 {
+			return fmt.Errorf("reminder step %s: days must not be negative", st.Name)
+		}
+	}
+	return nil
+}
+
+// ProcessReminders sends the reminder steps due at now and returns how many
+// were sent.
+func (s *ReminderService) ProcessReminders(now time.Time) (int, error) {
+	if len(s.Steps) == 0 {
+		return 0, nil
+	}
+	if s.Notifier == nil {
+		return 0, errors.New("reminders require a notifier")
+	}
+	window := s.Window
+	if window <= 0 {
+		window = 24 * time.Hour
+	}
+	candidates, err := s.Repo.ListCandidates()
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, c := range candidates {
+		if c.Enrollment.OverdueAt != 0 {
+			continue
+		}
+		for _, step := range s.Steps {
+			at := step.SendAt(c)
+			if at == 0 || now.Unix() < at || !now.Before(time.Unix(at, 0).Add(window)) {
+				continue
+			}
+			// Recorded before sending so replicas and retries never send twice.
+			first, err := s.Repo.MarkSent(c.Enrollment.ID, step.Name, step.AnchorAt(c), now.Unix())
+			if err != nil {
+				return sent, err
+			}
+			if !first {
+				continue
+			}
+			if err := s.remind(c, step); err != nil {
+				log.Printf("reminders: step %s failed for enrollment %s: %v", step.Name, c.Enrollment.ID, err)
+				continue
+			}
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// RunJob sends due reminders; it is run by the scheduler.
+func (s *ReminderService) RunJob(now time.Time) error {
+	n, err := s.ProcessReminders(now)
+	if n > 0 {
+		log.Printf("reminders: sent %d reminders", n)
+	}
+	return err
+}
+
+func (s *ReminderService) remind(c *enrollment.ReminderCandidate, step enrollment.ReminderStep) error {
+	e := c.Enrollment
+	vars := map[string]string{
+		"course_title": s.courseTitle(e.CourseID),
+		"days":         strconv.Itoa(step.Days),
+	}
+	event := notification.EventCourseReminder
+	if step.Anchor == enrollment.AnchorDue {
+		event = notification.EventDueSoon
+		vars["due_date"] = time.Unix(e.DueAt, 0).Format("2006-01-02")
+	}
+	return s.Notifier.Notify(e.UserID, event, vars)
+}
+
+func (s *ReminderService) courseTitle(courseID string) string {
+	c, err := s.Courses.FindByID(courseID)
+	if err != nil || c == nil {
+		return courseID
+	}
+	return c.Title
+}
@@ -0,0 +1,102 @@
+package enrollment
+
+import (
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/notification"
+)
+
+// MockReminderRepository serves fixed candidates and records sent steps
+type MockReminderRepository struct {
+	candidates []*enrollment.ReminderCandidate
+	sent       map[string]bool
+}
+
+func (m *MockReminderRepository) ListCandidates() ([]*enrollment.ReminderCandidate, error) {
+	return m.candidates, nil
+}
+
+func (m *MockReminderRepository) MarkSent(enrollmentID, step string, anchorAt, sentAt int64) (bool, error) {
+	key := enrollmentID + "/" + step + "/" + time.Unix(anchorAt, 0).String()
+	if m.sent[key] {
+		return false, nil
+	}
+	m.sent[key] = true
+	return true, nil
+}
+
+func TestReminderService_Sequence(t *testing.T) {
+	day := 24 * time.Hour
+	enrolled := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	due := enrolled.Add(20 * day)
+	idle := &enrollment.ReminderCandidate{
+		Enrollment:  &enrollment.Enrollment{ID: "e1", UserID: "learner", CourseID: "course-1", Status: enrollment.StatusActive, DueAt: due.Unix()},
+		ActiveSince: enrolled.Unix(),
+	}
+	busy := &enrollment.ReminderCandidate{
+		Enrollment:     &enrollment.Enrollment{ID: "e2", UserID: "busy", CourseID: "course-1", Status: enrollment.StatusActive},
+		ActiveSince:    enrolled.Unix(),
+		LastActivityAt: enrolled.Add(day).Unix(),
+	}
+	repo := &MockReminderRepository{candidates: []*enrollment.ReminderCandidate{idle, busy}, sent: map[string]bool{}}
+	notifier := &MockNotifier{}
+	service := &ReminderService{
+		Repo:     repo,
+		Courses:  MockCourseRepository{"course-1": &course.Course{ID: "course-1", Title: "Safety"}},
+		Notifier: notifier,
+		Steps: []enrollment.ReminderStep{
+			{Name: "no_activity", Anchor: enrollment.AnchorEnrolled, Days: 3},
+			{Name: "due_in_7_days", Anchor: enrollment.AnchorDue, Days: 7},
+			{Name: "due_today", Anchor: enrollment.AnchorDue, Days: 0},
+		},
+	}
+
+	steps := []struct {
+		at   time.Time
+		want int
+	}{
+		{enrolled.Add(2 * day), 0},
+		{enrolled.Add(3*day + time.Hour), 1},   // no activity for three days
+		{enrolled.Add(3*day + 2*time.Hour), 0}, // sent once
+		{due.Add(-7 * day), 1},
+		{due.Add(time.Hour), 1},
+		{due.Add(2 * day), 0},
+	}
+	for _, step := range steps {
+		sent, err := service.ProcessReminders(step.at)
+		if err != nil || sent != step.want {
+			t.Errorf("ProcessReminders(%v) = %d, %v, want %d", step.at, sent, err, step.want)
+		}
+	}
+	want := []notification.EventType{notification.EventCourseReminder, notification.EventDueSoon, notification.EventDueSoon}
+	if len(notifier.events) != len(want) {
+		t.Fatalf("events = %v, want %v", notifier.events, want)
+	}
+	for i := range want {
+		if notifier.events[i] != want[i] || notifier.recipients[i] != "learner" {
+			t.Errorf("reminder %d = %s to %s, want %s to learner", i, notifier.events[i], notifier.recipients[i], want[i])
+		}
+	}
+
+	// Moving the deadline starts the due-date steps over.
+	idle.Enrollment.DueAt = due.Add(7 * day).Unix()
+	if sent, _ := service.ProcessReminders(due); sent != 1 {
+		t.Errorf("ProcessReminders() after moving the deadline = %d, want 1", sent)
+	}
+}
+
+func TestValidateReminderSteps(t *testing.T) {
+	invalid := [][]enrollment.ReminderStep{
+		{{Name: "a", Anchor: enrollment.AnchorDue}, {Name: "a", Anchor: enrollment.AnchorDue}},
+		{{Name: "a", Anchor: "completed"}},
+		{{Name: "a", Anchor: enrollment.AnchorDue, Days: -1}},
+	}
+	for _, steps := range invalid {
+		if err := ValidateReminderSteps(steps); err == nil {
+			t.Errorf("ValidateReminderSteps(%+v) expected error", steps)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// File: internal/usecase/enrollment/rule_service.go
+package enrollment
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+var ErrRuleNotFound = errors.New("enrollment rule not found")
+
+// RuleRepository is the persistence contract used by RuleService.
+type RuleRepository interface {
+	FindByID(id string) (*enrollment.Rule, error)
+	Create(r *enrollment.Rule) error
+	Update(r *enrollment.Rule) error
+	Delete(id string) error
+	List() ([]*enrollment.Rule, error)
+	RecordEnrollment(a *enrollment.RuleEnrollment) error
+	ListAudit(ruleID string) ([]*enrollment.RuleEnrollment, error)
+}
+
+// UserLister lists all users for rule evaluation.
+type UserLister interface {
+	List() ([]*user.User, error)
+}
+
+// RulePreview summarises how many users a rule would affect.
+type RulePreview struct {
+	MatchingUsers  int // users the rule matches
+	NewEnrollments int // matching users not yet enrolled in the course
+}
+
+// RuleService manages auto-enrollment rules and applies them to users.
+// Users who already have an enrollment in the course, in any status, are
+// left alone so that staff decisions such as a drop are not undone.
+type RuleService struct {
+	Repo        RuleRepository
+	Users       UserLister
+	Enrollments *EnrollmentService
+}
+
+// ValidateRule checks that a rule is complete and uses a known field.
+func ValidateRule(r *enrollment.Rule) error {
+	if r == nil {
+		return errors.New("rule is required")
+	}
+	if r.Name == "" || r.Value == "" || r.CourseID == "" {
+		return errors.New("name, value and course_id are required")
+	}
+	if r.Field != enrollment.RuleFieldRole && r.Field != enrollment.RuleFieldDepartment {
+		return errors.New("invalid rule field")
+	}
+	return nil
+}
+
+// CreateRule stores a new rule. It takes effect on the next evaluation.
+func (s *RuleService) CreateRule(r *enrollment.Rule) error {
+	if err := ValidateRule(r); err != nil {
+		return err
+	}
+	r.ID = uuid.New().String()
+	r.CreatedAt = time.Now().Unix()
+	return s.Repo.Create(r)
+}
+
+// UpdateRule modifies an existing rule.
+func (s *RuleService) UpdateRule(r *enrollment.Rule) error {
+	if err := ValidateRule(r); err != nil {
+		return err
+	}
+	if _, err := s.GetRule(r.ID); err != nil {
+		return err
+	}
+	return s.Repo.Update(r)
+}
+
+// DeleteRule removes a rule. Enrollments it created are kept.
+func (s *RuleService) DeleteRule(id string) error {
+	if _, err := s.GetRule(id); err != nil {
+		return err
+	}
+	return s.Repo.Delete(id)
+}
+
+// GetRule retrieves a rule by ID.
+func (s *RuleService) GetRule(id string) (*enrollment.Rule, error) {
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+	r, err := s.Repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrRuleNotFound
+	}
+	return r, nil
+}
+
+// ListRules returns all rules.
+func (s *RuleService) ListRules() ([]*enrollment.Rule, error) {
+	return s.Repo.List()
+}
+
+// Audit returns the enrollments a rule has created.
+func (s *RuleService) Audit(ruleID string) ([]*enrollment.RuleEnrollment, error) {
+	if _, err := s.GetRule(ruleID); err != nil {
+		return nil, err
+	}
+	return s.Repo.ListAudit(ruleID)
+}
+
+// Preview reports how many users a rule would match and newly enroll, without
+// changing anything. The rule does not need to be saved.
+func (s *RuleService) Preview(r *enrollment.Rule) (*RulePreview, error) {
+	if err := ValidateRule(r); err != nil {
+		return nil, err
+	}
+	users, err := s.Users.List()
+	if err != nil {
+		return nil, err
+	}
+	preview := &RulePreview{}
+	for _, u := range users {
+		if !r.Matches(u) {
+			continue
+		}
+		preview.MatchingUsers++
+		existing, err := s.Enrollments.Repo.FindByUserAndCourse(u.ID, r.CourseID)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			preview.NewEnrollments++
+		}
+	}
+	return preview, nil
+}
+
+// EvaluateUser applies all active rules to a single user, typically after the
+// user is created or updated. It returns the number of enrollments created.
+// A failing rule does not stop the others; the failures are returned together.
+func (s *RuleService) EvaluateUser(u *user.User) (int, error) {
+	rules, err := s.Repo.List()
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	var errs []error
+	for _, r := range rules {
+		if !r.Active || !r.Matches(u) {
+			continue
+		}
+		ok, err := s.apply(r, u)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %w", r.Name, err))
+			continue
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+// EvaluateAll applies all active rules to every user and returns the number of
+// enrollments created. A failure for one user does not stop the others; the
+// failures are returned together.
+func (s *RuleService) EvaluateAll() (int, error) {
+	users, err := s.Users.List()
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	var errs []error
+	for _, u := range users {
+		n, err := s.EvaluateUser(u)
+		created += n
+		if err != nil {
+			log.Printf("auto-enrollment: evaluating rules for user %s failed: %v", u.ID, err)
+			errs = append(errs, err)
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+// RunJob re-evaluates all rules; it is run by the scheduler.
+func (s *RuleService) RunJob(now time.Time) error {
+	n, err := s.EvaluateAll()
+	if n > 0 {
+		log.Printf("auto-enrollment: created %d enrollments", n)
+	}
+	return err
+}
+
+// apply enrolls the user into the rule's course unless they already have an
+// enrollment there, and records the result in the rule's audit trail.
+func (s *RuleService) apply(r *enrollment.Rule, u *user.User) (bool, error) {
+	existing, err := s.Enrollments.Repo.FindByUserAndCourse(u.ID, r.CourseID)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		return false, nil
+	}
+	e, err := s.Enrollments.Assign(u.ID, r.CourseID, "")
+	if err != nil {
+		return false, err
+	}
+	return true, s.Repo.RecordEnrollment(&enrollment.RuleEnrollment{
+		ID:           uuid.New().String(),
+		RuleID:       r.ID,
+		UserID:       u.ID,
+		EnrollmentID: e.ID,
+		Status:       e.Status,
+		EnrolledAt:   time.Now().Unix(),
+	})
+}
@@ -0,0 +1,188 @@
+package enrollment
+
+import (
+	"errors"
+	"testing"
+
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/user"
+)
+
+// MockRuleRepository is an in-memory implementation of RuleRepository
+type MockRuleRepository struct {
+	rules []*enrollment.Rule
+	audit []*enrollment.RuleEnrollment
+}
+
+func (m *MockRuleRepository) FindByID(id string) (*enrollment.Rule, error) {
+	for _, r := range m.rules {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockRuleRepository) Create(r *enrollment.Rule) error {
+	m.rules = append(m.rules, r)
+	return nil
+}
+
+func (m *MockRuleRepository) Update(r *enrollment.Rule) error { return nil }
+
+func (m *MockRuleRepository) Delete(id string) error { return nil }
+
+func (m *MockRuleRepository) List() ([]*enrollment.Rule, error) { return m.rules, nil }
+
+func (m *MockRuleRepository) RecordEnrollment(a *enrollment.RuleEnrollment) error {
+	m.audit = append(m.audit, a)
+	return nil
+}
+
+func (m *MockRuleRepository) ListAudit(ruleID string) ([]*enrollment.RuleEnrollment, error) {
+	var out []*enrollment.RuleEnrollment
+	for _, a := range m.audit {
+		if a.RuleID == ruleID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// MockUserLister returns a fixed set of users
+type MockUserLister []*user.User
+
+func (m MockUserLister) List() ([]*user.User, error) { return m, nil }
+
+func TestRuleService_EvaluateAll(t *testing.T) {
+	enrollments, _ := newTestService(&course.Course{ID: "course-1"})
+	users := MockUserLister{
+		{ID: "user-1", Role: user.RoleTrainer},
+		{ID: "user-2", Role: user.RoleEmployee, Department: "Sales"},
+		{ID: "user-3", Role: user.RoleTrainer, Department: "Sales"},
+	}
+	rules := &MockRuleRepository{}
+	service := &RuleService{Repo: rules, Users: users, Enrollments: enrollments}
+
+	rule := &enrollment.Rule{Name: "Trainers", Field: enrollment.RuleFieldRole, Value: "trainer", CourseID: "course-1", Active: true}
+	if err := service.CreateRule(rule); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	preview, err := service.Preview(rule)
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	if preview.MatchingUsers != 2 || preview.NewEnrollments != 2 {
+		t.Errorf("Preview() = %+v, want 2 matching and 2 new", preview)
+	}
+
+	created, err := service.EvaluateAll()
+	if err != nil {
+		t.Fatalf("EvaluateAll() error = %v", err)
+	}
+	if created != 2 {
+		t.Errorf("EvaluateAll() created = %d, want 2", created)
+	}
+
+	// A second pass must not enroll anyone again.
+	if created, _ := service.EvaluateAll(); created != 0 {
+		t.Errorf("EvaluateAll() second pass created = %d, want 0", created)
+	}
+
+	audit, err := service.Audit(rule.ID)
+	if err != nil {
+		t.Fatalf("Audit() error = %v", err)
+	}
+	if len(audit) != 2 {
+		t.Errorf("Audit() len = %d, want 2", len(audit))
+	}
+	if ok, _ := enrollments.IsEnrolled("user-2", "course-1"); ok {
+		t.Error("non-matching user was enrolled")
+	}
+}
+
+// failingRepository fails every lookup for one user.
+type failingRepository struct {
+	*MockEnrollmentRepository
+	userID string
+}
+
+func (r failingRepository) FindByUserAndCourse(userID, courseID string) (*enrollment.Enrollment, error) {
+	if userID == r.userID {
+		return nil, errors.New("connection reset")
+	}
+	return r.MockEnrollmentRepository.FindByUserAndCourse(userID, courseID)
+}
+
+func TestRuleService_EvaluateAllContinuesAfterFailure(t *testing.T) {
+	enrollments, repo := newTestService(&course.Course{ID: "course-1"})
+	enrollments.Repo = failingRepository{repo, "user-1"}
+	users := MockUserLister{
+		{ID: "user-1", Role: user.RoleTrainer},
+		{ID: "user-2", Role: user.RoleTrainer},
+	}
+	service := &RuleService{Repo: &MockRuleRepository{}, Users: users, Enrollments: enrollments}
+	rule := &enrollment.Rule{Name: "Trainers", Field: enrollment.RuleFieldRole, Value: "trainer", CourseID: "course-1", Active: true}
+	if err := service.CreateRule(rule); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	created, err := service.EvaluateAll()
+	if err == nil {
+		t.Error("EvaluateAll() error = nil, want the failure for user-1")
+	}
+	if created != 1 {
+		t.Errorf("EvaluateAll() created = %d, want 1", created)
+	}
+	if ok, _ := enrollments.IsEnrolled("user-2", "course-1"); !ok {
+		t.Error("user after the failing one was not enrolled")
+	}
+}
+
+func TestRuleService_EvaluateUserContinuesAfterFailure(t *testing.T) {
+	enrollments, _ := newTestService(&course.Course{ID: "course-1"})
+	trainer := &user.User{ID: "user-1", Role: user.RoleTrainer}
+	rules := &MockRuleRepository{rules: []*enrollment.Rule{
+		{ID: "rule-1", Name: "Retired course", Field: enrollment.RuleFieldRole, Value: "trainer", CourseID: "course-gone", Active: true},
+		{ID: "rule-2", Name: "Trainers", Field: enrollment.RuleFieldRole, Value: "trainer", CourseID: "course-1", Active: true},
+	}}
+	service := &RuleService{Repo: rules, Users: MockUserLister{trainer}, Enrollments: enrollments}
+
+	created, err := service.EvaluateUser(trainer)
+	if err == nil {
+		t.Error("EvaluateUser() error = nil, want the failure of the first rule")
+	}
+	if created != 1 {
+		t.Errorf("EvaluateUser() created = %d, want 1", created)
+	}
+	if ok, _ := enrollments.IsEnrolled("user-1", "course-1"); !ok {
+		t.Error("rule after the failing one was not applied")
+	}
+}
+
+func TestRuleService_KeepsDroppedEnrollments(t *testing.T) {
+	enrollments, _ := newTestService(&course.Course{ID: "course-1"})
+	trainer := &user.User{ID: "user-1", Role: user.RoleTrainer}
+	service := &RuleService{Repo: &MockRuleRepository{}, Users: MockUserLister{trainer}, Enrollments: enrollments}
+	service.CreateRule(&enrollment.Rule{Name: "Trainers", Field: enrollment.RuleFieldRole, Value: "trainer", CourseID: "course-1", Active: true})
+
+	service.EvaluateUser(trainer)
+	if _, err := enrollments.Drop("user-1", "course-1", "admin-1", "exempt"); err != nil {
+		t.Fatalf("Drop() error = %v", err)
+	}
+
+	if created, _ := service.EvaluateUser(trainer); created != 0 {
+		t.Errorf("EvaluateUser() re-enrolled a dropped user")
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	if err := ValidateRule(&enrollment.Rule{Name: "x", Field: "location", Value: "y", CourseID: "c"}); err == nil {
+		t.Error("ValidateRule() with unknown field expected error")
+	}
+	if err := ValidateRule(&enrollment.Rule{Name: "x", Field: enrollment.RuleFieldDepartment, CourseID: "c"}); err == nil {
+		t.Error("ValidateRule() without value expected error")
+	}
+}
@@ -0,0 +1,457 @@
+// File: internal/usecase/enrollment/service.go
+package enrollment
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/realtime"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEnrollmentNotFound = errors.New("enrollment not found")
+	ErrInvalidTransition  = errors.New("invalid enrollment status transition")
+	ErrInvitationRequired = errors.New("course is invite-only")
+	ErrCourseNotFound     = errors.New("course not found")
+)
+
+// EnrollmentRepository is the persistence contract used by EnrollmentService.
+type EnrollmentRepository interface {
+	FindByID(id string) (*enrollment.Enrollment, error)
+	FindByUserAndCourse(userID, courseID string) (*enrollment.Enrollment, error)
+	Create(e *enrollment.Enrollment, change *enrollment.StatusChange) error
+	UpdateStatus(e *enrollment.Enrollment, change *enrollment.StatusChange) error
+	// ClaimSeat applies the change only while the course has fewer than capacity
+	// active enrollments, and reports whether it did.
+	ClaimSeat(e *enrollment.Enrollment, change *enrollment.StatusChange, capacity int) (bool, error)
+	List(userID, courseID string) ([]*enrollment.Enrollment, error)
+	// ListByStatus returns enrollments in the order they entered the status; an empty courseID spans all courses.
+	ListByStatus(courseID string, status enrollment.Status) ([]*enrollment.Enrollment, error)
+	ListHistory(enrollmentID string) ([]*enrollment.StatusChange, error)
+}
+
+// CourseRepository is the subset of course persistence EnrollmentService needs.
+type CourseRepository interface {
+	FindByID(id string) (*course.Course, error)
+}
+
+// Publisher pushes an event to a user's realtime stream.
+type Publisher interface {
+	Publish(userID string, t realtime.EventType, data interface{}) error
+}
+
+// StatusObserver is told about every saved status change.
+type StatusObserver interface {
+	EnrollmentChanged(e *enrollment.Enrollment, change *enrollment.StatusChange)
+}
+
+// CompletionRecorder is told about every completed enrollment.
+type CompletionRecorder interface {
+	RecordCompletion(e *enrollment.Enrollment) error
+}
+
+// EnrollmentService provides business logic for course enrollments.
+// Seats are held by active enrollments; when a course is full, admitted
+// learners are waitlisted and promoted in order as seats free up.
+type EnrollmentService struct {
+	Repo        EnrollmentRepository
+	Courses     CourseRepository
+	Completions []CompletionRecorder // run after each completion; failures are logged
+	Notifier    Notifier             // optional; tells learners about assigned courses
+	Events      Publisher            // optional; pushes status changes to the learner's realtime stream
+	Observers   []StatusObserver     // told about each status change after it is saved
+}
+
+// Enroll handles a learner's own enrollment request according to the course's
+// enrollment mode. Enrolling twice is idempotent: an existing active, completed,
+// pending or waitlisted enrollment is returned unchanged.
+func (s *EnrollmentService) Enroll(userID, courseID, actorID string) (*enrollment.Enrollment, error) {
+	if userID == "" || courseID == "" {
+		return nil, errors.New("user_id and course_id are required")
+	}
+	c, err := s.course(courseID)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.findOrNew(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	created := e.Status == ""
+	if err := s.enroll(e, c, actorID); err != nil {
+		if created {
+			if existing := s.lostRace(userID, courseID); existing != nil {
+				return existing, nil
+			}
+		}
+		if errors.Is(err, ErrInvitationRequired) {
+			return nil, err
+		}
+		return e, err
+	}
+	return e, nil
+}
+
+func (s *EnrollmentService) enroll(e *enrollment.Enrollment, c *course.Course, actorID string) error {
+	switch e.Status {
+	case enrollment.StatusActive, enrollment.StatusCompleted, enrollment.StatusPending, enrollment.StatusWaitlisted:
+		return nil
+	case enrollment.StatusInvited:
+		// An invitation counts as approval in every mode.
+		return s.admit(e, c, actorID, "")
+	}
+
+	switch c.EnrollmentMode {
+	case course.EnrollmentInvite:
+		return ErrInvitationRequired
+	case course.EnrollmentApproval:
+		return s.apply(e, enrollment.StatusPending, actorID, "")
+	default:
+		return s.admit(e, c, actorID, "")
+	}
+}
+
+// lostRace returns the enrollment a concurrent request created after
+// findOrNew found none, whose insert made ours fail on the unique
+// (user, course) constraint.
+func (s *EnrollmentService) lostRace(userID, courseID string) *enrollment.Enrollment {
+	existing, err := s.Repo.FindByUserAndCourse(userID, courseID)
+	if err != nil {
+		return nil
+	}
+	return existing
+}
+
+// Assign enrolls a user on behalf of staff, bypassing the enrollment mode.
+// Seat capacity still applies. Existing enrollments other than pending, invited,
+// dropped or rejected are returned unchanged.
+func (s *EnrollmentService) Assign(userID, courseID, actorID string) (*enrollment.Enrollment, error) {
+	if userID == "" || courseID == "" {
+		return nil, errors.New("user_id and course_id are required")
+	}
+	c, err := s.course(courseID)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.findOrNew(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	switch e.Status {
+	case enrollment.StatusActive, enrollment.StatusCompleted, enrollment.StatusWaitlisted:
+		return e, nil
+	}
+	created := e.Status == ""
+	if err := s.admit(e, c, actorID, ""); err != nil {
+		if created {
+			if existing := s.lostRace(userID, courseID); existing != nil {
+				return existing, nil
+			}
+		}
+		return e, err
+	}
+	if e.Status == enrollment.StatusActive && s.Notifier != nil {
+		if err := s.Notifier.Notify(userID, notification.EventCourseAssigned, map[string]string{"course_title": c.Title}); err != nil {
+			log.Printf("enrollment: notifying user %s of course %s failed: %v", userID, courseID, err)
+		}
+	}
+	return e, nil
+}
+
+// Invite invites a user to a course. The learner accepts by enrolling.
+func (s *EnrollmentService) Invite(userID, courseID, actorID string) (*enrollment.Enrollment, error) {
+	if userID == "" || courseID == "" {
+		return nil, errors.New("user_id and course_id are required")
+	}
+	if _, err := s.course(courseID); err != nil {
+		return nil, err
+	}
+	e, err := s.findOrNew(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	switch e.Status {
+	case "", enrollment.StatusDropped, enrollment.StatusRejected:
+		return e, s.apply(e, enrollment.StatusInvited, actorID, "")
+	}
+	return e, nil
+}
+
+// Approve admits a pending enrollment request, waitlisting it if the course is full.
+func (s *EnrollmentService) Approve(id, actorID, reason string) (*enrollment.Enrollment, error) {
+	e, err := s.GetEnrollment(id)
+	if err != nil {
+		return nil, err
+	}
+	if e.Status != enrollment.StatusPending {
+		return nil, ErrInvalidTransition
+	}
+	c, err := s.course(e.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	return e, s.admit(e, c, actorID, reason)
+}
+
+// Reject declines a pending enrollment request. A reason is required.
+func (s *EnrollmentService) Reject(id, actorID, reason string) (*enrollment.Enrollment, error) {
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	e, err := s.GetEnrollment(id)
+	if err != nil {
+		return nil, err
+	}
+	if e.Status != enrollment.StatusPending {
+		return nil, ErrInvalidTransition
+	}
+	return e, s.apply(e, enrollment.StatusRejected, actorID, reason)
+}
+
+// Drop withdraws a user from a course and promotes the waitlist if a seat was freed.
+func (s *EnrollmentService) Drop(userID, courseID, actorID, reason string) (*enrollment.Enrollment, error) {
+	e, err := s.Repo.FindByUserAndCourse(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrEnrollmentNotFound
+	}
+	heldSeat := e.Status == enrollment.StatusActive
+	if err := s.apply(e, enrollment.StatusDropped, actorID, reason); err != nil {
+		return nil, err
+	}
+	if heldSeat {
+		if _, err := s.PromoteWaitlist(courseID); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
+}
+
+// Complete marks an active enrollment as completed and passes it to the
+// completion recorders, e.g. to start a recertification cycle or issue a certificate.
+func (s *EnrollmentService) Complete(userID, courseID, actorID string) (*enrollment.Enrollment, error) {
+	e, err := s.Repo.FindByUserAndCourse(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrEnrollmentNotFound
+	}
+	heldSeat := e.Status == enrollment.StatusActive
+	if err := s.apply(e, enrollment.StatusCompleted, actorID, ""); err != nil {
+		return nil, err
+	}
+	// Completed learners no longer count against the seat capacity. The
+	// completion stands even if nobody could be promoted; the next freed
+	// seat or capacity change promotes the waitlist again.
+	if heldSeat {
+		if _, err := s.PromoteWaitlist(courseID); err != nil {
+			log.Printf("enrollment: promoting the waitlist of course %s failed: %v", courseID, err)
+		}
+	}
+	// Each recorder runs on its own, so a failed recertification cycle does
+	// not hold back the certificate, and neither undoes the saved completion.
+	for _, recorder := range s.Completions {
+		if err := recorder.RecordCompletion(e); err != nil {
+			log.Printf("enrollment: recording completion %s failed: %v", e.ID, err)
+		}
+	}
+	return e, nil
+}
+
+// PromoteWaitlist activates waitlisted enrollments in order while seats are
+// available and returns the promoted enrollments.
+func (s *EnrollmentService) PromoteWaitlist(courseID string) ([]*enrollment.Enrollment, error) {
+	c, err := s.course(courseID)
+	if err != nil {
+		return nil, err
+	}
+	waitlist, err := s.Repo.ListByStatus(courseID, enrollment.StatusWaitlisted)
+	if err != nil {
+		return nil, err
+	}
+
+	var promoted []*enrollment.Enrollment
+	for _, e := range waitlist {
+		if err := s.admit(e, c, "", "promoted from waitlist"); err != nil {
+			return promoted, err
+		}
+		if e.Status != enrollment.StatusActive {
+			break
+		}
+		promoted = append(promoted, e)
+	}
+	return promoted, nil
+}
+
+// Waitlist returns a course's waitlisted enrollments in promotion order.
+func (s *EnrollmentService) Waitlist(courseID string) ([]*enrollment.Enrollment, error) {
+	if courseID == "" {
+		return nil, errors.New("course_id is required")
+	}
+	return s.Repo.ListByStatus(courseID, enrollment.StatusWaitlisted)
+}
+
+// PendingApprovals returns enrollment requests awaiting approval, oldest first.
+// An empty courseID returns the queue across all courses.
+func (s *EnrollmentService) PendingApprovals(courseID string) ([]*enrollment.Enrollment, error) {
+	return s.Repo.ListByStatus(courseID, enrollment.StatusPending)
+}
+
+// GetEnrollment retrieves an enrollment by ID.
+func (s *EnrollmentService) GetEnrollment(id string) (*enrollment.Enrollment, error) {
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+	e, err := s.Repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrEnrollmentNotFound
+	}
+	return e, nil
+}
+
+// ListEnrollments returns enrollments filtered by user and/or course.
+func (s *EnrollmentService) ListEnrollments(userID, courseID string) ([]*enrollment.Enrollment, error) {
+	return s.Repo.List(userID, courseID)
+}
+
+// History returns the status transitions of an enrollment in chronological order.
+func (s *EnrollmentService) History(enrollmentID string) ([]*enrollment.StatusChange, error) {
+	if _, err := s.GetEnrollment(enrollmentID); err != nil {
+		return nil, err
+	}
+	return s.Repo.ListHistory(enrollmentID)
+}
+
+// IsEnrolled reports whether the user holds an active or completed enrollment in the course.
+func (s *EnrollmentService) IsEnrolled(userID, courseID string) (bool, error) {
+	if userID == "" || courseID == "" {
+		return false, nil
+	}
+	e, err := s.Repo.FindByUserAndCourse(userID, courseID)
+	if err != nil {
+		return false, err
+	}
+	return e != nil && (e.Status == enrollment.StatusActive || e.Status == enrollment.StatusCompleted), nil
+}
+
+func (s *EnrollmentService) course(id string) (*course.Course, error) {
+	c, err := s.Courses.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrCourseNotFound
+	}
+	return c, nil
+}
+
+// findOrNew returns the user's enrollment in the course, or an unsaved one with an empty status.
+func (s *EnrollmentService) findOrNew(userID, courseID string) (*enrollment.Enrollment, error) {
+	e, err := s.Repo.FindByUserAndCourse(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if e != nil {
+		return e, nil
+	}
+	now := time.Now().Unix()
+	return &enrollment.Enrollment{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		CourseID:  courseID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// admit activates an enrollment if the course has a free seat, otherwise waitlists it.
+func (s *EnrollmentService) admit(e *enrollment.Enrollment, c *course.Course, actorID, reason string) error {
+	if c.SeatCapacity <= 0 {
+		return s.apply(e, enrollment.StatusActive, actorID, reason)
+	}
+	updated, change, err := s.prepare(e, enrollment.StatusActive, actorID, reason)
+	if err != nil {
+		return err
+	}
+	claimed, err := s.Repo.ClaimSeat(updated, change, c.SeatCapacity)
+	if err != nil {
+		return err
+	}
+	if claimed {
+		*e = *updated
+		s.publish(e, change)
+		return nil
+	}
+	if e.Status == enrollment.StatusWaitlisted {
+		return nil
+	}
+	return s.apply(e, enrollment.StatusWaitlisted, actorID, "course is full")
+}
+
+// apply validates and persists a status change, recording it in the history.
+// Unsaved enrollments (empty status) are created.
+func (s *EnrollmentService) apply(e *enrollment.Enrollment, to enrollment.Status, actorID, reason string) error {
+	updated, change, err := s.prepare(e, to, actorID, reason)
+	if err != nil {
+		return err
+	}
+	if e.Status == "" {
+		err = s.Repo.Create(updated, change)
+	} else {
+		err = s.Repo.UpdateStatus(updated, change)
+	}
+	if err != nil {
+		return err
+	}
+	*e = *updated
+	s.publish(e, change)
+	return nil
+}
+
+// publish pushes a status change to the learner and the observers. Observers
+// deal with their own failures, and a learner who misses the push sees the
+// new status the next time their enrollments load, so a failed push is only
+// logged.
+func (s *EnrollmentService) publish(e *enrollment.Enrollment, change *enrollment.StatusChange) {
+	for _, o := range s.Observers {
+		o.EnrollmentChanged(e, change)
+	}
+	if s.Events == nil {
+		return
+	}
+	if err := s.Events.Publish(e.UserID, realtime.TypeEnrollment, e); err != nil {
+		log.Printf("enrollment: publishing %s to user %s failed: %v", e.ID, e.UserID, err)
+	}
+}
+
+func (s *EnrollmentService) prepare(e *enrollment.Enrollment, to enrollment.Status, actorID, reason string) (*enrollment.Enrollment, *enrollment.StatusChange, error) {
+	if e.Status != "" && !enrollment.CanTransition(e.Status, to) {
+		return nil, nil, ErrInvalidTransition
+	}
+	now := time.Now().Unix()
+	change := &enrollment.StatusChange{
+		ID:           uuid.New().String(),
+		EnrollmentID: e.ID,
+		FromStatus:   e.Status,
+		ToStatus:     to,
+		ChangedBy:    actorID,
+		Reason:       reason,
+		ChangedAt:    now,
+	}
+	updated := *e
+	updated.Status = to
+	updated.UpdatedAt = now
+	return &updated, change, nil
+}
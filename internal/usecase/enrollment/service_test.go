@@ -0,0 +1,413 @@
+package enrollment
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/realtime"
+)
+
+// MockEnrollmentRepository is an in-memory implementation of EnrollmentRepository
+type MockEnrollmentRepository struct {
+	enrollments map[string]*enrollment.Enrollment
+	history     map[string][]*enrollment.StatusChange
+	seq         map[string]int
+	nextSeq     int
+	shouldFail  bool
+}
+
+// MockCourseRepository serves fixed courses by ID
+type MockCourseRepository map[string]*course.Course
+
+func (m MockCourseRepository) FindByID(id string) (*course.Course, error) {
+	return m[id], nil
+}
+
+// MockPublisher records the realtime events published per user
+type MockPublisher map[string][]realtime.EventType
+
+func (m MockPublisher) Publish(userID string, t realtime.EventType, data interface{}) error {
+	m[userID] = append(m[userID], t)
+	return nil
+}
+
+func newTestService(courses ...*course.Course) (*EnrollmentService, *MockEnrollmentRepository) {
+	repo := NewMockEnrollmentRepository()
+	lookup := MockCourseRepository{}
+	for _, c := range courses {
+		lookup[c.ID] = c
+	}
+	return &EnrollmentService{Repo: repo, Courses: lookup}, repo
+}
+
+func NewMockEnrollmentRepository() *MockEnrollmentRepository {
+	return &MockEnrollmentRepository{
+		enrollments: make(map[string]*enrollment.Enrollment),
+		history:     make(map[string][]*enrollment.StatusChange),
+		seq:         make(map[string]int),
+	}
+}
+
+func (m *MockEnrollmentRepository) save(e *enrollment.Enrollment, change *enrollment.StatusChange) {
+	cp := *e
+	m.enrollments[e.ID] = &cp
+	m.history[e.ID] = append(m.history[e.ID], change)
+	m.nextSeq++
+	m.seq[e.ID] = m.nextSeq
+}
+
+func (m *MockEnrollmentRepository) FindByID(id string) (*enrollment.Enrollment, error) {
+	if m.shouldFail {
+		return nil, errors.New("database error")
+	}
+	if e, ok := m.enrollments[id]; ok {
+		cp := *e
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (m *MockEnrollmentRepository) FindByUserAndCourse(userID, courseID string) (*enrollment.Enrollment, error) {
+	if m.shouldFail {
+		return nil, errors.New("database error")
+	}
+	for _, e := range m.enrollments {
+		if e.UserID == userID && e.CourseID == courseID {
+			cp := *e
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockEnrollmentRepository) Create(e *enrollment.Enrollment, change *enrollment.StatusChange) error {
+	if m.shouldFail {
+		return errors.New("database error")
+	}
+	m.save(e, change)
+	return nil
+}
+
+func (m *MockEnrollmentRepository) UpdateStatus(e *enrollment.Enrollment, change *enrollment.StatusChange) error {
+	if m.shouldFail {
+		return errors.New("database error")
+	}
+	m.save(e, change)
+	return nil
+}
+
+func (m *MockEnrollmentRepository) ClaimSeat(e *enrollment.Enrollment, change *enrollment.StatusChange, capacity int) (bool, error) {
+	if m.shouldFail {
+		return false, errors.New("database error")
+	}
+	taken := 0
+	for _, existing := range m.enrollments {
+		if existing.CourseID == e.CourseID && existing.Status == enrollment.StatusActive {
+			taken++
+		}
+	}
+	if taken >= capacity {
+		return false, nil
+	}
+	m.save(e, change)
+	return true, nil
+}
+
+func (m *MockEnrollmentRepository) ListByStatus(courseID string, status enrollment.Status) ([]*enrollment.Enrollment, error) {
+	var out []*enrollment.Enrollment
+	for _, e := range m.enrollments {
+		if (courseID == "" || e.CourseID == courseID) && e.Status == status {
+			cp := *e
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return m.seq[out[i].ID] < m.seq[out[j].ID] })
+	return out, nil
+}
+
+func (m *MockEnrollmentRepository) List(userID, courseID string) ([]*enrollment.Enrollment, error) {
+	var out []*enrollment.Enrollment
+	for _, e := range m.enrollments {
+		if (userID == "" || e.UserID == userID) && (courseID == "" || e.CourseID == courseID) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockEnrollmentRepository) ListHistory(enrollmentID string) ([]*enrollment.StatusChange, error) {
+	return m.history[enrollmentID], nil
+}
+
+func TestEnrollmentService_EnrollIsIdempotent(t *testing.T) {
+	service, repo := newTestService(&course.Course{ID: "course-1"})
+
+	first, err := service.Enroll("user-1", "course-1", "user-1")
+	if err != nil {
+		t.Fatalf("Enroll() error = %v", err)
+	}
+	second, err := service.Enroll("user-1", "course-1", "user-1")
+	if err != nil {
+		t.Fatalf("Enroll() second call error = %v", err)
+	}
+	if first.ID != second.ID {
+		t.Errorf("Enroll() returned a new enrollment %s, want %s", second.ID, first.ID)
+	}
+	if len(repo.enrollments) != 1 {
+		t.Errorf("expected 1 enrollment, got %d", len(repo.enrollments))
+	}
+	if len(repo.history[first.ID]) != 1 {
+		t.Errorf("expected 1 history entry, got %d", len(repo.history[first.ID]))
+	}
+}
+
+// racingRepository lets a concurrent request create the same enrollment
+// between the lookup and the insert, which then fails on the unique key.
+type racingRepository struct {
+	*MockEnrollmentRepository
+}
+
+func (r racingRepository) Create(e *enrollment.Enrollment, change *enrollment.StatusChange) error {
+	winner := *e
+	winner.ID = "winner"
+	r.save(&winner, change)
+	return errors.New(`pq: duplicate key value violates unique constraint "enrollments_user_id_course_id_key"`)
+}
+
+func TestEnrollmentService_EnrollLosesRace(t *testing.T) {
+	service, repo := newTestService(&course.Course{ID: "course-1"})
+	service.Repo = racingRepository{repo}
+
+	e, err := service.Enroll("user-1", "course-1", "user-1")
+	if err != nil {
+		t.Fatalf("Enroll() error = %v, want the concurrently created enrollment", err)
+	}
+	if e.ID != "winner" || e.Status != enrollment.StatusActive {
+		t.Errorf("Enroll() = %+v, want the winner's enrollment", e)
+	}
+}
+
+func TestEnrollmentService_Lifecycle(t *testing.T) {
+	service, _ := newTestService(&course.Course{ID: "course-1"})
+	events := MockPublisher{}
+	service.Events = events
+
+	e, err := service.Enroll("user-1", "course-1", "admin-1")
+	if err != nil {
+		t.Fatalf("Enroll() error = %v", err)
+	}
+
+	dropped, err := service.Drop("user-1", "course-1", "user-1", "no time")
+	if err != nil {
+		t.Fatalf("Drop() error = %v", err)
+	}
+	if dropped.Status != enrollment.StatusDropped {
+		t.Errorf("Drop() status = %v, want %v", dropped.Status, enrollment.StatusDropped)
+	}
+	if ok, _ := service.IsEnrolled("user-1", "course-1"); ok {
+		t.Error("IsEnrolled() = true after drop, want false")
+	}
+
+	if _, err := service.Complete("user-1", "course-1", "admin-1"); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Complete() on dropped enrollment error = %v, want %v", err, ErrInvalidTransition)
+	}
+
+	reactivated, err := service.Enroll("user-1", "course-1", "user-1")
+	if err != nil {
+		t.Fatalf("Enroll() after drop error = %v", err)
+	}
+	if reactivated.ID != e.ID || reactivated.Status != enrollment.StatusActive {
+		t.Errorf("Enroll() after drop = %+v, want reactivated %s", reactivated, e.ID)
+	}
+
+	if _, err := service.Complete("user-1", "course-1", "admin-1"); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	history, err := service.History(e.ID)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	want := []enrollment.Status{enrollment.StatusActive, enrollment.StatusDropped, enrollment.StatusActive, enrollment.StatusCompleted}
+	if len(history) != len(want) {
+		t.Fatalf("History() len = %d, want %d", len(history), len(want))
+	}
+	for i, sc := range history {
+		if sc.ToStatus != want[i] {
+			t.Errorf("History()[%d].ToStatus = %v, want %v", i, sc.ToStatus, want[i])
+		}
+	}
+	if history[1].Reason != "no time" {
+		t.Errorf("History()[1].Reason = %q, want %q", history[1].Reason, "no time")
+	}
+	if len(events["user-1"]) != len(want) {
+		t.Errorf("published %d realtime events, want one per status change", len(events["user-1"]))
+	}
+}
+
+func TestEnrollmentService_DropUnknown(t *testing.T) {
+	service, _ := newTestService(&course.Course{ID: "course-1"})
+
+	if _, err := service.Drop("user-1", "course-1", "user-1", ""); !errors.Is(err, ErrEnrollmentNotFound) {
+		t.Errorf("Drop() error = %v, want %v", err, ErrEnrollmentNotFound)
+	}
+}
+
+func TestEnrollmentService_Validation(t *testing.T) {
+	service, repo := newTestService(&course.Course{ID: "course-1"})
+
+	if _, err := service.Enroll("", "course-1", ""); err == nil {
+		t.Error("Enroll() with empty user_id expected error")
+	}
+
+	repo.shouldFail = true
+	if _, err := service.Enroll("user-1", "course-1", ""); err == nil {
+		t.Error("Enroll() expected repository error")
+	}
+}
+
+func TestEnrollmentService_ApprovalMode(t *testing.T) {
+	service, _ := newTestService(&course.Course{ID: "course-1", EnrollmentMode: course.EnrollmentApproval})
+
+	e, err := service.Enroll("user-1", "course-1", "user-1")
+	if err != nil {
+		t.Fatalf("Enroll() error = %v", err)
+	}
+	if e.Status != enrollment.StatusPending {
+		t.Fatalf("Enroll() status = %v, want %v", e.Status, enrollment.StatusPending)
+	}
+	if ok, _ := service.IsEnrolled("user-1", "course-1"); ok {
+		t.Error("IsEnrolled() = true for pending request, want false")
+	}
+
+	queue, _ := service.PendingApprovals("")
+	if len(queue) != 1 || queue[0].ID != e.ID {
+		t.Fatalf("PendingApprovals() = %v, want [%s]", queue, e.ID)
+	}
+
+	if _, err := service.Reject(e.ID, "manager-1", ""); err == nil {
+		t.Error("Reject() without reason expected error")
+	}
+	approved, err := service.Approve(e.ID, "manager-1", "team needs it")
+	if err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if approved.Status != enrollment.StatusActive {
+		t.Errorf("Approve() status = %v, want %v", approved.Status, enrollment.StatusActive)
+	}
+
+	other, _ := service.Enroll("user-2", "course-1", "user-2")
+	rejected, err := service.Reject(other.ID, "manager-1", "not eligible")
+	if err != nil {
+		t.Fatalf("Reject() error = %v", err)
+	}
+	if rejected.Status != enrollment.StatusRejected {
+		t.Errorf("Reject() status = %v, want %v", rejected.Status, enrollment.StatusRejected)
+	}
+}
+
+func TestEnrollmentService_InviteOnlyMode(t *testing.T) {
+	service, _ := newTestService(&course.Course{ID: "course-1", EnrollmentMode: course.EnrollmentInvite})
+
+	if _, err := service.Enroll("user-1", "course-1", "user-1"); !errors.Is(err, ErrInvitationRequired) {
+		t.Fatalf("Enroll() without invitation error = %v, want %v", err, ErrInvitationRequired)
+	}
+
+	if _, err := service.Invite("user-1", "course-1", "trainer-1"); err != nil {
+		t.Fatalf("Invite() error = %v", err)
+	}
+	e, err := service.Enroll("user-1", "course-1", "user-1")
+	if err != nil {
+		t.Fatalf("Enroll() with invitation error = %v", err)
+	}
+	if e.Status != enrollment.StatusActive {
+		t.Errorf("Enroll() status = %v, want %v", e.Status, enrollment.StatusActive)
+	}
+}
+
+func TestEnrollmentService_WaitlistPromotion(t *testing.T) {
+	service, _ := newTestService(&course.Course{ID: "course-1", SeatCapacity: 1})
+
+	first, _ := service.Enroll("user-1", "course-1", "user-1")
+	second, _ := service.Enroll("user-2", "course-1", "user-2")
+	third, _ := service.Assign("user-3", "course-1", "admin-1")
+
+	if first.Status != enrollment.StatusActive {
+		t.Fatalf("first status = %v, want %v", first.Status, enrollment.StatusActive)
+	}
+	if second.Status != enrollment.StatusWaitlisted || third.Status != enrollment.StatusWaitlisted {
+		t.Fatalf("statuses = %v, %v, want waitlisted", second.Status, third.Status)
+	}
+
+	waitlist, _ := service.Waitlist("course-1")
+	if len(waitlist) != 2 || waitlist[0].ID != second.ID || waitlist[1].ID != third.ID {
+		t.Fatalf("Waitlist() order unexpected: %v", waitlist)
+	}
+
+	if _, err := service.Drop("user-1", "course-1", "user-1", ""); err != nil {
+		t.Fatalf("Drop() error = %v", err)
+	}
+
+	if ok, _ := service.IsEnrolled("user-2", "course-1"); !ok {
+		t.Error("first waitlisted user was not promoted")
+	}
+	if ok, _ := service.IsEnrolled("user-3", "course-1"); ok {
+		t.Error("second waitlisted user promoted without a free seat")
+	}
+}
+
+func TestEnrollmentService_CompletePromotesWaitlist(t *testing.T) {
+	service, _ := newTestService(&course.Course{ID: "course-1", SeatCapacity: 1})
+
+	service.Enroll("user-1", "course-1", "user-1")
+	service.Enroll("user-2", "course-1", "user-2")
+
+	if _, err := service.Complete("user-1", "course-1", "admin-1"); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if ok, _ := service.IsEnrolled("user-2", "course-1"); !ok {
+		t.Error("waitlisted user was not promoted after a completion")
+	}
+}
+
+func TestEnrollmentService_CompleteSurvivesFailedPromotion(t *testing.T) {
+	courses := MockCourseRepository{"course-1": {ID: "course-1", SeatCapacity: 1}}
+	service := &EnrollmentService{Repo: NewMockEnrollmentRepository(), Courses: courses}
+
+	service.Enroll("user-1", "course-1", "user-1")
+	service.Enroll("user-2", "course-1", "user-2")
+	delete(courses, "course-1") // the promotion can no longer look up the course
+
+	e, err := service.Complete("user-1", "course-1", "admin-1")
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if e.Status != enrollment.StatusCompleted {
+		t.Errorf("Status = %s, want %s", e.Status, enrollment.StatusCompleted)
+	}
+}
+
+// recorderFunc adapts a function to CompletionRecorder
+type recorderFunc func(e *enrollment.Enrollment) error
+
+func (f recorderFunc) RecordCompletion(e *enrollment.Enrollment) error { return f(e) }
+
+func TestEnrollmentService_CompleteRunsEveryRecorder(t *testing.T) {
+	service, _ := newTestService(&course.Course{ID: "course-1"})
+	var recorded bool
+	service.Completions = []CompletionRecorder{
+		recorderFunc(func(*enrollment.Enrollment) error { return errors.New("cycle store down") }),
+		recorderFunc(func(*enrollment.Enrollment) error { recorded = true; return nil }),
+	}
+
+	service.Enroll("user-1", "course-1", "user-1")
+	if _, err := service.Complete("user-1", "course-1", "admin-1"); err != nil {
+		t.Fatalf("Complete() error = %v, want a failed recorder to be logged", err)
+	}
+	if !recorded {
+		t.Error("a failed recorder kept the next one from running")
+	}
+}
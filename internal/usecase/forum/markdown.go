@@ -0,0 +1,210 @@
+package forum
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// renderMarkdown turns post and reply content into HTML that is safe to embed
+// in the web app. It supports a subset of GitHub-flavoured Markdown:
+// paragraphs with hard line breaks, headings, block quotes, lists, rules,
+// fenced and inline code, emphasis, strikethrough, links and @mentions.
+//
+// Nothing from the source reaches the output unescaped: every piece of text
+// goes through html.EscapeString and the only tags are the ones written here,
+// so raw HTML in a post is shown as text. Links are limited to http, https,
+// mailto and site-relative URLs.
+func renderMarkdown(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\x00", "")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"))
+	return b.String()
+}
+
+var (
+	headingLine = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	ruleLine    = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	bulletLine  = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	numberLine  = regexp.MustCompile(`^\s{0,3}\d{1,9}[.)]\s+(.*)$`)
+	fenceLine   = regexp.MustCompile("^\\s{0,3}(```|~~~)\\s*([A-Za-z0-9_+-]*)")
+)
+
+func renderBlocks(b *strings.Builder, lines []string) {
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + renderLines(para) + "</p>\n")
+			para = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case fenceLine.MatchString(line):
+			flush()
+			m := fenceLine.FindStringSubmatch(line)
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code")
+			if m[2] != "" {
+				b.WriteString(` class="language-` + m[2] + `"`)
+			}
+			b.WriteString(">" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+		case headingLine.MatchString(line):
+			flush()
+			m := headingLine.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + renderInline(m[2]) + "</h" + level + ">\n")
+		case ruleLine.MatchString(line):
+			flush()
+			b.WriteString("<hr>\n")
+		case strings.HasPrefix(strings.TrimLeft(line, " "), ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimLeft(lines[i], " "), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimLeft(lines[i], " "), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+		case bulletLine.MatchString(line) || numberLine.MatchString(line):
+			flush()
+			item, tag := bulletLine, "ul"
+			if !bulletLine.MatchString(line) {
+				item, tag = numberLine, "ol"
+			}
+			b.WriteString("<" + tag + ">\n")
+			for i < len(lines) && item.MatchString(lines[i]) {
+				content := []string{item.FindStringSubmatch(lines[i])[1]}
+				// Indented lines continue the item.
+				for i++; i < len(lines) && strings.HasPrefix(lines[i], "  ") && strings.TrimSpace(lines[i]) != "" &&
+					!item.MatchString(lines[i]); i++ {
+					content = append(content, strings.TrimSpace(lines[i]))
+				}
+				b.WriteString("<li>" + renderLines(content) + "</li>\n")
+			}
+			i--
+			b.WriteString("</" + tag + ">\n")
+		default:
+			para = append(para, strings.TrimSpace(line))
+		}
+	}
+	flush()
+}
+
+// renderLines renders consecutive lines of one block, keeping line breaks.
+func renderLines(lines []string) string {
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		rendered[i] = renderInline(line)
+	}
+	return strings.Join(rendered, "<br>\n")
+}
+
+var (
+	escapedChar = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!~>@])")
+	codeSpan    = regexp.MustCompile("`([^`]+)`")
+	inlineLink  = regexp.MustCompile(`\[([^\[\]]+)\]\(([^()\s]+)\)`)
+	bareURL     = regexp.MustCompile(`https?://[^\s<>"'\x00]*[^\s<>"'\x00.,:;!?)\]]`)
+	mention     = regexp.MustCompile(`(^|[^A-Za-z0-9_.+\-@/\x00])@([A-Za-z0-9_](?:[A-Za-z0-9_.+\-]*[A-Za-z0-9_])?)`)
+	strong      = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	strike      = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	emphasis    = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*`)
+	underscore  = regexp.MustCompile(`(^|[^A-Za-z0-9_])_(\S(?:[^_]*?\S)?)_($|[^A-Za-z0-9_])`)
+	placeholder = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// renderInline renders one line. Code spans, links and mentions are cut out
+// into placeholders before the rest is escaped and emphasis is applied, so
+// their contents are never reinterpreted.
+func renderInline(text string) string {
+	var pieces []string
+	hold := func(s string) string {
+		pieces = append(pieces, s)
+		return "\x00" + strconv.Itoa(len(pieces)-1) + "\x00"
+	}
+
+	text = escapedChar.ReplaceAllStringFunc(text, func(m string) string {
+		return hold(html.EscapeString(m[1:]))
+	})
+	text = codeSpan.ReplaceAllStringFunc(text, func(m string) string {
+		return hold("<code>" + html.EscapeString(m[1:len(m)-1]) + "</code>")
+	})
+	text = inlineLink.ReplaceAllStringFunc(text, func(m string) string {
+		parts := inlineLink.FindStringSubmatch(m)
+		label := emphasize(html.EscapeString(parts[1]))
+		if !safeURL(parts[2]) {
+			return hold(label)
+		}
+		return hold(anchor(parts[2], label))
+	})
+	text = bareURL.ReplaceAllStringFunc(text, func(m string) string {
+		return hold(anchor(m, html.EscapeString(m)))
+	})
+	text = mention.ReplaceAllStringFunc(text, func(m string) string {
+		parts := mention.FindStringSubmatch(m)
+		name := html.EscapeString(parts[2])
+		return parts[1] + hold(`<span class="mention" data-username="`+name+`">@`+name+`</span>`)
+	})
+
+	text = emphasize(html.EscapeString(text))
+	for placeholder.MatchString(text) {
+		text = placeholder.ReplaceAllStringFunc(text, func(m string) string {
+			i, _ := strconv.Atoi(m[1 : len(m)-1])
+			return pieces[i]
+		})
+	}
+	return text
+}
+
+// emphasize applies bold, strikethrough and italics to escaped text.
+func emphasize(text string) string {
+	text = strong.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = strike.ReplaceAllString(text, "<del>$1</del>")
+	text = emphasis.ReplaceAllString(text, "<em>$1</em>")
+	return underscore.ReplaceAllString(text, "$1<em>$2</em>$3")
+}
+
+func anchor(url, label string) string {
+	return `<a href="` + html.EscapeString(url) + `" rel="nofollow noopener noreferrer">` + label + `</a>`
+}
+
+// safeURL allows web and mail links and links within the portal.
+func safeURL(url string) bool {
+	lower := strings.ToLower(url)
+	for _, prefix := range []string{"http://", "https://", "mailto:", "/", "#"} {
+		if strings.HasPrefix(lower, prefix) {
+			return !strings.ContainsAny(url, "\x00\t\n\r")
+		}
+	}
+	return false
+}
+
+var codeBlock = regexp.MustCompile("(?s)(?:^|\n)\\s{0,3}(```|~~~).*?(?:\n\\s{0,3}(```|~~~)|$)")
+
+// mentions returns the distinct usernames mentioned in Markdown content,
+// ignoring code.
+func mentions(content string) []string {
+	content = codeBlock.ReplaceAllString(content, "\n")
+	content = codeSpan.ReplaceAllString(content, " ")
+	content = escapedChar.ReplaceAllString(content, " ")
+	seen := map[string]bool{}
+	var names []string
+	for _, m := range mention.FindAllStringSubmatch(content, -1) {
+		name := strings.ToLower(m[2])
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
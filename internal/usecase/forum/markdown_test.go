@@ -0,0 +1,104 @@
+package forum
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"paragraph", "Hello\nworld", "<p>Hello<br>\nworld</p>\n"},
+		{"emphasis", "**bold** *em* _em_ snake_case ~~gone~~", "<p><strong>bold</strong> <em>em</em> <em>em</em> snake_case <del>gone</del></p>\n"},
+		{"heading", "## Loops ##", "<h2>Loops</h2>\n"},
+		{"code span", "use `<b>` here", "<p>use <code>&lt;b&gt;</code> here</p>\n"},
+		{"fenced code", "```go\nif a < b {}\n```", "<pre><code class=\"language-go\">if a &lt; b {}</code></pre>\n"},
+		{"list", "- one\n- two", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n"},
+		{"ordered list", "1. one\n2. two", "<ol>\n<li>one</li>\n<li>two</li>\n</ol>\n"},
+		{"quote", "> said", "<blockquote>\n<p>said</p>\n</blockquote>\n"},
+		{"link", "[docs](https://go.dev/doc?a=1&b=2)", `<p><a href="https://go.dev/doc?a=1&amp;b=2" rel="nofollow noopener noreferrer">docs</a></p>` + "\n"},
+		{"relative link", "[course](/course/1)", `<p><a href="/course/1" rel="nofollow noopener noreferrer">course</a></p>` + "\n"},
+		{"bare url", "see https://go.dev.", `<p>see <a href="https://go.dev" rel="nofollow noopener noreferrer">https://go.dev</a>.</p>` + "\n"},
+		{"mention", "thanks @ada.l!", `<p>thanks <span class="mention" data-username="ada.l">@ada.l</span>!</p>` + "\n"},
+		{"email is not a mention", "mail ada@example.com", "<p>mail ada@example.com</p>\n"},
+		{"backslash escape", `\*not em\*`, "<p>*not em*</p>\n"},
+	}
+	for _, tt := range tests {
+		if got := renderMarkdown(tt.src); got != tt.want {
+			t.Errorf("%s: renderMarkdown(%q) = %q, want %q", tt.name, tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestRenderMarkdown_NoXSS(t *testing.T) {
+	attacks := []string{
+		`<script>alert(1)</script>`,
+		`<img src=x onerror=alert(1)>`,
+		`[x](javascript:alert(1))`,
+		`[x](JaVaScRiPt:alert(1))`,
+		`[x](data:text/html;base64,PHNjcmlwdD4=)`,
+		`[x](vbscript:msgbox)`,
+		`[x](https://a.com" onmouseover="alert(1))`,
+		`[x](https://a.com"onmouseover="alert(1))`,
+		`https://a.com/"><script>alert(1)</script>`,
+		"```\"><script>alert(1)</script>\n```",
+		"```go\" onload=\"alert(1)\nx\n```",
+		"@\"><svg/onload=alert(1)>",
+		`**<iframe src="//evil">**`,
+		"`</code><script>alert(1)</script>`",
+		"<a href=\"javascript:alert(1)\">x</a>",
+		"\x00<script>",
+	}
+	for _, src := range attacks {
+		if got := renderMarkdown(src); !safeHTML(got) {
+			t.Errorf("renderMarkdown(%q) = %q, has a tag or attribute outside the allowed set", src, got)
+		}
+	}
+}
+
+var (
+	htmlTag     = regexp.MustCompile(`<(/?)([a-zA-Z0-9]+)([^>]*)>`)
+	htmlAttr    = regexp.MustCompile(`\s+([a-z-]+)="([^"]*)"`)
+	allowedTags = map[string]bool{
+		"p": true, "br": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"strong": true, "em": true, "del": true, "code": true, "pre": true, "blockquote": true,
+		"ul": true, "ol": true, "li": true, "hr": true, "a": true, "span": true,
+	}
+	allowedAttrs = map[string]*regexp.Regexp{
+		"href":          regexp.MustCompile(`^(https?://|mailto:|/|#)`),
+		"rel":           regexp.MustCompile(`^nofollow noopener noreferrer$`),
+		"class":         regexp.MustCompile(`^(mention|language-[A-Za-z0-9_+-]+)$`),
+		"data-username": regexp.MustCompile(`^[A-Za-z0-9_.+-]+$`),
+	}
+)
+
+// safeHTML reports whether every tag in s is allowed and has only allowed
+// attributes. Text is escaped, so any "<" must start one of our tags.
+func safeHTML(s string) bool {
+	for _, m := range htmlTag.FindAllStringSubmatch(s, -1) {
+		if !allowedTags[m[2]] {
+			return false
+		}
+		attrs := m[3]
+		for _, a := range htmlAttr.FindAllStringSubmatch(attrs, -1) {
+			if re := allowedAttrs[a[1]]; re == nil || !re.MatchString(a[2]) {
+				return false
+			}
+		}
+		if strings.TrimSpace(htmlAttr.ReplaceAllString(attrs, "")) != "" {
+			return false
+		}
+	}
+	return strings.Count(s, "<") == len(htmlTag.FindAllString(s, -1))
+}
+
+func TestMentions(t *testing.T) {
+	got := mentions("Hi @Ada and @ada, ask @bob.smith. Not `@code`, mail cy@example.com\n```\n@fenced\n```\n@tina")
+	want := []string{"ada", "bob.smith", "tina"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mentions() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,350 @@
+package forum
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"training-portal/internal/domain/forum"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLocked             = errors.New("thread is locked")
+	ErrSuspended          = errors.New("you are suspended from posting in this course")
+	ErrOwnContent         = errors.New("you cannot report your own content")
+	ErrAlreadyReported    = errors.New("you have already reported this")
+	ErrInvalidAction      = errors.New("invalid moderation action")
+	ErrSuspensionNotFound = errors.New("suspension not found")
+)
+
+// ModerationRepository is the persistence contract for reports, moderator
+// actions and suspensions.
+type ModerationRepository interface {
+	// CreateReport inserts the report unless the reporter already has an
+	// open report on the same post or reply, and reports whether it did.
+	CreateReport(r *forum.Report) (bool, error)
+	// ListQueue returns the held posts and replies and those with open
+	// reports, in every course when courseID is empty, oldest first.
+	ListQueue(courseID string) ([]*forum.QueueItem, error)
+	// ApplyModeration saves the state of p or r when not nil, sets the open
+	// reports on the action's post or reply to status unless it is empty and
+	// logs the action, in one transaction.
+	ApplyModeration(a *forum.ModerationAction, p *forum.Post, r *forum.Reply, status forum.ReportStatus) error
+	LogAction(a *forum.ModerationAction) error
+	// ListActions returns the newest actions, in every course when courseID
+	// is empty.
+	ListActions(courseID string, limit int) ([]*forum.ModerationAction, error)
+
+	// CreateSuspension inserts s and logs a in one transaction.
+	CreateSuspension(s *forum.Suspension, a *forum.ModerationAction) error
+	FindSuspension(id string) (*forum.Suspension, error)
+	// ActiveSuspension returns the user's suspension in the course that is
+	// in force at now and ends last, or nil.
+	ActiveSuspension(userID, courseID string, now int64) (*forum.Suspension, error)
+	// ListSuspensions returns the course's suspensions, newest first.
+	ListSuspensions(courseID string) ([]*forum.Suspension, error)
+	// LiftSuspension saves s.LiftedAt and logs a in one transaction.
+	LiftSuspension(s *forum.Suspension, a *forum.ModerationAction) error
+}
+
+// ReportPost flags a post for the moderators.
+func (s *ForumService) ReportPost(id string, actor Actor, reason string) (*forum.Report, error) {
+	p, _, err := s.thread(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.report(&forum.Report{PostID: p.ID}, p.UserID, actor, reason)
+}
+
+// ReportReply flags a reply for the moderators.
+func (s *ForumService) ReportReply(id string, actor Actor, reason string) (*forum.Report, error) {
+	r, err := s.GetReply(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.report(&forum.Report{ReplyID: r.ID}, r.UserID, actor, reason)
+}
+
+func (s *ForumService) report(rep *forum.Report, authorID string, actor Actor, reason string) (*forum.Report, error) {
+	if authorID == actor.UserID {
+		return nil, ErrOwnContent
+	}
+	reason, err := validateReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	rep.ID = uuid.New().String()
+	rep.ReporterID = actor.UserID
+	rep.Reason = reason
+	rep.Status = forum.ReportOpen
+	rep.CreatedAt = time.Now().Unix()
+	created, err := s.Moderation.CreateReport(rep)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrAlreadyReported
+	}
+	return rep, nil
+}
+
+// ModerationQueue returns the content waiting for a moderator, in every
+// course when courseID is empty. Only staff can see it.
+func (s *ForumService) ModerationQueue(courseID string, actor Actor) ([]*forum.QueueItem, error) {
+	if !actor.Staff {
+		return nil, ErrForbidden
+	}
+	return s.Moderation.ListQueue(courseID)
+}
+
+// ModeratePost applies a moderator action to a thread: approve, hide,
+// restore, dismiss, lock, unlock, pin or unpin. Only staff can moderate
+// and every action needs a reason.
+func (s *ForumService) ModeratePost(id string, actor Actor, action forum.ModerationActionType, reason string) (*forum.Post, error) {
+	if !actor.Staff {
+		return nil, ErrForbidden
+	}
+	reason, err := validateReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	p, f, err := s.thread(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	held := p.State == forum.StateHeld
+	var status forum.ReportStatus
+	switch action {
+	case forum.ActionApprove, forum.ActionRestore:
+		p.State, status = forum.StateVisible, forum.ReportResolved
+	case forum.ActionHide:
+		p.State, status = forum.StateHidden, forum.ReportResolved
+	case forum.ActionDismiss:
+		status = forum.ReportDismissed
+	case forum.ActionLock, forum.ActionUnlock:
+		p.Locked = action == forum.ActionLock
+	case forum.ActionPin, forum.ActionUnpin:
+		p.Pinned = action == forum.ActionPin
+	default:
+		return nil, ErrInvalidAction
+	}
+	a := s.action(action, f.CourseID, p.UserID, actor, reason)
+	a.PostID = p.ID
+	if err := s.Moderation.ApplyModeration(a, p, nil, status); err != nil {
+		return nil, err
+	}
+	if held {
+		// Held threads were not announced when they were posted.
+		s.announce(f, p, nil, "")
+	}
+	return p, nil
+}
+
+// ModerateReply applies a moderator action to a reply: approve, hide,
+// restore or dismiss.
+func (s *ForumService) ModerateReply(id string, actor Actor, action forum.ModerationActionType, reason string) (*forum.Reply, error) {
+	if !actor.Staff {
+		return nil, ErrForbidden
+	}
+	reason, err := validateReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	r, p, f, err := s.reply(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	held := r.State == forum.StateHeld
+	var status forum.ReportStatus
+	switch action {
+	case forum.ActionApprove, forum.ActionRestore:
+		r.State, status = forum.StateVisible, forum.ReportResolved
+	case forum.ActionHide:
+		r.State, status = forum.StateHidden, forum.ReportResolved
+	case forum.ActionDismiss:
+		status = forum.ReportDismissed
+	default:
+		return nil, ErrInvalidAction
+	}
+	a := s.action(action, f.CourseID, r.UserID, actor, reason)
+	a.PostID, a.ReplyID = p.ID, r.ID
+	if err := s.Moderation.ApplyModeration(a, nil, r, status); err != nil {
+		return nil, err
+	}
+	if held {
+		s.announce(f, p, r, "")
+	}
+	return r, nil
+}
+
+// Suspend keeps a user from posting, replying and editing in a course's
+// forums for the given duration. They can still read them.
+func (s *ForumService) Suspend(courseID, userID string, actor Actor, duration time.Duration, reason string) (*forum.Suspension, error) {
+	if !actor.Staff {
+		return nil, ErrForbidden
+	}
+	if userID == "" {
+		return nil, errors.New("user is required")
+	}
+	if duration <= 0 {
+		return nil, errors.New("duration must be positive")
+	}
+	reason, err := validateReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.Courses.FindByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrCourseNotFound
+	}
+	now := time.Now()
+	sus := &forum.Suspension{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		CourseID:  courseID,
+		Reason:    reason,
+		CreatedBy: actor.UserID,
+		CreatedAt: now.Unix(),
+		EndsAt:    now.Add(duration).Unix(),
+	}
+	a := s.action(forum.ActionSuspend, courseID, userID, actor, reason)
+	if err := s.Moderation.CreateSuspension(sus, a); err != nil {
+		return nil, err
+	}
+	return sus, nil
+}
+
+// LiftSuspension ends a suspension early.
+func (s *ForumService) LiftSuspension(id string, actor Actor, reason string) (*forum.Suspension, error) {
+	if !actor.Staff {
+		return nil, ErrForbidden
+	}
+	reason, err := validateReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	sus, err := s.Moderation.FindSuspension(id)
+	if err != nil {
+		return nil, err
+	}
+	if sus == nil {
+		return nil, ErrSuspensionNotFound
+	}
+	now := time.Now().Unix()
+	if !sus.Active(now) {
+		return nil, errors.New("suspension has already ended")
+	}
+	sus.LiftedAt = now
+	a := s.action(forum.ActionUnsuspend, sus.CourseID, sus.UserID, actor, reason)
+	if err := s.Moderation.LiftSuspension(sus, a); err != nil {
+		return nil, err
+	}
+	return sus, nil
+}
+
+// ListSuspensions returns a course's suspensions, newest first.
+func (s *ForumService) ListSuspensions(courseID string, actor Actor) ([]*forum.Suspension, error) {
+	if !actor.Staff {
+		return nil, ErrForbidden
+	}
+	return s.Moderation.ListSuspensions(courseID)
+}
+
+// ModerationLog returns the newest moderation actions, in every course when
+// courseID is empty.
+func (s *ForumService) ModerationLog(courseID string, actor Actor, limit int) ([]*forum.ModerationAction, error) {
+	if !actor.Staff {
+		return nil, ErrForbidden
+	}
+	_, limit = pageBounds(1, limit)
+	return s.Moderation.ListActions(courseID, limit)
+}
+
+// checkSuspension refuses to let a suspended user write in a course forum.
+func (s *ForumService) checkSuspension(courseID string, actor Actor) error {
+	if courseID == "" || actor.Staff || s.Moderation == nil {
+		return nil
+	}
+	sus, err := s.Moderation.ActiveSuspension(actor.UserID, courseID, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+	if sus != nil {
+		return ErrSuspended
+	}
+	return nil
+}
+
+// checkWritable refuses replies and edits in locked threads, except from
+// staff, and from users suspended in the thread's course.
+func (s *ForumService) checkWritable(f *forum.Forum, p *forum.Post, actor Actor) error {
+	if p.Locked && !actor.Staff {
+		return ErrLocked
+	}
+	return s.checkSuspension(f.CourseID, actor)
+}
+
+// screen returns the first blocked word or phrase found in texts, or "" if
+// there is none. Staff content is never held.
+func (s *ForumService) screen(actor Actor, texts ...string) string {
+	if actor.Staff || len(s.BlockedWords) == 0 {
+		return ""
+	}
+	text := " " + strings.Join(words(strings.Join(texts, " ")), " ") + " "
+	for _, blocked := range s.BlockedWords {
+		w := words(blocked)
+		if len(w) > 0 && strings.Contains(text, " "+strings.Join(w, " ")+" ") {
+			return blocked
+		}
+	}
+	return ""
+}
+
+// words splits text into lower-case words, so that matching ignores case
+// and punctuation but not word boundaries.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// logHold records that the word filter held a post or reply. The content is
+// already saved and queued by its state, so a failure only loses the entry.
+func (s *ForumService) logHold(courseID, postID, replyID, authorID, word string) {
+	if s.Moderation == nil {
+		return
+	}
+	a := s.action(forum.ActionHold, courseID, authorID, Actor{}, "blocked word: "+word)
+	a.PostID, a.ReplyID = postID, replyID
+	_ = s.Moderation.LogAction(a)
+}
+
+func (s *ForumService) action(action forum.ModerationActionType, courseID, userID string, actor Actor, reason string) *forum.ModerationAction {
+	return &forum.ModerationAction{
+		ID:          uuid.New().String(),
+		Action:      action,
+		CourseID:    courseID,
+		UserID:      userID,
+		ModeratorID: actor.UserID,
+		Reason:      reason,
+		CreatedAt:   time.Now().Unix(),
+	}
+}
+
+func validateReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", errors.New("reason is required")
+	}
+	if utf8.RuneCountInString(reason) > 1000 {
+		return "", errors.New("reason is longer than 1000 characters")
+	}
+	return reason, nil
+}
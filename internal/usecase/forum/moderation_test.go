@@ -0,0 +1,304 @@
+package forum
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/forum"
+)
+
+// mockModeration is an in-memory implementation of ModerationRepository
+// that reads content from a MockRepository
+type mockModeration struct {
+	repo        *MockRepository
+	reports     []*forum.Report
+	actions     []*forum.ModerationAction
+	suspensions map[string]*forum.Suspension
+}
+
+func newMockModeration(repo *MockRepository) *mockModeration {
+	return &mockModeration{repo: repo, suspensions: map[string]*forum.Suspension{}}
+}
+
+func (m *mockModeration) CreateReport(r *forum.Report) (bool, error) {
+	for _, existing := range m.reports {
+		if existing.Status == forum.ReportOpen && existing.ReporterID == r.ReporterID &&
+			existing.PostID == r.PostID && existing.ReplyID == r.ReplyID {
+			return false, nil
+		}
+	}
+	m.reports = append(m.reports, r)
+	return true, nil
+}
+
+func (m *mockModeration) ListQueue(courseID string) ([]*forum.QueueItem, error) {
+	var out []*forum.QueueItem
+	for _, p := range m.repo.posts {
+		if item := m.item(p.ID, "", p.State, p.UserID, p.Content); item != nil {
+			out = append(out, item)
+		}
+	}
+	for _, r := range m.repo.replies {
+		if item := m.item(r.PostID, r.ID, r.State, r.UserID, r.Content); item != nil {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockModeration) item(postID, replyID string, state forum.State, authorID, content string) *forum.QueueItem {
+	item := &forum.QueueItem{PostID: postID, ReplyID: replyID, AuthorID: authorID, Content: content, State: state}
+	for _, r := range m.reports {
+		if r.Status == forum.ReportOpen && r.ReplyID == replyID && (replyID != "" || r.PostID == postID) {
+			item.Reports++
+			item.Reasons = append(item.Reasons, r.Reason)
+		}
+	}
+	if state != forum.StateHeld && item.Reports == 0 {
+		return nil
+	}
+	return item
+}
+
+func (m *mockModeration) ApplyModeration(a *forum.ModerationAction, p *forum.Post, r *forum.Reply, status forum.ReportStatus) error {
+	if status != "" {
+		for _, rep := range m.reports {
+			if rep.Status == forum.ReportOpen && rep.ReplyID == a.ReplyID && (a.ReplyID != "" || rep.PostID == a.PostID) {
+				rep.Status, rep.ResolvedBy = status, a.ModeratorID
+			}
+		}
+	}
+	m.actions = append(m.actions, a)
+	return nil
+}
+
+func (m *mockModeration) LogAction(a *forum.ModerationAction) error {
+	m.actions = append(m.actions, a)
+	return nil
+}
+
+func (m *mockModeration) ListActions(courseID string, limit int) ([]*forum.ModerationAction, error) {
+	return m.actions, nil
+}
+
+func (m *mockModeration) CreateSuspension(s *forum.Suspension, a *forum.ModerationAction) error {
+	m.suspensions[s.ID] = s
+	m.actions = append(m.actions, a)
+	return nil
+}
+
+func (m *mockModeration) FindSuspension(id string) (*forum.Suspension, error) {
+	return m.suspensions[id], nil
+}
+
+func (m *mockModeration) ActiveSuspension(userID, courseID string, now int64) (*forum.Suspension, error) {
+	for _, s := range m.suspensions {
+		if s.UserID == userID && s.CourseID == courseID && s.Active(now) {
+			return s, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockModeration) ListSuspensions(courseID string) ([]*forum.Suspension, error) {
+	var out []*forum.Suspension
+	for _, s := range m.suspensions {
+		if s.CourseID == courseID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockModeration) LiftSuspension(s *forum.Suspension, a *forum.ModerationAction) error {
+	m.actions = append(m.actions, a)
+	return nil
+}
+
+func TestForumService_ReportsAndQueue(t *testing.T) {
+	service, repo := newTestService()
+	moderation := service.Moderation.(*mockModeration)
+	f, _ := service.CourseForum("go", learner)
+	p, _ := service.CreatePost(f.ID, learner, forum.TypeDiscussion, "Deals", "Buy cheap watches")
+	r, _ := service.Reply(p.ID, learner, "Still available")
+
+	if _, err := service.ReportPost(p.ID, learner, "spam"); !errors.Is(err, ErrOwnContent) {
+		t.Errorf("ReportPost() own post error = %v, want ErrOwnContent", err)
+	}
+	if _, err := service.ReportPost(p.ID, other, " "); err == nil {
+		t.Error("ReportPost() without reason expected error")
+	}
+	if _, err := service.ReportPost(p.ID, other, "spam"); err != nil {
+		t.Fatalf("ReportPost() error = %v", err)
+	}
+	if _, err := service.ReportPost(p.ID, other, "still spam"); !errors.Is(err, ErrAlreadyReported) {
+		t.Errorf("ReportPost() twice error = %v, want ErrAlreadyReported", err)
+	}
+	if _, err := service.ReportReply(r.ID, other, "spam"); err != nil {
+		t.Fatalf("ReportReply() error = %v", err)
+	}
+
+	if _, err := service.ModerationQueue("go", learner); !errors.Is(err, ErrForbidden) {
+		t.Errorf("ModerationQueue() by learner error = %v, want ErrForbidden", err)
+	}
+	queue, err := service.ModerationQueue("go", trainer)
+	if err != nil || len(queue) != 2 {
+		t.Fatalf("ModerationQueue() = %d items, %v, want 2", len(queue), err)
+	}
+
+	if _, err := service.ModeratePost(p.ID, trainer, forum.ActionHide, ""); err == nil {
+		t.Error("ModeratePost() without reason expected error")
+	}
+	if _, err := service.ModeratePost(p.ID, learner, forum.ActionHide, "spam"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("ModeratePost() by learner error = %v, want ErrForbidden", err)
+	}
+	if _, err := service.ModeratePost(p.ID, trainer, "delete", "spam"); !errors.Is(err, ErrInvalidAction) {
+		t.Errorf("ModeratePost() unknown action error = %v, want ErrInvalidAction", err)
+	}
+	hidden, err := service.ModeratePost(p.ID, trainer, forum.ActionHide, "advertising")
+	if err != nil || hidden.State != forum.StateHidden {
+		t.Fatalf("ModeratePost(hide) = %+v, %v", hidden, err)
+	}
+	if _, err := service.GetPost(p.ID, other); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("GetPost() of hidden post error = %v, want ErrPostNotFound", err)
+	}
+	if _, err := service.GetPost(p.ID, learner); err != nil {
+		t.Errorf("GetPost() of hidden post by author error = %v", err)
+	}
+	if posts, _, _ := service.ListPosts(f.ID, other, "", 1, 20); len(posts) != 0 {
+		t.Errorf("ListPosts() shows %d hidden posts", len(posts))
+	}
+	if posts, _, _ := service.ListPosts(f.ID, trainer, "", 1, 20); len(posts) != 1 {
+		t.Errorf("ListPosts() for staff = %d posts, want the hidden one", len(posts))
+	}
+	if _, err := service.ModerateReply(r.ID, trainer, forum.ActionDismiss, "fine on its own"); err != nil {
+		t.Fatalf("ModerateReply(dismiss) error = %v", err)
+	}
+	if queue, _ := service.ModerationQueue("go", trainer); len(queue) != 0 {
+		t.Errorf("ModerationQueue() after resolving = %d items, want 0", len(queue))
+	}
+	if got := moderation.reports[0].Status; got != forum.ReportResolved {
+		t.Errorf("post report status = %q, want resolved", got)
+	}
+	if got := moderation.reports[1].Status; got != forum.ReportDismissed {
+		t.Errorf("reply report status = %q, want dismissed", got)
+	}
+
+	if _, err := service.ModeratePost(p.ID, trainer, forum.ActionRestore, "appealed"); err != nil || repo.posts[p.ID].State != forum.StateVisible {
+		t.Errorf("ModeratePost(restore) state = %q, %v", repo.posts[p.ID].State, err)
+	}
+	log, _ := service.ModerationLog("go", trainer, 0)
+	if len(log) != 3 || log[0].ModeratorID != "tina" || log[0].Reason != "advertising" {
+		t.Errorf("ModerationLog() = %+v", log)
+	}
+}
+
+func TestForumService_LockPinAndSuspend(t *testing.T) {
+	service, _ := newTestService()
+	f, _ := service.CourseForum("go", learner)
+	p, _ := service.CreatePost(f.ID, learner, forum.TypeDiscussion, "b", "Closed topic")
+	r, _ := service.Reply(p.ID, other, "First!")
+	service.CreatePost(f.ID, learner, forum.TypeDiscussion, "a", "Other topic")
+
+	if _, err := service.ModeratePost(p.ID, trainer, forum.ActionLock, "resolved"); err != nil {
+		t.Fatalf("ModeratePost(lock) error = %v", err)
+	}
+	if _, err := service.Reply(p.ID, other, "One more"); !errors.Is(err, ErrLocked) {
+		t.Errorf("Reply() to locked thread error = %v, want ErrLocked", err)
+	}
+	if _, err := service.EditReply(r.ID, other, "Second!"); !errors.Is(err, ErrLocked) {
+		t.Errorf("EditReply() in locked thread error = %v, want ErrLocked", err)
+	}
+	if _, err := service.Reply(p.ID, trainer, "Locked, see the FAQ."); err != nil {
+		t.Errorf("Reply() by staff to locked thread error = %v", err)
+	}
+
+	if _, err := service.ModeratePost(p.ID, trainer, forum.ActionPin, "read first"); err != nil {
+		t.Fatalf("ModeratePost(pin) error = %v", err)
+	}
+	if posts, _, _ := service.ListPosts(f.ID, other, "", 1, 20); posts[0].ID != p.ID {
+		t.Errorf("ListPosts() first = %q, want the pinned thread", posts[0].Title)
+	}
+
+	if _, err := service.Suspend("go", "bob", learner, time.Hour, "spam"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Suspend() by learner error = %v, want ErrForbidden", err)
+	}
+	if _, err := service.Suspend("go", "bob", trainer, 0, "spam"); err == nil {
+		t.Error("Suspend() without duration expected error")
+	}
+	sus, err := service.Suspend("go", "bob", trainer, 24*time.Hour, "repeated spam")
+	if err != nil {
+		t.Fatalf("Suspend() error = %v", err)
+	}
+	if _, err := service.CreatePost(f.ID, other, forum.TypeDiscussion, "Hi", "I'm back"); !errors.Is(err, ErrSuspended) {
+		t.Errorf("CreatePost() while suspended error = %v, want ErrSuspended", err)
+	}
+	if _, _, err := service.ListPosts(f.ID, other, "", 1, 20); err != nil {
+		t.Errorf("ListPosts() while suspended error = %v", err)
+	}
+	general, _ := service.CreateForum("Off topic", trainer)
+	if _, err := service.CreatePost(general.ID, other, forum.TypeDiscussion, "Hi", "Elsewhere"); err != nil {
+		t.Errorf("CreatePost() in a general forum while suspended error = %v", err)
+	}
+
+	if _, err := service.LiftSuspension(sus.ID, trainer, "apologised"); err != nil {
+		t.Fatalf("LiftSuspension() error = %v", err)
+	}
+	if _, err := service.LiftSuspension(sus.ID, trainer, "again"); err == nil {
+		t.Error("LiftSuspension() twice expected error")
+	}
+	if _, err := service.CreatePost(f.ID, other, forum.TypeDiscussion, "Hi", "I'm back"); err != nil {
+		t.Errorf("CreatePost() after lifting error = %v", err)
+	}
+}
+
+func TestForumService_WordFilter(t *testing.T) {
+	service, _ := newTestService()
+	service.BlockedWords = []string{"casino", "free money"}
+	f, _ := service.CourseForum("go", learner)
+
+	p, err := service.CreatePost(f.ID, learner, forum.TypeDiscussion, "Offer", "Get FREE   money now!")
+	if err != nil || p.State != forum.StateHeld {
+		t.Fatalf("CreatePost() with blocked phrase = %+v, %v, want held", p, err)
+	}
+	if _, err := service.GetPost(p.ID, other); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("GetPost() of held post error = %v, want ErrPostNotFound", err)
+	}
+	queue, _ := service.ModerationQueue("go", trainer)
+	if len(queue) != 1 || queue[0].State != forum.StateHeld {
+		t.Errorf("ModerationQueue() = %+v, want the held post", queue)
+	}
+	log, _ := service.ModerationLog("go", trainer, 20)
+	if len(log) != 1 || log[0].Action != forum.ActionHold || log[0].ModeratorID != "" || log[0].Reason != "blocked word: free money" {
+		t.Errorf("ModerationLog() = %+v, want an automatic hold", log)
+	}
+	if _, err := service.ModeratePost(p.ID, trainer, forum.ActionApprove, "legitimate"); err != nil {
+		t.Fatalf("ModeratePost(approve) error = %v", err)
+	}
+	if _, err := service.GetPost(p.ID, other); err != nil {
+		t.Errorf("GetPost() after approval error = %v", err)
+	}
+
+	tests := []struct {
+		actor Actor
+		text  string
+		want  string
+	}{
+		{learner, "The Casino scene", "casino"},
+		{learner, "casinos are fine", ""},
+		{learner, "free, money", "free money"},
+		{learner, "free of money", ""},
+		{trainer, "casino", ""},
+	}
+	for _, tt := range tests {
+		if got := service.screen(tt.actor, tt.text); got != tt.want {
+			t.Errorf("screen(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	r, _ := service.Reply(p.ID, other, "Nice")
+	if edited, _ := service.EditReply(r.ID, other, "Try the casino"); edited.State != forum.StateHeld {
+		t.Errorf("EditReply() with blocked word state = %q, want held", edited.State)
+	}
+}
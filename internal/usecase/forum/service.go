@@ -0,0 +1,709 @@
+// File: internal/usecase/forum/service.go
+package forum
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/forum"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrForumNotFound      = errors.New("forum not found")
+	ErrPostNotFound       = errors.New("post not found")
+	ErrReplyNotFound      = errors.New("reply not found")
+	ErrCourseNotFound     = errors.New("course not found")
+	ErrModuleNotFound     = errors.New("module not found")
+	ErrEnrollmentRequired = errors.New("enrollment required")
+	ErrForbidden          = errors.New("only the author or staff can do this")
+	ErrNotQuestion        = errors.New("only questions have votes and accepted answers")
+)
+
+// ForumRepository is the persistence contract used by ForumService.
+type ForumRepository interface {
+	CreateForum(f *forum.Forum) error
+	// EnsureForum returns the forum of f's course, or of its module when
+	// ModuleID is set, inserting f if there is none yet.
+	EnsureForum(f *forum.Forum) (*forum.Forum, error)
+	FindForum(id string) (*forum.Forum, error)
+	// ListForums returns the forums of a course, or the general forums when
+	// courseID is empty.
+	ListForums(courseID string) ([]*forum.Forum, error)
+
+	CreatePost(p *forum.Post) error
+	FindPost(id string) (*forum.Post, error)
+	// ListPosts returns a page of the posts matching the filter that are not
+	// deleted, and how many there are in total.
+	ListPosts(filter forum.PostFilter) ([]*forum.Post, int, error)
+	// UpdatePost saves the post and records rev in one transaction.
+	UpdatePost(p *forum.Post, rev *forum.Revision) error
+
+	// CreateReply inserts the reply and moves its post's LastActivityAt.
+	CreateReply(r *forum.Reply) error
+	FindReply(id string) (*forum.Reply, error)
+	// ListReplies returns a page of the post's replies that match the filter
+	// and are not deleted, and how many there are in total.
+	ListReplies(filter forum.ReplyFilter) ([]*forum.Reply, int, error)
+	// UpdateReply saves the reply and records rev in one transaction.
+	UpdateReply(r *forum.Reply, rev *forum.Revision) error
+
+	// ListRevisions returns the revisions of a post or reply, oldest first.
+	ListRevisions(postID, replyID string) ([]*forum.Revision, error)
+
+	// SaveVote stores, changes or (with Value 0) removes a vote and returns
+	// the new score of the post or reply.
+	SaveVote(v *forum.Vote) (int, error)
+	// FindVote returns the user's vote on a post or reply, 0 if none.
+	FindVote(userID, postID, replyID string) (int, error)
+	// SetAccepted marks a reply as the post's accepted answer; an empty
+	// replyID clears it.
+	SetAccepted(postID, replyID string) error
+}
+
+// CourseFinder looks up courses.
+type CourseFinder interface {
+	FindByID(id string) (*course.Course, error)
+}
+
+// ModuleFinder looks up modules.
+type ModuleFinder interface {
+	FindByID(id string) (*course.Module, error)
+	ListByCourse(courseID string) ([]*course.Module, error)
+}
+
+// EnrollmentChecker reports whether a user is enrolled in a course.
+type EnrollmentChecker interface {
+	IsEnrolled(userID, courseID string) (bool, error)
+}
+
+// Actor is the user a forum operation is performed for.
+type Actor struct {
+	UserID string
+	Staff  bool // admins and trainers see every forum and can edit any post
+}
+
+// ForumService manages course and module forums with their posts and
+// replies. Course forums are visible to enrolled users and staff only.
+// Staff moderate every forum; see moderation.go. Content is Markdown and is
+// returned with its rendered HTML.
+type ForumService struct {
+	Repo          ForumRepository
+	Moderation    ModerationRepository
+	Subscriptions SubscriptionRepository
+	Courses       CourseFinder
+	Modules       ModuleFinder
+	Enrollments   EnrollmentChecker
+	// Users and Notifier are optional; without them nobody is notified of
+	// mentions and new activity.
+	Users    UserFinder
+	Notifier Notifier
+	// MaxLength limits the characters in a post or reply; 0 means 20000.
+	MaxLength int
+	// BlockedWords holds posts and replies that contain any of these words
+	// or phrases for review.
+	BlockedWords []string
+}
+
+// CreateForum creates a general forum. Only staff can create one; course and
+// module forums are created automatically.
+func (s *ForumService) CreateForum(title string, actor Actor) (*forum.Forum, error) {
+	if !actor.Staff {
+		return nil, ErrForbidden
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, errors.New("title is required")
+	}
+	f := &forum.Forum{
+		ID:        uuid.New().String(),
+		Title:     title,
+		CreatedBy: actor.UserID,
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := s.Repo.CreateForum(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// GetForum returns a forum the actor can see.
+func (s *ForumService) GetForum(id string, actor Actor) (*forum.Forum, error) {
+	f, err := s.Repo.FindForum(id)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, ErrForumNotFound
+	}
+	if err := s.checkAccess(f.CourseID, actor); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// CourseForum returns the course's forum, creating it on first use.
+func (s *ForumService) CourseForum(courseID string, actor Actor) (*forum.Forum, error) {
+	c, err := s.Courses.FindByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrCourseNotFound
+	}
+	if err := s.checkAccess(c.ID, actor); err != nil {
+		return nil, err
+	}
+	return s.ensure(&forum.Forum{Title: c.Title, CourseID: c.ID})
+}
+
+// ModuleForum returns the module's forum, creating it on first use.
+func (s *ForumService) ModuleForum(moduleID string, actor Actor) (*forum.Forum, error) {
+	m, err := s.Modules.FindByID(moduleID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrModuleNotFound
+	}
+	if err := s.checkAccess(m.CourseID, actor); err != nil {
+		return nil, err
+	}
+	return s.ensure(&forum.Forum{Title: m.Title, CourseID: m.CourseID, ModuleID: m.ID})
+}
+
+// ListForums returns the general forums, or with a courseID the forums of
+// the course and each of its modules.
+func (s *ForumService) ListForums(courseID string, actor Actor) ([]*forum.Forum, error) {
+	if courseID == "" {
+		return s.Repo.ListForums("")
+	}
+	if _, err := s.CourseForum(courseID, actor); err != nil {
+		return nil, err
+	}
+	modules, err := s.Modules.ListByCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range modules {
+		if _, err := s.ensure(&forum.Forum{Title: m.Title, CourseID: courseID, ModuleID: m.ID}); err != nil {
+			return nil, err
+		}
+	}
+	return s.Repo.ListForums(courseID)
+}
+
+// ListPosts returns a page of a forum's posts in the given order, most
+// recently active first by default, and the total number of posts.
+func (s *ForumService) ListPosts(forumID string, actor Actor, sort forum.PostSort, page, limit int) ([]*forum.Post, int, error) {
+	if sort == "" {
+		sort = forum.SortActivity
+	}
+	if !sort.Valid() {
+		return nil, 0, fmt.Errorf("unknown sort %q", sort)
+	}
+	if _, err := s.GetForum(forumID, actor); err != nil {
+		return nil, 0, err
+	}
+	offset, limit := pageBounds(page, limit)
+	return renderPosts(s.Repo.ListPosts(forum.PostFilter{
+		ForumID:   forumID,
+		Sort:      sort,
+		ViewerID:  actor.UserID,
+		AllStates: actor.Staff,
+		Offset:    offset,
+		Limit:     limit,
+	}))
+}
+
+// UnansweredQuestions is the trainers' queue of unanswered questions in a
+// course and its modules, oldest first.
+func (s *ForumService) UnansweredQuestions(courseID string, actor Actor, page, limit int) ([]*forum.Post, int, error) {
+	if !actor.Staff {
+		return nil, 0, ErrForbidden
+	}
+	c, err := s.Courses.FindByID(courseID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if c == nil {
+		return nil, 0, ErrCourseNotFound
+	}
+	offset, limit := pageBounds(page, limit)
+	return renderPosts(s.Repo.ListPosts(forum.PostFilter{CourseID: c.ID, Sort: forum.SortUnanswered, ViewerID: actor.UserID, Offset: offset, Limit: limit}))
+}
+
+// CreatePost starts a discussion or question thread in a forum.
+func (s *ForumService) CreatePost(forumID string, actor Actor, postType forum.PostType, title, content string) (*forum.Post, error) {
+	if postType == "" {
+		postType = forum.TypeDiscussion
+	}
+	if !postType.Valid() {
+		return nil, fmt.Errorf("unknown post type %q", postType)
+	}
+	title, content, err := s.validatePost(title, content)
+	if err != nil {
+		return nil, err
+	}
+	f, err := s.GetForum(forumID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkSuspension(f.CourseID, actor); err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	p := &forum.Post{
+		ID:             uuid.New().String(),
+		ForumID:        forumID,
+		UserID:         actor.UserID,
+		Type:           postType,
+		State:          forum.StateVisible,
+		Title:          title,
+		Content:        content,
+		CreatedAt:      now,
+		LastActivityAt: now,
+	}
+	word := s.screen(actor, title, content)
+	if word != "" {
+		p.State = forum.StateHeld
+	}
+	if err := s.Repo.CreatePost(p); err != nil {
+		return nil, err
+	}
+	if word != "" {
+		s.logHold(f.CourseID, p.ID, "", actor.UserID, word)
+	}
+	p.HTML = renderMarkdown(p.Content)
+	s.follow(p)
+	s.announce(f, p, nil, "")
+	return p, nil
+}
+
+// GetPost returns a post the actor can see. Held and hidden posts are only
+// shown to their author and staff.
+func (s *ForumService) GetPost(id string, actor Actor) (*forum.Post, error) {
+	p, _, err := s.thread(id, actor)
+	return p, err
+}
+
+// thread returns a post the actor can see together with its forum.
+func (s *ForumService) thread(id string, actor Actor) (*forum.Post, *forum.Forum, error) {
+	p, err := s.Repo.FindPost(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p == nil || p.DeletedAt != 0 || !visibleTo(p.State, p.UserID, actor) {
+		return nil, nil, ErrPostNotFound
+	}
+	f, err := s.GetForum(p.ForumID, actor)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.Type == forum.TypeQuestion {
+		if p.Vote, err = s.Repo.FindVote(actor.UserID, p.ID, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+	p.HTML = renderMarkdown(p.Content)
+	return p, f, nil
+}
+
+// EditPost changes a post's title and content, keeping the previous version.
+func (s *ForumService) EditPost(id string, actor Actor, title, content string) (*forum.Post, error) {
+	title, content, err := s.validatePost(title, content)
+	if err != nil {
+		return nil, err
+	}
+	p, f, err := s.thread(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if p.UserID != actor.UserID && !actor.Staff {
+		return nil, ErrForbidden
+	}
+	if err := s.checkWritable(f, p, actor); err != nil {
+		return nil, err
+	}
+	if p.Title == title && p.Content == content {
+		return p, nil
+	}
+	rev := s.revision(forum.RevisionEdited, actor)
+	rev.PostID, rev.Title, rev.Content = p.ID, p.Title, p.Content
+	p.Title, p.Content, p.EditedAt = title, content, rev.EditedAt
+	p.HTML = renderMarkdown(content)
+	word := s.screen(actor, title, content)
+	if word != "" && p.State == forum.StateVisible {
+		p.State = forum.StateHeld
+	}
+	if err := s.Repo.UpdatePost(p, rev); err != nil {
+		return nil, err
+	}
+	if word != "" {
+		s.logHold(f.CourseID, p.ID, "", p.UserID, word)
+	}
+	s.announce(f, p, nil, rev.Content)
+	return p, nil
+}
+
+// DeletePost removes a post and its thread from the forum. Its content is
+// kept in the post's history.
+func (s *ForumService) DeletePost(id string, actor Actor) error {
+	p, err := s.GetPost(id, actor)
+	if err != nil {
+		return err
+	}
+	if p.UserID != actor.UserID && !actor.Staff {
+		return ErrForbidden
+	}
+	rev := s.revision(forum.RevisionDeleted, actor)
+	rev.PostID, rev.Title, rev.Content = p.ID, p.Title, p.Content
+	p.Content, p.HTML, p.DeletedAt = "", "", rev.EditedAt
+	return s.Repo.UpdatePost(p, rev)
+}
+
+// ListReplies returns a page of a post's replies, oldest first, and the
+// total number of replies.
+func (s *ForumService) ListReplies(postID string, actor Actor, page, limit int) ([]*forum.Reply, int, error) {
+	if _, err := s.GetPost(postID, actor); err != nil {
+		return nil, 0, err
+	}
+	offset, limit := pageBounds(page, limit)
+	replies, total, err := s.Repo.ListReplies(forum.ReplyFilter{
+		PostID:    postID,
+		ViewerID:  actor.UserID,
+		AllStates: actor.Staff,
+		Offset:    offset,
+		Limit:     limit,
+	})
+	for _, r := range replies {
+		r.HTML = renderMarkdown(r.Content)
+	}
+	return replies, total, err
+}
+
+// Reply adds a reply to a post.
+func (s *ForumService) Reply(postID string, actor Actor, content string) (*forum.Reply, error) {
+	content, err := s.validateContent(content)
+	if err != nil {
+		return nil, err
+	}
+	p, f, err := s.thread(postID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkWritable(f, p, actor); err != nil {
+		return nil, err
+	}
+	r := &forum.Reply{
+		ID:        uuid.New().String(),
+		PostID:    postID,
+		UserID:    actor.UserID,
+		Content:   content,
+		State:     forum.StateVisible,
+		CreatedAt: time.Now().Unix(),
+	}
+	word := s.screen(actor, content)
+	if word != "" {
+		r.State = forum.StateHeld
+	}
+	if err := s.Repo.CreateReply(r); err != nil {
+		return nil, err
+	}
+	if word != "" {
+		s.logHold(f.CourseID, p.ID, r.ID, actor.UserID, word)
+	}
+	r.HTML = renderMarkdown(r.Content)
+	s.announce(f, p, r, "")
+	return r, nil
+}
+
+// GetReply returns a reply the actor can see.
+func (s *ForumService) GetReply(id string, actor Actor) (*forum.Reply, error) {
+	r, _, _, err := s.reply(id, actor)
+	return r, err
+}
+
+// reply returns a reply the actor can see together with its post and forum.
+func (s *ForumService) reply(id string, actor Actor) (*forum.Reply, *forum.Post, *forum.Forum, error) {
+	r, err := s.Repo.FindReply(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if r == nil || r.DeletedAt != 0 || !visibleTo(r.State, r.UserID, actor) {
+		return nil, nil, nil, ErrReplyNotFound
+	}
+	p, f, err := s.thread(r.PostID, actor)
+	if err != nil {
+		if errors.Is(err, ErrPostNotFound) {
+			return nil, nil, nil, ErrReplyNotFound
+		}
+		return nil, nil, nil, err
+	}
+	r.HTML = renderMarkdown(r.Content)
+	return r, p, f, nil
+}
+
+// EditReply changes a reply's content, keeping the previous version.
+func (s *ForumService) EditReply(id string, actor Actor, content string) (*forum.Reply, error) {
+	content, err := s.validateContent(content)
+	if err != nil {
+		return nil, err
+	}
+	r, p, f, err := s.reply(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if r.UserID != actor.UserID && !actor.Staff {
+		return nil, ErrForbidden
+	}
+	if err := s.checkWritable(f, p, actor); err != nil {
+		return nil, err
+	}
+	if r.Content == content {
+		return r, nil
+	}
+	rev := s.revision(forum.RevisionEdited, actor)
+	rev.ReplyID, rev.Content = r.ID, r.Content
+	r.Content, r.EditedAt = content, rev.EditedAt
+	r.HTML = renderMarkdown(content)
+	word := s.screen(actor, content)
+	if word != "" && r.State == forum.StateVisible {
+		r.State = forum.StateHeld
+	}
+	if err := s.Repo.UpdateReply(r, rev); err != nil {
+		return nil, err
+	}
+	if word != "" {
+		s.logHold(f.CourseID, p.ID, r.ID, r.UserID, word)
+	}
+	s.announce(f, p, r, rev.Content)
+	return r, nil
+}
+
+// DeleteReply removes a reply. Its content is kept in the reply's history.
+func (s *ForumService) DeleteReply(id string, actor Actor) error {
+	r, err := s.GetReply(id, actor)
+	if err != nil {
+		return err
+	}
+	if r.UserID != actor.UserID && !actor.Staff {
+		return ErrForbidden
+	}
+	rev := s.revision(forum.RevisionDeleted, actor)
+	rev.ReplyID, rev.Content = r.ID, r.Content
+	r.Content, r.DeletedAt = "", rev.EditedAt
+	if err := s.Repo.UpdateReply(r, rev); err != nil {
+		return err
+	}
+	if r.Accepted {
+		return s.Repo.SetAccepted(r.PostID, "")
+	}
+	return nil
+}
+
+// VotePost records the actor's vote on a question: 1 up, -1 down, 0 to
+// withdraw it. It returns the question's new score.
+func (s *ForumService) VotePost(id string, actor Actor, value int) (int, error) {
+	p, err := s.GetPost(id, actor)
+	if err != nil {
+		return 0, err
+	}
+	if p.Type != forum.TypeQuestion {
+		return 0, ErrNotQuestion
+	}
+	return s.vote(&forum.Vote{UserID: actor.UserID, PostID: p.ID, Value: value}, p.UserID)
+}
+
+// VoteReply records the actor's vote on an answer to a question and returns
+// the answer's new score.
+func (s *ForumService) VoteReply(id string, actor Actor, value int) (int, error) {
+	r, err := s.GetReply(id, actor)
+	if err != nil {
+		return 0, err
+	}
+	p, err := s.Repo.FindPost(r.PostID)
+	if err != nil {
+		return 0, err
+	}
+	if p.Type != forum.TypeQuestion {
+		return 0, ErrNotQuestion
+	}
+	return s.vote(&forum.Vote{UserID: actor.UserID, ReplyID: r.ID, Value: value}, r.UserID)
+}
+
+func (s *ForumService) vote(v *forum.Vote, authorID string) (int, error) {
+	if v.Value < -1 || v.Value > 1 {
+		return 0, errors.New("vote must be 1, -1 or 0")
+	}
+	if v.UserID == authorID {
+		return 0, errors.New("you cannot vote on your own post")
+	}
+	v.CreatedAt = time.Now().Unix()
+	return s.Repo.SaveVote(v)
+}
+
+// AcceptAnswer marks one of a question's replies as its accepted answer, or
+// clears the accepted answer when replyID is empty. Only the asker and staff
+// can do this.
+func (s *ForumService) AcceptAnswer(postID, replyID string, actor Actor) (*forum.Post, error) {
+	p, err := s.GetPost(postID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if p.Type != forum.TypeQuestion {
+		return nil, ErrNotQuestion
+	}
+	if p.UserID != actor.UserID && !actor.Staff {
+		return nil, ErrForbidden
+	}
+	if replyID != "" {
+		r, err := s.GetReply(replyID, actor)
+		if err != nil {
+			return nil, err
+		}
+		if r.PostID != p.ID {
+			return nil, ErrReplyNotFound
+		}
+	}
+	if err := s.Repo.SetAccepted(p.ID, replyID); err != nil {
+		return nil, err
+	}
+	p.AcceptedReplyID = replyID
+	return p, nil
+}
+
+// PostHistory returns the earlier versions of a post, including deleted
+// ones. Only its author and staff can see them.
+func (s *ForumService) PostHistory(id string, actor Actor) ([]*forum.Revision, error) {
+	p, err := s.Repo.FindPost(id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrPostNotFound
+	}
+	if _, err := s.GetForum(p.ForumID, actor); err != nil {
+		return nil, err
+	}
+	if p.UserID != actor.UserID && !actor.Staff {
+		return nil, ErrForbidden
+	}
+	return s.Repo.ListRevisions(p.ID, "")
+}
+
+// ReplyHistory returns the earlier versions of a reply, including deleted
+// ones. Only its author and staff can see them.
+func (s *ForumService) ReplyHistory(id string, actor Actor) ([]*forum.Revision, error) {
+	r, err := s.Repo.FindReply(id)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrReplyNotFound
+	}
+	p, err := s.Repo.FindPost(r.PostID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrReplyNotFound
+	}
+	if _, err := s.GetForum(p.ForumID, actor); err != nil {
+		return nil, err
+	}
+	if r.UserID != actor.UserID && !actor.Staff {
+		return nil, ErrForbidden
+	}
+	return s.Repo.ListRevisions("", r.ID)
+}
+
+// renderPosts adds the rendered HTML to a page of posts.
+func renderPosts(posts []*forum.Post, total int, err error) ([]*forum.Post, int, error) {
+	for _, p := range posts {
+		p.HTML = renderMarkdown(p.Content)
+	}
+	return posts, total, err
+}
+
+// visibleTo reports whether content in the given state is shown to actor.
+func visibleTo(state forum.State, authorID string, actor Actor) bool {
+	return state == forum.StateVisible || state == "" || actor.Staff || authorID == actor.UserID
+}
+
+// checkAccess allows staff into every forum and everyone into general
+// forums; course forums need an active or completed enrollment.
+func (s *ForumService) checkAccess(courseID string, actor Actor) error {
+	if courseID == "" || actor.Staff {
+		return nil
+	}
+	enrolled, err := s.Enrollments.IsEnrolled(actor.UserID, courseID)
+	if err != nil {
+		return err
+	}
+	if !enrolled {
+		return ErrEnrollmentRequired
+	}
+	return nil
+}
+
+func (s *ForumService) ensure(f *forum.Forum) (*forum.Forum, error) {
+	f.ID = uuid.New().String()
+	f.CreatedAt = time.Now().Unix()
+	return s.Repo.EnsureForum(f)
+}
+
+func (s *ForumService) revision(action forum.RevisionAction, actor Actor) *forum.Revision {
+	return &forum.Revision{
+		ID:       uuid.New().String(),
+		Action:   action,
+		EditedBy: actor.UserID,
+		EditedAt: time.Now().Unix(),
+	}
+}
+
+func (s *ForumService) validatePost(title, content string) (string, string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", "", errors.New("title is required")
+	}
+	if utf8.RuneCountInString(title) > 255 {
+		return "", "", errors.New("title is longer than 255 characters")
+	}
+	content, err := s.validateContent(content)
+	return title, content, err
+}
+
+func (s *ForumService) validateContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", errors.New("content is required")
+	}
+	max := s.MaxLength
+	if max <= 0 {
+		max = 20000
+	}
+	if utf8.RuneCountInString(content) > max {
+		return "", fmt.Errorf("content is longer than %d characters", max)
+	}
+	return content, nil
+}
+
+// pageBounds turns a 1-based page and page size into an offset and limit.
+func pageBounds(page, limit int) (int, int) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if page < 1 {
+		page = 1
+	}
+	return (page - 1) * limit, limit
+}
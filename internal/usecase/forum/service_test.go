@@ -0,0 +1,454 @@
+package forum
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/forum"
+)
+
+// MockRepository is an in-memory implementation of ForumRepository
+type MockRepository struct {
+	forums    map[string]*forum.Forum
+	posts     map[string]*forum.Post
+	replies   map[string]*forum.Reply
+	revisions []*forum.Revision
+	votes     map[string]int // "user/post or reply ID" to value
+}
+
+func newMockRepository() *MockRepository {
+	return &MockRepository{
+		forums:  map[string]*forum.Forum{},
+		posts:   map[string]*forum.Post{},
+		replies: map[string]*forum.Reply{},
+		votes:   map[string]int{},
+	}
+}
+
+func (m *MockRepository) CreateForum(f *forum.Forum) error {
+	m.forums[f.ID] = f
+	return nil
+}
+
+func (m *MockRepository) EnsureForum(f *forum.Forum) (*forum.Forum, error) {
+	for _, existing := range m.forums {
+		if existing.CourseID == f.CourseID && existing.ModuleID == f.ModuleID {
+			return existing, nil
+		}
+	}
+	m.forums[f.ID] = f
+	return f, nil
+}
+
+func (m *MockRepository) FindForum(id string) (*forum.Forum, error) { return m.forums[id], nil }
+
+func (m *MockRepository) ListForums(courseID string) ([]*forum.Forum, error) {
+	var out []*forum.Forum
+	for _, f := range m.forums {
+		if f.CourseID == courseID {
+			out = append(out, f)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModuleID < out[j].ModuleID })
+	return out, nil
+}
+
+func (m *MockRepository) CreatePost(p *forum.Post) error {
+	m.posts[p.ID] = p
+	return nil
+}
+
+func (m *MockRepository) FindPost(id string) (*forum.Post, error) { return m.posts[id], nil }
+
+func (m *MockRepository) ListPosts(filter forum.PostFilter) ([]*forum.Post, int, error) {
+	var out []*forum.Post
+	for _, p := range m.posts {
+		f := m.forums[p.ForumID]
+		if p.DeletedAt != 0 || (filter.ForumID != "" && p.ForumID != filter.ForumID) || (filter.ForumID == "" && f.CourseID != filter.CourseID) {
+			continue
+		}
+		if !filter.AllStates && p.State != forum.StateVisible {
+			continue
+		}
+		if filter.Sort == forum.SortUnanswered && (p.Type != forum.TypeQuestion || p.AcceptedReplyID != "" || m.hasUpvotedAnswer(p.ID)) {
+			continue
+		}
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if filter.Sort != forum.SortUnanswered && out[i].Pinned != out[j].Pinned {
+			return out[i].Pinned
+		}
+		if filter.Sort == forum.SortVotes && out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Title < out[j].Title
+	})
+	return page(out, filter.Offset, filter.Limit), len(out), nil
+}
+
+func (m *MockRepository) hasUpvotedAnswer(postID string) bool {
+	for _, r := range m.replies {
+		if r.PostID == postID && r.DeletedAt == 0 && r.Score > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MockRepository) UpdatePost(p *forum.Post, rev *forum.Revision) error {
+	m.revisions = append(m.revisions, rev)
+	return nil
+}
+
+func (m *MockRepository) CreateReply(r *forum.Reply) error {
+	m.replies[r.ID] = r
+	m.posts[r.PostID].ReplyCount++
+	return nil
+}
+
+func (m *MockRepository) FindReply(id string) (*forum.Reply, error) { return m.replies[id], nil }
+
+func (m *MockRepository) ListReplies(filter forum.ReplyFilter) ([]*forum.Reply, int, error) {
+	var out []*forum.Reply
+	for _, r := range m.replies {
+		if r.PostID == filter.PostID && r.DeletedAt == 0 && (filter.AllStates || r.State == forum.StateVisible) {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Content < out[j].Content })
+	return page(out, filter.Offset, filter.Limit), len(out), nil
+}
+
+func (m *MockRepository) UpdateReply(r *forum.Reply, rev *forum.Revision) error {
+	m.revisions = append(m.revisions, rev)
+	return nil
+}
+
+func (m *MockRepository) ListRevisions(postID, replyID string) ([]*forum.Revision, error) {
+	var out []*forum.Revision
+	for _, rev := range m.revisions {
+		if (postID != "" && rev.PostID == postID) || (replyID != "" && rev.ReplyID == replyID) {
+			out = append(out, rev)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockRepository) SaveVote(v *forum.Vote) (int, error) {
+	target := v.PostID + v.ReplyID
+	if v.Value == 0 {
+		delete(m.votes, v.UserID+"/"+target)
+	} else {
+		m.votes[v.UserID+"/"+target] = v.Value
+	}
+	score := 0
+	for key, value := range m.votes {
+		if key[len(key)-len(target):] == target {
+			score += value
+		}
+	}
+	if p := m.posts[v.PostID]; p != nil {
+		p.Score = score
+	}
+	if r := m.replies[v.ReplyID]; r != nil {
+		r.Score = score
+	}
+	return score, nil
+}
+
+func (m *MockRepository) FindVote(userID, postID, replyID string) (int, error) {
+	return m.votes[userID+"/"+postID+replyID], nil
+}
+
+func (m *MockRepository) SetAccepted(postID, replyID string) error {
+	p := m.posts[postID]
+	if old := m.replies[p.AcceptedReplyID]; old != nil {
+		old.Accepted = false
+	}
+	p.AcceptedReplyID = replyID
+	if r := m.replies[replyID]; r != nil {
+		r.Accepted = true
+	}
+	return nil
+}
+
+func page[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	if offset+limit < len(items) {
+		return items[offset : offset+limit]
+	}
+	return items[offset:]
+}
+
+type mockCourses map[string]*course.Course
+
+func (m mockCourses) FindByID(id string) (*course.Course, error) { return m[id], nil }
+
+type mockModules map[string]*course.Module
+
+func (m mockModules) FindByID(id string) (*course.Module, error) { return m[id], nil }
+
+func (m mockModules) ListByCourse(courseID string) ([]*course.Module, error) {
+	var out []*course.Module
+	for _, mod := range m {
+		if mod.CourseID == courseID {
+			out = append(out, mod)
+		}
+	}
+	return out, nil
+}
+
+// mockEnrollments holds "user/course" pairs that are enrolled
+type mockEnrollments map[string]bool
+
+func (m mockEnrollments) IsEnrolled(userID, courseID string) (bool, error) {
+	return m[userID+"/"+courseID], nil
+}
+
+var (
+	learner  = Actor{UserID: "ada"}
+	other    = Actor{UserID: "bob"}
+	outsider = Actor{UserID: "cy"}
+	trainer  = Actor{UserID: "tina", Staff: true}
+)
+
+func newTestService() (*ForumService, *MockRepository) {
+	repo := newMockRepository()
+	return &ForumService{
+		Repo:          repo,
+		Moderation:    newMockModeration(repo),
+		Subscriptions: mockSubscriptions{},
+		Courses:       mockCourses{"go": {ID: "go", Title: "Go Basics"}},
+		Modules:       mockModules{"loops": {ID: "loops", CourseID: "go", Title: "Loops"}},
+		Enrollments:   mockEnrollments{"ada/go": true, "bob/go": true},
+		Users:         testUsers,
+		Notifier:      &mockNotifier{},
+	}, repo
+}
+
+func TestForumService_CourseForums(t *testing.T) {
+	service, _ := newTestService()
+
+	f, err := service.CourseForum("go", learner)
+	if err != nil {
+		t.Fatalf("CourseForum() error = %v", err)
+	}
+	if f.Title != "Go Basics" || f.CourseID != "go" || f.ModuleID != "" {
+		t.Errorf("CourseForum() = %+v", f)
+	}
+	if again, _ := service.CourseForum("go", trainer); again.ID != f.ID {
+		t.Error("CourseForum() created a second forum for the course")
+	}
+	if _, err := service.CourseForum("go", outsider); !errors.Is(err, ErrEnrollmentRequired) {
+		t.Errorf("CourseForum() for unenrolled user error = %v, want ErrEnrollmentRequired", err)
+	}
+	if _, err := service.GetForum(f.ID, outsider); !errors.Is(err, ErrEnrollmentRequired) {
+		t.Errorf("GetForum() for unenrolled user error = %v, want ErrEnrollmentRequired", err)
+	}
+
+	forums, err := service.ListForums("go", learner)
+	if err != nil {
+		t.Fatalf("ListForums() error = %v", err)
+	}
+	if len(forums) != 2 || forums[0].ID != f.ID || forums[1].ModuleID != "loops" {
+		t.Errorf("ListForums() = %+v, want the course forum and one module forum", forums)
+	}
+	if _, err := service.ModuleForum("missing", learner); !errors.Is(err, ErrModuleNotFound) {
+		t.Errorf("ModuleForum() error = %v, want ErrModuleNotFound", err)
+	}
+
+	if _, err := service.CreateForum("Announcements", learner); !errors.Is(err, ErrForbidden) {
+		t.Errorf("CreateForum() by learner error = %v, want ErrForbidden", err)
+	}
+	general, _ := service.CreateForum("Announcements", trainer)
+	if _, err := service.CreatePost(general.ID, outsider, forum.TypeDiscussion, "Hello", "Anyone here?"); err != nil {
+		t.Errorf("CreatePost() in a general forum error = %v", err)
+	}
+}
+
+func TestForumService_EditAndDeleteKeepHistory(t *testing.T) {
+	service, repo := newTestService()
+	f, _ := service.CourseForum("go", learner)
+
+	p, err := service.CreatePost(f.ID, learner, forum.TypeDiscussion, "Loops", "How do I break out of two loops?")
+	if err != nil {
+		t.Fatalf("CreatePost() error = %v", err)
+	}
+	if p.UserID != "ada" || p.LastActivityAt == 0 {
+		t.Errorf("CreatePost() = %+v", p)
+	}
+	if _, err := service.CreatePost(f.ID, learner, forum.TypeDiscussion, " ", "content"); err == nil {
+		t.Error("CreatePost() without title expected error")
+	}
+
+	if _, err := service.EditPost(p.ID, other, "Loops", "hijacked"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("EditPost() by another user error = %v, want ErrForbidden", err)
+	}
+	edited, err := service.EditPost(p.ID, learner, "Nested loops", "How do I break out of nested loops?")
+	if err != nil || edited.EditedAt == 0 {
+		t.Fatalf("EditPost() = %+v, %v", edited, err)
+	}
+
+	r, _ := service.Reply(p.ID, other, "Use a labeled break.")
+	if _, err := service.EditReply(r.ID, trainer, "Use a labeled break statement."); err != nil {
+		t.Errorf("EditReply() by staff error = %v", err)
+	}
+	if err := service.DeleteReply(r.ID, learner); !errors.Is(err, ErrForbidden) {
+		t.Errorf("DeleteReply() by another user error = %v, want ErrForbidden", err)
+	}
+	if err := service.DeleteReply(r.ID, other); err != nil {
+		t.Fatalf("DeleteReply() error = %v", err)
+	}
+	if _, err := service.GetReply(r.ID, other); !errors.Is(err, ErrReplyNotFound) {
+		t.Errorf("GetReply() after delete error = %v, want ErrReplyNotFound", err)
+	}
+	history, err := service.ReplyHistory(r.ID, trainer)
+	if err != nil || len(history) != 2 || history[1].Action != forum.RevisionDeleted || history[1].Content != "Use a labeled break statement." {
+		t.Fatalf("ReplyHistory() of a deleted reply = %+v, %v", history, err)
+	}
+
+	history, err = service.PostHistory(p.ID, learner)
+	if err != nil || len(history) != 1 || history[0].Title != "Loops" || history[0].Action != forum.RevisionEdited {
+		t.Fatalf("PostHistory() = %+v, %v", history, err)
+	}
+	if _, err := service.PostHistory(p.ID, other); !errors.Is(err, ErrForbidden) {
+		t.Errorf("PostHistory() by another user error = %v, want ErrForbidden", err)
+	}
+
+	if err := service.DeletePost(p.ID, trainer); err != nil {
+		t.Fatalf("DeletePost() by staff error = %v", err)
+	}
+	last := repo.revisions[len(repo.revisions)-1]
+	if last.Action != forum.RevisionDeleted || last.Content != "How do I break out of nested loops?" {
+		t.Errorf("delete revision = %+v, want previous content kept", last)
+	}
+	if _, err := service.GetPost(p.ID, learner); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("GetPost() after delete error = %v, want ErrPostNotFound", err)
+	}
+}
+
+func TestForumService_Pagination(t *testing.T) {
+	service, _ := newTestService()
+	f, _ := service.CourseForum("go", learner)
+	for _, title := range []string{"a", "b", "c", "d", "e"} {
+		service.CreatePost(f.ID, learner, forum.TypeDiscussion, title, "content")
+	}
+
+	posts, total, err := service.ListPosts(f.ID, other, "", 2, 2)
+	if err != nil {
+		t.Fatalf("ListPosts() error = %v", err)
+	}
+	if total != 5 || len(posts) != 2 || posts[0].Title != "c" {
+		t.Errorf("ListPosts(page 2) = %d posts starting %q of %d", len(posts), posts[0].Title, total)
+	}
+	if posts, _, _ := service.ListPosts(f.ID, other, forum.SortActivity, 3, 2); len(posts) != 1 {
+		t.Errorf("ListPosts(page 3) = %d posts, want 1", len(posts))
+	}
+	if _, _, err := service.ListPosts(f.ID, outsider, "", 1, 20); !errors.Is(err, ErrEnrollmentRequired) {
+		t.Errorf("ListPosts() for unenrolled user error = %v, want ErrEnrollmentRequired", err)
+	}
+
+	tests := []struct {
+		page, limit      int
+		wantOff, wantLim int
+	}{
+		{0, 0, 0, 20},
+		{3, 10, 20, 10},
+		{1, 500, 0, 100},
+	}
+	for _, tt := range tests {
+		if off, lim := pageBounds(tt.page, tt.limit); off != tt.wantOff || lim != tt.wantLim {
+			t.Errorf("pageBounds(%d, %d) = %d, %d, want %d, %d", tt.page, tt.limit, off, lim, tt.wantOff, tt.wantLim)
+		}
+	}
+}
+
+func TestForumService_QuestionsAndAnswers(t *testing.T) {
+	service, _ := newTestService()
+	f, _ := service.CourseForum("go", learner)
+	module, _ := service.ModuleForum("loops", learner)
+
+	q, err := service.CreatePost(f.ID, learner, forum.TypeQuestion, "Maps", "Are maps ordered?")
+	if err != nil {
+		t.Fatalf("CreatePost(question) error = %v", err)
+	}
+	other2, _ := service.CreatePost(module.ID, other, forum.TypeQuestion, "Range", "Does range copy?")
+	discussion, _ := service.CreatePost(f.ID, other, forum.TypeDiscussion, "Intro", "Hi all")
+	if _, err := service.CreatePost(f.ID, learner, "poll", "Poll", "?"); err == nil {
+		t.Error("CreatePost() with unknown type expected error")
+	}
+
+	if _, err := service.VotePost(discussion.ID, learner, 1); !errors.Is(err, ErrNotQuestion) {
+		t.Errorf("VotePost() on a discussion error = %v, want ErrNotQuestion", err)
+	}
+	if _, err := service.VotePost(q.ID, learner, 1); err == nil {
+		t.Error("VotePost() on own question expected error")
+	}
+	if score, err := service.VotePost(q.ID, other, 1); err != nil || score != 1 {
+		t.Errorf("VotePost() = %d, %v, want 1", score, err)
+	}
+	if score, _ := service.VotePost(q.ID, other, -1); score != -1 {
+		t.Errorf("VotePost() changed vote score = %d, want -1", score)
+	}
+	if p, _ := service.GetPost(q.ID, other); p.Vote != -1 {
+		t.Errorf("GetPost() viewer vote = %d, want -1", p.Vote)
+	}
+	if _, err := service.VotePost(q.ID, other, 2); err == nil {
+		t.Error("VotePost() with value 2 expected error")
+	}
+
+	queue, total, err := service.UnansweredQuestions("go", trainer, 1, 20)
+	if err != nil || total != 2 || len(queue) != 2 {
+		t.Fatalf("UnansweredQuestions() = %d of %d, %v, want both questions", len(queue), total, err)
+	}
+	if _, _, err := service.UnansweredQuestions("go", learner, 1, 20); !errors.Is(err, ErrForbidden) {
+		t.Errorf("UnansweredQuestions() by learner error = %v, want ErrForbidden", err)
+	}
+
+	// An upvoted answer takes a question off the queue.
+	answer, _ := service.Reply(other2.ID, learner, "Yes, the value is copied.")
+	if score, err := service.VoteReply(answer.ID, trainer, 1); err != nil || score != 1 {
+		t.Fatalf("VoteReply() = %d, %v", score, err)
+	}
+	if _, total, _ := service.UnansweredQuestions("go", trainer, 1, 20); total != 1 {
+		t.Errorf("UnansweredQuestions() after upvoted answer = %d, want 1", total)
+	}
+
+	wrong, _ := service.Reply(q.ID, other, "Yes.")
+	right, _ := service.Reply(q.ID, trainer, "No, iteration order is random.")
+	if _, err := service.AcceptAnswer(q.ID, right.ID, other); !errors.Is(err, ErrForbidden) {
+		t.Errorf("AcceptAnswer() by non-asker error = %v, want ErrForbidden", err)
+	}
+	if _, err := service.AcceptAnswer(q.ID, answer.ID, learner); !errors.Is(err, ErrReplyNotFound) {
+		t.Errorf("AcceptAnswer() with another question's reply error = %v, want ErrReplyNotFound", err)
+	}
+	p, err := service.AcceptAnswer(q.ID, wrong.ID, learner)
+	if err != nil || p.AcceptedReplyID != wrong.ID {
+		t.Fatalf("AcceptAnswer() = %+v, %v", p, err)
+	}
+	if p, _ = service.AcceptAnswer(q.ID, right.ID, trainer); p.AcceptedReplyID != right.ID {
+		t.Errorf("AcceptAnswer() by staff = %q, want %q", p.AcceptedReplyID, right.ID)
+	}
+	if _, total, _ := service.UnansweredQuestions("go", trainer, 1, 20); total != 0 {
+		t.Errorf("UnansweredQuestions() after accepting = %d, want 0", total)
+	}
+	if err := service.DeleteReply(right.ID, trainer); err != nil {
+		t.Fatalf("DeleteReply() error = %v", err)
+	}
+	if p, _ := service.GetPost(q.ID, learner); p.AcceptedReplyID != "" {
+		t.Error("deleting the accepted answer did not clear it")
+	}
+
+	byVotes, _, _ := service.ListPosts(f.ID, trainer, forum.SortVotes, 1, 20)
+	if len(byVotes) != 2 || byVotes[0].ID != discussion.ID {
+		t.Errorf("ListPosts(votes) first = %q, want the unvoted discussion above the downvoted question", byVotes[0].Title)
+	}
+	if _, _, err := service.ListPosts(f.ID, trainer, "random", 1, 20); err == nil {
+		t.Error("ListPosts() with unknown sort expected error")
+	}
+}
@@ -0,0 +1,178 @@
+package forum
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"training-portal/internal/domain/forum"
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/user"
+)
+
+// SubscriptionRepository stores forum and thread subscriptions.
+type SubscriptionRepository interface {
+	// Subscribe saves the subscription, replacing the user's digest choice
+	// if they are already subscribed.
+	Subscribe(s *forum.Subscription) error
+	Unsubscribe(userID, forumID, postID string) error
+	ListSubscriptions(userID string) ([]*forum.Subscription, error)
+	// Subscribers returns the subscribers of the thread, when postID is
+	// set, followed by those of the forum.
+	Subscribers(forumID, postID string) ([]*forum.Subscription, error)
+}
+
+// UserFinder looks up the authors, subscribers and mentioned users of
+// forum content.
+type UserFinder interface {
+	FindByID(id string) (*user.User, error)
+	// FindByUsername returns the user whose username, the part of their
+	// email address before the @, matches case-insensitively, or nil if
+	// there is no such user or more than one.
+	FindByUsername(username string) (*user.User, error)
+}
+
+// Notifier sends forum notifications, digested as the subscription asks.
+type Notifier interface {
+	NotifyDigest(userID string, event notification.EventType, vars map[string]string, digest notification.DigestMode) error
+}
+
+// SubscribeForum subscribes the actor to new threads and replies in a forum.
+func (s *ForumService) SubscribeForum(forumID string, actor Actor, digest notification.DigestMode) (*forum.Subscription, error) {
+	if _, err := s.GetForum(forumID, actor); err != nil {
+		return nil, err
+	}
+	return s.subscribe(&forum.Subscription{UserID: actor.UserID, ForumID: forumID}, digest)
+}
+
+// SubscribeThread subscribes the actor to new replies in a thread.
+func (s *ForumService) SubscribeThread(postID string, actor Actor, digest notification.DigestMode) (*forum.Subscription, error) {
+	p, err := s.GetPost(postID, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.subscribe(&forum.Subscription{UserID: actor.UserID, PostID: p.ID}, digest)
+}
+
+func (s *ForumService) subscribe(sub *forum.Subscription, digest notification.DigestMode) (*forum.Subscription, error) {
+	switch digest {
+	case "", notification.DigestImmediate, notification.DigestDaily, notification.DigestWeekly:
+	default:
+		return nil, errors.New("digest must be immediate, daily or weekly")
+	}
+	sub.Digest = digest
+	sub.CreatedAt = time.Now().Unix()
+	if err := s.Subscriptions.Subscribe(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// UnsubscribeForum stops the actor's notifications about a forum.
+func (s *ForumService) UnsubscribeForum(forumID string, actor Actor) error {
+	return s.Subscriptions.Unsubscribe(actor.UserID, forumID, "")
+}
+
+// UnsubscribeThread stops the actor's notifications about a thread.
+func (s *ForumService) UnsubscribeThread(postID string, actor Actor) error {
+	return s.Subscriptions.Unsubscribe(actor.UserID, "", postID)
+}
+
+// ListSubscriptions returns the actor's subscriptions.
+func (s *ForumService) ListSubscriptions(actor Actor) ([]*forum.Subscription, error) {
+	return s.Subscriptions.ListSubscriptions(actor.UserID)
+}
+
+// follow subscribes the author of a new thread to its replies.
+func (s *ForumService) follow(p *forum.Post) {
+	if s.Subscriptions == nil {
+		return
+	}
+	sub := &forum.Subscription{UserID: p.UserID, PostID: p.ID, CreatedAt: p.CreatedAt}
+	if err := s.Subscriptions.Subscribe(sub); err != nil {
+		log.Printf("forums: subscribing %s to thread %s failed: %v", p.UserID, p.ID, err)
+	}
+}
+
+// announce tells the users mentioned in a new thread (r nil) or reply, and
+// then the forum's or thread's subscribers, about it. For an edit, previous
+// is the content before it and only users newly mentioned are told.
+// Content that is not visible is not announced; approving it does that.
+// Failures are logged rather than returned because the content is saved.
+func (s *ForumService) announce(f *forum.Forum, p *forum.Post, r *forum.Reply, previous string) {
+	if s.Notifier == nil || s.Users == nil || p.State != forum.StateVisible || (r != nil && r.State != forum.StateVisible) {
+		return
+	}
+	authorID, content, event, threadID := p.UserID, p.Content, notification.EventForumPost, ""
+	if r != nil {
+		authorID, content, event, threadID = r.UserID, r.Content, notification.EventForumReply, p.ID
+	}
+	authorName := "Someone"
+	if author, err := s.Users.FindByID(authorID); err == nil && author != nil {
+		authorName = author.Name
+	}
+	vars := map[string]string{
+		"author_name": authorName,
+		"forum_title": f.Title,
+		"post_title":  p.Title,
+		"excerpt":     excerpt(content, 200),
+	}
+
+	told := map[string]bool{authorID: true}
+	known := map[string]bool{}
+	for _, name := range mentions(previous) {
+		known[name] = true
+	}
+	for _, name := range mentions(content) {
+		if known[name] {
+			continue
+		}
+		u, err := s.Users.FindByUsername(name)
+		if err != nil || u == nil || told[u.ID] || !s.canRead(f, u) {
+			continue
+		}
+		told[u.ID] = true
+		if err := s.Notifier.NotifyDigest(u.ID, notification.EventForumMention, vars, ""); err != nil {
+			log.Printf("forums: notifying %s of a mention failed: %v", u.ID, err)
+		}
+	}
+
+	if previous != "" || s.Subscriptions == nil {
+		return
+	}
+	subs, err := s.Subscriptions.Subscribers(f.ID, threadID)
+	if err != nil {
+		log.Printf("forums: listing subscribers of forum %s failed: %v", f.ID, err)
+		return
+	}
+	for _, sub := range subs {
+		if told[sub.UserID] {
+			continue
+		}
+		told[sub.UserID] = true
+		u, err := s.Users.FindByID(sub.UserID)
+		if err != nil || u == nil || !s.canRead(f, u) {
+			continue
+		}
+		if err := s.Notifier.NotifyDigest(u.ID, event, vars, sub.Digest); err != nil {
+			log.Printf("forums: notifying subscriber %s failed: %v", u.ID, err)
+		}
+	}
+}
+
+// canRead reports whether u may read the forum, e.g. is still enrolled.
+func (s *ForumService) canRead(f *forum.Forum, u *user.User) bool {
+	staff := u.Role == user.RoleAdmin || u.Role == user.RoleTrainer
+	return s.checkAccess(f.CourseID, Actor{UserID: u.ID, Staff: staff}) == nil
+}
+
+// excerpt shortens Markdown content to about max characters on one line.
+func excerpt(content string, max int) string {
+	text := strings.Join(strings.Fields(content), " ")
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	return string([]rune(text)[:max]) + "…"
+}
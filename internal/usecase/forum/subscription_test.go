@@ -0,0 +1,178 @@
+package forum
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"training-portal/internal/domain/forum"
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/user"
+)
+
+// mockSubscriptions keys subscriptions by "user/forum/post"
+type mockSubscriptions map[string]*forum.Subscription
+
+func (m mockSubscriptions) Subscribe(s *forum.Subscription) error {
+	m[s.UserID+"/"+s.ForumID+"/"+s.PostID] = s
+	return nil
+}
+
+func (m mockSubscriptions) Unsubscribe(userID, forumID, postID string) error {
+	delete(m, userID+"/"+forumID+"/"+postID)
+	return nil
+}
+
+func (m mockSubscriptions) ListSubscriptions(userID string) ([]*forum.Subscription, error) {
+	var out []*forum.Subscription
+	for _, s := range m {
+		if s.UserID == userID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (m mockSubscriptions) Subscribers(forumID, postID string) ([]*forum.Subscription, error) {
+	var thread, all []*forum.Subscription
+	for _, s := range m {
+		switch {
+		case postID != "" && s.PostID == postID:
+			thread = append(thread, s)
+		case s.ForumID == forumID:
+			all = append(all, s)
+		}
+	}
+	return append(thread, all...), nil
+}
+
+type mockUsers map[string]*user.User
+
+func (m mockUsers) FindByID(id string) (*user.User, error) { return m[id], nil }
+
+func (m mockUsers) FindByUsername(username string) (*user.User, error) {
+	for _, u := range m {
+		if strings.EqualFold(strings.Split(u.Email, "@")[0], username) {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+var testUsers = mockUsers{
+	"ada":  {ID: "ada", Name: "Ada", Email: "ada@example.com", Role: user.RoleEmployee},
+	"bob":  {ID: "bob", Name: "Bob", Email: "bob@example.com", Role: user.RoleEmployee},
+	"cy":   {ID: "cy", Name: "Cy", Email: "cy@example.com", Role: user.RoleEmployee},
+	"tina": {ID: "tina", Name: "Tina", Email: "tina@example.com", Role: user.RoleTrainer},
+}
+
+// mockNotifier records "user event digest" for every notification
+type mockNotifier struct {
+	sent []string
+}
+
+func (m *mockNotifier) NotifyDigest(userID string, event notification.EventType, vars map[string]string, digest notification.DigestMode) error {
+	m.sent = append(m.sent, fmt.Sprintf("%s %s %s", userID, event, digest))
+	return nil
+}
+
+// take returns the notifications sent since the last call, sorted.
+func (m *mockNotifier) take() []string {
+	sent := m.sent
+	m.sent = nil
+	sort.Strings(sent)
+	return sent
+}
+
+func TestForumService_SubscriptionsAndMentions(t *testing.T) {
+	service, _ := newTestService()
+	notifier := service.Notifier.(*mockNotifier)
+	f, _ := service.CourseForum("go", learner)
+
+	if _, err := service.SubscribeForum(f.ID, other, "hourly"); err == nil {
+		t.Error("SubscribeForum() with unknown digest expected error")
+	}
+	if _, err := service.SubscribeForum(f.ID, outsider, ""); !errors.Is(err, ErrEnrollmentRequired) {
+		t.Errorf("SubscribeForum() by unenrolled user error = %v, want ErrEnrollmentRequired", err)
+	}
+	if _, err := service.SubscribeForum(f.ID, other, notification.DigestDaily); err != nil {
+		t.Fatalf("SubscribeForum() error = %v", err)
+	}
+
+	p, err := service.CreatePost(f.ID, learner, forum.TypeDiscussion, "Loops", "Ping @Tina, @cy and @nobody")
+	if err != nil {
+		t.Fatalf("CreatePost() error = %v", err)
+	}
+	// cy is mentioned but cannot read the course forum.
+	want := []string{"bob forum_post daily", "tina forum_mention "}
+	if got := notifier.take(); !reflect.DeepEqual(got, want) {
+		t.Errorf("new thread notified %v, want %v", got, want)
+	}
+	if !strings.Contains(p.HTML, `<span class="mention" data-username="Tina">@Tina</span>`) {
+		t.Errorf("post HTML = %q, want the mention rendered", p.HTML)
+	}
+
+	// The author follows their thread; mentioned users are not told twice.
+	r, _ := service.Reply(p.ID, other, "Use a label, @ada")
+	if got, want := notifier.take(), []string{"ada forum_mention "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reply notified %v, want %v", got, want)
+	}
+
+	if _, err := service.SubscribeThread(p.ID, trainer, notification.DigestWeekly); err != nil {
+		t.Fatalf("SubscribeThread() error = %v", err)
+	}
+	service.Reply(p.ID, learner, "Thanks!")
+	want = []string{"bob forum_reply daily", "tina forum_reply weekly"}
+	if got := notifier.take(); !reflect.DeepEqual(got, want) {
+		t.Errorf("reply notified %v, want %v", got, want)
+	}
+
+	if err := service.UnsubscribeForum(f.ID, other); err != nil {
+		t.Fatalf("UnsubscribeForum() error = %v", err)
+	}
+	service.Reply(p.ID, learner, "One more thing")
+	if got, want := notifier.take(), []string{"tina forum_reply weekly"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after unsubscribing notified %v, want %v", got, want)
+	}
+
+	// Edits only notify users who are newly mentioned.
+	service.EditReply(r.ID, other, "Use a label, @ada. cc @tina")
+	if got, want := notifier.take(), []string{"tina forum_mention "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("edit notified %v, want %v", got, want)
+	}
+	service.EditReply(r.ID, other, "Use a labeled break, @ada. cc @tina")
+	if got := notifier.take(); len(got) != 0 {
+		t.Errorf("edit without new mentions notified %v", got)
+	}
+
+	subs, err := service.ListSubscriptions(learner)
+	if err != nil || len(subs) != 1 || subs[0].PostID != p.ID {
+		t.Errorf("ListSubscriptions() = %+v, %v, want the own thread", subs, err)
+	}
+}
+
+func TestForumService_HeldContentIsAnnouncedOnApproval(t *testing.T) {
+	service, _ := newTestService()
+	service.BlockedWords = []string{"spam"}
+	notifier := service.Notifier.(*mockNotifier)
+	f, _ := service.CourseForum("go", learner)
+	p, _ := service.CreatePost(f.ID, learner, forum.TypeDiscussion, "Loops", "Question")
+	notifier.take()
+
+	r, _ := service.Reply(p.ID, other, "Not spam, @ada")
+	if got := notifier.take(); len(got) != 0 {
+		t.Errorf("held reply notified %v", got)
+	}
+	if _, err := service.ModerateReply(r.ID, trainer, forum.ActionApprove, "fine"); err != nil {
+		t.Fatalf("ModerateReply(approve) error = %v", err)
+	}
+	if got, want := notifier.take(), []string{"ada forum_mention "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("approval notified %v, want %v", got, want)
+	}
+	if replies, _, _ := service.ListReplies(p.ID, learner, 1, 20); len(replies) != 1 || replies[0].HTML == "" {
+		t.Errorf("ListReplies() = %+v, want the approved reply with HTML", replies)
+	}
+}
@@ -0,0 +1,214 @@
+package message
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/message"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrInvalidLink        = errors.New("invalid download link")
+	ErrLinkExpired        = errors.New("download link has expired")
+)
+
+// FileStore stores attachment files.
+type FileStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+// Upload is a file sent with a message.
+type Upload struct {
+	FileName string
+	Data     []byte
+}
+
+// DefaultAttachmentTypes maps the file extensions accepted by default to the
+// content type attachments are served with.
+var DefaultAttachmentTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".txt":  "text/plain",
+	".csv":  "text/csv",
+	".md":   "text/markdown",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".zip":  "application/zip",
+}
+
+// AttachmentPolicy limits what can be attached to a message.
+type AttachmentPolicy struct {
+	MaxSize       int64             // bytes per file; 0 means 10 MB
+	MaxPerMessage int               // 0 means 5
+	Types         map[string]string // extension to content type; nil means DefaultAttachmentTypes
+}
+
+// check validates an upload and returns the content type it is served with.
+// The extension decides the type, and the file's content must agree with it
+// so that, say, an HTML page cannot be uploaded as a PNG.
+func (p AttachmentPolicy) check(u *Upload) (string, error) {
+	maxSize := p.MaxSize
+	if maxSize <= 0 {
+		maxSize = 10 << 20
+	}
+	types := p.Types
+	if types == nil {
+		types = DefaultAttachmentTypes
+	}
+	name := path.Base(strings.ReplaceAll(u.FileName, "\\", "/"))
+	if name == "" || name == "." || name == "/" {
+		return "", errors.New("attachment needs a file name")
+	}
+	if len(u.Data) == 0 {
+		return "", fmt.Errorf("attachment %s is empty", name)
+	}
+	if int64(len(u.Data)) > maxSize {
+		return "", fmt.Errorf("attachment %s is larger than %d bytes", name, maxSize)
+	}
+	contentType, ok := types[strings.ToLower(path.Ext(name))]
+	if !ok {
+		return "", fmt.Errorf("attachment %s has a file type that is not allowed", name)
+	}
+	if !contentMatches(contentType, http.DetectContentType(u.Data)) {
+		return "", fmt.Errorf("attachment %s does not look like %s", name, contentType)
+	}
+	u.FileName = name
+	return contentType, nil
+}
+
+// contentMatches reports whether a sniffed content type is plausible for the
+// declared one. Formats without a reliable signature only need to not look
+// like something else.
+func contentMatches(declared, sniffed string) bool {
+	sniffed = strings.TrimSpace(strings.Split(sniffed, ";")[0])
+	switch {
+	case strings.HasPrefix(declared, "image/"), declared == "application/pdf":
+		return sniffed == declared
+	case strings.HasPrefix(declared, "text/"):
+		return sniffed == "text/plain"
+	case declared == "application/zip", strings.HasPrefix(declared, "application/vnd.openxmlformats"):
+		return sniffed == "application/zip"
+	}
+	return sniffed == "application/octet-stream"
+}
+
+// storeAttachments validates the uploads and writes them to the file store.
+// On error the files already written are removed again.
+func (s *MessageService) storeAttachments(m *message.Message, uploads []*Upload) error {
+	max := s.Attachments.MaxPerMessage
+	if max <= 0 {
+		max = 5
+	}
+	if len(uploads) > max {
+		return fmt.Errorf("a message can have at most %d attachments", max)
+	}
+	if len(uploads) > 0 && s.Files == nil {
+		return errors.New("attachments are not enabled")
+	}
+	for _, u := range uploads {
+		contentType, err := s.Attachments.check(u)
+		if err != nil {
+			return err
+		}
+		a := &message.Attachment{
+			ID:             uuid.New().String(),
+			MessageID:      m.ID,
+			ConversationID: m.ConversationID,
+			FileName:       u.FileName,
+			ContentType:    contentType,
+			Size:           int64(len(u.Data)),
+		}
+		a.StorageKey = "attachments/" + m.ConversationID + "/" + a.ID
+		m.Attachments = append(m.Attachments, a)
+	}
+	for i, a := range m.Attachments {
+		if err := s.Files.Put(a.StorageKey, uploads[i].Data); err != nil {
+			s.removeFiles(m.Attachments[:i])
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MessageService) removeFiles(attachments []*message.Attachment) {
+	for _, a := range attachments {
+		s.Files.Delete(a.StorageKey)
+	}
+}
+
+// AttachmentLink returns a download signature for the user that expires
+// after LinkTTL. Only participants of the attachment's conversation get one.
+func (s *MessageService) AttachmentLink(attachmentID, userID string) (signature string, expiresAt int64, err error) {
+	a, err := s.attachment(attachmentID, userID)
+	if err != nil {
+		return "", 0, err
+	}
+	ttl := s.LinkTTL
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	expiresAt = time.Now().Add(ttl).Unix()
+	return s.linkSignature(a.ID, userID, expiresAt), expiresAt, nil
+}
+
+// OpenAttachment checks a download link and returns the attachment and its
+// file. The user must still be a participant when the link is used.
+func (s *MessageService) OpenAttachment(attachmentID, userID string, expiresAt int64, signature string) (*message.Attachment, []byte, error) {
+	want := s.linkSignature(attachmentID, userID, expiresAt)
+	if !hmac.Equal([]byte(signature), []byte(want)) {
+		return nil, nil, ErrInvalidLink
+	}
+	if time.Now().Unix() > expiresAt {
+		return nil, nil, ErrLinkExpired
+	}
+	a, err := s.attachment(attachmentID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := s.Files.Get(a.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, data, nil
+}
+
+func (s *MessageService) attachment(id, userID string) (*message.Attachment, error) {
+	a, err := s.Repo.FindAttachment(id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrAttachmentNotFound
+	}
+	if _, err := s.GetConversation(a.ConversationID, userID); err != nil {
+		if errors.Is(err, ErrConversationNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *MessageService) linkSignature(attachmentID, userID string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, s.LinkSecret)
+	mac.Write([]byte(attachmentID + "\n" + userID + "\n" + strconv.FormatInt(expiresAt, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,109 @@
+package message
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// MockFileStore is an in-memory FileStore
+type MockFileStore map[string][]byte
+
+func (m MockFileStore) Put(key string, data []byte) error {
+	m[key] = data
+	return nil
+}
+
+func (m MockFileStore) Get(key string) ([]byte, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+func (m MockFileStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestAttachmentPolicy_Check(t *testing.T) {
+	policy := AttachmentPolicy{MaxSize: 64}
+	tests := []struct {
+		name     string
+		upload   Upload
+		wantType string
+		wantErr  bool
+	}{
+		{"png", Upload{FileName: "diagram.PNG", Data: pngHeader}, "image/png", false},
+		{"pdf", Upload{FileName: "notes.pdf", Data: []byte("%PDF-1.7\n")}, "application/pdf", false},
+		{"text", Upload{FileName: "readme.txt", Data: []byte("hello")}, "text/plain", false},
+		{"path stripped", Upload{FileName: `C:\docs\plan.csv`, Data: []byte("a,b\n1,2\n")}, "text/csv", false},
+		{"html disguised as png", Upload{FileName: "cat.png", Data: []byte("<html><script>alert(1)</script>")}, "", true},
+		{"unknown extension", Upload{FileName: "setup.exe", Data: []byte("MZ")}, "", true},
+		{"too large", Upload{FileName: "big.txt", Data: []byte(strings.Repeat("a", 65))}, "", true},
+		{"empty", Upload{FileName: "empty.txt"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.upload
+			got, err := policy.check(&u)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.wantType {
+				t.Errorf("check() = %q, want %q", got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestMessageService_Attachments(t *testing.T) {
+	service, _ := newTestService()
+	files := MockFileStore{}
+	service.Files = files
+	service.LinkSecret = []byte("secret")
+	c, _ := service.StartDirect("ada", "bob")
+
+	m, err := service.Send(c.ID, "ada", "", &Upload{FileName: "diagram.png", Data: pngHeader})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(m.Attachments) != 1 || len(files) != 1 {
+		t.Fatalf("Send() stored %d attachments and %d files, want 1", len(m.Attachments), len(files))
+	}
+	a := m.Attachments[0]
+	if a.ContentType != "image/png" || a.StorageKey != "attachments/"+c.ID+"/"+a.ID {
+		t.Errorf("attachment = %+v", a)
+	}
+	if _, err := service.Send(c.ID, "ada", "bad", &Upload{FileName: "ok.png", Data: pngHeader}, &Upload{FileName: "x.exe", Data: []byte("MZ")}); err == nil {
+		t.Error("Send() with a disallowed file expected error")
+	}
+	if len(files) != 1 {
+		t.Errorf("files after rejected send = %d, want 1", len(files))
+	}
+
+	sig, expires, err := service.AttachmentLink(a.ID, "bob")
+	if err != nil {
+		t.Fatalf("AttachmentLink() error = %v", err)
+	}
+	if _, _, err := service.AttachmentLink(a.ID, "cy"); !errors.Is(err, ErrAttachmentNotFound) {
+		t.Errorf("AttachmentLink() for non-participant error = %v, want ErrAttachmentNotFound", err)
+	}
+	got, data, err := service.OpenAttachment(a.ID, "bob", expires, sig)
+	if err != nil || got.ID != a.ID || string(data) != string(pngHeader) {
+		t.Fatalf("OpenAttachment() = %v, %d bytes, %v", got, len(data), err)
+	}
+	if _, _, err := service.OpenAttachment(a.ID, "cy", expires, sig); !errors.Is(err, ErrInvalidLink) {
+		t.Errorf("OpenAttachment() with another user's link error = %v, want ErrInvalidLink", err)
+	}
+	if _, _, err := service.OpenAttachment(a.ID, "bob", expires+60, sig); !errors.Is(err, ErrInvalidLink) {
+		t.Errorf("OpenAttachment() with extended expiry error = %v, want ErrInvalidLink", err)
+	}
+	old := expires - 3600
+	if _, _, err := service.OpenAttachment(a.ID, "bob", old, service.linkSignature(a.ID, "bob", old)); !errors.Is(err, ErrLinkExpired) {
+		t.Errorf("OpenAttachment() with expired link error = %v, want ErrLinkExpired", err)
+	}
+}
@@ -0,0 +1,319 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/message"
+	"training-portal/internal/domain/realtime"
+	"training-portal/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrConversationNotFound is also returned to users who are not participants,
+	// so they cannot tell whether a conversation exists.
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrUserNotFound         = errors.New("user not found")
+)
+
+// MessageRepository is the persistence contract for conversations and messages.
+type MessageRepository interface {
+	// CreateConversation inserts the conversation and its participants.
+	CreateConversation(c *message.Conversation, directKey string) error
+	// FindConversation returns the conversation with its participants, or nil.
+	FindConversation(id string) (*message.Conversation, error)
+	// FindDirect returns the one-to-one conversation with the key, or nil.
+	FindDirect(directKey string) (*message.Conversation, error)
+	// ListConversations returns the user's conversations, most recently active
+	// first, with the last message and the user's unread count.
+	ListConversations(userID string) ([]*message.Conversation, error)
+	// CreateMessage inserts the message with its attachments, sets its Seq
+	// and bumps the conversation.
+	CreateMessage(m *message.Message) error
+	// ListMessages returns up to limit messages with a Seq below before
+	// (all when before is 0), newest first, with their attachments.
+	ListMessages(conversationID string, before int64, limit int) ([]*message.Message, error)
+	FindAttachment(id string) (*message.Attachment, error)
+	// UpdateReadCursor moves the participant's read cursor forward to seq; it
+	// never moves backwards.
+	UpdateReadCursor(conversationID, userID string, seq, readAt int64) error
+	RemoveParticipant(conversationID, userID string) error
+}
+
+// UserFinder looks up users by ID.
+type UserFinder interface {
+	FindByID(id string) (*user.User, error)
+}
+
+// Publisher pushes an event to a user's realtime stream.
+type Publisher interface {
+	Publish(userID string, t realtime.EventType, data interface{}) error
+}
+
+// MessageService provides direct messaging between users in one-to-one and
+// small group conversations. Only participants can read or post to a
+// conversation; each participant has a read cursor other participants see as
+// read receipts.
+type MessageService struct {
+	Repo   MessageRepository
+	Users  UserFinder
+	Events Publisher // optional; pushes new messages and read receipts to participants
+	// MaxGroupSize limits the participants of a group, including its creator; 0 means 20.
+	MaxGroupSize int
+	// MaxLength limits the characters in a message; 0 means 4000.
+	MaxLength int
+
+	// Files stores attachments; without it messages cannot have attachments.
+	Files       FileStore
+	Attachments AttachmentPolicy
+	// LinkSecret signs attachment download links, which are valid for LinkTTL
+	// (0 means five minutes).
+	LinkSecret []byte
+	LinkTTL    time.Duration
+}
+
+// StartDirect returns the one-to-one conversation between two users,
+// creating it on first use.
+func (s *MessageService) StartDirect(userID, otherID string) (*message.Conversation, error) {
+	if userID == "" || otherID == "" {
+		return nil, errors.New("user_id and participant are required")
+	}
+	if userID == otherID {
+		return nil, errors.New("cannot start a conversation with yourself")
+	}
+	key := message.DirectKey(userID, otherID)
+	if c, err := s.Repo.FindDirect(key); err != nil || c != nil {
+		return c, err
+	}
+	if err := s.checkUsers(otherID); err != nil {
+		return nil, err
+	}
+	c := s.newConversation(userID, "", false, []string{userID, otherID})
+	if err := s.Repo.CreateConversation(c, key); err != nil {
+		// Lost a race with the other user starting the same conversation.
+		if existing, findErr := s.Repo.FindDirect(key); findErr == nil && existing != nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// CreateGroup starts a group conversation between the creator and others.
+func (s *MessageService) CreateGroup(creatorID, title string, participantIDs []string) (*message.Conversation, error) {
+	title = strings.TrimSpace(title)
+	if creatorID == "" {
+		return nil, errors.New("user_id is required")
+	}
+	if title == "" {
+		return nil, errors.New("group title is required")
+	}
+	members := []string{creatorID}
+	seen := map[string]bool{creatorID: true}
+	for _, id := range participantIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			members = append(members, id)
+		}
+	}
+	if len(members) < 2 {
+		return nil, errors.New("a group needs at least one other participant")
+	}
+	if max := s.maxGroupSize(); len(members) > max {
+		return nil, fmt.Errorf("a group can have at most %d participants", max)
+	}
+	if err := s.checkUsers(members[1:]...); err != nil {
+		return nil, err
+	}
+	c := s.newConversation(creatorID, title, true, members)
+	if err := s.Repo.CreateConversation(c, ""); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ListConversations returns the user's conversations, most recent first.
+func (s *MessageService) ListConversations(userID string) ([]*message.Conversation, error) {
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+	return s.Repo.ListConversations(userID)
+}
+
+// GetConversation returns a conversation the user participates in.
+func (s *MessageService) GetConversation(id, userID string) (*message.Conversation, error) {
+	c, err := s.Repo.FindConversation(id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.Participant(userID) == nil {
+		return nil, ErrConversationNotFound
+	}
+	return c, nil
+}
+
+// Send posts a message with optional attachments from the sender to a
+// conversation they participate in. The sender's read cursor moves past
+// their own message.
+func (s *MessageService) Send(conversationID, senderID, content string, uploads ...*Upload) (*message.Message, error) {
+	content = strings.TrimSpace(content)
+	if content == "" && len(uploads) == 0 {
+		return nil, errors.New("message content is required")
+	}
+	if max := s.maxLength(); len([]rune(content)) > max {
+		return nil, fmt.Errorf("message is longer than %d characters", max)
+	}
+	c, err := s.GetConversation(conversationID, senderID)
+	if err != nil {
+		return nil, err
+	}
+	m := &message.Message{
+		ID:             uuid.New().String(),
+		ConversationID: c.ID,
+		SenderID:       senderID,
+		Content:        content,
+		SentAt:         time.Now().Unix(),
+	}
+	if err := s.storeAttachments(m, uploads); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateMessage(m); err != nil {
+		s.removeFiles(m.Attachments)
+		return nil, err
+	}
+	if err := s.Repo.UpdateReadCursor(c.ID, senderID, m.Seq, m.SentAt); err != nil {
+		return nil, err
+	}
+	s.publish(c, senderID, realtime.TypeMessage, m)
+	return m, nil
+}
+
+// History returns a page of a conversation's messages, newest first, and the
+// cursor for the next older page (0 when there are no more).
+func (s *MessageService) History(conversationID, userID string, before int64, limit int) ([]*message.Message, int64, error) {
+	if _, err := s.GetConversation(conversationID, userID); err != nil {
+		return nil, 0, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	// One extra message tells whether there is an older page.
+	messages, err := s.Repo.ListMessages(conversationID, before, limit+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	var next int64
+	if len(messages) > limit {
+		messages = messages[:limit]
+		next = messages[limit-1].Seq
+	}
+	return messages, next, nil
+}
+
+// MarkRead moves the user's read cursor to seq, or to the latest message when
+// seq is 0, and returns the updated participant.
+func (s *MessageService) MarkRead(conversationID, userID string, seq int64) (*message.Participant, error) {
+	c, err := s.GetConversation(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.Repo.ListMessages(c.ID, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(latest) == 0 {
+		return c.Participant(userID), nil
+	}
+	if seq <= 0 || seq > latest[0].Seq {
+		seq = latest[0].Seq
+	}
+	p := c.Participant(userID)
+	if seq <= p.LastReadSeq {
+		return p, nil
+	}
+	now := time.Now().Unix()
+	if err := s.Repo.UpdateReadCursor(c.ID, userID, seq, now); err != nil {
+		return nil, err
+	}
+	p.LastReadSeq, p.LastReadAt = seq, now
+	s.publish(c, userID, realtime.TypeMessageRead, p)
+	return p, nil
+}
+
+// Leave removes the user from a group conversation. One-to-one
+// conversations cannot be left.
+func (s *MessageService) Leave(conversationID, userID string) error {
+	c, err := s.GetConversation(conversationID, userID)
+	if err != nil {
+		return err
+	}
+	if !c.Group {
+		return errors.New("cannot leave a direct conversation")
+	}
+	return s.Repo.RemoveParticipant(c.ID, userID)
+}
+
+func (s *MessageService) newConversation(creatorID, title string, group bool, members []string) *message.Conversation {
+	now := time.Now().Unix()
+	c := &message.Conversation{
+		ID:        uuid.New().String(),
+		Title:     title,
+		Group:     group,
+		CreatedBy: creatorID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, id := range members {
+		c.Participants = append(c.Participants, &message.Participant{ConversationID: c.ID, UserID: id, JoinedAt: now})
+	}
+	return c
+}
+
+func (s *MessageService) checkUsers(ids ...string) error {
+	for _, id := range ids {
+		u, err := s.Users.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if u == nil {
+			return fmt.Errorf("%w: %s", ErrUserNotFound, id)
+		}
+	}
+	return nil
+}
+
+// publish pushes an event to every participant except the actor. A
+// participant who misses it still finds the message in the conversation's
+// history, so a failed push is logged and the others still get theirs.
+func (s *MessageService) publish(c *message.Conversation, actorID string, t realtime.EventType, data interface{}) {
+	if s.Events == nil {
+		return
+	}
+	for _, p := range c.Participants {
+		if p.UserID == actorID {
+			continue
+		}
+		if err := s.Events.Publish(p.UserID, t, data); err != nil {
+			log.Printf("messages: publishing to user %s failed: %v", p.UserID, err)
+		}
+	}
+}
+
+func (s *MessageService) maxGroupSize() int {
+	if s.MaxGroupSize > 0 {
+		return s.MaxGroupSize
+	}
+	return 20
+}
+
+func (s *MessageService) maxLength() int {
+	if s.MaxLength > 0 {
+		return s.MaxLength
+	}
+	return 4000
+}
@@ -0,0 +1,228 @@
+package message
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"training-portal/internal/domain/message"
+	"training-portal/internal/domain/realtime"
+	"training-portal/internal/domain/user"
+)
+
+// MockRepository is an in-memory implementation of MessageRepository
+type MockRepository struct {
+	conversations map[string]*message.Conversation
+	direct        map[string]string
+	messages      []*message.Message
+}
+
+func newMockRepository() *MockRepository {
+	return &MockRepository{conversations: map[string]*message.Conversation{}, direct: map[string]string{}}
+}
+
+func (m *MockRepository) CreateConversation(c *message.Conversation, directKey string) error {
+	if directKey != "" {
+		if _, ok := m.direct[directKey]; ok {
+			return errors.New("duplicate direct conversation")
+		}
+		m.direct[directKey] = c.ID
+	}
+	m.conversations[c.ID] = c
+	return nil
+}
+
+func (m *MockRepository) FindConversation(id string) (*message.Conversation, error) {
+	return m.conversations[id], nil
+}
+
+func (m *MockRepository) FindDirect(directKey string) (*message.Conversation, error) {
+	return m.conversations[m.direct[directKey]], nil
+}
+
+func (m *MockRepository) ListConversations(userID string) ([]*message.Conversation, error) {
+	var out []*message.Conversation
+	for _, c := range m.conversations {
+		if p := c.Participant(userID); p != nil {
+			c.Unread = 0
+			for _, msg := range m.messages {
+				if msg.ConversationID == c.ID && msg.Seq > p.LastReadSeq {
+					c.Unread++
+				}
+			}
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockRepository) CreateMessage(msg *message.Message) error {
+	msg.Seq = int64(len(m.messages) + 1)
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *MockRepository) ListMessages(conversationID string, before int64, limit int) ([]*message.Message, error) {
+	var out []*message.Message
+	for _, msg := range m.messages {
+		if msg.ConversationID == conversationID && (before == 0 || msg.Seq < before) {
+			out = append(out, msg)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Seq > out[j].Seq })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (m *MockRepository) UpdateReadCursor(conversationID, userID string, seq, readAt int64) error {
+	if p := m.conversations[conversationID].Participant(userID); p != nil && p.LastReadSeq < seq {
+		p.LastReadSeq, p.LastReadAt = seq, readAt
+	}
+	return nil
+}
+
+func (m *MockRepository) RemoveParticipant(conversationID, userID string) error {
+	c := m.conversations[conversationID]
+	for i, p := range c.Participants {
+		if p.UserID == userID {
+			c.Participants = append(c.Participants[:i], c.Participants[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (m *MockRepository) FindAttachment(id string) (*message.Attachment, error) {
+	for _, msg := range m.messages {
+		for _, a := range msg.Attachments {
+			if a.ID == id {
+				return a, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+type mockUsers map[string]*user.User
+
+func (m mockUsers) FindByID(id string) (*user.User, error) { return m[id], nil }
+
+// MockPublisher records the realtime events published per user
+type MockPublisher map[string][]realtime.EventType
+
+func (m MockPublisher) Publish(userID string, t realtime.EventType, data interface{}) error {
+	m[userID] = append(m[userID], t)
+	return nil
+}
+
+func newTestService() (*MessageService, MockPublisher) {
+	events := MockPublisher{}
+	return &MessageService{
+		Repo:   newMockRepository(),
+		Users:  mockUsers{"ada": {ID: "ada"}, "bob": {ID: "bob"}, "cy": {ID: "cy"}},
+		Events: events,
+	}, events
+}
+
+func TestMessageService_DirectConversation(t *testing.T) {
+	service, events := newTestService()
+	c, err := service.StartDirect("ada", "bob")
+	if err != nil {
+		t.Fatalf("StartDirect() error = %v", err)
+	}
+	if again, _ := service.StartDirect("bob", "ada"); again.ID != c.ID {
+		t.Error("StartDirect() created a second conversation for the same pair")
+	}
+	if _, err := service.StartDirect("ada", "nobody"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("StartDirect() with unknown user error = %v, want ErrUserNotFound", err)
+	}
+
+	m, err := service.Send(c.ID, "ada", "  Hi Bob  ")
+	if err != nil || m.Content != "Hi Bob" {
+		t.Fatalf("Send() = %+v, %v", m, err)
+	}
+	if _, err := service.Send(c.ID, "cy", "let me in"); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("Send() by non-participant error = %v, want ErrConversationNotFound", err)
+	}
+	if _, _, err := service.History(c.ID, "cy", 0, 10); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("History() by non-participant error = %v, want ErrConversationNotFound", err)
+	}
+	if len(events["bob"]) != 1 || events["bob"][0] != realtime.TypeMessage || len(events["ada"]) != 0 {
+		t.Errorf("published events = %v, want one message event for bob", events)
+	}
+	if err := service.Leave(c.ID, "ada"); err == nil {
+		t.Error("Leave() of a direct conversation expected error")
+	}
+}
+
+func TestMessageService_ReadCursors(t *testing.T) {
+	service, events := newTestService()
+	c, _ := service.StartDirect("ada", "bob")
+	first, _ := service.Send(c.ID, "ada", "one")
+	service.Send(c.ID, "ada", "two")
+
+	list, _ := service.ListConversations("bob")
+	if len(list) != 1 || list[0].Unread != 2 {
+		t.Fatalf("ListConversations() = %+v, want 2 unread", list)
+	}
+	if ada, _ := service.ListConversations("ada"); ada[0].Unread != 0 {
+		t.Errorf("sender unread = %d, want 0", ada[0].Unread)
+	}
+
+	p, err := service.MarkRead(c.ID, "bob", first.Seq)
+	if err != nil || p.LastReadSeq != first.Seq {
+		t.Fatalf("MarkRead() = %+v, %v", p, err)
+	}
+	if p, _ := service.MarkRead(c.ID, "bob", 0); p.LastReadSeq != 2 {
+		t.Errorf("MarkRead(0) cursor = %d, want latest", p.LastReadSeq)
+	}
+	if p, _ := service.MarkRead(c.ID, "bob", first.Seq); p.LastReadSeq != 2 {
+		t.Error("MarkRead() moved the cursor backwards")
+	}
+	if n := len(events["ada"]); n != 2 {
+		t.Errorf("read receipts published to ada = %d, want 2", n)
+	}
+}
+
+func TestMessageService_HistoryPages(t *testing.T) {
+	service, _ := newTestService()
+	c, _ := service.CreateGroup("ada", "Study group", []string{"bob", "cy", "bob"})
+	if len(c.Participants) != 3 {
+		t.Fatalf("CreateGroup() participants = %d, want 3", len(c.Participants))
+	}
+	for _, text := range []string{"1", "2", "3", "4", "5"} {
+		service.Send(c.ID, "bob", text)
+	}
+
+	var got []string
+	var before int64
+	for {
+		page, next, err := service.History(c.ID, "cy", before, 2)
+		if err != nil {
+			t.Fatalf("History() error = %v", err)
+		}
+		for _, m := range page {
+			got = append(got, m.Content)
+		}
+		if next == 0 {
+			break
+		}
+		before = next
+	}
+	if want := "54321"; len(got) != 5 || got[0]+got[1]+got[2]+got[3]+got[4] != want {
+		t.Errorf("History() pages = %v, want newest first %s", got, want)
+	}
+
+	if err := service.Leave(c.ID, "cy"); err != nil {
+		t.Fatalf("Leave() error = %v", err)
+	}
+	if _, err := service.GetConversation(c.ID, "cy"); !errors.Is(err, ErrConversationNotFound) {
+		t.Error("GetConversation() still allowed after leaving")
+	}
+	service.MaxGroupSize = 2
+	if _, err := service.CreateGroup("ada", "Too big", []string{"bob", "cy"}); err == nil {
+		t.Error("CreateGroup() over the size limit expected error")
+	}
+}
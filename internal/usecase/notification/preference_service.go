@@ -0,0 +1,99 @@
+// File: internal/usecase/notification/preference_service.go
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"training-portal/internal/domain/notification"
+)
+
+var ErrMandatoryEvent = errors.New("compliance notifications cannot be turned off or digested")
+
+// PreferenceRepository is the persistence contract for notification settings.
+type PreferenceRepository interface {
+	// FindSettings returns nil when the user has not saved any settings.
+	FindSettings(userID string) (*notification.Settings, error)
+	// SaveSettings replaces the user's settings and preferences.
+	SaveSettings(s *notification.Settings) error
+}
+
+// PreferenceService manages per-user notification settings.
+type PreferenceService struct {
+	Repo PreferenceRepository
+}
+
+// Settings returns the user's settings, or empty settings meaning every
+// event is delivered immediately on every channel.
+func (s *PreferenceService) Settings(userID string) (*notification.Settings, error) {
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+	settings, err := s.Repo.FindSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &notification.Settings{UserID: userID}
+	}
+	return settings, nil
+}
+
+// SaveSettings validates and stores a user's settings. Preferences for
+// mandatory events are accepted only if they keep in-app delivery immediate,
+// and are not stored since every channel is always used for them.
+func (s *PreferenceService) SaveSettings(settings *notification.Settings) error {
+	if settings == nil || settings.UserID == "" {
+		return errors.New("user_id is required")
+	}
+	if settings.Locale != "" && !localePattern.MatchString(settings.Locale) {
+		return errors.New("invalid locale")
+	}
+	if settings.TimeZone != "" {
+		if _, err := time.LoadLocation(settings.TimeZone); err != nil {
+			return fmt.Errorf("unknown time zone %q", settings.TimeZone)
+		}
+	}
+	for _, m := range []int{settings.QuietStart, settings.QuietEnd} {
+		if m < 0 || m >= 24*60 {
+			return errors.New("quiet hours must be times of day")
+		}
+	}
+
+	seen := map[notification.EventType]bool{}
+	var prefs []notification.Preference
+	for _, p := range settings.Preferences {
+		if !p.Event.Valid() || p.Event == notification.EventDigest {
+			return fmt.Errorf("unknown event type %q", p.Event)
+		}
+		if seen[p.Event] {
+			return fmt.Errorf("duplicate preference for %q", p.Event)
+		}
+		seen[p.Event] = true
+		if p.Digest == "" {
+			p.Digest = notification.DigestImmediate
+		}
+		switch p.Digest {
+		case notification.DigestImmediate, notification.DigestDaily, notification.DigestWeekly:
+		default:
+			return fmt.Errorf("invalid digest mode %q", p.Digest)
+		}
+		for _, c := range p.Channels {
+			switch c {
+			case notification.TypeInApp, notification.TypeEmail, notification.TypeSMS:
+			default:
+				return fmt.Errorf("invalid channel %q", c)
+			}
+		}
+		if p.Event.Mandatory() {
+			if p.Digest != notification.DigestImmediate || !p.Has(notification.TypeInApp) {
+				return fmt.Errorf("%w: %s", ErrMandatoryEvent, p.Event)
+			}
+			continue
+		}
+		prefs = append(prefs, p)
+	}
+	settings.Preferences = prefs
+	return s.Repo.SaveSettings(settings)
+}
@@ -0,0 +1,374 @@
+// File: internal/usecase/notification/service.go
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/realtime"
+	"training-portal/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationRepository is the persistence contract used by NotificationService.
+type NotificationRepository interface {
+	// Create stores a notification together with its outbox deliveries.
+	Create(n *notification.Notification, deliveries []*notification.Delivery) error
+	FindByID(id string) (*notification.Notification, error)
+	// ListByUser returns the user's in-app notifications, newest first.
+	ListByUser(userID string, unreadOnly bool) ([]*notification.Notification, error)
+	MarkRead(id string, readAt int64) error
+	ListDeliveries(notificationID string) ([]*notification.Delivery, error)
+	// ClaimDue returns up to limit pending deliveries due at now and pushes
+	// their next attempt to leaseUntil, so a crashed dispatcher's claims are
+	// picked up again once the lease runs out.
+	ClaimDue(now, leaseUntil int64, limit int) ([]*notification.Delivery, error)
+	UpdateDelivery(d *notification.Delivery) error
+}
+
+// Channel delivers notifications outside the portal, e.g. by email.
+type Channel interface {
+	Type() notification.NotificationType
+	Send(to *user.User, n *notification.Notification) error
+}
+
+// UserFinder looks up notification recipients.
+type UserFinder interface {
+	FindByID(id string) (*user.User, error)
+}
+
+// Publisher pushes an event to a user's realtime stream.
+type Publisher interface {
+	Publish(userID string, t realtime.EventType, data interface{}) error
+}
+
+// RetryPolicy controls how failed deliveries are retried. The delay doubles
+// after every failed attempt, starting at BaseDelay and capped at MaxDelay.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// delay returns how long to wait after the given number of failed attempts.
+func (p RetryPolicy) delay(attempts int) time.Duration {
+	d := p.BaseDelay
+	if d <= 0 {
+		d = time.Minute
+	}
+	for i := 1; i < attempts && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// NotificationService stores in-app notifications and queues their delivery
+// over the configured channels in an outbox, which DispatchDue works off.
+// Wording comes from Templates in the recipient's locale, and delivery
+// follows the recipient's Preferences: chosen channels, quiet hours and digests.
+type NotificationService struct {
+	Repo        NotificationRepository
+	Users       UserFinder
+	Channels    []Channel
+	Templates   *TemplateService
+	Preferences *PreferenceService
+	Retry       RetryPolicy
+	// DefaultLocale is used for users without a locale; empty means English.
+	DefaultLocale string
+	// BatchSize limits the deliveries claimed per dispatch; 0 means 100.
+	BatchSize int
+	// Events is optional; it pushes new in-app notifications to connected clients.
+	Events Publisher
+}
+
+// AvailableChannels returns the in-app channel followed by every configured delivery channel.
+func (s *NotificationService) AvailableChannels() []notification.NotificationType {
+	available := []notification.NotificationType{notification.TypeInApp}
+	for _, ch := range s.Channels {
+		available = append(available, ch.Type())
+	}
+	return available
+}
+
+// Notify sends a user the notification for an event, rendered from the
+// event's template with vars.
+func (s *NotificationService) Notify(userID string, event notification.EventType, vars map[string]string) error {
+	_, err := s.notify(userID, event, vars, "")
+	return err
+}
+
+// NotifyDigest is Notify with a digest mode that overrides the user's
+// preference for the event, e.g. one chosen for a forum subscription. An
+// empty mode keeps the preference; mandatory events are never digested.
+func (s *NotificationService) NotifyDigest(userID string, event notification.EventType, vars map[string]string, digest notification.DigestMode) error {
+	_, err := s.notify(userID, event, vars, digest)
+	return err
+}
+
+// Send sends an ad-hoc message. It returns nil if the user has turned off
+// general notifications.
+func (s *NotificationService) Send(userID, title, message string) (*notification.Notification, error) {
+	if message == "" {
+		return nil, errors.New("message is required")
+	}
+	return s.notify(userID, notification.EventGeneral, map[string]string{"title": title, "message": message}, "")
+}
+
+func (s *NotificationService) notify(userID string, event notification.EventType, vars map[string]string, digest notification.DigestMode) (*notification.Notification, error) {
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+	settings, err := s.Preferences.Settings(userID)
+	if err != nil {
+		return nil, err
+	}
+	pref := settings.Preference(event, s.AvailableChannels())
+	if len(pref.Channels) == 0 {
+		return nil, nil
+	}
+	if digest != "" && !event.Mandatory() {
+		pref.Digest = digest
+	}
+	t, err := s.template(event, settings)
+	if err != nil {
+		return nil, err
+	}
+	title, message := t.Render(vars)
+
+	now := time.Now()
+	n := &notification.Notification{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Type:      notification.TypeInApp,
+		Event:     event,
+		Title:     title,
+		Message:   message,
+		Status:    notification.StatusUnread,
+		CreatedAt: now.Unix(),
+	}
+	if !pref.Has(notification.TypeInApp) {
+		// Kept for the delivery record but not shown in the inbox.
+		n.Type, n.Status = pref.Channels[0], notification.StatusRead
+	}
+	due := settings.QuietUntil(settings.NextDigest(now, pref.Digest))
+	for _, ch := range s.Channels {
+		if !pref.Has(ch.Type()) {
+			continue
+		}
+		n.Deliveries = append(n.Deliveries, &notification.Delivery{
+			ID:             uuid.New().String(),
+			NotificationID: n.ID,
+			UserID:         userID,
+			Channel:        ch.Type(),
+			Digest:         pref.Digest,
+			Status:         notification.StatusPending,
+			NextAttemptAt:  due.Unix(),
+			CreatedAt:      now.Unix(),
+		})
+	}
+	if err := s.Repo.Create(n, n.Deliveries); err != nil {
+		return nil, err
+	}
+	if s.Events != nil && n.Type == notification.TypeInApp {
+		if err := s.Events.Publish(userID, realtime.TypeNotification, n); err != nil {
+			log.Printf("notifications: publishing %s to user %s failed: %v", n.ID, userID, err)
+		}
+	}
+	return n, nil
+}
+
+// GetNotification returns a user's notification with its delivery status.
+func (s *NotificationService) GetNotification(id, userID string) (*notification.Notification, error) {
+	n, err := s.Repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil || (userID != "" && n.UserID != userID) {
+		return nil, ErrNotificationNotFound
+	}
+	if n.Deliveries, err = s.Repo.ListDeliveries(id); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// ListNotifications returns a user's notifications, newest first.
+func (s *NotificationService) ListNotifications(userID string, unreadOnly bool) ([]*notification.Notification, error) {
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+	return s.Repo.ListByUser(userID, unreadOnly)
+}
+
+// MarkRead marks one of the user's notifications as read.
+func (s *NotificationService) MarkRead(id, userID string) (*notification.Notification, error) {
+	n, err := s.GetNotification(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n.Status == notification.StatusRead {
+		return n, nil
+	}
+	now := time.Now().Unix()
+	if err := s.Repo.MarkRead(id, now); err != nil {
+		return nil, err
+	}
+	n.Status, n.ReadAt = notification.StatusRead, &now
+	return n, nil
+}
+
+// DispatchDue sends the deliveries due at now and returns how many were sent.
+// Digest deliveries are combined into one message per user and channel.
+// Failures are rescheduled with backoff until the retry policy gives up.
+func (s *NotificationService) DispatchDue(now time.Time) (int, error) {
+	limit := s.BatchSize
+	if limit <= 0 {
+		limit = 100
+	}
+	// Claims are leased for the longest retry delay so they are not picked
+	// up twice while this batch is being sent.
+	lease := now.Add(s.Retry.delay(s.Retry.MaxAttempts)).Unix()
+	deliveries, err := s.Repo.ClaimDue(now.Unix(), lease, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var batches [][]*notification.Delivery
+	digests := map[string]int{}
+	for _, d := range deliveries {
+		if d.Digest == "" || d.Digest == notification.DigestImmediate {
+			batches = append(batches, []*notification.Delivery{d})
+			continue
+		}
+		key := d.UserID + "|" + string(d.Channel)
+		if i, ok := digests[key]; ok {
+			batches[i] = append(batches[i], d)
+			continue
+		}
+		digests[key] = len(batches)
+		batches = append(batches, []*notification.Delivery{d})
+	}
+
+	sent := 0
+	for _, batch := range batches {
+		err := s.deliver(batch)
+		for _, d := range batch {
+			d.Attempts++
+			if err != nil {
+				d.LastError = err.Error()
+				if s.Retry.MaxAttempts > 0 && d.Attempts >= s.Retry.MaxAttempts {
+					d.Status = notification.StatusFailed
+				} else {
+					d.NextAttemptAt = now.Add(s.Retry.delay(d.Attempts)).Unix()
+				}
+			} else {
+				d.Status, d.SentAt, d.LastError = notification.StatusSent, now.Unix(), ""
+				sent++
+			}
+			if err := s.Repo.UpdateDelivery(d); err != nil {
+				return sent, err
+			}
+		}
+	}
+	return sent, nil
+}
+
+// RunJob dispatches due deliveries; it is run by the scheduler.
+func (s *NotificationService) RunJob(now time.Time) error {
+	_, err := s.DispatchDue(now)
+	return err
+}
+
+// deliver sends deliveries for one user over one channel, combining several
+// into a digest.
+func (s *NotificationService) deliver(batch []*notification.Delivery) error {
+	first := batch[0]
+	ch := s.channel(first.Channel)
+	if ch == nil {
+		return fmt.Errorf("no %s channel configured", first.Channel)
+	}
+	u, err := s.Users.FindByID(first.UserID)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return errors.New("recipient not found")
+	}
+
+	var items []*notification.Notification
+	for _, d := range batch {
+		n, err := s.Repo.FindByID(d.NotificationID)
+		if err != nil {
+			return err
+		}
+		if n == nil {
+			return ErrNotificationNotFound
+		}
+		items = append(items, n)
+	}
+	if first.Digest == "" || first.Digest == notification.DigestImmediate {
+		return ch.Send(u, items[0])
+	}
+	digest, err := s.digest(u.ID, items)
+	if err != nil {
+		return err
+	}
+	return ch.Send(u, digest)
+}
+
+// digest combines notifications into one, worded in the user's locale.
+func (s *NotificationService) digest(userID string, items []*notification.Notification) (*notification.Notification, error) {
+	settings, err := s.Preferences.Settings(userID)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.template(notification.EventDigest, settings)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	for i, n := range items {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(n.Title + "\n" + n.Message)
+	}
+	title, message := t.Render(map[string]string{"count": strconv.Itoa(len(items)), "items": b.String()})
+	return &notification.Notification{
+		UserID:    userID,
+		Type:      items[0].Type,
+		Event:     notification.EventDigest,
+		Title:     title,
+		Message:   message,
+		CreatedAt: time.Now().Unix(),
+	}, nil
+}
+
+// template resolves the event's template in the user's or the default locale.
+func (s *NotificationService) template(event notification.EventType, settings *notification.Settings) (*notification.Template, error) {
+	locale := settings.Locale
+	if locale == "" {
+		locale = s.DefaultLocale
+	}
+	return s.Templates.Resolve(event, locale)
+}
+
+func (s *NotificationService) channel(t notification.NotificationType) Channel {
+	for _, ch := range s.Channels {
+		if ch.Type() == t {
+			return ch
+		}
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+package notification
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/notification"
+	"training-portal/internal/domain/user"
+)
+
+// MockRepository is an in-memory implementation of NotificationRepository
+type MockRepository struct {
+	notifications map[string]*notification.Notification
+	deliveries    []*notification.Delivery
+}
+
+func newMockRepository() *MockRepository {
+	return &MockRepository{notifications: map[string]*notification.Notification{}}
+}
+
+func (m *MockRepository) Create(n *notification.Notification, deliveries []*notification.Delivery) error {
+	m.notifications[n.ID] = n
+	m.deliveries = append(m.deliveries, deliveries...)
+	return nil
+}
+
+func (m *MockRepository) FindByID(id string) (*notification.Notification, error) {
+	return m.notifications[id], nil
+}
+
+func (m *MockRepository) ListByUser(userID string, unreadOnly bool) ([]*notification.Notification, error) {
+	var out []*notification.Notification
+	for _, n := range m.notifications {
+		if n.UserID == userID && (!unreadOnly || n.Status == notification.StatusUnread) {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockRepository) MarkRead(id string, readAt int64) error { return nil }
+
+func (m *MockRepository) ListDeliveries(notificationID string) ([]*notification.Delivery, error) {
+	var out []*notification.Delivery
+	for _, d := range m.deliveries {
+		if d.NotificationID == notificationID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockRepository) ClaimDue(now, leaseUntil int64, limit int) ([]*notification.Delivery, error) {
+	var out []*notification.Delivery
+	for _, d := range m.deliveries {
+		if d.Status == notification.StatusPending && d.NextAttemptAt <= now && len(out) < limit {
+			d.NextAttemptAt = leaseUntil
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockRepository) UpdateDelivery(d *notification.Delivery) error { return nil }
+
+// MockChannel fails the first failures sends and records the rest
+type MockChannel struct {
+	failures int
+	sent     []string
+}
+
+func (m *MockChannel) Type() notification.NotificationType { return notification.TypeEmail }
+
+func (m *MockChannel) Send(to *user.User, n *notification.Notification) error {
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("relay unavailable")
+	}
+	m.sent = append(m.sent, to.Email)
+	return nil
+}
+
+type mockUsers map[string]*user.User
+
+func (m mockUsers) FindByID(id string) (*user.User, error) { return m[id], nil }
+
+// MockTemplateRepository stores templates by event and locale
+type MockTemplateRepository map[string]*notification.Template
+
+func (m MockTemplateRepository) Save(t *notification.Template) error {
+	m[string(t.Event)+"/"+t.Locale] = t
+	return nil
+}
+
+func (m MockTemplateRepository) Delete(event notification.EventType, locale string) error {
+	delete(m, string(event)+"/"+locale)
+	return nil
+}
+
+func (m MockTemplateRepository) Find(event notification.EventType, locale string) (*notification.Template, error) {
+	return m[string(event)+"/"+locale], nil
+}
+
+func (m MockTemplateRepository) List() ([]*notification.Template, error) {
+	var out []*notification.Template
+	for _, t := range m {
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// MockPreferenceRepository stores settings by user
+type MockPreferenceRepository map[string]*notification.Settings
+
+func (m MockPreferenceRepository) FindSettings(userID string) (*notification.Settings, error) {
+	return m[userID], nil
+}
+
+func (m MockPreferenceRepository) SaveSettings(s *notification.Settings) error {
+	m[s.UserID] = s
+	return nil
+}
+
+func newTestService(channel *MockChannel) (*NotificationService, *MockRepository) {
+	repo := newMockRepository()
+	return &NotificationService{
+		Repo:        repo,
+		Users:       mockUsers{"user-1": {ID: "user-1", Email: "ada@example.com"}},
+		Channels:    []Channel{channel},
+		Templates:   &TemplateService{Repo: MockTemplateRepository{}},
+		Preferences: &PreferenceService{Repo: MockPreferenceRepository{}},
+		Retry:       RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour},
+	}, repo
+}
+
+func TestNotificationService_DispatchRetries(t *testing.T) {
+	channel := &MockChannel{failures: 1}
+	service, _ := newTestService(channel)
+	n, err := service.Send("user-1", "Reminder", "Course due soon")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	d := n.Deliveries[0]
+	if d.Status != notification.StatusPending || d.Channel != notification.TypeEmail {
+		t.Fatalf("delivery = %+v, want pending email", d)
+	}
+
+	now := time.Unix(n.CreatedAt, 0)
+	if sent, _ := service.DispatchDue(now); sent != 0 {
+		t.Errorf("DispatchDue() sent %d, want failure", sent)
+	}
+	if d.Attempts != 1 || d.LastError == "" || d.NextAttemptAt != now.Add(time.Minute).Unix() {
+		t.Errorf("after failure delivery = %+v, want retry in a minute", d)
+	}
+	if sent, _ := service.DispatchDue(now.Add(30 * time.Second)); sent != 0 {
+		t.Error("DispatchDue() retried before the backoff elapsed")
+	}
+	if sent, _ := service.DispatchDue(now.Add(time.Minute)); sent != 1 {
+		t.Errorf("DispatchDue() sent %d, want 1", sent)
+	}
+	if d.Status != notification.StatusSent || d.Attempts != 2 || d.LastError != "" || len(channel.sent) != 1 {
+		t.Errorf("after retry delivery = %+v, want sent", d)
+	}
+}
+
+func TestNotificationService_DispatchGivesUp(t *testing.T) {
+	service, _ := newTestService(&MockChannel{failures: 10})
+	n, _ := service.Send("user-1", "Reminder", "Course due soon")
+	d := n.Deliveries[0]
+
+	now := time.Unix(n.CreatedAt, 0)
+	for i := 0; i < 5; i++ {
+		service.DispatchDue(now)
+		now = now.Add(time.Hour)
+	}
+	if d.Status != notification.StatusFailed || d.Attempts != 3 {
+		t.Errorf("delivery = %+v, want failed after 3 attempts", d)
+	}
+}
+
+func TestNotificationService_GetNotificationChecksOwner(t *testing.T) {
+	service, _ := newTestService(&MockChannel{})
+	n, _ := service.Send("user-1", "Reminder", "Course due soon")
+	if _, err := service.GetNotification(n.ID, "user-2"); !errors.Is(err, ErrNotificationNotFound) {
+		t.Errorf("GetNotification() by another user error = %v, want ErrNotificationNotFound", err)
+	}
+	got, err := service.MarkRead(n.ID, "user-1")
+	if err != nil || got.Status != notification.StatusRead || got.ReadAt == nil || len(got.Deliveries) != 1 {
+		t.Errorf("MarkRead() = %+v, %v", got, err)
+	}
+}
+
+func TestNotificationService_NotifyFollowsPreferences(t *testing.T) {
+	channel := &MockChannel{}
+	service, repo := newTestService(channel)
+	err := service.Preferences.SaveSettings(&notification.Settings{
+		UserID: "user-1",
+		Preferences: []notification.Preference{
+			{Event: notification.EventCourseAssigned},
+			{Event: notification.EventCertificateIssued, Channels: []notification.NotificationType{notification.TypeEmail}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveSettings() error = %v", err)
+	}
+	vars := map[string]string{"course_title": "Safety", "due_date": "2024-01-10"}
+
+	if n, err := service.notify("user-1", notification.EventCourseAssigned, vars, ""); err != nil || n != nil {
+		t.Errorf("notify() opted-out event = %+v, %v, want nothing sent", n, err)
+	}
+	n, err := service.notify("user-1", notification.EventCertificateIssued, vars, "")
+	if err != nil {
+		t.Fatalf("notify() error = %v", err)
+	}
+	if n.Type != notification.TypeEmail || len(n.Deliveries) != 1 {
+		t.Errorf("email-only notification = %+v, want one email delivery and no inbox entry", n)
+	}
+	if inbox, _ := repo.ListByUser("user-1", true); len(inbox) != 0 {
+		t.Errorf("unread notifications = %d, want 0", len(inbox))
+	}
+	// Compliance notifications ignore preferences.
+	n, err = service.notify("user-1", notification.EventOverdue, vars, "")
+	if err != nil || n.Type != notification.TypeInApp || len(n.Deliveries) != 1 || !strings.Contains(n.Message, "Safety") {
+		t.Errorf("notify() mandatory event = %+v, %v", n, err)
+	}
+}
+
+func TestNotificationService_QuietHoursAndDigest(t *testing.T) {
+	channel := &MockChannel{}
+	service, _ := newTestService(channel)
+	service.Preferences.SaveSettings(&notification.Settings{
+		UserID:     "user-1",
+		QuietStart: 0,
+		QuietEnd:   23*60 + 59,
+		Preferences: []notification.Preference{{
+			Event:    notification.EventCourseAssigned,
+			Channels: []notification.NotificationType{notification.TypeEmail},
+			Digest:   notification.DigestDaily,
+		}},
+	})
+	first, _ := service.notify("user-1", notification.EventCourseAssigned, map[string]string{"course_title": "Safety"}, "")
+	service.notify("user-1", notification.EventCourseAssigned, map[string]string{"course_title": "Privacy"}, "")
+	due := time.Unix(first.Deliveries[0].NextAttemptAt, 0)
+	if !due.After(time.Now()) {
+		t.Fatalf("digest delivery due %v, want later", due)
+	}
+
+	if sent, _ := service.DispatchDue(time.Now()); sent != 0 {
+		t.Errorf("DispatchDue() sent %d before the digest was due", sent)
+	}
+	if sent, err := service.DispatchDue(due.Add(24 * time.Hour)); sent != 2 || err != nil {
+		t.Errorf("DispatchDue() = %d, %v, want both deliveries sent", sent, err)
+	}
+	if len(channel.sent) != 1 {
+		t.Errorf("emails sent = %d, want one digest", len(channel.sent))
+	}
+}
+
+func TestNotificationService_NotifyDigestOverridesPreference(t *testing.T) {
+	channel := &MockChannel{}
+	service, repo := newTestService(channel)
+	vars := map[string]string{"author_name": "Bob", "post_title": "Loops", "forum_title": "Go", "excerpt": "Try this"}
+
+	if err := service.NotifyDigest("user-1", notification.EventForumReply, vars, notification.DigestWeekly); err != nil {
+		t.Fatalf("NotifyDigest() error = %v", err)
+	}
+	inbox, _ := repo.ListByUser("user-1", true)
+	if len(inbox) != 1 || inbox[0].Title != "New reply: Loops" {
+		t.Fatalf("inbox = %+v, want the reply notification right away", inbox)
+	}
+	n, _ := service.GetNotification(inbox[0].ID, "user-1")
+	if len(n.Deliveries) != 1 || n.Deliveries[0].Digest != notification.DigestWeekly {
+		t.Errorf("deliveries = %+v, want one weekly digest email", n.Deliveries)
+	}
+
+	service.NotifyDigest("user-1", notification.EventOverdue, map[string]string{"course_title": "Safety"}, notification.DigestWeekly)
+	inbox, _ = repo.ListByUser("user-1", true)
+	for _, item := range inbox {
+		if item.Event != notification.EventOverdue {
+			continue
+		}
+		if n, _ := service.GetNotification(item.ID, "user-1"); n.Deliveries[0].Digest != notification.DigestImmediate {
+			t.Errorf("mandatory event digest = %q, want immediate", n.Deliveries[0].Digest)
+		}
+	}
+}
+
+func TestPreferenceService_MandatoryEvents(t *testing.T) {
+	service := &PreferenceService{Repo: MockPreferenceRepository{}}
+	for _, p := range []notification.Preference{
+		{Event: notification.EventOverdue},
+		{Event: notification.EventOverdue, Channels: []notification.NotificationType{notification.TypeInApp}, Digest: notification.DigestWeekly},
+	} {
+		err := service.SaveSettings(&notification.Settings{UserID: "user-1", Preferences: []notification.Preference{p}})
+		if !errors.Is(err, ErrMandatoryEvent) {
+			t.Errorf("SaveSettings(%+v) error = %v, want ErrMandatoryEvent", p, err)
+		}
+	}
+	if err := service.SaveSettings(&notification.Settings{UserID: "user-1", TimeZone: "Mars/Olympus"}); err == nil {
+		t.Error("SaveSettings() with unknown time zone expected error")
+	}
+}
+
+func TestTemplateService_ResolveFallsBack(t *testing.T) {
+	service := &TemplateService{Repo: MockTemplateRepository{}}
+	de := &notification.Template{Event: notification.EventOverdue, Locale: "de", Subject: "Überfällig: {{course_title}}", Body: "Bitte abschließen."}
+	if err := service.SaveTemplate(de); err != nil {
+		t.Fatalf("SaveTemplate() error = %v", err)
+	}
+	if err := service.SaveTemplate(&notification.Template{Event: "unknown", Locale: "de", Subject: "x", Body: "x"}); err == nil {
+		t.Error("SaveTemplate() with unknown event expected error")
+	}
+
+	for locale, want := range map[string]string{"de-CH": "de", "de": "de", "fr": "en", "": "en"} {
+		got, err := service.Resolve(notification.EventOverdue, locale)
+		if err != nil || got.Locale != want {
+			t.Errorf("Resolve(%q) = %+v, %v, want locale %q", locale, got, err, want)
+		}
+	}
+	subject, _ := de.Render(map[string]string{"course_title": "Brandschutz"})
+	if subject != "Überfällig: Brandschutz" {
+		t.Errorf("Render() subject = %q", subject)
+	}
+}
+
+func TestSettings_QuietUntil(t *testing.T) {
+	s := &notification.Settings{TimeZone: "Europe/Berlin", QuietStart: 22 * 60, QuietEnd: 7 * 60}
+	berlin := s.Location()
+	at := time.Date(2024, 3, 4, 23, 30, 0, 0, berlin)
+	if got, want := s.QuietUntil(at), time.Date(2024, 3, 5, 7, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("QuietUntil(23:30) = %v, want %v", got, want)
+	}
+	if noon := time.Date(2024, 3, 4, 12, 0, 0, 0, berlin); !s.QuietUntil(noon).Equal(noon) {
+		t.Error("QuietUntil(12:00) moved a time outside quiet hours")
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Minute, MaxDelay: 10 * time.Minute}
+	for attempts, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 4: 8 * time.Minute, 5: 10 * time.Minute, 20: 10 * time.Minute} {
+		if got := p.delay(attempts); got != want {
+			t.Errorf("delay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
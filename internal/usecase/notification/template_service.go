@@ -0,0 +1,114 @@
+// File: internal/usecase/notification/template_service.go
+package notification
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/notification"
+
+	"github.com/google/uuid"
+)
+
+var ErrTemplateNotFound = errors.New("notification template not found")
+
+// TemplateRepository is the persistence contract for notification templates.
+// There is at most one template per event and locale.
+type TemplateRepository interface {
+	// Save inserts the template or replaces the one for the same event and locale.
+	Save(t *notification.Template) error
+	Delete(event notification.EventType, locale string) error
+	Find(event notification.EventType, locale string) (*notification.Template, error)
+	List() ([]*notification.Template, error)
+}
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// TemplateService manages the wording of notifications. Stored templates
+// override the built-in English defaults and add other locales.
+type TemplateService struct {
+	Repo TemplateRepository
+}
+
+// ValidateTemplate checks the event, locale and required text.
+func ValidateTemplate(t *notification.Template) error {
+	if t == nil {
+		return errors.New("template is required")
+	}
+	if !t.Event.Valid() {
+		return errors.New("unknown event type")
+	}
+	if !localePattern.MatchString(t.Locale) {
+		return errors.New("invalid locale")
+	}
+	if strings.TrimSpace(t.Subject) == "" || strings.TrimSpace(t.Body) == "" {
+		return errors.New("subject and body are required")
+	}
+	return nil
+}
+
+// SaveTemplate creates or replaces the template for its event and locale.
+func (s *TemplateService) SaveTemplate(t *notification.Template) error {
+	if err := ValidateTemplate(t); err != nil {
+		return err
+	}
+	existing, err := s.Repo.Find(t.Event, t.Locale)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		t.ID = existing.ID
+	} else {
+		t.ID = uuid.New().String()
+	}
+	t.UpdatedAt = time.Now().Unix()
+	return s.Repo.Save(t)
+}
+
+// DeleteTemplate removes a stored template; built-in defaults apply again.
+func (s *TemplateService) DeleteTemplate(event notification.EventType, locale string) error {
+	existing, err := s.Repo.Find(event, locale)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrTemplateNotFound
+	}
+	return s.Repo.Delete(event, locale)
+}
+
+// ListTemplates returns the stored templates.
+func (s *TemplateService) ListTemplates() ([]*notification.Template, error) {
+	return s.Repo.List()
+}
+
+// Resolve returns the template for an event in the closest available locale:
+// the exact locale, its language ("de" for "de-CH"), stored English, and
+// finally the built-in default.
+func (s *TemplateService) Resolve(event notification.EventType, locale string) (*notification.Template, error) {
+	candidates := []string{locale}
+	if i := strings.Index(locale, "-"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, notification.DefaultLocale)
+	for _, l := range candidates {
+		if l == "" {
+			continue
+		}
+		t, err := s.Repo.Find(event, l)
+		if err != nil {
+			return nil, err
+		}
+		if t != nil {
+			return t, nil
+		}
+	}
+	t, ok := notification.DefaultTemplates[event]
+	if !ok {
+		return nil, ErrTemplateNotFound
+	}
+	t.Event, t.Locale = event, notification.DefaultLocale
+	return &t, nil
+}
@@ -0,0 +1,162 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"training-portal/internal/domain/realtime"
+)
+
+// EventRepository is the persistence contract for realtime events.
+// Storing an event must also wake the subscribers of its user on every
+// server instance, e.g. through Postgres NOTIFY.
+type EventRepository interface {
+	// Append stores the event and sets its ID.
+	Append(e *realtime.Event) error
+	// ListAfter returns the user's events with an ID greater than after, oldest first.
+	ListAfter(userID string, after int64, limit int) ([]*realtime.Event, error)
+	// LastID returns the ID of the user's newest event, 0 if there are none.
+	LastID(userID string) (int64, error)
+	DeleteBefore(before int64) (int64, error)
+}
+
+// Subscription is a connected client of one user's stream. Wake receives a
+// value whenever new events may be available; the client then reads them
+// with Replay from its own cursor, so a slow client never loses events.
+type Subscription struct {
+	UserID string
+	Wake   <-chan struct{}
+	wake   chan struct{}
+}
+
+// Service stores per-user events and tells connected clients about them.
+type Service struct {
+	Repo      EventRepository
+	Retention time.Duration // how long events can be replayed; defaults to a week
+
+	mu          sync.Mutex
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+// Publish stores an event for a user. data is encoded as JSON.
+func (s *Service) Publish(userID string, t realtime.EventType, data interface{}) error {
+	if userID == "" {
+		return errors.New("user_id is required")
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.Repo.Append(&realtime.Event{
+		UserID:    userID,
+		Type:      t,
+		Data:      raw,
+		CreatedAt: time.Now().Unix(),
+	})
+}
+
+// Replay returns up to limit of the user's events after the cursor.
+func (s *Service) Replay(userID string, after int64, limit int) ([]*realtime.Event, error) {
+	if userID == "" {
+		return nil, errors.New("user_id is required")
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 500
+	}
+	return s.Repo.ListAfter(userID, after, limit)
+}
+
+// Cursor returns the cursor of the user's newest event, for clients that
+// connect without one and only want new events.
+func (s *Service) Cursor(userID string) (int64, error) {
+	return s.Repo.LastID(userID)
+}
+
+// Subscribe registers a client of the user's stream. Call Unsubscribe when
+// the client disconnects.
+func (s *Service) Subscribe(userID string) *Subscription {
+	wake := make(chan struct{}, 1)
+	sub := &Subscription{UserID: userID, Wake: wake, wake: wake}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers == nil {
+		s.subscribers = map[string]map[*Subscription]struct{}{}
+	}
+	if s.subscribers[userID] == nil {
+		s.subscribers[userID] = map[*Subscription]struct{}{}
+	}
+	s.subscribers[userID][sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes a client registered with Subscribe.
+func (s *Service) Unsubscribe(sub *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscribers[sub.UserID], sub)
+	if len(s.subscribers[sub.UserID]) == 0 {
+		delete(s.subscribers, sub.UserID)
+	}
+}
+
+// Wake tells the user's clients on this instance that new events were stored.
+func (s *Service) Wake(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers[userID] {
+		signal(sub)
+	}
+}
+
+// WakeAll wakes every client, e.g. after wake-ups may have been missed while
+// the connection to the database was down.
+func (s *Service) WakeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, subs := range s.subscribers {
+		for sub := range subs {
+			signal(sub)
+		}
+	}
+}
+
+// Subscribers returns the number of connected clients on this instance.
+func (s *Service) Subscribers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, subs := range s.subscribers {
+		n += len(subs)
+	}
+	return n
+}
+
+// Prune deletes events older than the retention period.
+func (s *Service) Prune(now time.Time) (int64, error) {
+	retention := s.Retention
+	if retention <= 0 {
+		retention = 7 * 24 * time.Hour
+	}
+	return s.Repo.DeleteBefore(now.Add(-retention).Unix())
+}
+
+// RunJob prunes expired events; it is run by the scheduler.
+func (s *Service) RunJob(now time.Time) error {
+	n, err := s.Prune(now)
+	if n > 0 {
+		log.Printf("realtime: pruned %d events", n)
+	}
+	return err
+}
+
+// signal wakes a subscription without blocking; a pending wake-up already
+// covers the new events.
+func signal(sub *Subscription) {
+	select {
+	case sub.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,115 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/realtime"
+)
+
+// MockEventRepository is an in-memory implementation of EventRepository
+type MockEventRepository struct {
+	events []*realtime.Event
+}
+
+func (m *MockEventRepository) Append(e *realtime.Event) error {
+	e.ID = int64(len(m.events) + 1)
+	m.events = append(m.events, e)
+	return nil
+}
+
+func (m *MockEventRepository) ListAfter(userID string, after int64, limit int) ([]*realtime.Event, error) {
+	var out []*realtime.Event
+	for _, e := range m.events {
+		if e.UserID == userID && e.ID > after && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (m *MockEventRepository) LastID(userID string) (int64, error) {
+	var id int64
+	for _, e := range m.events {
+		if e.UserID == userID {
+			id = e.ID
+		}
+	}
+	return id, nil
+}
+
+func (m *MockEventRepository) DeleteBefore(before int64) (int64, error) {
+	var kept []*realtime.Event
+	for _, e := range m.events {
+		if e.CreatedAt >= before {
+			kept = append(kept, e)
+		}
+	}
+	n := int64(len(m.events) - len(kept))
+	m.events = kept
+	return n, nil
+}
+
+func TestService_PublishAndReplay(t *testing.T) {
+	service := &Service{Repo: &MockEventRepository{}}
+	service.Publish("user-1", realtime.TypeNotification, map[string]string{"title": "Welcome"})
+	service.Publish("user-2", realtime.TypeMessage, map[string]string{"content": "hi"})
+	service.Publish("user-1", realtime.TypeEnrollment, map[string]string{"status": "active"})
+
+	events, err := service.Replay("user-1", 0, 10)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("Replay() = %v, %v, want 2 events", events, err)
+	}
+	if string(events[0].Data) != `{"title":"Welcome"}` {
+		t.Errorf("Replay()[0].Data = %s", events[0].Data)
+	}
+	if missed, _ := service.Replay("user-1", events[0].ID, 10); len(missed) != 1 || missed[0].Type != realtime.TypeEnrollment {
+		t.Errorf("Replay() after cursor = %v, want the enrollment event", missed)
+	}
+	if cursor, _ := service.Cursor("user-1"); cursor != events[1].ID {
+		t.Errorf("Cursor() = %d, want %d", cursor, events[1].ID)
+	}
+}
+
+func TestService_WakeSubscribers(t *testing.T) {
+	service := &Service{Repo: &MockEventRepository{}}
+	a := service.Subscribe("user-1")
+	b := service.Subscribe("user-2")
+
+	service.Wake("user-1")
+	service.Wake("user-1") // coalesced with the pending wake-up
+	select {
+	case <-a.Wake:
+	default:
+		t.Error("subscriber of user-1 was not woken")
+	}
+	select {
+	case <-a.Wake:
+		t.Error("wake-ups were not coalesced")
+	case <-b.Wake:
+		t.Error("subscriber of user-2 was woken")
+	default:
+	}
+
+	service.WakeAll()
+	if len(b.Wake) != 1 {
+		t.Error("WakeAll() did not wake user-2")
+	}
+	service.Unsubscribe(a)
+	service.Unsubscribe(b)
+	if n := service.Subscribers(); n != 0 {
+		t.Errorf("Subscribers() = %d after unsubscribing, want 0", n)
+	}
+}
+
+func TestService_Prune(t *testing.T) {
+	repo := &MockEventRepository{}
+	service := &Service{Repo: repo, Retention: time.Hour}
+	now := time.Now()
+	repo.Append(&realtime.Event{UserID: "user-1", CreatedAt: now.Add(-2 * time.Hour).Unix()})
+	repo.Append(&realtime.Event{UserID: "user-1", CreatedAt: now.Unix()})
+
+	if n, err := service.Prune(now); err != nil || n != 1 {
+		t.Errorf("Prune() = %d, %v, want 1", n, err)
+	}
+}
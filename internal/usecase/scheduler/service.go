@@ -0,0 +1,181 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"training-portal/internal/domain/job"
+
+	"github.com/google/uuid"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+// JobRepository is the persistence contract for scheduled jobs.
+type JobRepository interface {
+	// Ensure creates the job if it does not exist and updates its interval.
+	Ensure(name string, interval time.Duration, now int64) error
+	// Claim locks the job for owner until leaseUntil if it is due, not paused
+	// and not claimed by anyone else, and reports whether it did.
+	Claim(name, owner string, now, leaseUntil int64) (bool, error)
+	// Finish records a run and releases owner's claim.
+	Finish(name, owner string, ranAt, durationMillis int64, lastError string, nextRunAt int64) error
+	FindByName(name string) (*job.Job, error)
+	List() ([]*job.Job, error)
+	SetPaused(name string, paused bool) error
+	SetNextRun(name string, at int64) error
+}
+
+// Func is the work of a job. now is the time the run was scheduled at.
+type Func func(now time.Time) error
+
+type registration struct {
+	name     string
+	interval time.Duration
+	timeout  time.Duration
+	run      Func
+}
+
+// Scheduler runs registered jobs on every server instance, using the job
+// rows as locks so each run happens on only one of them.
+type Scheduler struct {
+	Repo JobRepository
+	// Instance identifies this server in job locks; defaults to the hostname
+	// and a random suffix.
+	Instance string
+
+	mu   sync.Mutex
+	jobs []*registration
+}
+
+// Register adds a job that runs every interval. A run that takes longer
+// than timeout may be started again by another instance; 0 means ten minutes.
+func (s *Scheduler) Register(name string, interval, timeout time.Duration, run Func) error {
+	if name == "" || interval <= 0 || run == nil {
+		return errors.New("name, positive interval and function are required")
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+	if err := s.Repo.Ensure(name, interval, time.Now().Unix()); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &registration{name: name, interval: interval, timeout: timeout, run: run})
+	return nil
+}
+
+// RunDue runs every registered job that is due at now and that this instance
+// manages to claim, one after another. It returns the number of jobs run.
+func (s *Scheduler) RunDue(now time.Time) (int, error) {
+	s.mu.Lock()
+	jobs := append([]*registration(nil), s.jobs...)
+	s.mu.Unlock()
+
+	ran := 0
+	for _, r := range jobs {
+		claimed, err := s.Repo.Claim(r.name, s.instance(), now.Unix(), now.Add(r.timeout).Unix())
+		if err != nil {
+			return ran, err
+		}
+		if !claimed {
+			continue
+		}
+		ran++
+		start := time.Now()
+		var lastError string
+		if err := safeRun(r.run, now); err != nil {
+			lastError = err.Error()
+			log.Printf("scheduler: job %s failed: %v", r.name, err)
+		}
+		// The next run is scheduled from the planned time so runs do not drift.
+		next := now.Add(r.interval)
+		if err := s.Repo.Finish(r.name, s.instance(), now.Unix(), time.Since(start).Milliseconds(), lastError, next.Unix()); err != nil {
+			return ran, err
+		}
+	}
+	return ran, nil
+}
+
+// Run checks for due jobs every tick until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := s.RunDue(now); err != nil {
+				log.Printf("scheduler: %v", err)
+			}
+		}
+	}
+}
+
+// ListJobs returns the registered jobs and their state, by name.
+func (s *Scheduler) ListJobs() ([]*job.Job, error) {
+	jobs, err := s.Repo.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs, nil
+}
+
+// Pause stops a job from running on any instance until it is resumed.
+// A run already in progress finishes.
+func (s *Scheduler) Pause(name string) (*job.Job, error) {
+	return s.update(name, func() error { return s.Repo.SetPaused(name, true) })
+}
+
+// Resume lets a paused job run again from its next scheduled time.
+func (s *Scheduler) Resume(name string) (*job.Job, error) {
+	return s.update(name, func() error { return s.Repo.SetPaused(name, false) })
+}
+
+// Trigger makes a job due now; it runs on the next tick of any instance.
+func (s *Scheduler) Trigger(name string) (*job.Job, error) {
+	return s.update(name, func() error { return s.Repo.SetNextRun(name, time.Now().Unix()) })
+}
+
+func (s *Scheduler) update(name string, apply func() error) (*job.Job, error) {
+	j, err := s.Repo.FindByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if j == nil {
+		return nil, ErrJobNotFound
+	}
+	if err := apply(); err != nil {
+		return nil, err
+	}
+	return s.Repo.FindByName(name)
+}
+
+func (s *Scheduler) instance() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Instance == "" {
+		host, _ := os.Hostname()
+		s.Instance = host + "-" + uuid.New().String()[:8]
+	}
+	return s.Instance
+}
+
+// safeRun turns a panicking job into a failed run so the claim is released.
+func safeRun(run Func, now time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(now)
+}
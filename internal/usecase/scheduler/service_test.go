@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/job"
+)
+
+// MockJobRepository is an in-memory implementation of JobRepository
+type MockJobRepository map[string]*job.Job
+
+func (m MockJobRepository) Ensure(name string, interval time.Duration, now int64) error {
+	if j, ok := m[name]; ok {
+		j.Interval = int64(interval / time.Second)
+		return nil
+	}
+	m[name] = &job.Job{Name: name, Interval: int64(interval / time.Second), NextRunAt: now}
+	return nil
+}
+
+func (m MockJobRepository) Claim(name, owner string, now, leaseUntil int64) (bool, error) {
+	j := m[name]
+	if j == nil || j.Paused || j.NextRunAt > now || j.Running(now) {
+		return false, nil
+	}
+	j.LockedBy, j.LockedUntil = owner, leaseUntil
+	return true, nil
+}
+
+func (m MockJobRepository) Finish(name, owner string, ranAt, durationMillis int64, lastError string, nextRunAt int64) error {
+	j := m[name]
+	if j.LockedBy != owner {
+		return nil
+	}
+	j.LastRunAt, j.LastDuration, j.LastError, j.NextRunAt = ranAt, durationMillis, lastError, nextRunAt
+	j.LockedBy, j.LockedUntil = "", 0
+	return nil
+}
+
+func (m MockJobRepository) FindByName(name string) (*job.Job, error) { return m[name], nil }
+
+func (m MockJobRepository) List() ([]*job.Job, error) {
+	var out []*job.Job
+	for _, j := range m {
+		out = append(out, j)
+	}
+	return out, nil
+}
+
+func (m MockJobRepository) SetPaused(name string, paused bool) error {
+	m[name].Paused = paused
+	return nil
+}
+
+func (m MockJobRepository) SetNextRun(name string, at int64) error {
+	m[name].NextRunAt = at
+	return nil
+}
+
+func TestScheduler_RunsOncePerInterval(t *testing.T) {
+	repo := MockJobRepository{}
+	a := &Scheduler{Repo: repo, Instance: "a"}
+	b := &Scheduler{Repo: repo, Instance: "b"}
+	runs := 0
+	for _, s := range []*Scheduler{a, b} {
+		if err := s.Register("reminders", time.Hour, 0, func(now time.Time) error { runs++; return nil }); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	now := time.Now()
+	a.RunDue(now)
+	b.RunDue(now) // already run by a
+	if runs != 1 {
+		t.Fatalf("runs = %d, want 1", runs)
+	}
+	b.RunDue(now.Add(30 * time.Minute))
+	if runs != 1 {
+		t.Errorf("job ran again before its interval")
+	}
+	b.RunDue(now.Add(time.Hour))
+	if runs != 2 {
+		t.Errorf("runs = %d after one interval, want 2", runs)
+	}
+}
+
+func TestScheduler_LockExcludesOtherInstances(t *testing.T) {
+	repo := MockJobRepository{}
+	b := &Scheduler{Repo: repo, Instance: "b"}
+	b.Register("dispatch", time.Minute, time.Minute, func(now time.Time) error { return nil })
+
+	now := time.Now()
+	repo.Claim("dispatch", "a", now.Unix(), now.Add(time.Minute).Unix()) // a crashed mid-run
+	if ran, _ := b.RunDue(now); ran != 0 {
+		t.Error("job ran while another instance held the lock")
+	}
+	if ran, _ := b.RunDue(now.Add(2 * time.Minute)); ran != 1 {
+		t.Error("job did not run after the lock expired")
+	}
+}
+
+func TestScheduler_PauseAndFailures(t *testing.T) {
+	repo := MockJobRepository{}
+	s := &Scheduler{Repo: repo, Instance: "a"}
+	s.Register("broken", time.Hour, 0, func(now time.Time) error { panic("boom") })
+	s.Register("failing", time.Hour, 0, func(now time.Time) error { return errors.New("smtp down") })
+
+	if _, err := s.Pause("broken"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	now := time.Now()
+	if ran, _ := s.RunDue(now); ran != 1 {
+		t.Errorf("RunDue() ran %d jobs, want only the unpaused one", ran)
+	}
+	if j := repo["failing"]; j.LastError != "smtp down" || j.LockedBy != "" {
+		t.Errorf("failed job = %+v, want error recorded and lock released", j)
+	}
+
+	s.Resume("broken")
+	if _, err := s.Trigger("failing"); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	if ran, _ := s.RunDue(time.Now()); ran != 2 {
+		t.Errorf("RunDue() after resume and trigger ran %d jobs, want 2", ran)
+	}
+	if j := repo["broken"]; j.LastError != "panic: boom" {
+		t.Errorf("panicking job LastError = %q", j.LastError)
+	}
+	if _, err := s.Pause("unknown"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Pause(unknown) error = %v, want ErrJobNotFound", err)
+	}
+}
@@ -0,0 +1,568 @@
+package scorm
+
+import (
+	"archive/zip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/analytics"
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/realtime"
+	"training-portal/internal/domain/scorm"
+	"training-portal/internal/domain/user"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCourseNotFound  = errors.New("course not found")
+	ErrPackageNotFound = errors.New("SCORM package not found")
+	ErrSCONotFound     = errors.New("SCO not found")
+	ErrInvalidPackage  = errors.New("invalid SCORM package")
+	ErrPackageTooLarge = errors.New("SCORM package too large")
+	ErrNotLaunched     = errors.New("SCO has not been launched")
+	ErrInvalidLink     = errors.New("invalid content link")
+	ErrLinkExpired     = errors.New("content link has expired")
+	ErrContentNotFound = errors.New("content not found")
+)
+
+// ContentType is the module content type of imported SCOs.
+const ContentType = "scorm"
+
+// Repository is the persistence contract of SCORM packages and attempts.
+type Repository interface {
+	// CreatePackage stores a package and its SCOs in one transaction.
+	CreatePackage(p *scorm.Package, scos []*scorm.SCO) error
+	FindPackage(id string) (*scorm.Package, error)
+	ListPackages(courseID string) ([]*scorm.Package, error)
+	FindSCO(id string) (*scorm.SCO, error)
+	ListSCOs(packageID string) ([]*scorm.SCO, error)
+	FindAttempt(userID, scoID string) (*scorm.Attempt, error)
+	SaveAttempt(a *scorm.Attempt) error
+}
+
+// FileStore stores the extracted package files.
+type FileStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+// CourseFinder looks up the course a package is imported into.
+type CourseFinder interface {
+	FindByID(id string) (*course.Course, error)
+}
+
+// ModuleStore creates the modules SCOs are launched from.
+type ModuleStore interface {
+	Create(m *course.Module) error
+	Delete(id string) error
+	ListByCourse(courseID string) ([]*course.Module, error)
+}
+
+// ProgressRepository records module completion and scores reported by SCOs.
+type ProgressRepository interface {
+	CompleteModule(userID, courseID, moduleID string, at int64) (bool, error)
+	RecordQuizScore(userID, courseID, quizID string, score int, at int64) error
+}
+
+// UserFinder looks up the learner a SCO is told about.
+type UserFinder interface {
+	FindByID(id string) (*user.User, error)
+}
+
+// Recorder records analytics events.
+type Recorder interface {
+	Record(userID, eventType string, metadata map[string]interface{})
+}
+
+// Publisher pushes an event to a user's realtime stream.
+type Publisher interface {
+	Publish(userID string, t realtime.EventType, data interface{}) error
+}
+
+// Service imports SCORM packages as course modules, serves their content and
+// keeps learners' runtime data. What SCOs report feeds module completion and
+// scores: a completed or passed SCO completes its module, and its score is
+// kept like a quiz score under the SCO's ID.
+//
+// Package content is served from the portal's own origin, because a SCO finds
+// the runtime API in the window that launched it. Only staff upload packages.
+type Service struct {
+	Repo      Repository
+	Files     FileStore
+	Courses   CourseFinder
+	Modules   ModuleStore
+	Progress  ProgressRepository
+	Users     UserFinder
+	Analytics Recorder  // optional
+	Events    Publisher // optional; tells the learner about new scores
+
+	LinkSecret []byte
+	LinkTTL    time.Duration // content links; defaults to 12 hours
+	MaxSize    int64         // bytes of extracted content per package; defaults to 500 MiB
+	MaxFiles   int           // files per package; defaults to 10000
+}
+
+// Value is an element a SCO set.
+type Value struct {
+	Element string
+	Value   string
+}
+
+// ValueError is a value the runtime refused, with the SCORM error code the
+// SCO should see.
+type ValueError struct {
+	Element string
+	Code    int
+	Message string
+}
+
+// Session is what the API adapter needs to run a SCO.
+type Session struct {
+	SCO       *scorm.SCO
+	Version   scorm.Version
+	URL       string            // signed URL of the launch page
+	ExpiresAt int64             // of the URL
+	Values    map[string]string // readable elements with a value
+	Completed bool
+	Errors    []ValueError // values refused by a commit
+}
+
+// Import unpacks a SCORM zip into the course: its files are stored, and each
+// launchable item of the manifest's default organization becomes a module
+// after the course's existing ones. The archive is read in place and its
+// files are extracted and stored one at a time.
+func (s *Service) Import(courseID, userID string, archive io.ReaderAt, size int64, now time.Time) (*scorm.Package, []*scorm.SCO, error) {
+	c, err := s.Courses.FindByID(courseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c == nil {
+		return nil, nil, ErrCourseNotFound
+	}
+	entries, err := s.entries(archive, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	mf, ok := entries[scorm.ManifestFile]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s is missing", ErrInvalidPackage, scorm.ManifestFile)
+	}
+	raw, err := s.extract(mf, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest, err := scorm.ParseManifest(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	for _, it := range manifest.Items {
+		page := strings.SplitN(strings.SplitN(it.LaunchPath, "?", 2)[0], "#", 2)[0]
+		if _, ok := entries[page]; !ok {
+			return nil, nil, fmt.Errorf("%w: %s is missing", ErrInvalidPackage, page)
+		}
+	}
+
+	p := &scorm.Package{
+		ID:         uuid.New().String(),
+		CourseID:   courseID,
+		Title:      manifest.Title,
+		Identifier: manifest.Identifier,
+		Version:    manifest.Version,
+		UploadedBy: userID,
+		CreatedAt:  now.Unix(),
+	}
+	p.ContentPrefix = "scorm/" + p.ID + "/"
+	for name := range entries {
+		p.Files = append(p.Files, name)
+	}
+	sort.Strings(p.Files)
+	var extracted int64
+	for i, name := range p.Files {
+		content, err := s.extract(entries[name], extracted)
+		if err == nil {
+			err = s.Files.Put(p.ContentPrefix+name, content)
+		}
+		if err != nil {
+			s.removeFiles(p, p.Files[:i])
+			return nil, nil, err
+		}
+		extracted += int64(len(content))
+	}
+
+	existing, err := s.Modules.ListByCourse(courseID)
+	if err != nil {
+		s.removeFiles(p, p.Files)
+		return nil, nil, err
+	}
+	order := 0
+	for _, m := range existing {
+		if m.OrderIndex >= order {
+			order = m.OrderIndex + 1
+		}
+	}
+	var scos []*scorm.SCO
+	for i, it := range manifest.Items {
+		sco := &scorm.SCO{
+			ID:                  uuid.New().String(),
+			PackageID:           p.ID,
+			CourseID:            courseID,
+			Identifier:          it.Identifier,
+			Title:               it.Title,
+			LaunchPath:          it.LaunchPath,
+			Asset:               it.Asset,
+			PassingScore:        it.PassingScore,
+			CompletionThreshold: it.CompletionThreshold,
+			LaunchData:          it.LaunchData,
+			OrderIndex:          i,
+		}
+		m := &course.Module{
+			ID:          uuid.New().String(),
+			CourseID:    courseID,
+			Title:       it.Title,
+			ContentType: ContentType,
+			ContentURL:  "/api/scorm/sco/" + sco.ID + "/launch",
+			OrderIndex:  order + i,
+		}
+		if err := s.Modules.Create(m); err != nil {
+			s.removeModules(scos)
+			s.removeFiles(p, p.Files)
+			return nil, nil, err
+		}
+		sco.ModuleID = m.ID
+		scos = append(scos, sco)
+	}
+	if err := s.Repo.CreatePackage(p, scos); err != nil {
+		s.removeModules(scos)
+		s.removeFiles(p, p.Files)
+		return nil, nil, err
+	}
+	return p, scos, nil
+}
+
+// entries lists the package's files, keyed by their cleaned path, within the
+// file count limit.
+func (s *Service) entries(archive io.ReaderAt, size int64) (map[string]*zip.File, error) {
+	zr, err := zip.NewReader(archive, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a zip file", ErrInvalidPackage)
+	}
+	maxFiles := s.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = 10000
+	}
+	entries := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		name, err := scorm.CleanPath(f.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		if len(entries) >= maxFiles {
+			return nil, fmt.Errorf("%w: more than %d files", ErrPackageTooLarge, maxFiles)
+		}
+		entries[name] = f
+	}
+	return entries, nil
+}
+
+// extract reads a file of the package, given how many bytes of the package
+// were extracted before it; the total must stay within the size limit.
+func (s *Service) extract(f *zip.File, extracted int64) ([]byte, error) {
+	maxSize := s.MaxSize
+	if maxSize <= 0 {
+		maxSize = 500 << 20
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPackage, f.Name, err)
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(io.LimitReader(rc, maxSize-extracted+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPackage, f.Name, err)
+	}
+	if extracted+int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("%w: more than %d bytes extracted", ErrPackageTooLarge, maxSize)
+	}
+	return content, nil
+}
+
+func (s *Service) removeFiles(p *scorm.Package, names []string) {
+	for _, name := range names {
+		s.Files.Delete(p.ContentPrefix + name)
+	}
+}
+
+func (s *Service) removeModules(scos []*scorm.SCO) {
+	for _, sco := range scos {
+		s.Modules.Delete(sco.ModuleID)
+	}
+}
+
+// Packages returns the packages imported into a course.
+func (s *Service) Packages(courseID string) ([]*scorm.Package, error) {
+	return s.Repo.ListPackages(courseID)
+}
+
+// Package returns a package and its SCOs.
+func (s *Service) Package(id string) (*scorm.Package, []*scorm.SCO, error) {
+	p, err := s.Repo.FindPackage(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p == nil {
+		return nil, nil, ErrPackageNotFound
+	}
+	scos, err := s.Repo.ListSCOs(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, scos, nil
+}
+
+// SCO returns a SCO; callers check the learner may access its course.
+func (s *Service) SCO(id string) (*scorm.SCO, error) {
+	sco, err := s.Repo.FindSCO(id)
+	if err != nil {
+		return nil, err
+	}
+	if sco == nil {
+		return nil, ErrSCONotFound
+	}
+	return sco, nil
+}
+
+// Launch starts a session of the SCO for the learner and returns the signed
+// URL of its launch page with the runtime values the SCO may read. An asset
+// is completed by being launched.
+func (s *Service) Launch(scoID, userID string, now time.Time) (*Session, error) {
+	rt, p, err := s.runtime(scoID, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	rt.Attempt.Begin()
+	if rt.SCO.Asset {
+		rt.Attempt.LessonStatus = "completed"
+		rt.Attempt.CompletionStatus = "completed"
+	}
+	if err := s.save(rt, now); err != nil {
+		return nil, err
+	}
+	expiresAt := now.Add(s.linkTTL()).Unix()
+	return &Session{
+		SCO:       rt.SCO,
+		Version:   p.Version,
+		URL:       s.contentURL(p.ID, expiresAt, rt.SCO.LaunchPath),
+		ExpiresAt: expiresAt,
+		Values:    rt.Values(),
+		Completed: rt.Attempt.Completed(),
+	}, nil
+}
+
+// Commit stores the values the SCO set since the last commit, in the order it
+// set them, and ends the session when finish is set. Values the runtime
+// refuses are returned with their error codes; the others are kept.
+func (s *Service) Commit(scoID, userID string, values []Value, finish bool, now time.Time) (*Session, error) {
+	rt, p, err := s.runtime(scoID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	before, hadScore := rt.Attempt.Score()
+	var refused []ValueError
+	for _, v := range values {
+		if err := rt.SetValue(v.Element, v.Value); err != nil {
+			refused = append(refused, ValueError{Element: v.Element, Code: err.Code, Message: err.Message})
+		}
+	}
+	if finish {
+		rt.Finish()
+	} else {
+		rt.Commit()
+	}
+	if err := s.save(rt, now); err != nil {
+		return nil, err
+	}
+	if score, ok := rt.Attempt.Score(); ok && (!hadScore || score != before) {
+		s.publishGrade(rt.Attempt.UserID, rt.SCO, score)
+	}
+	return &Session{
+		SCO:       rt.SCO,
+		Version:   p.Version,
+		Values:    rt.Values(),
+		Completed: rt.Attempt.Completed(),
+		Errors:    refused,
+	}, nil
+}
+
+// Attempt returns a learner's runtime data for a SCO, or nil before the
+// first launch.
+func (s *Service) Attempt(scoID, userID string) (*scorm.Attempt, error) {
+	if _, err := s.SCO(scoID); err != nil {
+		return nil, err
+	}
+	return s.Repo.FindAttempt(userID, scoID)
+}
+
+func (s *Service) runtime(scoID, userID string, launch bool) (*scorm.Runtime, *scorm.Package, error) {
+	sco, err := s.SCO(scoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := s.Repo.FindPackage(sco.PackageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p == nil {
+		return nil, nil, ErrPackageNotFound
+	}
+	a, err := s.Repo.FindAttempt(userID, scoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if a == nil {
+		if !launch {
+			return nil, nil, ErrNotLaunched
+		}
+		a = &scorm.Attempt{UserID: userID, SCOID: scoID, Version: p.Version}
+	}
+	learner := scorm.Learner{ID: userID}
+	u, err := s.Users.FindByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u != nil {
+		learner.Name = u.Name
+	}
+	return &scorm.Runtime{SCO: sco, Learner: learner, Attempt: a}, p, nil
+}
+
+// save stores the attempt and applies it to the learner's progress.
+func (s *Service) save(rt *scorm.Runtime, now time.Time) error {
+	a, sco := rt.Attempt, rt.SCO
+	a.UpdatedAt = now.Unix()
+	if err := s.Repo.SaveAttempt(a); err != nil {
+		return err
+	}
+	score, scored := a.Score()
+	if scored {
+		if err := s.Progress.RecordQuizScore(a.UserID, sco.CourseID, sco.ID, score, now.Unix()); err != nil {
+			return err
+		}
+	}
+	if !a.Completed() {
+		return nil
+	}
+	added, err := s.Progress.CompleteModule(a.UserID, sco.CourseID, sco.ModuleID, now.Unix())
+	if err != nil {
+		return err
+	}
+	if added && s.Analytics != nil {
+		meta := map[string]interface{}{
+			analytics.MetaCourseID: sco.CourseID,
+			analytics.MetaModuleID: sco.ModuleID,
+		}
+		if scored {
+			meta[analytics.MetaScore] = score
+		}
+		s.Analytics.Record(a.UserID, analytics.EventModuleCompleted, meta)
+	}
+	return nil
+}
+
+// publishGrade pushes a new SCO score, as a percentage, to the learner.
+func (s *Service) publishGrade(userID string, sco *scorm.SCO, score int) {
+	if s.Events == nil {
+		return
+	}
+	grade := realtime.Grade{CourseID: sco.CourseID, QuizID: sco.ID, Score: score, Total: 100}
+	if err := s.Events.Publish(userID, realtime.TypeGrade, grade); err != nil {
+		log.Printf("scorm: publishing the score of %s to user %s failed: %v", sco.ID, userID, err)
+	}
+}
+
+// OpenContent checks a content link and returns the requested file of the
+// package.
+func (s *Service) OpenContent(packageID string, expiresAt int64, signature, name string) ([]byte, error) {
+	want := s.linkSignature(packageID, expiresAt)
+	if !hmac.Equal([]byte(signature), []byte(want)) {
+		return nil, ErrInvalidLink
+	}
+	if time.Now().Unix() > expiresAt {
+		return nil, ErrLinkExpired
+	}
+	name, err := scorm.CleanPath(name)
+	if err != nil {
+		return nil, ErrContentNotFound
+	}
+	p, err := s.Repo.FindPackage(packageID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrContentNotFound
+	}
+	found := false
+	for _, f := range p.Files {
+		if f == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrContentNotFound
+	}
+	data, err := s.Files.Get(p.ContentPrefix + name)
+	if err != nil {
+		log.Printf("scorm: reading %s%s: %v", p.ContentPrefix, name, err)
+		return nil, ErrContentNotFound
+	}
+	return data, nil
+}
+
+// contentURL is a signed link to a file of the package. The signature sits
+// in the path, so the relative links between the package's files keep
+// working.
+func (s *Service) contentURL(packageID string, expiresAt int64, launchPath string) string {
+	launchPath, fragment, hasFragment := strings.Cut(launchPath, "#")
+	page, query, hasQuery := strings.Cut(launchPath, "?")
+	segs := strings.Split(page, "/")
+	for i, seg := range segs {
+		segs[i] = url.PathEscape(seg)
+	}
+	u := "/scorm/content/" + packageID + "/" + strconv.FormatInt(expiresAt, 10) + "/" +
+		s.linkSignature(packageID, expiresAt) + "/" + strings.Join(segs, "/")
+	if hasQuery {
+		u += "?" + query
+	}
+	if hasFragment {
+		u += "#" + fragment
+	}
+	return u
+}
+
+func (s *Service) linkTTL() time.Duration {
+	if s.LinkTTL <= 0 {
+		return 12 * time.Hour
+	}
+	return s.LinkTTL
+}
+
+func (s *Service) linkSignature(packageID string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, s.LinkSecret)
+	mac.Write([]byte(packageID + "\n" + strconv.FormatInt(expiresAt, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
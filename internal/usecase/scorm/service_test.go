@@ -0,0 +1,381 @@
+package scorm
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/realtime"
+	"training-portal/internal/domain/scorm"
+	"training-portal/internal/domain/user"
+)
+
+type mockRepo struct {
+	packages map[string]*scorm.Package
+	scos     map[string]*scorm.SCO
+	attempts map[string]*scorm.Attempt
+}
+
+func newMockRepo() *mockRepo {
+	return &mockRepo{packages: map[string]*scorm.Package{}, scos: map[string]*scorm.SCO{}, attempts: map[string]*scorm.Attempt{}}
+}
+
+func (m *mockRepo) CreatePackage(p *scorm.Package, scos []*scorm.SCO) error {
+	m.packages[p.ID] = p
+	for _, sco := range scos {
+		m.scos[sco.ID] = sco
+	}
+	return nil
+}
+
+func (m *mockRepo) FindPackage(id string) (*scorm.Package, error) { return m.packages[id], nil }
+
+func (m *mockRepo) ListPackages(courseID string) ([]*scorm.Package, error) {
+	var out []*scorm.Package
+	for _, p := range m.packages {
+		if p.CourseID == courseID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockRepo) FindSCO(id string) (*scorm.SCO, error) { return m.scos[id], nil }
+
+func (m *mockRepo) ListSCOs(packageID string) ([]*scorm.SCO, error) {
+	var out []*scorm.SCO
+	for _, sco := range m.scos {
+		if sco.PackageID == packageID {
+			out = append(out, sco)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockRepo) FindAttempt(userID, scoID string) (*scorm.Attempt, error) {
+	a, ok := m.attempts[userID+"/"+scoID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *a
+	copied.Extra = map[string]string{}
+	for k, v := range a.Extra {
+		copied.Extra[k] = v
+	}
+	return &copied, nil
+}
+
+func (m *mockRepo) SaveAttempt(a *scorm.Attempt) error {
+	m.attempts[a.UserID+"/"+a.SCOID] = a
+	return nil
+}
+
+type mockFiles map[string][]byte
+
+func (m mockFiles) Put(key string, data []byte) error { m[key] = data; return nil }
+
+func (m mockFiles) Get(key string) ([]byte, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, errors.New("no such file")
+	}
+	return data, nil
+}
+
+func (m mockFiles) Delete(key string) error { delete(m, key); return nil }
+
+type mockCourses map[string]*course.Course
+
+func (m mockCourses) FindByID(id string) (*course.Course, error) { return m[id], nil }
+
+type mockModules struct {
+	modules []*course.Module
+}
+
+func (m *mockModules) Create(mod *course.Module) error {
+	m.modules = append(m.modules, mod)
+	return nil
+}
+
+func (m *mockModules) Delete(id string) error { return nil }
+
+func (m *mockModules) ListByCourse(courseID string) ([]*course.Module, error) {
+	return m.modules, nil
+}
+
+type mockProgress struct {
+	completed map[string]bool
+	scores    map[string]int
+}
+
+func (m *mockProgress) CompleteModule(userID, courseID, moduleID string, at int64) (bool, error) {
+	if m.completed[moduleID] {
+		return false, nil
+	}
+	m.completed[moduleID] = true
+	return true, nil
+}
+
+func (m *mockProgress) RecordQuizScore(userID, courseID, quizID string, score int, at int64) error {
+	if score > m.scores[quizID] {
+		m.scores[quizID] = score
+	}
+	return nil
+}
+
+type mockUsers struct{}
+
+func (mockUsers) FindByID(id string) (*user.User, error) {
+	return &user.User{ID: id, Name: "Ann Lee"}, nil
+}
+
+type mockRecorder struct {
+	events []string
+}
+
+func (m *mockRecorder) Record(userID, eventType string, metadata map[string]interface{}) {
+	m.events = append(m.events, eventType)
+}
+
+type mockPublisher struct {
+	grades []realtime.Grade
+}
+
+func (m *mockPublisher) Publish(userID string, t realtime.EventType, data interface{}) error {
+	if t == realtime.TypeGrade {
+		m.grades = append(m.grades, data.(realtime.Grade))
+	}
+	return nil
+}
+
+const testManifest = `<?xml version="1.0"?>
+<manifest identifier="pkg" xmlns:adlcp="http://www.adlnet.org/xsd/adlcp_rootv1p2">
+  <metadata><schemaversion>1.2</schemaversion></metadata>
+  <organizations default="org">
+    <organization identifier="org">
+      <title>Fire Safety</title>
+      <item identifier="i1" identifierref="r1"><title>Lesson</title><adlcp:masteryscore>70</adlcp:masteryscore></item>
+      <item identifier="i2" identifierref="r2"><title>Handout</title></item>
+    </organization>
+  </organizations>
+  <resources>
+    <resource identifier="r1" type="webcontent" adlcp:scormtype="sco" href="lesson/index.html"/>
+    <resource identifier="r2" type="webcontent" adlcp:scormtype="asset" href="handout.html"/>
+  </resources>
+</manifest>`
+
+func zipOf(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+type fixture struct {
+	svc      *Service
+	repo     *mockRepo
+	files    mockFiles
+	modules  *mockModules
+	progress *mockProgress
+	recorder *mockRecorder
+	events   *mockPublisher
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		repo:     newMockRepo(),
+		files:    mockFiles{},
+		modules:  &mockModules{modules: []*course.Module{{ID: "m0", CourseID: "c1", OrderIndex: 4}}},
+		progress: &mockProgress{completed: map[string]bool{}, scores: map[string]int{}},
+		recorder: &mockRecorder{},
+		events:   &mockPublisher{},
+	}
+	f.svc = &Service{
+		Repo: f.repo, Files: f.files, Courses: mockCourses{"c1": {ID: "c1"}}, Modules: f.modules, Progress: f.progress,
+		Users: mockUsers{}, Analytics: f.recorder, Events: f.events, LinkSecret: []byte("secret"),
+	}
+	return f
+}
+
+func (f *fixture) importPackage(t *testing.T) (*scorm.Package, []*scorm.SCO) {
+	t.Helper()
+	archive := zipOf(t, map[string]string{
+		"imsmanifest.xml":   testManifest,
+		"lesson/index.html": "<html>lesson</html>",
+		"lesson/app.js":     "initialize()",
+		"handout.html":      "<html>handout</html>",
+	})
+	p, scos, err := f.svc.Import("c1", "admin", archive, archive.Size(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, scos
+}
+
+func TestImport(t *testing.T) {
+	f := newFixture()
+	p, scos := f.importPackage(t)
+	if p.Version != scorm.Version12 || p.Title != "Fire Safety" || len(p.Files) != 4 || len(scos) != 2 {
+		t.Fatalf("Import() = %+v, %d SCOs", p, len(scos))
+	}
+	if string(f.files[p.ContentPrefix+"lesson/app.js"]) != "initialize()" {
+		t.Error("package files should be stored under the package's prefix")
+	}
+	if len(f.modules.modules) != 3 {
+		t.Fatalf("modules = %d, want one per SCO after the existing one", len(f.modules.modules))
+	}
+	lesson := f.modules.modules[1]
+	if lesson.ContentType != ContentType || lesson.OrderIndex != 5 || lesson.ContentURL != "/api/scorm/sco/"+scos[0].ID+"/launch" || scos[0].ModuleID != lesson.ID {
+		t.Errorf("lesson module = %+v", lesson)
+	}
+	if scos[0].PassingScore == nil || *scos[0].PassingScore != 0.7 || scos[1].PassingScore != nil || scos[0].Asset || !scos[1].Asset {
+		t.Errorf("SCOs = %+v, %+v", scos[0], scos[1])
+	}
+}
+
+func TestImport_Invalid(t *testing.T) {
+	tests := map[string]map[string]string{
+		"no manifest":    {"index.html": "x"},
+		"missing launch": {"imsmanifest.xml": testManifest, "handout.html": "x"},
+		"zip slip":       {"imsmanifest.xml": testManifest, "../../evil.sh": "x"},
+	}
+	for name, files := range tests {
+		f := newFixture()
+		archive := zipOf(t, files)
+		if _, _, err := f.svc.Import("c1", "admin", archive, archive.Size(), time.Now()); !errors.Is(err, ErrInvalidPackage) {
+			t.Errorf("%s: error = %v, want ErrInvalidPackage", name, err)
+		}
+		if len(f.files) != 0 || len(f.modules.modules) != 1 {
+			t.Errorf("%s: nothing should be stored", name)
+		}
+	}
+	f := newFixture()
+	f.svc.MaxSize = 10
+	archive := zipOf(t, map[string]string{"imsmanifest.xml": testManifest})
+	if _, _, err := f.svc.Import("c1", "admin", archive, archive.Size(), time.Now()); !errors.Is(err, ErrPackageTooLarge) {
+		t.Errorf("oversized package: error = %v", err)
+	}
+	if _, _, err := f.svc.Import("missing", "admin", archive, archive.Size(), time.Now()); !errors.Is(err, ErrCourseNotFound) {
+		t.Errorf("unknown course: error = %v, want ErrCourseNotFound", err)
+	}
+}
+
+func TestLaunchAndCommit(t *testing.T) {
+	f := newFixture()
+	_, scos := f.importPackage(t)
+	lesson := scos[0]
+	now := time.Now()
+
+	if _, err := f.svc.Commit(lesson.ID, "u1", nil, false, now); !errors.Is(err, ErrNotLaunched) {
+		t.Errorf("commit before launch: error = %v", err)
+	}
+	session, err := f.svc.Launch(lesson.ID, "u1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Values["cmi.core.student_name"] != "Ann Lee" || session.Values["cmi.core.entry"] != "ab-initio" || session.Completed {
+		t.Errorf("launch values = %v", session.Values)
+	}
+
+	session, err = f.svc.Commit(lesson.ID, "u1", []Value{
+		{"cmi.core.lesson_location", "page-2"},
+		{"cmi.core.score.raw", "not a number"},
+	}, false, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(session.Errors) != 1 || session.Errors[0].Code != 405 || session.Values["cmi.core.lesson_location"] != "page-2" {
+		t.Errorf("commit = %+v", session)
+	}
+	if len(f.progress.completed) != 0 {
+		t.Error("an incomplete SCO should not complete its module")
+	}
+
+	if _, err := f.svc.Commit(lesson.ID, "u1", []Value{
+		{"cmi.core.score.raw", "82"},
+		{"cmi.core.session_time", "00:05:00"},
+	}, true, now); err != nil {
+		t.Fatal(err)
+	}
+	a := f.repo.attempts["u1/"+lesson.ID]
+	if a.LessonStatus != "passed" || a.TotalSeconds != 300 {
+		t.Errorf("attempt = %+v", a)
+	}
+	if !f.progress.completed[lesson.ModuleID] || f.progress.scores[lesson.ID] != 82 {
+		t.Errorf("progress = %+v", f.progress)
+	}
+	if len(f.recorder.events) != 1 || f.recorder.events[0] != "module_completed" {
+		t.Errorf("events = %v", f.recorder.events)
+	}
+	if len(f.events.grades) != 1 || f.events.grades[0].Score != 82 || f.events.grades[0].QuizID != lesson.ID {
+		t.Errorf("published grades = %+v", f.events.grades)
+	}
+
+	if _, err := f.svc.Commit(lesson.ID, "u1", nil, false, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.recorder.events) != 1 {
+		t.Error("a completed module should be recorded once")
+	}
+	if len(f.events.grades) != 1 {
+		t.Error("an unchanged score should not be published again")
+	}
+}
+
+func TestLaunch_AssetCompletes(t *testing.T) {
+	f := newFixture()
+	_, scos := f.importPackage(t)
+	session, err := f.svc.Launch(scos[1].ID, "u1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.Completed || !f.progress.completed[scos[1].ModuleID] {
+		t.Error("launching an asset should complete its module")
+	}
+}
+
+func TestOpenContent(t *testing.T) {
+	f := newFixture()
+	p, scos := f.importPackage(t)
+	session, err := f.svc.Launch(scos[0].ID, "u1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// /scorm/content/<package>/<expires>/<signature>/<path>
+	parts := strings.SplitN(strings.TrimPrefix(session.URL, "/scorm/content/"), "/", 4)
+	if parts[0] != p.ID || parts[3] != "lesson/index.html" {
+		t.Fatalf("URL = %s", session.URL)
+	}
+	expires, _ := strconv.ParseInt(parts[1], 10, 64)
+	sig, _ := url.PathUnescape(parts[2])
+
+	if data, err := f.svc.OpenContent(p.ID, expires, sig, "lesson/app.js"); err != nil || string(data) != "initialize()" {
+		t.Errorf("OpenContent() = %q, %v", data, err)
+	}
+	if _, err := f.svc.OpenContent(p.ID, expires, sig, "../other/secret"); !errors.Is(err, ErrContentNotFound) {
+		t.Errorf("path outside the package: error = %v", err)
+	}
+	if _, err := f.svc.OpenContent(p.ID, expires+1, sig, "lesson/app.js"); !errors.Is(err, ErrInvalidLink) {
+		t.Errorf("tampered expiry: error = %v", err)
+	}
+	past := time.Now().Add(-time.Minute).Unix()
+	if _, err := f.svc.OpenContent(p.ID, past, f.svc.linkSignature(p.ID, past), "lesson/app.js"); !errors.Is(err, ErrLinkExpired) {
+		t.Errorf("expired link: error = %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package user
 
 import (
 	"errors"
+	"log"
 	"regexp"
 
 	"training-portal/internal/domain/user"
@@ -11,8 +12,15 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// RuleEvaluator applies auto-enrollment rules to a user.
+type RuleEvaluator interface {
+	EvaluateUser(u *user.User) (int, error)
+}
+
 type UserService struct {
 	Repo repository.UserRepository
+	// AutoEnroll, when set, is run after a user is created or updated.
+	AutoEnroll RuleEvaluator
 }
 
 // ValidateEmail checks if the email is in a valid format.
@@ -50,6 +58,7 @@ func (s *UserService) Register(name, email, password string, role user.Role) (*u
 	if err := s.Repo.Create(u); err != nil {
 		return nil, err
 	}
+	s.evaluateRules(u)
 	return u, nil
 }
 
@@ -91,7 +100,13 @@ func (s *UserService) UpdateUser(u *user.User) error {
 	if u.Email != "" && !ValidateEmail(u.Email) {
 		return errors.New("invalid email format")
 	}
-	return s.Repo.Update(u)
+	if err := s.Repo.Update(u); err != nil {
+		return err
+	}
+	if updated, err := s.Repo.FindByID(u.ID); err == nil && updated != nil {
+		s.evaluateRules(updated)
+	}
+	return nil
 }
 
 // UpdatePassword updates a user's password.
@@ -126,3 +141,14 @@ func (s *UserService) DeleteUser(id string) error {
 func (s *UserService) ListUsers() ([]*user.User, error) {
 	return s.Repo.List()
 }
+
+// evaluateRules runs auto-enrollment for the user. Failures are logged rather
+// than returned so they never block account changes.
+func (s *UserService) evaluateRules(u *user.User) {
+	if s.AutoEnroll == nil {
+		return
+	}
+	if _, err := s.AutoEnroll.EvaluateUser(u); err != nil {
+		log.Printf("auto-enrollment failed for user %s: %v", u.ID, err)
+	}
+}
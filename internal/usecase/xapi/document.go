@@ -0,0 +1,196 @@
+package xapi
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/user"
+	"training-portal/internal/domain/xapi"
+
+	"github.com/google/uuid"
+)
+
+// Precondition holds a request's If-Match and If-None-Match ETags, without
+// quotes. IfNoneMatch "*" means the document must not exist yet.
+type Precondition struct {
+	IfMatch     string
+	IfNoneMatch string
+}
+
+// Document returns a State or Activity Profile document.
+func (s *Service) Document(actor Actor, key xapi.DocumentKey) (*xapi.Document, error) {
+	if err := s.checkKey(actor, key, false); err != nil {
+		return nil, err
+	}
+	if key.DocID == "" {
+		return nil, fmt.Errorf("%w: a document id is required", ErrInvalidRequest)
+	}
+	d, err := s.Repo.FindDocument(key)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrNotFound
+	}
+	return d, nil
+}
+
+// DocumentIDs returns the IDs of the documents under the key, optionally only
+// those updated after since.
+func (s *Service) DocumentIDs(actor Actor, key xapi.DocumentKey, since time.Time) ([]string, error) {
+	key.DocID = ""
+	if err := s.checkKey(actor, key, false); err != nil {
+		return nil, err
+	}
+	ids, err := s.Repo.ListDocumentIDs(key, since)
+	if ids == nil && err == nil {
+		ids = []string{}
+	}
+	return ids, err
+}
+
+// SaveDocument stores a document. With merge set, as for POST, a JSON object
+// is merged into a stored JSON object, top-level properties of the new one
+// winning; other content cannot be merged. Activity Profile documents may
+// only be replaced with a precondition once they exist, so that two clients
+// do not overwrite each other.
+func (s *Service) SaveDocument(actor Actor, d *xapi.Document, pre Precondition, merge bool, now time.Time) error {
+	if err := s.checkKey(actor, d.DocumentKey, true); err != nil {
+		return err
+	}
+	if d.DocID == "" {
+		return fmt.Errorf("%w: a document id is required", ErrInvalidRequest)
+	}
+	if max := positive(s.MaxDocument, 1<<20); len(d.Content) > max {
+		return fmt.Errorf("%w: at most %d bytes", ErrTooLarge, max)
+	}
+	if d.ContentType == "" {
+		d.ContentType = "application/octet-stream"
+	}
+	prev, err := s.Repo.FindDocument(d.DocumentKey)
+	if err != nil {
+		return err
+	}
+	if err := checkPrecondition(prev, pre); err != nil {
+		return err
+	}
+	if !merge && prev != nil && d.Kind == xapi.DocumentActivityProfile && pre.IfMatch == "" && pre.IfNoneMatch == "" {
+		return ErrConcurrency
+	}
+	if merge {
+		newObj, newOK := jsonObject(d.ContentType, d.Content)
+		if prev != nil {
+			oldObj, oldOK := jsonObject(prev.ContentType, prev.Content)
+			if !newOK || !oldOK {
+				return fmt.Errorf("%w: only JSON objects can be merged", ErrInvalidRequest)
+			}
+			for k, v := range newObj {
+				oldObj[k] = v
+			}
+			if d.Content, err = json.Marshal(oldObj); err != nil {
+				return err
+			}
+		} else if !newOK {
+			return fmt.Errorf("%w: POST requires a JSON object", ErrInvalidRequest)
+		}
+		d.ContentType = "application/json"
+	}
+	sum := sha1.Sum(d.Content)
+	d.ETag = hex.EncodeToString(sum[:])
+	d.UpdatedAt = now
+	return s.Repo.SaveDocument(d)
+}
+
+// DeleteDocuments deletes a document, or every State document of the
+// activity and agent when no document id is given.
+func (s *Service) DeleteDocuments(actor Actor, key xapi.DocumentKey, pre Precondition) error {
+	if err := s.checkKey(actor, key, true); err != nil {
+		return err
+	}
+	if key.DocID == "" && key.Kind != xapi.DocumentState {
+		return fmt.Errorf("%w: a document id is required", ErrInvalidRequest)
+	}
+	if key.DocID != "" && (pre.IfMatch != "" || pre.IfNoneMatch != "") {
+		prev, err := s.Repo.FindDocument(key)
+		if err != nil {
+			return err
+		}
+		if err := checkPrecondition(prev, pre); err != nil {
+			return err
+		}
+	}
+	return s.Repo.DeleteDocuments(key)
+}
+
+// checkKey validates a document key and that the actor may use it: learners
+// only use their own State documents, and only read Activity Profiles.
+func (s *Service) checkKey(actor Actor, key xapi.DocumentKey, write bool) error {
+	if key.ActivityID == "" {
+		return fmt.Errorf("%w: activityId is required", ErrInvalidRequest)
+	}
+	switch key.Kind {
+	case xapi.DocumentState:
+		if key.Agent == "" {
+			return fmt.Errorf("%w: agent is required", ErrInvalidRequest)
+		}
+		if key.Registration != "" && uuid.Validate(key.Registration) != nil {
+			return fmt.Errorf("%w: registration must be a UUID", ErrInvalidRequest)
+		}
+		if actor.Staff {
+			return nil
+		}
+		u, err := s.user(actor.UserID)
+		if err != nil {
+			return err
+		}
+		if u == nil || !s.ownsIFI(key.Agent, u) {
+			return ErrForbidden
+		}
+	case xapi.DocumentActivityProfile:
+		if write && !actor.Staff {
+			return ErrForbidden
+		}
+	default:
+		return fmt.Errorf("%w: unknown document kind %q", ErrInvalidRequest, key.Kind)
+	}
+	return nil
+}
+
+// ownsIFI reports whether the IFI identifies the user.
+func (s *Service) ownsIFI(ifi string, u *user.User) bool {
+	if ifi == (&xapi.Agent{Account: &xapi.Account{HomePage: s.BaseURL, Name: u.ID}}).IFI() {
+		return true
+	}
+	if u.Email == "" {
+		return false
+	}
+	return strings.EqualFold(ifi, "mbox:mailto:"+u.Email) || strings.EqualFold(ifi, "mbox_sha1sum:"+xapi.MboxSHA1(strings.ToLower(u.Email)))
+}
+
+func checkPrecondition(prev *xapi.Document, pre Precondition) error {
+	switch {
+	case pre.IfMatch != "" && (prev == nil || (pre.IfMatch != "*" && !strings.EqualFold(pre.IfMatch, prev.ETag))):
+		return ErrPrecondition
+	case pre.IfNoneMatch == "*" && prev != nil:
+		return ErrPrecondition
+	case pre.IfNoneMatch != "" && pre.IfNoneMatch != "*" && prev != nil && strings.EqualFold(pre.IfNoneMatch, prev.ETag):
+		return ErrPrecondition
+	}
+	return nil
+}
+
+// jsonObject parses content that is a JSON object.
+func jsonObject(contentType string, content []byte) (map[string]json.RawMessage, bool) {
+	if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(contentType)), "application/json") {
+		return nil, false
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(content, &obj); err != nil || obj == nil {
+		return nil, false
+	}
+	return obj, true
+}
@@ -0,0 +1,149 @@
+package xapi
+
+import (
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/analytics"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/xapi"
+
+	"github.com/google/uuid"
+)
+
+// statementNamespace derives the IDs of emitted statements from the IDs of
+// what they describe, so that emitting twice stores one statement.
+var statementNamespace = uuid.MustParse("5b0c3f63-2b8e-4c3a-9a55-0d6f3c1e8a21")
+
+// EventsRecorded emits statements for module completions, quiz attempts and
+// issued certificates recorded as analytics events. Events recorded from a
+// statement are skipped. Failures are logged since the events are stored.
+func (s *Service) EventsRecorded(events []*analytics.AnalyticsEvent) {
+	for _, e := range events {
+		if _, fromStatement := e.Metadata[analytics.MetaStatementID]; fromStatement {
+			continue
+		}
+		st := s.eventStatement(e)
+		if st == nil {
+			continue
+		}
+		s.emit(e.UserID, "analytics:"+e.ID, st, time.Unix(e.Timestamp, 0))
+	}
+}
+
+func (s *Service) eventStatement(e *analytics.AnalyticsEvent) *xapi.Statement {
+	courseID := e.CourseID()
+	if courseID == "" {
+		return nil
+	}
+	meta := func(key string) string {
+		v, _ := e.Metadata[key].(string)
+		return v
+	}
+	parent := &xapi.Context{ContextActivities: &xapi.ContextActivities{
+		Parent: xapi.ActivityList{s.activity("course", courseID, xapi.ActivityCourse)},
+	}}
+	switch e.EventType {
+	case analytics.EventModuleCompleted:
+		return &xapi.Statement{
+			Verb:    verb(xapi.VerbCompleted, "completed"),
+			Object:  s.activity("module", meta(analytics.MetaModuleID), xapi.ActivityModule),
+			Result:  &xapi.Result{Completion: boolPtr(true)},
+			Context: parent,
+		}
+	case analytics.EventQuizSubmitted:
+		st := &xapi.Statement{
+			Verb:    verb(xapi.VerbCompleted, "completed"),
+			Object:  s.activity("quiz", meta(analytics.MetaQuizID), xapi.ActivityAssessment),
+			Result:  &xapi.Result{Completion: boolPtr(true)},
+			Context: parent,
+		}
+		if passed, ok := e.Metadata[analytics.MetaPassed].(bool); ok {
+			st.Result.Success = boolPtr(passed)
+			if passed {
+				st.Verb = verb(xapi.VerbPassed, "passed")
+			} else {
+				st.Verb = verb(xapi.VerbFailed, "failed")
+			}
+		}
+		if score, ok := e.Metadata[analytics.MetaScore].(float64); ok && score >= 0 && score <= 100 {
+			scaled, raw, lo, hi := score/100, score, 0.0, 100.0
+			st.Result.Score = &xapi.Score{Scaled: &scaled, Raw: &raw, Min: &lo, Max: &hi}
+		}
+		return st
+	case analytics.EventCertificateIssued:
+		return &xapi.Statement{
+			Verb:   verb(xapi.VerbEarned, "earned"),
+			Object: s.activity("course", courseID, xapi.ActivityCourse),
+			Context: &xapi.Context{Extensions: map[string]interface{}{
+				strings.TrimRight(s.BaseURL, "/") + "/xapi/extensions/certificate": meta("certificate_id"),
+			}},
+		}
+	}
+	return nil
+}
+
+// EnrollmentChanged emits a registered statement when an enrollment becomes
+// active and a completed statement when it is completed. The enrollment ID
+// is the statement's registration.
+func (s *Service) EnrollmentChanged(e *enrollment.Enrollment, change *enrollment.StatusChange) {
+	var v *xapi.Verb
+	var result *xapi.Result
+	switch change.ToStatus {
+	case enrollment.StatusActive:
+		v = verb(xapi.VerbRegistered, "registered")
+	case enrollment.StatusCompleted:
+		v = verb(xapi.VerbCompleted, "completed")
+		result = &xapi.Result{Completion: boolPtr(true)}
+	default:
+		return
+	}
+	st := &xapi.Statement{
+		Verb:    v,
+		Object:  s.activity("course", e.CourseID, xapi.ActivityCourse),
+		Result:  result,
+		Context: &xapi.Context{Registration: e.ID},
+	}
+	s.emit(e.UserID, "enrollment:"+change.ID, st, time.Unix(change.ChangedAt, 0))
+}
+
+// emit stores a statement about a portal user. key names what it describes
+// and determines its ID. Without a BaseURL there are no activity IRIs, and
+// nothing is emitted.
+func (s *Service) emit(userID, key string, st *xapi.Statement, at time.Time) {
+	if s.BaseURL == "" {
+		return
+	}
+	u, err := s.user(userID)
+	if err != nil || u == nil {
+		if err != nil {
+			logf("looking up user %s to emit %s failed: %v", userID, key, err)
+		}
+		return
+	}
+	st.ID = uuid.NewSHA1(statementNamespace, []byte(key)).String()
+	st.Actor = s.agent(u)
+	st.Timestamp = xapi.FormatTime(at)
+	st.Stored = xapi.FormatTime(time.Now())
+	st.Authority = s.portal()
+	st.Version = xapi.Version
+	if _, err := s.Repo.SaveStatements([]*xapi.Record{{Statement: st, UserID: u.ID}}); err != nil {
+		logf("emitting %s for user %s failed: %v", key, userID, err)
+	}
+}
+
+func (s *Service) activity(kind, id, activityType string) *xapi.Object {
+	return &xapi.Object{
+		ObjectType: xapi.ObjectActivity,
+		ID:         s.ActivityIRI(kind, id),
+		Definition: &xapi.ActivityDefinition{Type: activityType},
+	}
+}
+
+func verb(id, display string) *xapi.Verb {
+	return &xapi.Verb{ID: id, Display: xapi.LanguageMap{"en-US": display}}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
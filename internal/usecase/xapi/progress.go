@@ -0,0 +1,169 @@
+package xapi
+
+import (
+	"math"
+	"time"
+
+	"training-portal/internal/domain/analytics"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/realtime"
+	"training-portal/internal/domain/xapi"
+)
+
+// ContentType is the module content type of xAPI content, which is launched
+// from the portal and reports the learner's progress through statements.
+const ContentType = "xapi"
+
+// launchedContent lists the module content types whose content reports
+// progress from the learner's browser: xAPI content and SCORM packages.
+var launchedContent = map[string]bool{ContentType: true, "scorm": true}
+
+// applyProgress updates the learner's progress from a statement about a
+// portal module or quiz. A module is completed by a completed or passed
+// statement, or any statement whose result says it is complete; a quiz
+// records the scores of its statements. The same progress is recorded as an
+// analytics event, marked with the statement so that it is not emitted back
+// as a statement. Voiding a statement later does not undo its progress.
+// Failures are logged since the statement itself has been stored.
+//
+// Statements sent with a learner's own token are only trusted for modules of
+// launched content; quiz scores and other modules need a configured client.
+// Either way the learner must hold an active enrollment in the course.
+func (s *Service) applyProgress(actor Actor, r *xapi.Record) {
+	st := r.Statement
+	if r.UserID == "" || s.Progress == nil || s.Enrollments == nil || st.Voids() != "" || st.Object.Type() != xapi.ObjectActivity {
+		return
+	}
+	kind, id, ok := s.portalActivity(st.Object.ID)
+	if !ok {
+		return
+	}
+	at := time.Now().Unix()
+	if t, err := time.Parse(time.RFC3339Nano, st.Timestamp); err == nil {
+		at = t.Unix()
+	}
+	switch kind {
+	case "module":
+		if !completes(st) {
+			return
+		}
+		m, err := s.Modules.FindByID(id)
+		if err != nil || m == nil {
+			if err != nil {
+				logf("looking up module %s for statement %s failed: %v", id, st.ID, err)
+			}
+			return
+		}
+		if actor.Client == "" && !launchedContent[m.ContentType] {
+			return
+		}
+		if !s.enrolled(r.UserID, m.CourseID, st.ID) {
+			return
+		}
+		added, err := s.Progress.CompleteModule(r.UserID, m.CourseID, m.ID, at)
+		if err != nil {
+			logf("completing module %s for user %s from statement %s failed: %v", m.ID, r.UserID, st.ID, err)
+			return
+		}
+		if added && s.Analytics != nil {
+			s.Analytics.Record(r.UserID, analytics.EventModuleCompleted, map[string]interface{}{
+				analytics.MetaCourseID: m.CourseID, analytics.MetaModuleID: m.ID, analytics.MetaStatementID: st.ID,
+			})
+		}
+	case "quiz":
+		score, ok := percentScore(st.Result)
+		if !ok || actor.Client == "" {
+			return
+		}
+		courseID, err := s.Progress.QuizCourse(id)
+		if err != nil || courseID == "" {
+			if err != nil {
+				logf("looking up quiz %s for statement %s failed: %v", id, st.ID, err)
+			}
+			return
+		}
+		if !s.enrolled(r.UserID, courseID, st.ID) {
+			return
+		}
+		if err := s.Progress.RecordQuizScore(r.UserID, courseID, id, score, at); err != nil {
+			logf("recording quiz %s for user %s from statement %s failed: %v", id, r.UserID, st.ID, err)
+			return
+		}
+		if s.Events != nil {
+			grade := realtime.Grade{CourseID: courseID, QuizID: id, Score: score, Total: 100}
+			if err := s.Events.Publish(r.UserID, realtime.TypeGrade, grade); err != nil {
+				logf("publishing quiz %s score to user %s failed: %v", id, r.UserID, err)
+			}
+		}
+		if s.Analytics != nil {
+			meta := map[string]interface{}{
+				analytics.MetaCourseID: courseID, analytics.MetaQuizID: id, analytics.MetaScore: score, analytics.MetaStatementID: st.ID,
+			}
+			if passed, ok := passes(st); ok {
+				meta[analytics.MetaPassed] = passed
+			}
+			s.Analytics.Record(r.UserID, analytics.EventQuizSubmitted, meta)
+		}
+	}
+}
+
+// enrolled reports whether the user holds an active enrollment in the course,
+// which progress from a statement requires.
+func (s *Service) enrolled(userID, courseID, statementID string) bool {
+	e, err := s.Enrollments.FindByUserAndCourse(userID, courseID)
+	if err != nil {
+		logf("looking up the enrollment of user %s in course %s for statement %s failed: %v", userID, courseID, statementID, err)
+		return false
+	}
+	return e != nil && e.Status == enrollment.StatusActive
+}
+
+// completes reports whether the statement says its activity is complete.
+func completes(st *xapi.Statement) bool {
+	if st.Verb.ID == xapi.VerbCompleted || st.Verb.ID == xapi.VerbPassed {
+		return true
+	}
+	return st.Result != nil && st.Result.Completion != nil && *st.Result.Completion
+}
+
+// passes reports whether the statement says the learner passed, if it says.
+func passes(st *xapi.Statement) (passed, known bool) {
+	switch {
+	case st.Result != nil && st.Result.Success != nil:
+		return *st.Result.Success, true
+	case st.Verb.ID == xapi.VerbPassed:
+		return true, true
+	case st.Verb.ID == xapi.VerbFailed:
+		return false, true
+	}
+	return false, false
+}
+
+// percentScore converts a result's score to the 0 to 100 scale of the
+// portal's quizzes: the scaled score if there is one, otherwise the raw score
+// within min and max, otherwise a raw score that is already a percentage.
+func percentScore(r *xapi.Result) (int, bool) {
+	if r == nil || r.Score == nil {
+		return 0, false
+	}
+	sc := r.Score
+	var pct float64
+	switch {
+	case sc.Scaled != nil:
+		pct = *sc.Scaled * 100
+	case sc.Raw != nil && sc.Max != nil && *sc.Max > scoreMin(sc.Min):
+		pct = (*sc.Raw - scoreMin(sc.Min)) / (*sc.Max - scoreMin(sc.Min)) * 100
+	case sc.Raw != nil && *sc.Raw >= 0 && *sc.Raw <= 100:
+		pct = *sc.Raw
+	default:
+		return 0, false
+	}
+	return int(math.Round(math.Max(0, math.Min(100, pct)))), true
+}
+
+func scoreMin(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
@@ -0,0 +1,434 @@
+package xapi
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/realtime"
+	"training-portal/internal/domain/user"
+	"training-portal/internal/domain/xapi"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidStatement = errors.New("invalid statement")
+	ErrInvalidRequest   = errors.New("invalid xAPI request")
+	ErrConflict         = errors.New("a different statement with this id is already stored")
+	ErrForbidden        = errors.New("not allowed to access these xAPI records")
+	ErrNotFound         = errors.New("not found")
+	ErrPrecondition     = errors.New("document has changed")
+	ErrConcurrency      = errors.New("document exists; send If-Match or If-None-Match")
+	ErrTooLarge         = errors.New("document too large")
+)
+
+// Repository is the persistence contract of the LRS.
+type Repository interface {
+	// FindStatements returns the stored statements with the given IDs,
+	// voided ones included.
+	FindStatements(ids []string) ([]*xapi.Record, error)
+	// SaveStatements stores the statements in one transaction, skipping IDs
+	// that are already stored, and marks the statements voided by voiding
+	// statements, whichever arrives first. It returns the records it stored.
+	SaveStatements(records []*xapi.Record) ([]*xapi.Record, error)
+	// QueryStatements returns up to filter.Limit statements that have not
+	// been voided, in storage order.
+	QueryStatements(filter xapi.StatementFilter) ([]*xapi.Record, error)
+
+	FindDocument(key xapi.DocumentKey) (*xapi.Document, error)
+	// ListDocumentIDs returns the IDs of documents under the key updated
+	// after since.
+	ListDocumentIDs(key xapi.DocumentKey, since time.Time) ([]string, error)
+	SaveDocument(d *xapi.Document) error
+	// DeleteDocuments deletes the document, or every document under the key
+	// when DocID is empty.
+	DeleteDocuments(key xapi.DocumentKey) error
+}
+
+// ProgressRepository records course progress reported through statements.
+type ProgressRepository interface {
+	// CompleteModule adds the module to the learner's completed modules and
+	// reports whether it was not there yet.
+	CompleteModule(userID, courseID, moduleID string, at int64) (bool, error)
+	// RecordQuizScore keeps the learner's best score, 0 to 100, for the quiz.
+	RecordQuizScore(userID, courseID, quizID string, score int, at int64) error
+	// QuizCourse returns the course a quiz belongs to, or "" if there is no
+	// such quiz.
+	QuizCourse(quizID string) (string, error)
+}
+
+// UserFinder resolves actors to portal users.
+type UserFinder interface {
+	FindByID(id string) (*user.User, error)
+	FindByEmail(email string) (*user.User, error)
+}
+
+// ModuleFinder looks up the course of a module.
+type ModuleFinder interface {
+	FindByID(id string) (*course.Module, error)
+}
+
+// EnrollmentFinder looks up the enrollment progress from statements is
+// applied under.
+type EnrollmentFinder interface {
+	FindByUserAndCourse(userID, courseID string) (*enrollment.Enrollment, error)
+}
+
+// Recorder records analytics events.
+type Recorder interface {
+	Record(userID, eventType string, metadata map[string]interface{})
+}
+
+// Publisher pushes an event to a user's realtime stream.
+type Publisher interface {
+	Publish(userID string, t realtime.EventType, data interface{}) error
+}
+
+// Actor is who is calling the LRS: a portal user, or an external tool
+// authenticated with client credentials.
+type Actor struct {
+	UserID string
+	Client string // name of the configured client, "" for users
+	Staff  bool   // admins, trainers and clients may read and write any learner's records
+}
+
+// Service is the portal's Learning Record Store. It stores statements from
+// third-party content, applies those about the portal's modules and quizzes
+// to learners' progress, and emits statements for what happens in the portal
+// itself.
+//
+// Portal activities are identified by IRIs under BaseURL:
+// <BaseURL>/xapi/activities/course/<id>, /module/<id> and /quiz/<id>. Portal
+// users are identified by an account on BaseURL named by their user ID, or by
+// their email address.
+type Service struct {
+	Repo        Repository
+	Progress    ProgressRepository
+	Users       UserFinder
+	Modules     ModuleFinder
+	Enrollments EnrollmentFinder
+	Analytics   Recorder  // optional; told about progress reported through statements
+	Events      Publisher // optional; tells learners about quiz scores reported through statements
+	BaseURL     string
+	Name        string // name of the portal in the authority of its statements
+
+	MaxBatch    int // statements per request; defaults to 100
+	MaxDocument int // bytes per state or profile document; defaults to 1 MiB
+	MaxPage     int // statements per query page; defaults to 100
+}
+
+// Store validates and stores statements sent by content, and returns their
+// IDs in order. Learners may only store statements about themselves. A batch
+// is stored entirely or not at all; resending a stored statement is a no-op.
+func (s *Service) Store(actor Actor, statements []*xapi.Statement, now time.Time) ([]string, error) {
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("%w: no statements", ErrInvalidStatement)
+	}
+	if max := positive(s.MaxBatch, 100); len(statements) > max {
+		return nil, fmt.Errorf("%w: at most %d statements per request", ErrInvalidStatement, max)
+	}
+	var self *user.User
+	if !actor.Staff {
+		var err error
+		if self, err = s.user(actor.UserID); err != nil {
+			return nil, err
+		}
+		if self == nil {
+			return nil, ErrForbidden
+		}
+	}
+	authority, err := s.authority(actor)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := xapi.FormatTime(now)
+	seen := map[string]bool{}
+	var given []string
+	for i, st := range statements {
+		if st == nil {
+			return nil, fmt.Errorf("%w: statement %d is empty", ErrInvalidStatement, i)
+		}
+		if err := st.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: statement %d: %v", ErrInvalidStatement, i, err)
+		}
+		if self != nil && !s.identifies(st.Actor, self) {
+			return nil, fmt.Errorf("%w: statement %d is not about you", ErrForbidden, i)
+		}
+		if st.ID == "" {
+			st.ID = uuid.New().String()
+		} else {
+			st.ID = strings.ToLower(st.ID)
+			given = append(given, st.ID)
+		}
+		if seen[st.ID] {
+			return nil, fmt.Errorf("%w: id %s appears twice", ErrInvalidStatement, st.ID)
+		}
+		seen[st.ID] = true
+	}
+
+	existing, err := s.Repo.FindStatements(given)
+	if err != nil {
+		return nil, err
+	}
+	already := map[string]*xapi.Statement{}
+	for _, r := range existing {
+		already[r.Statement.ID] = r.Statement
+	}
+	ids := make([]string, len(statements))
+	var records []*xapi.Record
+	for i, st := range statements {
+		ids[i] = st.ID
+		if prev, ok := already[st.ID]; ok {
+			if !xapi.Equivalent(prev, st) {
+				return nil, fmt.Errorf("%w: %s", ErrConflict, st.ID)
+			}
+			continue
+		}
+		st.Stored = stored
+		if st.Timestamp == "" {
+			st.Timestamp = stored
+		}
+		if st.Version == "" {
+			st.Version = "1.0.0"
+		}
+		st.Authority = authority
+		records = append(records, &xapi.Record{Statement: st})
+	}
+	if err := s.checkVoiding(self, records); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if self != nil {
+			r.UserID = self.ID
+		} else if r.UserID, err = s.resolve(r.Statement.Actor); err != nil {
+			return nil, err
+		}
+	}
+	if len(records) == 0 {
+		return ids, nil
+	}
+	saved, err := s.Repo.SaveStatements(records)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range saved {
+		s.applyProgress(actor, r)
+	}
+	return ids, nil
+}
+
+// checkVoiding rejects statements that void a voiding statement, which the
+// specification does not allow, or a statement the portal recorded. A learner,
+// self, may only void their own stored statements.
+func (s *Service) checkVoiding(self *user.User, records []*xapi.Record) error {
+	var targets []string
+	batch := map[string]*xapi.Record{}
+	for _, r := range records {
+		batch[r.Statement.ID] = r
+		if id := r.Statement.Voids(); id != "" {
+			targets = append(targets, strings.ToLower(id))
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	found, err := s.Repo.FindStatements(targets)
+	if err != nil {
+		return err
+	}
+	stored := map[string]bool{}
+	for _, r := range found {
+		batch[r.Statement.ID] = r
+		stored[r.Statement.ID] = true
+	}
+	for _, id := range targets {
+		t := batch[id]
+		if t == nil {
+			if self != nil {
+				return fmt.Errorf("%w: statement %s is not yours to void", ErrForbidden, id)
+			}
+			continue
+		}
+		if t.Statement.Voids() != "" {
+			return fmt.Errorf("%w: a voiding statement cannot be voided", ErrInvalidStatement)
+		}
+		if s.fromPortal(t.Statement) {
+			return fmt.Errorf("%w: statements recorded by the portal cannot be voided", ErrForbidden)
+		}
+		// A learner's own batch only has statements about them.
+		if self != nil && stored[id] && t.UserID != self.ID {
+			return fmt.Errorf("%w: statement %s is not yours to void", ErrForbidden, id)
+		}
+	}
+	return nil
+}
+
+// fromPortal reports whether the portal itself vouches for the statement.
+func (s *Service) fromPortal(st *xapi.Statement) bool {
+	a, p := st.Authority, s.portal()
+	return a != nil && a.Account != nil && *a.Account == *p.Account
+}
+
+// Statement returns a stored statement. With voided set it returns only a
+// voided statement, otherwise only one that has not been voided.
+func (s *Service) Statement(actor Actor, id string, voided bool) (*xapi.Statement, error) {
+	if uuid.Validate(id) != nil {
+		return nil, fmt.Errorf("%w: statementId must be a UUID", ErrInvalidRequest)
+	}
+	found, err := s.Repo.FindStatements([]string{strings.ToLower(id)})
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 || found[0].Voided != voided {
+		return nil, ErrNotFound
+	}
+	if !actor.Staff && found[0].UserID != actor.UserID {
+		return nil, ErrNotFound
+	}
+	return found[0].Statement, nil
+}
+
+// Query returns a page of statements matching the filter and the cursor of
+// the next page, or 0 on the last one. Learners only see their own
+// statements.
+func (s *Service) Query(actor Actor, filter xapi.StatementFilter) ([]*xapi.Statement, int64, error) {
+	if !actor.Staff {
+		if actor.UserID == "" {
+			return nil, 0, ErrForbidden
+		}
+		filter.UserID = actor.UserID
+	}
+	if filter.Registration != "" && uuid.Validate(filter.Registration) != nil {
+		return nil, 0, fmt.Errorf("%w: registration must be a UUID", ErrInvalidRequest)
+	}
+	max := positive(s.MaxPage, 100)
+	if filter.Limit <= 0 || filter.Limit > max {
+		filter.Limit = max
+	}
+	page := filter.Limit
+	filter.Limit++ // one more tells whether there is a next page
+	records, err := s.Repo.QueryStatements(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	var next int64
+	if len(records) > page {
+		records = records[:page]
+		next = records[page-1].Seq
+	}
+	out := make([]*xapi.Statement, len(records))
+	for i, r := range records {
+		out[i] = r.Statement
+	}
+	return out, next, nil
+}
+
+// authority is the agent vouching for statements stored by the actor.
+func (s *Service) authority(actor Actor) (*xapi.Agent, error) {
+	if actor.Client != "" {
+		return &xapi.Agent{Name: actor.Client, Account: &xapi.Account{HomePage: s.BaseURL, Name: "client:" + actor.Client}}, nil
+	}
+	u, err := s.user(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrForbidden
+	}
+	return s.agent(u), nil
+}
+
+// agent is how statements identify a portal user.
+func (s *Service) agent(u *user.User) *xapi.Agent {
+	return &xapi.Agent{Name: u.Name, Account: &xapi.Account{HomePage: s.BaseURL, Name: u.ID}}
+}
+
+// portal is the agent vouching for statements the portal emits.
+func (s *Service) portal() *xapi.Agent {
+	name := s.Name
+	if name == "" {
+		name = "Training Portal"
+	}
+	return &xapi.Agent{Name: name, Account: &xapi.Account{HomePage: s.BaseURL, Name: "portal"}}
+}
+
+// identifies reports whether the agent is the user, by portal account, email
+// address or its hash.
+func (s *Service) identifies(a *xapi.Agent, u *user.User) bool {
+	if a == nil || a.ObjectType == xapi.ObjectGroup {
+		return false
+	}
+	switch {
+	case a.Account != nil:
+		return a.Account.HomePage == s.BaseURL && a.Account.Name == u.ID
+	case a.Mbox != "":
+		return u.Email != "" && strings.EqualFold(strings.TrimPrefix(a.Mbox, "mailto:"), u.Email)
+	case a.MboxSHA1Sum != "":
+		return u.Email != "" && strings.EqualFold(a.MboxSHA1Sum, xapi.MboxSHA1(strings.ToLower(u.Email)))
+	}
+	return false
+}
+
+// resolve returns the ID of the portal user the agent is, or "".
+func (s *Service) resolve(a *xapi.Agent) (string, error) {
+	if a == nil || a.ObjectType == xapi.ObjectGroup {
+		return "", nil
+	}
+	var u *user.User
+	var err error
+	switch {
+	case a.Account != nil && a.Account.HomePage == s.BaseURL:
+		if uuid.Validate(a.Account.Name) != nil {
+			return "", nil
+		}
+		u, err = s.Users.FindByID(a.Account.Name)
+	case a.Mbox != "":
+		u, err = s.Users.FindByEmail(strings.TrimPrefix(a.Mbox, "mailto:"))
+	}
+	if err != nil || u == nil {
+		return "", err
+	}
+	return u.ID, nil
+}
+
+func (s *Service) user(id string) (*user.User, error) {
+	if id == "" {
+		return nil, nil
+	}
+	return s.Users.FindByID(id)
+}
+
+// ActivityIRI returns the IRI of a portal course, module or quiz.
+func (s *Service) ActivityIRI(kind, id string) string {
+	return strings.TrimRight(s.BaseURL, "/") + "/xapi/activities/" + kind + "/" + id
+}
+
+// portalActivity splits a portal activity IRI into its kind and ID.
+func (s *Service) portalActivity(iri string) (kind, id string, ok bool) {
+	rest, found := strings.CutPrefix(iri, strings.TrimRight(s.BaseURL, "/")+"/xapi/activities/")
+	if !found || s.BaseURL == "" {
+		return "", "", false
+	}
+	kind, id, found = strings.Cut(rest, "/")
+	if !found || uuid.Validate(id) != nil {
+		return "", "", false
+	}
+	return kind, id, true
+}
+
+func logf(format string, args ...interface{}) {
+	log.Printf("xapi: "+format, args...)
+}
+
+func positive(n, fallback int) int {
+	if n <= 0 {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,510 @@
+package xapi
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"training-portal/internal/domain/analytics"
+	"training-portal/internal/domain/course"
+	"training-portal/internal/domain/enrollment"
+	"training-portal/internal/domain/user"
+	"training-portal/internal/domain/xapi"
+)
+
+const (
+	base     = "https://portal.example.com"
+	learner  = "8b1f0c52-3d4e-4a6b-9c7d-1e2f3a4b5c6d"
+	other    = "9c2a1d63-4e5f-4b7c-8d9e-2f3a4b5c6d7e"
+	courseID = "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+	moduleID = "2b3c4d5e-6f7a-4b8c-9d0e-1f2a3b4c5d6e"
+	quizID   = "3c4d5e6f-7a8b-4c9d-8e1f-2a3b4c5d6e7f"
+	lessonID = "5e6f7a8b-9c0d-4e1f-8a2b-3c4d5e6f7a8b" // a module that is not launched content
+)
+
+type mockRepo struct {
+	records []*xapi.Record
+	docs    map[xapi.DocumentKey]*xapi.Document
+}
+
+func (m *mockRepo) find(id string) *xapi.Record {
+	for _, r := range m.records {
+		if r.Statement.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+func (m *mockRepo) FindStatements(ids []string) ([]*xapi.Record, error) {
+	var out []*xapi.Record
+	for _, id := range ids {
+		if r := m.find(id); r != nil {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockRepo) SaveStatements(records []*xapi.Record) ([]*xapi.Record, error) {
+	var saved []*xapi.Record
+	for _, r := range records {
+		if m.find(r.Statement.ID) != nil {
+			continue
+		}
+		r.Seq = int64(len(m.records) + 1)
+		m.records = append(m.records, r)
+		saved = append(saved, r)
+	}
+	for _, r := range m.records {
+		if target := m.find(r.Statement.Voids()); target != nil && target.Statement.Voids() == "" {
+			target.Voided = true
+		}
+	}
+	return saved, nil
+}
+
+func (m *mockRepo) QueryStatements(filter xapi.StatementFilter) ([]*xapi.Record, error) {
+	var out []*xapi.Record
+	for i := len(m.records) - 1; i >= 0 && len(out) < filter.Limit; i-- {
+		r := m.records[i]
+		if r.Voided || (filter.UserID != "" && r.UserID != filter.UserID) || (filter.After > 0 && r.Seq >= filter.After) {
+			continue
+		}
+		if filter.Verb != "" && r.Statement.Verb.ID != filter.Verb {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (m *mockRepo) FindDocument(key xapi.DocumentKey) (*xapi.Document, error) {
+	return m.docs[key], nil
+}
+
+func (m *mockRepo) ListDocumentIDs(key xapi.DocumentKey, since time.Time) ([]string, error) {
+	var ids []string
+	for k := range m.docs {
+		id := k.DocID
+		k.DocID = ""
+		if k == key {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *mockRepo) SaveDocument(d *xapi.Document) error {
+	m.docs[d.DocumentKey] = d
+	return nil
+}
+
+func (m *mockRepo) DeleteDocuments(key xapi.DocumentKey) error {
+	delete(m.docs, key)
+	return nil
+}
+
+type mockProgress struct {
+	modules map[string]bool
+	quizzes map[string]int
+}
+
+func (m *mockProgress) CompleteModule(userID, courseID, moduleID string, at int64) (bool, error) {
+	key := userID + "/" + courseID + "/" + moduleID
+	added := !m.modules[key]
+	m.modules[key] = true
+	return added, nil
+}
+
+func (m *mockProgress) RecordQuizScore(userID, courseID, quizID string, score int, at int64) error {
+	key := userID + "/" + courseID + "/" + quizID
+	if score > m.quizzes[key] {
+		m.quizzes[key] = score
+	}
+	return nil
+}
+
+func (m *mockProgress) QuizCourse(id string) (string, error) {
+	if id == quizID {
+		return courseID, nil
+	}
+	return "", nil
+}
+
+type mockUsers map[string]*user.User
+
+func (m mockUsers) FindByID(id string) (*user.User, error) {
+	return m[id], nil
+}
+
+func (m mockUsers) FindByEmail(email string) (*user.User, error) {
+	for _, u := range m {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+type mockModules struct{}
+
+func (mockModules) FindByID(id string) (*course.Module, error) {
+	switch id {
+	case moduleID:
+		return &course.Module{ID: moduleID, CourseID: courseID, ContentType: ContentType}, nil
+	case lessonID:
+		return &course.Module{ID: lessonID, CourseID: courseID, ContentType: "video"}, nil
+	}
+	return nil, nil
+}
+
+// mockEnrollments holds the status of each user's enrollment in courseID.
+type mockEnrollments map[string]enrollment.Status
+
+func (m mockEnrollments) FindByUserAndCourse(userID, course string) (*enrollment.Enrollment, error) {
+	status, ok := m[userID]
+	if !ok || course != courseID {
+		return nil, nil
+	}
+	return &enrollment.Enrollment{UserID: userID, CourseID: course, Status: status}, nil
+}
+
+type recordedEvent struct {
+	userID, eventType string
+	metadata          map[string]interface{}
+}
+
+type mockRecorder struct {
+	events []recordedEvent
+}
+
+func (m *mockRecorder) Record(userID, eventType string, metadata map[string]interface{}) {
+	m.events = append(m.events, recordedEvent{userID, eventType, metadata})
+}
+
+func newTestService() (*Service, *mockRepo, *mockProgress, *mockRecorder) {
+	repo := &mockRepo{docs: map[xapi.DocumentKey]*xapi.Document{}}
+	progress := &mockProgress{modules: map[string]bool{}, quizzes: map[string]int{}}
+	rec := &mockRecorder{}
+	users := mockUsers{
+		learner: {ID: learner, Name: "Ann", Email: "ann@example.com"},
+		other:   {ID: other, Name: "Bob", Email: "bob@example.com"},
+	}
+	enrollments := mockEnrollments{learner: enrollment.StatusActive, other: enrollment.StatusCompleted}
+	return &Service{
+		Repo: repo, Progress: progress, Users: users, Modules: mockModules{}, Enrollments: enrollments, Analytics: rec, BaseURL: base,
+	}, repo, progress, rec
+}
+
+func statement(actor *xapi.Agent, verbID, objectID string) *xapi.Statement {
+	return &xapi.Statement{Actor: actor, Verb: &xapi.Verb{ID: verbID}, Object: &xapi.Object{ID: objectID}}
+}
+
+func ann() *xapi.Agent {
+	return &xapi.Agent{Mbox: "mailto:ann@example.com"}
+}
+
+func TestStore_LearnerProgress(t *testing.T) {
+	svc, repo, progress, rec := newTestService()
+	actor := Actor{UserID: learner}
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	bob := &xapi.Agent{Account: &xapi.Account{HomePage: base, Name: other}}
+	if _, err := svc.Store(actor, []*xapi.Statement{statement(bob, xapi.VerbCompleted, base+"/xapi/activities/module/"+moduleID)}, now); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("storing a statement about someone else: error = %v, want ErrForbidden", err)
+	}
+
+	ids, err := svc.Store(actor, []*xapi.Statement{statement(ann(), xapi.VerbCompleted, base+"/xapi/activities/module/"+moduleID)}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := repo.find(ids[0])
+	if r == nil || r.UserID != learner {
+		t.Fatalf("stored record = %+v, want one for the learner", r)
+	}
+	if r.Statement.Authority == nil || r.Statement.Authority.Account.Name != learner || r.Statement.Stored != "2024-03-01T10:00:00.000Z" {
+		t.Errorf("authority = %+v, stored = %q", r.Statement.Authority, r.Statement.Stored)
+	}
+	if !progress.modules[learner+"/"+courseID+"/"+moduleID] {
+		t.Error("module was not marked complete")
+	}
+	if len(rec.events) != 1 || rec.events[0].eventType != analytics.EventModuleCompleted || rec.events[0].metadata[analytics.MetaStatementID] != ids[0] {
+		t.Errorf("analytics events = %+v", rec.events)
+	}
+
+	// The same completion again is new progress for nobody.
+	if _, err := svc.Store(actor, []*xapi.Statement{statement(ann(), xapi.VerbCompleted, base+"/xapi/activities/module/"+moduleID)}, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.events) != 1 {
+		t.Errorf("repeated completion recorded %d events, want 1", len(rec.events))
+	}
+
+	// Modules that are not launched content only trust configured clients.
+	if _, err := svc.Store(actor, []*xapi.Statement{statement(ann(), xapi.VerbCompleted, base+"/xapi/activities/module/"+lessonID)}, now); err != nil {
+		t.Fatal(err)
+	}
+	if progress.modules[learner+"/"+courseID+"/"+lessonID] {
+		t.Error("a learner completed a video module through a statement")
+	}
+
+	// Scores of the portal's quizzes are only taken from configured clients.
+	scaled := 0.8
+	quiz := statement(ann(), xapi.VerbPassed, base+"/xapi/activities/quiz/"+quizID)
+	quiz.Result = &xapi.Result{Score: &xapi.Score{Scaled: &scaled}}
+	if _, err := svc.Store(actor, []*xapi.Statement{quiz}, now); err != nil {
+		t.Fatal(err)
+	}
+	if got := progress.quizzes[learner+"/"+courseID+"/"+quizID]; got != 0 {
+		t.Errorf("quiz score from the learner's token = %d, want none", got)
+	}
+	quiz = statement(ann(), xapi.VerbPassed, base+"/xapi/activities/quiz/"+quizID)
+	quiz.Result = &xapi.Result{Score: &xapi.Score{Scaled: &scaled}}
+	if _, err := svc.Store(Actor{Client: "tool", Staff: true}, []*xapi.Statement{quiz}, now); err != nil {
+		t.Fatal(err)
+	}
+	if got := progress.quizzes[learner+"/"+courseID+"/"+quizID]; got != 80 {
+		t.Errorf("quiz score = %d, want 80", got)
+	}
+	if last := rec.events[len(rec.events)-1]; last.eventType != analytics.EventQuizSubmitted || last.metadata[analytics.MetaPassed] != true {
+		t.Errorf("quiz event = %+v", last)
+	}
+}
+
+func TestStore_ConflictsAndVoiding(t *testing.T) {
+	svc, repo, _, _ := newTestService()
+	actor := Actor{Client: "tool", Staff: true}
+	now := time.Now()
+
+	st := statement(ann(), "http://example.com/did", "http://example.com/a")
+	st.ID = "0f4c2b1e-8f0a-4c9b-9a4e-0d2d6b7c1a11"
+	if _, err := svc.Store(actor, []*xapi.Statement{st}, now); err != nil {
+		t.Fatal(err)
+	}
+	if repo.records[0].UserID != learner {
+		t.Errorf("actor resolved to %q, want the learner by email", repo.records[0].UserID)
+	}
+
+	again := statement(ann(), "http://example.com/did", "http://example.com/a")
+	again.ID = st.ID
+	if _, err := svc.Store(actor, []*xapi.Statement{again}, now.Add(time.Minute)); err != nil {
+		t.Errorf("resending the same statement: error = %v", err)
+	}
+	changed := statement(ann(), "http://example.com/other", "http://example.com/a")
+	changed.ID = st.ID
+	if _, err := svc.Store(actor, []*xapi.Statement{changed}, now); !errors.Is(err, ErrConflict) {
+		t.Errorf("changing a stored statement: error = %v, want ErrConflict", err)
+	}
+
+	void := &xapi.Statement{Actor: ann(), Verb: &xapi.Verb{ID: xapi.VerbVoided}, Object: &xapi.Object{ObjectType: xapi.ObjectStatementRef, ID: st.ID}}
+	ids, err := svc.Store(actor, []*xapi.Statement{void}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Statement(actor, st.ID, false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("voided statement by statementId: error = %v, want ErrNotFound", err)
+	}
+	if _, err := svc.Statement(actor, st.ID, true); err != nil {
+		t.Errorf("voided statement by voidedStatementId: error = %v", err)
+	}
+	voidVoid := &xapi.Statement{Actor: ann(), Verb: &xapi.Verb{ID: xapi.VerbVoided}, Object: &xapi.Object{ObjectType: xapi.ObjectStatementRef, ID: ids[0]}}
+	if _, err := svc.Store(actor, []*xapi.Statement{voidVoid}, now); !errors.Is(err, ErrInvalidStatement) {
+		t.Errorf("voiding a voiding statement: error = %v, want ErrInvalidStatement", err)
+	}
+}
+
+func TestStore_ProgressNeedsActiveEnrollment(t *testing.T) {
+	svc, _, progress, _ := newTestService()
+	bob := &xapi.Agent{Mbox: "mailto:bob@example.com"}
+
+	// Bob's enrollment is completed, not active.
+	if _, err := svc.Store(Actor{UserID: other}, []*xapi.Statement{statement(bob, xapi.VerbCompleted, base+"/xapi/activities/module/"+moduleID)}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if progress.modules[other+"/"+courseID+"/"+moduleID] {
+		t.Error("progress applied without an active enrollment")
+	}
+}
+
+func TestStore_VoidingRestrictions(t *testing.T) {
+	svc, repo, _, _ := newTestService()
+	client := Actor{Client: "tool", Staff: true}
+	now := time.Now()
+	voiding := func(id string) *xapi.Statement {
+		return &xapi.Statement{Actor: ann(), Verb: &xapi.Verb{ID: xapi.VerbVoided}, Object: &xapi.Object{ObjectType: xapi.ObjectStatementRef, ID: id}}
+	}
+
+	bobs := statement(&xapi.Agent{Mbox: "mailto:bob@example.com"}, "http://example.com/did", "http://example.com/a")
+	ids, err := svc.Store(client, []*xapi.Statement{bobs}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Store(Actor{UserID: learner}, []*xapi.Statement{voiding(ids[0])}, now); !errors.Is(err, ErrForbidden) {
+		t.Errorf("a learner voiding someone else's statement: error = %v, want ErrForbidden", err)
+	}
+	if _, err := svc.Store(Actor{UserID: learner}, []*xapi.Statement{voiding("6f7a8b9c-0d1e-4f2a-9b3c-4d5e6f7a8b9c")}, now); !errors.Is(err, ErrForbidden) {
+		t.Errorf("a learner voiding an unknown statement: error = %v, want ErrForbidden", err)
+	}
+
+	svc.EventsRecorded([]*analytics.AnalyticsEvent{{ID: "e1", UserID: learner, EventType: analytics.EventQuizSubmitted, Timestamp: 1709287200, Metadata: map[string]interface{}{
+		analytics.MetaCourseID: courseID, analytics.MetaQuizID: quizID, analytics.MetaScore: 90.0,
+	}}})
+	emitted := repo.records[len(repo.records)-1].Statement.ID
+	for _, actor := range []Actor{{UserID: learner}, client} {
+		if _, err := svc.Store(actor, []*xapi.Statement{voiding(emitted)}, now); !errors.Is(err, ErrForbidden) {
+			t.Errorf("voiding a statement of the portal as %+v: error = %v, want ErrForbidden", actor, err)
+		}
+	}
+
+	own := statement(ann(), "http://example.com/did", "http://example.com/a")
+	ids, err = svc.Store(Actor{UserID: learner}, []*xapi.Statement{own}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Store(Actor{UserID: learner}, []*xapi.Statement{voiding(ids[0])}, now); err != nil {
+		t.Errorf("a learner voiding their own statement: error = %v", err)
+	}
+}
+
+func TestQuery_LearnersSeeOwnStatements(t *testing.T) {
+	svc, _, _, _ := newTestService()
+	staff := Actor{Client: "tool", Staff: true}
+	bob := &xapi.Agent{Mbox: "mailto:bob@example.com"}
+	var batch []*xapi.Statement
+	for i := 0; i < 3; i++ {
+		batch = append(batch, statement(ann(), "http://example.com/did", "http://example.com/a"), statement(bob, "http://example.com/did", "http://example.com/a"))
+	}
+	if _, err := svc.Store(staff, batch, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	page, next, err := svc.Query(Actor{UserID: learner}, xapi.StatementFilter{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || next == 0 {
+		t.Fatalf("first page: %d statements, next %d", len(page), next)
+	}
+	rest, next, err := svc.Query(Actor{UserID: learner}, xapi.StatementFilter{Limit: 2, After: next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 1 || next != 0 {
+		t.Fatalf("second page: %d statements, next %d", len(rest), next)
+	}
+	for _, st := range append(page, rest...) {
+		if st.Actor.Mbox != "mailto:ann@example.com" {
+			t.Errorf("learner saw a statement about %s", st.Actor.Mbox)
+		}
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	svc, _, _, _ := newTestService()
+	actor := Actor{UserID: learner}
+	now := time.Now()
+	key := xapi.DocumentKey{Kind: xapi.DocumentState, ActivityID: "http://example.com/a", Agent: ann().IFI(), DocID: "bookmark"}
+
+	if err := svc.SaveDocument(actor, &xapi.Document{DocumentKey: key, Content: []byte(`{"page":1,"theme":"dark"}`), ContentType: "application/json"}, Precondition{}, false, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SaveDocument(actor, &xapi.Document{DocumentKey: key, Content: []byte(`{"page":2}`), ContentType: "application/json"}, Precondition{}, true, now); err != nil {
+		t.Fatal(err)
+	}
+	d, err := svc.Document(actor, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(d.Content) != `{"page":2,"theme":"dark"}` {
+		t.Errorf("merged document = %s", d.Content)
+	}
+	if err := svc.SaveDocument(actor, &xapi.Document{DocumentKey: key, Content: []byte("text"), ContentType: "text/plain"}, Precondition{}, true, now); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("merging text: error = %v, want ErrInvalidRequest", err)
+	}
+
+	bobs := key
+	bobs.Agent = (&xapi.Agent{Mbox: "mailto:bob@example.com"}).IFI()
+	if _, err := svc.Document(actor, bobs); !errors.Is(err, ErrForbidden) {
+		t.Errorf("reading someone else's state: error = %v, want ErrForbidden", err)
+	}
+
+	profile := xapi.DocumentKey{Kind: xapi.DocumentActivityProfile, ActivityID: "http://example.com/a", DocID: "settings"}
+	staff := Actor{Client: "tool", Staff: true}
+	if err := svc.SaveDocument(actor, &xapi.Document{DocumentKey: profile, Content: []byte(`{}`)}, Precondition{}, false, now); !errors.Is(err, ErrForbidden) {
+		t.Errorf("learner writing a profile: error = %v, want ErrForbidden", err)
+	}
+	if err := svc.SaveDocument(staff, &xapi.Document{DocumentKey: profile, Content: []byte(`{}`)}, Precondition{IfNoneMatch: "*"}, false, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SaveDocument(staff, &xapi.Document{DocumentKey: profile, Content: []byte(`{"a":1}`)}, Precondition{}, false, now); !errors.Is(err, ErrConcurrency) {
+		t.Errorf("replacing a profile without a precondition: error = %v, want ErrConcurrency", err)
+	}
+	if err := svc.SaveDocument(staff, &xapi.Document{DocumentKey: profile, Content: []byte(`{"a":1}`)}, Precondition{IfMatch: "stale"}, false, now); !errors.Is(err, ErrPrecondition) {
+		t.Errorf("replacing a profile with a stale ETag: error = %v, want ErrPrecondition", err)
+	}
+	current, _ := svc.Document(actor, profile)
+	if err := svc.SaveDocument(staff, &xapi.Document{DocumentKey: profile, Content: []byte(`{"a":1}`)}, Precondition{IfMatch: current.ETag}, false, now); err != nil {
+		t.Errorf("replacing a profile with its ETag: error = %v", err)
+	}
+}
+
+func TestEmit(t *testing.T) {
+	svc, repo, _, _ := newTestService()
+	events := []*analytics.AnalyticsEvent{
+		{ID: "e1", UserID: learner, EventType: analytics.EventQuizSubmitted, Timestamp: 1709287200, Metadata: map[string]interface{}{
+			analytics.MetaCourseID: courseID, analytics.MetaQuizID: quizID, analytics.MetaScore: 90.0, analytics.MetaPassed: true,
+		}},
+		{ID: "e2", UserID: learner, EventType: analytics.EventModuleCompleted, Metadata: map[string]interface{}{
+			analytics.MetaCourseID: courseID, analytics.MetaModuleID: moduleID, analytics.MetaStatementID: "from-a-statement",
+		}},
+		{ID: "e3", UserID: learner, EventType: analytics.EventLogin},
+	}
+	svc.EventsRecorded(events)
+	svc.EventsRecorded(events[:1]) // a retried batch
+	if len(repo.records) != 1 {
+		t.Fatalf("emitted %d statements, want 1", len(repo.records))
+	}
+	st := repo.records[0].Statement
+	if st.Verb.ID != xapi.VerbPassed || st.Object.ID != base+"/xapi/activities/quiz/"+quizID || *st.Result.Score.Scaled != 0.9 {
+		t.Errorf("quiz statement = %+v", st)
+	}
+	if st.Actor.Account.Name != learner || st.Authority.Account.Name != "portal" || st.Timestamp != "2024-03-01T10:00:00.000Z" {
+		t.Errorf("actor %+v, authority %+v, timestamp %s", st.Actor, st.Authority, st.Timestamp)
+	}
+	if err := st.Validate(); err != nil {
+		t.Errorf("emitted statement is invalid: %v", err)
+	}
+
+	e := &enrollment.Enrollment{ID: "4d5e6f7a-8b9c-4d0e-9f1a-2b3c4d5e6f7a", UserID: learner, CourseID: courseID}
+	svc.EnrollmentChanged(e, &enrollment.StatusChange{ID: "c1", ToStatus: enrollment.StatusActive, ChangedAt: 1709287200})
+	svc.EnrollmentChanged(e, &enrollment.StatusChange{ID: "c2", ToStatus: enrollment.StatusDropped, ChangedAt: 1709287200})
+	if len(repo.records) != 2 {
+		t.Fatalf("emitted %d statements, want 2", len(repo.records))
+	}
+	if reg := repo.records[1].Statement; reg.Verb.ID != xapi.VerbRegistered || reg.Context.Registration != e.ID {
+		t.Errorf("enrollment statement = %+v", reg)
+	}
+}
+
+func TestPercentScore(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		score *xapi.Score
+		want  int
+		ok    bool
+	}{
+		{&xapi.Score{Scaled: f(0.755)}, 76, true},
+		{&xapi.Score{Raw: f(15), Min: f(0), Max: f(20)}, 75, true},
+		{&xapi.Score{Raw: f(5), Min: f(5), Max: f(10)}, 0, true},
+		{&xapi.Score{Raw: f(64)}, 64, true},
+		{&xapi.Score{Raw: f(640)}, 0, false},
+		{&xapi.Score{Scaled: f(-0.5)}, 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := percentScore(&xapi.Result{Score: tt.score})
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("percentScore(%+v) = %d, %v, want %d, %v", tt.score, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+ALTER TABLE enrollments
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active',
+    ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ADD CONSTRAINT uq_enrollments_user_course UNIQUE (user_id, course_id);
+
+CREATE TABLE enrollment_history (
+                                    id UUID PRIMARY KEY,
+                                    enrollment_id UUID REFERENCES enrollments(id) ON DELETE CASCADE,
+                                    from_status VARCHAR(20),
+                                    to_status VARCHAR(20) NOT NULL,
+                                    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+                                    reason TEXT,
+                                    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_enrollment_history_enrollment ON enrollment_history(enrollment_id);
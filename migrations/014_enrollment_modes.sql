@@ -0,0 +1,12 @@
+ALTER TABLE courses
+    ADD COLUMN enrollment_mode VARCHAR(20) NOT NULL DEFAULT 'open',
+    ADD COLUMN seat_capacity INTEGER NOT NULL DEFAULT 0;
+
+-- status_seq increases on every status change so queues (approvals, waitlists)
+-- are served strictly in arrival order.
+CREATE SEQUENCE enrollment_status_seq;
+
+ALTER TABLE enrollments
+    ADD COLUMN status_seq BIGINT NOT NULL DEFAULT nextval('enrollment_status_seq');
+
+CREATE INDEX idx_enrollments_course_status ON enrollments(course_id, status, status_seq);
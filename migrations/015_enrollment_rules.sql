@@ -0,0 +1,24 @@
+ALTER TABLE users
+    ADD COLUMN department VARCHAR(100);
+
+CREATE TABLE enrollment_rules (
+                                  id UUID PRIMARY KEY,
+                                  name VARCHAR(255) NOT NULL,
+                                  field VARCHAR(50) NOT NULL,
+                                  value VARCHAR(255) NOT NULL,
+                                  course_id UUID REFERENCES courses(id) ON DELETE CASCADE,
+                                  active BOOLEAN DEFAULT TRUE,
+                                  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+                                  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE enrollment_rule_audit (
+                                       id UUID PRIMARY KEY,
+                                       rule_id UUID REFERENCES enrollment_rules(id) ON DELETE CASCADE,
+                                       user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                                       enrollment_id UUID REFERENCES enrollments(id) ON DELETE SET NULL,
+                                       status VARCHAR(20) NOT NULL,
+                                       enrolled_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_enrollment_rule_audit_rule ON enrollment_rule_audit(rule_id);
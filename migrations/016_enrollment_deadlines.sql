@@ -0,0 +1,11 @@
+ALTER TABLE users
+    ADD COLUMN manager_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE enrollments
+    ADD COLUMN assigned_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN due_at TIMESTAMP,
+    ADD COLUMN grace_days INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN overdue_at TIMESTAMP,
+    ADD COLUMN escalation_level INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_enrollments_due ON enrollments(due_at) WHERE due_at IS NOT NULL;
@@ -0,0 +1,19 @@
+ALTER TABLE courses
+    ADD COLUMN recertification_months INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE certification_cycles (
+                                      id UUID PRIMARY KEY,
+                                      user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                      course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+                                      enrollment_id UUID NOT NULL REFERENCES enrollments(id) ON DELETE CASCADE,
+                                      cycle_number INTEGER NOT NULL,
+                                      completed_at TIMESTAMP NOT NULL,
+                                      expires_at TIMESTAMP NOT NULL,
+                                      renewal_started_at TIMESTAMP,
+                                      reminders_sent INTEGER NOT NULL DEFAULT 0,
+                                      renewed_at TIMESTAMP,
+                                      UNIQUE (user_id, course_id, cycle_number)
+);
+
+CREATE UNIQUE INDEX idx_certification_cycles_current ON certification_cycles(user_id, course_id) WHERE renewed_at IS NULL;
+CREATE INDEX idx_certification_cycles_expiry ON certification_cycles(expires_at) WHERE renewed_at IS NULL;
@@ -0,0 +1,27 @@
+CREATE TABLE certificate_templates (
+                                       id UUID PRIMARY KEY,
+                                       name VARCHAR(255) NOT NULL,
+                                       course_id UUID REFERENCES courses(id) ON DELETE CASCADE,
+                                       is_default BOOLEAN NOT NULL DEFAULT FALSE,
+                                       orientation VARCHAR(20) NOT NULL DEFAULT 'landscape',
+                                       title VARCHAR(255) NOT NULL,
+                                       body TEXT NOT NULL,
+                                       signatory_name VARCHAR(255) NOT NULL DEFAULT '',
+                                       logo_key TEXT,
+                                       signature_key TEXT,
+                                       created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_certificate_templates_default ON certificate_templates(is_default) WHERE is_default;
+
+ALTER TABLE certificates
+    ADD COLUMN template_id UUID REFERENCES certificate_templates(id) ON DELETE SET NULL,
+    ADD COLUMN credential_id VARCHAR(32),
+    ADD COLUMN score INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN file_key TEXT NOT NULL DEFAULT '';
+
+UPDATE certificates SET credential_id = 'TP-' || UPPER(SUBSTRING(REPLACE(id::text, '-', '') FROM 1 FOR 16)) WHERE credential_id IS NULL;
+
+ALTER TABLE certificates
+    ALTER COLUMN credential_id SET NOT NULL,
+    ADD CONSTRAINT certificates_credential_id_key UNIQUE (credential_id);
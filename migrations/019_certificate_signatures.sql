@@ -0,0 +1,2 @@
+ALTER TABLE certificates
+    ADD COLUMN signature TEXT;
@@ -0,0 +1,21 @@
+ALTER TABLE certificates
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active',
+    ADD COLUMN expires_at TIMESTAMP,
+    ADD COLUMN revoked_at TIMESTAMP,
+    ADD COLUMN revocation_reason TEXT,
+    ADD COLUMN replaces_id UUID REFERENCES certificates(id) ON DELETE SET NULL,
+    ADD COLUMN replaced_by_id UUID REFERENCES certificates(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_certificates_template_status ON certificates(template_id, status);
+
+CREATE TABLE certificate_history (
+                                     id UUID PRIMARY KEY,
+                                     certificate_id UUID REFERENCES certificates(id) ON DELETE CASCADE,
+                                     from_status VARCHAR(20),
+                                     to_status VARCHAR(20) NOT NULL,
+                                     changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+                                     reason TEXT,
+                                     changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_certificate_history_certificate ON certificate_history(certificate_id);
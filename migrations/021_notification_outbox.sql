@@ -0,0 +1,22 @@
+ALTER TABLE notifications
+    ADD COLUMN type VARCHAR(20) NOT NULL DEFAULT 'in_app',
+    ADD COLUMN title VARCHAR(255),
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'unread',
+    ADD COLUMN read_at TIMESTAMP;
+
+CREATE TABLE notification_deliveries (
+                                         id UUID PRIMARY KEY,
+                                         notification_id UUID REFERENCES notifications(id) ON DELETE CASCADE,
+                                         user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                                         channel VARCHAR(20) NOT NULL,
+                                         status VARCHAR(20) NOT NULL DEFAULT 'pending',
+                                         attempts INTEGER NOT NULL DEFAULT 0,
+                                         last_error TEXT,
+                                         next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                         sent_at TIMESTAMP,
+                                         created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notification_deliveries_due ON notification_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_notification_deliveries_notification ON notification_deliveries(notification_id);
+CREATE INDEX idx_notifications_user ON notifications(user_id, created_at DESC);
@@ -0,0 +1,32 @@
+ALTER TABLE notifications
+    ADD COLUMN event VARCHAR(40) NOT NULL DEFAULT 'general';
+
+ALTER TABLE notification_deliveries
+    ADD COLUMN digest VARCHAR(20) NOT NULL DEFAULT 'immediate';
+
+CREATE TABLE notification_templates (
+                                        id UUID PRIMARY KEY,
+                                        event VARCHAR(40) NOT NULL,
+                                        locale VARCHAR(35) NOT NULL,
+                                        subject TEXT NOT NULL,
+                                        body TEXT NOT NULL,
+                                        updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                        CONSTRAINT uq_notification_templates_event_locale UNIQUE (event, locale)
+);
+
+CREATE TABLE notification_settings (
+                                       user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                                       locale VARCHAR(35),
+                                       time_zone VARCHAR(64),
+                                       quiet_start INTEGER NOT NULL DEFAULT 0,
+                                       quiet_end INTEGER NOT NULL DEFAULT 0
+);
+
+-- channels is a comma-separated list; empty means the user opted out.
+CREATE TABLE notification_preferences (
+                                          user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                                          event VARCHAR(40) NOT NULL,
+                                          channels TEXT NOT NULL DEFAULT '',
+                                          digest VARCHAR(20) NOT NULL DEFAULT 'immediate',
+                                          PRIMARY KEY (user_id, event)
+);
@@ -0,0 +1,22 @@
+CREATE TABLE realtime_events (
+                                 id BIGSERIAL PRIMARY KEY,
+                                 user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                                 type VARCHAR(30) NOT NULL,
+                                 data JSONB NOT NULL,
+                                 created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_realtime_events_user ON realtime_events(user_id, id);
+CREATE INDEX idx_realtime_events_created ON realtime_events(created_at);
+
+-- Wake stream subscribers on every server instance. NOTIFY is delivered on commit.
+CREATE FUNCTION notify_realtime_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('realtime_events', NEW.user_id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER realtime_events_notify
+    AFTER INSERT ON realtime_events
+    FOR EACH ROW EXECUTE FUNCTION notify_realtime_event();
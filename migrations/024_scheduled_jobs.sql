@@ -0,0 +1,20 @@
+CREATE TABLE scheduled_jobs (
+                                name VARCHAR(100) PRIMARY KEY,
+                                interval_seconds BIGINT NOT NULL,
+                                paused BOOLEAN NOT NULL DEFAULT FALSE,
+                                next_run_at TIMESTAMP NOT NULL,
+                                last_run_at TIMESTAMP,
+                                last_duration_ms BIGINT NOT NULL DEFAULT 0,
+                                last_error TEXT,
+                                locked_by VARCHAR(255),
+                                locked_until TIMESTAMP,
+                                updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE enrollment_reminders (
+                                      enrollment_id UUID REFERENCES enrollments(id) ON DELETE CASCADE,
+                                      step VARCHAR(100) NOT NULL,
+                                      anchor_at TIMESTAMP NOT NULL,
+                                      sent_at TIMESTAMP NOT NULL,
+                                      PRIMARY KEY (enrollment_id, step, anchor_at)
+);
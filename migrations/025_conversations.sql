@@ -0,0 +1,57 @@
+CREATE TABLE conversations (
+                               id UUID PRIMARY KEY,
+                               title VARCHAR(255),
+                               is_group BOOLEAN NOT NULL DEFAULT FALSE,
+                               direct_key VARCHAR(80) UNIQUE, -- sorted "user:user" pair, NULL for groups
+                               created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+                               created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                               updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE conversation_participants (
+                                           conversation_id UUID REFERENCES conversations(id) ON DELETE CASCADE,
+                                           user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                                           joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                           last_read_seq BIGINT NOT NULL DEFAULT 0,
+                                           last_read_at TIMESTAMP,
+                                           PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX idx_conversation_participants_user ON conversation_participants(user_id);
+
+ALTER TABLE messages ADD COLUMN conversation_id UUID REFERENCES conversations(id) ON DELETE CASCADE;
+ALTER TABLE messages ADD COLUMN seq BIGSERIAL;
+
+-- Move existing direct messages into one conversation per pair of users.
+INSERT INTO conversations (id, is_group, direct_key, created_at, updated_at)
+SELECT md5(k.direct_key)::uuid, FALSE, k.direct_key, MIN(k.created_at), MAX(k.created_at)
+FROM (
+    SELECT LEAST(sender_id::text COLLATE "C", receiver_id::text COLLATE "C") || ':' ||
+           GREATEST(sender_id::text COLLATE "C", receiver_id::text COLLATE "C") AS direct_key,
+           created_at
+    FROM messages
+    WHERE sender_id IS NOT NULL AND receiver_id IS NOT NULL
+) k
+GROUP BY k.direct_key;
+
+UPDATE messages SET conversation_id = md5(
+    LEAST(sender_id::text COLLATE "C", receiver_id::text COLLATE "C") || ':' ||
+    GREATEST(sender_id::text COLLATE "C", receiver_id::text COLLATE "C")
+)::uuid
+WHERE sender_id IS NOT NULL AND receiver_id IS NOT NULL;
+
+INSERT INTO conversation_participants (conversation_id, user_id, joined_at, last_read_seq)
+SELECT m.conversation_id, u.user_id, MIN(m.created_at),
+       COALESCE(MAX(m.seq) FILTER (WHERE m.sender_id = u.user_id OR m.read), 0)
+FROM messages m
+CROSS JOIN LATERAL (VALUES (m.sender_id), (m.receiver_id)) AS u(user_id)
+WHERE m.conversation_id IS NOT NULL
+GROUP BY m.conversation_id, u.user_id;
+
+DELETE FROM messages WHERE conversation_id IS NULL;
+ALTER TABLE messages ALTER COLUMN conversation_id SET NOT NULL;
+ALTER TABLE messages DROP COLUMN receiver_id;
+ALTER TABLE messages DROP COLUMN read;
+
+CREATE UNIQUE INDEX idx_messages_seq ON messages(seq);
+CREATE INDEX idx_messages_conversation ON messages(conversation_id, seq DESC);
@@ -0,0 +1,12 @@
+CREATE TABLE message_attachments (
+                                     id UUID PRIMARY KEY,
+                                     message_id UUID REFERENCES messages(id) ON DELETE CASCADE,
+                                     conversation_id UUID REFERENCES conversations(id) ON DELETE CASCADE,
+                                     file_name VARCHAR(255) NOT NULL,
+                                     content_type VARCHAR(255) NOT NULL,
+                                     size BIGINT NOT NULL,
+                                     storage_key VARCHAR(512) NOT NULL,
+                                     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_message_attachments_message ON message_attachments(message_id);
@@ -0,0 +1,48 @@
+ALTER TABLE forums ADD COLUMN course_id UUID REFERENCES courses(id) ON DELETE CASCADE;
+ALTER TABLE forums ADD COLUMN module_id UUID REFERENCES modules(id) ON DELETE CASCADE;
+
+-- One forum per course and one per module.
+CREATE UNIQUE INDEX idx_forums_course ON forums(course_id) WHERE course_id IS NOT NULL AND module_id IS NULL;
+CREATE UNIQUE INDEX idx_forums_module ON forums(module_id) WHERE module_id IS NOT NULL;
+
+INSERT INTO forums (id, title, course_id, created_at)
+SELECT md5('course:' || c.id::text)::uuid, COALESCE(c.title, 'Course forum'), c.id, CURRENT_TIMESTAMP
+FROM courses c;
+
+INSERT INTO forums (id, title, course_id, module_id, created_at)
+SELECT md5('module:' || m.id::text)::uuid, COALESCE(m.title, 'Module forum'), m.course_id, m.id, CURRENT_TIMESTAMP
+FROM modules m
+WHERE m.course_id IS NOT NULL;
+
+ALTER TABLE posts ADD COLUMN title VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE posts ADD COLUMN edited_at TIMESTAMP;
+ALTER TABLE posts ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE posts ADD COLUMN last_activity_at TIMESTAMP;
+
+UPDATE posts p SET last_activity_at = GREATEST(
+    COALESCE(p.created_at, CURRENT_TIMESTAMP),
+    COALESCE((SELECT MAX(r.created_at) FROM replies r WHERE r.post_id = p.id), p.created_at, CURRENT_TIMESTAMP)
+);
+ALTER TABLE posts ALTER COLUMN last_activity_at SET NOT NULL;
+ALTER TABLE posts ALTER COLUMN last_activity_at SET DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE replies ADD COLUMN edited_at TIMESTAMP;
+ALTER TABLE replies ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_posts_forum_activity ON posts(forum_id, last_activity_at DESC) WHERE deleted_at IS NULL;
+
+-- Previous versions of edited and deleted posts and replies.
+CREATE TABLE forum_revisions (
+                                 id UUID PRIMARY KEY,
+                                 post_id UUID REFERENCES posts(id) ON DELETE CASCADE,
+                                 reply_id UUID REFERENCES replies(id) ON DELETE CASCADE,
+                                 title VARCHAR(255) NOT NULL DEFAULT '',
+                                 content TEXT NOT NULL,
+                                 action VARCHAR(20) NOT NULL,
+                                 edited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+                                 edited_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                 CHECK ((post_id IS NULL) <> (reply_id IS NULL))
+);
+
+CREATE INDEX idx_forum_revisions_post ON forum_revisions(post_id);
+CREATE INDEX idx_forum_revisions_reply ON forum_revisions(reply_id);
@@ -0,0 +1,24 @@
+ALTER TABLE posts ADD COLUMN type VARCHAR(20) NOT NULL DEFAULT 'discussion';
+ALTER TABLE posts ADD COLUMN score INT NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN accepted_reply_id UUID REFERENCES replies(id) ON DELETE SET NULL;
+ALTER TABLE replies ADD COLUMN score INT NOT NULL DEFAULT 0;
+
+CREATE TABLE post_votes (
+                            post_id UUID REFERENCES posts(id) ON DELETE CASCADE,
+                            user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                            value SMALLINT NOT NULL CHECK (value IN (-1, 1)),
+                            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                            PRIMARY KEY (post_id, user_id)
+);
+
+CREATE TABLE reply_votes (
+                             reply_id UUID REFERENCES replies(id) ON DELETE CASCADE,
+                             user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                             value SMALLINT NOT NULL CHECK (value IN (-1, 1)),
+                             created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                             PRIMARY KEY (reply_id, user_id)
+);
+
+-- Trainers' unanswered queue.
+CREATE INDEX idx_posts_open_questions ON posts(forum_id, created_at)
+    WHERE type = 'question' AND accepted_reply_id IS NULL AND deleted_at IS NULL;
@@ -0,0 +1,52 @@
+ALTER TABLE posts ADD COLUMN state VARCHAR(20) NOT NULL DEFAULT 'visible';
+ALTER TABLE posts ADD COLUMN locked BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE posts ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE replies ADD COLUMN state VARCHAR(20) NOT NULL DEFAULT 'visible';
+
+CREATE INDEX idx_posts_held ON posts(forum_id) WHERE state = 'held' AND deleted_at IS NULL;
+CREATE INDEX idx_replies_held ON replies(post_id) WHERE state = 'held' AND deleted_at IS NULL;
+
+CREATE TABLE forum_reports (
+                               id UUID PRIMARY KEY,
+                               post_id UUID REFERENCES posts(id) ON DELETE CASCADE,
+                               reply_id UUID REFERENCES replies(id) ON DELETE CASCADE,
+                               reporter_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                               reason TEXT NOT NULL,
+                               status VARCHAR(20) NOT NULL DEFAULT 'open',
+                               created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                               resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+                               resolved_at TIMESTAMP,
+                               CHECK ((post_id IS NULL) <> (reply_id IS NULL))
+);
+
+-- One open report per user and post or reply.
+CREATE UNIQUE INDEX idx_forum_reports_open_post ON forum_reports(post_id, reporter_id) WHERE status = 'open' AND post_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_forum_reports_open_reply ON forum_reports(reply_id, reporter_id) WHERE status = 'open' AND reply_id IS NOT NULL;
+
+CREATE TABLE forum_suspensions (
+                                   id UUID PRIMARY KEY,
+                                   user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                                   course_id UUID REFERENCES courses(id) ON DELETE CASCADE,
+                                   reason TEXT NOT NULL,
+                                   created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+                                   created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                   ends_at TIMESTAMP NOT NULL,
+                                   lifted_at TIMESTAMP
+);
+
+CREATE INDEX idx_forum_suspensions_user ON forum_suspensions(user_id, course_id, ends_at);
+
+-- Who did what and why; entries outlive the content they refer to.
+CREATE TABLE forum_moderation_log (
+                                      id UUID PRIMARY KEY,
+                                      action VARCHAR(20) NOT NULL,
+                                      course_id UUID REFERENCES courses(id) ON DELETE CASCADE,
+                                      post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+                                      reply_id UUID REFERENCES replies(id) ON DELETE SET NULL,
+                                      user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+                                      moderator_id UUID REFERENCES users(id) ON DELETE SET NULL,
+                                      reason TEXT NOT NULL,
+                                      created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_forum_moderation_log_course ON forum_moderation_log(course_id, created_at DESC);
@@ -0,0 +1,18 @@
+CREATE TABLE forum_subscriptions (
+                                     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                     forum_id UUID REFERENCES forums(id) ON DELETE CASCADE,
+                                     post_id UUID REFERENCES posts(id) ON DELETE CASCADE,
+                                     digest VARCHAR(20) NOT NULL DEFAULT '',
+                                     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                     CHECK ((forum_id IS NULL) <> (post_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_forum_subscriptions_forum ON forum_subscriptions(forum_id, user_id) WHERE forum_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_forum_subscriptions_post ON forum_subscriptions(post_id, user_id) WHERE post_id IS NOT NULL;
+CREATE INDEX idx_forum_subscriptions_user ON forum_subscriptions(user_id);
+
+-- Authors follow their own threads.
+INSERT INTO forum_subscriptions (user_id, post_id, created_at)
+SELECT p.user_id, p.id, COALESCE(p.created_at, CURRENT_TIMESTAMP)
+FROM posts p
+WHERE p.user_id IS NOT NULL AND p.deleted_at IS NULL;
@@ -0,0 +1,54 @@
+-- Append-only store of what users did. course_id is copied out of the
+-- metadata for aggregation; seq orders events by when they were stored.
+CREATE TABLE analytics_events (
+                                  id UUID PRIMARY KEY,
+                                  seq BIGSERIAL NOT NULL UNIQUE,
+                                  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                  event_type VARCHAR(30) NOT NULL,
+                                  course_id UUID,
+                                  metadata JSONB NOT NULL DEFAULT '{}',
+                                  occurred_at TIMESTAMP NOT NULL,
+                                  received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_analytics_events_user ON analytics_events(user_id, occurred_at);
+CREATE INDEX idx_analytics_events_course ON analytics_events(course_id, user_id) WHERE course_id IS NOT NULL;
+CREATE INDEX idx_analytics_events_type ON analytics_events(event_type, occurred_at);
+
+CREATE FUNCTION reject_analytics_event_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'analytics_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER analytics_events_append_only
+    BEFORE UPDATE ON analytics_events
+    FOR EACH ROW EXECUTE FUNCTION reject_analytics_event_update();
+
+CREATE TABLE user_engagement (
+                                 user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                 course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+                                 modules_completed INTEGER NOT NULL DEFAULT 0,
+                                 total_modules INTEGER NOT NULL DEFAULT 0,
+                                 last_active TIMESTAMP,
+                                 updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                 PRIMARY KEY (user_id, course_id)
+);
+
+CREATE INDEX idx_user_engagement_course ON user_engagement(course_id);
+
+CREATE TABLE course_analytics (
+                                  course_id UUID PRIMARY KEY REFERENCES courses(id) ON DELETE CASCADE,
+                                  enrollments INTEGER NOT NULL DEFAULT 0,
+                                  completions INTEGER NOT NULL DEFAULT 0,
+                                  average_progress DOUBLE PRECISION NOT NULL DEFAULT 0,
+                                  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The last event seq the aggregation job has processed.
+CREATE TABLE analytics_aggregation (
+                                       id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+                                       last_seq BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO analytics_aggregation (id, last_seq) VALUES (TRUE, 0);
@@ -0,0 +1,29 @@
+-- Saved compliance reports. data is kept as text so that it stays
+-- byte-for-byte what was hashed.
+CREATE TABLE compliance_snapshots (
+                                      id UUID PRIMARY KEY,
+                                      kind VARCHAR(20) NOT NULL,
+                                      filter JSONB NOT NULL DEFAULT '{}',
+                                      note TEXT NOT NULL DEFAULT '',
+                                      data TEXT NOT NULL,
+                                      report_table TEXT NOT NULL,
+                                      hash CHAR(64) NOT NULL,
+                                      created_by UUID, -- no foreign key: removing a user must not change a snapshot
+                                      created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_compliance_snapshots_kind ON compliance_snapshots(kind, created_at);
+
+CREATE FUNCTION reject_compliance_snapshot_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'compliance snapshots cannot be changed or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER compliance_snapshots_immutable
+    BEFORE UPDATE OR DELETE ON compliance_snapshots
+    FOR EACH ROW EXECUTE FUNCTION reject_compliance_snapshot_change();
+
+CREATE TRIGGER compliance_snapshots_no_truncate
+    BEFORE TRUNCATE ON compliance_snapshots
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_compliance_snapshot_change();
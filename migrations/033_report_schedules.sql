@@ -0,0 +1,41 @@
+-- Saved report definitions that are emailed on a cron schedule, and the
+-- history of their runs.
+CREATE TABLE report_schedules (
+                                  id UUID PRIMARY KEY,
+                                  name VARCHAR(255) NOT NULL,
+                                  kind VARCHAR(20) NOT NULL,
+                                  filter JSONB NOT NULL DEFAULT '{}',
+                                  format VARCHAR(10) NOT NULL,
+                                  cron VARCHAR(100) NOT NULL,
+                                  timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+                                  delivery VARCHAR(20) NOT NULL,
+                                  recipients UUID[] NOT NULL DEFAULT '{}',
+                                  owner_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                                  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+                                  next_run_at TIMESTAMP,
+                                  last_run_at TIMESTAMP,
+                                  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_report_schedules_due ON report_schedules(next_run_at) WHERE enabled;
+CREATE INDEX idx_report_schedules_owner ON report_schedules(owner_id);
+
+-- Runs outlive their schedule so failures stay visible after it is deleted.
+CREATE TABLE report_runs (
+                             id UUID PRIMARY KEY,
+                             schedule_id UUID NOT NULL,
+                             schedule_name VARCHAR(255) NOT NULL,
+                             status VARCHAR(20) NOT NULL,
+                             error TEXT NOT NULL DEFAULT '',
+                             recipients INTEGER NOT NULL DEFAULT 0,
+                             file_name VARCHAR(255) NOT NULL DEFAULT '',
+                             file_key TEXT NOT NULL DEFAULT '',
+                             expires_at TIMESTAMP,
+                             started_at TIMESTAMP NOT NULL,
+                             finished_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_report_runs_schedule ON report_runs(schedule_id, started_at);
+CREATE INDEX idx_report_runs_status ON report_runs(status, started_at);
+CREATE INDEX idx_report_runs_files ON report_runs(expires_at) WHERE file_key <> '';
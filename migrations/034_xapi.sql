@@ -0,0 +1,39 @@
+-- Statements and State and Activity Profile documents of the built-in xAPI
+-- Learning Record Store.
+CREATE TABLE xapi_statements (
+                                 id UUID PRIMARY KEY,
+                                 seq BIGSERIAL NOT NULL UNIQUE, -- storage order, used as the query cursor
+                                 statement JSONB NOT NULL,
+                                 verb_id TEXT NOT NULL,
+                                 agents TEXT[] NOT NULL, -- IFIs of the actor and of an agent object
+                                 related_agents TEXT[] NOT NULL,
+                                 activity_id TEXT NOT NULL DEFAULT '', -- empty unless the object is an activity
+                                 related_activities TEXT[] NOT NULL,
+                                 registration UUID,
+                                 voids_id UUID, -- set on voiding statements
+                                 voided BOOLEAN NOT NULL DEFAULT FALSE,
+                                 user_id UUID REFERENCES users(id) ON DELETE SET NULL, -- the portal user the actor is
+                                 stored TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_xapi_statements_agents ON xapi_statements USING GIN (agents);
+CREATE INDEX idx_xapi_statements_related_agents ON xapi_statements USING GIN (related_agents);
+CREATE INDEX idx_xapi_statements_related_activities ON xapi_statements USING GIN (related_activities);
+CREATE INDEX idx_xapi_statements_verb ON xapi_statements(verb_id);
+CREATE INDEX idx_xapi_statements_activity ON xapi_statements(activity_id);
+CREATE INDEX idx_xapi_statements_registration ON xapi_statements(registration);
+CREATE INDEX idx_xapi_statements_voids ON xapi_statements(voids_id);
+CREATE INDEX idx_xapi_statements_user ON xapi_statements(user_id, seq);
+
+CREATE TABLE xapi_documents (
+                                kind VARCHAR(20) NOT NULL CHECK (kind IN ('state', 'activity_profile')),
+                                activity_id TEXT NOT NULL,
+                                agent TEXT NOT NULL DEFAULT '', -- IFI; empty for activity profiles
+                                registration TEXT NOT NULL DEFAULT '',
+                                doc_id TEXT NOT NULL,
+                                content BYTEA NOT NULL,
+                                content_type VARCHAR(255) NOT NULL,
+                                etag VARCHAR(40) NOT NULL,
+                                updated_at TIMESTAMP NOT NULL,
+                                PRIMARY KEY (kind, activity_id, agent, registration, doc_id)
+);
@@ -0,0 +1,56 @@
+-- Imported SCORM packages, the SCOs they were turned into modules for, and
+-- learners' runtime data per SCO.
+CREATE TABLE scorm_packages (
+                                id UUID PRIMARY KEY,
+                                course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+                                title VARCHAR(255) NOT NULL,
+                                identifier VARCHAR(255) NOT NULL DEFAULT '', -- from imsmanifest.xml
+                                version VARCHAR(10) NOT NULL CHECK (version IN ('1.2', '2004')),
+                                content_prefix VARCHAR(255) NOT NULL, -- storage key prefix of the extracted files
+                                files TEXT[] NOT NULL,
+                                uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+                                created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_scorm_packages_course ON scorm_packages(course_id);
+
+CREATE TABLE scorm_scos (
+                            id UUID PRIMARY KEY,
+                            package_id UUID NOT NULL REFERENCES scorm_packages(id) ON DELETE CASCADE,
+                            course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+                            module_id UUID NOT NULL REFERENCES modules(id) ON DELETE CASCADE,
+                            identifier VARCHAR(255) NOT NULL,
+                            title VARCHAR(255) NOT NULL,
+                            launch_path TEXT NOT NULL,
+                            asset BOOLEAN NOT NULL DEFAULT FALSE,
+                            passing_score DOUBLE PRECISION NOT NULL DEFAULT 0, -- scaled; 0 when not set
+                            completion_threshold DOUBLE PRECISION NOT NULL DEFAULT 0,
+                            launch_data TEXT NOT NULL DEFAULT '',
+                            order_index INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_scorm_scos_package ON scorm_scos(package_id, order_index);
+
+CREATE TABLE scorm_attempts (
+                                user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                sco_id UUID NOT NULL REFERENCES scorm_scos(id) ON DELETE CASCADE,
+                                version VARCHAR(10) NOT NULL,
+                                lesson_status VARCHAR(20) NOT NULL DEFAULT '',
+                                completion_status VARCHAR(20) NOT NULL DEFAULT '',
+                                success_status VARCHAR(20) NOT NULL DEFAULT '',
+                                score_raw VARCHAR(40) NOT NULL DEFAULT '',
+                                score_min VARCHAR(40) NOT NULL DEFAULT '',
+                                score_max VARCHAR(40) NOT NULL DEFAULT '',
+                                score_scaled VARCHAR(40) NOT NULL DEFAULT '',
+                                progress_measure VARCHAR(40) NOT NULL DEFAULT '',
+                                location VARCHAR(1000) NOT NULL DEFAULT '',
+                                suspend_data TEXT NOT NULL DEFAULT '',
+                                entry VARCHAR(20) NOT NULL DEFAULT '',
+                                exit_reason VARCHAR(20) NOT NULL DEFAULT '',
+                                session_time VARCHAR(40) NOT NULL DEFAULT '',
+                                total_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+                                sessions INT NOT NULL DEFAULT 0,
+                                extra JSONB NOT NULL DEFAULT '{}', -- interactions, objectives, comments and preferences
+                                updated_at TIMESTAMP NOT NULL,
+                                PRIMARY KEY (user_id, sco_id)
+);
@@ -0,0 +1,4 @@
+-- HR users already notified about an overdue enrollment, so a partly failed
+-- HR escalation only retries the recipients that were missed.
+ALTER TABLE enrollments
+    ADD COLUMN notified_hr TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,6 @@
+-- A SCO's passing score may be 0 or negative, so one the manifest does not set
+-- is stored as NULL rather than 0.
+ALTER TABLE scorm_scos
+    ALTER COLUMN passing_score DROP NOT NULL,
+    ALTER COLUMN passing_score DROP DEFAULT;
+UPDATE scorm_scos SET passing_score = NULL WHERE passing_score = 0;
@@ -43,10 +43,19 @@ VALUES
     ('ffffffff-ffff-ffff-ffff-ffffffffffff', '44444444-4444-4444-4444-444444444444', 'bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb');
 
 -- MESSAGES
-INSERT INTO messages (id, sender_id, receiver_id, content)
+INSERT INTO conversations (id, is_group, direct_key, created_by)
 VALUES
-    ('m1111111-aaaa-1111-aaaa-111111111111', '33333333-3333-3333-3333-333333333333', '22222222-2222-2222-2222-222222222222', 'Hello teacher!'),
-    ('m2222222-bbbb-2222-bbbb-222222222222', '22222222-2222-2222-2222-222222222222', '33333333-3333-3333-3333-333333333333', 'Hello student!');
+    ('c1111111-aaaa-1111-aaaa-111111111111', FALSE, '22222222-2222-2222-2222-222222222222:33333333-3333-3333-3333-333333333333', '33333333-3333-3333-3333-333333333333');
+
+INSERT INTO conversation_participants (conversation_id, user_id)
+VALUES
+    ('c1111111-aaaa-1111-aaaa-111111111111', '22222222-2222-2222-2222-222222222222'),
+    ('c1111111-aaaa-1111-aaaa-111111111111', '33333333-3333-3333-3333-333333333333');
+
+INSERT INTO messages (id, conversation_id, sender_id, content)
+VALUES
+    ('m1111111-aaaa-1111-aaaa-111111111111', 'c1111111-aaaa-1111-aaaa-111111111111', '33333333-3333-3333-3333-333333333333', 'Hello teacher!'),
+    ('m2222222-bbbb-2222-bbbb-222222222222', 'c1111111-aaaa-1111-aaaa-111111111111', '22222222-2222-2222-2222-222222222222', 'Hello student!');
 
 -- NOTIFICATIONS
 INSERT INTO notifications (id, user_id, message, read)
@@ -55,15 +64,15 @@ VALUES
     ('n2222222-bbbb-2222-bbbb-222222222222', '44444444-4444-4444-4444-444444444444', 'Your course starts tomorrow', FALSE);
 
 -- FORUMS
-INSERT INTO forums (id, title, description, created_by)
+INSERT INTO forums (id, title, course_id, created_by)
 VALUES
-    ('f1111111-aaaa-1111-aaaa-111111111111', 'Go Basics Discussion', 'Discuss Go basics here', '22222222-2222-2222-2222-222222222222');
+    ('f1111111-aaaa-1111-aaaa-111111111111', 'Go Basics Discussion', 'aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa', '22222222-2222-2222-2222-222222222222');
 
 -- POSTS
-INSERT INTO posts (id, forum_id, user_id, content)
+INSERT INTO posts (id, forum_id, user_id, title, content)
 VALUES
-    ('p1111111-aaaa-1111-aaaa-111111111111', 'f1111111-aaaa-1111-aaaa-111111111111', '33333333-3333-3333-3333-333333333333', 'I have a question about loops'),
-    ('p2222222-bbbb-2222-bbbb-222222222222', 'f1111111-aaaa-1111-aaaa-111111111111', '44444444-4444-4444-4444-444444444444', 'What about arrays?');
+    ('p1111111-aaaa-1111-aaaa-111111111111', 'f1111111-aaaa-1111-aaaa-111111111111', '33333333-3333-3333-3333-333333333333', 'Loops', 'I have a question about loops'),
+    ('p2222222-bbbb-2222-bbbb-222222222222', 'f1111111-aaaa-1111-aaaa-111111111111', '44444444-4444-4444-4444-444444444444', 'Arrays', 'What about arrays?');
 
 -- REPLIES
 INSERT INTO replies (id, post_id, user_id, content)
@@ -83,6 +92,6 @@ VALUES
     ('c1111111-aaaa-1111-aaaa-111111111111', '33333333-3333-3333-3333-333333333333', 'aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa');
 
 -- ANALYTICS
-INSERT INTO analytics (id, event_type, user_id, course_id, metadata)
+INSERT INTO analytics_events (id, event_type, user_id, course_id, metadata, occurred_at)
 VALUES
-    ('a1111111-aaaa-1111-aaaa-111111111111', 'course_view', '33333333-3333-3333-3333-333333333333', 'aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa', '{"page":"overview"}');
+    ('a1111111-aaaa-1111-aaaa-111111111111', 'course_viewed', '33333333-3333-3333-3333-333333333333', 'aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa', '{"course_id":"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa","page":"overview"}', CURRENT_TIMESTAMP);